@@ -64,6 +64,8 @@ export interface CodegenConfig {
   hasDelete: boolean;     // 是否有删除
   hasDetail: boolean;     // 是否有详情
   hasPagination: boolean; // 是否分页
+  hasTrash?: boolean;     // 是否生成回收站
+  hasCache?: boolean;     // 详情接口是否使用查询缓存
   fields: FieldInfo[];    // 字段列表
 }
 
@@ -1,11 +1,18 @@
 package admin
 
 import (
+	"context"
+
 	"github.com/gin-gonic/gin"
+	_ "github.com/zhoudm1743/go-web/apps/admin/migrations" // 注册管理后台迁移
 	"github.com/zhoudm1743/go-web/apps/admin/models"
 	"github.com/zhoudm1743/go-web/apps/admin/routes"
+	_ "github.com/zhoudm1743/go-web/apps/admin/seeders" // 注册管理后台数据填充
 	"github.com/zhoudm1743/go-web/core/app"
 	"github.com/zhoudm1743/go-web/core/facades"
+	"github.com/zhoudm1743/go-web/core/migration"
+	"github.com/zhoudm1743/go-web/core/seeder"
+	"github.com/zhoudm1743/go-web/core/tenant"
 	"github.com/zhoudm1743/go-web/core/utils"
 )
 
@@ -26,27 +33,44 @@ func (a *App) Name() string {
 
 // Initialize 初始化应用
 func (a *App) Initialize() error {
-	// 自动迁移数据库表结构
 	db := facades.DB()
 	if db == nil {
 		return nil
 	}
 
-	// 自动迁移模型到数据库表
-	err := db.AutoMigrate(
-		&models.Admin{},
-		&models.Role{},
-		&models.Menu{},
-		&models.RoleMenu{},
-		&models.AdminRole{},
-	)
-	if err != nil {
-		return err
+	// 执行未完成的数据库迁移
+	if config := facades.Config(); config == nil || config.Database.AutoMigrate {
+		var opts []migration.Option
+		if logger := facades.Log(); logger != nil {
+			opts = append(opts, migration.WithLogger(logger.Infof))
+		}
+		if _, err := migration.NewMigrator(db, opts...).Up(context.Background(), 0); err != nil {
+			return err
+		}
 	}
 
-	// 初始化默认管理员账号
-	if err := models.CreateDefaultAdminIfNotExists(db); err != nil {
-		return err
+	// 表结构尚未迁移时（例如CLI模式）跳过数据初始化
+	if !db.Migrator().HasTable(&models.Admin{}) {
+		return nil
+	}
+
+	// 执行数据填充（角色、菜单、默认管理员等）
+	if config := facades.Config(); config == nil || config.Database.AutoSeed {
+		opts := seeder.Options{}
+		if config != nil {
+			opts.Env = config.App.Mode
+		}
+		if logger := facades.Log(); logger != nil {
+			opts.Logf = logger.Infof
+		}
+		// 启动时填充超级租户的数据，其他租户通过 tenant:create 命令填充
+		ctx, err := tenant.ContextFor(context.Background(), db, tenant.SuperCode())
+		if err != nil {
+			return err
+		}
+		if err := seeder.Run(ctx, db, opts); err != nil {
+			return err
+		}
 	}
 
 	// 初始化Casbin表和权限
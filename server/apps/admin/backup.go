@@ -0,0 +1,24 @@
+package admin
+
+import (
+	gormadapter "github.com/casbin/gorm-adapter/v3"
+	"github.com/zhoudm1743/go-web/apps/admin/models"
+	"github.com/zhoudm1743/go-web/core/backup"
+	"github.com/zhoudm1743/go-web/core/tenant"
+	"github.com/zhoudm1743/go-web/pkg/generator"
+)
+
+func init() {
+	// 注册参与逻辑备份的模型，被引用的表在前；schema_migrations 不参与备份，由目标库执行迁移生成
+	backup.Register(
+		&tenant.Tenant{},
+		&models.Role{},
+		&models.Admin{},
+		&models.Menu{},
+		&models.RoleMenu{},
+		&models.AdminRole{},
+		&gormadapter.CasbinRule{},
+		&models.CacheAudit{},
+		&generator.HistoryModel{},
+	)
+}
@@ -1,13 +1,18 @@
 package controllers
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/zhoudm1743/go-web/apps/admin/dto"
 	"github.com/zhoudm1743/go-web/apps/admin/models"
+	"github.com/zhoudm1743/go-web/core/database"
 	"github.com/zhoudm1743/go-web/core/facades"
+	"github.com/zhoudm1743/go-web/core/repository"
 	"github.com/zhoudm1743/go-web/core/response"
+	"github.com/zhoudm1743/go-web/core/trash"
+	"gorm.io/gorm"
 )
 
 // AdminController 管理员控制器
@@ -18,17 +23,29 @@ func NewAdminController() *AdminController {
 	return &AdminController{}
 }
 
+// adminQueryFields 管理员列表允许查询的字段
+var adminQueryFields = repository.Fields{
+	Filter:      repository.Columns("id", "username", "nickname", "real_name", "email", "mobile", "status", "role_id", "created_at"),
+	Sort:        repository.Columns("id", "username", "status", "role_id", "created_at", "last_login_at"),
+	Search:      []string{"username", "nickname", "real_name", "mobile", "email"},
+	DefaultSort: "-id",
+}
+
 // GetAdmins 获取管理员列表
 func (c *AdminController) GetAdmins(ctx *gin.Context) {
-	var admins []models.Admin
-	db := facades.DB()
+	query, err := repository.ParseQuery(ctx, adminQueryFields)
+	if err != nil {
+		response.FailWithMsg(ctx, response.ParamsValidError, err.Error())
+		return
+	}
 
-	if err := db.Find(&admins).Error; err != nil {
+	page, err := repository.New[models.Admin](facades.DBFrom(ctx)).Paginate(query)
+	if err != nil {
 		response.Fail(ctx, response.SystemError)
 		return
 	}
 
-	response.OkWithData(ctx, admins)
+	response.OkWithData(ctx, page)
 }
 
 // CreateAdmin 创建管理员
@@ -41,7 +58,7 @@ func (c *AdminController) CreateAdmin(ctx *gin.Context) {
 
 	// 检查管理员名是否已存在
 	var count int64
-	db := facades.DB()
+	db := facades.DBFrom(ctx)
 	if err := db.Model(&models.Admin{}).Where("username = ?", req.Username).Count(&count).Error; err != nil {
 		response.Fail(ctx, response.SystemError)
 		return
@@ -52,6 +69,11 @@ func (c *AdminController) CreateAdmin(ctx *gin.Context) {
 		return
 	}
 
+	if req.RoleID > 0 && !roleExists(db, req.RoleID) {
+		response.FailWithMsg(ctx, response.Failed, "角色不存在")
+		return
+	}
+
 	// 加密密码
 	hashedPassword, err := models.HashPassword(req.Password)
 	if err != nil {
@@ -85,7 +107,7 @@ func (c *AdminController) UpdateAdmin(ctx *gin.Context) {
 		return
 	}
 
-	db := facades.DB()
+	db := facades.DBFrom(ctx)
 	var admin models.Admin
 	if err := db.First(&admin, req.ID).Error; err != nil {
 		response.FailWithMsg(ctx, response.Failed, "管理员不存在")
@@ -106,6 +128,14 @@ func (c *AdminController) UpdateAdmin(ctx *gin.Context) {
 		}
 	}
 
+	if req.RoleID > 0 && !roleExists(db, req.RoleID) {
+		response.FailWithMsg(ctx, response.Failed, "角色不存在")
+		return
+	}
+
+	// 按读取时的版本号更新，期间被他人修改时返回冲突
+	admin.Version = response.ExpectVersion(ctx, req.Version, admin.Version)
+
 	// 只更新提供的字段
 	updates := map[string]interface{}{}
 
@@ -136,10 +166,15 @@ func (c *AdminController) UpdateAdmin(ctx *gin.Context) {
 	}
 
 	if err := db.Model(&admin).Updates(updates).Error; err != nil {
+		if errors.Is(err, database.ErrVersionConflict) {
+			response.Fail(ctx, response.VersionConflict)
+			return
+		}
 		response.Fail(ctx, response.SystemError)
 		return
 	}
 
+	response.SetETag(ctx, admin.Version)
 	response.OkWithMsg(ctx, "更新成功")
 }
 
@@ -152,7 +187,7 @@ func (c *AdminController) DeleteAdmin(ctx *gin.Context) {
 		return
 	}
 
-	db := facades.DB()
+	db := facades.DBFrom(ctx)
 	if err := db.Delete(&models.Admin{}, AdminID).Error; err != nil {
 		response.Fail(ctx, response.SystemError)
 		return
@@ -160,3 +195,39 @@ func (c *AdminController) DeleteAdmin(ctx *gin.Context) {
 
 	response.OkWithMsg(ctx, "删除成功")
 }
+
+// adminTrash 管理员回收站
+var adminTrash = &trash.Handler[models.Admin]{
+	Name:   "管理员",
+	Fields: adminQueryFields,
+	Restoring: func(db *gorm.DB, admin *models.Admin) error {
+		if admin.RoleID != 0 && !roleExists(db, admin.RoleID) {
+			return errors.New("管理员的角色已删除，请先恢复角色")
+		}
+		return nil
+	},
+}
+
+// GetTrashedAdmins 获取已删除的管理员列表
+func (c *AdminController) GetTrashedAdmins(ctx *gin.Context) {
+	adminTrash.List(ctx)
+}
+
+// RestoreAdmin 恢复已删除的管理员
+func (c *AdminController) RestoreAdmin(ctx *gin.Context) {
+	adminTrash.Restore(ctx)
+}
+
+// ForceDeleteAdmin 永久删除回收站中的管理员
+func (c *AdminController) ForceDeleteAdmin(ctx *gin.Context) {
+	adminTrash.ForceDelete(ctx)
+}
+
+// roleExists 判断角色是否存在于当前租户
+func roleExists(db *gorm.DB, id uint) bool {
+	var count int64
+	if err := db.Model(&models.Role{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return false
+	}
+	return count > 0
+}
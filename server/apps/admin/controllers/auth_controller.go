@@ -28,7 +28,7 @@ func (c *AuthController) Login(ctx *gin.Context) {
 		return
 	}
 
-	admin, token, err := c.AuthService.Login(req.Username, req.Password)
+	admin, token, err := c.AuthService.Login(ctx.Request.Context(), req.Username, req.Password)
 	if err != nil {
 		response.FailWithMsg(ctx, response.LoginAccountError, err.Error())
 		return
@@ -58,7 +58,7 @@ func (c *AuthController) GetUserInfo(ctx *gin.Context) {
 		return
 	}
 
-	admin, err := c.AuthService.GetUserInfo(int(claims.UserID))
+	admin, err := c.AuthService.GetUserInfo(ctx.Request.Context(), int(claims.UserID))
 	if err != nil {
 		response.Fail(ctx, response.SystemError)
 		return
@@ -99,7 +99,7 @@ func (c *AuthController) GetUserRoutes(ctx *gin.Context) {
 		return
 	}
 
-	menus, err := c.AuthService.GetUserMenus(int(claims.UserID))
+	menus, err := c.AuthService.GetUserMenus(ctx.Request.Context(), int(claims.UserID))
 	if err != nil {
 		response.Fail(ctx, response.SystemError)
 		return
@@ -110,7 +110,7 @@ func (c *AuthController) GetUserRoutes(ctx *gin.Context) {
 
 // GetAllRoutes 获取所有路由菜单（管理菜单页面使用）
 func (c *AuthController) GetAllRoutes(ctx *gin.Context) {
-	menus, err := c.AuthService.GetAllMenus()
+	menus, err := c.AuthService.GetAllMenus(ctx.Request.Context())
 	if err != nil {
 		response.Fail(ctx, response.SystemError)
 		return
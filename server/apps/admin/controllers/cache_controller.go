@@ -0,0 +1,202 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhoudm1743/go-web/apps/admin/dto"
+	"github.com/zhoudm1743/go-web/apps/admin/models"
+	"github.com/zhoudm1743/go-web/core/cache"
+	"github.com/zhoudm1743/go-web/core/facades"
+	"github.com/zhoudm1743/go-web/core/repository"
+	"github.com/zhoudm1743/go-web/core/response"
+	"github.com/zhoudm1743/go-web/core/utils"
+)
+
+// 缓存操作记录的操作类型
+const (
+	cacheActionDelete         = "delete"
+	cacheActionDeletePattern  = "delete_pattern"
+	cacheActionFlushNamespace = "flush_namespace"
+)
+
+// cacheAuditQueryFields 缓存操作记录允许查询的字段
+var cacheAuditQueryFields = repository.Fields{
+	Filter:      repository.Columns("admin_id", "username", "action", "created_at"),
+	Sort:        repository.Columns("id", "created_at", "affected"),
+	Search:      []string{"target"},
+	DefaultSort: "-id",
+}
+
+// CacheController 缓存管理控制器
+//
+// 键名不含 cache.prefix，与业务代码中使用的键一致。删除的键无法随事务回滚，路由不使用事务中间件，
+// 删除中途失败时操作记录同样保留。
+type CacheController struct{}
+
+// NewCacheController 创建缓存管理控制器
+func NewCacheController() *CacheController {
+	return &CacheController{}
+}
+
+// GetStats 获取缓存的命中率、各命令的调用统计和键数量，cache.metrics 关闭时只返回键数量
+func (c *CacheController) GetStats(ctx *gin.Context) {
+	store := facades.Cache()
+	if ic, ok := store.(*cache.InstrumentedCache); ok {
+		stats, err := ic.Stats(ctx.Request.Context())
+		if err != nil {
+			response.FailWithMsg(ctx, response.SystemError, "读取缓存用量失败")
+			return
+		}
+		response.OkWithData(ctx, gin.H{"enabled": true, "buckets": cache.LatencyBuckets, "stats": stats})
+		return
+	}
+
+	usage, err := cache.UsageOf(ctx.Request.Context(), store)
+	if err != nil {
+		response.FailWithMsg(ctx, response.SystemError, "读取缓存用量失败")
+		return
+	}
+	response.OkWithData(ctx, gin.H{"enabled": false, "stats": cache.Stats{Usage: usage}})
+}
+
+// ResetStats 清空缓存调用统计
+func (c *CacheController) ResetStats(ctx *gin.Context) {
+	if ic, ok := facades.Cache().(*cache.InstrumentedCache); ok {
+		ic.Reset()
+	}
+	response.OkWithMsg(ctx, "已清空")
+}
+
+// GetKeys 按前缀分批列出键及其类型和剩余过期时间
+//
+// 支持 prefix、cursor（默认0，返回的 cursor 为 0 时遍历结束）和 count（默认100，最大1000）参数。
+func (c *CacheController) GetKeys(ctx *gin.Context) {
+	cursor, err := strconv.ParseUint(ctx.DefaultQuery("cursor", "0"), 10, 64)
+	if err != nil {
+		response.FailWithMsg(ctx, response.ParamsValidError, "cursor无效")
+		return
+	}
+	count, err := strconv.ParseInt(ctx.DefaultQuery("count", "100"), 10, 64)
+	if err != nil || count <= 0 || count > 1000 {
+		response.FailWithMsg(ctx, response.ParamsValidError, "count无效")
+		return
+	}
+
+	page, err := cache.ScanKeys(ctx.Request.Context(), facades.Cache(), ctx.Query("prefix"), cursor, count)
+	if err != nil {
+		response.FailWithMsg(ctx, response.SystemError, "遍历缓存键失败")
+		return
+	}
+	response.OkWithData(ctx, page)
+}
+
+// GetKey 查看键的值，集合类型最多返回 limit（默认100，最大1000）个元素
+func (c *CacheController) GetKey(ctx *gin.Context) {
+	key := ctx.Query("key")
+	if key == "" {
+		response.FailWithMsg(ctx, response.ParamsValidError, "key不能为空")
+		return
+	}
+	limit, err := strconv.ParseInt(ctx.DefaultQuery("limit", "100"), 10, 64)
+	if err != nil || limit <= 0 || limit > 1000 {
+		response.FailWithMsg(ctx, response.ParamsValidError, "limit无效")
+		return
+	}
+
+	value, err := cache.InspectKey(ctx.Request.Context(), facades.Cache(), key, limit)
+	if errors.Is(err, cache.ErrKeyNotFound) {
+		response.FailWithMsg(ctx, response.Failed, "键不存在")
+		return
+	}
+	if err != nil {
+		response.FailWithMsg(ctx, response.SystemError, "读取缓存键失败")
+		return
+	}
+	response.OkWithData(ctx, value)
+}
+
+// DeleteKeys 删除指定的键
+func (c *CacheController) DeleteKeys(ctx *gin.Context) {
+	var req dto.CacheDeleteKeysRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.FailWithMsg(ctx, response.ParamsValidError, "请求参数有误")
+		return
+	}
+
+	deleted, err := facades.Cache().DelCtx(ctx.Request.Context(), req.Keys...)
+	c.respond(ctx, cacheActionDelete, strings.Join(req.Keys, "\n"), deleted, err)
+}
+
+// DeletePattern 删除匹配通配符的键，通配符规则同 Redis 的 SCAN MATCH
+func (c *CacheController) DeletePattern(ctx *gin.Context) {
+	var req dto.CacheDeletePatternRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.FailWithMsg(ctx, response.ParamsValidError, "请求参数有误")
+		return
+	}
+
+	c.flush(ctx, cacheActionDeletePattern, req.Pattern, req.Pattern)
+}
+
+// FlushNamespace 清空命名空间，即删除以 namespace 开头的所有键
+func (c *CacheController) FlushNamespace(ctx *gin.Context) {
+	var req dto.CacheFlushNamespaceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.FailWithMsg(ctx, response.ParamsValidError, "请求参数有误")
+		return
+	}
+
+	c.flush(ctx, cacheActionFlushNamespace, req.Namespace, cache.EscapePattern(req.Namespace)+"*")
+}
+
+// GetAudits 获取缓存操作记录
+func (c *CacheController) GetAudits(ctx *gin.Context) {
+	query, err := repository.ParseQuery(ctx, cacheAuditQueryFields)
+	if err != nil {
+		response.FailWithMsg(ctx, response.ParamsValidError, err.Error())
+		return
+	}
+
+	page, err := repository.New[models.CacheAudit](facades.DBFrom(ctx)).Paginate(query)
+	if err != nil {
+		response.Fail(ctx, response.SystemError)
+		return
+	}
+	response.OkWithData(ctx, page)
+}
+
+// flush 按模式分批删除键
+func (c *CacheController) flush(ctx *gin.Context, action, target, pattern string) {
+	helper := cache.NewCacheHelper(facades.Cache(), facades.Log(), "")
+	deleted, err := helper.FlushByPatternCtx(ctx.Request.Context(), pattern)
+	c.respond(ctx, action, target, deleted, err)
+}
+
+// respond 记录破坏性操作并返回删除数量，删除中途失败时同样记录已删除的数量
+func (c *CacheController) respond(ctx *gin.Context, action, target string, deleted int64, err error) {
+	audit := &models.CacheAudit{
+		IP:       ctx.ClientIP(),
+		Action:   action,
+		Target:   target,
+		Affected: deleted,
+	}
+	if claims, claimsErr := utils.GetClaims(ctx); claimsErr == nil {
+		audit.AdminID, audit.Username = uint(claims.UserID), claims.Username
+	}
+	if err != nil {
+		audit.Error = err.Error()
+	}
+
+	if auditErr := facades.DBFrom(ctx).Create(audit).Error; auditErr != nil {
+		facades.Log().Errorf("记录缓存操作失败: %s %s: %v", action, target, auditErr)
+	}
+
+	if err != nil {
+		response.FailWithData(ctx, response.SystemError, gin.H{"deleted": deleted})
+		return
+	}
+	response.OkWithData(ctx, gin.H{"deleted": deleted})
+}
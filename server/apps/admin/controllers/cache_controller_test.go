@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/zhoudm1743/go-web/apps/admin/middlewares"
+	"github.com/zhoudm1743/go-web/apps/admin/models"
+	"github.com/zhoudm1743/go-web/apps/admin/seeders"
+	"github.com/zhoudm1743/go-web/core/cache"
+	"github.com/zhoudm1743/go-web/core/conf"
+	"github.com/zhoudm1743/go-web/core/database"
+	"github.com/zhoudm1743/go-web/core/facades"
+	"github.com/zhoudm1743/go-web/core/log"
+	"github.com/zhoudm1743/go-web/core/response"
+	"github.com/zhoudm1743/go-web/core/tenant"
+	"github.com/zhoudm1743/go-web/core/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupCacheTest 创建测试数据库、缓存和缓存管理路由，请求的租户由 X-Tenant 请求头指定
+func setupCacheTest(t *testing.T) (*gin.Engine, cache.Cache, *gorm.DB) {
+	gin.SetMode(gin.TestMode)
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "cache.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Admin{}, &models.Role{}, &models.CacheAudit{}); err != nil {
+		t.Fatalf("创建表失败: %v", err)
+	}
+
+	cfg := &conf.Config{Log: conf.LogConfig{Level: "error", OutputPath: "stdout"}}
+	l, err := log.NewLogger(log.LoggerParams{Config: cfg})
+	if err != nil {
+		t.Fatalf("创建日志失败: %v", err)
+	}
+	c, _ := cache.NewMemoryCache(cfg, l)
+	facades.SetConfig(cfg)
+	facades.SetLog(l)
+	facades.SetCache(c)
+	facades.SetDBManager(database.NewManagerWith(db))
+
+	controller := NewCacheController()
+	r := gin.New()
+	r.Use(func(ctx *gin.Context) {
+		current := &tenant.Tenant{ID: 1, Code: ctx.GetHeader("X-Tenant")}
+		ctx.Request = ctx.Request.WithContext(tenant.WithTenant(ctx.Request.Context(), current))
+	})
+	auth := []gin.HandlerFunc{middlewares.AdminAuth(), middlewares.SuperOnly("只有平台管理员可以管理缓存")}
+	r.GET("/admin/cache/stats", append(auth, controller.GetStats)...)
+	r.DELETE("/admin/cache/keys", append(auth, controller.DeleteKeys)...)
+	return r, c, db
+}
+
+// createAdmin 创建指定角色的管理员并返回令牌
+func createAdmin(t *testing.T, db *gorm.DB, username, roleCode string) string {
+	t.Helper()
+	role := models.Role{Name: roleCode, Code: roleCode}
+	if err := db.Create(&role).Error; err != nil {
+		t.Fatalf("创建角色失败: %v", err)
+	}
+	admin := models.Admin{Username: username, Password: "x", RoleID: role.ID}
+	if err := db.Create(&admin).Error; err != nil {
+		t.Fatalf("创建管理员失败: %v", err)
+	}
+	token, err := utils.GenerateToken(int(admin.ID), admin.Username, int(role.ID))
+	if err != nil {
+		t.Fatalf("生成令牌失败: %v", err)
+	}
+	return token
+}
+
+// TestCacheSuperOnly 测试只有超级租户中超级管理员角色的管理员可以管理缓存
+func TestCacheSuperOnly(t *testing.T) {
+	r, c, db := setupCacheTest(t)
+	ctx := context.Background()
+	c.SetCtx(ctx, "k", "v", 0)
+
+	super := createAdmin(t, db, "root", seeders.SuperRoleCode)
+	editor := createAdmin(t, db, "bob", "editor")
+
+	call := func(method, path, token, tenantCode string, body string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Tenant", tenantCode)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		var resp struct {
+			Code int `json:"code"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("解析响应失败: %v, %s", err, w.Body.String())
+		}
+		return resp.Code
+	}
+	denied := response.NoPermission.Code()
+
+	cases := []struct {
+		name   string
+		token  string
+		tenant string
+		code   int
+	}{
+		{"未登录", "", tenant.SuperCode(), response.TokenInvalid.Code()},
+		{"超级租户中的普通管理员", editor, tenant.SuperCode(), denied},
+		{"其他租户中的超级管理员", super, "acme", denied},
+		{"超级租户中的超级管理员", super, tenant.SuperCode(), response.Success.Code()},
+	}
+	for _, tc := range cases {
+		if code := call(http.MethodGet, "/admin/cache/stats", tc.token, tc.tenant, ""); code != tc.code {
+			t.Fatalf("%s 查看统计: 期望 %d, 实际 %d", tc.name, tc.code, code)
+		}
+	}
+
+	if code := call(http.MethodDelete, "/admin/cache/keys", editor, tenant.SuperCode(), `{"keys":["k"]}`); code != denied {
+		t.Fatalf("普通管理员删除键: 期望 %d, 实际 %d", denied, code)
+	}
+	if v, err := c.GetCtx(ctx, "k"); err != nil || v != "v" {
+		t.Fatalf("被拒绝的删除不应生效: %q, %v", v, err)
+	}
+	var audits int64
+	if db.Model(&models.CacheAudit{}).Count(&audits); audits != 0 {
+		t.Fatalf("被拒绝的删除不应记录操作: %d", audits)
+	}
+
+	if code := call(http.MethodDelete, "/admin/cache/keys", super, tenant.SuperCode(), `{"keys":["k"]}`); code != response.Success.Code() {
+		t.Fatalf("超级管理员删除键失败: %d", code)
+	}
+	if _, err := c.GetCtx(ctx, "k"); err != cache.ErrKeyNotFound {
+		t.Fatalf("键未删除: %v", err)
+	}
+}
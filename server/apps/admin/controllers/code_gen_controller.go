@@ -5,11 +5,15 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/zhoudm1743/go-web/core/database"
+	"github.com/zhoudm1743/go-web/core/dbschema"
 	"github.com/zhoudm1743/go-web/core/facades"
 	"github.com/zhoudm1743/go-web/core/response"
 	"github.com/zhoudm1743/go-web/pkg/generator"
+	"gorm.io/gorm"
 )
 
 // CodeGenController 代码生成器控制器
@@ -72,112 +76,52 @@ func (c *CodeGenController) GetApps(ctx *gin.Context) {
 	response.OkWithData(ctx, apps)
 }
 
-// GetTables 获取数据库表列表
-func (c *CodeGenController) GetTables(ctx *gin.Context) {
-	db := facades.DB()
-
-	// 检测数据库类型，针对不同类型的数据库使用不同的查询语句
-	dialect := db.Dialector.Name()
-	fmt.Printf("当前数据库类型: %s\n", dialect)
-
-	switch {
-	case dialect == "sqlite" || dialect == "sqlite3":
-		// 对于SQLite，直接查询表名，然后手动构建结果
-		var tableNames []string
-		query := `
-			SELECT 
-				name
-			FROM 
-				sqlite_master 
-			WHERE 
-				type = 'table' AND 
-				name NOT LIKE 'sqlite_%' AND
-				name NOT LIKE '%casbin%'
-			ORDER BY 
-				name
-		`
-
-		if err := db.Raw(query).Scan(&tableNames).Error; err != nil {
-			response.FailWithMsg(ctx, response.SystemError, fmt.Sprintf("获取表列表失败: %v", err))
-			return
-		}
-
-		// 手动构建结果数组
-		tables := make([]generator.TableInfo, 0, len(tableNames))
-		for _, name := range tableNames {
-			tables = append(tables, generator.TableInfo{
-				TableName:    name,
-				TableComment: "",
-			})
-		}
-
-		// 添加调试信息
-		fmt.Printf("数据库类型: %s, 查询到 %d 个表:\n", dialect, len(tables))
-		for i, table := range tables {
-			fmt.Printf("  %d. 表名: %s\n", i+1, table.TableName)
-		}
+// GetDatabases 获取可用的数据库连接
+func (c *CodeGenController) GetDatabases(ctx *gin.Context) {
+	names := []string{database.DefaultConnection}
+	if manager := facades.DBManager(); manager != nil {
+		names = manager.Names()
+	}
+	response.OkWithData(ctx, names)
+}
 
-		response.OkWithData(ctx, tables)
-
-	case dialect == "postgres" || dialect == "postgresql":
-		// PostgreSQL查询
-		var tables []generator.TableInfo
-		query := `
-			SELECT 
-				table_name AS "tableName",
-				obj_description((quote_ident(table_name)::text)::regclass, 'pg_class') AS "tableComment"
-			FROM 
-				information_schema.tables 
-			WHERE 
-				table_schema = 'public' AND 
-				table_type = 'BASE TABLE' AND
-				table_name NOT LIKE 'pg_%' AND
-				table_name NOT LIKE '%casbin%'
-			ORDER BY 
-				table_name
-		`
-
-		if err := db.Raw(query).Scan(&tables).Error; err != nil {
-			response.FailWithMsg(ctx, response.SystemError, fmt.Sprintf("获取表列表失败: %v", err))
-			return
-		}
+// businessDB 获取请求指定的业务数据库连接
+func (c *CodeGenController) businessDB(ctx *gin.Context) (*gorm.DB, bool) {
+	name := ctx.Query("db")
+	db := facades.DB(name)
+	if db == nil {
+		response.FailWithMsg(ctx, response.ParamsValidError, fmt.Sprintf("数据库连接 %s 未配置", name))
+		return nil, false
+	}
+	return db, true
+}
 
-		// 添加调试信息
-		fmt.Printf("数据库类型: %s, 查询到 %d 个表:\n", dialect, len(tables))
-		for i, table := range tables {
-			fmt.Printf("  %d. 表名: %s, 注释: %s\n", i+1, table.TableName, table.TableComment)
-		}
+// GetTables 获取数据库表列表
+func (c *CodeGenController) GetTables(ctx *gin.Context) {
+	db, ok := c.businessDB(ctx)
+	if !ok {
+		return
+	}
 
-		response.OkWithData(ctx, tables)
-
-	default: // MySQL和其他数据库
-		// MySQL查询
-		var tables []generator.TableInfo
-		query := `
-			SELECT 
-				table_name AS "tableName",
-				table_comment AS "tableComment" 
-			FROM 
-				information_schema.tables 
-			WHERE 
-				table_schema = DATABASE() 
-			ORDER BY 
-				table_name
-		`
-
-		if err := db.Raw(query).Scan(&tables).Error; err != nil {
-			response.FailWithMsg(ctx, response.SystemError, fmt.Sprintf("获取表列表失败: %v", err))
-			return
-		}
+	list, err := dbschema.Tables(db)
+	if err != nil {
+		response.FailWithMsg(ctx, response.SystemError, err.Error())
+		return
+	}
 
-		// 添加调试信息
-		fmt.Printf("数据库类型: %s, 查询到 %d 个表:\n", dialect, len(tables))
-		for i, table := range tables {
-			fmt.Printf("  %d. 表名: %s, 注释: %s\n", i+1, table.TableName, table.TableComment)
+	// 权限表由casbin维护，不用于生成代码
+	tables := make([]generator.TableInfo, 0, len(list))
+	for _, table := range list {
+		if strings.Contains(table.Name, "casbin") {
+			continue
 		}
-
-		response.OkWithData(ctx, tables)
+		tables = append(tables, generator.TableInfo{
+			TableName:    table.Name,
+			TableComment: table.Comment,
+		})
 	}
+
+	response.OkWithData(ctx, tables)
 }
 
 // GetColumns 获取表字段
@@ -188,149 +132,40 @@ func (c *CodeGenController) GetColumns(ctx *gin.Context) {
 		return
 	}
 
-	db := facades.DB()
-
-	// 检测数据库类型，针对不同类型的数据库使用不同的查询语句
-	dialect := db.Dialector.Name()
-	fmt.Printf("当前数据库类型: %s\n", dialect)
-
-	switch {
-	case dialect == "sqlite" || dialect == "sqlite3":
-		// SQLite查询 - 使用pragma_table_info获取表结构
-		type SqliteColumnInfo struct {
-			Cid       int         `gorm:"column:cid"`
-			Name      string      `gorm:"column:name"`
-			Type      string      `gorm:"column:type"`
-			NotNull   int         `gorm:"column:notnull"`
-			DfltValue interface{} `gorm:"column:dflt_value"`
-			Pk        int         `gorm:"column:pk"`
-		}
-
-		var sqliteColumns []SqliteColumnInfo
-		query := `
-			SELECT 
-				cid, name, type, "notnull", dflt_value, pk
-			FROM 
-				pragma_table_info(?)
-			ORDER BY 
-				cid
-		`
-
-		if err := db.Raw(query, tableName).Scan(&sqliteColumns).Error; err != nil {
-			response.FailWithMsg(ctx, response.SystemError, fmt.Sprintf("获取表字段失败: %v", err))
-			return
-		}
-
-		// 转换为通用列信息格式
-		columns := make([]generator.ColumnInfo, 0, len(sqliteColumns))
-		for _, col := range sqliteColumns {
-			isNullable := "YES"
-			if col.NotNull == 1 {
-				isNullable = "NO"
-			}
-
-			columnKey := ""
-			if col.Pk == 1 {
-				columnKey = "PRI"
-			}
-
-			columns = append(columns, generator.ColumnInfo{
-				ColumnName:    col.Name,
-				DataType:      col.Type,
-				ColumnComment: "", // SQLite不支持列注释
-				IsNullable:    isNullable,
-				ColumnKey:     columnKey,
-			})
-		}
-
-		// 添加调试信息
-		fmt.Printf("表 %s 的字段信息 (%d 个):\n", tableName, len(columns))
-		for i, col := range columns {
-			fmt.Printf("  %d. 字段名: %s, 类型: %s, 主键: %s, 可空: %s\n",
-				i+1, col.ColumnName, col.DataType, col.ColumnKey, col.IsNullable)
-		}
-
-		response.OkWithData(ctx, columns)
-
-	case dialect == "postgres" || dialect == "postgresql":
-		// PostgreSQL查询
-		var columns []generator.ColumnInfo
-		query := `
-			SELECT 
-				column_name AS "columnName",
-				data_type AS "dataType",
-				col_description(
-					(table_schema || '.' || table_name)::regclass::oid, 
-					ordinal_position
-				) AS "columnComment",
-				is_nullable AS "isNullable",
-				CASE 
-					WHEN EXISTS (
-						SELECT 1 FROM information_schema.table_constraints tc
-						JOIN information_schema.constraint_column_usage ccu 
-						ON tc.constraint_name = ccu.constraint_name AND tc.table_schema = ccu.table_schema
-						WHERE tc.constraint_type = 'PRIMARY KEY' 
-						AND tc.table_name = ? 
-						AND ccu.column_name = c.column_name
-					) THEN 'PRI'
-					ELSE ''
-				END AS "columnKey"
-			FROM 
-				information_schema.columns c
-			WHERE 
-				table_schema = 'public' 
-				AND table_name = ? 
-			ORDER BY 
-				ordinal_position
-		`
-
-		if err := db.Raw(query, tableName, tableName).Scan(&columns).Error; err != nil {
-			response.FailWithMsg(ctx, response.SystemError, fmt.Sprintf("获取表字段失败: %v", err))
-			return
-		}
+	db, ok := c.businessDB(ctx)
+	if !ok {
+		return
+	}
 
-		// 添加调试信息
-		fmt.Printf("表 %s 的字段信息 (%d 个):\n", tableName, len(columns))
-		for i, col := range columns {
-			fmt.Printf("  %d. 字段名: %s, 类型: %s, 主键: %s, 可空: %s\n",
-				i+1, col.ColumnName, col.DataType, col.ColumnKey, col.IsNullable)
-		}
+	list, err := dbschema.Columns(db, tableName)
+	if err != nil {
+		response.FailWithMsg(ctx, response.SystemError, err.Error())
+		return
+	}
 
-		response.OkWithData(ctx, columns)
-
-	default: // MySQL和其他数据库
-		// MySQL查询
-		var columns []generator.ColumnInfo
-		query := `
-			SELECT 
-				column_name AS "columnName",
-				data_type AS "dataType",
-				column_comment AS "columnComment",
-				is_nullable AS "isNullable",
-				column_key AS "columnKey"
-			FROM 
-				information_schema.columns 
-			WHERE 
-				table_schema = DATABASE() 
-				AND table_name = ? 
-			ORDER BY 
-				ordinal_position
-		`
-
-		if err := db.Raw(query, tableName).Scan(&columns).Error; err != nil {
-			response.FailWithMsg(ctx, response.SystemError, fmt.Sprintf("获取表字段失败: %v", err))
-			return
+	// 转换为代码生成器的列信息格式
+	columns := make([]generator.ColumnInfo, 0, len(list))
+	for _, col := range list {
+		isNullable := "YES"
+		if !col.Nullable {
+			isNullable = "NO"
 		}
 
-		// 添加调试信息
-		fmt.Printf("表 %s 的字段信息 (%d 个):\n", tableName, len(columns))
-		for i, col := range columns {
-			fmt.Printf("  %d. 字段名: %s, 类型: %s, 主键: %s, 可空: %s\n",
-				i+1, col.ColumnName, col.DataType, col.ColumnKey, col.IsNullable)
+		columnKey := ""
+		if col.PrimaryKey {
+			columnKey = "PRI"
 		}
 
-		response.OkWithData(ctx, columns)
+		columns = append(columns, generator.ColumnInfo{
+			ColumnName:    col.Name,
+			DataType:      col.Type,
+			ColumnComment: col.Comment,
+			IsNullable:    isNullable,
+			ColumnKey:     columnKey,
+		})
 	}
+
+	response.OkWithData(ctx, columns)
 }
 
 // Generate 生成代码
@@ -342,12 +177,15 @@ func (c *CodeGenController) Generate(ctx *gin.Context) {
 		Description   string             `json:"description"`
 		ApiPrefix     string             `json:"apiPrefix"`
 		AppName       string             `json:"appName"`
+		BusinessDB    string             `json:"businessDb"`
 		HasList       bool               `json:"hasList"`
 		HasCreate     bool               `json:"hasCreate"`
 		HasUpdate     bool               `json:"hasUpdate"`
 		HasDelete     bool               `json:"hasDelete"`
 		HasDetail     bool               `json:"hasDetail"`
 		HasPagination bool               `json:"hasPagination"`
+		HasTrash      bool               `json:"hasTrash"`
+		HasCache      bool               `json:"hasCache"`
 		Fields        []*generator.Field `json:"fields"`
 	}
 
@@ -372,6 +210,11 @@ func (c *CodeGenController) Generate(ctx *gin.Context) {
 		return
 	}
 
+	if req.BusinessDB != "" && facades.DB(req.BusinessDB) == nil {
+		response.FailWithMsg(ctx, response.ParamsValidError, fmt.Sprintf("数据库连接 %s 未配置", req.BusinessDB))
+		return
+	}
+
 	// 创建配置
 	config := &generator.Config{
 		StructName:    req.StructName,
@@ -387,6 +230,9 @@ func (c *CodeGenController) Generate(ctx *gin.Context) {
 		HasDelete:     req.HasDelete,
 		HasDetail:     req.HasDetail,
 		HasPagination: req.HasPagination,
+		HasTrash:      req.HasTrash,
+		HasCache:      req.HasCache,
+		BusinessDB:    req.BusinessDB,
 		Fields:        req.Fields,
 	}
 
@@ -485,6 +331,7 @@ func InitRoutes(r *gin.Engine) {
 	// 创建生成器
 	gen := generator.New(config)
 	gen.SetRootPath(rootPath)
+	gen.UseDB(facades.DBFrom(ctx))
 
 	// 初始化历史记录数据库
 	if err := gen.InitHistoryDB(); err != nil {
@@ -520,6 +367,7 @@ func (c *CodeGenController) GetHistory(ctx *gin.Context) {
 	// 创建生成器
 	gen := generator.New(&generator.Config{})
 	gen.SetRootPath("./")
+	gen.UseDB(facades.DBFrom(ctx))
 
 	// 获取历史记录列表
 	list, total, err := gen.ListHistory(page, pageSize)
@@ -559,6 +407,7 @@ func (c *CodeGenController) Rollback(ctx *gin.Context) {
 	// 创建生成器
 	gen := generator.New(&generator.Config{})
 	gen.SetRootPath("./")
+	gen.UseDB(facades.DBFrom(ctx))
 
 	// 执行回滚
 	if err := gen.RollBack(req.ID, req.DeleteFiles, req.DeleteAPI, req.DeleteMenu, req.DeleteTable); err != nil {
@@ -581,6 +430,7 @@ func (c *CodeGenController) DeleteHistory(ctx *gin.Context) {
 	// 创建生成器
 	gen := generator.New(&generator.Config{})
 	gen.SetRootPath("./")
+	gen.UseDB(facades.DBFrom(ctx))
 
 	// 删除历史记录
 	if err := gen.History.Delete(uint(id)); err != nil {
@@ -596,6 +446,7 @@ func (c *CodeGenController) RegisterRoutes(router *gin.RouterGroup) {
 	codegenGroup := router.Group("/codegen")
 	{
 		codegenGroup.GET("/apps", c.GetApps)
+		codegenGroup.GET("/databases", c.GetDatabases)
 		codegenGroup.GET("/tables", c.GetTables)
 		codegenGroup.GET("/columns", c.GetColumns)
 		codegenGroup.POST("/generate", c.Generate)
@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhoudm1743/go-web/core/database"
+	"github.com/zhoudm1743/go-web/core/facades"
+	"github.com/zhoudm1743/go-web/core/response"
+)
+
+// DiagnosticsController 诊断控制器
+type DiagnosticsController struct{}
+
+// NewDiagnosticsController 创建诊断控制器
+func NewDiagnosticsController() *DiagnosticsController {
+	return &DiagnosticsController{}
+}
+
+// GetSlowQueries 获取慢查询统计
+//
+// 支持 limit（默认20）和 sort（total、max、avg、count、last，默认total）参数。
+func (c *DiagnosticsController) GetSlowQueries(ctx *gin.Context) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 {
+		response.FailWithMsg(ctx, response.ParamsValidError, "limit无效")
+		return
+	}
+
+	threshold := ""
+	if config := facades.Config(); config != nil {
+		threshold = config.Database.SlowThreshold.String()
+	}
+
+	response.OkWithData(ctx, gin.H{
+		"threshold": threshold,
+		"list":      database.SlowQueries().Top(limit, ctx.DefaultQuery("sort", "total")),
+	})
+}
+
+// ResetSlowQueries 清空慢查询统计
+func (c *DiagnosticsController) ResetSlowQueries(ctx *gin.Context) {
+	database.SlowQueries().Reset()
+	response.OkWithMsg(ctx, "已清空")
+}
+
+// GetDBHealth 获取每个连接（含副本）的 Ping 耗时、错误信息与连接池状态
+func (c *DiagnosticsController) GetDBHealth(ctx *gin.Context) {
+	manager := facades.DBManager()
+	if manager == nil {
+		response.FailWithMsg(ctx, response.SystemError, "数据库未初始化")
+		return
+	}
+	response.OkWithData(ctx, manager.Health(ctx.Request.Context()))
+}
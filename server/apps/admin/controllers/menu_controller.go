@@ -1,13 +1,19 @@
 package controllers
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/zhoudm1743/go-web/apps/admin/dto"
 	"github.com/zhoudm1743/go-web/apps/admin/models"
+	"github.com/zhoudm1743/go-web/apps/admin/services"
+	"github.com/zhoudm1743/go-web/core/database"
 	"github.com/zhoudm1743/go-web/core/facades"
+	"github.com/zhoudm1743/go-web/core/repository"
 	"github.com/zhoudm1743/go-web/core/response"
+	"github.com/zhoudm1743/go-web/core/trash"
+	"gorm.io/gorm"
 )
 
 // MenuController 菜单控制器
@@ -21,7 +27,7 @@ func NewMenuController() *MenuController {
 // GetMenus 获取菜单列表
 func (c *MenuController) GetMenus(ctx *gin.Context) {
 	var menus []models.Menu
-	db := facades.DB()
+	db := facades.DBFrom(ctx)
 
 	// 查询条件
 	title := ctx.Query("title")
@@ -58,7 +64,7 @@ func (c *MenuController) CreateMenu(ctx *gin.Context) {
 
 	// 检查菜单名称是否已存在
 	var count int64
-	db := facades.DB()
+	db := facades.DBFrom(ctx)
 	if err := db.Model(&models.Menu{}).Where("name = ?", req.Name).Count(&count).Error; err != nil {
 		response.Fail(ctx, response.SystemError)
 		return
@@ -90,7 +96,7 @@ func (c *MenuController) UpdateMenu(ctx *gin.Context) {
 		return
 	}
 
-	db := facades.DB()
+	db := facades.DBFrom(ctx)
 	var menu models.Menu
 	if err := db.First(&menu, req.ID).Error; err != nil {
 		response.FailWithMsg(ctx, response.Failed, "菜单不存在")
@@ -117,14 +123,21 @@ func (c *MenuController) UpdateMenu(ctx *gin.Context) {
 		return
 	}
 
-	// 更新菜单
+	// 更新菜单，按读取时的版本号更新，期间被他人修改时返回冲突
+	version := response.ExpectVersion(ctx, req.Version, menu.Version)
 	response.Copy(&menu, req)
+	menu.Version = version
 
 	if err := db.Save(&menu).Error; err != nil {
+		if errors.Is(err, database.ErrVersionConflict) {
+			response.Fail(ctx, response.VersionConflict)
+			return
+		}
 		response.Fail(ctx, response.SystemError)
 		return
 	}
 
+	response.SetETag(ctx, menu.Version)
 	response.OkWithMsg(ctx, "更新成功")
 }
 
@@ -137,31 +150,58 @@ func (c *MenuController) DeleteMenu(ctx *gin.Context) {
 		return
 	}
 
-	db := facades.DB()
-
-	// 检查是否有子菜单
-	var childCount int64
-	if err := db.Model(&models.Menu{}).Where("parent_id = ?", menuID).Count(&childCount).Error; err != nil {
-		response.Fail(ctx, response.SystemError)
-		return
-	}
-
-	if childCount > 0 {
-		response.FailWithMsg(ctx, response.Failed, "该菜单下有子菜单，请先删除子菜单")
+	var query dto.DeleteQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		response.FailWithMsg(ctx, response.ParamsValidError, "请求参数有误")
 		return
 	}
 
-	// 删除菜单
-	if err := db.Delete(&models.Menu{}, menuID).Error; err != nil {
+	preview, err := services.NewCascadeService().DeleteMenu(ctx.Request.Context(), uint(menuID), query)
+	if err != nil {
+		if errors.Is(err, services.ErrMenuNotFound) {
+			response.FailWithMsg(ctx, response.Failed, err.Error())
+			return
+		}
 		response.Fail(ctx, response.SystemError)
 		return
 	}
+	deleteResult(ctx, "该菜单下有子菜单，请先删除子菜单或使用级联删除", preview)
+}
 
-	// 同时删除角色菜单关联
-	if err := db.Where("menu_id = ?", menuID).Delete(&models.RoleMenu{}).Error; err != nil {
-		response.Fail(ctx, response.SystemError)
-		return
-	}
+// menuTrash 菜单回收站，父菜单已删除时不能恢复
+var menuTrash = &trash.Handler[models.Menu]{
+	Name: "菜单",
+	Fields: repository.Fields{
+		Filter: repository.Columns("id", "name", "title", "path", "parent_id"),
+		Sort:   repository.Columns("id", "order"),
+		Search: []string{"name", "title", "path"},
+	},
+	Restoring: func(db *gorm.DB, menu *models.Menu) error {
+		if menu.PID == nil || *menu.PID == 0 {
+			return nil
+		}
+		var count int64
+		if err := db.Model(&models.Menu{}).Where("id = ?", *menu.PID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return errors.New("父菜单已删除，请先恢复父菜单")
+		}
+		return nil
+	},
+}
+
+// GetTrashedMenus 获取已删除的菜单列表
+func (c *MenuController) GetTrashedMenus(ctx *gin.Context) {
+	menuTrash.List(ctx)
+}
+
+// RestoreMenu 恢复已删除的菜单
+func (c *MenuController) RestoreMenu(ctx *gin.Context) {
+	menuTrash.Restore(ctx)
+}
 
-	response.OkWithMsg(ctx, "删除成功")
+// ForceDeleteMenu 永久删除回收站中的菜单
+func (c *MenuController) ForceDeleteMenu(ctx *gin.Context) {
+	menuTrash.ForceDelete(ctx)
 }
@@ -1,14 +1,20 @@
 package controllers
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/zhoudm1743/go-web/apps/admin/dto"
 	"github.com/zhoudm1743/go-web/apps/admin/models"
+	"github.com/zhoudm1743/go-web/apps/admin/services"
+	"github.com/zhoudm1743/go-web/core/database"
 	"github.com/zhoudm1743/go-web/core/facades"
+	"github.com/zhoudm1743/go-web/core/repository"
 	"github.com/zhoudm1743/go-web/core/response"
+	"github.com/zhoudm1743/go-web/core/trash"
 	"github.com/zhoudm1743/go-web/core/utils"
+	"gorm.io/gorm"
 )
 
 // RoleController 角色控制器
@@ -19,23 +25,35 @@ func NewRoleController() *RoleController {
 	return &RoleController{}
 }
 
+// roleQueryFields 角色列表允许查询的字段
+var roleQueryFields = repository.Fields{
+	Filter:      repository.Columns("id", "name", "code", "status", "created_at"),
+	Sort:        repository.Columns("id", "name", "code", "sort", "status", "created_at"),
+	Search:      []string{"name", "code", "remark"},
+	DefaultSort: "sort,id",
+}
+
 // GetRoles 获取角色列表
 func (c *RoleController) GetRoles(ctx *gin.Context) {
-	var roles []models.Role
-	db := facades.DB()
+	query, err := repository.ParseQuery(ctx, roleQueryFields)
+	if err != nil {
+		response.FailWithMsg(ctx, response.ParamsValidError, err.Error())
+		return
+	}
 
-	if err := db.Find(&roles).Error; err != nil {
+	page, err := repository.New[models.Role](facades.DBFrom(ctx)).Paginate(query)
+	if err != nil {
 		response.Fail(ctx, response.SystemError)
 		return
 	}
 
-	response.OkWithData(ctx, roles)
+	response.OkWithData(ctx, page)
 }
 
 // GetRoleList 获取角色简易列表（用于下拉选择）
 func (c *RoleController) GetRoleList(ctx *gin.Context) {
 	var roles []models.Role
-	db := facades.DB()
+	db := facades.DBFrom(ctx)
 
 	if err := db.Select("id, name, code").Find(&roles).Error; err != nil {
 		response.Fail(ctx, response.SystemError)
@@ -55,7 +73,7 @@ func (c *RoleController) CreateRole(ctx *gin.Context) {
 
 	// 检查角色编码是否已存在
 	var count int64
-	db := facades.DB()
+	db := facades.DBFrom(ctx)
 	if err := db.Model(&models.Role{}).Where("code = ?", req.Code).Count(&count).Error; err != nil {
 		response.Fail(ctx, response.SystemError)
 		return
@@ -86,7 +104,7 @@ func (c *RoleController) UpdateRole(ctx *gin.Context) {
 		return
 	}
 
-	db := facades.DB()
+	db := facades.DBFrom(ctx)
 	var role models.Role
 	if err := db.First(&role, req.ID).Error; err != nil {
 		response.FailWithMsg(ctx, response.Failed, "角色不存在")
@@ -107,6 +125,9 @@ func (c *RoleController) UpdateRole(ctx *gin.Context) {
 		}
 	}
 
+	// 按读取时的版本号更新，期间被他人修改时返回冲突
+	role.Version = response.ExpectVersion(ctx, req.Version, role.Version)
+
 	// 更新角色，只更新非空字段
 	updates := map[string]interface{}{}
 
@@ -132,10 +153,15 @@ func (c *RoleController) UpdateRole(ctx *gin.Context) {
 	}
 
 	if err := db.Model(&role).Updates(updates).Error; err != nil {
+		if errors.Is(err, database.ErrVersionConflict) {
+			response.Fail(ctx, response.VersionConflict)
+			return
+		}
 		response.Fail(ctx, response.SystemError)
 		return
 	}
 
+	response.SetETag(ctx, role.Version)
 	response.OkWithMsg(ctx, "更新成功")
 }
 
@@ -148,32 +174,53 @@ func (c *RoleController) DeleteRole(ctx *gin.Context) {
 		return
 	}
 
-	// 检查是否有管理员在使用该角色
-	db := facades.DB()
-	var count int64
-	if err := db.Model(&models.Admin{}).Where("role_id = ?", roleID).Count(&count).Error; err != nil {
-		response.Fail(ctx, response.SystemError)
+	var query dto.DeleteQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		response.FailWithMsg(ctx, response.ParamsValidError, "请求参数有误")
 		return
 	}
 
-	if count > 0 {
-		response.FailWithMsg(ctx, response.Failed, "该角色正在被使用，无法删除")
+	preview, err := services.NewCascadeService().DeleteRole(ctx.Request.Context(), uint(roleID), query)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrSuperRole), errors.Is(err, services.ErrRoleNotFound):
+			response.FailWithMsg(ctx, response.Failed, err.Error())
+		default:
+			response.Fail(ctx, response.SystemError)
+		}
 		return
 	}
+	deleteResult(ctx, "该角色正在被使用，无法删除", preview)
+}
 
-	// 删除角色
-	if err := db.Delete(&models.Role{}, roleID).Error; err != nil {
-		response.Fail(ctx, response.SystemError)
-		return
+// deleteResult 输出删除结果，被关联数据阻止时返回失败和影响范围，只预览时返回影响范围
+func deleteResult(ctx *gin.Context, blockedMsg string, preview *dto.DeletePreview) {
+	switch {
+	case preview.Blocked:
+		response.Result(ctx, response.Failed.Make(blockedMsg), preview)
+	case preview.DryRun:
+		response.OkWithData(ctx, preview)
+	default:
+		response.Result(ctx, response.Success.Make("删除成功"), preview)
 	}
+}
 
-	// 同时删除角色菜单关联
-	if err := db.Where("role_id = ?", roleID).Delete(&models.RoleMenu{}).Error; err != nil {
-		response.Fail(ctx, response.SystemError)
-		return
-	}
+// roleTrash 角色回收站，角色删除时已清除菜单关联，恢复后需重新分配菜单
+var roleTrash = &trash.Handler[models.Role]{Name: "角色", Fields: roleQueryFields}
+
+// GetTrashedRoles 获取已删除的角色列表
+func (c *RoleController) GetTrashedRoles(ctx *gin.Context) {
+	roleTrash.List(ctx)
+}
 
-	response.OkWithMsg(ctx, "删除成功")
+// RestoreRole 恢复已删除的角色
+func (c *RoleController) RestoreRole(ctx *gin.Context) {
+	roleTrash.Restore(ctx)
+}
+
+// ForceDeleteRole 永久删除回收站中的角色
+func (c *RoleController) ForceDeleteRole(ctx *gin.Context) {
+	roleTrash.ForceDelete(ctx)
 }
 
 // GetRoleMenus 获取角色菜单
@@ -185,9 +232,16 @@ func (c *RoleController) GetRoleMenus(ctx *gin.Context) {
 		return
 	}
 
+	// 角色必须属于当前租户
+	db := facades.DBFrom(ctx)
+	var role models.Role
+	if err := db.First(&role, roleID).Error; err != nil {
+		response.FailWithMsg(ctx, response.Failed, "角色不存在")
+		return
+	}
+
 	// 查询该角色关联的菜单ID
 	var menuIDs []uint
-	db := facades.DB()
 	if err := db.Model(&models.RoleMenu{}).Where("role_id = ?", roleID).Pluck("menu_id", &menuIDs).Error; err != nil {
 		response.Fail(ctx, response.SystemError)
 		return
@@ -207,20 +261,34 @@ func (c *RoleController) UpdateRoleMenus(ctx *gin.Context) {
 		return
 	}
 
-	db := facades.DB()
+	db := facades.DBFrom(ctx)
 
-	// 开启事务
-	tx := db.Begin()
-
-	// 先删除原有的角色菜单关联
-	if err := tx.Where("role_id = ?", req.RoleID).Delete(&models.RoleMenu{}).Error; err != nil {
-		tx.Rollback()
-		response.Fail(ctx, response.SystemError)
+	// 角色和菜单必须属于当前租户
+	var role models.Role
+	if err := db.First(&role, req.RoleID).Error; err != nil {
+		response.FailWithMsg(ctx, response.Failed, "角色不存在")
 		return
 	}
-
-	// 添加新的角色菜单关联
 	if len(req.MenuIDs) > 0 {
+		var menuIDs []uint
+		if err := db.Model(&models.Menu{}).Where("id IN ?", req.MenuIDs).Pluck("id", &menuIDs).Error; err != nil {
+			response.Fail(ctx, response.SystemError)
+			return
+		}
+		req.MenuIDs = menuIDs
+	}
+
+	// 在事务中更新关联，已处于请求事务中时使用保存点
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// 先删除原有的角色菜单关联
+		if err := tx.Where("role_id = ?", req.RoleID).Delete(&models.RoleMenu{}).Error; err != nil {
+			return err
+		}
+
+		// 添加新的角色菜单关联
+		if len(req.MenuIDs) == 0 {
+			return nil
+		}
 		var roleMenus []models.RoleMenu
 		for _, menuID := range req.MenuIDs {
 			roleMenus = append(roleMenus, models.RoleMenu{
@@ -228,42 +296,36 @@ func (c *RoleController) UpdateRoleMenus(ctx *gin.Context) {
 				MenuID: menuID,
 			})
 		}
-
-		if err := tx.Create(&roleMenus).Error; err != nil {
-			tx.Rollback()
-			response.Fail(ctx, response.SystemError)
-			return
-		}
-	}
-
-	// 提交事务
-	if err := tx.Commit().Error; err != nil {
-		tx.Rollback()
+		return tx.Create(&roleMenus).Error
+	})
+	if err != nil {
 		response.Fail(ctx, response.SystemError)
 		return
 	}
 
-	// 更新casbin权限
+	// 更新casbin权限，策略按租户域隔离
 	enforcer := utils.Casbin()
+	sub := strconv.Itoa(int(req.RoleID))
+	dom := utils.CasbinDomain(ctx.Request.Context())
 
 	// 清除该角色原有权限
-	enforcer.RemoveFilteredPolicy(0, strconv.Itoa(int(req.RoleID)))
+	enforcer.RemoveFilteredPolicy(0, sub, dom)
 
 	// 添加新权限
 	// 超级管理员可以访问所有API
-	if req.RoleID == 1 {
-		enforcer.AddPolicy(strconv.Itoa(int(req.RoleID)), "/*", "*")
+	if role.Code == "super" {
+		enforcer.AddPolicy(sub, dom, "/*", "*")
 	} else {
 		// 其他角色只能访问有权限的API
 		// 这里根据菜单ID设置权限
 		// 实际项目中可能需要更复杂的权限控制
 		// 简单示例：为每个菜单ID添加一个API权限
 		for _, menuID := range req.MenuIDs {
-			enforcer.AddPolicy(strconv.Itoa(int(req.RoleID)), "/api/menu/"+strconv.Itoa(int(menuID)), "GET")
+			enforcer.AddPolicy(sub, dom, "/api/menu/"+strconv.Itoa(int(menuID)), "GET")
 		}
 
 		// 添加一些基础权限
-		enforcer.AddPolicy(strconv.Itoa(int(req.RoleID)), "/api/user/info", "GET")
+		enforcer.AddPolicy(sub, dom, "/api/user/info", "GET")
 	}
 
 	// 保存策略
@@ -67,6 +67,7 @@ type MenuUpdateRequest struct {
 	WithoutTab   bool   `json:"withoutTab"`
 	PinTab       bool   `json:"pinTab"`
 	MenuType     string `json:"menuType" binding:"required"`
+	Version      uint   `json:"version"`
 }
 
 // RoleCreateRequest 创建角色请求
@@ -80,12 +81,13 @@ type RoleCreateRequest struct {
 
 // RoleUpdateRequest 更新角色请求
 type RoleUpdateRequest struct {
-	ID     uint   `json:"id" binding:"required"`
-	Name   string `json:"name"`
-	Code   string `json:"code"`
-	Sort   uint   `json:"sort"`
-	Status uint   `json:"status"`
-	Remark string `json:"remark"`
+	ID      uint   `json:"id" binding:"required"`
+	Name    string `json:"name"`
+	Code    string `json:"code"`
+	Sort    uint   `json:"sort"`
+	Status  uint   `json:"status"`
+	Remark  string `json:"remark"`
+	Version uint   `json:"version"`
 }
 
 // RoleMenuRequest 角色菜单关联请求
@@ -116,4 +118,51 @@ type AdminUpdateRequest struct {
 	Mobile   string `json:"mobile"`
 	RoleID   uint   `json:"roleId"`
 	Status   uint   `json:"status"`
+	Version  uint   `json:"version"`
+}
+
+// DeleteQuery 删除请求参数
+type DeleteQuery struct {
+	Cascade bool `form:"cascade"` // 是否级联处理关联数据，否则存在关联数据时阻止删除
+	DryRun  bool `form:"dryRun"`  // 是否只预览影响范围，不执行删除
+}
+
+// DeleteItem 删除涉及的记录
+type DeleteItem struct {
+	ID    uint   `json:"id"`
+	Label string `json:"label"`
+}
+
+// DeleteImpact 删除对关联数据的影响
+type DeleteImpact struct {
+	Table    string       `json:"table"`           // 表名
+	Action   string       `json:"action"`          // 处理方式 delete:删除 detach:解除关联
+	Count    int64        `json:"count"`           // 影响的记录数
+	Blocking bool         `json:"blocking"`        // 未开启级联时是否阻止删除
+	Items    []DeleteItem `json:"items,omitempty"` // 影响的记录，关联表只返回数量
+}
+
+// DeletePreview 删除预览和执行结果
+type DeletePreview struct {
+	Target  DeleteItem     `json:"target"`  // 删除的记录
+	Cascade bool           `json:"cascade"` // 是否级联
+	DryRun  bool           `json:"dryRun"`  // 是否只预览
+	Blocked bool           `json:"blocked"` // 是否因关联数据被阻止
+	Deleted bool           `json:"deleted"` // 是否已删除
+	Impacts []DeleteImpact `json:"impacts"` // 关联数据影响
+}
+
+// CacheDeleteKeysRequest 删除缓存键请求
+type CacheDeleteKeysRequest struct {
+	Keys []string `json:"keys" binding:"required,min=1,max=1000"`
+}
+
+// CacheDeletePatternRequest 按模式删除缓存键请求
+type CacheDeletePatternRequest struct {
+	Pattern string `json:"pattern" binding:"required"`
+}
+
+// CacheFlushNamespaceRequest 清空缓存命名空间请求
+type CacheFlushNamespaceRequest struct {
+	Namespace string `json:"namespace" binding:"required"`
 }
@@ -3,12 +3,18 @@ package middlewares
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/zhoudm1743/go-web/apps/admin/models"
+	"github.com/zhoudm1743/go-web/apps/admin/seeders"
+	"github.com/zhoudm1743/go-web/core/dbcache"
 	"github.com/zhoudm1743/go-web/core/facades"
 	"github.com/zhoudm1743/go-web/core/middleware"
 	"github.com/zhoudm1743/go-web/core/response"
+	"github.com/zhoudm1743/go-web/core/tenant"
 	"github.com/zhoudm1743/go-web/core/utils"
 )
 
+// rolesKey 上下文中保存当前管理员角色编码的键
+const rolesKey = "admin_roles"
+
 // PermissionAuth 权限认证中间件
 func PermissionAuth() gin.HandlerFunc {
 	return middleware.CasbinHandler()
@@ -25,9 +31,10 @@ func AdminAuth() gin.HandlerFunc {
 			return
 		}
 
-		// 查询用户角色
+		// 查询用户角色，超级租户的账号可切换到其他租户，按ID查询时不过滤租户；启用模型缓存时读取缓存
 		var admin models.Admin
-		if err := facades.DB().First(&admin, claims.UserID).Error; err != nil {
+		db := facades.DBContext(tenant.SkipScope(c.Request.Context()))
+		if err := db.Scopes(dbcache.Cached(0)).First(&admin, claims.UserID).Error; err != nil {
 			response.Fail(c, response.NoPermission)
 			c.Abort()
 			return
@@ -49,6 +56,34 @@ func AdminAuth() gin.HandlerFunc {
 			return
 		}
 
+		c.Set(rolesKey, roles)
 		c.Next()
 	}
 }
+
+// SuperOnly 只允许超级租户中超级管理员角色的管理员访问，否则以 msg 拒绝，用于包含所有租户数据的诊断和缓存路由
+//
+// 需放在 AdminAuth 之后，角色使用 AdminAuth 从数据库中查询的结果，不使用令牌中的角色；
+// 未启用多租户时所有请求都属于超级租户，因此同时检查角色
+func SuperOnly(msg string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !tenant.IsSuper(tenant.FromContext(c.Request.Context())) || !hasRole(c, seeders.SuperRoleCode) {
+			response.NoAuth(c, msg)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// hasRole 判断 AdminAuth 解析出的角色中是否包含 code
+func hasRole(c *gin.Context, code string) bool {
+	roles, _ := c.Get(rolesKey)
+	list, _ := roles.([]string)
+	for _, role := range list {
+		if role == code {
+			return true
+		}
+	}
+	return false
+}
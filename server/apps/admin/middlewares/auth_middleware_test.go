@@ -0,0 +1,142 @@
+package middlewares
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	gormadapter "github.com/casbin/gorm-adapter/v3"
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/zhoudm1743/go-web/apps/admin/models"
+	"github.com/zhoudm1743/go-web/apps/admin/seeders"
+	"github.com/zhoudm1743/go-web/core/conf"
+	"github.com/zhoudm1743/go-web/core/database"
+	"github.com/zhoudm1743/go-web/core/facades"
+	"github.com/zhoudm1743/go-web/core/middleware"
+	"github.com/zhoudm1743/go-web/core/response"
+	"github.com/zhoudm1743/go-web/core/seeder"
+	"github.com/zhoudm1743/go-web/core/tenant"
+	"github.com/zhoudm1743/go-web/core/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// TestCrossTenantPermission 测试超级租户的超级管理员切换到其他租户时通过权限检查，其他租户的管理员不能越权
+func TestCrossTenantPermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tenant.Configure(conf.TenantConfig{Enabled: true, Resolvers: []string{tenant.ResolverHeader, tenant.ResolverJWT}})
+	t.Cleanup(func() { tenant.Configure(conf.TenantConfig{}) })
+	tenant.Forget()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "auth.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	if err := db.Use(tenant.Plugin{}); err != nil {
+		t.Fatalf("注册租户插件失败: %v", err)
+	}
+	err = db.AutoMigrate(&tenant.Tenant{}, &models.Admin{}, &models.Role{}, &models.Menu{},
+		&models.RoleMenu{}, &models.AdminRole{}, &gormadapter.CasbinRule{})
+	if err != nil {
+		t.Fatalf("创建表失败: %v", err)
+	}
+	facades.SetConfig(&conf.Config{})
+	facades.SetDBManager(database.NewManagerWith(db))
+
+	super := &tenant.Tenant{Code: tenant.SuperCode(), Name: "平台"}
+	acme := &tenant.Tenant{Code: "acme", Name: "Acme"}
+	beta := &tenant.Tenant{Code: "beta", Name: "Beta"}
+	for _, tt := range []*tenant.Tenant{super, acme, beta} {
+		if err := tenant.Create(db, tt); err != nil {
+			t.Fatalf("创建租户失败: %v", err)
+		}
+	}
+
+	// 其他租户必须指定默认管理员的密码
+	opts := seeder.Options{Env: "prod"}
+	ctx := context.Background()
+	if err := seeder.Run(tenant.WithTenant(ctx, beta), db, opts); !errors.Is(err, seeders.ErrAdminPassword) {
+		t.Fatalf("期望 ErrAdminPassword, 实际: %v", err)
+	}
+	if err := seeder.Run(tenant.WithTenant(ctx, super), db, opts); err != nil {
+		t.Fatalf("填充超级租户失败: %v", err)
+	}
+	if err := seeder.Run(seeders.WithAdminPassword(tenant.WithTenant(ctx, acme), "acme-secret"), db, opts); err != nil {
+		t.Fatalf("填充租户失败: %v", err)
+	}
+
+	// token 返回租户中默认管理员的令牌
+	token := func(tt *tenant.Tenant) string {
+		var admin models.Admin
+		if err := db.WithContext(tenant.WithTenant(ctx, tt)).Where("username = ?", "admin").First(&admin).Error; err != nil {
+			t.Fatalf("查询默认管理员失败: %v", err)
+		}
+		if tt == acme && !models.CheckPassword("acme-secret", admin.Password) {
+			t.Fatal("默认管理员应使用指定的密码")
+		}
+		s, err := utils.GenerateTenantToken(tt.ID, int(admin.ID), admin.Username, int(admin.RoleID))
+		if err != nil {
+			t.Fatalf("生成令牌失败: %v", err)
+		}
+		return s
+	}
+	superToken, acmeToken := token(super), token(acme)
+
+	r := gin.New()
+	r.Use(middleware.Tenant())
+	r.GET("/admin/role/list", AdminAuth(), PermissionAuth(), func(c *gin.Context) {
+		response.Ok(c)
+	})
+	call := func(token string, tt *tenant.Tenant) int {
+		req := httptest.NewRequest(http.MethodGet, "/admin/role/list", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("X-Tenant", tt.Code)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		var resp struct {
+			Code int `json:"code"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("解析响应失败: %v, %s", err, w.Body.String())
+		}
+		return resp.Code
+	}
+
+	cases := []struct {
+		name  string
+		token string
+		dom   *tenant.Tenant
+		code  int
+	}{
+		{"超级管理员访问超级租户", superToken, super, response.Success.Code()},
+		{"超级管理员切换到其他租户", superToken, acme, response.Success.Code()},
+		{"租户管理员访问本租户", acmeToken, acme, response.Success.Code()},
+		{"租户管理员访问超级租户", acmeToken, super, response.TenantMismatch.Code()},
+	}
+	for _, tc := range cases {
+		if code := call(tc.token, tc.dom); code != tc.code {
+			t.Errorf("%s: 期望 %d, 实际 %d", tc.name, tc.code, code)
+		}
+	}
+
+	// 租户超级管理员角色的策略只在本租户的域中生效
+	var acmeRole models.Role
+	if err := db.WithContext(tenant.WithTenant(ctx, acme)).Where("code = ?", seeders.SuperRoleCode).First(&acmeRole).Error; err != nil {
+		t.Fatalf("查询角色失败: %v", err)
+	}
+	sub := strconv.FormatUint(uint64(acmeRole.ID), 10)
+	for dom, want := range map[string]bool{acme.Domain(): true, super.Domain(): false, beta.Domain(): false} {
+		if ok, _ := utils.Casbin().Enforce(sub, dom, "/admin/role/list", http.MethodGet); ok != want {
+			t.Errorf("租户角色在域 %s 中的权限: 期望 %v, 实际 %v", dom, want, ok)
+		}
+	}
+}
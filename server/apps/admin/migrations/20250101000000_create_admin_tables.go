@@ -0,0 +1,115 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zhoudm1743/go-web/core/migration"
+	"gorm.io/gorm"
+)
+
+// 迁移使用编写时的表结构，不引用 models 中的模型，模型后续的修改通过新的迁移完成
+
+// baseAdmin 管理员表的初始结构
+type baseAdmin struct {
+	ID          uint `gorm:"primarykey"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+	UUID        uuid.UUID      `gorm:"type:char(36);index;comment:用户UUID"`
+	Username    string         `gorm:"type:varchar(50);not null;unique;comment:用户名"`
+	Password    string         `gorm:"type:varchar(100);not null;comment:密码"`
+	Nickname    string         `gorm:"type:varchar(50);comment:昵称"`
+	RealName    string         `gorm:"type:varchar(50);comment:真实姓名"`
+	Avatar      string         `gorm:"type:varchar(255);comment:头像"`
+	Email       string         `gorm:"type:varchar(100);comment:邮箱"`
+	Mobile      string         `gorm:"type:varchar(20);comment:手机号"`
+	Status      uint           `gorm:"type:tinyint(1);default:1;comment:状态 1:启用 2:禁用"`
+	RoleID      uint           `gorm:"comment:角色ID"`
+	LastLoginAt time.Time      `gorm:"comment:最后登录时间"`
+	LastLoginIP string         `gorm:"type:varchar(50);comment:最后登录IP"`
+}
+
+func (baseAdmin) TableName() string { return "admins" }
+
+// baseRole 角色表的初始结构
+type baseRole struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	Name      string         `gorm:"type:varchar(50);not null;comment:角色名称"`
+	Code      string         `gorm:"type:varchar(50);not null;unique;comment:角色编码"`
+	Sort      uint           `gorm:"default:0;comment:排序"`
+	Status    uint           `gorm:"type:tinyint(1);default:1;comment:状态 1:启用 2:禁用"`
+	Remark    string         `gorm:"type:varchar(255);comment:备注"`
+}
+
+func (baseRole) TableName() string { return "roles" }
+
+// baseMenu 菜单表的初始结构
+type baseMenu struct {
+	ID           uint `gorm:"primarykey"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    gorm.DeletedAt `gorm:"index"`
+	PID          *uint          `gorm:"column:parent_id;default:null;comment:父菜单ID"`
+	Name         string         `gorm:"type:varchar(50);not null;comment:路由名称"`
+	Path         string         `gorm:"type:varchar(100);comment:路由路径"`
+	Component    string         `gorm:"type:varchar(100);comment:组件路径"`
+	Redirect     string         `gorm:"type:varchar(100);comment:重定向路径"`
+	Icon         string         `gorm:"type:varchar(50);comment:图标"`
+	Title        string         `gorm:"type:varchar(50);comment:标题"`
+	Order        int            `gorm:"default:0;comment:排序"`
+	Hidden       bool           `gorm:"default:false;comment:是否隐藏"`
+	KeepAlive    bool           `gorm:"default:false;comment:是否缓存"`
+	RequiresAuth bool           `gorm:"default:true;comment:是否需要认证"`
+	WithoutTab   bool           `gorm:"default:false;comment:是否不添加到标签页"`
+	PinTab       bool           `gorm:"default:false;comment:是否固定在标签页"`
+	MenuType     string         `gorm:"type:varchar(10);default:'page';comment:菜单类型 page|dir"`
+	Status       uint           `gorm:"type:tinyint(1);default:1;comment:状态 1:启用 2:禁用"`
+}
+
+func (baseMenu) TableName() string { return "menus" }
+
+// baseRoleMenu 角色菜单中间表
+type baseRoleMenu struct {
+	RoleID uint `gorm:"primarykey;comment:角色ID"`
+	MenuID uint `gorm:"primarykey;comment:菜单ID"`
+}
+
+func (baseRoleMenu) TableName() string { return "role_menus" }
+
+// baseAdminRole 管理员角色中间表
+type baseAdminRole struct {
+	AdminID uint `gorm:"primarykey;comment:管理员ID"`
+	RoleID  uint `gorm:"primarykey;comment:角色ID"`
+}
+
+func (baseAdminRole) TableName() string { return "admin_roles" }
+
+func init() {
+	migration.Register(&migration.Migration{
+		Version: "20250101000000",
+		Name:    "create_admin_tables",
+		Up: func(tx *gorm.DB) error {
+			// 使用AutoMigrate以兼容已通过自动迁移创建过表结构的安装
+			return tx.AutoMigrate(
+				&baseAdmin{},
+				&baseRole{},
+				&baseMenu{},
+				&baseRoleMenu{},
+				&baseAdminRole{},
+			)
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(
+				&baseAdminRole{},
+				&baseRoleMenu{},
+				&baseMenu{},
+				&baseRole{},
+				&baseAdmin{},
+			)
+		},
+	})
+}
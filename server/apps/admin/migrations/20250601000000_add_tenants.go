@@ -0,0 +1,97 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/zhoudm1743/go-web/core/migration"
+	"github.com/zhoudm1743/go-web/core/tenant"
+	"gorm.io/gorm"
+)
+
+// tenantRecord 租户表的结构
+type tenantRecord struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	Code      string         `gorm:"type:varchar(50);not null;unique;comment:租户编码"`
+	Name      string         `gorm:"type:varchar(100);not null;comment:租户名称"`
+	Status    uint           `gorm:"type:tinyint(1);default:1;comment:状态 1:启用 2:禁用"`
+	Remark    string         `gorm:"type:varchar(255);comment:备注"`
+}
+
+func (tenantRecord) TableName() string { return "tenants" }
+
+// 增加租户ID后的结构，同名字段覆盖嵌入结构中的定义
+
+// tenantAdmin 用户名改为租户内唯一
+type tenantAdmin struct {
+	baseAdmin
+	TenantID uint   `gorm:"not null;default:0;uniqueIndex:idx_admins_tenant_username;comment:租户ID"`
+	Username string `gorm:"type:varchar(50);not null;uniqueIndex:idx_admins_tenant_username;comment:用户名"`
+}
+
+// tenantRole 角色编码改为租户内唯一
+type tenantRole struct {
+	baseRole
+	TenantID uint   `gorm:"not null;default:0;uniqueIndex:idx_roles_tenant_code;comment:租户ID"`
+	Code     string `gorm:"type:varchar(50);not null;uniqueIndex:idx_roles_tenant_code;comment:角色编码"`
+}
+
+type tenantMenu struct {
+	baseMenu
+	TenantID uint `gorm:"not null;default:0;index;comment:租户ID"`
+}
+
+// tenantTables 按租户隔离的后台表
+var tenantTables = []interface{}{&tenantAdmin{}, &tenantRole{}, &tenantMenu{}}
+
+func init() {
+	migration.Register(&migration.Migration{
+		Version: "20250601000000",
+		Name:    "add_tenants",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&tenantRecord{}); err != nil {
+				return err
+			}
+
+			// 创建超级租户，已有数据全部归属超级租户
+			super := tenantRecord{Code: tenant.SuperCode(), Name: "平台", Status: 1, Remark: "平台运营方"}
+			if err := tx.Where("code = ?", super.Code).FirstOrCreate(&super).Error; err != nil {
+				return err
+			}
+
+			// 增加 tenant_id 列，用户名和角色编码改为租户内唯一
+			if err := tx.AutoMigrate(tenantTables...); err != nil {
+				return err
+			}
+			// 迁移作用于所有租户的数据
+			all := tx.WithContext(tenant.SkipScope(tx.Statement.Context))
+			for _, table := range tenantTables {
+				if err := all.Model(table).Unscoped().Where("tenant_id = ?", 0).
+					UpdateColumn("tenant_id", super.ID).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			indexes := map[interface{}]string{
+				&tenantAdmin{}: "idx_admins_tenant_username",
+				&tenantRole{}:  "idx_roles_tenant_code",
+				&tenantMenu{}:  "idx_menus_tenant_id",
+			}
+			for table, index := range indexes {
+				if tx.Migrator().HasIndex(table, index) {
+					if err := tx.Migrator().DropIndex(table, index); err != nil {
+						return err
+					}
+				}
+				if err := tx.Migrator().DropColumn(table, "tenant_id"); err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().DropTable(&tenantRecord{})
+		},
+	})
+}
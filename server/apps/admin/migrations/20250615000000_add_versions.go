@@ -0,0 +1,58 @@
+package migrations
+
+import (
+	"github.com/zhoudm1743/go-web/core/migration"
+	"github.com/zhoudm1743/go-web/core/tenant"
+	"gorm.io/gorm"
+)
+
+// 增加乐观锁版本号后的结构
+type (
+	versionAdmin struct {
+		tenantAdmin
+		Version uint `gorm:"not null;default:1;comment:版本号"`
+	}
+	versionRole struct {
+		tenantRole
+		Version uint `gorm:"not null;default:1;comment:版本号"`
+	}
+	versionMenu struct {
+		tenantMenu
+		Version uint `gorm:"not null;default:1;comment:版本号"`
+	}
+)
+
+// versionTables 带版本号的后台表
+var versionTables = []interface{}{&versionAdmin{}, &versionRole{}, &versionMenu{}}
+
+func init() {
+	migration.Register(&migration.Migration{
+		Version: "20250615000000",
+		Name:    "add_versions",
+		Up: func(tx *gorm.DB) error {
+			// 增加 version 列用于乐观锁，已有数据从版本 1 开始
+			if err := tx.AutoMigrate(versionTables...); err != nil {
+				return err
+			}
+			// 迁移作用于所有租户的数据
+			all := tx.WithContext(tenant.SkipScope(tx.Statement.Context))
+			for _, table := range versionTables {
+				if err := all.Model(table).Unscoped().Where("version IS NULL OR version = ?", 0).
+					UpdateColumn("version", 1).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, table := range versionTables {
+				if tx.Migrator().HasColumn(table, "Version") {
+					if err := tx.Migrator().DropColumn(table, "Version"); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+	})
+}
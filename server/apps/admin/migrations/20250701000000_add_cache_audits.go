@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/zhoudm1743/go-web/core/migration"
+	"gorm.io/gorm"
+)
+
+// cacheAudit 缓存操作记录表的结构
+type cacheAudit struct {
+	ID        uint      `gorm:"primarykey"`
+	CreatedAt time.Time `gorm:"index"`
+	AdminID   uint      `gorm:"index;comment:管理员ID"`
+	Username  string    `gorm:"type:varchar(50);comment:管理员用户名"`
+	IP        string    `gorm:"type:varchar(50);comment:操作IP"`
+	Action    string    `gorm:"type:varchar(20);index;comment:操作类型"`
+	Target    string    `gorm:"type:text;comment:操作对象"`
+	Affected  int64     `gorm:"comment:删除的键数量"`
+	Error     string    `gorm:"type:text;comment:失败原因"`
+}
+
+func (cacheAudit) TableName() string { return "cache_audits" }
+
+func init() {
+	migration.Register(&migration.Migration{
+		Version: "20250701000000",
+		Name:    "add_cache_audits",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&cacheAudit{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&cacheAudit{})
+		},
+	})
+}
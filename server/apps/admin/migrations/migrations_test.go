@@ -0,0 +1,62 @@
+package migrations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/zhoudm1743/go-web/core/migration"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// TestMigrationsUpDown 测试迁移按编写时的表结构逐步执行并可完整回滚
+func TestMigrationsUpDown(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	migrations := migration.Registered()
+	m := migration.NewMigrator(db, migration.WithMigrations(migrations...),
+		migration.WithLogger(func(string, ...interface{}) {}))
+
+	// 初始迁移不包含后续迁移增加的列
+	if _, err := m.Up(ctx, 1); err != nil {
+		t.Fatalf("执行初始迁移失败: %v", err)
+	}
+	for _, column := range []string{"tenant_id", "version"} {
+		if db.Migrator().HasColumn("admins", column) {
+			t.Errorf("初始迁移不应创建列 %s", column)
+		}
+	}
+
+	if _, err := m.Up(ctx, 0); err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+	for _, column := range []string{"tenant_id", "version"} {
+		if !db.Migrator().HasColumn("admins", column) {
+			t.Errorf("缺少列 %s", column)
+		}
+	}
+	if !db.Migrator().HasIndex("admins", "idx_admins_tenant_username") {
+		t.Error("缺少租户内用户名唯一索引")
+	}
+	if !db.Migrator().HasTable("cache_audits") {
+		t.Error("缺少缓存操作记录表")
+	}
+
+	if _, err := m.Down(ctx, len(migrations)); err != nil {
+		t.Fatalf("回滚迁移失败: %v", err)
+	}
+	for _, table := range []string{"admins", "roles", "menus", "tenants", "cache_audits"} {
+		if db.Migrator().HasTable(table) {
+			t.Errorf("回滚后表 %s 仍然存在", table)
+		}
+	}
+}
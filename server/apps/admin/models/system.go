@@ -1,46 +1,53 @@
 package models
 
 import (
+	"context"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/zhoudm1743/go-web/core/dbcache"
 	"github.com/zhoudm1743/go-web/core/facades"
+	"github.com/zhoudm1743/go-web/core/tenant"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
 // Admin 管理员模型
 type Admin struct {
-	ID          uint           `gorm:"primarykey" json:"id"`                                         // 主键ID
-	CreatedAt   time.Time      `json:"createdAt"`                                                    // 创建时间
-	UpdatedAt   time.Time      `json:"updatedAt"`                                                    // 更新时间
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`                                               // 删除时间
-	UUID        uuid.UUID      `gorm:"type:char(36);index;comment:用户UUID" json:"uuid"`               // 用户UUID
-	Username    string         `gorm:"type:varchar(50);not null;unique;comment:用户名" json:"username"` // 用户名
-	Password    string         `gorm:"type:varchar(100);not null;comment:密码" json:"-"`               // 密码
-	Nickname    string         `gorm:"type:varchar(50);comment:昵称" json:"nickname"`                  // 昵称
-	RealName    string         `gorm:"type:varchar(50);comment:真实姓名" json:"realName"`                // 真实姓名
-	Avatar      string         `gorm:"type:varchar(255);comment:头像" json:"avatar"`                   // 头像
-	Email       string         `gorm:"type:varchar(100);comment:邮箱" json:"email"`                    // 邮箱
-	Mobile      string         `gorm:"type:varchar(20);comment:手机号" json:"mobile"`                   // 手机号
-	Status      uint           `gorm:"type:tinyint(1);default:1;comment:状态 1:启用 2:禁用" json:"status"` // 状态
-	RoleID      uint           `gorm:"comment:角色ID" json:"roleId"`                                   // 角色ID
-	LastLoginAt time.Time      `gorm:"comment:最后登录时间" json:"lastLoginAt"`                            // 最后登录时间
-	LastLoginIP string         `gorm:"type:varchar(50);comment:最后登录IP" json:"lastLoginIp"`           // 最后登录IP
+	ID          uint           `gorm:"primarykey" json:"id"`                                                                         // 主键ID
+	CreatedAt   time.Time      `json:"createdAt"`                                                                                    // 创建时间
+	UpdatedAt   time.Time      `json:"updatedAt"`                                                                                    // 更新时间
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`                                                                               // 删除时间
+	TenantID    uint           `gorm:"not null;default:0;uniqueIndex:idx_admins_tenant_username;comment:租户ID" json:"tenantId"`       // 租户ID
+	UUID        uuid.UUID      `gorm:"type:char(36);index;comment:用户UUID" json:"uuid"`                                               // 用户UUID
+	Username    string         `gorm:"type:varchar(50);not null;uniqueIndex:idx_admins_tenant_username;comment:用户名" json:"username"` // 用户名
+	Password    string         `gorm:"type:varchar(100);not null;comment:密码" json:"-"`                                               // 密码
+	Nickname    string         `gorm:"type:varchar(50);comment:昵称" json:"nickname"`                                                  // 昵称
+	RealName    string         `gorm:"type:varchar(50);comment:真实姓名" json:"realName"`                                                // 真实姓名
+	Avatar      string         `gorm:"type:varchar(255);comment:头像" json:"avatar"`                                                   // 头像
+	Email       string         `gorm:"type:varchar(100);comment:邮箱" json:"email"`                                                    // 邮箱
+	Mobile      string         `gorm:"type:varchar(20);comment:手机号" json:"mobile"`                                                   // 手机号
+	Status      uint           `gorm:"type:tinyint(1);default:1;comment:状态 1:启用 2:禁用" json:"status"`                                 // 状态
+	RoleID      uint           `gorm:"comment:角色ID" json:"roleId"`                                                                   // 角色ID
+	LastLoginAt time.Time      `gorm:"comment:最后登录时间" json:"lastLoginAt"`                                                            // 最后登录时间
+	LastLoginIP string         `gorm:"type:varchar(50);comment:最后登录IP" json:"lastLoginIp"`                                           // 最后登录IP
+	Version     uint           `gorm:"not null;default:1;comment:版本号" json:"version"`                                                // 版本号，用于乐观锁
 }
 
 // Role 角色模型
 type Role struct {
-	ID        uint           `gorm:"primarykey" json:"id"`                                         // 主键ID
-	CreatedAt time.Time      `json:"createdAt"`                                                    // 创建时间
-	UpdatedAt time.Time      `json:"updatedAt"`                                                    // 更新时间
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`                                               // 删除时间
-	Name      string         `gorm:"type:varchar(50);not null;comment:角色名称" json:"name"`           // 角色名称
-	Code      string         `gorm:"type:varchar(50);not null;unique;comment:角色编码" json:"code"`    // 角色编码
-	Sort      uint           `gorm:"default:0;comment:排序" json:"sort"`                             // 排序
-	Status    uint           `gorm:"type:tinyint(1);default:1;comment:状态 1:启用 2:禁用" json:"status"` // 状态
-	Remark    string         `gorm:"type:varchar(255);comment:备注" json:"remark"`                   // 备注
-	Menus     []*Menu        `gorm:"many2many:role_menus;" json:"menus"`                           // 角色菜单关联
+	ID        uint           `gorm:"primarykey" json:"id"`                                                                 // 主键ID
+	CreatedAt time.Time      `json:"createdAt"`                                                                            // 创建时间
+	UpdatedAt time.Time      `json:"updatedAt"`                                                                            // 更新时间
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`                                                                       // 删除时间
+	TenantID  uint           `gorm:"not null;default:0;uniqueIndex:idx_roles_tenant_code;comment:租户ID" json:"tenantId"`    // 租户ID
+	Name      string         `gorm:"type:varchar(50);not null;comment:角色名称" json:"name"`                                   // 角色名称
+	Code      string         `gorm:"type:varchar(50);not null;uniqueIndex:idx_roles_tenant_code;comment:角色编码" json:"code"` // 角色编码
+	Sort      uint           `gorm:"default:0;comment:排序" json:"sort"`                                                     // 排序
+	Status    uint           `gorm:"type:tinyint(1);default:1;comment:状态 1:启用 2:禁用" json:"status"`                         // 状态
+	Remark    string         `gorm:"type:varchar(255);comment:备注" json:"remark"`                                           // 备注
+	Version   uint           `gorm:"not null;default:1;comment:版本号" json:"version"`                                        // 版本号，用于乐观锁
+	Menus     []*Menu        `gorm:"many2many:role_menus;" json:"menus"`                                                   // 角色菜单关联
 }
 
 // Menu 菜单模型 - 重构符合前端要求的菜单结构
@@ -49,6 +56,7 @@ type Menu struct {
 	CreatedAt    time.Time      `json:"-"`                                                                     // 创建时间
 	UpdatedAt    time.Time      `json:"-"`                                                                     // 更新时间
 	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`                                                        // 删除时间
+	TenantID     uint           `gorm:"not null;default:0;index;comment:租户ID" json:"-"`                        // 租户ID
 	PID          *uint          `gorm:"column:parent_id;default:null;comment:父菜单ID" json:"pid"`                // 父菜单ID，顶级菜单为null
 	Name         string         `gorm:"type:varchar(50);not null;comment:路由名称" json:"name"`                    // 路由名称(唯一标识)
 	Path         string         `gorm:"type:varchar(100);comment:路由路径" json:"path"`                            // 路由路径
@@ -64,6 +72,7 @@ type Menu struct {
 	PinTab       bool           `gorm:"default:false;comment:是否固定在标签页" json:"pinTab"`                          // 是否固定在标签页
 	MenuType     string         `gorm:"type:varchar(10);default:'page';comment:菜单类型 page|dir" json:"menuType"` // 菜单类型
 	Status       uint           `gorm:"type:tinyint(1);default:1;comment:状态 1:启用 2:禁用" json:"-"`               // 状态
+	Version      uint           `gorm:"not null;default:1;comment:版本号" json:"version"`                         // 版本号，用于乐观锁
 	Roles        []*Role        `gorm:"many2many:role_menus;" json:"-"`                                        // 菜单角色关联
 }
 
@@ -79,6 +88,19 @@ type RoleMenu struct {
 	MenuID uint `gorm:"primarykey;comment:菜单ID" json:"menuId"`
 }
 
+// CacheAudit 缓存管理操作记录，记录删除键、按模式删除和清空命名空间等破坏性操作
+type CacheAudit struct {
+	ID        uint      `gorm:"primarykey" json:"id"`                              // 主键ID
+	CreatedAt time.Time `gorm:"index" json:"createdAt"`                            // 操作时间
+	AdminID   uint      `gorm:"index;comment:管理员ID" json:"adminId"`                // 管理员ID
+	Username  string    `gorm:"type:varchar(50);comment:管理员用户名" json:"username"`   // 管理员用户名
+	IP        string    `gorm:"type:varchar(50);comment:操作IP" json:"ip"`           // 操作IP
+	Action    string    `gorm:"type:varchar(20);index;comment:操作类型" json:"action"` // 操作类型：delete、delete_pattern、flush_namespace
+	Target    string    `gorm:"type:text;comment:操作对象" json:"target"`              // 删除的键、模式或命名空间
+	Affected  int64     `gorm:"comment:删除的键数量" json:"affected"`                    // 删除的键数量
+	Error     string    `gorm:"type:text;comment:失败原因" json:"error"`               // 失败原因，成功时为空
+}
+
 // HashPassword 加密密码
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -91,187 +113,13 @@ func CheckPassword(password, hash string) bool {
 	return err == nil
 }
 
-// CreateDefaultAdminIfNotExists 创建默认管理员账号
-func CreateDefaultAdminIfNotExists(db *gorm.DB) error {
-	var count int64
-	db.Model(&Admin{}).Count(&count)
-	if count > 0 {
-		return nil
-	}
-
-	// 创建默认管理员角色
-	adminRole := &Role{
-		Name:   "超级管理员",
-		Code:   "super",
-		Sort:   1,
-		Status: 1,
-		Remark: "系统默认创建的超级管理员角色",
-	}
-	if err := db.Create(adminRole).Error; err != nil {
-		return err
-	}
-
-	// 创建默认仪表盘目录
-	dashboardMenu := &Menu{
-		PID:          nil,
-		Name:         "dashboard",
-		Path:         "/dashboard",
-		Component:    "LAYOUT",
-		Icon:         "icon-park-outline:analysis",
-		Title:        "仪表盘",
-		Order:        1,
-		RequiresAuth: true,
-		MenuType:     "dir",
-		Status:       1,
-	}
-	if err := db.Create(dashboardMenu).Error; err != nil {
-		return err
-	}
-
-	// 创建仪表盘子菜单 - 只保留工作台
-	workbenchMenu := &Menu{
-		PID:          &dashboardMenu.ID,
-		Name:         "workbench",
-		Path:         "/dashboard/workbench",
-		Component:    "/dashboard/workbench/index.vue",
-		Icon:         "icon-park-outline:alarm",
-		Title:        "工作台",
-		Order:        1,
-		RequiresAuth: true,
-		PinTab:       true,
-		MenuType:     "page",
-		Status:       1,
-	}
-	if err := db.Create(workbenchMenu).Error; err != nil {
-		return err
-	}
-
-	// 创建系统设置目录
-	settingMenu := &Menu{
-		PID:          nil,
-		Name:         "setting",
-		Path:         "/setting",
-		Component:    "",
-		Icon:         "icon-park-outline:setting",
-		Title:        "系统设置",
-		Order:        2,
-		RequiresAuth: true,
-		MenuType:     "dir",
-		Status:       1,
-	}
-	if err := db.Create(settingMenu).Error; err != nil {
-		return err
-	}
-
-	// 创建菜单管理
-	menuSettingMenu := &Menu{
-		PID:          &settingMenu.ID,
-		Name:         "menuSetting",
-		Path:         "/setting/menu",
-		Component:    "/setting/menu/index.vue",
-		Icon:         "icon-park-outline:application-menu",
-		Title:        "菜单管理",
-		Order:        1,
-		RequiresAuth: true,
-		MenuType:     "page",
-		Status:       1,
-	}
-	if err := db.Create(menuSettingMenu).Error; err != nil {
-		return err
-	}
-
-	// 创建角色管理
-	roleSettingMenu := &Menu{
-		PID:          &settingMenu.ID,
-		Name:         "roleSetting",
-		Path:         "/setting/role",
-		Component:    "/setting/role/index.vue",
-		Icon:         "icon-park-outline:people-safe",
-		Title:        "角色管理",
-		Order:        2,
-		RequiresAuth: true,
-		MenuType:     "page",
-		Status:       1,
-	}
-	if err := db.Create(roleSettingMenu).Error; err != nil {
-		return err
-	}
-
-	// 创建管理员管理
-	adminSettingMenu := &Menu{
-		PID:          &settingMenu.ID,
-		Name:         "adminSetting",
-		Path:         "/setting/admin",
-		Component:    "/setting/account/index.vue",
-		Icon:         "icon-park-outline:every-user",
-		Title:        "管理员管理",
-		Order:        3,
-		RequiresAuth: true,
-		MenuType:     "page",
-		Status:       1,
-	}
-	if err := db.Create(adminSettingMenu).Error; err != nil {
-		return err
-	}
-
-	// 创建代码生成器菜单
-	codegenMenu := &Menu{
-		PID:          &settingMenu.ID,
-		Name:         "codegen",
-		Path:         "/setting/codegen",
-		Component:    "/setting/codegen/index.vue",
-		Icon:         "icon-park-outline:code",
-		Title:        "代码生成器",
-		Order:        4,
-		RequiresAuth: true,
-		MenuType:     "page",
-		Status:       1,
-	}
-	if err := db.Create(codegenMenu).Error; err != nil {
-		return err
-	}
-
-	// 关联角色和菜单
-	roleMenus := []RoleMenu{
-		{RoleID: adminRole.ID, MenuID: dashboardMenu.ID},
-		{RoleID: adminRole.ID, MenuID: workbenchMenu.ID},
-		{RoleID: adminRole.ID, MenuID: settingMenu.ID},
-		{RoleID: adminRole.ID, MenuID: menuSettingMenu.ID},
-		{RoleID: adminRole.ID, MenuID: roleSettingMenu.ID},
-		{RoleID: adminRole.ID, MenuID: adminSettingMenu.ID},
-		{RoleID: adminRole.ID, MenuID: codegenMenu.ID},
-	}
-
-	if err := db.Create(&roleMenus).Error; err != nil {
-		return err
-	}
-
-	// 创建默认管理员账号
-	hashedPassword, err := HashPassword("admin123")
-	if err != nil {
-		return err
-	}
-
-	admin := &Admin{
-		UUID:     uuid.New(),
-		Username: "admin",
-		Password: hashedPassword,
-		Nickname: "管理员",
-		RealName: "系统管理员",
-		Avatar:   "https://avatar.vercel.sh/admin.svg?text=A",
-		Email:    "admin@example.com",
-		Status:   1,
-		RoleID:   adminRole.ID,
-	}
-
-	return db.Create(admin).Error
-}
-
 // GetRoles 获取管理员角色列表
 func (u *Admin) GetRoles() []string {
-	// 查询用户角色
+	// 查询用户角色，每次请求鉴权都会调用，启用模型缓存时读取缓存；
+	// 超级租户的账号可能在其他租户中操作，按管理员所属租户查询角色
 	var role Role
-	if err := facades.DB().First(&role, u.RoleID).Error; err != nil {
+	db := facades.DB().WithContext(tenant.SkipScope(context.Background()))
+	if err := db.Scopes(dbcache.Cached(0)).Where("tenant_id = ?", u.TenantID).First(&role, u.RoleID).Error; err != nil {
 		return []string{}
 	}
 	return []string{role.Code}
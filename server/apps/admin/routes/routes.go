@@ -4,6 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/zhoudm1743/go-web/apps/admin/controllers"
 	"github.com/zhoudm1743/go-web/apps/admin/middlewares"
+	"github.com/zhoudm1743/go-web/core/middleware"
 )
 
 // InitRoutes 初始化路由
@@ -14,6 +15,8 @@ func RegisterRoutes(r *gin.RouterGroup) {
 	menuController := controllers.NewMenuController()
 	roleController := controllers.NewRoleController()
 	codeGenController := controllers.NewCodeGenController()
+	diagnosticsController := controllers.NewDiagnosticsController()
+	cacheController := controllers.NewCacheController()
 
 	publicRoutes := r
 	{
@@ -27,6 +30,8 @@ func RegisterRoutes(r *gin.RouterGroup) {
 	// 私有路由
 	privateRoutes := r.Group("/admin")
 	privateRoutes.Use(middlewares.AdminAuth())
+	// 写请求在事务中执行，失败时整体回滚
+	privateRoutes.Use(middleware.Transaction())
 	{
 		// 认证相关路由
 		privateRoutes.GET("/me", authController.GetUserInfo)
@@ -37,6 +42,9 @@ func RegisterRoutes(r *gin.RouterGroup) {
 		privateRoutes.POST("/admin", adminController.CreateAdmin)
 		privateRoutes.PUT("/admin", adminController.UpdateAdmin)
 		privateRoutes.DELETE("/admin/:id", adminController.DeleteAdmin)
+		privateRoutes.GET("/admins/trash", adminController.GetTrashedAdmins)
+		privateRoutes.PUT("/admin/:id/restore", adminController.RestoreAdmin)
+		privateRoutes.DELETE("/admin/:id/force", adminController.ForceDeleteAdmin)
 
 		// 菜单路由
 		privateRoutes.GET("/menus", menuController.GetMenus)
@@ -44,16 +52,42 @@ func RegisterRoutes(r *gin.RouterGroup) {
 		privateRoutes.POST("/menu", menuController.CreateMenu)
 		privateRoutes.PUT("/menu", menuController.UpdateMenu)
 		privateRoutes.DELETE("/menu/:id", menuController.DeleteMenu)
+		privateRoutes.GET("/menus/trash", menuController.GetTrashedMenus)
+		privateRoutes.PUT("/menu/:id/restore", menuController.RestoreMenu)
+		privateRoutes.DELETE("/menu/:id/force", menuController.ForceDeleteMenu)
 
 		// 角色路由
 		privateRoutes.GET("/roles", roleController.GetRoles)
 		privateRoutes.POST("/role", roleController.CreateRole)
 		privateRoutes.PUT("/role", roleController.UpdateRole)
 		privateRoutes.DELETE("/role/:id", roleController.DeleteRole)
+		privateRoutes.GET("/roles/trash", roleController.GetTrashedRoles)
+		privateRoutes.PUT("/role/:id/restore", roleController.RestoreRole)
+		privateRoutes.DELETE("/role/:id/force", roleController.ForceDeleteRole)
 		// privateRoutes.PUT("/role/menu", roleController.AssignMenu)
 
+		// 诊断路由，诊断数据包含所有租户的数据，只允许平台管理员查看
+		diagnosticsRoutes := privateRoutes.Group("/diagnostics", middlewares.SuperOnly("只有平台管理员可以查看诊断信息"))
+		diagnosticsRoutes.GET("/slow-queries", diagnosticsController.GetSlowQueries)
+		diagnosticsRoutes.DELETE("/slow-queries", diagnosticsController.ResetSlowQueries)
+		diagnosticsRoutes.GET("/db", diagnosticsController.GetDBHealth)
+
 		// 代码生成器路由
 		codeGenController.RegisterRoutes(privateRoutes)
 
 	}
+
+	// 缓存管理路由，删除的键无法回滚，不使用事务中间件
+	cacheRoutes := r.Group("/admin/cache")
+	cacheRoutes.Use(middlewares.AdminAuth(), middlewares.SuperOnly("只有平台管理员可以管理缓存"))
+	{
+		cacheRoutes.GET("/stats", cacheController.GetStats)
+		cacheRoutes.DELETE("/stats", cacheController.ResetStats)
+		cacheRoutes.GET("/keys", cacheController.GetKeys)
+		cacheRoutes.GET("/key", cacheController.GetKey)
+		cacheRoutes.DELETE("/keys", cacheController.DeleteKeys)
+		cacheRoutes.DELETE("/pattern", cacheController.DeletePattern)
+		cacheRoutes.DELETE("/namespace", cacheController.FlushNamespace)
+		cacheRoutes.GET("/audits", cacheController.GetAudits)
+	}
 }
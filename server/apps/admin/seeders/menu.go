@@ -0,0 +1,95 @@
+package seeders
+
+import (
+	"fmt"
+
+	"github.com/zhoudm1743/go-web/apps/admin/models"
+	"github.com/zhoudm1743/go-web/core/seeder"
+	"gorm.io/gorm"
+)
+
+// MenuSeed 菜单填充数据
+type MenuSeed struct {
+	Name         string   // 路由名称，作为唯一键
+	Parent       string   // 父菜单路由名称，为空表示顶级菜单
+	Path         string   // 路由路径
+	Component    string   // 组件路径
+	Icon         string   // 图标
+	Title        string   // 标题
+	Order        int      // 排序值
+	MenuType     string   // 菜单类型 page|dir，默认page
+	PinTab       bool     // 是否固定在标签页
+	Hidden       bool     // 是否隐藏
+	KeepAlive    bool     // 是否缓存
+	Roles        []string // 授权的角色编码，默认授权给超级管理员
+	RequiresAuth *bool    // 是否需要认证，默认true
+}
+
+// MenuOrder 菜单填充的默认执行顺序，需在角色填充之后
+const MenuOrder = 20
+
+// RegisterMenus 注册菜单填充，生成的模块可通过它贡献自己的菜单
+func RegisterMenus(name string, menus ...MenuSeed) {
+	seeder.Register(&seeder.Seeder{
+		Name:  name,
+		Order: MenuOrder,
+		Run: func(tx *gorm.DB) error {
+			return SeedMenus(tx, menus...)
+		},
+	})
+}
+
+// SeedMenus 按名称写入菜单并关联角色，已存在的菜单不会被覆盖
+func SeedMenus(tx *gorm.DB, menus ...MenuSeed) error {
+	for _, item := range menus {
+		menu := &models.Menu{
+			Name:         item.Name,
+			Path:         item.Path,
+			Component:    item.Component,
+			Icon:         item.Icon,
+			Title:        item.Title,
+			Order:        item.Order,
+			Hidden:       item.Hidden,
+			KeepAlive:    item.KeepAlive,
+			RequiresAuth: true,
+			PinTab:       item.PinTab,
+			MenuType:     item.MenuType,
+			Status:       1,
+		}
+		if menu.MenuType == "" {
+			menu.MenuType = "page"
+		}
+		if item.RequiresAuth != nil {
+			menu.RequiresAuth = *item.RequiresAuth
+		}
+
+		// 解析父菜单
+		if item.Parent != "" {
+			var parent models.Menu
+			if err := tx.Where("name = ?", item.Parent).First(&parent).Error; err != nil {
+				return fmt.Errorf("菜单 %s 的父菜单 %s 不存在: %w", item.Name, item.Parent, err)
+			}
+			menu.PID = &parent.ID
+		}
+
+		if err := seeder.Upsert(tx, menu, []string{"Name"}); err != nil {
+			return fmt.Errorf("写入菜单 %s 失败: %w", item.Name, err)
+		}
+
+		// 关联角色
+		roles := item.Roles
+		if len(roles) == 0 {
+			roles = []string{SuperRoleCode}
+		}
+		for _, code := range roles {
+			var role models.Role
+			if err := tx.Where("code = ?", code).First(&role).Error; err != nil {
+				return fmt.Errorf("菜单 %s 关联的角色 %s 不存在: %w", item.Name, code, err)
+			}
+			if err := seeder.Upsert(tx, &models.RoleMenu{RoleID: role.ID, MenuID: menu.ID}, []string{"RoleID", "MenuID"}); err != nil {
+				return fmt.Errorf("关联菜单 %s 与角色 %s 失败: %w", item.Name, code, err)
+			}
+		}
+	}
+	return nil
+}
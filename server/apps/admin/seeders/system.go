@@ -0,0 +1,192 @@
+package seeders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	gormadapter "github.com/casbin/gorm-adapter/v3"
+	"github.com/google/uuid"
+	"github.com/zhoudm1743/go-web/apps/admin/models"
+	"github.com/zhoudm1743/go-web/core/seeder"
+	"github.com/zhoudm1743/go-web/core/tenant"
+	"gorm.io/gorm"
+)
+
+// SuperRoleCode 超级管理员角色编码
+const SuperRoleCode = "super"
+
+func init() {
+	// 数据文件中可引用的模型
+	seeder.RegisterModel("admins", &models.Admin{})
+	seeder.RegisterModel("roles", &models.Role{})
+	seeder.RegisterModel("menus", &models.Menu{})
+	seeder.RegisterModel("role_menus", &models.RoleMenu{})
+
+	seeder.Register(
+		&seeder.Seeder{Name: "admin:roles", Order: 10, Run: seedRoles},
+		&seeder.Seeder{Name: "admin:account", Order: 30, Run: seedDefaultAdmin},
+		&seeder.Seeder{Name: "admin:policies", Order: 40, Run: seedPolicies},
+		&seeder.Seeder{Name: "admin:demo", Order: 100, Envs: []string{"dev", "test"}, Run: seedDemo},
+	)
+
+	// 系统菜单
+	RegisterMenus("admin:menus",
+		MenuSeed{Name: "dashboard", Path: "/dashboard", Component: "LAYOUT", Icon: "icon-park-outline:analysis", Title: "仪表盘", Order: 1, MenuType: "dir"},
+		MenuSeed{Name: "workbench", Parent: "dashboard", Path: "/dashboard/workbench", Component: "/dashboard/workbench/index.vue", Icon: "icon-park-outline:alarm", Title: "工作台", Order: 1, PinTab: true},
+		MenuSeed{Name: "setting", Path: "/setting", Icon: "icon-park-outline:setting", Title: "系统设置", Order: 2, MenuType: "dir"},
+		MenuSeed{Name: "menuSetting", Parent: "setting", Path: "/setting/menu", Component: "/setting/menu/index.vue", Icon: "icon-park-outline:application-menu", Title: "菜单管理", Order: 1},
+		MenuSeed{Name: "roleSetting", Parent: "setting", Path: "/setting/role", Component: "/setting/role/index.vue", Icon: "icon-park-outline:people-safe", Title: "角色管理", Order: 2},
+		MenuSeed{Name: "adminSetting", Parent: "setting", Path: "/setting/admin", Component: "/setting/account/index.vue", Icon: "icon-park-outline:every-user", Title: "管理员管理", Order: 3},
+		MenuSeed{Name: "codegen", Parent: "setting", Path: "/setting/codegen", Component: "/setting/codegen/index.vue", Icon: "icon-park-outline:code", Title: "代码生成器", Order: 4},
+	)
+}
+
+// seedRoles 创建超级管理员角色
+func seedRoles(tx *gorm.DB) error {
+	return seeder.Upsert(tx, &models.Role{
+		Name:   "超级管理员",
+		Code:   SuperRoleCode,
+		Sort:   1,
+		Status: 1,
+		Remark: "系统默认创建的超级管理员角色",
+	}, []string{"Code"})
+}
+
+// defaultAdminPassword 首次启动时超级租户默认管理员的密码，登录后应立即修改
+const defaultAdminPassword = "admin123"
+
+// ErrAdminPassword 为超级租户以外的租户创建默认管理员时没有指定密码
+var ErrAdminPassword = errors.New("未指定默认管理员密码")
+
+type adminPasswordKey struct{}
+
+// WithAdminPassword 指定填充时创建的默认管理员的密码
+//
+// 超级租户未指定时使用固定的初始密码，其他租户必须指定，避免每个租户都有已知的账号密码。
+func WithAdminPassword(ctx context.Context, password string) context.Context {
+	return context.WithValue(ctx, adminPasswordKey{}, password)
+}
+
+// adminPassword 返回默认管理员的密码
+func adminPassword(ctx context.Context) (string, error) {
+	if password, _ := ctx.Value(adminPasswordKey{}).(string); password != "" {
+		return password, nil
+	}
+	if t := tenant.FromContext(ctx); t != nil && !tenant.IsSuper(t) {
+		return "", fmt.Errorf("租户 %s: %w", t.Code, ErrAdminPassword)
+	}
+	return defaultAdminPassword, nil
+}
+
+// seedDefaultAdmin 没有任何管理员时创建默认管理员账号
+func seedDefaultAdmin(tx *gorm.DB) error {
+	var count int64
+	if err := tx.Unscoped().Model(&models.Admin{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	password, err := adminPassword(tx.Statement.Context)
+	if err != nil {
+		return err
+	}
+
+	var role models.Role
+	if err := tx.Where("code = ?", SuperRoleCode).First(&role).Error; err != nil {
+		return err
+	}
+
+	return createAdmin(tx, &models.Admin{
+		Username: "admin",
+		Nickname: "管理员",
+		RealName: "系统管理员",
+		Avatar:   "https://avatar.vercel.sh/admin.svg?text=A",
+		Email:    "admin@example.com",
+		Status:   1,
+		RoleID:   role.ID,
+	}, password)
+}
+
+// seedPolicies 为当前租户的超级管理员角色授予全部接口权限
+//
+// 超级租户的超级管理员可以切换到任意租户操作，策略的域为 *；其他租户的策略只在本租户的域中生效。
+// 直接写入策略表而不通过 casbin 的 enforcer，保证在填充事务中执行，
+// 运行中的服务需重新加载策略后生效。
+func seedPolicies(tx *gorm.DB) error {
+	t := tenant.FromContext(tx.Statement.Context)
+	if t == nil {
+		return nil
+	}
+
+	var role models.Role
+	if err := tx.Where("code = ?", SuperRoleCode).First(&role).Error; err != nil {
+		return err
+	}
+
+	if err := tx.AutoMigrate(&gormadapter.CasbinRule{}); err != nil {
+		return err
+	}
+	domain := t.Domain()
+	if tenant.IsSuper(t) {
+		domain = "*"
+	}
+	return seeder.Upsert(tx, &gormadapter.CasbinRule{
+		Ptype: "p",
+		V0:    strconv.FormatUint(uint64(role.ID), 10),
+		V1:    domain,
+		V2:    "/*",
+		V3:    "*",
+	}, []string{"Ptype", "V0", "V1", "V2", "V3"})
+}
+
+// seedDemo 开发环境演示数据
+func seedDemo(tx *gorm.DB) error {
+	role := &models.Role{
+		Name:   "演示角色",
+		Code:   "demo",
+		Sort:   10,
+		Status: 1,
+		Remark: "开发环境演示数据",
+	}
+	if err := seeder.Upsert(tx, role, []string{"Code"}); err != nil {
+		return err
+	}
+
+	if err := SeedMenus(tx, MenuSeed{
+		Name: "workbench", Parent: "dashboard", Roles: []string{role.Code},
+	}); err != nil {
+		return err
+	}
+
+	var exists int64
+	if err := tx.Unscoped().Model(&models.Admin{}).Where("username = ?", "demo").Count(&exists).Error; err != nil {
+		return err
+	}
+	if exists > 0 {
+		return nil
+	}
+	return createAdmin(tx, &models.Admin{
+		Username: "demo",
+		Nickname: "演示账号",
+		RealName: "演示账号",
+		Avatar:   "https://avatar.vercel.sh/demo.svg?text=D",
+		Email:    "demo@example.com",
+		Status:   1,
+		RoleID:   role.ID,
+	}, "demo123")
+}
+
+// createAdmin 创建管理员并加密密码
+func createAdmin(tx *gorm.DB, admin *models.Admin, password string) error {
+	hashedPassword, err := models.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	admin.UUID = uuid.New()
+	admin.Password = hashedPassword
+	return tx.Create(admin).Error
+}
@@ -1,11 +1,13 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"time"
 
 	"github.com/zhoudm1743/go-web/apps/admin/models"
 	"github.com/zhoudm1743/go-web/core/facades"
+	"github.com/zhoudm1743/go-web/core/tenant"
 	"github.com/zhoudm1743/go-web/core/utils"
 )
 
@@ -17,10 +19,10 @@ func NewAuthService() *AuthService {
 	return &AuthService{}
 }
 
-// Login 用户登录
-func (s *AuthService) Login(username, password string) (*models.Admin, string, error) {
+// Login 用户登录，在上下文中的租户内查找账号
+func (s *AuthService) Login(ctx context.Context, username, password string) (*models.Admin, string, error) {
 	var admin models.Admin
-	db := facades.DB()
+	db := facades.DBContext(ctx)
 
 	// 查询管理员信息
 	if err := db.Where("username = ?", username).First(&admin).Error; err != nil {
@@ -38,22 +40,23 @@ func (s *AuthService) Login(username, password string) (*models.Admin, string, e
 	}
 
 	// 生成JWT Token
-	token, err := utils.GenerateToken(int(admin.ID), admin.Username, int(admin.RoleID))
+	token, err := utils.GenerateTenantToken(admin.TenantID, int(admin.ID), admin.Username, int(admin.RoleID))
 	if err != nil {
 		return nil, "", err
 	}
 
-	// 更新登录信息
+	// 更新登录信息，只更新登录字段，不改变版本号
 	admin.LastLoginAt = time.Now()
-	db.Save(&admin)
+	db.Model(&admin).UpdateColumn("last_login_at", admin.LastLoginAt)
 
 	return &admin, token, nil
 }
 
 // GetUserInfo 获取用户信息
-func (s *AuthService) GetUserInfo(userID int) (*models.Admin, error) {
+func (s *AuthService) GetUserInfo(ctx context.Context, userID int) (*models.Admin, error) {
 	var admin models.Admin
-	db := facades.DB()
+	// 超级租户的账号切换到其他租户时不属于当前租户，按ID查询时不过滤租户
+	db := facades.DBContext(tenant.SkipScope(ctx))
 
 	if err := db.First(&admin, userID).Error; err != nil {
 		return nil, errors.New("用户不存在")
@@ -70,18 +73,20 @@ func (s *AuthService) GetUserAccessCodes(userID int) ([]string, error) {
 }
 
 // GetUserMenus 获取用户菜单
-func (s *AuthService) GetUserMenus(userID int) ([]models.Menu, error) {
+func (s *AuthService) GetUserMenus(ctx context.Context, userID int) ([]models.Menu, error) {
 	var admin models.Admin
-	db := facades.DB()
+	db := facades.DBContext(ctx)
+	// 账号和角色可能属于超级租户，按ID查询时不过滤租户
+	unscoped := facades.DBContext(tenant.SkipScope(ctx))
 
 	// 查询用户信息
-	if err := db.First(&admin, userID).Error; err != nil {
+	if err := unscoped.First(&admin, userID).Error; err != nil {
 		return nil, errors.New("用户不存在")
 	}
 
 	// 查询用户角色
 	var role models.Role
-	if err := db.First(&role, admin.RoleID).Error; err != nil {
+	if err := unscoped.First(&role, admin.RoleID).Error; err != nil {
 		return nil, errors.New("角色不存在")
 	}
 
@@ -109,9 +114,9 @@ func (s *AuthService) GetUserMenus(userID int) ([]models.Menu, error) {
 }
 
 // GetAllMenus 获取所有菜单
-func (s *AuthService) GetAllMenus() ([]models.Menu, error) {
+func (s *AuthService) GetAllMenus(ctx context.Context) ([]models.Menu, error) {
 	var menus []models.Menu
-	db := facades.DB()
+	db := facades.DBContext(ctx)
 
 	// 查询所有菜单
 	if err := db.Order("`order` asc").Find(&menus).Error; err != nil {
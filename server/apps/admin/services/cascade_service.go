@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	gormadapter "github.com/casbin/gorm-adapter/v3"
+	"github.com/zhoudm1743/go-web/apps/admin/dto"
+	"github.com/zhoudm1743/go-web/apps/admin/models"
+	"github.com/zhoudm1743/go-web/apps/admin/seeders"
+	"github.com/zhoudm1743/go-web/core/database"
+	"github.com/zhoudm1743/go-web/core/facades"
+	"github.com/zhoudm1743/go-web/core/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrSuperRole 内置超级管理员角色不能删除
+	ErrSuperRole = errors.New("超级管理员角色不能删除")
+	// ErrRoleNotFound 角色不存在
+	ErrRoleNotFound = errors.New("角色不存在")
+	// ErrMenuNotFound 菜单不存在
+	ErrMenuNotFound = errors.New("菜单不存在")
+)
+
+// 关联数据的处理方式
+const (
+	ImpactDelete = "delete" // 删除
+	ImpactDetach = "detach" // 解除关联
+)
+
+// CascadeService 管理域实体的级联删除服务
+//
+// 删除前统计关联数据：存在阻止删除的关联数据且未开启级联时返回预览并标记为阻止，
+// 开启级联时在事务中一并处理。
+type CascadeService struct{}
+
+// NewCascadeService 创建级联删除服务
+func NewCascadeService() *CascadeService {
+	return &CascadeService{}
+}
+
+// DeleteRole 删除角色
+//
+// 使用该角色的管理员阻止删除，级联时解除关联；角色菜单、管理员角色关联和当前域的 Casbin 策略随角色删除。
+func (s *CascadeService) DeleteRole(ctx context.Context, id uint, query dto.DeleteQuery) (*dto.DeletePreview, error) {
+	db := facades.DBContext(ctx)
+
+	var role models.Role
+	if err := db.First(&role, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, fmt.Errorf("查询角色失败: %w", err)
+	}
+	if role.Code == seeders.SuperRoleCode {
+		return nil, ErrSuperRole
+	}
+
+	sub := strconv.FormatUint(uint64(role.ID), 10)
+	dom := utils.CasbinDomain(ctx)
+	policies := func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&gormadapter.CasbinRule{}).
+			Where("(ptype = 'p' AND v0 = ? AND v1 = ?) OR (ptype = 'g' AND v1 = ? AND v2 = ?)", sub, dom, sub, dom)
+	}
+
+	var admins []models.Admin
+	if err := db.Select("id", "username").Where("role_id = ?", role.ID).Order("id").Find(&admins).Error; err != nil {
+		return nil, fmt.Errorf("查询角色管理员失败: %w", err)
+	}
+	adminItems := make([]dto.DeleteItem, 0, len(admins))
+	for _, admin := range admins {
+		adminItems = append(adminItems, dto.DeleteItem{ID: admin.ID, Label: admin.Username})
+	}
+
+	plan := newDeletePlan(dto.DeleteItem{ID: role.ID, Label: role.Name}, query)
+	plan.add(dto.DeleteImpact{Table: "admins", Action: ImpactDetach, Count: int64(len(admins)), Blocking: true, Items: adminItems})
+	if err := plan.count(db.Model(&models.AdminRole{}).Where("role_id = ?", role.ID), "admin_roles"); err != nil {
+		return nil, err
+	}
+	if err := plan.count(db.Model(&models.RoleMenu{}).Where("role_id = ?", role.ID), "role_menus"); err != nil {
+		return nil, err
+	}
+	if err := plan.count(policies(db), "casbin_rule"); err != nil {
+		return nil, err
+	}
+	if plan.Blocked || plan.DryRun {
+		return plan.DeletePreview, nil
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if len(admins) > 0 {
+			if err := tx.Model(&models.Admin{}).Where("role_id = ?", role.ID).Update("role_id", 0).Error; err != nil {
+				return fmt.Errorf("解除管理员角色失败: %w", err)
+			}
+		}
+		if err := tx.Where("role_id = ?", role.ID).Delete(&models.AdminRole{}).Error; err != nil {
+			return fmt.Errorf("删除管理员角色关联失败: %w", err)
+		}
+		if err := tx.Where("role_id = ?", role.ID).Delete(&models.RoleMenu{}).Error; err != nil {
+			return fmt.Errorf("删除角色菜单关联失败: %w", err)
+		}
+		if err := policies(tx).Delete(&gormadapter.CasbinRule{}).Error; err != nil {
+			return fmt.Errorf("删除角色权限策略失败: %w", err)
+		}
+		return tx.Delete(&role).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 策略已通过事务删除，只需同步内存中的策略，避免 Casbin 适配器使用另一个连接写库；
+	// 处于请求事务中时等提交后再同步，请求回滚时内存中的策略与数据库保持一致
+	queued := database.AfterCommit(ctx, "casbin:"+sub, func() {
+		if err := removePolicies(sub, dom); err != nil {
+			if logger := facades.Log(); logger != nil {
+				logger.Errorf("同步角色 %s 的权限策略失败: %v", sub, err)
+			}
+		}
+	})
+	if !queued {
+		if err := removePolicies(sub, dom); err != nil {
+			return nil, err
+		}
+	}
+	plan.Deleted = true
+	return plan.DeletePreview, nil
+}
+
+// DeleteMenu 删除菜单
+//
+// 子菜单阻止删除，级联时连同所有下级菜单一起删除；角色菜单关联随菜单删除。
+func (s *CascadeService) DeleteMenu(ctx context.Context, id uint, query dto.DeleteQuery) (*dto.DeletePreview, error) {
+	db := facades.DBContext(ctx)
+
+	var menu models.Menu
+	if err := db.First(&menu, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMenuNotFound
+		}
+		return nil, fmt.Errorf("查询菜单失败: %w", err)
+	}
+
+	// 逐层查找所有下级菜单
+	ids := []uint{menu.ID}
+	var items []dto.DeleteItem
+	for parents := ids; len(parents) > 0; {
+		var children []models.Menu
+		if err := db.Select("id", "title", "name").Where("parent_id IN ?", parents).Order("id").Find(&children).Error; err != nil {
+			return nil, fmt.Errorf("查询子菜单失败: %w", err)
+		}
+		var next []uint
+		for _, child := range children {
+			next = append(next, child.ID)
+			items = append(items, dto.DeleteItem{ID: child.ID, Label: menuLabel(&child)})
+		}
+		ids = append(ids, next...)
+		parents = next
+	}
+
+	plan := newDeletePlan(dto.DeleteItem{ID: menu.ID, Label: menuLabel(&menu)}, query)
+	plan.add(dto.DeleteImpact{Table: "menus", Action: ImpactDelete, Count: int64(len(items)), Blocking: true, Items: items})
+	if err := plan.count(db.Model(&models.RoleMenu{}).Where("menu_id IN ?", ids), "role_menus"); err != nil {
+		return nil, err
+	}
+	if plan.Blocked || plan.DryRun {
+		return plan.DeletePreview, nil
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("menu_id IN ?", ids).Delete(&models.RoleMenu{}).Error; err != nil {
+			return fmt.Errorf("删除角色菜单关联失败: %w", err)
+		}
+		return tx.Delete(&models.Menu{}, ids).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	plan.Deleted = true
+	return plan.DeletePreview, nil
+}
+
+// deletePlan 删除预览的构建
+type deletePlan struct {
+	*dto.DeletePreview
+}
+
+// newDeletePlan 创建删除预览的构建
+func newDeletePlan(target dto.DeleteItem, query dto.DeleteQuery) deletePlan {
+	return deletePlan{&dto.DeletePreview{
+		Target:  target,
+		Cascade: query.Cascade,
+		DryRun:  query.DryRun,
+		Impacts: []dto.DeleteImpact{},
+	}}
+}
+
+// add 添加影响，未开启级联时阻止删除的关联数据会标记预览为阻止
+func (p deletePlan) add(impact dto.DeleteImpact) {
+	if impact.Blocking && impact.Count > 0 && !p.Cascade {
+		p.Blocked = true
+	}
+	p.Impacts = append(p.Impacts, impact)
+}
+
+// count 统计随删除一并删除的关联表记录
+func (p deletePlan) count(db *gorm.DB, table string) error {
+	var n int64
+	if err := db.Count(&n).Error; err != nil {
+		return fmt.Errorf("统计 %s 失败: %w", table, err)
+	}
+	p.add(dto.DeleteImpact{Table: table, Action: ImpactDelete, Count: n})
+	return nil
+}
+
+// menuLabel 菜单的显示名称
+func menuLabel(menu *models.Menu) string {
+	if menu.Title != "" {
+		return menu.Title
+	}
+	return menu.Name
+}
+
+// removePolicies 从内存中移除角色在域内的策略和角色继承关系
+func removePolicies(sub, dom string) error {
+	m := utils.Casbin().GetModel()
+	if _, _, err := m.RemoveFilteredPolicy("p", "p", 0, sub, dom); err != nil {
+		return fmt.Errorf("移除角色权限策略失败: %w", err)
+	}
+	removed, _, err := m.RemoveFilteredPolicy("g", "g", 1, sub, dom)
+	if err != nil {
+		return fmt.Errorf("移除角色继承关系失败: %w", err)
+	}
+	if removed {
+		return utils.Casbin().BuildRoleLinks()
+	}
+	return nil
+}
@@ -0,0 +1,325 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	gormadapter "github.com/casbin/gorm-adapter/v3"
+	"github.com/glebarez/sqlite"
+	"github.com/zhoudm1743/go-web/apps/admin/dto"
+	"github.com/zhoudm1743/go-web/apps/admin/models"
+	"github.com/zhoudm1743/go-web/apps/admin/seeders"
+	"github.com/zhoudm1743/go-web/core/database"
+	"github.com/zhoudm1743/go-web/core/facades"
+	"github.com/zhoudm1743/go-web/core/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupCascadeTest 创建测试数据库并设置为默认连接
+func setupCascadeTest(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "cascade.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	err = db.AutoMigrate(&models.Admin{}, &models.Role{}, &models.Menu{},
+		&models.AdminRole{}, &models.RoleMenu{}, &gormadapter.CasbinRule{})
+	if err != nil {
+		t.Fatalf("创建表失败: %v", err)
+	}
+	facades.SetDBManager(database.NewManagerWith(db))
+	return db
+}
+
+// mustCreate 写入测试数据
+func mustCreate(t *testing.T, db *gorm.DB, values ...interface{}) {
+	t.Helper()
+	for _, v := range values {
+		if err := db.Create(v).Error; err != nil {
+			t.Fatalf("写入测试数据失败: %v", err)
+		}
+	}
+}
+
+// count 统计表中未删除的记录数
+func count(t *testing.T, db *gorm.DB, model interface{}, query string, args ...interface{}) int64 {
+	t.Helper()
+	var n int64
+	if err := db.Model(model).Where(query, args...).Count(&n).Error; err != nil {
+		t.Fatalf("统计记录失败: %v", err)
+	}
+	return n
+}
+
+// impact 按表名查找预览中的影响
+func impact(t *testing.T, preview *dto.DeletePreview, table string) dto.DeleteImpact {
+	t.Helper()
+	for _, i := range preview.Impacts {
+		if i.Table == table {
+			return i
+		}
+	}
+	t.Fatalf("预览中缺少 %s: %+v", table, preview.Impacts)
+	return dto.DeleteImpact{}
+}
+
+// seedRole 创建带管理员、菜单和权限策略的角色，另一个角色的数据用于确认删除范围
+func seedRole(t *testing.T, db *gorm.DB) (*models.Role, *models.Admin) {
+	role := &models.Role{Name: "编辑", Code: "editor"}
+	other := &models.Role{Name: "访客", Code: "guest"}
+	mustCreate(t, db, role, other, &models.Menu{Name: "dashboard"})
+
+	admin := &models.Admin{Username: "alice", Password: "x", RoleID: role.ID}
+	mustCreate(t, db, admin)
+
+	sub, otherSub := strconv.Itoa(int(role.ID)), strconv.Itoa(int(other.ID))
+	mustCreate(t, db,
+		&models.AdminRole{AdminID: admin.ID, RoleID: role.ID},
+		&models.RoleMenu{RoleID: role.ID, MenuID: 1},
+		&models.RoleMenu{RoleID: other.ID, MenuID: 1},
+		&gormadapter.CasbinRule{Ptype: "p", V0: sub, V1: "*", V2: "/admin/*", V3: "GET"},
+		&gormadapter.CasbinRule{Ptype: "g", V0: "alice", V1: sub, V2: "*"},
+		&gormadapter.CasbinRule{Ptype: "p", V0: otherSub, V1: "*", V2: "/admin/*", V3: "GET"},
+	)
+	return role, admin
+}
+
+// TestDeleteRoleBlocked 测试角色仍有管理员时阻止删除
+func TestDeleteRoleBlocked(t *testing.T) {
+	db := setupCascadeTest(t)
+	role, admin := seedRole(t, db)
+
+	preview, err := NewCascadeService().DeleteRole(context.Background(), role.ID, dto.DeleteQuery{})
+	if err != nil {
+		t.Fatalf("删除角色失败: %v", err)
+	}
+	if !preview.Blocked || preview.Deleted {
+		t.Fatalf("存在管理员时应阻止删除: %+v", preview)
+	}
+
+	admins := impact(t, preview, "admins")
+	if admins.Action != ImpactDetach || admins.Count != 1 || !admins.Blocking ||
+		len(admins.Items) != 1 || admins.Items[0].ID != admin.ID || admins.Items[0].Label != "alice" {
+		t.Fatalf("管理员影响不正确: %+v", admins)
+	}
+	for table, want := range map[string]int64{"admin_roles": 1, "role_menus": 1, "casbin_rule": 2} {
+		if got := impact(t, preview, table); got.Count != want || got.Action != ImpactDelete || got.Blocking {
+			t.Fatalf("%s 影响不正确, 期望 %d, 实际 %+v", table, want, got)
+		}
+	}
+	if count(t, db, &models.Role{}, "id = ?", role.ID) != 1 {
+		t.Fatal("阻止删除时不应删除角色")
+	}
+}
+
+// TestDeleteRoleDryRun 测试预览不执行删除
+func TestDeleteRoleDryRun(t *testing.T) {
+	db := setupCascadeTest(t)
+	role, _ := seedRole(t, db)
+
+	preview, err := NewCascadeService().DeleteRole(context.Background(), role.ID, dto.DeleteQuery{Cascade: true, DryRun: true})
+	if err != nil {
+		t.Fatalf("预览失败: %v", err)
+	}
+	if preview.Blocked || preview.Deleted || !preview.DryRun || !preview.Cascade {
+		t.Fatalf("级联预览状态不正确: %+v", preview)
+	}
+	if preview.Target.ID != role.ID || preview.Target.Label != "编辑" {
+		t.Fatalf("预览目标不正确: %+v", preview.Target)
+	}
+	if len(preview.Impacts) != 4 || impact(t, preview, "admins").Count != 1 {
+		t.Fatalf("预览影响不正确: %+v", preview.Impacts)
+	}
+
+	if count(t, db, &models.Role{}, "id = ?", role.ID) != 1 ||
+		count(t, db, &models.Admin{}, "role_id = ?", role.ID) != 1 ||
+		count(t, db, &gormadapter.CasbinRule{}, "1 = 1") != 3 {
+		t.Fatal("预览不应修改数据")
+	}
+}
+
+// TestDeleteRoleCascade 测试级联删除角色时解除管理员并清理关联和策略
+func TestDeleteRoleCascade(t *testing.T) {
+	db := setupCascadeTest(t)
+	role, admin := seedRole(t, db)
+
+	sub := strconv.Itoa(int(role.ID))
+	m := utils.Casbin().GetModel()
+	if err := m.AddPolicy("p", "p", []string{sub, "*", "/admin/*", "GET"}); err != nil {
+		t.Fatalf("添加内存策略失败: %v", err)
+	}
+
+	preview, err := NewCascadeService().DeleteRole(context.Background(), role.ID, dto.DeleteQuery{Cascade: true})
+	if err != nil {
+		t.Fatalf("级联删除角色失败: %v", err)
+	}
+	if !preview.Deleted || preview.Blocked {
+		t.Fatalf("应删除角色: %+v", preview)
+	}
+
+	if count(t, db, &models.Role{}, "id = ?", role.ID) != 0 {
+		t.Fatal("角色未删除")
+	}
+	if count(t, db, &models.Admin{}, "id = ? AND role_id = 0", admin.ID) != 1 {
+		t.Fatal("管理员应保留并解除角色")
+	}
+	if count(t, db, &models.AdminRole{}, "role_id = ?", role.ID) != 0 {
+		t.Fatal("管理员角色关联未删除")
+	}
+	if count(t, db, &models.RoleMenu{}, "role_id = ?", role.ID) != 0 || count(t, db, &models.RoleMenu{}, "1 = 1") != 1 {
+		t.Fatal("应只删除该角色的菜单关联")
+	}
+	if count(t, db, &gormadapter.CasbinRule{}, "v0 = ? OR v1 = ?", sub, sub) != 0 || count(t, db, &gormadapter.CasbinRule{}, "1 = 1") != 1 {
+		t.Fatal("应只删除该角色的权限策略")
+	}
+	if has, _ := m.HasPolicy("p", "p", []string{sub, "*", "/admin/*", "GET"}); has {
+		t.Fatal("内存中的策略未移除")
+	}
+}
+
+// TestDeleteRoleRollback 测试删除失败时回滚所有修改
+func TestDeleteRoleRollback(t *testing.T) {
+	db := setupCascadeTest(t)
+	role, admin := seedRole(t, db)
+
+	boom := errors.New("boom")
+	err := db.Callback().Delete().Before("gorm:delete").Register("test:fail_roles", func(tx *gorm.DB) {
+		if tx.Statement.Table == "roles" {
+			_ = tx.AddError(boom)
+		}
+	})
+	if err != nil {
+		t.Fatalf("注册回调失败: %v", err)
+	}
+
+	if _, err := NewCascadeService().DeleteRole(context.Background(), role.ID, dto.DeleteQuery{Cascade: true}); !errors.Is(err, boom) {
+		t.Fatalf("应返回删除失败的错误, 实际: %v", err)
+	}
+
+	if count(t, db, &models.Role{}, "id = ?", role.ID) != 1 ||
+		count(t, db, &models.Admin{}, "id = ? AND role_id = ?", admin.ID, role.ID) != 1 ||
+		count(t, db, &models.AdminRole{}, "role_id = ?", role.ID) != 1 ||
+		count(t, db, &models.RoleMenu{}, "role_id = ?", role.ID) != 1 ||
+		count(t, db, &gormadapter.CasbinRule{}, "1 = 1") != 3 {
+		t.Fatal("删除失败时应回滚所有修改")
+	}
+}
+
+// TestDeleteRoleRequestRollback 测试请求事务回滚时保留内存中的策略，提交后才移除
+func TestDeleteRoleRequestRollback(t *testing.T) {
+	db := setupCascadeTest(t)
+	role, _ := seedRole(t, db)
+
+	sub := strconv.Itoa(int(role.ID))
+	policy := []string{sub, "*", "/admin/*", "GET"}
+	m := utils.Casbin().GetModel()
+	if err := m.AddPolicy("p", "p", policy); err != nil {
+		t.Fatalf("添加内存策略失败: %v", err)
+	}
+
+	// 与 middleware.Transaction 相同：开启提交后回调队列，在请求事务中删除
+	deleteInTx := func() (context.Context, *gorm.DB) {
+		ctx := database.WithAfterCommit(context.Background())
+		tx := db.Begin()
+		ctx = database.WithTx(ctx, tx)
+		if _, err := NewCascadeService().DeleteRole(ctx, role.ID, dto.DeleteQuery{Cascade: true}); err != nil {
+			t.Fatalf("级联删除角色失败: %v", err)
+		}
+		if has, _ := m.HasPolicy("p", "p", policy); !has {
+			t.Fatal("请求事务提交前不应移除内存中的策略")
+		}
+		return ctx, tx
+	}
+
+	_, tx := deleteInTx()
+	tx.Rollback()
+	if has, _ := m.HasPolicy("p", "p", policy); !has {
+		t.Fatal("请求事务回滚后应保留内存中的策略")
+	}
+	if count(t, db, &gormadapter.CasbinRule{}, "v0 = ?", sub) != 1 {
+		t.Fatal("请求事务回滚后应保留数据库中的策略")
+	}
+
+	ctx, tx := deleteInTx()
+	if err := tx.Commit().Error; err != nil {
+		t.Fatalf("提交事务失败: %v", err)
+	}
+	database.RunAfterCommit(ctx)
+	if has, _ := m.HasPolicy("p", "p", policy); has {
+		t.Fatal("请求事务提交后应移除内存中的策略")
+	}
+}
+
+// TestDeleteSuperRole 测试不能删除超级管理员角色
+func TestDeleteSuperRole(t *testing.T) {
+	db := setupCascadeTest(t)
+	role := &models.Role{Name: "超级管理员", Code: seeders.SuperRoleCode}
+	mustCreate(t, db, role)
+
+	_, err := NewCascadeService().DeleteRole(context.Background(), role.ID, dto.DeleteQuery{Cascade: true})
+	if !errors.Is(err, ErrSuperRole) {
+		t.Fatalf("应拒绝删除超级管理员角色, 实际: %v", err)
+	}
+	if _, err := NewCascadeService().DeleteRole(context.Background(), role.ID+1, dto.DeleteQuery{}); !errors.Is(err, ErrRoleNotFound) {
+		t.Fatalf("角色不存在时应返回 ErrRoleNotFound, 实际: %v", err)
+	}
+}
+
+// TestDeleteMenu 测试删除菜单时阻止、预览和递归删除下级菜单
+func TestDeleteMenu(t *testing.T) {
+	db := setupCascadeTest(t)
+
+	root := &models.Menu{Name: "system", Title: "系统管理"}
+	mustCreate(t, db, root)
+	child := &models.Menu{Name: "role", PID: &root.ID}
+	mustCreate(t, db, child)
+	grandchild := &models.Menu{Name: "role-edit", Title: "编辑角色", PID: &child.ID}
+	other := &models.Menu{Name: "home"}
+	mustCreate(t, db, grandchild, other)
+	mustCreate(t, db,
+		&models.RoleMenu{RoleID: 2, MenuID: root.ID},
+		&models.RoleMenu{RoleID: 2, MenuID: grandchild.ID},
+		&models.RoleMenu{RoleID: 2, MenuID: other.ID},
+	)
+
+	svc := NewCascadeService()
+	preview, err := svc.DeleteMenu(context.Background(), root.ID, dto.DeleteQuery{})
+	if err != nil {
+		t.Fatalf("删除菜单失败: %v", err)
+	}
+	if !preview.Blocked || preview.Deleted || preview.Target.Label != "系统管理" {
+		t.Fatalf("存在子菜单时应阻止删除: %+v", preview)
+	}
+	menus := impact(t, preview, "menus")
+	if menus.Count != 2 || len(menus.Items) != 2 ||
+		menus.Items[0] != (dto.DeleteItem{ID: child.ID, Label: "role"}) ||
+		menus.Items[1] != (dto.DeleteItem{ID: grandchild.ID, Label: "编辑角色"}) {
+		t.Fatalf("应列出所有下级菜单: %+v", menus)
+	}
+	if impact(t, preview, "role_menus").Count != 2 {
+		t.Fatal("应统计所有下级菜单的角色关联")
+	}
+
+	if preview, err = svc.DeleteMenu(context.Background(), grandchild.ID, dto.DeleteQuery{}); err != nil || !preview.Deleted {
+		t.Fatalf("没有子菜单时应直接删除: %+v, %v", preview, err)
+	}
+
+	if preview, err = svc.DeleteMenu(context.Background(), root.ID, dto.DeleteQuery{Cascade: true}); err != nil || !preview.Deleted {
+		t.Fatalf("级联删除菜单失败: %+v, %v", preview, err)
+	}
+	if count(t, db, &models.Menu{}, "1 = 1") != 1 || count(t, db, &models.Menu{}, "id = ?", other.ID) != 1 {
+		t.Fatal("应删除菜单及所有下级菜单")
+	}
+	if count(t, db, &models.RoleMenu{}, "1 = 1") != 1 || count(t, db, &models.RoleMenu{}, "menu_id = ?", other.ID) != 1 {
+		t.Fatal("应删除菜单及下级菜单的角色关联")
+	}
+
+	if _, err := svc.DeleteMenu(context.Background(), root.ID, dto.DeleteQuery{}); !errors.Is(err, ErrMenuNotFound) {
+		t.Fatalf("菜单已删除时应返回 ErrMenuNotFound, 实际: %v", err)
+	}
+}
@@ -91,13 +91,43 @@ func (a *CLIApp) ExecuteCommand(name string, args []string) error {
 		}
 	}
 
-	// 避免使用可能未初始化的facades
-	fmt.Printf("命令 %s 不存在\n", name)
-	return nil
+	return fmt.Errorf("命令 %s 不存在", name)
 }
 
 // registerCommands 注册内置命令
 func (a *CLIApp) registerCommands() {
-	// 这里可以注册内置命令
-	// 例如：a.AddCommand(NewMigrateCommand())
+	// 数据库迁移命令
+	a.AddCommand(NewMigrateCommand("up"))
+	a.AddCommand(NewMigrateCommand("down"))
+	a.AddCommand(NewMigrateCommand("redo"))
+	a.AddCommand(NewMigrateCommand("status"))
+
+	// 数据填充命令
+	a.AddCommand(NewSeedCommand())
+
+	// 租户管理命令
+	a.AddCommand(NewTenantCommand("create"))
+	a.AddCommand(NewTenantCommand("list"))
+
+	// 备份恢复命令
+	a.AddCommand(NewBackupCommand())
+	a.AddCommand(NewRestoreCommand())
+
+	// 表结构比较命令
+	a.AddCommand(NewSchemaDiffCommand())
+
+	// 回收站清理命令
+	a.AddCommand(NewTrashPurgeCommand())
+
+	// 文件缓存维护命令
+	a.AddCommand(NewCacheCompactCommand())
+	a.AddCommand(NewCacheStatsCommand())
+}
+
+// PrintUsage 输出可用命令列表
+func (a *CLIApp) PrintUsage() {
+	fmt.Println("可用命令:")
+	for _, cmd := range a.commands {
+		fmt.Printf("  %-20s %s\n", cmd.Name(), cmd.Description())
+	}
 }
@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/zhoudm1743/go-web/core/backup"
+	"github.com/zhoudm1743/go-web/core/conf"
+	"github.com/zhoudm1743/go-web/core/facades"
+	"github.com/zhoudm1743/go-web/core/migration"
+	"github.com/zhoudm1743/go-web/pkg/generator"
+)
+
+// BackupCommand 数据库备份命令
+type BackupCommand struct{}
+
+// NewBackupCommand 创建数据库备份命令
+func NewBackupCommand() *BackupCommand {
+	return &BackupCommand{}
+}
+
+// Name 命令名称
+func (c *BackupCommand) Name() string {
+	return "db:backup"
+}
+
+// Description 命令描述
+func (c *BackupCommand) Description() string {
+	return "备份数据库 [-format auto|sqlite|jsonl] [-out 文件] [-keep N]"
+}
+
+// Execute 执行命令
+func (c *BackupCommand) Execute(args []string) error {
+	cfg := backupConfig()
+
+	fs := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	format := fs.String("format", cfg.Format, "备份格式: auto, sqlite, jsonl")
+	out := fs.String("out", "", "备份文件路径，默认写入备份目录")
+	keep := fs.Int("keep", cfg.Keep, "备份目录中保留的备份数量，0 表示不清理")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	db := facades.DB()
+	if db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	cfg.Format, cfg.Keep = *format, *keep
+	ctx := context.Background()
+
+	var (
+		result *backup.Result
+		err    error
+	)
+	if *out != "" {
+		if dir := filepath.Dir(*out); dir != "" {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return fmt.Errorf("创建备份目录失败: %w", err)
+			}
+		}
+		result, err = backup.Write(ctx, db, cfg.Format, *out)
+	} else {
+		result, err = backup.Create(ctx, db, cfg)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("备份完成: %s (%s, %d 字节, 耗时 %s)\n", result.Path, result.Format, result.Size, result.Elapsed.Round(time.Millisecond))
+	printSummary(result.Summary)
+	return nil
+}
+
+// RestoreCommand 数据库恢复命令
+type RestoreCommand struct{}
+
+// NewRestoreCommand 创建数据库恢复命令
+func NewRestoreCommand() *RestoreCommand {
+	return &RestoreCommand{}
+}
+
+// Name 命令名称
+func (c *RestoreCommand) Name() string {
+	return "db:restore"
+}
+
+// Description 命令描述
+func (c *RestoreCommand) Description() string {
+	return "从备份恢复数据库，会覆盖现有数据 -file 文件 -yes [-no-migrate]"
+}
+
+// Execute 执行命令
+//
+// sqlite 快照恢复到 sqlite 时直接替换数据库文件；其他情况先执行迁移建表，
+// 再清空并导入数据，因此 sqlite 快照也可以导入到 MySQL 或 PostgreSQL。
+func (c *RestoreCommand) Execute(args []string) error {
+	fs := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	file := fs.String("file", "", "备份文件路径")
+	yes := fs.Bool("yes", false, "确认覆盖现有数据")
+	noMigrate := fs.Bool("no-migrate", false, "导入前不执行数据库迁移")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("请通过 -file 指定备份文件")
+	}
+	if !*yes {
+		return fmt.Errorf("恢复会覆盖当前数据库的数据，确认后请加上 -yes 参数")
+	}
+
+	db := facades.DB()
+	if db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	snapshot, err := backup.IsSnapshot(*file)
+	if err != nil {
+		return fmt.Errorf("读取备份文件失败: %w", err)
+	}
+
+	// sqlite 快照恢复到 sqlite：关闭连接后替换数据库文件
+	if snapshot && db.Dialector.Name() == "sqlite" {
+		config := facades.Config()
+		if config == nil {
+			return fmt.Errorf("配置未初始化")
+		}
+		if manager := facades.DBManager(); manager != nil {
+			manager.Close()
+		}
+		previous, err := backup.RestoreSnapshot(*file, config.Database.DSN)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("已从快照恢复数据库: %s\n", backup.SQLitePath(config.Database.DSN))
+		if previous != "" {
+			fmt.Printf("原数据库已保留为: %s\n", previous)
+		}
+		return nil
+	}
+
+	ctx := context.Background()
+	if !*noMigrate {
+		if _, err := migration.NewMigrator(db).Up(ctx, 0); err != nil {
+			return fmt.Errorf("执行数据库迁移失败: %w", err)
+		}
+		// 代码生成历史表在首次使用生成器时创建，不在迁移中
+		if err := (&generator.HistoryManager{DB: db}).Migrate(); err != nil {
+			return fmt.Errorf("创建代码生成历史表失败: %w", err)
+		}
+	}
+
+	var summary *backup.Summary
+	if snapshot {
+		summary, err = backup.ImportSnapshot(ctx, db, *file)
+	} else {
+		summary, err = backup.ImportFile(ctx, db, *file)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("恢复完成，共 %d 条记录\n", summary.Total())
+	printSummary(summary)
+	if len(summary.Skipped) > 0 {
+		fmt.Printf("跳过未注册的表: %s\n", strings.Join(summary.Skipped, ", "))
+	}
+	return nil
+}
+
+// backupConfig 获取备份配置，未加载配置时使用默认值
+func backupConfig() conf.BackupConfig {
+	if config := facades.Config(); config != nil {
+		return config.Backup
+	}
+	return conf.BackupConfig{Path: "backups", Format: backup.FormatAuto, Keep: 7}
+}
+
+// printSummary 输出每张表的记录数
+func printSummary(summary *backup.Summary) {
+	if summary == nil {
+		return
+	}
+	for _, table := range summary.Tables {
+		fmt.Printf("  %-24s %d\n", table, summary.Counts[table])
+	}
+}
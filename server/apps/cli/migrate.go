@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/zhoudm1743/go-web/core/facades"
+	"github.com/zhoudm1743/go-web/core/migration"
+)
+
+// MigrateCommand 执行数据库迁移
+type MigrateCommand struct {
+	action string // up, down, redo, status
+}
+
+// NewMigrateCommand 创建迁移命令
+func NewMigrateCommand(action string) *MigrateCommand {
+	return &MigrateCommand{action: action}
+}
+
+// Name 命令名称
+func (c *MigrateCommand) Name() string {
+	if c.action == "up" {
+		return "migrate"
+	}
+	return "migrate:" + c.action
+}
+
+// Description 命令描述
+func (c *MigrateCommand) Description() string {
+	switch c.action {
+	case "down":
+		return "回滚数据库迁移，默认回滚最后一个批次 [-step N]"
+	case "redo":
+		return "回滚并重新执行迁移，默认最后一个批次 [-step N]"
+	case "status":
+		return "查看数据库迁移状态"
+	default:
+		return "执行未完成的数据库迁移 [-step N]"
+	}
+}
+
+// Execute 执行命令
+func (c *MigrateCommand) Execute(args []string) error {
+	fs := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	steps := fs.Int("step", 0, "执行的迁移数量，0 表示默认行为")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	db := facades.DB()
+	if db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	ctx := context.Background()
+	migrator := migration.NewMigrator(db)
+
+	switch c.action {
+	case "down":
+		reverted, err := migrator.Down(ctx, *steps)
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			fmt.Println("没有可回滚的迁移")
+		}
+		return nil
+	case "redo":
+		return migrator.Redo(ctx, *steps)
+	case "status":
+		return c.printStatus(ctx, migrator)
+	default:
+		applied, err := migrator.Up(ctx, *steps)
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("没有需要执行的迁移")
+		}
+		return nil
+	}
+}
+
+// printStatus 输出迁移状态表格
+func (c *MigrateCommand) printStatus(ctx context.Context, migrator *migration.Migrator) error {
+	list, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "版本\t名称\t状态\t批次\t执行时间\n")
+	for _, s := range list {
+		state, batch, appliedAt := "待执行", "-", "-"
+		if s.Applied {
+			state = "已执行"
+			batch = fmt.Sprintf("%d", s.Batch)
+			appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		if s.Missing {
+			state = "已执行(文件缺失)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.Version, s.Name, state, batch, appliedAt)
+	}
+	fmt.Fprintf(w, "\n当前数据库: %s\n", migrator.Dialect())
+	return w.Flush()
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/zhoudm1743/go-web/core/backup"
+	"github.com/zhoudm1743/go-web/core/dbschema"
+	"github.com/zhoudm1743/go-web/core/facades"
+	"github.com/zhoudm1743/go-web/core/migration"
+)
+
+// SchemaDiffCommand 比较模型与数据库表结构
+type SchemaDiffCommand struct{}
+
+// NewSchemaDiffCommand 创建表结构比较命令
+func NewSchemaDiffCommand() *SchemaDiffCommand {
+	return &SchemaDiffCommand{}
+}
+
+// Name 命令名称
+func (c *SchemaDiffCommand) Name() string {
+	return "db:diff"
+}
+
+// Description 命令描述
+func (c *SchemaDiffCommand) Description() string {
+	return "比较模型与数据库的表结构 [-table 表名] [-extra] [-json] [-migration 迁移文件]"
+}
+
+// Execute 执行命令
+//
+// 检查所有通过 backup.Register 注册的模型以及迁移和代码生成器的记录表。
+// 存在差异时返回错误，便于在部署流水线中检查。
+func (c *SchemaDiffCommand) Execute(args []string) error {
+	fs := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	table := fs.String("table", "", "只检查指定的表")
+	extra := fs.Bool("extra", false, "同时报告没有对应模型的表")
+	asJSON := fs.Bool("json", false, "以JSON格式输出")
+	out := fs.String("migration", "", "生成迁移文件的路径，例如 apps/admin/migrations")
+	name := fs.String("name", "sync_schema", "生成的迁移名称")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	db := facades.DB()
+	if db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	models := append(backup.Registered(),
+		&migration.SchemaMigration{},
+		&migration.MigrationLock{},
+	)
+	opts := dbschema.Options{
+		ExtraTables: *extra,
+		// casbin 适配器在模型之外创建的唯一索引
+		IgnoreIndexes: []string{"idx_casbin_rule"},
+	}
+	if *table != "" {
+		opts.Tables = []string{*table}
+	}
+
+	report, err := dbschema.Diff(db, models, opts)
+	if err != nil {
+		return err
+	}
+	if *table != "" && len(report.Tables) == 0 {
+		return fmt.Errorf("表 %s 没有对应的模型", *table)
+	}
+
+	if *asJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	} else {
+		fmt.Print(report.String())
+	}
+
+	if *out != "" && report.HasChanges() {
+		version := time.Now().Format("20060102150405")
+		source, err := report.Migration(version, *name)
+		if err != nil {
+			return err
+		}
+		path := *out
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			path = filepath.Join(path, fmt.Sprintf("%s_%s.go", version, *name))
+		}
+		if err := os.WriteFile(path, source, 0644); err != nil {
+			return fmt.Errorf("写入迁移文件失败: %w", err)
+		}
+		fmt.Printf("\n已生成迁移文件: %s\n", path)
+	}
+
+	if report.HasChanges() {
+		return fmt.Errorf("模型与数据库存在 %d 处差异", len(report.Changes))
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/zhoudm1743/go-web/core/facades"
+	"github.com/zhoudm1743/go-web/core/seeder"
+	"github.com/zhoudm1743/go-web/core/tenant"
+)
+
+// SeedCommand 执行数据填充
+type SeedCommand struct{}
+
+// NewSeedCommand 创建数据填充命令
+func NewSeedCommand() *SeedCommand {
+	return &SeedCommand{}
+}
+
+// Name 命令名称
+func (c *SeedCommand) Name() string {
+	return "db:seed"
+}
+
+// Description 命令描述
+func (c *SeedCommand) Description() string {
+	return "执行数据填充并加载数据文件 [-env dev] [-tenant code] [-only a,b] [-fixtures dir] [-list]"
+}
+
+// Execute 执行命令
+func (c *SeedCommand) Execute(args []string) error {
+	config := facades.Config()
+	defaultEnv, defaultFixtures := "dev", ""
+	if config != nil {
+		defaultEnv, defaultFixtures = config.App.Mode, config.Database.FixturesPath
+	}
+
+	fs := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	env := fs.String("env", defaultEnv, "运行环境: dev, test, prod")
+	code := fs.String("tenant", tenant.SuperCode(), "填充的租户编码，默认为超级租户")
+	only := fs.String("only", "", "只执行指定名称的填充，逗号分隔")
+	fixtures := fs.String("fixtures", defaultFixtures, "数据文件目录，为空表示不加载")
+	list := fs.Bool("list", false, "列出已注册的填充")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *list {
+		for _, s := range seeder.Registered() {
+			envs := "全部"
+			if len(s.Envs) > 0 {
+				envs = strings.Join(s.Envs, ",")
+			}
+			fmt.Printf("  %-4d %-24s 环境: %s\n", s.Order, s.Name, envs)
+		}
+		return nil
+	}
+
+	db := facades.DB()
+	if db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	opts := seeder.Options{
+		Env:          *env,
+		FixturesPath: *fixtures,
+	}
+	if *only != "" {
+		opts.Only = strings.Split(*only, ",")
+	}
+	ctx, err := tenant.ContextFor(context.Background(), db, *code)
+	if err != nil {
+		return fmt.Errorf("租户 %s: %w", *code, err)
+	}
+	return seeder.Run(ctx, db, opts)
+}
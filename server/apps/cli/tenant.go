@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/zhoudm1743/go-web/apps/admin/seeders"
+	"github.com/zhoudm1743/go-web/core/facades"
+	"github.com/zhoudm1743/go-web/core/seeder"
+	"github.com/zhoudm1743/go-web/core/tenant"
+)
+
+// TenantCommand 租户管理命令
+type TenantCommand struct {
+	action string // create, list
+}
+
+// NewTenantCommand 创建租户管理命令
+func NewTenantCommand(action string) *TenantCommand {
+	return &TenantCommand{action: action}
+}
+
+// Name 命令名称
+func (c *TenantCommand) Name() string {
+	return "tenant:" + c.action
+}
+
+// Description 命令描述
+func (c *TenantCommand) Description() string {
+	if c.action == "list" {
+		return "列出所有租户"
+	}
+	return "创建租户并填充初始数据 -code acme -name 名称 [-admin-password 密码] [-env dev] [-no-seed]"
+}
+
+// Execute 执行命令
+func (c *TenantCommand) Execute(args []string) error {
+	db := facades.DB()
+	if db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	if c.action == "list" {
+		var tenants []tenant.Tenant
+		if err := db.Order("id").Find(&tenants).Error; err != nil {
+			return fmt.Errorf("查询租户失败: %w", err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\t编码\t名称\t状态\t超级租户")
+		for i := range tenants {
+			t := &tenants[i]
+			status := "启用"
+			if !t.Active() {
+				status = "停用"
+			}
+			super := ""
+			if tenant.IsSuper(t) {
+				super = "是"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", t.ID, t.Code, t.Name, status, super)
+		}
+		return w.Flush()
+	}
+
+	defaultEnv := "dev"
+	if config := facades.Config(); config != nil {
+		defaultEnv = config.App.Mode
+	}
+
+	fs := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	code := fs.String("code", "", "租户编码，同时用作子域名")
+	name := fs.String("name", "", "租户名称")
+	env := fs.String("env", defaultEnv, "运行环境: dev, test, prod")
+	password := fs.String("admin-password", "", "默认管理员 admin 的密码，为空时随机生成")
+	noSeed := fs.Bool("no-seed", false, "只创建租户，不填充初始数据")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *code == "" {
+		return fmt.Errorf("请通过 -code 指定租户编码")
+	}
+	if *name == "" {
+		*name = *code
+	}
+
+	t := &tenant.Tenant{Code: *code, Name: *name}
+	if err := tenant.Create(db, t); err != nil {
+		return err
+	}
+	fmt.Printf("已创建租户: %s (ID: %d)\n", t.Code, t.ID)
+
+	if *noSeed {
+		return nil
+	}
+
+	generated := *password == ""
+	if generated {
+		var err error
+		if *password, err = randomPassword(); err != nil {
+			return err
+		}
+	}
+	ctx := seeders.WithAdminPassword(tenant.WithTenant(context.Background(), t), *password)
+	if err := seeder.Run(ctx, db, seeder.Options{Env: *env}); err != nil {
+		return err
+	}
+	if generated {
+		fmt.Printf("默认管理员: admin，密码: %s（只显示一次，请登录后修改）\n", *password)
+	}
+	return nil
+}
+
+// randomPassword 生成随机密码
+func randomPassword() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成密码失败: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
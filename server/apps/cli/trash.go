@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/zhoudm1743/go-web/core/facades"
+	"github.com/zhoudm1743/go-web/core/trash"
+)
+
+// TrashPurgeCommand 清理回收站命令
+type TrashPurgeCommand struct{}
+
+// NewTrashPurgeCommand 创建清理回收站命令
+func NewTrashPurgeCommand() *TrashPurgeCommand {
+	return &TrashPurgeCommand{}
+}
+
+// Name 命令名称
+func (c *TrashPurgeCommand) Name() string {
+	return "db:purge"
+}
+
+// Description 命令描述
+func (c *TrashPurgeCommand) Description() string {
+	return "永久删除超过保留期的软删除记录 [-retention 720h] [-dry-run]"
+}
+
+// Execute 执行命令
+func (c *TrashPurgeCommand) Execute(args []string) error {
+	retention := 30 * 24 * time.Hour
+	if config := facades.Config(); config != nil {
+		retention = config.Trash.Retention
+	}
+
+	fs := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	fs.DurationVar(&retention, "retention", retention, "保留期，删除时间早于该时长的记录会被永久删除，0 表示清空回收站")
+	dryRun := fs.Bool("dry-run", false, "只统计将被删除的记录数")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if facades.DB() == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	result, err := trash.PurgeRegistered(context.Background(), facades.DBFor, retention, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	action := "已永久删除"
+	if result.DryRun {
+		action = "将永久删除"
+	}
+	fmt.Printf("%s %d 条 %s 之前删除的记录\n", action, result.Total(), result.Before.Format(time.DateTime))
+	for _, table := range result.Tables {
+		fmt.Printf("  %-24s %d\n", table, result.Counts[table])
+	}
+	return nil
+}
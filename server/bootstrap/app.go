@@ -15,12 +15,18 @@ import (
 	"github.com/zhoudm1743/go-web/apps/cli"
 	"github.com/zhoudm1743/go-web/core"
 	"github.com/zhoudm1743/go-web/core/app"
+	"github.com/zhoudm1743/go-web/core/backup"
+	"github.com/zhoudm1743/go-web/core/cache"
 	"github.com/zhoudm1743/go-web/core/conf"
 	"github.com/zhoudm1743/go-web/core/database"
+	"github.com/zhoudm1743/go-web/core/dbcache"
 	"github.com/zhoudm1743/go-web/core/facades"
 	"github.com/zhoudm1743/go-web/core/log"
+	"github.com/zhoudm1743/go-web/core/tenant"
+	"github.com/zhoudm1743/go-web/core/trash"
 	"github.com/zhoudm1743/go-web/core/utils"
 	"github.com/zhoudm1743/go-web/routes"
+	"gorm.io/gorm"
 )
 
 // Application 应用实例
@@ -32,6 +38,7 @@ type Application struct {
 	server  *http.Server       // HTTP服务器
 	manager *app.Manager       // 应用管理器
 	appMode string             // 应用模式：http, cli
+	args    []string           // 命令行参数（CLI模式下为命令及其参数）
 	signal  chan os.Signal     // 信号通道
 	ctx     context.Context    // 上下文
 	cancel  context.CancelFunc // 取消函数
@@ -66,6 +73,12 @@ func (a *Application) Initialize() error {
 	}
 	a.config = config
 
+	// CLI模式下由迁移和填充命令显式控制
+	if a.appMode == "cli" {
+		a.config.Database.AutoMigrate = false
+		a.config.Database.AutoSeed = false
+	}
+
 	// 设置全局配置
 	facades.SetConfig(config)
 
@@ -150,7 +163,7 @@ func (a *Application) initDatabase() error {
 		}
 
 		// 手动创建数据库连接
-		dbInstance, err := database.NewDB(database.DBParams{
+		manager, err := database.NewManager(database.DBParams{
 			Config: a.config,
 			Logger: a.logger,
 		})
@@ -159,8 +172,28 @@ func (a *Application) initDatabase() error {
 		}
 
 		// 设置到facades
-		facades.SetDB(dbInstance)
-		db = dbInstance
+		facades.SetDBManager(manager)
+		db = manager.Default()
+	}
+
+	// 注册租户插件，需在迁移和填充之前完成
+	if err := tenant.Setup(a.config.Tenant, facades.DBManager()); err != nil {
+		return err
+	}
+
+	// 注册模型查询缓存插件，缓存服务未初始化时按配置创建
+	if a.config.Cache.Model.Enabled {
+		c := facades.Cache()
+		if c == nil {
+			var err error
+			if c, err = cache.New(a.config, a.logger); err != nil {
+				return fmt.Errorf("创建缓存失败: %w", err)
+			}
+			facades.SetCache(c)
+		}
+		if err := dbcache.Setup(a.config.Cache.Model, facades.DBManager(), c, a.logger); err != nil {
+			return err
+		}
 	}
 
 	// 初始化Casbin表和策略
@@ -253,6 +286,11 @@ func (a *Application) SetMode(mode string) {
 	a.appMode = mode
 }
 
+// SetArgs 设置命令行参数
+func (a *Application) SetArgs(args []string) {
+	a.args = args
+}
+
 // Run 运行应用
 func (a *Application) Run() error {
 	// 启动所有应用
@@ -263,8 +301,8 @@ func (a *Application) Run() error {
 	// 根据模式运行
 	switch a.appMode {
 	case "cli":
-		// CLI模式下，可能没有需要运行的HTTP服务
-		a.waitForSignal()
+		// CLI模式下执行命令后直接退出
+		return a.runCommand()
 	default:
 		// HTTP模式
 		go func() {
@@ -274,6 +312,16 @@ func (a *Application) Run() error {
 			}
 		}()
 
+		// 定时备份
+		if a.config.Backup.Interval > 0 {
+			go backup.Schedule(a.ctx, a.config.Backup, func() *gorm.DB { return facades.DB() }, a.logger)
+		}
+
+		// 定时清理回收站
+		if a.config.Trash.Interval > 0 {
+			go trash.Schedule(a.ctx, a.config.Trash, facades.DBFor, a.logger)
+		}
+
 		// 等待信号
 		a.waitForSignal()
 	}
@@ -281,6 +329,24 @@ func (a *Application) Run() error {
 	return nil
 }
 
+// runCommand 执行CLI命令
+func (a *Application) runCommand() error {
+	instance, ok := a.manager.GetApp("cli")
+	if !ok {
+		return fmt.Errorf("命令行应用未注册")
+	}
+	cliApp, ok := instance.(*cli.CLIApp)
+	if !ok {
+		return fmt.Errorf("命令行应用类型错误")
+	}
+
+	if len(a.args) == 0 {
+		cliApp.PrintUsage()
+		return nil
+	}
+	return cliApp.ExecuteCommand(a.args[0], a.args[1:])
+}
+
 // waitForSignal 等待信号
 func (a *Application) waitForSignal() {
 	// 等待中断信号
@@ -314,6 +380,13 @@ func (a *Application) Shutdown() error {
 		a.logger.Errorf("应用关闭失败: %v", err)
 	}
 
+	// 关闭所有数据库连接
+	if manager := facades.DBManager(); manager != nil {
+		if err := manager.Close(); err != nil {
+			a.logger.Errorf("数据库连接关闭失败: %v", err)
+		}
+	}
+
 	a.logger.Info("应用已完全关闭")
 	return nil
 }
@@ -334,12 +407,16 @@ func (a *Application) GetManager() *app.Manager {
 }
 
 // InitializeApp 初始化应用
-func InitializeApp() (*Application, error) {
+func InitializeApp(mode string, args []string) (*Application, error) {
 	app, err := NewApplication()
 	if err != nil {
 		return nil, err
 	}
 
+	// 模式需要在初始化前设置，部分初始化逻辑依赖运行模式
+	app.SetMode(mode)
+	app.SetArgs(args)
+
 	if err := app.Initialize(); err != nil {
 		return nil, err
 	}
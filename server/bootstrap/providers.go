@@ -51,6 +51,11 @@ func registerProviders(app core.Application) error {
 		return fmt.Errorf("注册缓存提供者失败: %w", err)
 	}
 
+	// 注册多租户提供者
+	if err := app.Register(providers.NewTenantProvider()); err != nil {
+		return fmt.Errorf("注册多租户提供者失败: %w", err)
+	}
+
 	// 启动数据库和缓存提供者
 	for _, name := range []string{"database", "cache"} {
 		provider := app.GetProvider(name)
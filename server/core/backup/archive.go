@@ -0,0 +1,348 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/zhoudm1743/go-web/core/database"
+	"github.com/zhoudm1743/go-web/core/tenant"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// 归档格式标识与版本
+const (
+	archiveFormat  = "go-web-backup"
+	archiveVersion = 1
+)
+
+// batchSize 导出和导入时每批处理的记录数
+const batchSize = 500
+
+// 归档中的记录类型
+const (
+	kindHeader = "header"
+	kindRow    = "row"
+	kindEnd    = "end"
+)
+
+// record JSON Lines 归档中的一行
+//
+// 第一行为 header，列出归档包含的表；之后每行一条记录；最后一行为 end，记录每张表的记录数，
+// 用于校验归档是否完整。字段按列名保存，值使用模型字段类型的 JSON 编码，与数据库驱动无关。
+type record struct {
+	Kind    string                     `json:"kind"`
+	Format  string                     `json:"format,omitempty"`
+	Version int                        `json:"version,omitempty"`
+	Driver  string                     `json:"driver,omitempty"`
+	Time    *time.Time                 `json:"time,omitempty"`
+	Tables  []string                   `json:"tables,omitempty"`
+	Table   string                     `json:"table,omitempty"`
+	Data    map[string]json.RawMessage `json:"data,omitempty"`
+	Counts  map[string]int64           `json:"counts,omitempty"`
+}
+
+// Summary 导出或导入的统计
+type Summary struct {
+	Tables  []string         // 按顺序处理的表
+	Counts  map[string]int64 // 每张表的记录数
+	Skipped []string         // 导入时没有对应模型而跳过的表
+}
+
+// Total 记录总数
+func (s *Summary) Total() int64 {
+	var total int64
+	for _, count := range s.Counts {
+		total += count
+	}
+	return total
+}
+
+// table 已解析的备份模型
+type table struct {
+	model  interface{}
+	schema *schema.Schema
+}
+
+// tables 解析已注册的模型，跳过属于其他命名连接的模型
+func tables(db *gorm.DB) ([]table, error) {
+	var list []table
+	for _, model := range Registered() {
+		if c, ok := model.(database.Connector); ok {
+			if name := c.Connection(); name != "" && name != database.DefaultConnection {
+				continue
+			}
+		}
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, fmt.Errorf("解析模型 %T 失败: %w", model, err)
+		}
+		list = append(list, table{model: model, schema: stmt.Schema})
+	}
+	return list, nil
+}
+
+// columns 需要备份的字段
+func columns(s *schema.Schema) []*schema.Field {
+	fields := make([]*schema.Field, 0, len(s.DBNames))
+	for _, name := range s.DBNames {
+		if field := s.FieldsByDBName[name]; field != nil && field.Readable {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// Export 将所有已注册模型的数据（含软删除的记录）导出为 JSON Lines 归档
+func Export(ctx context.Context, db *gorm.DB, w io.Writer) (*Summary, error) {
+	list, err := tables(db)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &Summary{Counts: make(map[string]int64)}
+	for _, t := range list {
+		summary.Tables = append(summary.Tables, t.schema.Table)
+	}
+
+	enc := json.NewEncoder(w)
+	now := time.Now()
+	if err := enc.Encode(record{
+		Kind:    kindHeader,
+		Format:  archiveFormat,
+		Version: archiveVersion,
+		Driver:  db.Dialector.Name(),
+		Time:    &now,
+		Tables:  summary.Tables,
+	}); err != nil {
+		return nil, err
+	}
+
+	db = db.WithContext(tenant.SkipScope(ctx))
+	for _, t := range list {
+		count, err := exportTable(db, enc, t)
+		if err != nil {
+			return nil, fmt.Errorf("导出 %s 失败: %w", t.schema.Table, err)
+		}
+		summary.Counts[t.schema.Table] = count
+	}
+
+	if err := enc.Encode(record{Kind: kindEnd, Counts: summary.Counts}); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// exportTable 按主键顺序从主库分批导出一张表
+func exportTable(db *gorm.DB, enc *json.Encoder, t table) (int64, error) {
+	fields := columns(t.schema)
+	order := make([]string, 0, len(t.schema.PrimaryFieldDBNames))
+	for _, name := range t.schema.PrimaryFieldDBNames {
+		order = append(order, db.Statement.Quote(name))
+	}
+
+	sliceType := reflect.SliceOf(t.schema.ModelType)
+	var count int64
+	for offset := 0; ; offset += batchSize {
+		rows := reflect.New(sliceType)
+		query := database.Write(db).Unscoped().Model(t.model).Limit(batchSize).Offset(offset)
+		if len(order) > 0 {
+			query = query.Order(strings.Join(order, ", "))
+		}
+		if err := query.Find(rows.Interface()).Error; err != nil {
+			return count, err
+		}
+
+		list := rows.Elem()
+		for i := 0; i < list.Len(); i++ {
+			data := make(map[string]json.RawMessage, len(fields))
+			for _, field := range fields {
+				value, _ := field.ValueOf(db.Statement.Context, list.Index(i))
+				raw, err := json.Marshal(value)
+				if err != nil {
+					return count, fmt.Errorf("编码字段 %s 失败: %w", field.DBName, err)
+				}
+				data[field.DBName] = raw
+			}
+			if err := enc.Encode(record{Kind: kindRow, Table: t.schema.Table, Data: data}); err != nil {
+				return count, err
+			}
+			count++
+		}
+		if list.Len() < batchSize {
+			return count, nil
+		}
+	}
+}
+
+// Import 从 JSON Lines 归档恢复数据
+//
+// 在一个事务中先清空归档包含的表（按注册顺序逆序），再按归档顺序写入记录，
+// 归档中的表必须已存在，通常先执行迁移。迁移记录（schema_migrations）不在归档中，
+// 目标库的表结构和迁移记录以目标库执行的迁移为准。归档中没有对应模型的表会被跳过，
+// 模型中不存在的列会被忽略。PostgreSQL 恢复后会重置自增序列。
+func Import(ctx context.Context, db *gorm.DB, r io.Reader) (*Summary, error) {
+	dec := json.NewDecoder(r)
+	var header record
+	if err := dec.Decode(&header); err != nil {
+		return nil, fmt.Errorf("读取归档头失败: %w", err)
+	}
+	if header.Kind != kindHeader || header.Format != archiveFormat {
+		return nil, errors.New("不是有效的备份归档")
+	}
+	if header.Version > archiveVersion {
+		return nil, fmt.Errorf("不支持的归档版本: %d", header.Version)
+	}
+
+	list, err := tables(db)
+	if err != nil {
+		return nil, err
+	}
+	byTable := make(map[string]table, len(list))
+	for _, t := range list {
+		byTable[t.schema.Table] = t
+	}
+
+	summary := &Summary{Counts: make(map[string]int64)}
+	for _, name := range header.Tables {
+		if _, ok := byTable[name]; ok {
+			summary.Tables = append(summary.Tables, name)
+		} else {
+			summary.Skipped = append(summary.Skipped, name)
+		}
+	}
+
+	err = db.WithContext(tenant.SkipScope(ctx)).Transaction(func(tx *gorm.DB) error {
+		// 按注册顺序逆序清空，先删除引用方
+		for i := len(list) - 1; i >= 0; i-- {
+			t := list[i]
+			if !contains(summary.Tables, t.schema.Table) {
+				continue
+			}
+			if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Unscoped().Delete(t.model).Error; err != nil {
+				return fmt.Errorf("清空 %s 失败: %w", t.schema.Table, err)
+			}
+		}
+
+		var (
+			current *table
+			batch   []map[string]interface{}
+		)
+		flush := func() error {
+			if current == nil || len(batch) == 0 {
+				return nil
+			}
+			n := len(batch)
+			if err := tx.Session(&gorm.Session{SkipHooks: true}).Model(current.model).Create(&batch).Error; err != nil {
+				return fmt.Errorf("写入 %s 失败: %w", current.schema.Table, err)
+			}
+			// 以 map 切片写入时 GORM 会向切片追加回填的主键，按写入前的数量统计
+			summary.Counts[current.schema.Table] += int64(n)
+			batch = nil
+			return nil
+		}
+
+		for {
+			var rec record
+			if err := dec.Decode(&rec); err != nil {
+				if errors.Is(err, io.EOF) {
+					return errors.New("归档不完整：缺少结束标记")
+				}
+				return fmt.Errorf("读取归档失败: %w", err)
+			}
+
+			if rec.Kind == kindEnd {
+				if err := flush(); err != nil {
+					return err
+				}
+				for _, name := range summary.Tables {
+					if rec.Counts[name] != summary.Counts[name] {
+						return fmt.Errorf("归档不完整：%s 应有 %d 条记录，实际 %d 条", name, rec.Counts[name], summary.Counts[name])
+					}
+				}
+				return resetSequences(tx, list, summary.Tables)
+			}
+			if rec.Kind != kindRow {
+				continue
+			}
+
+			t, ok := byTable[rec.Table]
+			if !ok {
+				continue
+			}
+			if current == nil || current.schema != t.schema {
+				if err := flush(); err != nil {
+					return err
+				}
+				current = &t
+			}
+
+			values, err := decodeRow(t.schema, rec.Data)
+			if err != nil {
+				return fmt.Errorf("解析 %s 的记录失败: %w", t.schema.Table, err)
+			}
+			batch = append(batch, values)
+			if len(batch) >= batchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// decodeRow 按模型字段类型解码一条记录
+func decodeRow(s *schema.Schema, data map[string]json.RawMessage) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(data))
+	for name, raw := range data {
+		field := s.FieldsByDBName[name]
+		if field == nil || !field.Creatable {
+			continue
+		}
+		ptr := reflect.New(field.FieldType)
+		if err := json.Unmarshal(raw, ptr.Interface()); err != nil {
+			return nil, fmt.Errorf("字段 %s: %w", name, err)
+		}
+		values[name] = ptr.Elem().Interface()
+	}
+	return values, nil
+}
+
+// resetSequences PostgreSQL 写入指定主键后需要重置自增序列
+func resetSequences(tx *gorm.DB, list []table, names []string) error {
+	if tx.Dialector.Name() != "postgres" {
+		return nil
+	}
+	for _, t := range list {
+		field := t.schema.PrioritizedPrimaryField
+		if field == nil || !field.AutoIncrement || !contains(names, t.schema.Table) {
+			continue
+		}
+		sql := fmt.Sprintf("SELECT setval(pg_get_serial_sequence(?, ?), COALESCE((SELECT MAX(%s) FROM %s), 0) + 1, false)",
+			tx.Statement.Quote(field.DBName), tx.Statement.Quote(t.schema.Table))
+		if err := tx.Exec(sql, t.schema.Table, field.DBName).Error; err != nil {
+			return fmt.Errorf("重置 %s 的自增序列失败: %w", t.schema.Table, err)
+		}
+	}
+	return nil
+}
+
+// contains 判断字符串是否在列表中
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
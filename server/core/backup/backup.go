@@ -0,0 +1,256 @@
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zhoudm1743/go-web/core/conf"
+	"github.com/zhoudm1743/go-web/core/log"
+	"gorm.io/gorm"
+)
+
+// 备份格式
+const (
+	FormatAuto   = "auto"   // sqlite 使用快照，其他驱动使用逻辑导出
+	FormatSQLite = "sqlite" // VACUUM INTO 生成的数据库快照
+	FormatJSONL  = "jsonl"  // gzip 压缩的 JSON Lines 逻辑归档，可跨数据库恢复
+)
+
+// filePrefix 备份文件名前缀，清理时只处理该前缀的文件
+const filePrefix = "backup-"
+
+// sqliteMagic SQLite 数据库文件头
+var sqliteMagic = []byte("SQLite format 3\x00")
+
+// Result 一次备份的结果
+type Result struct {
+	Path    string        // 备份文件路径
+	Format  string        // 备份格式
+	Size    int64         // 文件大小
+	Elapsed time.Duration // 耗时
+	Summary *Summary      // 逻辑导出的统计，快照为nil
+}
+
+// ResolveFormat 根据驱动解析备份格式
+func ResolveFormat(format, driver string) (string, error) {
+	switch format {
+	case "", FormatAuto:
+		if driver == "sqlite" {
+			return FormatSQLite, nil
+		}
+		return FormatJSONL, nil
+	case FormatSQLite:
+		if driver != "sqlite" {
+			return "", fmt.Errorf("快照仅支持 sqlite，当前驱动: %s", driver)
+		}
+		return FormatSQLite, nil
+	case FormatJSONL:
+		return FormatJSONL, nil
+	default:
+		return "", fmt.Errorf("不支持的备份格式: %s", format)
+	}
+}
+
+// Filename 生成带时间戳的备份文件名
+func Filename(format string, t time.Time) string {
+	ext := ".jsonl.gz"
+	if format == FormatSQLite {
+		ext = ".db"
+	}
+	return filePrefix + t.Format("20060102-150405") + ext
+}
+
+// Create 按配置备份数据库到备份目录，并按保留数量清理旧备份
+func Create(ctx context.Context, db *gorm.DB, cfg conf.BackupConfig) (*Result, error) {
+	format, err := ResolveFormat(cfg.Format, db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(cfg.Path, 0755); err != nil {
+		return nil, fmt.Errorf("创建备份目录失败: %w", err)
+	}
+
+	result, err := Write(ctx, db, format, filepath.Join(cfg.Path, Filename(format, time.Now())))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := Prune(cfg.Path, cfg.Keep); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// Write 以指定格式备份数据库到文件，格式为 auto 且文件名包含 .jsonl 时使用逻辑导出
+//
+// 先写入临时文件，完成后再重命名，中途失败不会留下不完整的备份。
+func Write(ctx context.Context, db *gorm.DB, format, path string) (*Result, error) {
+	if (format == "" || format == FormatAuto) && strings.Contains(filepath.Base(path), ".jsonl") {
+		format = FormatJSONL
+	}
+	format, err := ResolveFormat(format, db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("备份文件已存在: %s", path)
+	}
+
+	start := time.Now()
+	result := &Result{Path: path, Format: format}
+	tmp := path + ".tmp"
+	os.Remove(tmp)
+
+	if format == FormatSQLite {
+		err = Snapshot(ctx, db, tmp)
+	} else {
+		result.Summary, err = exportFile(ctx, db, tmp)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return nil, fmt.Errorf("保存备份文件失败: %w", err)
+	}
+
+	if info, err := os.Stat(path); err == nil {
+		result.Size = info.Size()
+	}
+	result.Elapsed = time.Since(start)
+	return result, nil
+}
+
+// exportFile 逻辑导出到文件，文件名以 .gz 结尾时使用 gzip 压缩
+func exportFile(ctx context.Context, db *gorm.DB, path string) (*Summary, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("创建备份文件失败: %w", err)
+	}
+	defer file.Close()
+
+	var w io.Writer = file
+	var gz *gzip.Writer
+	if strings.HasSuffix(path, ".gz") || strings.HasSuffix(path, ".gz.tmp") {
+		gz = gzip.NewWriter(file)
+		w = gz
+	}
+
+	summary, err := Export(ctx, db, w)
+	if err != nil {
+		return nil, err
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return summary, file.Sync()
+}
+
+// IsSnapshot 判断文件是否为 SQLite 数据库快照
+func IsSnapshot(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	head := make([]byte, len(sqliteMagic))
+	if _, err := io.ReadFull(file, head); err != nil {
+		return false, nil
+	}
+	return bytes.Equal(head, sqliteMagic), nil
+}
+
+// ImportFile 从逻辑归档文件恢复数据，自动识别 gzip 压缩
+func ImportFile(ctx context.Context, db *gorm.DB, path string) (*Summary, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开备份文件失败: %w", err)
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	head := make([]byte, 2)
+	if n, _ := io.ReadFull(file, head); n == 2 && head[0] == 0x1f && head[1] == 0x8b {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("解压备份文件失败: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	} else if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	return Import(ctx, db, r)
+}
+
+// Prune 保留最新的 keep 个备份文件，返回删除的文件，keep 小于等于0时不清理
+func Prune(dir string, keep int) ([]string, error) {
+	if keep <= 0 {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取备份目录失败: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, filePrefix) || strings.HasSuffix(name, ".tmp") {
+			continue
+		}
+		names = append(names, name)
+	}
+	// 文件名包含时间戳，按名称倒序即按时间倒序
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+
+	var removed []string
+	for i := keep; i < len(names); i++ {
+		path := filepath.Join(dir, names[i])
+		if err := os.Remove(path); err != nil {
+			return removed, fmt.Errorf("删除旧备份失败: %w", err)
+		}
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
+
+// Schedule 按配置的间隔定时备份，直到上下文取消
+func Schedule(ctx context.Context, cfg conf.BackupConfig, db func() *gorm.DB, l log.Logger) {
+	if cfg.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+	l.Infof("定时备份已启动，间隔: %s，目录: %s", cfg.Interval, cfg.Path)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			result, err := Create(ctx, db(), cfg)
+			if err != nil {
+				l.Errorf("定时备份失败: %v", err)
+				continue
+			}
+			l.Infof("定时备份完成: %s (%d 字节, 耗时 %s)", result.Path, result.Size, result.Elapsed)
+		}
+	}
+}
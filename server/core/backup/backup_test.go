@@ -0,0 +1,161 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/zhoudm1743/go-web/core/conf"
+	"github.com/zhoudm1743/go-web/core/database"
+	"gorm.io/gorm"
+)
+
+// backupGroup 测试模型：带默认值为 true 的布尔字段和软删除
+type backupGroup struct {
+	ID        uint
+	Name      string
+	Enabled   bool `gorm:"default:true"`
+	DeletedAt gorm.DeletedAt
+}
+
+// backupMember 测试模型：复合主键
+type backupMember struct {
+	GroupID  uint `gorm:"primarykey"`
+	UserID   uint `gorm:"primarykey"`
+	JoinedAt time.Time
+}
+
+// openTestDB 打开临时 SQLite 数据库并建表
+func openTestDB(t *testing.T, name string) *gorm.DB {
+	t.Helper()
+	db, err := database.Open(name, conf.ConnectionConfig{
+		Driver:   "sqlite",
+		DSN:      filepath.Join(t.TempDir(), name+".db"),
+		LogLevel: "silent",
+	}, nil)
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if err := db.AutoMigrate(&backupGroup{}, &backupMember{}); err != nil {
+		t.Fatalf("建表失败: %v", err)
+	}
+	return db
+}
+
+// TestExportImport 测试逻辑导出后导入到另一个数据库
+func TestExportImport(t *testing.T) {
+	Register(&backupGroup{}, &backupMember{})
+	ctx := context.Background()
+
+	source := openTestDB(t, "source")
+	joined := time.Date(2025, 6, 1, 8, 30, 0, 0, time.UTC)
+	source.Create(&backupGroup{Name: "a"})
+	source.Model(&backupGroup{}).Create(map[string]interface{}{"name": "b", "enabled": false})
+	deleted := &backupGroup{Name: "c"}
+	source.Create(deleted)
+	source.Delete(deleted)
+	source.Create(&[]backupMember{{GroupID: 1, UserID: 2, JoinedAt: joined}, {GroupID: 1, UserID: 3, JoinedAt: joined}})
+
+	var buf bytes.Buffer
+	summary, err := Export(ctx, source, &buf)
+	if err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+	if summary.Counts["backup_groups"] != 3 || summary.Counts["backup_members"] != 2 {
+		t.Fatalf("导出数量不正确: %v", summary.Counts)
+	}
+
+	// 目标库中已有的数据会被清空
+	target := openTestDB(t, "target")
+	target.Create(&backupGroup{Name: "old"})
+
+	if _, err := Import(ctx, target, bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("导入失败: %v", err)
+	}
+
+	var groups []backupGroup
+	target.Unscoped().Order("id").Find(&groups)
+	if len(groups) != 3 {
+		t.Fatalf("期望3条记录（含软删除）, 实际: %d", len(groups))
+	}
+	if !groups[0].Enabled || groups[1].Enabled {
+		t.Fatalf("布尔值未正确恢复: %+v", groups)
+	}
+	if !groups[2].DeletedAt.Valid || groups[0].DeletedAt.Valid {
+		t.Fatalf("软删除状态未正确恢复: %+v", groups)
+	}
+
+	var count int64
+	target.Model(&backupGroup{}).Count(&count)
+	if count != 2 {
+		t.Fatalf("期望2条未删除记录, 实际: %d", count)
+	}
+
+	var members []backupMember
+	target.Order("user_id").Find(&members)
+	if len(members) != 2 || members[1].UserID != 3 || !members[0].JoinedAt.Equal(joined) {
+		t.Fatalf("复合主键表未正确恢复: %+v", members)
+	}
+
+	// 截断的归档不能导入
+	truncated := buf.Bytes()[:bytes.LastIndexByte(buf.Bytes()[:buf.Len()-1], '\n')+1]
+	if _, err := Import(ctx, target, bytes.NewReader(truncated)); err == nil {
+		t.Fatal("截断的归档应导入失败")
+	}
+	target.Unscoped().Model(&backupGroup{}).Count(&count)
+	if count != 3 {
+		t.Fatalf("导入失败时应回滚, 实际记录数: %d", count)
+	}
+}
+
+// TestSnapshotAndPrune 测试 SQLite 快照、快照导入和清理旧备份
+func TestSnapshotAndPrune(t *testing.T) {
+	Register(&backupGroup{}, &backupMember{})
+	ctx := context.Background()
+	db := openTestDB(t, "snapshot")
+	db.Create(&backupGroup{Name: "a"})
+
+	dir := t.TempDir()
+	cfg := conf.BackupConfig{Path: dir, Format: FormatAuto, Keep: 2}
+	result, err := Create(ctx, db, cfg)
+	if err != nil {
+		t.Fatalf("备份失败: %v", err)
+	}
+	if result.Format != FormatSQLite {
+		t.Fatalf("sqlite 默认应使用快照, 实际: %s", result.Format)
+	}
+	if ok, _ := IsSnapshot(result.Path); !ok {
+		t.Fatal("快照文件应为 SQLite 数据库")
+	}
+
+	target := openTestDB(t, "restored")
+	if _, err := ImportSnapshot(ctx, target, result.Path); err != nil {
+		t.Fatalf("导入快照失败: %v", err)
+	}
+	var group backupGroup
+	if err := target.First(&group).Error; err != nil || group.Name != "a" {
+		t.Fatalf("快照数据未导入: %+v %v", group, err)
+	}
+
+	for _, name := range []string{"backup-20240101-000000.db", "backup-20240102-000000.jsonl.gz", "other.txt"} {
+		os.WriteFile(filepath.Join(dir, name), nil, 0644)
+	}
+	removed, err := Prune(dir, cfg.Keep)
+	if err != nil {
+		t.Fatalf("清理失败: %v", err)
+	}
+	if len(removed) != 1 || filepath.Base(removed[0]) != "backup-20240101-000000.db" {
+		t.Fatalf("应只删除最旧的备份, 实际: %v", removed)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "other.txt")); err != nil {
+		t.Fatal("不应删除非备份文件")
+	}
+}
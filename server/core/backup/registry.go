@@ -0,0 +1,49 @@
+package backup
+
+import (
+	"reflect"
+	"sync"
+)
+
+// 参与逻辑备份的模型，按注册顺序导入、逆序清空
+var (
+	modelsMu sync.RWMutex
+	models   []reflect.Type
+)
+
+// Register 注册参与逻辑备份的模型
+//
+// 被引用的模型应先注册，例如先注册角色再注册管理员，恢复时按注册顺序写入。
+func Register(list ...interface{}) {
+	modelsMu.Lock()
+	defer modelsMu.Unlock()
+
+	for _, model := range list {
+		t := reflect.TypeOf(model)
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		registered := false
+		for _, existing := range models {
+			if existing == t {
+				registered = true
+				break
+			}
+		}
+		if !registered {
+			models = append(models, t)
+		}
+	}
+}
+
+// Registered 获取已注册的模型，返回新建的模型指针
+func Registered() []interface{} {
+	modelsMu.RLock()
+	defer modelsMu.RUnlock()
+
+	list := make([]interface{}, 0, len(models))
+	for _, t := range models {
+		list = append(list, reflect.New(t).Interface())
+	}
+	return list
+}
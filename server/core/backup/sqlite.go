@@ -0,0 +1,126 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/zhoudm1743/go-web/core/conf"
+	"github.com/zhoudm1743/go-web/core/database"
+	"gorm.io/gorm"
+)
+
+// Snapshot 使用 VACUUM INTO 在线生成 SQLite 数据库快照
+//
+// VACUUM INTO 在一个读事务中复制整个数据库，不阻塞其他读写，生成的文件可以直接作为数据库使用。
+func Snapshot(ctx context.Context, db *gorm.DB, path string) error {
+	if name := db.Dialector.Name(); name != "sqlite" {
+		return fmt.Errorf("快照仅支持 sqlite，当前驱动: %s", name)
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("快照文件已存在: %s", path)
+	}
+	if err := db.WithContext(ctx).Exec("VACUUM INTO ?", path).Error; err != nil {
+		return fmt.Errorf("生成快照失败: %w", err)
+	}
+	return nil
+}
+
+// SQLitePath 从 SQLite DSN 中解析数据库文件路径，内存数据库返回空字符串
+func SQLitePath(dsn string) string {
+	path := strings.TrimPrefix(dsn, "file:")
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+	if path == "" || path == ":memory:" {
+		return ""
+	}
+	return path
+}
+
+// RestoreSnapshot 用快照文件替换 SQLite 数据库文件
+//
+// 调用前必须关闭数据库的所有连接。原文件会重命名为 <文件名>.before-restore-<时间> 保留，
+// 同时删除遗留的 -wal 和 -shm 文件，返回原文件的新路径。
+func RestoreSnapshot(snapshot, dsn string) (string, error) {
+	target := SQLitePath(dsn)
+	if target == "" {
+		return "", errors.New("无法从 DSN 解析 sqlite 数据库文件路径")
+	}
+
+	src, err := os.Open(snapshot)
+	if err != nil {
+		return "", fmt.Errorf("打开快照失败: %w", err)
+	}
+	defer src.Close()
+
+	tmp := target + ".restoring"
+	dst, err := os.Create(tmp)
+	if err != nil {
+		return "", fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(tmp)
+		return "", fmt.Errorf("复制快照失败: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+
+	var previous string
+	if _, err := os.Stat(target); err == nil {
+		previous = fmt.Sprintf("%s.before-restore-%s", target, time.Now().Format("20060102-150405"))
+		if err := os.Rename(target, previous); err != nil {
+			os.Remove(tmp)
+			return "", fmt.Errorf("保留原数据库失败: %w", err)
+		}
+	}
+	for _, suffix := range []string{"-wal", "-shm"} {
+		os.Remove(target + suffix)
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		return previous, fmt.Errorf("替换数据库文件失败: %w", err)
+	}
+	return previous, nil
+}
+
+// ImportSnapshot 将 SQLite 快照中的数据逻辑导入到目标数据库，目标可以是任意驱动
+//
+// 数据经 Export 导出后通过管道直接交给 Import，不生成中间文件。
+func ImportSnapshot(ctx context.Context, db *gorm.DB, path string) (*Summary, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("打开快照失败: %w", err)
+	}
+	source, err := database.Open("backup", conf.ConnectionConfig{
+		Driver:   "sqlite",
+		DSN:      path,
+		LogLevel: "silent",
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("打开快照失败: %w", err)
+	}
+	defer func() {
+		if sqlDB, err := source.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}()
+
+	r, w := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := Export(ctx, source, w)
+		w.CloseWithError(err)
+	}()
+	summary, err := Import(ctx, db, r)
+	// 导入提前失败时关闭管道，结束导出
+	r.Close()
+	<-done
+	return summary, err
+}
@@ -0,0 +1,2452 @@
+package cache
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/zhoudm1743/go-web/core/conf"
+	"github.com/zhoudm1743/go-web/core/log"
+	"go.etcd.io/bbolt"
+)
+
+// testProfile 测试用结构体
+type testProfile struct {
+	Name  string   `json:"name"`
+	Roles []string `json:"roles"`
+}
+
+// newTestConfig 创建测试配置和日志
+func newTestConfig(t *testing.T) (*conf.Config, log.Logger) {
+	t.Helper()
+	cfg := &conf.Config{
+		Log:   conf.LogConfig{Level: "error", OutputPath: "stdout"},
+		Cache: conf.CacheConfig{FilePath: t.TempDir()},
+	}
+	logger, err := log.NewLogger(log.LoggerParams{Config: cfg})
+	if err != nil {
+		t.Fatalf("创建日志失败: %v", err)
+	}
+	return cfg, logger
+}
+
+// newTestHelper 创建基于内存缓存的助手
+func newTestHelper(t *testing.T) (*CacheHelper, Cache) {
+	t.Helper()
+	cfg, logger := newTestConfig(t)
+	c, _ := NewMemoryCache(cfg, logger)
+	return NewCacheHelper(c, logger, "test"), c
+}
+
+// TestRememberSingleflight 测试并发未命中只加载一次
+func TestRememberSingleflight(t *testing.T) {
+	h, _ := newTestHelper(t)
+	ctx := context.Background()
+
+	var calls atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := Remember(ctx, h, "profile", RememberOptions{TTL: time.Minute}, func() (testProfile, error) {
+				calls.Add(1)
+				time.Sleep(50 * time.Millisecond)
+				return testProfile{Name: "alice", Roles: []string{"admin"}}, nil
+			})
+			if err != nil || v.Name != "alice" || len(v.Roles) != 1 {
+				t.Errorf("结果错误: %+v, %v", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("期望加载 1 次，实际 %d 次", n)
+	}
+
+	// 非类型化版本同样合并，且内存驱动可以命中
+	calls.Store(0)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.RememberCtx(ctx, "count", time.Minute, func() (interface{}, error) {
+				calls.Add(1)
+				time.Sleep(50 * time.Millisecond)
+				return 42, nil
+			})
+		}()
+	}
+	wg.Wait()
+	if v, err := h.RememberCtx(ctx, "count", time.Minute, func() (interface{}, error) {
+		calls.Add(1)
+		return 0, nil
+	}); err != nil || v != "42" || calls.Load() != 1 {
+		t.Fatalf("期望命中缓存: %v, %v, 加载 %d 次", v, err, calls.Load())
+	}
+}
+
+// TestRememberNotFound 测试不存在结果的缓存
+func TestRememberNotFound(t *testing.T) {
+	h, _ := newTestHelper(t)
+	ctx := context.Background()
+
+	calls := 0
+	load := func() (*testProfile, error) {
+		calls++
+		return nil, ErrNotFound
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := Remember(ctx, h, "missing", RememberOptions{TTL: time.Minute, NotFoundTTL: time.Minute}, load); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("期望 ErrNotFound: %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("不存在的结果应被缓存，加载 %d 次", calls)
+	}
+
+	// 未配置 NotFoundTTL 时不缓存
+	calls = 0
+	Remember(ctx, h, "missing2", RememberOptions{TTL: time.Minute}, load)
+	Remember(ctx, h, "missing2", RememberOptions{TTL: time.Minute}, load)
+	if calls != 2 {
+		t.Fatalf("未配置 NotFoundTTL 不应缓存，加载 %d 次", calls)
+	}
+
+	// 其他错误不缓存
+	boom := errors.New("boom")
+	if _, err := Remember(ctx, h, "error", RememberOptions{NotFoundTTL: time.Minute}, func() (int, error) {
+		return 0, boom
+	}); !errors.Is(err, boom) {
+		t.Fatalf("期望返回原错误: %v", err)
+	}
+	if n, _ := h.cache.ExistsCtx(ctx, h.buildKey("error")); n > 0 {
+		t.Fatal("加载失败不应写入缓存")
+	}
+}
+
+// TestRememberEarlyRefresh 测试 XFetch 提前刷新
+func TestRememberEarlyRefresh(t *testing.T) {
+	h, _ := newTestHelper(t)
+	ctx := context.Background()
+
+	version := 0
+	load := func() (int, error) {
+		version++
+		time.Sleep(20 * time.Millisecond)
+		return version, nil
+	}
+
+	// 不提前刷新时在有效期内一直命中
+	opts := RememberOptions{TTL: time.Second}
+	Remember(ctx, h, "v", opts, load)
+	if v, _ := Remember(ctx, h, "v", opts, load); v != 1 {
+		t.Fatalf("期望命中缓存: %d", v)
+	}
+
+	// 系数足够大时必然提前刷新
+	opts.Beta = 1e6
+	if v, _ := Remember(ctx, h, "v", opts, load); v != 2 {
+		t.Fatalf("期望提前刷新: %d", v)
+	}
+}
+
+// TestRememberLock 测试跨实例加载锁
+func TestRememberLock(t *testing.T) {
+	h, _ := newTestHelper(t)
+	ctx := context.Background()
+
+	// 模拟其他实例持有锁，稍后写入缓存（同一进程内会被合并，这里直接写入）
+	if lock, err := h.LockCtx(ctx, "remember:shared", time.Second); lock == nil || err != nil {
+		t.Fatalf("加锁失败: %v, %v", lock, err)
+	}
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		h.loadAndStore(ctx, h.buildKey("shared"), RememberOptions{TTL: time.Minute}, jsonEncoder, func() (interface{}, error) {
+			return "remote", nil
+		})
+	}()
+
+	opts := RememberOptions{TTL: time.Minute, Lock: time.Second, LockWait: 500 * time.Millisecond}
+	v, err := Remember(ctx, h, "shared", opts, func() (string, error) {
+		return "local", nil
+	})
+	if err != nil || v != "remote" {
+		t.Fatalf("期望等待其他实例的结果: %q, %v", v, err)
+	}
+
+	// 等待超时后自行加载
+	v, err = Remember(ctx, h, "timeout", RememberOptions{TTL: time.Minute, Lock: time.Second, LockWait: 20 * time.Millisecond}, func() (string, error) {
+		return "local", nil
+	})
+	if err != nil || v != "local" {
+		t.Fatalf("期望自行加载: %q, %v", v, err)
+	}
+}
+
+// lockRaceCache 在加锁前执行 beforeLock，模拟上一个持有锁的实例刚写入缓存并释放锁
+type lockRaceCache struct {
+	Cache
+	beforeLock func()
+}
+
+func (c *lockRaceCache) SetNXCtx(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	if c.beforeLock != nil {
+		c.beforeLock()
+		c.beforeLock = nil
+	}
+	return c.Cache.SetNXCtx(ctx, key, value, expiration)
+}
+
+// TestRememberLockRecheck 测试获取加载锁后重新读取缓存，不重复加载
+func TestRememberLockRecheck(t *testing.T) {
+	cfg, logger := newTestConfig(t)
+	memory, _ := NewMemoryCache(cfg, logger)
+	c := &lockRaceCache{Cache: memory}
+	h := NewCacheHelper(c, logger, "test")
+	ctx := context.Background()
+
+	store := func(v string) func() {
+		return func() {
+			h.loadAndStore(ctx, h.buildKey("race"), RememberOptions{TTL: time.Minute}, jsonEncoder, func() (interface{}, error) {
+				time.Sleep(time.Millisecond) // 加载耗时决定提前刷新的概率
+				return v, nil
+			})
+		}
+	}
+	opts := RememberOptions{TTL: time.Minute, Lock: time.Second}
+	load := func() (string, error) {
+		t.Error("其他实例已写入缓存，不应再加载")
+		return "local", nil
+	}
+
+	c.beforeLock = store("remote")
+	if v, err := Remember(ctx, h, "race", opts, load); err != nil || v != "remote" {
+		t.Fatalf("未命中时期望使用其他实例的结果: %q, %v", v, err)
+	}
+
+	// 提前刷新时其他实例已刷新
+	opts.Beta = 1e12
+	c.beforeLock = func() {
+		time.Sleep(2 * time.Millisecond)
+		store("refreshed")()
+	}
+	if v, err := Remember(ctx, h, "race", opts, load); err != nil || v != "refreshed" {
+		t.Fatalf("刷新时期望使用其他实例刷新的结果: %q, %v", v, err)
+	}
+}
+
+// TestRememberCancel 测试等待合并加载的调用方取消时立即返回，加载继续供其他调用方使用
+func TestRememberCancel(t *testing.T) {
+	h, _ := newTestHelper(t)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	load := func() (string, error) {
+		close(started)
+		<-release
+		return "slow", nil
+	}
+
+	done := make(chan string, 1)
+	go func() {
+		v, _ := Remember(context.Background(), h, "slow", RememberOptions{TTL: time.Minute}, load)
+		done <- v
+	}()
+	<-started
+
+	// 取消的调用方一直等待时超时放行
+	timer := time.AfterFunc(time.Second, func() { close(release) })
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := Remember(ctx, h, "slow", RememberOptions{TTL: time.Minute}, load)
+	expectErr(t, "取消等待", err, context.DeadlineExceeded)
+	if !timer.Stop() {
+		t.Fatal("取消后应立即返回，不等待加载完成")
+	}
+
+	close(release)
+	if v := <-done; v != "slow" {
+		t.Fatalf("其他调用方应得到加载结果: %q", v)
+	}
+	var jsonV string
+	err = h.RememberJSONCtx(ctx, "slow-json", time.Minute, &jsonV, func() (interface{}, error) { return "x", nil })
+	expectErr(t, "已取消的调用方", err, context.DeadlineExceeded)
+}
+
+// TestRememberBackends 测试不同驱动的序列化结果一致
+func TestRememberBackends(t *testing.T) {
+	cfg, logger := newTestConfig(t)
+	memory, _ := NewMemoryCache(cfg, logger)
+	file, err := NewFileCache(cfg, logger)
+	if err != nil {
+		t.Fatalf("创建文件缓存失败: %v", err)
+	}
+	defer file.Close()
+
+	ctx := context.Background()
+	want := testProfile{Name: "bob", Roles: []string{"a", "b"}}
+	for name, c := range map[string]Cache{"memory": memory, "file": file} {
+		h := NewCacheHelper(c, logger, "")
+		for i := 0; i < 2; i++ {
+			got, err := Remember(ctx, h, "profile", RememberOptions{TTL: time.Minute}, func() (testProfile, error) {
+				if i > 0 {
+					t.Errorf("%s: 第二次应命中缓存", name)
+				}
+				return want, nil
+			})
+			if err != nil || got.Name != want.Name || len(got.Roles) != 2 {
+				t.Fatalf("%s: 结果错误: %+v, %v", name, got, err)
+			}
+		}
+	}
+}
+
+// TestFlightKey 测试前缀包装的缓存使用独立的合并键
+func TestFlightKey(t *testing.T) {
+	_, c := newTestHelper(t)
+	a, b := WithPrefix(c, "t:a:"), WithPrefix(c, "t:b:")
+	if flightKey(a, "entry", "k") == flightKey(b, "entry", "k") {
+		t.Fatal("不同前缀不应合并")
+	}
+	if flightKey(WithPrefix(c, "t:a:"), "entry", "k") != flightKey(a, "entry", "k") {
+		t.Fatal("相同前缀应合并")
+	}
+}
+
+// TestRememberMixedFlight 测试同一键上混用 Remember 和 RememberJSONCtx 时不合并加载
+func TestRememberMixedFlight(t *testing.T) {
+	h, _ := newTestHelper(t)
+	ctx := context.Background()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		_, err := Remember(ctx, h, "mixed", RememberOptions{TTL: time.Minute}, func() (testProfile, error) {
+			close(started)
+			<-release
+			return testProfile{Name: "alice"}, nil
+		})
+		done <- err
+	}()
+	<-started
+
+	// 加入 Remember 的加载时会一直等待，超时后放行
+	timer := time.AfterFunc(time.Second, func() { close(release) })
+	var got testProfile
+	err := h.RememberJSONCtx(ctx, "mixed", time.Minute, &got, func() (interface{}, error) {
+		return testProfile{Name: "bob"}, nil
+	})
+	if !timer.Stop() {
+		t.Fatal("RememberJSONCtx 不应等待 Remember 的加载")
+	}
+	close(release)
+	if err != nil || got.Name != "bob" {
+		t.Fatalf("RememberJSONCtx 应自行加载: %+v, %v", got, err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Remember 失败: %v", err)
+	}
+}
+
+// testBackends 创建内存和文件缓存
+func testBackends(t *testing.T) map[string]Cache {
+	t.Helper()
+	backends := make(map[string]Cache)
+	for name, open := range conformanceBackends {
+		backends[name] = open(t)
+	}
+	return backends
+}
+
+// conformanceBackends 一致性测试的缓存后端，Redis 使用进程内的 miniredis
+var conformanceBackends = map[string]func(t *testing.T) Cache{
+	"memory": func(t *testing.T) Cache {
+		cfg, logger := newTestConfig(t)
+		c, _ := NewMemoryCache(cfg, logger)
+		return c
+	},
+	"file": func(t *testing.T) Cache {
+		cfg, logger := newTestConfig(t)
+		c, err := NewFileCache(cfg, logger)
+		if err != nil {
+			t.Fatalf("创建文件缓存失败: %v", err)
+		}
+		t.Cleanup(func() { c.Close() })
+		return c
+	},
+	"redis": func(t *testing.T) Cache {
+		return newTestRedis(t, "app:")
+	},
+	"redis-cluster": func(t *testing.T) Cache {
+		// miniredis 以单个主节点持有全部槽位的方式响应 CLUSTER SLOTS
+		return newTestRedisWith(t, "app:", nil, func(cfg *conf.CacheConfig) {
+			cfg.Redis.Mode = "cluster"
+		})
+	},
+}
+
+// newTestRedis 创建连接 miniredis 的 Redis 缓存
+func newTestRedis(t *testing.T, prefix string) Cache {
+	return newTestRedisWith(t, prefix, nil, nil)
+}
+
+// newTestRedisWith 创建连接已启动的 mr 的 Redis 缓存，mr 为 nil 时启动新的 miniredis，
+// 后台推进 miniredis 的时钟使过期时间生效；configure 用于修改连接配置
+func newTestRedisWith(t *testing.T, prefix string, mr *miniredis.Miniredis, configure func(cfg *conf.CacheConfig)) Cache {
+	t.Helper()
+	if mr == nil {
+		mr = miniredis.RunT(t)
+	}
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		last := time.Now()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				mr.FastForward(now.Sub(last))
+				last = now
+			}
+		}
+	}()
+
+	cfg, logger := newTestConfig(t)
+	port, _ := strconv.Atoi(mr.Port())
+	cfg.Cache.Host, cfg.Cache.Port, cfg.Cache.Prefix = mr.Host(), port, prefix
+	if configure != nil {
+		configure(&cfg.Cache)
+	}
+	c, err := NewRedisCache(cfg, logger)
+	if err != nil {
+		t.Fatalf("创建 Redis 缓存失败: %v", err)
+	}
+	t.Cleanup(func() {
+		close(done)
+		c.Close()
+	})
+	return c
+}
+
+// TestRedisConnect 测试部署模式配置、TLS 和启动重试
+func TestRedisConnect(t *testing.T) {
+	t.Run("配置错误", func(t *testing.T) {
+		cfg, logger := newTestConfig(t)
+		cfg.Cache.Redis.Mode = "sentinel"
+		if _, err := NewRedisCache(cfg, logger); err == nil {
+			t.Error("哨兵模式缺少 masterName 应返回错误")
+		}
+		cfg.Cache.Redis.Mode = "proxy"
+		if _, err := NewRedisCache(cfg, logger); err == nil {
+			t.Error("未知的部署模式应返回错误")
+		}
+		cfg.Cache.Redis.Mode = ""
+		cfg.Cache.Redis.TLS = conf.RedisTLSConfig{Enabled: true, CAFile: filepath.Join(t.TempDir(), "missing.pem")}
+		if _, err := NewRedisCache(cfg, logger); err == nil {
+			t.Error("CA 证书不存在应返回错误")
+		}
+	})
+
+	t.Run("TLS", func(t *testing.T) {
+		caFile, serverCert := newTestCertificates(t)
+		mr := miniredis.NewMiniRedis()
+		if err := mr.StartTLS(&tls.Config{Certificates: []tls.Certificate{serverCert}}); err != nil {
+			t.Fatalf("启动 miniredis 失败: %v", err)
+		}
+		t.Cleanup(mr.Close)
+
+		c := newTestRedisWith(t, "", mr, func(cfg *conf.CacheConfig) {
+			cfg.Redis.TLS = conf.RedisTLSConfig{Enabled: true, CAFile: caFile}
+		})
+		c.Set("k", "v", 0)
+		v, err := c.Get("k")
+		expect(t, "TLS 连接读取", v, "v")
+		expect(t, "TLS 连接读取的错误", err, nil)
+
+		// 不信任服务端证书时连接失败
+		cfg, logger := newTestConfig(t)
+		port, _ := strconv.Atoi(mr.Port())
+		cfg.Cache.Host, cfg.Cache.Port = mr.Host(), port
+		cfg.Cache.Redis.TLS.Enabled = true
+		if _, err := NewRedisCache(cfg, logger); err == nil {
+			t.Error("未配置 CA 时应无法验证服务端证书")
+		}
+	})
+
+	t.Run("启动重试", func(t *testing.T) {
+		// 预留地址，稍后再启动 miniredis
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("预留地址失败: %v", err)
+		}
+		addr := ln.Addr().String()
+		ln.Close()
+
+		mr := miniredis.NewMiniRedis()
+		t.Cleanup(mr.Close)
+		started := make(chan error, 1)
+		time.AfterFunc(150*time.Millisecond, func() { started <- mr.StartAddr(addr) })
+
+		cfg, logger := newTestConfig(t)
+		cfg.Cache.Redis.Addrs = []string{addr}
+		cfg.Cache.Redis.ConnectRetries = 5
+		cfg.Cache.Redis.ConnectBackoff = 50 * time.Millisecond
+		c, err := NewRedisCache(cfg, logger)
+		if err != nil {
+			t.Fatalf("重试后仍连接失败: %v", err)
+		}
+		defer c.Close()
+		if err := <-started; err != nil {
+			t.Fatalf("启动 miniredis 失败: %v", err)
+		}
+
+		// 不重试时立即返回错误
+		cfg.Cache.Redis.ConnectRetries = 0
+		cfg.Cache.Redis.Addrs = []string{"127.0.0.1:1"}
+		if _, err := NewRedisCache(cfg, logger); err == nil {
+			t.Error("无法连接时应返回错误")
+		}
+	})
+}
+
+// newTestCertificates 生成自签名 CA 和它签发的 127.0.0.1 服务端证书，返回 CA 证书文件路径和服务端证书
+func newTestCertificates(t *testing.T) (string, tls.Certificate) {
+	t.Helper()
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("生成 CA 证书失败: %v", err)
+	}
+	ca, _ := x509.ParseCertificate(caDER)
+
+	serverKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	serverDER, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "redis"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca, &serverKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("生成服务端证书失败: %v", err)
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}), 0o600); err != nil {
+		t.Fatalf("写入 CA 证书失败: %v", err)
+	}
+	return caFile, tls.Certificate{Certificate: [][]byte{serverDER}, PrivateKey: serverKey}
+}
+
+// TestHashTag 测试集群事务分组使用的哈希标签
+func TestHashTag(t *testing.T) {
+	for key, want := range map[string]string{
+		"user:1":            "user:1",
+		"{order:1}:items":   "order:1",
+		"app:{order:1}:sum": "order:1",
+		"{}:items":          "{}:items",
+		"a{b}{c}":           "b",
+		"{unterminated":     "{unterminated",
+	} {
+		expect(t, key, hashTag(key), want)
+	}
+}
+
+// TestSetNX 测试原子设置和比较删除、续期
+func TestSetNX(t *testing.T) {
+	ctx := context.Background()
+	for name, c := range testBackends(t) {
+		var wins atomic.Int32
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if ok, err := c.SetNXCtx(ctx, "nx", "owner", time.Minute); err != nil {
+					t.Errorf("%s: 设置失败: %v", name, err)
+				} else if ok {
+					wins.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+		if n := wins.Load(); n != 1 {
+			t.Fatalf("%s: 期望只有 1 次设置成功，实际 %d 次", name, n)
+		}
+
+		if ok, _ := c.CompareAndExpireCtx(ctx, "nx", "other", time.Hour); ok {
+			t.Fatalf("%s: 值不相等时不应续期", name)
+		}
+		if ok, _ := c.CompareAndExpireCtx(ctx, "nx", "owner", time.Hour); !ok {
+			t.Fatalf("%s: 值相等时应续期", name)
+		}
+		if ttl, _ := c.TTLCtx(ctx, "nx"); ttl < 59*time.Minute {
+			t.Fatalf("%s: 续期未生效: %v", name, ttl)
+		}
+		if ok, _ := c.CompareAndDelCtx(ctx, "nx", "other"); ok {
+			t.Fatalf("%s: 值不相等时不应删除", name)
+		}
+		if ok, _ := c.CompareAndDelCtx(ctx, "nx", "owner"); !ok {
+			t.Fatalf("%s: 值相等时应删除", name)
+		}
+		if ok, _ := c.SetNXCtx(ctx, "nx", "next", time.Minute); !ok {
+			t.Fatalf("%s: 删除后应可再次设置", name)
+		}
+	}
+}
+
+// TestLockMutualExclusion 测试阻塞获取的互斥性
+func TestLockMutualExclusion(t *testing.T) {
+	ctx := context.Background()
+	for name, c := range testBackends(t) {
+		var inside atomic.Int32
+		counter := 0
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				lock := NewLock(c, "lock:counter", LockOptions{TTL: 5 * time.Second, RetryMin: time.Millisecond, RetryMax: 5 * time.Millisecond})
+				if err := lock.Acquire(ctx); err != nil {
+					t.Errorf("%s: 获取锁失败: %v", name, err)
+					return
+				}
+				if inside.Add(1) != 1 {
+					t.Errorf("%s: 多个持有者同时进入", name)
+				}
+				counter++
+				time.Sleep(time.Millisecond)
+				inside.Add(-1)
+				if err := lock.Release(ctx); err != nil {
+					t.Errorf("%s: 释放锁失败: %v", name, err)
+				}
+			}()
+		}
+		wg.Wait()
+		if counter != 10 {
+			t.Fatalf("%s: 计数错误: %d", name, counter)
+		}
+	}
+}
+
+// TestLockOwnership 测试令牌校验、看门狗续期和阻塞超时
+func TestLockOwnership(t *testing.T) {
+	h, c := newTestHelper(t)
+	ctx := context.Background()
+
+	// 非持有者不能释放
+	a := NewLock(c, "lock:job", LockOptions{TTL: 100 * time.Millisecond, RenewInterval: 20 * time.Millisecond})
+	b := NewLock(c, "lock:job", LockOptions{TTL: 100 * time.Millisecond})
+	if ok, err := a.TryAcquire(ctx); !ok || err != nil {
+		t.Fatalf("获取锁失败: %v, %v", ok, err)
+	}
+	if err := b.Release(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("非持有者释放应返回 ErrLockNotHeld: %v", err)
+	}
+
+	// 看门狗续期，超过租期后仍然持有
+	time.Sleep(250 * time.Millisecond)
+	if ok, _ := b.TryAcquire(ctx); ok {
+		t.Fatal("看门狗未续期")
+	}
+
+	// 阻塞获取在 ctx 结束时返回
+	timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err := b.Acquire(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("期望超时: %v", err)
+	}
+
+	// 锁被删除后看门狗通知丢失
+	c.Del("lock:job")
+	select {
+	case <-a.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("未通知锁丢失")
+	}
+	if err := a.Release(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("锁丢失后释放应返回 ErrLockNotHeld: %v", err)
+	}
+
+	// 助手获取的锁带有令牌，可通过其他助手释放，如每次调用都会新建助手的 tenant.CacheHelper
+	lock, err := h.LockCtx(ctx, "task", time.Minute)
+	if lock == nil || err != nil {
+		t.Fatalf("获取锁失败: %v, %v", lock, err)
+	}
+	if held, _ := h.LockCtx(ctx, "task", time.Minute); held != nil {
+		t.Fatal("锁被占用时不应获取成功")
+	}
+	other := NewCacheHelper(c, h.logger, "test")
+	if err := other.UnlockCtx(ctx, nil); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("释放空锁应返回 ErrLockNotHeld: %v", err)
+	}
+	if err := other.UnlockCtx(ctx, lock); err != nil {
+		t.Fatalf("其他助手释放锁失败: %v", err)
+	}
+	if err := h.UnlockCtx(ctx, lock); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("重复释放应返回 ErrLockNotHeld: %v", err)
+	}
+	if err := h.WithLockCtx(ctx, "task", time.Minute, func() error { return nil }); err != nil {
+		t.Fatalf("释放后应可再次获取: %v", err)
+	}
+}
+
+// TestMemoryEviction 测试容量限制和各淘汰策略
+func TestMemoryEviction(t *testing.T) {
+	_, logger := newTestConfig(t)
+	ctx := context.Background()
+
+	// LRU：淘汰最久未访问的键
+	lru := newMemoryCache("", conf.MemoryCacheConfig{MaxEntries: 3, Policy: PolicyLRU, Shards: 1}, logger)
+	for _, k := range []string{"a", "b", "c"} {
+		lru.Set(k, k, 0)
+	}
+	lru.Get("a")
+	lru.Set("d", "d", 0)
+	if n, _ := lru.Exists("b"); n != 0 {
+		t.Fatal("LRU 应淘汰 b")
+	}
+	if n, _ := lru.Exists("a", "c", "d"); n != 3 {
+		t.Fatal("LRU 淘汰了错误的键")
+	}
+
+	// LFU：淘汰访问次数最少的键
+	lfu := newMemoryCache("", conf.MemoryCacheConfig{MaxEntries: 3, Policy: PolicyLFU, Shards: 1}, logger)
+	for _, k := range []string{"a", "b", "c"} {
+		lfu.Set(k, k, 0)
+	}
+	for i := 0; i < 3; i++ {
+		lfu.Get("a")
+		lfu.Get("c")
+	}
+	lfu.Get("b")
+	lfu.Set("d", "d", 0)
+	lfu.Get("d")
+	lfu.Set("e", "e", 0)
+	if n, _ := lfu.Exists("a", "c", "e"); n != 3 {
+		t.Fatal("LFU 不应淘汰高频键和新写入的键")
+	}
+	if n, _ := lfu.Exists("b", "d"); n != 0 {
+		t.Fatal("LFU 应淘汰低频键")
+	}
+
+	// TinyLFU：低频的新键不准入，高频的新键替换淘汰候选
+	tiny := newMemoryCache("", conf.MemoryCacheConfig{MaxEntries: 2, Policy: PolicyTinyLFU, Shards: 1}, logger)
+	tiny.Set("a", "a", 0)
+	tiny.Set("b", "b", 0)
+	for i := 0; i < 5; i++ {
+		tiny.Get("a")
+		tiny.Get("b")
+	}
+	tiny.Set("once", "x", 0)
+	if n, _ := tiny.Exists("once"); n != 0 {
+		t.Fatal("TinyLFU 不应准入低频键")
+	}
+	for i := 0; i < 10; i++ {
+		tiny.Get("hot")
+	}
+	tiny.Set("hot", "x", 0)
+	if n, _ := tiny.Exists("hot"); n != 1 {
+		t.Fatal("TinyLFU 应准入高频键")
+	}
+	// 原子操作不受准入限制
+	if ok, _ := tiny.SetNXCtx(ctx, "lock", "token", time.Minute); !ok {
+		t.Fatal("SetNX 不应被拒绝")
+	}
+	if n, _ := tiny.Exists("lock"); n != 1 {
+		t.Fatal("SetNX 写入的键不应被淘汰")
+	}
+
+	stats := tiny.Stats()
+	if stats.Entries != 2 || stats.Rejected != 1 || stats.Evictions != 2 || stats.Hits != 10 || stats.Misses != 10 {
+		t.Fatalf("统计错误: %+v", stats)
+	}
+}
+
+// TestMemoryBytes 测试按字节数限制和大小估算
+func TestMemoryBytes(t *testing.T) {
+	_, logger := newTestConfig(t)
+	c := newMemoryCache("", conf.MemoryCacheConfig{MaxBytes: 1000, Shards: 1}, logger)
+
+	for i := 0; i < 20; i++ {
+		c.Set(fmt.Sprintf("k%02d", i), string(make([]byte, 100)), 0)
+	}
+	stats := c.Stats()
+	if stats.Bytes > 1000 || stats.Entries == 0 || stats.Evictions == 0 {
+		t.Fatalf("超出字节限制: %+v", stats)
+	}
+	if n, _ := c.Exists("k19"); n != 1 {
+		t.Fatal("最新写入的键不应被淘汰")
+	}
+
+	// 结构类型的增删同步调整大小
+	c.Close()
+	c.HSet("h", "f1", "v1", "f2", "v2")
+	c.RPush("l", "a", "b")
+	c.LPop("l")
+	c.SAdd("s", "x", "y")
+	c.SRem("s", "x")
+	c.ZAdd("z", Z{Score: 1, Member: "m"})
+	c.HDel("h", "f1")
+	c.Del("h", "l", "s", "z")
+	if stats := c.Stats(); stats.Bytes != 0 || stats.Entries != 0 {
+		t.Fatalf("删除后大小未归零: %+v", stats)
+	}
+}
+
+// TestMemorySweep 测试后台清理过期键
+func TestMemorySweep(t *testing.T) {
+	_, logger := newTestConfig(t)
+	c := newMemoryCache("", conf.MemoryCacheConfig{SweepInterval: 10 * time.Millisecond}, logger)
+
+	for i := 0; i < 100; i++ {
+		c.Set(fmt.Sprintf("k%d", i), "v", 20*time.Millisecond)
+	}
+	c.Set("keep", "v", 0)
+	time.Sleep(100 * time.Millisecond)
+	if stats := c.Stats(); stats.Entries != 1 || stats.Expired != 100 {
+		t.Fatalf("过期键未清理: %+v", stats)
+	}
+
+	// Close 后清理协程退出，可以重复调用
+	c.Close()
+	c.Close()
+	select {
+	case <-c.done:
+	default:
+		t.Fatal("清理协程未退出")
+	}
+}
+
+// newTestFileCache 使用指定的文件缓存配置打开 dir 中的缓存
+func newTestFileCache(t *testing.T, dir string, opts conf.FileCacheConfig) *FileCache {
+	t.Helper()
+	cfg, logger := newTestConfig(t)
+	cfg.Cache.FilePath, cfg.Cache.File = dir, opts
+	c, err := NewFileCache(cfg, logger)
+	if err != nil {
+		t.Fatalf("创建文件缓存失败: %v", err)
+	}
+	return c.(*FileCache)
+}
+
+// TestFileCompression 测试压缩算法和配置错误，修改压缩算法后仍能读取旧数据
+func TestFileCompression(t *testing.T) {
+	dir := t.TempDir()
+	large := strings.Repeat("compressible ", 1000)
+	for _, codec := range []string{"gzip", "flate", "none"} {
+		c := newTestFileCache(t, dir, conf.FileCacheConfig{Compression: codec, CompressionThreshold: 1024})
+		c.Set(codec, large, 0)
+		c.Set(codec+":small", "v", 0)
+		c.Close()
+	}
+
+	c := newTestFileCache(t, dir, conf.FileCacheConfig{Compression: "none", SyncMode: "full"})
+	defer c.Close()
+	for _, codec := range []string{"gzip", "flate", "none"} {
+		c.memCache.Clear()
+		v, err := c.Get(codec)
+		expect(t, codec+" 压缩的值", v, large)
+		expect(t, codec+" 压缩的值的错误", err, nil)
+		v, _ = c.Get(codec + ":small")
+		expect(t, codec+" 未达到阈值的值", v, "v")
+	}
+
+	// 压缩后的值明显变小
+	c.view(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(defaultBucket))
+		if n := len(bucket.Get([]byte("flate"))); n >= len(large)/10 {
+			t.Errorf("flate 压缩后的大小: %d", n)
+		}
+		if n := len(bucket.Get([]byte("none"))); n < len(large) {
+			t.Errorf("不压缩的大小: %d", n)
+		}
+		return nil
+	})
+
+	cfg, logger := newTestConfig(t)
+	for _, opts := range []conf.FileCacheConfig{{Compression: "lz4"}, {SyncMode: "always"}} {
+		cfg.Cache.File = opts
+		if _, err := NewFileCache(cfg, logger); err == nil {
+			t.Errorf("配置 %+v 应返回错误", opts)
+		}
+	}
+}
+
+// TestFileMaintenance 测试后台清理过期键、按过期时间淘汰和 Close 停止后台任务
+func TestFileMaintenance(t *testing.T) {
+	t.Run("清理", func(t *testing.T) {
+		c := newTestFileCache(t, t.TempDir(), conf.FileCacheConfig{CleanupInterval: 50 * time.Millisecond})
+		for i := 0; i < 10; i++ {
+			c.Set(fmt.Sprintf("k%d", i), "v", time.Second)
+		}
+		c.Set("keep", "v", 0)
+		time.Sleep(1500 * time.Millisecond)
+
+		stats, err := c.Stats()
+		expect(t, "统计的错误", err, nil)
+		expect(t, "清理的过期键", stats.Expired, int64(10))
+		expect(t, "剩余的键", stats.Keys, int64(1))
+		expect(t, "过期时间记录", stats.Buckets[expirationBucket].Keys, int64(0))
+
+		// Close 后后台任务退出，可以重复调用
+		c.Close()
+		c.Close()
+		select {
+		case <-c.done:
+		default:
+			t.Fatal("后台任务未退出")
+		}
+	})
+
+	t.Run("淘汰", func(t *testing.T) {
+		const maxSize = 1 << 20
+		c := newTestFileCache(t, t.TempDir(), conf.FileCacheConfig{Compression: "none", MaxSize: maxSize})
+		defer c.Close()
+
+		c.Set("permanent", "v", 0)
+		value := strings.Repeat("x", 1024)
+		for i := 0; i < 2000; i++ {
+			c.Set(fmt.Sprintf("k%d", i), value, time.Duration(i+1)*time.Minute)
+		}
+		c.enforceMaxSize()
+
+		if size, _ := c.dataSize(); size > maxSize {
+			t.Errorf("淘汰后的数据大小 %d 超过上限", size)
+		}
+		stats, _ := c.Stats()
+		if stats.Evictions == 0 {
+			t.Fatal("没有淘汰任何键")
+		}
+
+		// 先淘汰最早过期的键，没有过期时间的键不淘汰
+		for key, want := range map[string]int64{"k0": 0, "k1999": 1, "permanent": 1} {
+			n, _ := c.Exists(key)
+			expect(t, key+" 是否存在", n, want)
+		}
+	})
+}
+
+// TestFileCompact 测试压缩期间的并发读写
+func TestFileCompact(t *testing.T) {
+	c := newTestFileCache(t, t.TempDir(), conf.FileCacheConfig{})
+	defer c.Close()
+
+	value := strings.Repeat("x", 2048)
+	for i := 0; i < 500; i++ {
+		c.Set(fmt.Sprintf("k%d", i), value, 0)
+	}
+	c.HSet("hash", "f", "v")
+	for i := 0; i < 400; i++ {
+		c.Del(fmt.Sprintf("k%d", i))
+	}
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for {
+				if _, err := c.Incr(fmt.Sprintf("w%d", w)); err != nil {
+					t.Errorf("压缩期间写入失败: %v", err)
+					return
+				}
+				if _, err := c.Get("k450"); err != nil {
+					t.Errorf("压缩期间读取失败: %v", err)
+					return
+				}
+				select {
+				case <-stop:
+					return
+				default:
+				}
+			}
+		}(w)
+	}
+
+	result, err := c.Compact(context.Background())
+	close(stop)
+	wg.Wait()
+	expect(t, "压缩的错误", err, nil)
+	if result.After >= result.Before {
+		t.Errorf("压缩后文件未变小: %+v", result)
+	}
+
+	v, _ := c.HGet("hash", "f")
+	expect(t, "压缩后读取哈希", v, "v")
+	stats, _ := c.Stats()
+	expect(t, "压缩次数", stats.Compactions, int64(1))
+	expect(t, "字符串键数量", stats.Buckets[defaultBucket].Keys, int64(104))
+	expect(t, "哈希键数量", stats.Buckets[hashBucket].Keys, int64(1))
+	expect(t, "键数量", stats.Keys, int64(105))
+	if stats.FileSize != result.After || stats.DataSize <= 0 {
+		t.Errorf("文件大小统计: %+v", stats)
+	}
+}
+
+// legacyMemoryCache 改造前的内存缓存：一把全局读写锁保护键值和过期时间两个 map，没有容量限制，
+// 只保留基准测试用到的 Get、Set。原实现在读锁下删除过期键，这里只判断过期，避免数据竞争
+type legacyMemoryCache struct {
+	data   map[string]interface{}
+	expiry map[string]time.Time
+	mu     sync.RWMutex
+}
+
+func newLegacyMemoryCache() *legacyMemoryCache {
+	return &legacyMemoryCache{data: map[string]interface{}{}, expiry: map[string]time.Time{}}
+}
+
+func (m *legacyMemoryCache) Get(key string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if exp, ok := m.expiry[key]; ok && exp.Before(time.Now()) {
+		return "", ErrKeyNotFound
+	}
+	if val, ok := m.data[key]; ok {
+		if str, ok := val.(string); ok {
+			return str, nil
+		}
+		return "", ErrTypeMismatch
+	}
+	return "", ErrKeyNotFound
+}
+
+func (m *legacyMemoryCache) Set(key string, value interface{}, expiration time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.data[key] = value
+	if expiration > 0 {
+		m.expiry[key] = time.Now().Add(expiration)
+	} else {
+		delete(m.expiry, key)
+	}
+	return nil
+}
+
+// benchStore 基准测试对比的读写操作
+type benchStore interface {
+	Get(key string) (string, error)
+	Set(key string, value interface{}, expiration time.Duration) error
+}
+
+// benchCase 基准测试对比的一种实现
+type benchCase struct {
+	name string
+	new  func() benchStore
+}
+
+// legacyCase 改造前的实现
+var legacyCase = benchCase{"legacy", func() benchStore { return newLegacyMemoryCache() }}
+
+// memoryCase 当前实现
+func memoryCase(name string, cfg conf.MemoryCacheConfig) benchCase {
+	return benchCase{name, func() benchStore { return newMemoryCache("", cfg, nil) }}
+}
+
+// benchmarkMemory 预先写入 10000 个键后并发读写，每 writeEvery 次操作中有一次写入，0 表示只读
+func benchmarkMemory(b *testing.B, c benchStore, writeEvery int) {
+	keys := make([]string, 10000)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key:%d", i)
+		c.Set(keys[i], "value", 0)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			key := keys[i%len(keys)]
+			if writeEvery > 0 && i%writeEvery == 0 {
+				c.Set(key, "value", 0)
+			} else {
+				c.Get(key)
+			}
+			i++
+		}
+	})
+}
+
+// BenchmarkMemoryCache 对比改造前的实现和当前实现的并发读、写、读写混合（9:1）吞吐，
+// 以及持续写入新键时的淘汰开销
+func BenchmarkMemoryCache(b *testing.B) {
+	loads := []struct {
+		name       string
+		writeEvery int
+	}{
+		{"read", 0},
+		{"write", 1},
+		{"mixed", 10},
+	}
+	for _, load := range loads {
+		stores := []benchCase{
+			legacyCase,
+			memoryCase("shards=1", conf.MemoryCacheConfig{Shards: 1, MaxEntries: 100000}),
+			memoryCase("shards=32", conf.MemoryCacheConfig{Shards: 32, MaxEntries: 100000}),
+		}
+		for _, store := range stores {
+			b.Run(load.name+"/"+store.name, func(b *testing.B) {
+				benchmarkMemory(b, store.new(), load.writeEvery)
+			})
+		}
+	}
+
+	// 持续写入新键：当前实现容量为 10000，需要淘汰；改造前的实现没有容量限制，键数量随写入增长
+	evict := []benchCase{legacyCase}
+	for _, policy := range []string{"lru", "lfu", "tinylfu"} {
+		evict = append(evict, memoryCase(policy, conf.MemoryCacheConfig{Shards: 32, MaxEntries: 10000, Policy: policy}))
+	}
+	for _, store := range evict {
+		b.Run("evict/"+store.name, func(b *testing.B) {
+			c := store.new()
+			var seq atomic.Int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					c.Set(fmt.Sprintf("key:%d", seq.Add(1)), "value", 0)
+				}
+			})
+		})
+	}
+}
+
+// TestTiered 测试两级缓存的回填和跨实例失效
+func TestTiered(t *testing.T) {
+	cfg, logger := newTestConfig(t)
+	ctx := context.Background()
+
+	// 两个实例共享二级缓存和消息通道，各自有一级缓存
+	l2, _ := NewMemoryCache(cfg, logger)
+	bus := NewMemoryBus()
+	node := func() *TieredCache {
+		l1, _ := NewMemoryCache(cfg, logger)
+		c, err := NewTiered(l1, l2, bus, TieredOptions{L1TTL: time.Minute}, logger)
+		if err != nil {
+			t.Fatalf("创建两级缓存失败: %v", err)
+		}
+		return c
+	}
+	a, b := node(), node()
+
+	a.SetCtx(ctx, "k", "v1", time.Minute)
+	if v, err := b.GetCtx(ctx, "k"); err != nil || v != "v1" {
+		t.Fatalf("读取二级缓存失败: %q, %v", v, err)
+	}
+	if v, _ := b.L1().GetCtx(ctx, "k"); v != "v1" {
+		t.Fatal("未回填一级缓存")
+	}
+
+	// 绕过两级缓存直接修改二级缓存，一级缓存仍返回旧值
+	l2.SetCtx(ctx, "k", "raw", time.Minute)
+	if v, _ := b.GetCtx(ctx, "k"); v != "v1" {
+		t.Fatalf("期望读取一级缓存: %q", v)
+	}
+
+	// 其他实例写入后失效
+	a.SetCtx(ctx, "k", "v2", time.Minute)
+	if v, _ := b.GetCtx(ctx, "k"); v != "v2" {
+		t.Fatalf("其他实例写入后一级缓存未失效: %q", v)
+	}
+	a.IncrByCtx(ctx, "n", 5)
+	b.GetCtx(ctx, "n")
+	a.IncrByCtx(ctx, "n", 1)
+	if v, _ := b.GetCtx(ctx, "n"); v != "6" {
+		t.Fatalf("递增后一级缓存未失效: %q", v)
+	}
+	a.DelCtx(ctx, "k")
+	if _, err := b.GetCtx(ctx, "k"); err == nil {
+		t.Fatal("删除后一级缓存未失效")
+	}
+
+	// 回填时间不超过二级缓存的剩余时间
+	a.SetCtx(ctx, "short", "v", 50*time.Millisecond)
+	b.GetCtx(ctx, "short")
+	time.Sleep(80 * time.Millisecond)
+	if _, err := b.GetCtx(ctx, "short"); err == nil {
+		t.Fatal("一级缓存的保留时间超过二级缓存")
+	}
+
+	// 管道写入后失效
+	a.SetCtx(ctx, "p", "v1", time.Minute)
+	b.GetCtx(ctx, "p")
+	pipe := a.Pipeline()
+	pipe.Set("p", "v2", time.Minute)
+	if _, err := pipe.Exec(ctx); err != nil {
+		t.Fatalf("执行管道失败: %v", err)
+	}
+	if v, _ := b.GetCtx(ctx, "p"); v != "v2" {
+		t.Fatalf("管道写入后一级缓存未失效: %q", v)
+	}
+
+	// 结构类型直接读写二级缓存
+	a.HSetCtx(ctx, "h", "f", "1")
+	if v, _ := b.HGetCtx(ctx, "h", "f"); v != "1" {
+		t.Fatalf("哈希读取失败: %q", v)
+	}
+}
+
+// TestInstrument 测试调用统计和命中率
+func TestInstrument(t *testing.T) {
+	cfg, logger := newTestConfig(t)
+	ctx := context.Background()
+	cfg.Cache.Metrics = true
+	c, err := New(cfg, logger)
+	if err != nil {
+		t.Fatalf("创建缓存失败: %v", err)
+	}
+	ic, ok := c.(*InstrumentedCache)
+	if !ok {
+		t.Fatalf("开启统计时应返回 *InstrumentedCache: %T", c)
+	}
+	if _, ok := Unwrap(c).(*MemoryCache); !ok {
+		t.Fatalf("Unwrap 应返回内存缓存: %T", Unwrap(c))
+	}
+
+	c.Set("a", "1", 0)
+	c.Get("a")
+	c.Get("missing")
+	c.MGet("a", "b", "c")
+	c.HSet("h", "f", "v")
+	c.HGet("h", "f")
+	c.Incr("a")
+	c.HGet("a", "f") // 类型不匹配，计为失败，不计入命中率
+	pipe := c.Pipeline()
+	pipe.Set("p", "v", 0)
+	pipe.Exec(ctx)
+
+	stats, err := ic.Stats(ctx)
+	if err != nil {
+		t.Fatalf("读取统计失败: %v", err)
+	}
+	expect(t, "命中", stats.Hits, int64(3))
+	expect(t, "未命中", stats.Misses, int64(3))
+	expect(t, "命中率", stats.HitRate, 0.5)
+	expect(t, "get 调用", stats.Commands["get"].Calls, int64(2))
+	expect(t, "get 失败", stats.Commands["get"].Errors, int64(0))
+	expect(t, "hget 失败", stats.Commands["hget"].Errors, int64(1))
+	expect(t, "管道", stats.Commands["pipeline"].Calls, int64(1))
+	expect(t, "键数量", stats.Usage.Keys, int64(3))
+
+	var bucketed int64
+	for _, n := range stats.Commands["get"].Buckets {
+		bucketed += n
+	}
+	expect(t, "直方图", bucketed, int64(2))
+	expect(t, "直方图区间数", len(stats.Commands["get"].Buckets), len(LatencyBuckets)+1)
+
+	ic.Reset()
+	stats, _ = ic.Stats(ctx)
+	if stats.Hits != 0 || len(stats.Commands) != 0 {
+		t.Fatalf("重置后统计未清空: %+v", stats)
+	}
+
+	// 两级缓存只统计整体，用量包含各级
+	cfg.Cache.Type = "tiered"
+	cfg.Cache.Tiered.L2 = "memory"
+	tiered, err := New(cfg, logger)
+	if err != nil {
+		t.Fatalf("创建两级缓存失败: %v", err)
+	}
+	defer tiered.Close()
+	inner := Unwrap(tiered).(*TieredCache)
+	if _, ok := inner.L1().(*InstrumentedCache); ok {
+		t.Fatal("一级缓存不应再包装统计")
+	}
+	tiered.Set("k", "v", 0)
+	usage, _ := UsageOf(ctx, tiered)
+	expect(t, "两级缓存的级数", len(usage.Levels), 2)
+	expect(t, "两级缓存的键数量", usage.Keys, int64(1))
+}
+
+// TypedBase 嵌入的结构体，字段展开到外层；gob 不编码未导出的嵌入字段，类型需要导出
+type TypedBase struct {
+	ID      uint64    `json:"id"`
+	Created time.Time `json:"created"`
+}
+
+// typedUser Typed 测试用结构体，覆盖常见的字段类型
+type typedUser struct {
+	TypedBase
+	Name     string            `json:"name"`
+	Nick     string            `json:"nick,omitempty"`
+	Secret   string            `json:"-"`
+	Age      int8              `msgpack:"age" json:"years"`
+	Score    float64           `json:"score"`
+	Ratio    float32           `json:"ratio"`
+	Balance  int64             `json:"balance"`
+	Active   bool              `json:"active"`
+	Avatar   []byte            `json:"avatar"`
+	Tags     []string          `json:"tags"`
+	Attrs    map[string]int    `json:"attrs"`
+	Manager  *testProfile      `json:"manager"`
+	Deleted  *time.Time        `json:"deleted"`
+	Extra    interface{}       `json:"extra"`
+	Children []TypedBase       `json:"children"`
+	Labels   map[string]string `json:"labels"`
+}
+
+// newTypedUser 创建各个字段都有值的测试数据，时间为 UTC 以便比较
+func newTypedUser() typedUser {
+	created := time.Unix(1700000000, 123456789).UTC()
+	return typedUser{
+		TypedBase: TypedBase{ID: 1 << 40, Created: created},
+		Name:      "张三",
+		Age:       -30,
+		Score:     98.5,
+		Ratio:     0.25,
+		Balance:   math.MinInt64,
+		Active:    true,
+		Avatar:    []byte{0, 0xc1, 0xff},
+		Tags:      []string{"a", "b"},
+		Attrs:     map[string]int{"x": 1, "y": -200},
+		Manager:   &testProfile{Name: "李四", Roles: []string{"admin"}},
+		Deleted:   &created,
+		Extra:     "text",
+		Children:  []TypedBase{{ID: 2, Created: created}},
+	}
+}
+
+// TestMsgpackCodec 测试 MessagePack 编码的格式和往返
+func TestMsgpackCodec(t *testing.T) {
+	// 编码结果与 MessagePack 规范一致
+	for _, c := range []struct {
+		value interface{}
+		want  []byte
+	}{
+		{nil, []byte{0xc0}},
+		{true, []byte{0xc3}},
+		{-1, []byte{0xff}},
+		{-33, []byte{0xd0, 0xdf}},
+		{127, []byte{0x7f}},
+		{300, []byte{0xcd, 0x01, 0x2c}},
+		{int64(math.MaxUint32) + 1, []byte{0xcf, 0, 0, 0, 1, 0, 0, 0, 0}},
+		{1.5, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{"", []byte{0xa0}},
+		{strings.Repeat("x", 32), append([]byte{0xd9, 32}, strings.Repeat("x", 32)...)},
+		{[]byte{1}, []byte{0xc4, 1, 1}},
+		{[]int{1, 2}, []byte{0x92, 1, 2}},
+		{map[string]int{"b": 2, "a": 1}, []byte{0x82, 0xa1, 'a', 1, 0xa1, 'b', 2}},
+		{testProfile{Name: "n"}, []byte{0x82, 0xa4, 'n', 'a', 'm', 'e', 0xa1, 'n', 0xa5, 'r', 'o', 'l', 'e', 's', 0xc0}},
+		{time.Unix(1, 2), []byte{0xc7, 12, 0xff, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 1}},
+	} {
+		got, err := MsgpackCodec.Marshal(c.value)
+		expect(t, fmt.Sprintf("编码 %#v", c.value), got, c.want)
+		expect(t, fmt.Sprintf("编码 %#v 的错误", c.value), err, nil)
+	}
+
+	// 结构体往返
+	user := newTypedUser()
+	user.Secret = "不编码"
+	data, err := MsgpackCodec.Marshal(user)
+	if err != nil {
+		t.Fatalf("编码失败: %v", err)
+	}
+	var decoded typedUser
+	if err := MsgpackCodec.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("解码失败: %v", err)
+	}
+	user.Secret = ""
+	expect(t, "结构体往返", decoded, user)
+
+	// 解码到 interface{}
+	var generic interface{}
+	if err := MsgpackCodec.Unmarshal(data, &generic); err != nil {
+		t.Fatalf("解码到 interface{} 失败: %v", err)
+	}
+	m, _ := generic.(map[string]interface{})
+	expect(t, "展开的嵌入字段", m["id"], int64(1<<40))
+	expect(t, "msgpack 标签优先", m["age"], int64(-30))
+	expect(t, "二进制数据", m["avatar"], []byte{0, 0xc1, 0xff})
+	expect(t, "时间", m["created"], user.Created)
+	if _, ok := m["nick"]; ok {
+		t.Error("omitempty 的空字段不应编码")
+	}
+
+	// 数值在兼容的类型之间转换，超出范围时报错
+	small, _ := MsgpackCodec.Marshal(200)
+	var f float32
+	expect(t, "整数解码为浮点数", MsgpackCodec.Unmarshal(small, &f), nil)
+	expect(t, "浮点数", f, float32(200))
+	var i8 int8
+	if err := MsgpackCodec.Unmarshal(small, &i8); err == nil {
+		t.Error("超出 int8 范围应报错")
+	}
+
+	// 损坏的数据返回错误，不会 panic
+	for n := range data {
+		var v typedUser
+		if err := MsgpackCodec.Unmarshal(data[:n], &v); err == nil {
+			t.Fatalf("截断到 %d 字节的数据应报错", n)
+		}
+	}
+	if err := MsgpackCodec.Unmarshal([]byte{0xdd, 0xff, 0xff, 0xff, 0xff}, &generic); err == nil {
+		t.Error("长度超过数据的数组应报错")
+	}
+}
+
+// TestTyped 测试不同缓存驱动之间复制编码后的值，以及 Remember 和读取旧格式的值
+func TestTyped(t *testing.T) {
+	ctx := context.Background()
+	backends := testBackends(t)
+	user := newTypedUser()
+
+	for _, codec := range []Codec{JSONCodec, GobCodec, MsgpackCodec} {
+		src := NewTyped[typedUser](backends["memory"], TypedOptions{Codec: codec, Compression: CompressionGzip, CompressionThreshold: 1})
+		if err := src.Set(ctx, "user", user, 0); err != nil {
+			t.Fatalf("%s: 写入失败: %v", codec.Name(), err)
+		}
+		raw, _ := backends["memory"].Get("user")
+
+		for name, c := range backends {
+			c.Set("copied", raw, 0)
+			got, err := NewTyped[typedUser](c, TypedOptions{}).Get(ctx, "copied")
+			if err != nil {
+				t.Fatalf("%s -> %s: 读取失败: %v", codec.Name(), name, err)
+			}
+			expect(t, codec.Name()+" -> "+name, got, user)
+		}
+	}
+
+	c := backends["memory"]
+	users := NewTyped[typedUser](c, TypedOptions{Codec: MsgpackCodec})
+
+	// 没有编码标记的值按 JSON 解析
+	helper := NewCacheHelper(c, nil, "")
+	helper.SetJSON("legacy", user, 0)
+	got, err := users.Get(ctx, "legacy")
+	expect(t, "读取 JSON 值", got, user)
+	expect(t, "读取 JSON 值的错误", err, nil)
+
+	_, err = users.Get(ctx, "missing")
+	expectErr(t, "不存在的键", err, ErrKeyNotFound)
+	c.Set("unknown", string([]byte{codecMagic, 200, 0}), 0)
+	_, err = users.Get(ctx, "unknown")
+	expectErr(t, "未注册的编码", err, ErrUnknownCodec)
+
+	// 并发未命中只加载一次，无法解码的值重新加载
+	var calls atomic.Int32
+	load := func() (typedUser, error) {
+		calls.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		return user, nil
+	}
+	c.Set("remember", "{broken", 0)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := users.Remember(ctx, "remember", RememberOptions{TTL: time.Minute}, load); err != nil || v.Name != user.Name {
+				t.Errorf("Remember: %+v, %v", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+	expect(t, "加载次数", calls.Load(), int32(1))
+	got, err = users.Get(ctx, "remember")
+	expect(t, "Get 读取 Typed.Remember 写入的值", got, user)
+	expect(t, "Get 读取 Typed.Remember 写入的值的错误", err, nil)
+
+	// Typed.Remember 与 Remember 函数的缓存内容相同，可以互相读取
+	got, err = Remember(ctx, helper, "remember", RememberOptions{TTL: time.Minute}, load)
+	expect(t, "Remember 读取 Typed.Remember 写入的值", got, user)
+	expect(t, "加载次数", calls.Load(), int32(1))
+	Remember(ctx, helper, "json", RememberOptions{TTL: time.Minute}, func() (typedUser, error) { return user, nil })
+	got, err = users.Get(ctx, "json")
+	expect(t, "Get 读取 Remember 写入的值", got, user)
+	expect(t, "Get 读取 Remember 写入的值的错误", err, nil)
+
+	// 类型不符或内容损坏时返回错误，不返回零值
+	Remember(ctx, helper, "number", RememberOptions{TTL: time.Minute}, func() (int, error) { return 1, nil })
+	if _, err := users.Get(ctx, "number"); err == nil {
+		t.Error("读取其他类型的 Remember 值应返回错误")
+	}
+	c.Set("corrupt", string([]byte{codecMagic, entryCodecID, 0})+"{broken", 0)
+	if _, err := users.Get(ctx, "corrupt"); err == nil {
+		t.Error("读取损坏的 Remember 值应返回错误")
+	}
+
+	// 缓存不存在的结果
+	opts := RememberOptions{TTL: time.Minute, NotFoundTTL: time.Minute}
+	_, err = users.Remember(ctx, "absent", opts, func() (typedUser, error) { return typedUser{}, ErrNotFound })
+	expectErr(t, "加载不存在的结果", err, ErrNotFound)
+	_, err = users.Get(ctx, "absent")
+	expectErr(t, "Get 读取缓存的不存在结果", err, ErrNotFound)
+
+	loadErr := errors.New("加载失败")
+	_, err = users.Remember(ctx, "failed", RememberOptions{TTL: time.Minute}, func() (typedUser, error) { return typedUser{}, loadErr })
+	expectErr(t, "加载失败", err, loadErr)
+	if n, _ := c.Exists("failed"); n != 0 {
+		t.Error("加载失败时不应写入缓存")
+	}
+}
+
+// messagingBackends 发布订阅和流的测试后端，包括追加前缀的包装
+func messagingBackends(t *testing.T) map[string]Cache {
+	backends := testBackends(t)
+	backends["prefixed"] = WithPrefix(backends["memory"], "tenant:")
+	return backends
+}
+
+// receive 读取一条订阅消息
+func receive(t *testing.T, sub Subscription) *Message {
+	t.Helper()
+	select {
+	case msg := <-sub.Channel():
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("等待订阅消息超时")
+		return nil
+	}
+}
+
+// TestPubSub 测试频道订阅和模式订阅
+func TestPubSub(t *testing.T) {
+	ctx := context.Background()
+	for name, c := range messagingBackends(t) {
+		sub, err := c.SubscribeCtx(ctx, "news")
+		if err != nil {
+			t.Fatalf("%s: 订阅失败: %v", name, err)
+		}
+		psub, err := c.PSubscribeCtx(ctx, "news.*")
+		if err != nil {
+			t.Fatalf("%s: 模式订阅失败: %v", name, err)
+		}
+
+		if n, err := c.PublishCtx(ctx, "news", "hello"); err != nil || n != 1 {
+			t.Fatalf("%s: 发布失败: %d, %v", name, n, err)
+		}
+		if n, _ := c.PublishCtx(ctx, "news.sport", 42); n != 1 {
+			t.Fatalf("%s: 模式订阅未接收: %d", name, n)
+		}
+		if n, _ := c.PublishCtx(ctx, "other", "x"); n != 0 {
+			t.Fatalf("%s: 无订阅的频道接收数应为 0: %d", name, n)
+		}
+
+		if msg := receive(t, sub); msg.Channel != "news" || msg.Payload != "hello" || msg.Pattern != "" {
+			t.Fatalf("%s: 频道消息错误: %+v", name, msg)
+		}
+		if msg := receive(t, psub); msg.Channel != "news.sport" || msg.Pattern != "news.*" || msg.Payload != "42" {
+			t.Fatalf("%s: 模式消息错误: %+v", name, msg)
+		}
+
+		// 取消订阅后通道关闭，不再计入接收数；Redis 异步处理取消订阅，需等待生效
+		sub.Close()
+		psub.Close()
+		for range sub.Channel() {
+		}
+		deadline := time.Now().Add(time.Second)
+		for {
+			n, _ := c.PublishCtx(ctx, "news", "bye")
+			if n == 0 {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("%s: 取消订阅后仍接收消息: %d", name, n)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
+
+// TestMatchPattern 测试通配符匹配
+func TestMatchPattern(t *testing.T) {
+	cases := []struct {
+		pattern, str string
+		want         bool
+	}{
+		{"*", "anything", true},
+		{"user:*", "user:1", true},
+		{"*:1", "user:1", true},
+		{"*ser*", "user:1", true},
+		{"u?er", "user", true},
+		{"u?er", "uuser", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{`news\*`, "news*", true},
+		{`news\*`, "newsx", false},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "axxbyy", false},
+	}
+	for _, c := range cases {
+		if got := matchPattern(c.pattern, c.str); got != c.want {
+			t.Errorf("matchPattern(%q, %q) = %v", c.pattern, c.str, got)
+		}
+	}
+}
+
+// TestBlockingPop 测试阻塞弹出
+func TestBlockingPop(t *testing.T) {
+	ctx := context.Background()
+	for name, c := range messagingBackends(t) {
+		// 已有元素时立即返回，按键的顺序检查
+		c.RPushCtx(ctx, "jobs:b", "b1", "b2")
+		if v, err := c.BLPopCtx(ctx, time.Second, "jobs:a", "jobs:b"); err != nil || v[0] != "jobs:b" || v[1] != "b1" {
+			t.Fatalf("%s: 弹出失败: %v, %v", name, v, err)
+		}
+		if v, err := c.BRPopCtx(ctx, time.Second, "jobs:b"); err != nil || v[1] != "b2" {
+			t.Fatalf("%s: 尾部弹出失败: %v, %v", name, v, err)
+		}
+
+		// 超时
+		start := time.Now()
+		if _, err := c.BLPopCtx(ctx, 50*time.Millisecond, "jobs:a"); !errors.Is(err, ErrKeyNotFound) {
+			t.Fatalf("%s: 超时应返回 ErrKeyNotFound: %v", name, err)
+		}
+		if time.Since(start) < 50*time.Millisecond {
+			t.Fatalf("%s: 未等待到超时", name)
+		}
+
+		// 等待期间写入后唤醒
+		go func() {
+			time.Sleep(30 * time.Millisecond)
+			c.LPushCtx(ctx, "jobs:a", "a1")
+		}()
+		if v, err := c.BLPopCtx(ctx, time.Second, "jobs:a"); err != nil || v[1] != "a1" {
+			t.Fatalf("%s: 写入后未唤醒: %v, %v", name, v, err)
+		}
+
+		// timeout 为 0 时等待到 ctx 结束
+		cctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		_, err := c.BLPopCtx(cctx, 0, "jobs:a")
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("%s: ctx 结束应返回 DeadlineExceeded: %v", name, err)
+		}
+	}
+}
+
+// TestStreams 测试流的读取和消费组
+func TestStreams(t *testing.T) {
+	ctx := context.Background()
+	for name, c := range messagingBackends(t) {
+		id1, err := c.XAddCtx(ctx, "events", map[string]interface{}{"type": "created", "id": 1})
+		if err != nil {
+			t.Fatalf("%s: 追加失败: %v", name, err)
+		}
+		id2, _ := c.XAddCtx(ctx, "events", map[string]interface{}{"type": "updated", "id": 1})
+		if n, _ := c.XLenCtx(ctx, "events"); n != 2 {
+			t.Fatalf("%s: 消息数量错误: %d", name, n)
+		}
+		if _, err := c.XAddCtx(ctx, "events", nil); err == nil {
+			t.Fatalf("%s: 空消息应失败", name)
+		}
+
+		// 从头读取和按 ID 续读
+		streams, err := c.XReadCtx(ctx, XReadArgs{Streams: map[string]string{"events": "0"}})
+		if err != nil || len(streams) != 1 || streams[0].Stream != "events" || len(streams[0].Messages) != 2 {
+			t.Fatalf("%s: 读取失败: %+v, %v", name, streams, err)
+		}
+		if msg := streams[0].Messages[0]; msg.ID != id1 || msg.Values["type"] != "created" || msg.Values["id"] != "1" {
+			t.Fatalf("%s: 消息内容错误: %+v", name, msg)
+		}
+		streams, _ = c.XReadCtx(ctx, XReadArgs{Streams: map[string]string{"events": id1}, Count: 1})
+		if len(streams) != 1 || streams[0].Messages[0].ID != id2 {
+			t.Fatalf("%s: 续读失败: %+v", name, streams)
+		}
+		if streams, err := c.XReadCtx(ctx, XReadArgs{Streams: map[string]string{"events": id2}}); err != nil || streams != nil {
+			t.Fatalf("%s: 没有新消息时应返回空结果: %+v, %v", name, streams, err)
+		}
+
+		// "$" 阻塞等待新消息
+		go func() {
+			time.Sleep(30 * time.Millisecond)
+			c.XAddCtx(ctx, "events", map[string]interface{}{"type": "deleted"})
+		}()
+		streams, err = c.XReadCtx(ctx, XReadArgs{Streams: map[string]string{"events": "$"}, Block: time.Second})
+		if err != nil || len(streams) != 1 || streams[0].Messages[0].Values["type"] != "deleted" {
+			t.Fatalf("%s: 阻塞读取失败: %+v, %v", name, streams, err)
+		}
+
+		// 消费组
+		if _, err := c.XReadGroupCtx(ctx, XReadGroupArgs{Group: "g", Consumer: "c1", Streams: map[string]string{"events": ">"}}); !errors.Is(err, ErrNoGroup) {
+			t.Fatalf("%s: 消费组不存在应返回 ErrNoGroup: %v", name, err)
+		}
+		if err := c.XGroupCreateCtx(ctx, "events", "g", "0"); err != nil {
+			t.Fatalf("%s: 创建消费组失败: %v", name, err)
+		}
+		if err := c.XGroupCreateCtx(ctx, "events", "g", "$"); err != nil {
+			t.Fatalf("%s: 重复创建消费组应忽略: %v", name, err)
+		}
+
+		// 两个消费者分摊消息
+		args := XReadGroupArgs{Group: "g", Consumer: "c1", Streams: map[string]string{"events": ">"}, Count: 2}
+		first, err := c.XReadGroupCtx(ctx, args)
+		if err != nil || len(first) != 1 || len(first[0].Messages) != 2 {
+			t.Fatalf("%s: 消费组读取失败: %+v, %v", name, first, err)
+		}
+		args.Consumer = "c2"
+		second, _ := c.XReadGroupCtx(ctx, args)
+		if len(second) != 1 || len(second[0].Messages) != 1 {
+			t.Fatalf("%s: 第二个消费者读取失败: %+v", name, second)
+		}
+		if streams, _ := c.XReadGroupCtx(ctx, args); streams != nil {
+			t.Fatalf("%s: 消息已全部投递: %+v", name, streams)
+		}
+
+		// 未确认的消息可以重新读取，确认后不再返回
+		pending := XReadGroupArgs{Group: "g", Consumer: "c1", Streams: map[string]string{"events": "0"}}
+		streams, _ = c.XReadGroupCtx(ctx, pending)
+		if len(streams) != 1 || len(streams[0].Messages) != 2 || streams[0].Messages[0].ID != id1 {
+			t.Fatalf("%s: 读取待确认消息失败: %+v", name, streams)
+		}
+		if n, err := c.XAckCtx(ctx, "events", "g", id1, id2, id2); err != nil || n != 2 {
+			t.Fatalf("%s: 确认失败: %d, %v", name, n, err)
+		}
+		streams, _ = c.XReadGroupCtx(ctx, pending)
+		if len(streams) != 1 || len(streams[0].Messages) != 0 {
+			t.Fatalf("%s: 确认后仍有待确认消息: %+v", name, streams)
+		}
+
+		// 消费组阻塞等待新消息
+		go func() {
+			time.Sleep(30 * time.Millisecond)
+			c.XAddCtx(ctx, "events", map[string]interface{}{"type": "archived"})
+		}()
+		args.Block = time.Second
+		streams, err = c.XReadGroupCtx(ctx, args)
+		if err != nil || len(streams) != 1 || streams[0].Messages[0].Values["type"] != "archived" {
+			t.Fatalf("%s: 消费组阻塞读取失败: %+v, %v", name, streams, err)
+		}
+	}
+}
+
+// conformanceCase 一致性测试用例，以 Redis 的行为为准，每个后端使用独立的实例执行
+type conformanceCase struct {
+	name string
+	run  func(t *testing.T, c Cache)
+}
+
+// expect 比较结果
+func expect(t *testing.T, what string, got, want interface{}) {
+	t.Helper()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s: 期望 %#v，实际 %#v", what, want, got)
+	}
+}
+
+// expectErr 检查错误类型
+func expectErr(t *testing.T, what string, err, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Errorf("%s: 期望错误 %v，实际 %v", what, target, err)
+	}
+}
+
+// sorted 排序后返回，用于比较无序结果
+func sorted(values []string, _ error) []string {
+	if values == nil {
+		values = []string{}
+	}
+	sort.Strings(values)
+	return values
+}
+
+var conformanceCases = []conformanceCase{
+	{"字符串读写", func(t *testing.T, c Cache) {
+		_, err := c.Get("missing")
+		expectErr(t, "读取不存在的键", err, ErrKeyNotFound)
+
+		c.Set("int", 42, 0)
+		c.Set("float", 1.5, 0)
+		c.Set("bool", true, 0)
+		c.Set("bytes", []byte("raw"), 0)
+		for key, want := range map[string]string{"int": "42", "float": "1.5", "bool": "1", "bytes": "raw"} {
+			got, err := c.Get(key)
+			expect(t, "读取 "+key, got, want)
+			expect(t, "读取 "+key+" 的错误", err, nil)
+		}
+	}},
+	{"计数器", func(t *testing.T, c Cache) {
+		n, err := c.Incr("n")
+		expect(t, "不存在时自增", n, int64(1))
+		expect(t, "自增错误", err, nil)
+		n, _ = c.IncrBy("n", 5)
+		expect(t, "增加", n, int64(6))
+		n, _ = c.Decr("n")
+		expect(t, "自减", n, int64(5))
+		v, _ := c.Get("n")
+		expect(t, "读取计数器", v, "5")
+
+		c.Set("text", "abc", 0)
+		if _, err := c.Incr("text"); err == nil {
+			t.Error("非整数自增应失败")
+		}
+	}},
+	{"过期时间", func(t *testing.T, c Cache) {
+		ttl, err := c.TTL("missing")
+		expect(t, "不存在的键", ttl, time.Duration(-2))
+		expect(t, "不存在的键的错误", err, nil)
+
+		c.Set("persist", "v", 0)
+		ttl, _ = c.TTL("persist")
+		expect(t, "永不过期", ttl, time.Duration(-1))
+
+		c.Set("temp", "v", 10*time.Second)
+		if ttl, _ := c.TTL("temp"); ttl <= 8*time.Second || ttl > 10*time.Second {
+			t.Errorf("剩余时间错误: %v", ttl)
+		}
+
+		expect(t, "设置不存在的键的过期时间", c.Expire("missing", time.Minute), nil)
+		c.Expire("persist", time.Minute)
+		if ttl, _ := c.TTL("persist"); ttl <= 58*time.Second {
+			t.Errorf("设置过期时间后剩余时间错误: %v", ttl)
+		}
+
+		c.Set("short", "v", 50*time.Millisecond)
+		time.Sleep(1200 * time.Millisecond)
+		_, err = c.Get("short")
+		expectErr(t, "读取过期的键", err, ErrKeyNotFound)
+		n, _ := c.Exists("short")
+		expect(t, "过期的键不存在", n, int64(0))
+	}},
+	{"删除和存在", func(t *testing.T, c Cache) {
+		c.Set("a", "1", 0)
+		c.Set("b", "2", 0)
+		c.RPush("list", "x")
+		c.HSet("hash", "f", "v")
+		n, _ := c.Exists("a", "b", "missing", "a", "list", "hash")
+		expect(t, "存在数量（重复的键重复计数）", n, int64(5))
+		n, _ = c.Del("a", "missing", "list", "hash")
+		expect(t, "删除数量", n, int64(3))
+		n, _ = c.Exists("a", "list", "hash")
+		expect(t, "删除后存在数量", n, int64(0))
+	}},
+	{"哈希", func(t *testing.T, c Cache) {
+		n, err := c.HSet("h", "f1", "v1", "f2", 2)
+		expect(t, "新增字段", n, int64(2))
+		expect(t, "新增字段的错误", err, nil)
+		n, _ = c.HSet("h", "f1", "x")
+		expect(t, "更新字段", n, int64(0))
+		n, _ = c.HSet("h", map[string]interface{}{"f3": true})
+		expect(t, "按 map 设置", n, int64(1))
+
+		v, _ := c.HGet("h", "f1")
+		expect(t, "读取字段", v, "x")
+		v, _ = c.HGet("h", "f2")
+		expect(t, "读取数字字段", v, "2")
+		_, err = c.HGet("h", "missing")
+		expectErr(t, "读取不存在的字段", err, ErrKeyNotFound)
+		_, err = c.HGet("missing", "f")
+		expectErr(t, "读取不存在的哈希", err, ErrKeyNotFound)
+
+		all, _ := c.HGetAll("h")
+		expect(t, "读取全部", all, map[string]string{"f1": "x", "f2": "2", "f3": "1"})
+		all, err = c.HGetAll("missing")
+		expect(t, "读取不存在的哈希", all, map[string]string{})
+		expect(t, "读取不存在的哈希的错误", err, nil)
+
+		ok, _ := c.HExists("h", "f2")
+		expect(t, "字段存在", ok, true)
+		ok, _ = c.HExists("h", "missing")
+		expect(t, "字段不存在", ok, false)
+		n, _ = c.HLen("h")
+		expect(t, "字段数量", n, int64(3))
+
+		n, _ = c.HDel("h", "f1", "missing")
+		expect(t, "删除字段", n, int64(1))
+		c.HDel("h", "f2", "f3")
+		n, _ = c.Exists("h")
+		expect(t, "删除全部字段后键不存在", n, int64(0))
+	}},
+	{"列表", func(t *testing.T, c Cache) {
+		c.RPush("l", "a", "b")
+		n, _ := c.LPush("l", "x", "y")
+		expect(t, "插入后长度", n, int64(4))
+		expect(t, "全部元素", must(c.LRange("l", 0, -1)), []string{"y", "x", "a", "b"})
+		expect(t, "中间范围", must(c.LRange("l", 1, 2)), []string{"x", "a"})
+		expect(t, "负数下标", must(c.LRange("l", -2, -1)), []string{"a", "b"})
+		expect(t, "越界范围", must(c.LRange("l", 5, 10)), []string{})
+		expect(t, "结束下标越界", must(c.LRange("l", 2, 100)), []string{"a", "b"})
+		n, _ = c.LLen("l")
+		expect(t, "长度", n, int64(4))
+
+		v, _ := c.LPop("l")
+		expect(t, "弹出头部", v, "y")
+		v, _ = c.RPop("l")
+		expect(t, "弹出尾部", v, "b")
+		c.LPop("l")
+		c.LPop("l")
+		_, err := c.LPop("l")
+		expectErr(t, "弹出空列表", err, ErrKeyNotFound)
+		n, _ = c.Exists("l")
+		expect(t, "弹出全部元素后键不存在", n, int64(0))
+
+		c.RPush("nums", 1, 2.5)
+		expect(t, "数字元素", must(c.LRange("nums", 0, -1)), []string{"1", "2.5"})
+		expect(t, "不存在的列表", must(c.LRange("missing", 0, -1)), []string{})
+	}},
+	{"集合", func(t *testing.T, c Cache) {
+		n, _ := c.SAdd("s", "a", "b", "a")
+		expect(t, "新增成员（去重）", n, int64(2))
+		n, _ = c.SAdd("s", "b", "c", 1)
+		expect(t, "新增部分成员", n, int64(2))
+		expect(t, "全部成员", sorted(c.SMembers("s")), []string{"1", "a", "b", "c"})
+		ok, _ := c.SIsMember("s", 1)
+		expect(t, "数字成员", ok, true)
+		ok, _ = c.SIsMember("s", "z")
+		expect(t, "不存在的成员", ok, false)
+		n, _ = c.SRem("s", "a", "z")
+		expect(t, "删除成员", n, int64(1))
+		n, _ = c.SCard("s")
+		expect(t, "成员数量", n, int64(3))
+		expect(t, "不存在的集合", sorted(c.SMembers("missing")), []string{})
+
+		c.SRem("s", "b", "c", "1")
+		n, _ = c.Exists("s")
+		expect(t, "删除全部成员后键不存在", n, int64(0))
+	}},
+	{"有序集合", func(t *testing.T, c Cache) {
+		n, _ := c.ZAdd("z", Z{Score: 1, Member: "a"}, Z{Score: 2, Member: "b"}, Z{Score: 1, Member: "c"})
+		expect(t, "新增成员", n, int64(3))
+		n, _ = c.ZAdd("z", Z{Score: 3, Member: "a"}, Z{Score: 1, Member: "d"})
+		expect(t, "更新分数不计数", n, int64(1))
+
+		// 分数相同时按成员的字典序
+		expect(t, "按分数排序", must(c.ZRange("z", 0, -1)), []string{"c", "d", "b", "a"})
+		expect(t, "负数下标", must(c.ZRange("z", -2, -1)), []string{"b", "a"})
+		expect(t, "越界范围", must(c.ZRange("z", 10, 20)), []string{})
+		withScores, _ := c.ZRangeWithScores("z", 0, 1)
+		expect(t, "带分数", withScores, []Z{{Score: 1, Member: "c"}, {Score: 1, Member: "d"}})
+
+		score, _ := c.ZScore("z", "a")
+		expect(t, "分数", score, float64(3))
+		_, err := c.ZScore("z", "missing")
+		expectErr(t, "不存在的成员", err, ErrKeyNotFound)
+
+		n, _ = c.ZRem("z", "a", "missing")
+		expect(t, "删除成员", n, int64(1))
+		n, _ = c.ZCard("z")
+		expect(t, "成员数量", n, int64(3))
+
+		c.ZAdd("nums", Z{Score: 1, Member: 10})
+		expect(t, "数字成员", must(c.ZRange("nums", 0, -1)), []string{"10"})
+	}},
+	{"有序集合分数查询", func(t *testing.T, c Cache) {
+		c.ZAdd("rank", Z{Score: 10, Member: "a"}, Z{Score: 20, Member: "b"}, Z{Score: 20, Member: "c"}, Z{Score: 30, Member: "d"})
+
+		expect(t, "闭区间", must(c.ZRangeByScore("rank", ZRangeBy{Min: "10", Max: "20"})), []string{"a", "b", "c"})
+		expect(t, "开区间", must(c.ZRangeByScore("rank", ZRangeBy{Min: "(10", Max: "(30"})), []string{"b", "c"})
+		expect(t, "无穷边界", must(c.ZRangeByScore("rank", ZRangeBy{Min: "-inf", Max: "+inf"})), []string{"a", "b", "c", "d"})
+		expect(t, "分页", must(c.ZRangeByScore("rank", ZRangeBy{Min: "-inf", Max: "+inf", Offset: 1, Count: 2})), []string{"b", "c"})
+		expect(t, "只有偏移", must(c.ZRangeByScore("rank", ZRangeBy{Min: "-inf", Max: "+inf", Offset: 3})), []string{"d"})
+		_, err := c.ZRangeByScore("rank", ZRangeBy{Min: "x", Max: "1"})
+		if err == nil {
+			t.Error("分数范围格式错误应失败")
+		}
+
+		expect(t, "倒序", must(c.ZRevRange("rank", 0, -1)), []string{"d", "c", "b", "a"})
+		expect(t, "倒序前两名", must(c.ZRevRange("rank", 0, 1)), []string{"d", "c"})
+
+		n, _ := c.ZCount("rank", "20", "+inf")
+		expect(t, "范围内数量", n, int64(3))
+		rank, _ := c.ZRank("rank", "c")
+		expect(t, "排名", rank, int64(2))
+		_, err = c.ZRank("rank", "missing")
+		expectErr(t, "不存在的成员的排名", err, ErrKeyNotFound)
+
+		score, _ := c.ZIncrBy("rank", 15, "a")
+		expect(t, "增加分数", score, float64(25))
+		score, _ = c.ZIncrBy("rank", 5, "e")
+		expect(t, "增加不存在的成员", score, float64(5))
+		expect(t, "增加后的顺序", must(c.ZRange("rank", 0, -1)), []string{"e", "b", "c", "a", "d"})
+
+		n, _ = c.ZRemRangeByScore("rank", "-inf", "(25")
+		expect(t, "按分数删除", n, int64(3))
+		expect(t, "删除后的成员", must(c.ZRange("rank", 0, -1)), []string{"a", "d"})
+		c.ZRemRangeByScore("rank", "-inf", "+inf")
+		n, _ = c.Exists("rank")
+		expect(t, "删除全部成员后键不存在", n, int64(0))
+
+		expect(t, "不存在的有序集合", must(c.ZRevRange("missing", 0, -1)), []string{})
+		n, _ = c.ZCount("missing", "-inf", "+inf")
+		expect(t, "不存在的有序集合的数量", n, int64(0))
+	}},
+	{"批量读写", func(t *testing.T, c Cache) {
+		expect(t, "批量写入", c.MSet("k1", "v1", "k2", 2), nil)
+		expect(t, "按 map 批量写入", c.MSet(map[string]interface{}{"k3": true}), nil)
+		c.RPush("list", "x")
+		values, err := c.MGet("k1", "missing", "k2", "k3", "list")
+		expect(t, "批量读取", values, []interface{}{"v1", nil, "2", "1", nil})
+		expect(t, "批量读取的错误", err, nil)
+
+		old, err := c.GetSet("k1", "new")
+		expect(t, "旧值", old, "v1")
+		expect(t, "旧值的错误", err, nil)
+		v, _ := c.Get("k1")
+		expect(t, "新值", v, "new")
+		_, err = c.GetSet("k4", "v4")
+		expectErr(t, "设置不存在的键", err, ErrKeyNotFound)
+		v, _ = c.Get("k4")
+		expect(t, "不存在的键仍然写入", v, "v4")
+		_, err = c.GetSet("list", "v")
+		expectErr(t, "设置列表", err, ErrTypeMismatch)
+	}},
+	{"移除过期时间和重命名", func(t *testing.T, c Cache) {
+		c.Set("temp", "v", time.Minute)
+		ok, _ := c.Persist("temp")
+		expect(t, "移除过期时间", ok, true)
+		ttl, _ := c.TTL("temp")
+		expect(t, "移除后永不过期", ttl, time.Duration(-1))
+		ok, _ = c.Persist("temp")
+		expect(t, "没有过期时间", ok, false)
+		ok, _ = c.Persist("missing")
+		expect(t, "不存在的键", ok, false)
+
+		c.Set("src", "v", time.Minute)
+		c.Set("dst", "old", 0)
+		expect(t, "重命名", c.Rename("src", "dst"), nil)
+		v, _ := c.Get("dst")
+		expect(t, "重命名后读取", v, "v")
+		if ttl, _ := c.TTL("dst"); ttl <= 0 {
+			t.Errorf("重命名应保留过期时间: %v", ttl)
+		}
+		n, _ := c.Exists("src")
+		expect(t, "原键不存在", n, int64(0))
+		expectErr(t, "重命名不存在的键", c.Rename("missing", "x"), ErrKeyNotFound)
+
+		c.RPush("queue", "a", "b")
+		c.ZAdd("board", Z{Score: 1, Member: "m"})
+		expect(t, "重命名列表", c.Rename("queue", "queue2"), nil)
+		expect(t, "重命名后的列表", must(c.LRange("queue2", 0, -1)), []string{"a", "b"})
+		expect(t, "重命名有序集合", c.Rename("board", "board2"), nil)
+		expect(t, "重命名后的有序集合", must(c.ZRange("board2", 0, -1)), []string{"m"})
+		expect(t, "重命名后的有序集合可以更新", must(c.ZRevRange("board2", 0, 0)), []string{"m"})
+		n, _ = c.Exists("queue", "board")
+		expect(t, "原列表和有序集合不存在", n, int64(0))
+	}},
+	{"游标遍历", func(t *testing.T, c Cache) {
+		want := make([]string, 0, 50)
+		for i := 0; i < 50; i++ {
+			key := fmt.Sprintf("scan:%02d", i)
+			c.Set(key, "v", 0)
+			want = append(want, key)
+		}
+		c.Set("other", "v", 0)
+
+		seen := make(map[string]bool)
+		var cursor uint64
+		for rounds := 0; ; rounds++ {
+			if rounds > 100 {
+				t.Fatal("遍历没有结束")
+			}
+			keys, next, err := c.Scan(cursor, "scan:*", 7)
+			if err != nil {
+				t.Fatalf("遍历失败: %v", err)
+			}
+			for _, key := range keys {
+				seen[key] = true
+			}
+			if next == 0 {
+				break
+			}
+			cursor = next
+		}
+		got := make([]string, 0, len(seen))
+		for key := range seen {
+			got = append(got, key)
+		}
+		expect(t, "遍历的键", sorted(got, nil), want)
+
+		h := NewCacheHelper(c, nil, "")
+		n, err := h.FlushByPattern("scan:*")
+		expect(t, "按模式删除的错误", err, nil)
+		if n == 0 {
+			t.Error("按模式删除数量为 0")
+		}
+		expect(t, "删除后剩余的键", sorted(c.Keys("*")), []string{"other"})
+	}},
+	{"助手批量操作", func(t *testing.T, c Cache) {
+		h := NewCacheHelper(c, nil, "app")
+		err := h.BatchSet(map[string]interface{}{"a": "1", "b": 2}, time.Minute)
+		expect(t, "批量设置的错误", err, nil)
+		if ttl, _ := c.TTL("app:a"); ttl <= 0 {
+			t.Errorf("批量设置应设置过期时间: %v", ttl)
+		}
+		values, err := h.BatchGet([]string{"a", "b", "missing"})
+		expect(t, "批量获取", values, map[string]string{"a": "1", "b": "2"})
+		expect(t, "批量获取的错误", err, nil)
+	}},
+	{"二进制值", func(t *testing.T, c Cache) {
+		binary := string([]byte{0xc1, 0x00, 0xff, 0xfe, 'a', 0x80})
+		c.Set("bin", binary, 0)
+		v, err := c.Get("bin")
+		expect(t, "二进制值", v, binary)
+		expect(t, "二进制值的错误", err, nil)
+		c.MSet("m1", []byte{0xff}, "m2", "")
+		values, _ := c.MGet("m1", "m2")
+		expect(t, "批量写入的二进制值", values, []interface{}{"\xff", ""})
+	}},
+	{"类型安全缓存", func(t *testing.T, c Cache) {
+		ctx := context.Background()
+		user := newTypedUser()
+		for _, codec := range []Codec{JSONCodec, GobCodec, MsgpackCodec} {
+			for _, compression := range []Compression{CompressionNone, CompressionGzip, CompressionFlate} {
+				typed := NewTyped[typedUser](c, TypedOptions{Codec: codec, Compression: compression, CompressionThreshold: 1})
+				what := fmt.Sprintf("%s/%d", codec.Name(), compression)
+				if err := typed.Set(ctx, "user", user, time.Minute); err != nil {
+					t.Fatalf("%s: 写入失败: %v", what, err)
+				}
+				got, err := typed.Get(ctx, "user")
+				expect(t, what, got, user)
+				expect(t, what+" 的错误", err, nil)
+
+				// 读取时按值中的标记选择编码，与选项无关
+				got, _ = NewTyped[typedUser](c, TypedOptions{Codec: JSONCodec}).Get(ctx, "user")
+				expect(t, what+" 用其他编码读取", got, user)
+			}
+		}
+		if ttl, _ := c.TTL("user"); ttl <= 0 {
+			t.Errorf("过期时间: %v", ttl)
+		}
+
+		ids := NewTyped[[]int64](WithPrefix(c, "p:"), TypedOptions{Codec: MsgpackCodec})
+		ids.Set(ctx, "ids", []int64{1, -2, math.MaxInt64}, 0)
+		got, _ := ids.Get(ctx, "ids")
+		expect(t, "切片", got, []int64{1, -2, math.MaxInt64})
+	}},
+	{"键类型", func(t *testing.T, c Cache) {
+		c.Set("str", "v", 0)
+		c.Incr("counter")
+		c.HSet("hash", "f", "v")
+		c.RPush("list", "a")
+		c.SAdd("set", "a")
+		c.ZAdd("zset", Z{Score: 1, Member: "a"})
+		c.XAdd("stream", map[string]interface{}{"f": "v"})
+		for key, want := range map[string]string{
+			"str": "string", "counter": "string", "hash": "hash", "list": "list",
+			"set": "set", "zset": "zset", "stream": "stream", "missing": "none",
+		} {
+			kind, err := c.Type(key)
+			expect(t, key+" 的类型", kind, want)
+			expect(t, key+" 的类型的错误", err, nil)
+		}
+
+		// 清空的结构视为不存在
+		c.LPop("list")
+		kind, _ := c.Type("list")
+		expect(t, "清空的列表", kind, "none")
+		kind, _ = WithPrefix(c, "p:").Type("str")
+		expect(t, "带前缀的类型", kind, "none")
+	}},
+	{"查看键", func(t *testing.T, c Cache) {
+		ctx := context.Background()
+		c.Set("user:1", "alice", time.Minute)
+		c.Set("user:*", "literal", 0)
+		c.Set("users", "other", 0)
+		c.HSet("user:h", "a", "1", "b", "2", "c", "3")
+		c.RPush("user:l", "x", "y", "z")
+		c.ZAdd("user:z", Z{Score: 2, Member: "b"}, Z{Score: 1, Member: "a"})
+		c.SAdd("user:s", "b", "a")
+		c.XAdd("user:x", map[string]interface{}{"f": "v"})
+
+		var infos []KeyInfo
+		var cursor uint64
+		for {
+			page, err := ScanKeys(ctx, c, "user:", cursor, 2)
+			if err != nil {
+				t.Fatalf("遍历键失败: %v", err)
+			}
+			infos = append(infos, page.Keys...)
+			if cursor = page.Cursor; cursor == 0 {
+				break
+			}
+		}
+		types := make(map[string]string)
+		for _, info := range infos {
+			types[info.Key] = info.Type
+			if info.Key == "user:1" && (info.TTL <= 0 || info.TTL > time.Minute) {
+				t.Errorf("过期时间: %v", info.TTL)
+			}
+			if info.Key == "user:*" && info.TTL != -1 {
+				t.Errorf("未设置过期时间: %v", info.TTL)
+			}
+		}
+		expect(t, "前缀下的键", types, map[string]string{
+			"user:1": "string", "user:*": "string", "user:h": "hash", "user:l": "list",
+			"user:z": "zset", "user:s": "set", "user:x": "stream",
+		})
+		page, _ := ScanKeys(ctx, c, "user:*", 0, 100)
+		expect(t, "前缀中的通配符按字面匹配", len(page.Keys), 1)
+
+		kv, err := InspectKey(ctx, c, "user:1", 0)
+		expect(t, "字符串", kv.Value, "alice")
+		expect(t, "字符串长度", kv.Size, int64(5))
+		expect(t, "字符串的错误", err, nil)
+		kv, _ = InspectKey(ctx, c, "user:h", 2)
+		expect(t, "截断的哈希", kv.Value, map[string]string{"a": "1", "b": "2"})
+		expect(t, "哈希字段数", kv.Size, int64(3))
+		expect(t, "哈希被截断", kv.Truncated, true)
+		kv, _ = InspectKey(ctx, c, "user:l", 2)
+		expect(t, "截断的列表", kv.Value, []string{"x", "y"})
+		kv, _ = InspectKey(ctx, c, "user:s", 0)
+		expect(t, "集合", kv.Value, []string{"a", "b"})
+		expect(t, "集合未截断", kv.Truncated, false)
+		kv, _ = InspectKey(ctx, c, "user:z", 0)
+		expect(t, "有序集合", kv.Value, []Z{{Score: 1, Member: "a"}, {Score: 2, Member: "b"}})
+		kv, _ = InspectKey(ctx, c, "user:x", 0)
+		if messages, _ := kv.Value.([]XMessage); len(messages) != 1 || messages[0].Values["f"] != "v" {
+			t.Errorf("流: %#v", kv.Value)
+		}
+		_, err = InspectKey(ctx, c, "missing", 0)
+		expectErr(t, "不存在的键", err, ErrKeyNotFound)
+	}},
+	{"用量", func(t *testing.T, c Cache) {
+		ctx := context.Background()
+		c.Set("a", strings.Repeat("x", 100), 0)
+		c.HSet("h", "f", "v")
+		usage, err := UsageOf(ctx, Instrument(WithPrefix(c, "p:")))
+		if err != nil {
+			t.Fatalf("读取用量失败: %v", err)
+		}
+		expect(t, "键数量", usage.Keys, int64(2))
+		if usage.Bytes == 0 || usage.Backend == "unknown" {
+			t.Errorf("用量: %+v", usage)
+		}
+	}},
+	{"键匹配", func(t *testing.T, c Cache) {
+		for _, key := range []string{"user:1", "user:2", "user:10", "admin"} {
+			c.Set(key, "v", 0)
+		}
+		c.RPush("user:list", "x")
+		expect(t, "单字符通配", sorted(c.Keys("user:?")), []string{"user:1", "user:2"})
+		expect(t, "前缀通配", sorted(c.Keys("user:*")), []string{"user:1", "user:10", "user:2", "user:list"})
+		expect(t, "字符集合", sorted(c.Keys("[a]*")), []string{"admin"})
+		expect(t, "带上下文", sorted(c.KeysCtx(context.Background(), "user:1*")), []string{"user:1", "user:10"})
+	}},
+	{"类型不匹配", func(t *testing.T, c Cache) {
+		c.Set("str", "v", 0)
+		c.RPush("list", "a")
+
+		_, err := c.LPush("str", "x")
+		expectErr(t, "向字符串插入列表元素", err, ErrTypeMismatch)
+		_, err = c.HGet("str", "f")
+		expectErr(t, "读取字符串的字段", err, ErrTypeMismatch)
+		_, err = c.SAdd("str", "m")
+		expectErr(t, "向字符串添加集合成员", err, ErrTypeMismatch)
+		_, err = c.ZAdd("str", Z{Score: 1, Member: "m"})
+		expectErr(t, "向字符串添加有序集合成员", err, ErrTypeMismatch)
+		_, err = c.Get("list")
+		expectErr(t, "读取列表", err, ErrTypeMismatch)
+		_, err = c.Incr("list")
+		expectErr(t, "列表自增", err, ErrTypeMismatch)
+		_, err = c.SMembers("list")
+		expectErr(t, "读取列表的集合成员", err, ErrTypeMismatch)
+
+		// 设置字符串覆盖任意类型
+		c.Set("list", "v", 0)
+		v, _ := c.Get("list")
+		expect(t, "覆盖后读取", v, "v")
+	}},
+	{"管道", func(t *testing.T, c Cache) {
+		ctx := context.Background()
+		pipe := c.Pipeline()
+		set := pipe.Set("s", "v", time.Minute)
+		get := pipe.Get("s")
+		incr := pipe.IncrBy("n", 5)
+		pipe.Decr("n")
+		hset := pipe.HSet("h", "f", "1")
+		hget := pipe.HGet("h", "f")
+		push := pipe.RPush("l", "a", "b")
+		pipe.LPush("l", "z")
+		sadd := pipe.SAdd("set", "x", "y")
+		zadd := pipe.ZAdd("z", Z{Score: 1, Member: "m"})
+		zincr := pipe.ZIncrBy("z", 2, "m")
+		wrong := pipe.HGet("s", "f")
+		after := pipe.Incr("n")
+		del := pipe.Del("set", "missing")
+		exists := pipe.Exists("s", "h", "set")
+		expect(t, "队列长度", pipe.Len(), 15)
+
+		_, err := c.Get("s")
+		expectErr(t, "执行前未写入", err, ErrKeyNotFound)
+
+		cmds, err := pipe.Exec(ctx)
+		expect(t, "命令数", len(cmds), 15)
+		expectErr(t, "返回第一条失败命令的错误", err, ErrTypeMismatch)
+		expect(t, "执行后清空队列", pipe.Len(), 0)
+
+		expect(t, "设置", set.Err(), nil)
+		text, _ := get.Text()
+		expect(t, "读取", text, "v")
+		n, _ := incr.Int64()
+		expect(t, "增加", n, int64(5))
+		n, _ = hset.Int64()
+		expect(t, "设置哈希字段", n, int64(1))
+		text, _ = hget.Text()
+		expect(t, "读取哈希字段", text, "1")
+		n, _ = push.Int64()
+		expect(t, "插入列表", n, int64(2))
+		n, _ = sadd.Int64()
+		expect(t, "添加集合成员", n, int64(2))
+		n, _ = zadd.Int64()
+		expect(t, "添加有序集合成员", n, int64(1))
+		score, _ := zincr.Float64()
+		expect(t, "增加分数", score, float64(3))
+		expectErr(t, "类型不匹配的命令", wrong.Err(), ErrTypeMismatch)
+		n, err = after.Int64()
+		expect(t, "出错后继续执行", n, int64(5))
+		expect(t, "出错后继续执行的错误", err, nil)
+		n, _ = del.Int64()
+		expect(t, "删除", n, int64(1))
+		n, _ = exists.Int64()
+		expect(t, "存在的键", n, int64(2))
+
+		expect(t, "列表内容", must(c.LRange("l", 0, -1)), []string{"z", "a", "b"})
+		if ttl, _ := c.TTL("s"); ttl <= 0 {
+			t.Errorf("管道设置的过期时间未生效: %v", ttl)
+		}
+
+		pipe.Set("discarded", "v", 0)
+		pipe.Discard()
+		cmds, err = pipe.Exec(ctx)
+		expect(t, "清空后执行", len(cmds), 0)
+		expect(t, "清空后执行的错误", err, nil)
+		n, _ = c.Exists("discarded")
+		expect(t, "清空的命令未执行", n, int64(0))
+
+		// 追加前缀的包装同样为管道中的键追加前缀
+		tx := WithPrefix(c, "t:").TxPipeline()
+		tx.Set("k", "v", 0)
+		tx.Exec(ctx)
+		text, _ = c.Get("t:k")
+		expect(t, "带前缀的事务", text, "v")
+	}},
+	{"事务原子性", func(t *testing.T, c Cache) {
+		// 集群模式下只有哈希标签相同的键在同一个事务中执行
+		ctx := context.Background()
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 50; j++ {
+					tx := c.TxPipeline()
+					tx.Incr("{n}a")
+					tx.Incr("{n}b")
+					if _, err := tx.Exec(ctx); err != nil {
+						t.Errorf("执行事务失败: %v", err)
+						return
+					}
+				}
+			}()
+		}
+
+		// 同时读取的两个计数器总是相等
+		for i := 0; i < 50; i++ {
+			tx := c.TxPipeline()
+			a, b := tx.Get("{n}a"), tx.Get("{n}b")
+			tx.Exec(ctx)
+			av, _ := a.Text()
+			bv, _ := b.Text()
+			if av != bv {
+				t.Fatalf("读取到部分执行的事务: a=%q b=%q", av, bv)
+			}
+		}
+		wg.Wait()
+
+		v, _ := c.Get("{n}a")
+		expect(t, "全部执行后的计数", v, "200")
+	}},
+}
+
+// TestScanPage 测试遍历期间删除和写入其他键时，一直存在的键全部返回
+func TestScanPage(t *testing.T) {
+	keys := make([]string, 100)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key:%d", i)
+	}
+
+	seen := make(map[string]bool)
+	var cursor uint64
+	for round := 0; ; round++ {
+		if round > 100 {
+			t.Fatal("遍历没有结束")
+		}
+		page, next := scanPage(append([]string(nil), keys...), cursor, 7)
+		for _, key := range page {
+			seen[key] = true
+		}
+
+		// 删除本批的第一个键并写入新键，模拟遍历期间的修改
+		if len(page) > 0 {
+			for i, key := range keys {
+				if key == page[0] {
+					keys = append(keys[:i], keys[i+1:]...)
+					break
+				}
+			}
+		}
+		keys = append(keys, fmt.Sprintf("new:%d", round))
+
+		if next == 0 {
+			break
+		}
+		cursor = next
+	}
+
+	for _, key := range keys {
+		if strings.HasPrefix(key, "key:") && !seen[key] {
+			t.Errorf("一直存在的键未返回: %s", key)
+		}
+	}
+}
+
+// must 忽略错误，错误由结果比较体现
+func must(values []string, _ error) []string {
+	if values == nil {
+		values = []string{}
+	}
+	return values
+}
+
+// TestConformance 所有后端执行相同的用例
+func TestConformance(t *testing.T) {
+	for _, tc := range conformanceCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for name, open := range conformanceBackends {
+				t.Run(name, func(t *testing.T) {
+					t.Parallel()
+					tc.run(t, open(t))
+				})
+			}
+		})
+	}
+}
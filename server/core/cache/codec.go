@@ -0,0 +1,164 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// codecMagic 编码值的第一个字节。0xC1 在 MessagePack 中不会出现，也不是合法的 UTF-8 首字节，
+// JSON 和普通字符串不会以它开头，没有该标记的值按 JSON 解析
+const codecMagic = byte(0xC1)
+
+// codecHeaderSize 编码值的头部长度：标记、编码 ID、压缩标记
+const codecHeaderSize = 3
+
+// ErrUnknownCodec 值中的编码 ID 没有注册
+var ErrUnknownCodec = errors.New("未注册的编码")
+
+// Codec 值的序列化方式，编码后的值带有 ID，读取时按 ID 选择编码，与写入时使用哪种编码无关
+type Codec interface {
+	// ID 写入值中的编码标记，0 为记忆模式的缓存内容，1-63 为内置编码保留，自定义编码使用 64-255
+	ID() byte
+	// Name 编码名称，用于日志和错误信息
+	Name() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// Compression 编码值的压缩算法
+type Compression byte
+
+// 编码值的压缩算法，标记与文件缓存的压缩标记一致
+const (
+	CompressionNone  Compression = 0
+	CompressionGzip  Compression = Compression(gzipFlag)
+	CompressionFlate Compression = Compression(flateFlag)
+)
+
+// 内置编码
+var (
+	JSONCodec    Codec = jsonCodec{}
+	GobCodec     Codec = gobCodec{}
+	MsgpackCodec Codec = msgpackCodec{}
+)
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[byte]Codec{}
+)
+
+func init() {
+	RegisterCodec(JSONCodec, GobCodec, MsgpackCodec)
+}
+
+// RegisterCodec 注册编码，ID 为 0 或重复时 panic，通常在 init 中调用
+func RegisterCodec(list ...Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+
+	for _, c := range list {
+		if c.ID() == entryCodecID {
+			panic(fmt.Sprintf("编码 %s 的 ID 不能为 0", c.Name()))
+		}
+		if existing, ok := codecs[c.ID()]; ok {
+			panic(fmt.Sprintf("编码 ID 重复: %d（%s、%s）", c.ID(), existing.Name(), c.Name()))
+		}
+		codecs[c.ID()] = c
+	}
+}
+
+// codecByID 返回已注册的编码
+func codecByID(id byte) (Codec, error) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	c, ok := codecs[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownCodec, id)
+	}
+	return c, nil
+}
+
+// encodeValue 编码值并写入头部，数据不小于 threshold 时按 compression 压缩
+func encodeValue(codec Codec, compression Compression, threshold int, v interface{}) ([]byte, error) {
+	data, err := codec.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("%s 序列化失败: %w", codec.Name(), err)
+	}
+
+	header := []byte{codecMagic, codec.ID(), byte(CompressionNone)}
+	if compression == CompressionNone || len(data) < threshold {
+		return append(header, data...), nil
+	}
+	header[2] = byte(compression)
+	return compress(byte(compression), header, data)
+}
+
+// decodeValue 按头部的编码和压缩标记解码值，没有头部的值按 JSON 解析；
+// 记忆模式的缓存内容解析出其中的值，缓存的是不存在的结果时返回 ErrNotFound
+func decodeValue(data []byte, v interface{}) error {
+	if len(data) < codecHeaderSize || data[0] != codecMagic {
+		if err := json.Unmarshal(data, v); err != nil {
+			return fmt.Errorf("json 反序列化失败: %w", err)
+		}
+		return nil
+	}
+	if data[1] == entryCodecID {
+		e, err := parseEntry(data)
+		if err != nil {
+			return err
+		}
+		return e.decode(v)
+	}
+
+	codec, err := codecByID(data[1])
+	if err != nil {
+		return err
+	}
+	payload := data[codecHeaderSize:]
+	if flag := data[2]; flag != byte(CompressionNone) {
+		if payload, err = decompress(flag, payload); err != nil {
+			return fmt.Errorf("解压失败: %w", err)
+		}
+	}
+	if err := codec.Unmarshal(payload, v); err != nil {
+		return fmt.Errorf("%s 反序列化失败: %w", codec.Name(), err)
+	}
+	return nil
+}
+
+// jsonCodec JSON 编码，与 CacheHelper.SetJSON 写入的内容兼容
+type jsonCodec struct{}
+
+func (jsonCodec) ID() byte     { return 1 }
+func (jsonCodec) Name() string { return "json" }
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// gobCodec gob 编码，保留 Go 的类型信息；接口类型的字段需要先通过 gob.Register 注册具体类型
+type gobCodec struct{}
+
+func (gobCodec) ID() byte     { return 2 }
+func (gobCodec) Name() string { return "gob" }
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
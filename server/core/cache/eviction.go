@@ -0,0 +1,276 @@
+package cache
+
+import (
+	"container/heap"
+	"container/list"
+)
+
+// 内存缓存的淘汰策略
+const (
+	PolicyLRU     = "lru"     // 淘汰最久未访问的键
+	PolicyLFU     = "lfu"     // 淘汰访问次数最少的键，次数相同时淘汰最久未访问的
+	PolicyTinyLFU = "tinylfu" // 按近期访问频率决定新键是否准入，淘汰顺序同 LRU
+)
+
+// evictionPolicy 分片内的淘汰策略，调用方持有分片锁
+type evictionPolicy interface {
+	// add 记录新写入的键
+	add(key string)
+	// access 记录一次访问，键不存在时也会调用（用于统计频率）
+	access(key string)
+	// remove 移除键
+	remove(key string)
+	// victim 返回下一个淘汰的键，跳过 skip
+	victim(skip string) (string, bool)
+	// admit 缓存已满时判断新键 candidate 是否可以替换 victim
+	admit(candidate, victim string) bool
+}
+
+// newEvictionPolicy 创建淘汰策略，capacity 用于估算频率统计的规模
+func newEvictionPolicy(name string, capacity int) evictionPolicy {
+	switch name {
+	case PolicyLFU:
+		return newLFUPolicy()
+	case PolicyTinyLFU:
+		return &tinyLFUPolicy{lruPolicy: newLRUPolicy(), sketch: newCountMinSketch(capacity)}
+	default:
+		return newLRUPolicy()
+	}
+}
+
+// lruPolicy 最近最少使用
+type lruPolicy struct {
+	order *list.List // 队首为最近访问
+	elems map[string]*list.Element
+}
+
+func newLRUPolicy() *lruPolicy {
+	return &lruPolicy{order: list.New(), elems: make(map[string]*list.Element)}
+}
+
+func (p *lruPolicy) add(key string) {
+	if e, ok := p.elems[key]; ok {
+		p.order.MoveToFront(e)
+		return
+	}
+	p.elems[key] = p.order.PushFront(key)
+}
+
+func (p *lruPolicy) access(key string) {
+	if e, ok := p.elems[key]; ok {
+		p.order.MoveToFront(e)
+	}
+}
+
+func (p *lruPolicy) remove(key string) {
+	if e, ok := p.elems[key]; ok {
+		p.order.Remove(e)
+		delete(p.elems, key)
+	}
+}
+
+func (p *lruPolicy) victim(skip string) (string, bool) {
+	for e := p.order.Back(); e != nil; e = e.Prev() {
+		if key := e.Value.(string); key != skip {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+func (p *lruPolicy) admit(candidate, victim string) bool {
+	return true
+}
+
+// lfuEntry LFU 堆中的键
+type lfuEntry struct {
+	key   string
+	count uint64
+	tick  uint64 // 最近访问序号，次数相同时淘汰较早访问的
+	index int
+}
+
+// lfuHeap 按访问次数和访问序号排序的小顶堆
+type lfuHeap []*lfuEntry
+
+func (h lfuHeap) Len() int { return len(h) }
+func (h lfuHeap) Less(i, j int) bool {
+	if h[i].count != h[j].count {
+		return h[i].count < h[j].count
+	}
+	return h[i].tick < h[j].tick
+}
+func (h lfuHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index, h[j].index = i, j
+}
+func (h *lfuHeap) Push(x interface{}) {
+	e := x.(*lfuEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+func (h *lfuHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
+
+// lfuPolicy 最不经常使用
+type lfuPolicy struct {
+	heap    lfuHeap
+	entries map[string]*lfuEntry
+	tick    uint64
+}
+
+func newLFUPolicy() *lfuPolicy {
+	return &lfuPolicy{entries: make(map[string]*lfuEntry)}
+}
+
+func (p *lfuPolicy) add(key string) {
+	if _, ok := p.entries[key]; ok {
+		p.access(key)
+		return
+	}
+	p.tick++
+	e := &lfuEntry{key: key, count: 1, tick: p.tick}
+	p.entries[key] = e
+	heap.Push(&p.heap, e)
+}
+
+func (p *lfuPolicy) access(key string) {
+	if e, ok := p.entries[key]; ok {
+		p.tick++
+		e.count++
+		e.tick = p.tick
+		heap.Fix(&p.heap, e.index)
+	}
+}
+
+func (p *lfuPolicy) remove(key string) {
+	if e, ok := p.entries[key]; ok {
+		heap.Remove(&p.heap, e.index)
+		delete(p.entries, key)
+	}
+}
+
+func (p *lfuPolicy) victim(skip string) (string, bool) {
+	if len(p.heap) == 0 {
+		return "", false
+	}
+	if p.heap[0].key != skip {
+		return p.heap[0].key, true
+	}
+	// 堆顶被跳过时，次小值在其子节点中
+	var next *lfuEntry
+	for _, i := range []int{1, 2} {
+		if i < len(p.heap) && (next == nil || p.heap.Less(i, next.index)) {
+			next = p.heap[i]
+		}
+	}
+	if next == nil {
+		return "", false
+	}
+	return next.key, true
+}
+
+func (p *lfuPolicy) admit(candidate, victim string) bool {
+	return true
+}
+
+// tinyLFUPolicy 在 LRU 的基础上用计数草图统计近期访问频率，新键频率低于淘汰候选时不准入
+type tinyLFUPolicy struct {
+	*lruPolicy
+	sketch *countMinSketch
+}
+
+func (p *tinyLFUPolicy) add(key string) {
+	p.sketch.increment(key)
+	p.lruPolicy.add(key)
+}
+
+func (p *tinyLFUPolicy) access(key string) {
+	p.sketch.increment(key)
+	p.lruPolicy.access(key)
+}
+
+func (p *tinyLFUPolicy) admit(candidate, victim string) bool {
+	p.sketch.increment(candidate)
+	return p.sketch.estimate(candidate) > p.sketch.estimate(victim)
+}
+
+// countMinSketch 4 位计数的 Count-Min 草图，累计计数达到上限后减半，使频率反映近期访问
+type countMinSketch struct {
+	rows      [4][]uint8
+	mask      uint64
+	additions int
+	resetAt   int
+}
+
+func newCountMinSketch(capacity int) *countMinSketch {
+	width := 64
+	for width < capacity {
+		width <<= 1
+	}
+	s := &countMinSketch{mask: uint64(width - 1), resetAt: width * 10}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+	return s
+}
+
+// indexes 双重哈希计算每行的位置
+func (s *countMinSketch) indexes(key string) [4]uint64 {
+	// 分片按哈希的低位选择，这里先混合高位，避免同一分片内的键落在相同位置
+	sum := hashKey(key)
+	sum ^= sum >> 33
+	sum *= 0xff51afd7ed558ccd
+	sum ^= sum >> 33
+	h1, h2 := sum&0xffffffff, sum>>32|1
+	var idx [4]uint64
+	for i := range idx {
+		idx[i] = (h1 + uint64(i)*h2) & s.mask
+	}
+	return idx
+}
+
+func (s *countMinSketch) increment(key string) {
+	for i, j := range s.indexes(key) {
+		if s.rows[i][j] < 15 {
+			s.rows[i][j]++
+		}
+	}
+	if s.additions++; s.additions >= s.resetAt {
+		s.reset()
+	}
+}
+
+func (s *countMinSketch) estimate(key string) uint8 {
+	est := uint8(15)
+	for i, j := range s.indexes(key) {
+		if v := s.rows[i][j]; v < est {
+			est = v
+		}
+	}
+	return est
+}
+
+// reset 所有计数减半
+func (s *countMinSketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	s.additions /= 2
+}
+
+// hashKey FNV-1a 哈希
+func hashKey(key string) uint64 {
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= 1099511628211
+	}
+	return h
+}
@@ -2,9 +2,11 @@ package cache
 
 import (
 	"bytes"
+	"compress/flate"
 	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -12,11 +14,12 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"encoding/binary"
 	"math"
-	"regexp"
 	"sort"
 
 	"github.com/zhoudm1743/go-web/core/conf"
@@ -33,66 +36,128 @@ const (
 	zsetBucket       = "zset"
 	zsetScoreBucket  = "zset_score"
 	expirationBucket = "expiration"
+	streamBucket     = "stream"
 
-	// 压缩相关常量
-	compressionThreshold = 4096    // 超过4KB的值进行压缩
-	compressionFlag      = byte(1) // 标记值是否被压缩
+	// 值的压缩标记，写在压缩数据的第一个字节；未压缩的值是 JSON，不会以这些字节开头
+	gzipFlag  = byte(1)
+	flateFlag = byte(2)
+
+	defaultCompressionThreshold = 4096 // 默认超过4KB的值进行压缩
+	evictBatchSize              = 100  // 每个事务淘汰的过期时间记录数
+	maxCleanPerRun              = 1000 // 每次清理的过期时间记录数
 )
 
-// 压缩值
-func compressValue(data []byte) ([]byte, error) {
-	// 如果数据小于阈值，不进行压缩
-	if len(data) < compressionThreshold {
-		return data, nil
-	}
+// fileCodec 值的压缩方式，只压缩超过阈值的值
+type fileCodec struct {
+	flag      byte // 0 表示不压缩
+	threshold int
+}
 
-	var buf bytes.Buffer
-	zw := gzip.NewWriter(&buf)
-	_, err := zw.Write(data)
-	if err != nil {
-		return nil, err
+// newFileCodec 根据配置创建压缩方式，name 为空时使用 gzip，threshold 不大于 0 时使用默认阈值
+func newFileCodec(name string, threshold int) (fileCodec, error) {
+	if threshold <= 0 {
+		threshold = defaultCompressionThreshold
 	}
-	if err := zw.Close(); err != nil {
-		return nil, err
+	switch name {
+	case "", "gzip":
+		return fileCodec{flag: gzipFlag, threshold: threshold}, nil
+	case "flate":
+		return fileCodec{flag: flateFlag, threshold: threshold}, nil
+	case "none":
+		return fileCodec{}, nil
+	default:
+		return fileCodec{}, fmt.Errorf("不支持的压缩算法: %s", name)
 	}
+}
 
-	// 添加压缩标记
-	result := make([]byte, buf.Len()+1)
-	result[0] = compressionFlag
-	copy(result[1:], buf.Bytes())
+// encode 压缩值，数据小于阈值或未启用压缩时原样返回
+func (c fileCodec) encode(data []byte) ([]byte, error) {
+	if c.flag == 0 || len(data) < c.threshold {
+		return data, nil
+	}
 
-	return result, nil
+	return compress(c.flag, []byte{c.flag}, data)
 }
 
-// 解压缩值
+// decompressValue 按标记解压值，与当前的压缩配置无关，修改配置后仍能读取旧数据
 func decompressValue(data []byte) ([]byte, error) {
-	// 检查是否被压缩
-	if len(data) == 0 || data[0] != compressionFlag {
+	if len(data) == 0 || (data[0] != gzipFlag && data[0] != flateFlag) {
 		return data, nil
 	}
+	return decompress(data[0], data[1:])
+}
 
-	zr, err := gzip.NewReader(bytes.NewReader(data[1:]))
-	if err != nil {
+// compress 按标记压缩 data，结果追加在 header 之后
+func compress(flag byte, header, data []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(header)
+	var zw io.WriteCloser
+	if flag == flateFlag {
+		fw, err := flate.NewWriter(buf, flate.DefaultCompression)
+		if err != nil {
+			return nil, err
+		}
+		zw = fw
+	} else {
+		zw = gzip.NewWriter(buf)
+	}
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
 		return nil, err
 	}
+	return buf.Bytes(), nil
+}
+
+// decompress 按标记解压 data
+func decompress(flag byte, data []byte) ([]byte, error) {
+	var zr io.ReadCloser
+	switch flag {
+	case gzipFlag:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		zr = r
+	case flateFlag:
+		zr = flate.NewReader(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("不支持的压缩标记: %d", flag)
+	}
 	defer zr.Close()
 
 	var buf bytes.Buffer
-	_, err = io.Copy(&buf, zr)
-	if err != nil {
+	if _, err := io.Copy(&buf, zr); err != nil {
 		return nil, err
 	}
-
 	return buf.Bytes(), nil
 }
 
 // FileCache 基于文件的缓存实现，使用 BoltDB 作为存储引擎
 type FileCache struct {
-	db       *bbolt.DB
-	logger   log.Logger
-	prefix   string
-	memCache sync.Map      // 内存缓存层
-	cacheTTL time.Duration // 内存缓存过期时间
+	// mu Compact 替换数据库文件时持有写锁，其他读写通过 view、update 持有读锁
+	mu        sync.RWMutex
+	db        *bbolt.DB
+	dbOptions *bbolt.Options
+	logger    log.Logger
+	prefix    string
+	opts      conf.FileCacheConfig
+	codec     fileCodec
+	memCache  sync.Map      // 内存缓存层
+	cacheTTL  time.Duration // 内存缓存过期时间
+	hub       *hub          // 发布订阅和阻塞读取
+	streams   streamEmulator
+	counters  fileCounters
+
+	evict     chan struct{} // 写入后数据超过 MaxSize 时通知后台淘汰
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// fileCounters 文件缓存的计数器
+type fileCounters struct {
+	expired, evictions, compactions atomic.Int64
 }
 
 // 缓存项结构
@@ -101,6 +166,34 @@ type cacheItem struct {
 	Expiration int64       `json:"expiration"` // Unix时间戳，0表示永不过期
 }
 
+// cacheItemJSON 缓存项的存储格式，JSON 字符串无法保存不是 UTF-8 的字节，这样的字符串以 base64 存在 Bytes 中
+type cacheItemJSON struct {
+	Value      interface{} `json:"value"`
+	Bytes      []byte      `json:"bytes,omitempty"`
+	Expiration int64       `json:"expiration"`
+}
+
+// MarshalJSON 序列化缓存项，字符串值与 Redis 一样可以保存任意字节
+func (i cacheItem) MarshalJSON() ([]byte, error) {
+	if s, ok := i.Value.(string); ok && !utf8.ValidString(s) {
+		return json.Marshal(cacheItemJSON{Bytes: []byte(s), Expiration: i.Expiration})
+	}
+	return json.Marshal(cacheItemJSON{Value: i.Value, Expiration: i.Expiration})
+}
+
+// UnmarshalJSON 反序列化缓存项
+func (i *cacheItem) UnmarshalJSON(data []byte) error {
+	var raw cacheItemJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	i.Value, i.Expiration = raw.Value, raw.Expiration
+	if raw.Bytes != nil {
+		i.Value = string(raw.Bytes)
+	}
+	return nil
+}
+
 // 对象池，减少内存分配和垃圾回收压力
 var itemPool = sync.Pool{
 	New: func() interface{} {
@@ -110,6 +203,16 @@ var itemPool = sync.Pool{
 
 // NewFileCache 创建新的文件缓存实例
 func NewFileCache(cfg *conf.Config, logger log.Logger) (Cache, error) {
+	opts := cfg.Cache.File
+	codec, err := newFileCodec(opts.Compression, opts.CompressionThreshold)
+	if err != nil {
+		return nil, err
+	}
+	dbOptions, err := boltOptions(opts.SyncMode)
+	if err != nil {
+		return nil, err
+	}
+
 	// 确保缓存目录存在
 	if err := os.MkdirAll(cfg.Cache.FilePath, 0755); err != nil {
 		return nil, fmt.Errorf("创建缓存目录失败: %w", err)
@@ -117,21 +220,8 @@ func NewFileCache(cfg *conf.Config, logger log.Logger) (Cache, error) {
 
 	dbPath := filepath.Join(cfg.Cache.FilePath, "cache.db")
 
-	// 打开 BoltDB 数据库，优化配置参数
-	db, err := bbolt.Open(dbPath, 0600, &bbolt.Options{
-		// 增加超时时间，避免高并发时锁争用问题
-		Timeout: 3 * time.Second,
-
-		// 增加页面大小，对于大数据量写入非常有效
-		// 默认是4KB，可以增加到8KB或16KB
-		PageSize: 16 * 1024,
-
-		// 设置freelist类型为map而不是array，对大型数据库有性能优势
-		FreelistType: bbolt.FreelistMapType,
-
-		// 禁用freelist同步，提高写入性能
-		NoFreelistSync: true,
-	})
+	// 打开 BoltDB 数据库
+	db, err := bbolt.Open(dbPath, 0600, dbOptions)
 	if err != nil {
 		return nil, fmt.Errorf("无法打开缓存数据库: %w", err)
 	}
@@ -146,6 +236,7 @@ func NewFileCache(cfg *conf.Config, logger log.Logger) (Cache, error) {
 			zsetBucket,
 			zsetScoreBucket,
 			expirationBucket,
+			streamBucket,
 		}
 
 		for _, bucket := range buckets {
@@ -158,91 +249,313 @@ func NewFileCache(cfg *conf.Config, logger log.Logger) (Cache, error) {
 	})
 
 	if err != nil {
+		db.Close()
 		return nil, fmt.Errorf("初始化缓存数据库失败: %w", err)
 	}
 
-	// 启动一个后台协程，定期清理过期的键
-	go func(db *bbolt.DB, logger log.Logger) {
-		ticker := time.NewTicker(5 * time.Minute)
-		defer ticker.Stop()
-
-		for range ticker.C {
-			cleanExpiredKeys(db, logger)
-		}
-	}(db, logger)
-
 	logger.WithFields(map[string]interface{}{
-		"path":   dbPath,
-		"prefix": cfg.Cache.Prefix,
+		"path":        dbPath,
+		"prefix":      cfg.Cache.Prefix,
+		"syncMode":    opts.SyncMode,
+		"compression": opts.Compression,
+		"maxSize":     opts.MaxSize,
 	}).Info("文件缓存初始化成功")
 
 	fileCache := &FileCache{
-		db:       db,
-		logger:   logger,
-		prefix:   cfg.Cache.Prefix,
-		cacheTTL: 5 * time.Minute, // 设置内存缓存默认过期时间为5分钟
+		db:        db,
+		dbOptions: dbOptions,
+		logger:    logger,
+		prefix:    cfg.Cache.Prefix,
+		opts:      opts,
+		codec:     codec,
+		cacheTTL:  5 * time.Minute, // 设置内存缓存默认过期时间为5分钟
+		hub:       newHub(),
 	}
+	fileCache.streams = streamEmulator{hub: fileCache.hub, store: fileCache.updateStream, fullKey: fileCache.buildKey}
 
-	// 启动定期压缩任务
-	fileCache.startCompactTask()
+	// 启动后台清理、淘汰和压缩任务，Close 时停止
+	if opts.CleanupInterval > 0 || opts.CompactInterval > 0 || opts.MaxSize > 0 {
+		fileCache.evict = make(chan struct{}, 1)
+		fileCache.stop, fileCache.done = make(chan struct{}), make(chan struct{})
+		go fileCache.maintain(opts.CleanupInterval, opts.CompactInterval)
+	}
 
 	return fileCache, nil
 }
 
-// 清理过期的键，采用懒清理模式，每次只清理一部分
-func cleanExpiredKeys(db *bbolt.DB, logger log.Logger) {
+// boltOptions 按持久化方式创建 BoltDB 的打开参数
+//
+// normal 每次提交都同步数据，但不同步空闲页列表，打开时扫描文件重建；full 同时同步空闲页列表，
+// 写入稍慢但打开更快；off 不同步到磁盘，写入最快，系统崩溃时可能丢失最近的写入甚至损坏文件。
+func boltOptions(mode string) (*bbolt.Options, error) {
+	opts := &bbolt.Options{
+		// 增加超时时间，避免高并发时锁争用问题
+		Timeout: 3 * time.Second,
+
+		// 增加页面大小，对于大数据量写入非常有效
+		PageSize: 16 * 1024,
+
+		// 设置freelist类型为map而不是array，对大型数据库有性能优势
+		FreelistType: bbolt.FreelistMapType,
+	}
+	switch mode {
+	case "", "normal":
+		opts.NoFreelistSync = true
+	case "full":
+	case "off":
+		opts.NoFreelistSync = true
+		opts.NoSync = true
+	default:
+		return nil, fmt.Errorf("不支持的文件缓存同步方式: %s", mode)
+	}
+	return opts, nil
+}
+
+// view 在读事务中执行 fn
+func (f *FileCache) view(fn func(tx *bbolt.Tx) error) error {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.db.View(fn)
+}
+
+// update 在写事务中执行 fn，提交后数据超过 MaxSize 时通知后台淘汰
+func (f *FileCache) update(fn func(tx *bbolt.Tx) error) error {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	var size int64
+	err := f.db.Update(func(tx *bbolt.Tx) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		size = tx.Size()
+		return nil
+	})
+	if err == nil && f.opts.MaxSize > 0 && size-int64(f.db.Stats().FreeAlloc) > f.opts.MaxSize {
+		select {
+		case f.evict <- struct{}{}:
+		default:
+		}
+	}
+	return err
+}
+
+// maintain 后台定期清理过期键、执行压缩，并在数据超过 MaxSize 时淘汰
+func (f *FileCache) maintain(cleanupInterval, compactInterval time.Duration) {
+	defer close(f.done)
+
+	var cleanup, compact <-chan time.Time
+	if cleanupInterval > 0 {
+		ticker := time.NewTicker(cleanupInterval)
+		defer ticker.Stop()
+		cleanup = ticker.C
+	}
+	if compactInterval > 0 {
+		ticker := time.NewTicker(compactInterval)
+		defer ticker.Stop()
+		compact = ticker.C
+	}
+
+	for {
+		select {
+		case <-f.stop:
+			return
+		case <-cleanup:
+			f.cleanExpired()
+			f.enforceMaxSize()
+		case <-f.evict:
+			f.enforceMaxSize()
+		case <-compact:
+			if result, err := f.Compact(context.Background()); err != nil {
+				f.logger.Errorf("压缩数据库失败: %v", err)
+			} else {
+				f.logger.Infof("数据库压缩成功: %d -> %d 字节", result.Before, result.After)
+			}
+		}
+	}
+}
+
+// cleanExpired 清理过期的键，采用懒清理模式，每次最多处理 maxCleanPerRun 个过期时间
+func (f *FileCache) cleanExpired() {
 	now := time.Now().Unix()
+	var removed []string
 
-	err := db.Update(func(tx *bbolt.Tx) error {
+	err := f.update(func(tx *bbolt.Tx) error {
+		removed = removed[:0]
 		expBucket := tx.Bucket([]byte(expirationBucket))
 		if expBucket == nil {
 			return fmt.Errorf("过期桶不存在")
 		}
 
-		// 遍历所有过期时间
+		// 遍历所有过期时间，删除记录会使游标失效，先收集再删除
+		var done [][]byte
 		c := expBucket.Cursor()
-		count := 0
-		maxClean := 1000 // 每次最多清理1000个过期键
-
-		for k, v := c.First(); k != nil && count < maxClean; k, v = c.Next() {
-			expTime := bytesToInt64(k)
-			if expTime <= now {
-				// 已过期，删除键
-				keysData := v
-				var keys []string
-				if err := json.Unmarshal(keysData, &keys); err != nil {
-					logger.Errorf("解析过期键列表失败: %v", err)
+		for k, v := c.First(); k != nil && len(done) < maxCleanPerRun; k, v = c.Next() {
+			if bytesToInt64(k) > now {
+				// 后面的都还没过期
+				break
+			}
+			done = append(done, k)
+
+			var keys []string
+			if err := json.Unmarshal(v, &keys); err != nil {
+				f.logger.Errorf("解析过期键列表失败: %v", err)
+				continue
+			}
+
+			// 删除每个过期的键，键可能已被重新写入或移除了过期时间，只删除确实过期的键
+			for _, key := range keys {
+				bucketName, bucketKey := parseKey(key)
+				bucket := tx.Bucket([]byte(bucketName))
+				if bucket == nil || bucket.Get([]byte(bucketKey)) == nil {
 					continue
 				}
-
-				// 删除每个过期的键
-				for _, key := range keys {
-					bucketName, bucketKey := parseKey(key)
-					bucket := tx.Bucket([]byte(bucketName))
-					if bucket != nil {
-						bucket.Delete([]byte(bucketKey))
+				if item, err := readItem(bucket, bucketKey); err == nil && item == nil {
+					if err := bucket.Delete([]byte(bucketKey)); err != nil {
+						return err
 					}
+					removed = append(removed, bucketKey)
 				}
+			}
+		}
 
-				// 删除过期时间记录
-				expBucket.Delete(k)
-				count++
-			} else {
-				// 后面的都还没过期
-				break
+		// 删除过期时间记录
+		for _, k := range done {
+			if err := expBucket.Delete(k); err != nil {
+				return err
 			}
 		}
+		return nil
+	})
+	if err != nil {
+		f.logger.Errorf("清理过期键失败: %v", err)
+		return
+	}
+
+	for _, key := range removed {
+		f.memCache.Delete(key)
+	}
+	if len(removed) > 0 {
+		f.counters.expired.Add(int64(len(removed)))
+		f.logger.Infof("清理了 %d 个过期键", len(removed))
+	}
+}
+
+// dataSize 返回数据占用的大小，即已分配的页面减去空闲页面
+func (f *FileCache) dataSize() (int64, error) {
+	var size int64
+	err := f.view(func(tx *bbolt.Tx) error {
+		size = tx.Size() - int64(f.db.Stats().FreeAlloc)
+		return nil
+	})
+	return size, err
+}
 
-		if count > 0 {
-			logger.Infof("清理了 %d 个过期键", count)
+// enforceMaxSize 数据超过 MaxSize 时按过期时间从早到晚淘汰设置了过期时间的键，直到低于 MaxSize 的 90%，
+// 与 Redis 的 volatile-ttl 策略相同，没有过期时间的键不会被淘汰。淘汰只释放页面，不会缩小文件，
+// 文件中已分配的页面仍超过 MaxSize 时执行压缩
+func (f *FileCache) enforceMaxSize() {
+	if f.opts.MaxSize <= 0 {
+		return
+	}
+
+	target := f.opts.MaxSize / 10 * 9
+	var evicted int64
+	for {
+		size, err := f.dataSize()
+		if err != nil || size <= target {
+			break
+		}
+		n, err := f.evictBatch()
+		if err != nil {
+			f.logger.Errorf("淘汰文件缓存失败: %v", err)
+			return
 		}
+		if n < 0 {
+			f.logger.Warnf("文件缓存超过上限 %d 字节，但没有可淘汰的键（只淘汰设置了过期时间的键）", f.opts.MaxSize)
+			break
+		}
+		evicted += int64(n)
+	}
+	if evicted > 0 {
+		f.logger.Infof("文件缓存超过上限，淘汰了 %d 个键", evicted)
+	}
 
+	var allocated int64
+	f.view(func(tx *bbolt.Tx) error {
+		allocated = tx.Size()
 		return nil
 	})
+	if allocated > f.opts.MaxSize {
+		if _, err := f.Compact(context.Background()); err != nil {
+			f.logger.Errorf("压缩数据库失败: %v", err)
+		}
+	}
+}
+
+// evictBatch 淘汰最早过期的一批键，返回淘汰的键数，没有过期时间记录时返回 -1
+func (f *FileCache) evictBatch() (int, error) {
+	var removed []string
+	empty := false
+	err := f.update(func(tx *bbolt.Tx) error {
+		removed = removed[:0]
+		expBucket := tx.Bucket([]byte(expirationBucket))
+		bucket := tx.Bucket([]byte(defaultBucket))
+		if expBucket == nil || bucket == nil {
+			return fmt.Errorf("桶不存在")
+		}
+
+		var done [][]byte
+		c := expBucket.Cursor()
+		for k, v := c.First(); k != nil && len(done) < evictBatchSize; k, v = c.Next() {
+			done = append(done, k)
+			var keys []string
+			if err := json.Unmarshal(v, &keys); err != nil {
+				continue
+			}
+
+			// 键的过期时间已修改时记录已失效，不淘汰
+			expiration := bytesToInt64(k)
+			for _, key := range keys {
+				data := bucket.Get([]byte(key))
+				if data == nil {
+					continue
+				}
+				decompressedData, err := decompressValue(data)
+				if err != nil {
+					return err
+				}
+				var item cacheItem
+				if err := json.Unmarshal(decompressedData, &item); err != nil || item.Expiration != expiration {
+					continue
+				}
+				if err := bucket.Delete([]byte(key)); err != nil {
+					return err
+				}
+				removed = append(removed, key)
+			}
+		}
+		empty = len(done) == 0
 
+		for _, k := range done {
+			if err := expBucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		logger.Errorf("清理过期键失败: %v", err)
+		return 0, err
+	}
+	if empty {
+		return -1, nil
+	}
+
+	for _, key := range removed {
+		f.memCache.Delete(key)
 	}
+	f.counters.evictions.Add(int64(len(removed)))
+	return len(removed), nil
 }
 
 // 将过期时间和键关联起来
@@ -309,13 +622,25 @@ func (f *FileCache) buildKey(key string) string {
 	return f.prefix + key
 }
 
-// GetClient 获取原始 BoltDB 客户端
+// GetClient 获取原始 BoltDB 客户端，Compact 会重新打开数据库，之前获取的客户端随之失效
 func (f *FileCache) GetClient() interface{} {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
 	return f.db
 }
 
-// Close 关闭数据库连接
+// Close 停止后台任务，关闭订阅和数据库连接
 func (f *FileCache) Close() error {
+	f.hub.close()
+	f.closeOnce.Do(func() {
+		if f.stop != nil {
+			close(f.stop)
+			<-f.done
+		}
+	})
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
 	return f.db.Close()
 }
 
@@ -336,24 +661,13 @@ func (f *FileCache) Get(key string) (string, error) {
 		if item.Expiration > 0 && item.Expiration <= time.Now().Unix() {
 			f.memCache.Delete(prefixedKey)
 		} else {
-			// 转换为字符串
-			switch v := item.Value.(type) {
-			case string:
-				return v, nil
-			default:
-				// 尝试将其他类型转换为字符串
-				valueBytes, err := json.Marshal(item.Value)
-				if err != nil {
-					return "", err
-				}
-				return string(valueBytes), nil
-			}
+			return itemValue(&item)
 		}
 	}
 
 	// 内存缓存未命中，查询文件缓存
 	var value string
-	err := f.db.View(func(tx *bbolt.Tx) error {
+	err := f.view(func(tx *bbolt.Tx) error {
 		bucket := tx.Bucket([]byte(defaultBucket))
 		if bucket == nil {
 			return fmt.Errorf("桶不存在")
@@ -361,7 +675,15 @@ func (f *FileCache) Get(key string) (string, error) {
 
 		data := bucket.Get([]byte(prefixedKey))
 		if data == nil {
-			return ErrKeyNotFound // 已过期
+			// 键不是字符串时与 Redis 一致返回类型错误
+			current, err := keyType(tx, prefixedKey)
+			if err != nil {
+				return err
+			}
+			if current != "" {
+				return ErrTypeMismatch
+			}
+			return ErrKeyNotFound
 		}
 
 		// 解压缩数据
@@ -384,16 +706,8 @@ func (f *FileCache) Get(key string) (string, error) {
 		}
 
 		// 转换为字符串
-		switch v := item.Value.(type) {
-		case string:
-			value = v
-		default:
-			// 尝试将其他类型转换为字符串
-			valueBytes, err := json.Marshal(item.Value)
-			if err != nil {
-				return err
-			}
-			value = string(valueBytes)
+		if value, err = itemValue(item); err != nil {
+			return err
 		}
 
 		// 将结果放入内存缓存
@@ -409,44 +723,15 @@ func (f *FileCache) Get(key string) (string, error) {
 func (f *FileCache) Set(key string, value interface{}, expiration time.Duration) error {
 	prefixedKey := f.buildKey(key)
 
-	var exp int64
-	if expiration > 0 {
-		exp = time.Now().Add(expiration).Unix()
-	}
-
-	// 创建缓存项
-	item := cacheItem{
-		Value:      value,
-		Expiration: exp,
+	// 创建缓存项，值按 Redis 的规则转换为字符串
+	item, err := newCacheItem(value, expiration)
+	if err != nil {
+		return err
 	}
 
-	// 更新文件缓存
-	err := f.db.Update(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte(defaultBucket))
-		if bucket == nil {
-			return fmt.Errorf("桶不存在")
-		}
-
-		if expiration > 0 {
-			// 添加到过期时间索引
-			if err := addKeyExpiration(tx, prefixedKey, exp); err != nil {
-				return err
-			}
-		}
-
-		// 序列化缓存项
-		data, err := json.Marshal(item)
-		if err != nil {
-			return err
-		}
-
-		// 压缩大型值
-		compressedData, err := compressValue(data)
-		if err != nil {
-			return err
-		}
-
-		return bucket.Put([]byte(prefixedKey), compressedData)
+	// 更新文件缓存，覆盖键原有的任意类型的数据
+	err = f.update(func(tx *bbolt.Tx) error {
+		return fileTx{f: f, tx: tx}.setItem(prefixedKey, item)
 	})
 
 	if err == nil {
@@ -457,27 +742,47 @@ func (f *FileCache) Set(key string, value interface{}, expiration time.Duration)
 	return err
 }
 
+// newCacheItem 创建字符串缓存项
+func newCacheItem(value interface{}, expiration time.Duration) (cacheItem, error) {
+	var exp int64
+	if expiration > 0 {
+		exp = time.Now().Add(expiration).Unix()
+	}
+
+	str, err := stringValue(value)
+	if err != nil {
+		return cacheItem{}, err
+	}
+	return cacheItem{Value: str, Expiration: exp}, nil
+}
+
+// setItem 写入缓存项，覆盖键原有的任意类型的数据
+func (t fileTx) setItem(prefixedKey string, item cacheItem) error {
+	bucket := t.tx.Bucket([]byte(defaultBucket))
+	if bucket == nil {
+		return fmt.Errorf("桶不存在")
+	}
+	if _, err := dropKey(t.tx, prefixedKey); err != nil {
+		return err
+	}
+	return t.f.putItem(t.tx, bucket, prefixedKey, item)
+}
+
 // Del 删除缓存
 func (f *FileCache) Del(keys ...string) (int64, error) {
 	var count int64
-	err := f.db.Update(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte(defaultBucket))
-		if bucket == nil {
-			return fmt.Errorf("桶不存在")
-		}
-
+	err := f.update(func(tx *bbolt.Tx) error {
 		for _, key := range keys {
-			prefixedKey := f.buildKey(key)
-			// 检查键是否存在
-			if data := bucket.Get([]byte(prefixedKey)); data != nil {
-				if err := bucket.Delete([]byte(prefixedKey)); err != nil {
-					return err
-				}
+			existed, err := fileTx{f: f, tx: tx}.del(key)
+			if err != nil {
+				return err
+			}
+			if existed {
 				count++
-
-				// 同时删除内存缓存
-				f.memCache.Delete(prefixedKey)
 			}
+
+			// 同时删除内存缓存
+			f.memCache.Delete(f.buildKey(key))
 		}
 
 		return nil
@@ -489,209 +794,647 @@ func (f *FileCache) Del(keys ...string) (int64, error) {
 // Exists 检查键是否存在
 func (f *FileCache) Exists(keys ...string) (int64, error) {
 	var count int64
-	err := f.db.View(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte(defaultBucket))
-		if bucket == nil {
-			return fmt.Errorf("桶不存在")
+	err := f.view(func(tx *bbolt.Tx) error {
+		// 与 Redis 一致，重复的键重复计数
+		for _, key := range keys {
+			exists, err := fileTx{f: f, tx: tx}.exists(key)
+			if err != nil {
+				return err
+			}
+			if exists {
+				count++
+			}
 		}
 
-		now := time.Now().Unix()
+		return nil
+	})
 
-		for _, key := range keys {
-			data := bucket.Get([]byte(f.buildKey(key)))
-			if data != nil {
-				var item cacheItem
-				if err := json.Unmarshal(data, &item); err != nil {
-					return err
-				}
+	return count, err
+}
 
-				// 检查是否过期
-				if item.Expiration == 0 || item.Expiration > now {
-					count++
-				}
+// Expire 设置过期时间，键不存在时忽略，expiration 不大于 0 时删除键
+//
+// 文件缓存只记录字符串的过期时间，为其他类型的键设置过期时间返回错误。
+func (f *FileCache) Expire(key string, expiration time.Duration) error {
+	err := f.update(func(tx *bbolt.Tx) error {
+		return fileTx{f: f, tx: tx}.expire(key, expiration)
+	})
+	if err == nil {
+		f.memCache.Delete(f.buildKey(key))
+	}
+	return err
+}
+
+// expire 设置过期时间，键不存在时忽略，expiration 不大于 0 时删除键
+func (t fileTx) expire(key string, expiration time.Duration) error {
+	prefixedKey := t.f.buildKey(key)
+	bucket := t.tx.Bucket([]byte(defaultBucket))
+	if bucket == nil {
+		return fmt.Errorf("桶不存在")
+	}
+
+	current, err := readItem(bucket, prefixedKey)
+	if err != nil {
+		return err
+	}
+	if current == nil || expiration <= 0 {
+		kind, err := keyType(t.tx, prefixedKey)
+		if err != nil || kind == "" {
+			return err
+		}
+		if expiration <= 0 {
+			_, err = dropKey(t.tx, prefixedKey)
+			return err
+		}
+		return fmt.Errorf("文件缓存不支持为 %s 类型的键设置过期时间", kind)
+	}
+
+	current.Expiration = expireAt(expiration)
+	return t.f.putItem(t.tx, bucket, prefixedKey, *current)
+}
+
+// TTL 获取剩余生存时间，键不存在时返回 -2，未设置过期时间返回 -1
+func (f *FileCache) TTL(key string) (time.Duration, error) {
+	prefixedKey := f.buildKey(key)
+
+	var ttl time.Duration
+	err := f.view(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(defaultBucket))
+		if bucket == nil {
+			return fmt.Errorf("桶不存在")
+		}
+
+		item, err := readItem(bucket, prefixedKey)
+		if err != nil {
+			return err
+		}
+		if item == nil {
+			// 其他类型的键不会过期
+			kind, err := keyType(tx, prefixedKey)
+			if err != nil {
+				return err
 			}
+			ttl = -2
+			if kind != "" {
+				ttl = -1
+			}
+			return nil
 		}
 
+		// 检查过期时间
+		if item.Expiration == 0 {
+			ttl = -1 // 永不过期
+		} else {
+			ttl = time.Duration(item.Expiration-time.Now().Unix()) * time.Second
+		}
 		return nil
 	})
 
-	return count, err
+	return ttl, err
 }
 
-// Expire 设置过期时间
-func (f *FileCache) Expire(key string, expiration time.Duration) error {
-	return f.db.Update(func(tx *bbolt.Tx) error {
+// bucketTypes 数据所在的桶对应的键类型
+var bucketTypes = map[string]string{
+	defaultBucket: "string",
+	hashBucket:    "hash",
+	listBucket:    "list",
+	setBucket:     "set",
+	zsetBucket:    "zset",
+	streamBucket:  "stream",
+}
+
+// Type 获取键的类型，键不存在时返回 none
+func (f *FileCache) Type(key string) (string, error) {
+	kind := "none"
+	err := f.view(func(tx *bbolt.Tx) error {
+		bucket, err := keyType(tx, f.buildKey(key))
+		if bucket != "" {
+			kind = bucketTypes[bucket]
+		}
+		return err
+	})
+	return kind, err
+}
+
+// TypeCtx 获取键的类型（带上下文）
+func (f *FileCache) TypeCtx(ctx context.Context, key string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return f.Type(key)
+}
+
+// SetNX 键不存在时设置缓存，检查和写入在同一事务中完成
+func (f *FileCache) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	prefixedKey := f.buildKey(key)
+	str, err := stringValue(value)
+	if err != nil {
+		return false, err
+	}
+	item := cacheItem{Value: str, Expiration: expireAt(expiration)}
+
+	var ok bool
+	err = f.update(func(tx *bbolt.Tx) error {
 		bucket := tx.Bucket([]byte(defaultBucket))
 		if bucket == nil {
 			return fmt.Errorf("桶不存在")
 		}
 
-		prefixedKey := f.buildKey(key)
-		data := bucket.Get([]byte(prefixedKey))
-		if data == nil {
-			return ErrKeyNotFound
+		// 任意类型的键存在时都不写入
+		current, err := keyType(tx, prefixedKey)
+		if err != nil {
+			return err
+		}
+		if current != "" {
+			return nil
 		}
 
-		var item cacheItem
-		if err := json.Unmarshal(data, &item); err != nil {
+		if err := f.putItem(tx, bucket, prefixedKey, item); err != nil {
 			return err
 		}
+		ok = true
+		return nil
+	})
+	if err == nil && ok {
+		f.memCache.Store(prefixedKey, item)
+	}
+	return ok, err
+}
 
-		// 设置新的过期时间
-		var exp int64
-		if expiration > 0 {
-			exp = time.Now().Add(expiration).Unix()
+// CompareAndDel 值相等时删除键
+func (f *FileCache) CompareAndDel(key, value string) (bool, error) {
+	prefixedKey := f.buildKey(key)
 
-			// 添加到过期时间索引
-			if err := addKeyExpiration(tx, prefixedKey, exp); err != nil {
-				return err
-			}
+	var ok bool
+	err := f.update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(defaultBucket))
+		if bucket == nil {
+			return fmt.Errorf("桶不存在")
 		}
 
-		item.Expiration = exp
-
-		updatedData, err := json.Marshal(item)
-		if err != nil {
+		current, err := readItem(bucket, prefixedKey)
+		if err != nil || current == nil || current.Value != value {
 			return err
 		}
 
-		return bucket.Put([]byte(prefixedKey), updatedData)
+		if err := bucket.Delete([]byte(prefixedKey)); err != nil {
+			return err
+		}
+		ok = true
+		return nil
 	})
+	if err == nil && ok {
+		f.memCache.Delete(prefixedKey)
+	}
+	return ok, err
 }
 
-// TTL 获取剩余生存时间
-func (f *FileCache) TTL(key string) (time.Duration, error) {
-	var ttl time.Duration
-	err := f.db.View(func(tx *bbolt.Tx) error {
+// CompareAndExpire 值相等时设置过期时间
+func (f *FileCache) CompareAndExpire(key, value string, expiration time.Duration) (bool, error) {
+	prefixedKey := f.buildKey(key)
+
+	var item *cacheItem
+	err := f.update(func(tx *bbolt.Tx) error {
 		bucket := tx.Bucket([]byte(defaultBucket))
 		if bucket == nil {
 			return fmt.Errorf("桶不存在")
 		}
 
-		data := bucket.Get([]byte(f.buildKey(key)))
-		if data == nil {
-			return ErrKeyNotFound // 已过期
+		current, err := readItem(bucket, prefixedKey)
+		if err != nil || current == nil || current.Value != value {
+			return err
 		}
 
-		var item cacheItem
-		if err := json.Unmarshal(data, &item); err != nil {
+		current.Expiration = expireAt(expiration)
+		if err := f.putItem(tx, bucket, prefixedKey, *current); err != nil {
 			return err
 		}
+		item = current
+		return nil
+	})
+	if err == nil && item != nil {
+		f.memCache.Store(prefixedKey, *item)
+	}
+	return item != nil, err
+}
 
-		// 检查过期时间
-		if item.Expiration == 0 {
-			ttl = -1 // 永不过期
-		} else {
-			now := time.Now().Unix()
-			if item.Expiration <= now {
-				return ErrKeyNotFound // 已过期
-			}
-			ttl = time.Duration(item.Expiration-now) * time.Second
+// GetSet 设置新值并返回旧值，新值不过期；键不存在时仍然写入并返回 ErrKeyNotFound
+func (f *FileCache) GetSet(key string, value interface{}) (string, error) {
+	prefixedKey := f.buildKey(key)
+	str, err := stringValue(value)
+	if err != nil {
+		return "", err
+	}
+	item := cacheItem{Value: str}
+
+	var old *cacheItem
+	err = f.update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(defaultBucket))
+		if bucket == nil {
+			return fmt.Errorf("桶不存在")
+		}
+		if err := checkType(tx, prefixedKey, defaultBucket); err != nil {
+			return err
 		}
 
-		return nil
+		if old, err = readItem(bucket, prefixedKey); err != nil {
+			return err
+		}
+		return f.putItem(tx, bucket, prefixedKey, item)
 	})
+	if err != nil {
+		return "", err
+	}
 
-	return ttl, err
+	f.memCache.Store(prefixedKey, item)
+	if old == nil {
+		return "", ErrKeyNotFound
+	}
+	return itemValue(old)
 }
 
-// ================== 计数器操作 ==================
+// MGet 批量读取，不存在或不是字符串的键对应 nil
+func (f *FileCache) MGet(keys ...string) ([]interface{}, error) {
+	result := make([]interface{}, len(keys))
+	err := f.view(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(defaultBucket))
+		if bucket == nil {
+			return fmt.Errorf("桶不存在")
+		}
 
-// Incr 自增
-func (f *FileCache) Incr(key string) (int64, error) {
-	return f.IncrBy(key, 1)
+		for i, key := range keys {
+			item, err := readItem(bucket, f.buildKey(key))
+			if err != nil {
+				return err
+			}
+			if item == nil {
+				continue
+			}
+			if result[i], err = itemValue(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return result, err
 }
 
-// Decr 自减
-func (f *FileCache) Decr(key string) (int64, error) {
-	return f.IncrBy(key, -1)
+// MSet 在同一事务中批量写入，覆盖键原有的任意类型的数据
+func (f *FileCache) MSet(values ...interface{}) error {
+	pairs, err := hashFields(values)
+	if err != nil {
+		return err
+	}
+
+	err = f.update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(defaultBucket))
+		if bucket == nil {
+			return fmt.Errorf("桶不存在")
+		}
+
+		for i := 0; i < len(pairs); i += 2 {
+			prefixedKey := f.buildKey(pairs[i])
+			if _, err := dropKey(tx, prefixedKey); err != nil {
+				return err
+			}
+			if err := f.putItem(tx, bucket, prefixedKey, cacheItem{Value: pairs[i+1]}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		for i := 0; i < len(pairs); i += 2 {
+			f.memCache.Store(f.buildKey(pairs[i]), cacheItem{Value: pairs[i+1]})
+		}
+	}
+	return err
 }
 
-// IncrBy 按指定值自增
-func (f *FileCache) IncrBy(key string, value int64) (int64, error) {
-	var result int64
-	err := f.db.Update(func(tx *bbolt.Tx) error {
+// Persist 移除过期时间，返回键是否存在且原本设置了过期时间
+func (f *FileCache) Persist(key string) (bool, error) {
+	prefixedKey := f.buildKey(key)
+
+	var item *cacheItem
+	err := f.update(func(tx *bbolt.Tx) error {
 		bucket := tx.Bucket([]byte(defaultBucket))
 		if bucket == nil {
 			return fmt.Errorf("桶不存在")
 		}
 
-		prefixedKey := f.buildKey(key)
-		data := bucket.Get([]byte(prefixedKey))
+		current, err := readItem(bucket, prefixedKey)
+		if err != nil || current == nil || current.Expiration == 0 {
+			return err
+		}
+		current.Expiration = 0
+		item = current
+		return f.putItem(tx, bucket, prefixedKey, *current)
+	})
+	if err == nil && item != nil {
+		f.memCache.Store(prefixedKey, *item)
+	}
+	return item != nil, err
+}
 
-		if data == nil {
-			// 键不存在，创建新键并设置为指定值
-			result = value
-			item := cacheItem{
-				Value:      result,
-				Expiration: 0, // 永不过期
-			}
+// Rename 重命名键，保留过期时间并覆盖 newKey，键不存在时返回 ErrKeyNotFound
+func (f *FileCache) Rename(key, newKey string) error {
+	prefixedKey, prefixedNewKey := f.buildKey(key), f.buildKey(newKey)
 
-			data, err := json.Marshal(item)
+	var kind string
+	err := f.update(func(tx *bbolt.Tx) error {
+		var err error
+		if kind, err = keyType(tx, prefixedKey); err != nil {
+			return err
+		}
+		if kind == "" {
+			return ErrKeyNotFound
+		}
+		if prefixedKey == prefixedNewKey {
+			return nil
+		}
+		if _, err := dropKey(tx, prefixedNewKey); err != nil {
+			return err
+		}
+
+		switch kind {
+		case defaultBucket:
+			bucket := tx.Bucket([]byte(defaultBucket))
+			item, err := readItem(bucket, prefixedKey)
 			if err != nil {
 				return err
 			}
+			if err := f.putItem(tx, bucket, prefixedNewKey, *item); err != nil {
+				return err
+			}
+		case streamBucket:
+			bucket := tx.Bucket([]byte(streamBucket))
+			data := append([]byte(nil), bucket.Get([]byte(prefixedKey))...)
+			if err := bucket.Put([]byte(prefixedNewKey), data); err != nil {
+				return err
+			}
+		default:
+			names := []string{kind}
+			if kind == zsetBucket {
+				names = append(names, zsetScoreBucket)
+			}
+			for _, name := range names {
+				if err := renameBucket(tx.Bucket([]byte(name)), prefixedKey, prefixedNewKey); err != nil {
+					return err
+				}
+			}
+		}
 
-			return bucket.Put([]byte(prefixedKey), data)
+		_, err = dropKey(tx, prefixedKey)
+		return err
+	})
+	if err == nil {
+		f.memCache.Delete(prefixedKey)
+		f.memCache.Delete(prefixedNewKey)
+		if kind == listBucket {
+			f.hub.signal(prefixedNewKey)
 		}
+	}
+	return err
+}
 
-		// 键存在，解析并增加值
-		var item cacheItem
-		if err := json.Unmarshal(data, &item); err != nil {
-			return err
+// renameBucket 将 parent 下的子桶复制为新名称，bbolt 不支持直接重命名子桶
+func renameBucket(parent *bbolt.Bucket, from, to string) error {
+	src := parent.Bucket([]byte(from))
+	if src == nil {
+		return nil
+	}
+	dst, err := parent.CreateBucket([]byte(to))
+	if err != nil {
+		return err
+	}
+	return src.ForEach(func(k, v []byte) error {
+		return dst.Put(append([]byte(nil), k...), append([]byte(nil), v...))
+	})
+}
+
+// expireAt 计算过期时间戳，向上取整到秒，避免不足一秒的过期时间立即过期
+func expireAt(expiration time.Duration) int64 {
+	if expiration <= 0 {
+		return 0
+	}
+	return (time.Now().Add(expiration).UnixNano() + int64(time.Second) - 1) / int64(time.Second)
+}
+
+// itemValue 将缓存项的值转换为字符串，计数器的值反序列化后为数字
+func itemValue(item *cacheItem) (string, error) {
+	if v, ok := item.Value.(string); ok {
+		return v, nil
+	}
+	data, err := json.Marshal(item.Value)
+	return string(data), err
+}
+
+// readItem 在事务中读取未过期的缓存项，不存在时返回 nil
+func readItem(bucket *bbolt.Bucket, prefixedKey string) (*cacheItem, error) {
+	data := bucket.Get([]byte(prefixedKey))
+	if data == nil {
+		return nil, nil
+	}
+
+	decompressedData, err := decompressValue(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var item cacheItem
+	if err := json.Unmarshal(decompressedData, &item); err != nil {
+		return nil, err
+	}
+	if item.Expiration > 0 && item.Expiration <= time.Now().Unix() {
+		return nil, nil
+	}
+	return &item, nil
+}
+
+// putItem 在事务中写入缓存项并登记过期时间
+func (f *FileCache) putItem(tx *bbolt.Tx, bucket *bbolt.Bucket, prefixedKey string, item cacheItem) error {
+	if err := addKeyExpiration(tx, prefixedKey, item.Expiration); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	compressedData, err := f.codec.encode(data)
+	if err != nil {
+		return err
+	}
+	return bucket.Put([]byte(prefixedKey), compressedData)
+}
+
+// typeBuckets 字符串以外的数据类型所在的桶，流以键名存储，其余类型以键名作为子桶
+var typeBuckets = []string{hashBucket, listBucket, setBucket, zsetBucket, streamBucket}
+
+// keyType 返回键的数据所在的桶名，键不存在或已过期时返回空字符串，空的哈希、列表和集合视为不存在
+func keyType(tx *bbolt.Tx, prefixedKey string) (string, error) {
+	if bucket := tx.Bucket([]byte(defaultBucket)); bucket != nil {
+		item, err := readItem(bucket, prefixedKey)
+		if err != nil {
+			return "", err
+		}
+		if item != nil {
+			return defaultBucket, nil
 		}
+	}
 
-		// 检查是否过期
-		if item.Expiration > 0 && item.Expiration < time.Now().Unix() {
-			// 已过期，创建新键
-			result = value
-			item = cacheItem{
-				Value:      result,
-				Expiration: 0, // 永不过期
+	for _, name := range typeBuckets {
+		bucket := tx.Bucket([]byte(name))
+		if bucket == nil {
+			continue
+		}
+		if name == streamBucket {
+			if bucket.Get([]byte(prefixedKey)) != nil {
+				return name, nil
 			}
-		} else {
-			// 未过期，增加值
-			var currentValue int64
+			continue
+		}
 
-			// 尝试将值转换为 int64
-			switch v := item.Value.(type) {
-			case float64:
-				currentValue = int64(v)
-			case int64:
-				currentValue = v
-			case int:
-				currentValue = int64(v)
-			case string:
-				// 尝试将字符串解析为数字
-				var err error
-				currentValue, err = strconv.ParseInt(v, 10, 64)
-				if err != nil {
-					return fmt.Errorf("值不是有效的整数: %w", err)
-				}
-			default:
-				return fmt.Errorf("值不是有效的整数")
+		sub := bucket.Bucket([]byte(prefixedKey))
+		if sub == nil {
+			continue
+		}
+		if name == listBucket {
+			if length, err := getListLength(sub); err != nil || length > 0 {
+				return name, err
 			}
-
-			result = currentValue + value
-			item.Value = result
+			continue
 		}
+		if k, _ := sub.Cursor().First(); k != nil {
+			return name, nil
+		}
+	}
+	return "", nil
+}
+
+// checkType 键存在且数据不在 want 桶中时返回 ErrTypeMismatch
+func checkType(tx *bbolt.Tx, prefixedKey, want string) error {
+	current, err := keyType(tx, prefixedKey)
+	if err != nil {
+		return err
+	}
+	if current != "" && current != want {
+		return ErrTypeMismatch
+	}
+	return nil
+}
+
+// dropKey 在写事务中删除键的所有类型的数据，返回键删除前是否存在
+func dropKey(tx *bbolt.Tx, prefixedKey string) (bool, error) {
+	current, err := keyType(tx, prefixedKey)
+	if err != nil {
+		return false, err
+	}
 
-		// 保存更新后的值
-		updatedData, err := json.Marshal(item)
+	name := []byte(prefixedKey)
+	if bucket := tx.Bucket([]byte(defaultBucket)); bucket != nil {
+		if err := bucket.Delete(name); err != nil {
+			return false, err
+		}
+	}
+	for _, bucketName := range append(typeBuckets, zsetScoreBucket) {
+		bucket := tx.Bucket([]byte(bucketName))
+		if bucket == nil {
+			continue
+		}
+		if bucketName == streamBucket {
+			err = bucket.Delete(name)
+		} else if bucket.Bucket(name) != nil {
+			err = bucket.DeleteBucket(name)
+		}
 		if err != nil {
-			return err
+			return false, err
 		}
+	}
+	return current != "", nil
+}
+
+// ================== 计数器操作 ==================
+
+// Incr 自增
+func (f *FileCache) Incr(key string) (int64, error) {
+	return f.IncrBy(key, 1)
+}
+
+// Decr 自减
+func (f *FileCache) Decr(key string) (int64, error) {
+	return f.IncrBy(key, -1)
+}
 
-		return bucket.Put([]byte(prefixedKey), updatedData)
+// IncrBy 按指定值自增
+func (f *FileCache) IncrBy(key string, value int64) (int64, error) {
+	var result int64
+	err := f.update(func(tx *bbolt.Tx) error {
+		var err error
+		result, err = fileTx{f: f, tx: tx}.incrBy(key, value)
+		return err
 	})
+	if err == nil {
+		f.memCache.Delete(f.buildKey(key))
+	}
 
 	return result, err
 }
 
+// incrBy 按指定值自增，保留原有的过期时间，键不存在或已过期时从 0 开始
+func (t fileTx) incrBy(key string, value int64) (int64, error) {
+	bucket := t.tx.Bucket([]byte(defaultBucket))
+	if bucket == nil {
+		return 0, fmt.Errorf("桶不存在")
+	}
+
+	prefixedKey := t.f.buildKey(key)
+	if err := checkType(t.tx, prefixedKey, defaultBucket); err != nil {
+		return 0, err
+	}
+	item, err := readItem(bucket, prefixedKey)
+	if err != nil {
+		return 0, err
+	}
+	if item == nil {
+		item = &cacheItem{}
+	}
+
+	// 尝试将值转换为 int64
+	var current int64
+	switch v := item.Value.(type) {
+	case nil:
+	case float64:
+		current = int64(v)
+	case string:
+		// 尝试将字符串解析为数字
+		current, err = strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("值不是有效的整数: %w", err)
+		}
+	default:
+		return 0, fmt.Errorf("值不是有效的整数")
+	}
+
+	// 保存更新后的值，过期时间已登记过，不重复登记
+	item.Value = current + value
+	data, err := json.Marshal(item)
+	if err != nil {
+		return 0, err
+	}
+	return current + value, bucket.Put([]byte(prefixedKey), data)
+}
+
 // ================== 哈希表操作 ==================
 
 // 获取哈希表的桶
 func getHashBucket(tx *bbolt.Tx, key string) (*bbolt.Bucket, error) {
+	// 键已存储为其他类型时返回类型错误
+	if err := checkType(tx, key, hashBucket); err != nil {
+		return nil, err
+	}
+
 	// 获取哈希桶
 	hashBucketObj := tx.Bucket([]byte(hashBucket))
 	if hashBucketObj == nil {
@@ -723,107 +1466,122 @@ func getHashBucket(tx *bbolt.Tx, key string) (*bbolt.Bucket, error) {
 // HGet 获取哈希表中的字段值
 func (f *FileCache) HGet(key, field string) (string, error) {
 	var value string
-	err := f.db.View(func(tx *bbolt.Tx) error {
-		bucket, err := getHashBucket(tx, f.buildKey(key))
-		if err != nil {
-			return err
-		}
-
-		data := bucket.Get([]byte(field))
-		if data == nil {
-			return ErrKeyNotFound
-		}
-
-		value = string(data)
-		return nil
+	err := f.view(func(tx *bbolt.Tx) error {
+		var err error
+		value, err = fileTx{f: f, tx: tx}.hget(key, field)
+		return err
 	})
 
 	return value, err
 }
 
-// HSet 设置哈希表中的字段值
-func (f *FileCache) HSet(key string, values ...interface{}) (int64, error) {
-	if len(values)%2 != 0 {
-		return 0, fmt.Errorf("值必须是键值对")
+// hget 获取哈希表中的字段值
+func (t fileTx) hget(key, field string) (string, error) {
+	// 写事务中 getHashBucket 会创建不存在的哈希表，读取时直接查找子桶
+	prefixedKey := t.f.buildKey(key)
+	if err := checkType(t.tx, prefixedKey, hashBucket); err != nil {
+		return "", err
 	}
+	bucket := t.tx.Bucket([]byte(hashBucket)).Bucket([]byte(prefixedKey))
+	if bucket == nil {
+		return "", ErrKeyNotFound
+	}
+
+	data := bucket.Get([]byte(field))
+	if data == nil {
+		return "", ErrKeyNotFound
+	}
+	return string(data), nil
+}
 
+// HSet 设置哈希表中的字段值
+func (f *FileCache) HSet(key string, values ...interface{}) (int64, error) {
 	var count int64
-	err := f.db.Update(func(tx *bbolt.Tx) error {
-		bucket, err := getHashBucket(tx, f.buildKey(key))
-		if err != nil {
-			return err
-		}
+	err := f.update(func(tx *bbolt.Tx) error {
+		var err error
+		count, err = fileTx{f: f, tx: tx}.hset(key, values)
+		return err
+	})
 
-		// 处理键值对
-		for i := 0; i < len(values); i += 2 {
-			fieldName, ok := values[i].(string)
-			if !ok {
-				return fmt.Errorf("字段名必须是字符串")
-			}
+	return count, err
+}
 
-			// 将值转换为字符串
-			var fieldValue string
-			switch v := values[i+1].(type) {
-			case string:
-				fieldValue = v
-			case []byte:
-				fieldValue = string(v)
-			default:
-				// 尝试将其他类型转换为字符串
-				valueBytes, err := json.Marshal(v)
-				if err != nil {
-					return err
-				}
-				fieldValue = string(valueBytes)
-			}
+// hset 设置哈希表中的字段值，返回新增的字段数
+func (t fileTx) hset(key string, values []interface{}) (int64, error) {
+	pairs, err := hashFields(values)
+	if err != nil {
+		return 0, err
+	}
 
-			// 检查字段是否已存在
-			if bucket.Get([]byte(fieldName)) == nil {
-				count++
-			}
+	bucket, err := getHashBucket(t.tx, t.f.buildKey(key))
+	if err != nil {
+		return 0, err
+	}
 
-			// 设置字段值
-			if err := bucket.Put([]byte(fieldName), []byte(fieldValue)); err != nil {
-				return err
-			}
+	// 处理键值对
+	var count int64
+	for i := 0; i < len(pairs); i += 2 {
+		fieldName, fieldValue := pairs[i], pairs[i+1]
+
+		// 检查字段是否已存在
+		if bucket.Get([]byte(fieldName)) == nil {
+			count++
 		}
 
-		return nil
-	})
+		// 设置字段值
+		if err := bucket.Put([]byte(fieldName), []byte(fieldValue)); err != nil {
+			return count, err
+		}
+	}
 
-	return count, err
+	return count, nil
 }
 
-// HDel 删除哈希表中的字段
+// HDel 删除哈希表中的字段，删除最后一个字段时删除键
 func (f *FileCache) HDel(key string, fields ...string) (int64, error) {
 	var count int64
-	err := f.db.Update(func(tx *bbolt.Tx) error {
-		bucket, err := getHashBucket(tx, f.buildKey(key))
-		if err != nil {
-			return err
-		}
+	err := f.update(func(tx *bbolt.Tx) error {
+		var err error
+		count, err = fileTx{f: f, tx: tx}.hdel(key, fields)
+		return err
+	})
+
+	return count, err
+}
+
+// hdel 删除哈希表中的字段，删除最后一个字段时删除键
+func (t fileTx) hdel(key string, fields []string) (int64, error) {
+	prefixedKey := t.f.buildKey(key)
+	bucket, err := getHashBucket(t.tx, prefixedKey)
+	if err != nil {
+		return 0, err
+	}
 
-		// 删除每个字段
-		for _, field := range fields {
-			if bucket.Get([]byte(field)) != nil {
-				if err := bucket.Delete([]byte(field)); err != nil {
-					return err
-				}
-				count++
+	// 删除每个字段
+	var count int64
+	for _, field := range fields {
+		if bucket.Get([]byte(field)) != nil {
+			if err := bucket.Delete([]byte(field)); err != nil {
+				return count, err
 			}
+			count++
 		}
+	}
 
-		return nil
-	})
-
-	return count, err
+	if k, _ := bucket.Cursor().First(); k == nil {
+		return count, t.tx.Bucket([]byte(hashBucket)).DeleteBucket([]byte(prefixedKey))
+	}
+	return count, nil
 }
 
 // HGetAll 获取哈希表中的所有字段和值
 func (f *FileCache) HGetAll(key string) (map[string]string, error) {
 	result := make(map[string]string)
-	err := f.db.View(func(tx *bbolt.Tx) error {
+	err := f.view(func(tx *bbolt.Tx) error {
 		bucket, err := getHashBucket(tx, f.buildKey(key))
+		if errors.Is(err, ErrKeyNotFound) {
+			return nil // 键不存在时视为空哈希表
+		}
 		if err != nil {
 			return err
 		}
@@ -841,8 +1599,11 @@ func (f *FileCache) HGetAll(key string) (map[string]string, error) {
 // HExists 检查哈希表中是否存在指定字段
 func (f *FileCache) HExists(key, field string) (bool, error) {
 	var exists bool
-	err := f.db.View(func(tx *bbolt.Tx) error {
+	err := f.view(func(tx *bbolt.Tx) error {
 		bucket, err := getHashBucket(tx, f.buildKey(key))
+		if errors.Is(err, ErrKeyNotFound) {
+			return nil // 键不存在时视为空哈希表
+		}
 		if err != nil {
 			return err
 		}
@@ -857,8 +1618,11 @@ func (f *FileCache) HExists(key, field string) (bool, error) {
 // HLen 获取哈希表中的字段数量
 func (f *FileCache) HLen(key string) (int64, error) {
 	var count int64
-	err := f.db.View(func(tx *bbolt.Tx) error {
+	err := f.view(func(tx *bbolt.Tx) error {
 		bucket, err := getHashBucket(tx, f.buildKey(key))
+		if errors.Is(err, ErrKeyNotFound) {
+			return nil // 键不存在时视为空哈希表
+		}
 		if err != nil {
 			return err
 		}
@@ -877,6 +1641,11 @@ func (f *FileCache) HLen(key string) (int64, error) {
 
 // 获取列表的桶
 func getListBucket(tx *bbolt.Tx, key string) (*bbolt.Bucket, error) {
+	// 键已存储为其他类型时返回类型错误
+	if err := checkType(tx, key, listBucket); err != nil {
+		return nil, err
+	}
+
 	// 获取列表桶
 	listBucketObj := tx.Bucket([]byte(listBucket))
 	if listBucketObj == nil {
@@ -943,135 +1712,88 @@ func getListItemKey(index int64) []byte {
 
 // LPush 将一个或多个值插入到列表头部
 func (f *FileCache) LPush(key string, values ...interface{}) (int64, error) {
-	var length int64
-	err := f.db.Update(func(tx *bbolt.Tx) error {
-		bucket, err := getListBucket(tx, f.buildKey(key))
-		if err != nil {
-			return err
-		}
-
-		// 获取当前长度
-		length, err = getListLength(bucket)
-		if err != nil {
-			return err
-		}
-
-		// 插入元素到头部，从前往后处理，这样最后一个元素会在最前面
-		for i := 0; i < len(values); i++ {
-			// 将值转换为字符串
-			var value string
-			switch v := values[i].(type) {
-			case string:
-				value = v
-			case []byte:
-				value = string(v)
-			default:
-				// 尝试将其他类型转换为字符串
-				valueBytes, err := json.Marshal(v)
-				if err != nil {
-					return err
-				}
-				value = string(valueBytes)
-			}
-
-			// 将所有元素后移
-			for j := length; j > 0; j-- {
-				itemKey := getListItemKey(j - 1)
-				itemData := bucket.Get(itemKey)
-				if itemData != nil {
-					if err := bucket.Put(getListItemKey(j), itemData); err != nil {
-						return err
-					}
-				}
-			}
-
-			// 插入新元素到头部
-			item := listItem{
-				Index: 0,
-				Value: value,
-			}
-
-			itemData, err := json.Marshal(item)
-			if err != nil {
-				return err
-			}
-
-			if err := bucket.Put(getListItemKey(0), itemData); err != nil {
-				return err
-			}
+	return f.push(key, values, true)
+}
 
-			length++
-		}
+// RPush 将一个或多个值插入到列表尾部
+func (f *FileCache) RPush(key string, values ...interface{}) (int64, error) {
+	return f.push(key, values, false)
+}
 
-		// 更新长度
-		return setListLength(bucket, length)
+// push 将值插入到列表头部或尾部，并唤醒等待该列表的阻塞弹出
+func (f *FileCache) push(key string, values []interface{}, left bool) (int64, error) {
+	var length int64
+	err := f.update(func(tx *bbolt.Tx) error {
+		var err error
+		length, err = fileTx{f: f, tx: tx}.push(key, values, left)
+		return err
 	})
 
+	if err == nil {
+		f.hub.signal(f.buildKey(key))
+	}
 	return length, err
 }
 
-// RPush 将一个或多个值插入到列表尾部
-func (f *FileCache) RPush(key string, values ...interface{}) (int64, error) {
-	var length int64
-	err := f.db.Update(func(tx *bbolt.Tx) error {
-		bucket, err := getListBucket(tx, f.buildKey(key))
-		if err != nil {
-			return err
-		}
-
-		// 获取当前长度
-		length, err = getListLength(bucket)
+// push 将值插入到列表头部或尾部，返回插入后的长度
+func (t fileTx) push(key string, values []interface{}, left bool) (int64, error) {
+	// 先转换全部的值，避免转换失败时只插入了部分元素
+	elements := make([]string, len(values))
+	for i, value := range values {
+		str, err := stringValue(value)
 		if err != nil {
-			return err
+			return 0, err
 		}
+		elements[i] = str
+	}
 
-		// 插入元素到尾部
-		for _, v := range values {
-			// 将值转换为字符串
-			var value string
-			switch val := v.(type) {
-			case string:
-				value = val
-			case []byte:
-				value = string(val)
-			default:
-				// 尝试将其他类型转换为字符串
-				valueBytes, err := json.Marshal(val)
-				if err != nil {
-					return err
-				}
-				value = string(valueBytes)
-			}
-
-			// 插入新元素到尾部
-			item := listItem{
-				Index: length,
-				Value: value,
-			}
+	bucket, err := getListBucket(t.tx, t.f.buildKey(key))
+	if err != nil {
+		return 0, err
+	}
 
-			itemData, err := json.Marshal(item)
-			if err != nil {
-				return err
-			}
+	// 获取当前长度
+	length, err := getListLength(bucket)
+	if err != nil {
+		return 0, err
+	}
 
-			if err := bucket.Put(getListItemKey(length), itemData); err != nil {
-				return err
+	if left {
+		// 将所有元素后移，为插入的元素腾出位置
+		n := int64(len(elements))
+		for j := length - 1; j >= 0; j-- {
+			if itemData := bucket.Get(getListItemKey(j)); itemData != nil {
+				if err := bucket.Put(getListItemKey(j+n), itemData); err != nil {
+					return 0, err
+				}
 			}
-
-			length++
 		}
+	}
 
-		// 更新长度
-		return setListLength(bucket, length)
-	})
+	// 从左侧插入时逐个插入到头部，最后一个元素位于最前面
+	for i, value := range elements {
+		index := length + int64(i)
+		if left {
+			index = int64(len(elements) - 1 - i)
+		}
+		itemData, err := json.Marshal(listItem{Index: index, Value: value})
+		if err != nil {
+			return 0, err
+		}
+		if err := bucket.Put(getListItemKey(index), itemData); err != nil {
+			return 0, err
+		}
+	}
 
-	return length, err
+	// 更新长度
+	length += int64(len(elements))
+	return length, setListLength(bucket, length)
 }
 
 // LPop 移除并返回列表头部元素
 func (f *FileCache) LPop(key string) (string, error) {
 	var value string
-	err := f.db.Update(func(tx *bbolt.Tx) error {
+	err := f.update(func(tx *bbolt.Tx) error {
 		bucket, err := getListBucket(tx, f.buildKey(key))
 		if err != nil {
 			return err
@@ -1125,7 +1847,7 @@ func (f *FileCache) LPop(key string) (string, error) {
 // RPop 移除并返回列表尾部元素
 func (f *FileCache) RPop(key string) (string, error) {
 	var value string
-	err := f.db.Update(func(tx *bbolt.Tx) error {
+	err := f.update(func(tx *bbolt.Tx) error {
 		bucket, err := getListBucket(tx, f.buildKey(key))
 		if err != nil {
 			return err
@@ -1169,7 +1891,7 @@ func (f *FileCache) RPop(key string) (string, error) {
 // LLen 获取列表长度
 func (f *FileCache) LLen(key string) (int64, error) {
 	var length int64
-	err := f.db.View(func(tx *bbolt.Tx) error {
+	err := f.view(func(tx *bbolt.Tx) error {
 		bucket, err := getListBucket(tx, f.buildKey(key))
 		if err != nil {
 			return err
@@ -1186,7 +1908,7 @@ func (f *FileCache) LLen(key string) (int64, error) {
 // LRange 获取列表指定范围内的元素
 func (f *FileCache) LRange(key string, start, stop int64) ([]string, error) {
 	var result []string
-	err := f.db.View(func(tx *bbolt.Tx) error {
+	err := f.view(func(tx *bbolt.Tx) error {
 		bucket, err := getListBucket(tx, f.buildKey(key))
 		if err != nil {
 			return err
@@ -1247,6 +1969,11 @@ func (f *FileCache) LRange(key string, start, stop int64) ([]string, error) {
 
 // 获取集合的桶
 func getSetBucket(tx *bbolt.Tx, key string) (*bbolt.Bucket, error) {
+	// 键已存储为其他类型时返回类型错误
+	if err := checkType(tx, key, setBucket); err != nil {
+		return nil, err
+	}
+
 	// 获取集合桶
 	setBucketObj := tx.Bucket([]byte(setBucket))
 	if setBucketObj == nil {
@@ -1278,50 +2005,48 @@ func getSetBucket(tx *bbolt.Tx, key string) (*bbolt.Bucket, error) {
 // SAdd 将一个或多个成员元素加入到集合中
 func (f *FileCache) SAdd(key string, members ...interface{}) (int64, error) {
 	var count int64
-	err := f.db.Update(func(tx *bbolt.Tx) error {
-		bucket, err := getSetBucket(tx, f.buildKey(key))
+	err := f.update(func(tx *bbolt.Tx) error {
+		var err error
+		count, err = fileTx{f: f, tx: tx}.sadd(key, members)
+		return err
+	})
+
+	return count, err
+}
+
+// sadd 将成员加入到集合中，返回新增的成员数
+func (t fileTx) sadd(key string, members []interface{}) (int64, error) {
+	bucket, err := getSetBucket(t.tx, t.f.buildKey(key))
+	if err != nil {
+		return 0, err
+	}
+
+	// 添加成员
+	var count int64
+	for _, member := range members {
+		// 将值转换为字符串
+		value, err := stringValue(member)
 		if err != nil {
-			return err
+			return count, err
 		}
 
-		// 添加成员
-		for _, member := range members {
-			// 将值转换为字符串
-			var value string
-			switch v := member.(type) {
-			case string:
-				value = v
-			case []byte:
-				value = string(v)
-			default:
-				// 尝试将其他类型转换为字符串
-				valueBytes, err := json.Marshal(v)
-				if err != nil {
-					return err
-				}
-				value = string(valueBytes)
-			}
-
-			// 检查成员是否已存在
-			if bucket.Get([]byte(value)) == nil {
-				// 添加新成员
-				if err := bucket.Put([]byte(value), []byte{1}); err != nil {
-					return err
-				}
-				count++
+		// 检查成员是否已存在
+		if bucket.Get([]byte(value)) == nil {
+			// 添加新成员
+			if err := bucket.Put([]byte(value), []byte{1}); err != nil {
+				return count, err
 			}
+			count++
 		}
+	}
 
-		return nil
-	})
-
-	return count, err
+	return count, nil
 }
 
 // SMembers 返回集合中的所有成员
 func (f *FileCache) SMembers(key string) ([]string, error) {
 	var members []string
-	err := f.db.View(func(tx *bbolt.Tx) error {
+	err := f.view(func(tx *bbolt.Tx) error {
 		bucket, err := getSetBucket(tx, f.buildKey(key))
 		if err != nil {
 			return err
@@ -1340,50 +2065,48 @@ func (f *FileCache) SMembers(key string) ([]string, error) {
 // SRem 移除集合中的一个或多个成员
 func (f *FileCache) SRem(key string, members ...interface{}) (int64, error) {
 	var count int64
-	err := f.db.Update(func(tx *bbolt.Tx) error {
-		bucket, err := getSetBucket(tx, f.buildKey(key))
+	err := f.update(func(tx *bbolt.Tx) error {
+		var err error
+		count, err = fileTx{f: f, tx: tx}.srem(key, members)
+		return err
+	})
+
+	return count, err
+}
+
+// srem 移除集合中的成员，返回移除的成员数
+func (t fileTx) srem(key string, members []interface{}) (int64, error) {
+	bucket, err := getSetBucket(t.tx, t.f.buildKey(key))
+	if err != nil {
+		return 0, err
+	}
+
+	// 移除成员
+	var count int64
+	for _, member := range members {
+		// 将值转换为字符串
+		value, err := stringValue(member)
 		if err != nil {
-			return err
+			return count, err
 		}
 
-		// 移除成员
-		for _, member := range members {
-			// 将值转换为字符串
-			var value string
-			switch v := member.(type) {
-			case string:
-				value = v
-			case []byte:
-				value = string(v)
-			default:
-				// 尝试将其他类型转换为字符串
-				valueBytes, err := json.Marshal(v)
-				if err != nil {
-					return err
-				}
-				value = string(valueBytes)
-			}
-
-			// 检查成员是否存在
-			if bucket.Get([]byte(value)) != nil {
-				// 删除成员
-				if err := bucket.Delete([]byte(value)); err != nil {
-					return err
-				}
-				count++
+		// 检查成员是否存在
+		if bucket.Get([]byte(value)) != nil {
+			// 删除成员
+			if err := bucket.Delete([]byte(value)); err != nil {
+				return count, err
 			}
+			count++
 		}
+	}
 
-		return nil
-	})
-
-	return count, err
+	return count, nil
 }
 
 // SCard 获取集合的成员数
 func (f *FileCache) SCard(key string) (int64, error) {
 	var count int64
-	err := f.db.View(func(tx *bbolt.Tx) error {
+	err := f.view(func(tx *bbolt.Tx) error {
 		bucket, err := getSetBucket(tx, f.buildKey(key))
 		if err != nil {
 			return err
@@ -1402,26 +2125,16 @@ func (f *FileCache) SCard(key string) (int64, error) {
 // SIsMember 判断成员元素是否是集合的成员
 func (f *FileCache) SIsMember(key string, member interface{}) (bool, error) {
 	var isMember bool
-	err := f.db.View(func(tx *bbolt.Tx) error {
+	err := f.view(func(tx *bbolt.Tx) error {
 		bucket, err := getSetBucket(tx, f.buildKey(key))
 		if err != nil {
 			return err
 		}
 
 		// 将值转换为字符串
-		var value string
-		switch v := member.(type) {
-		case string:
-			value = v
-		case []byte:
-			value = string(v)
-		default:
-			// 尝试将其他类型转换为字符串
-			valueBytes, err := json.Marshal(v)
-			if err != nil {
-				return err
-			}
-			value = string(valueBytes)
+		value, err := stringValue(member)
+		if err != nil {
+			return err
 		}
 
 		// 检查成员是否存在
@@ -1488,6 +2201,78 @@ func (f *FileCache) TTLCtx(ctx context.Context, key string) (time.Duration, erro
 	return f.TTL(key)
 }
 
+// SetNXCtx 键不存在时设置缓存（带上下文）
+func (f *FileCache) SetNXCtx(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	// 检查上下文是否已取消
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return f.SetNX(key, value, expiration)
+}
+
+// CompareAndDelCtx 值相等时删除键（带上下文）
+func (f *FileCache) CompareAndDelCtx(ctx context.Context, key, value string) (bool, error) {
+	// 检查上下文是否已取消
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return f.CompareAndDel(key, value)
+}
+
+// CompareAndExpireCtx 值相等时设置过期时间（带上下文）
+func (f *FileCache) CompareAndExpireCtx(ctx context.Context, key, value string, expiration time.Duration) (bool, error) {
+	// 检查上下文是否已取消
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return f.CompareAndExpire(key, value, expiration)
+}
+
+// GetSetCtx 设置新值并返回旧值（带上下文）
+func (f *FileCache) GetSetCtx(ctx context.Context, key string, value interface{}) (string, error) {
+	// 检查上下文是否已取消
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return f.GetSet(key, value)
+}
+
+// MGetCtx 批量读取（带上下文）
+func (f *FileCache) MGetCtx(ctx context.Context, keys ...string) ([]interface{}, error) {
+	// 检查上下文是否已取消
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return f.MGet(keys...)
+}
+
+// MSetCtx 批量写入（带上下文）
+func (f *FileCache) MSetCtx(ctx context.Context, values ...interface{}) error {
+	// 检查上下文是否已取消
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return f.MSet(values...)
+}
+
+// PersistCtx 移除过期时间（带上下文）
+func (f *FileCache) PersistCtx(ctx context.Context, key string) (bool, error) {
+	// 检查上下文是否已取消
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return f.Persist(key)
+}
+
+// RenameCtx 重命名键（带上下文）
+func (f *FileCache) RenameCtx(ctx context.Context, key, newKey string) error {
+	// 检查上下文是否已取消
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return f.Rename(key, newKey)
+}
+
 // ================== 带 Context 的计数器操作 ==================
 
 // IncrCtx 自增（带上下文）
@@ -1678,103 +2463,53 @@ func (f *FileCache) SIsMemberCtx(ctx context.Context, key string, member interfa
 
 // ================== 键操作 ==================
 
-// Keys 查找所有符合给定模式的键
+// Keys 查找所有符合给定模式的键，模式规则与 Redis 相同
 func (f *FileCache) Keys(pattern string) ([]string, error) {
-	var keys []string
-
-	// 如果模式是 "*"，则返回所有键
-	if pattern == "*" {
-		err := f.db.View(func(tx *bbolt.Tx) error {
-			// 遍历所有桶
-			buckets := []string{
-				defaultBucket,
-				hashBucket,
-				listBucket,
-				setBucket,
-				zsetBucket,
+	keys := []string{}
+	err := f.view(func(tx *bbolt.Tx) error {
+		// 收集所有桶中带前缀的键名，同一键可能在多个桶中留有空的子桶
+		seen := make(map[string]bool)
+		collect := func(k []byte) {
+			key := string(k)
+			if !strings.HasPrefix(key, f.prefix) || seen[key] {
+				return
 			}
-
-			for _, bucketName := range buckets {
-				bucket := tx.Bucket([]byte(bucketName))
-				if bucket == nil {
-					continue
-				}
-
-				// 对于默认桶，直接获取所有键
-				if bucketName == defaultBucket {
-					err := bucket.ForEach(func(k, v []byte) error {
-						// 去除前缀
-						key := string(k)
-						if f.prefix != "" && strings.HasPrefix(key, f.prefix) {
-							key = key[len(f.prefix):]
-						}
-						keys = append(keys, key)
-						return nil
-					})
-					if err != nil {
-						return err
-					}
-				} else {
-					// 对于其他桶，获取所有子桶名称
-					err := bucket.ForEach(func(k, v []byte) error {
-						if v == nil { // 子桶
-							// 去除前缀
-							key := string(k)
-							if f.prefix != "" && strings.HasPrefix(key, f.prefix) {
-								key = key[len(f.prefix):]
-							}
-							keys = append(keys, key)
-						}
-						return nil
-					})
-					if err != nil {
-						return err
-					}
-				}
+			seen[key] = true
+			if matchPattern(pattern, key[len(f.prefix):]) {
+				keys = append(keys, key)
 			}
-
-			return nil
-		})
-
-		return keys, err
-	}
-
-	// 如果模式不是 "*"，则需要进行模式匹配
-	// 将 Redis 风格的通配符模式转换为正则表达式
-	regexPattern := "^"
-	for i := 0; i < len(pattern); i++ {
-		switch pattern[i] {
-		case '*':
-			regexPattern += ".*"
-		case '?':
-			regexPattern += "."
-		case '[', ']', '(', ')', '.', '+', '|', '^', '$', '\\':
-			regexPattern += "\\" + string(pattern[i])
-		default:
-			regexPattern += string(pattern[i])
 		}
-	}
-	regexPattern += "$"
-
-	regex, err := regexp.Compile(regexPattern)
-	if err != nil {
-		return nil, err
-	}
 
-	// 获取所有键并进行模式匹配
-	allKeys, err := f.Keys("*")
-	if err != nil {
-		return nil, err
-	}
+		for _, bucketName := range append([]string{defaultBucket}, typeBuckets...) {
+			bucket := tx.Bucket([]byte(bucketName))
+			if bucket == nil {
+				continue
+			}
+			err := bucket.ForEach(func(k, v []byte) error {
+				collect(k)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
 
-	// 过滤符合模式的键
-	for _, key := range allKeys {
-		if regex.MatchString(key) {
-			keys = append(keys, key)
+		// 过滤已过期和空的键
+		matched := keys[:0]
+		for _, key := range keys {
+			current, err := keyType(tx, key)
+			if err != nil {
+				return err
+			}
+			if current != "" {
+				matched = append(matched, key[len(f.prefix):])
+			}
 		}
-	}
+		keys = matched
+		return nil
+	})
 
-	return keys, nil
+	return keys, err
 }
 
 // KeysCtx 查找所有符合给定模式的键（带上下文）
@@ -1786,6 +2521,28 @@ func (f *FileCache) KeysCtx(ctx context.Context, pattern string) ([]string, erro
 	return f.Keys(pattern)
 }
 
+// Scan 按游标分批遍历匹配的键，每次调用都会遍历所有桶
+func (f *FileCache) Scan(cursor uint64, match string, count int64) ([]string, uint64, error) {
+	if match == "" {
+		match = "*"
+	}
+	keys, err := f.Keys(match)
+	if err != nil {
+		return nil, 0, err
+	}
+	page, next := scanPage(keys, cursor, count)
+	return page, next, nil
+}
+
+// ScanCtx 按游标分批遍历匹配的键（带上下文）
+func (f *FileCache) ScanCtx(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	// 检查上下文是否已取消
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	return f.Scan(cursor, match, count)
+}
+
 // ================== 有序集合操作 ==================
 
 // 有序集合成员结构
@@ -1796,6 +2553,11 @@ type zsetMember struct {
 
 // 获取有序集合的桶
 func getZSetBucket(tx *bbolt.Tx, key string) (*bbolt.Bucket, error) {
+	// 键已存储为其他类型时返回类型错误
+	if err := checkType(tx, key, zsetBucket); err != nil {
+		return nil, err
+	}
+
 	// 获取有序集合桶
 	zsetBucketObj := tx.Bucket([]byte(zsetBucket))
 	if zsetBucketObj == nil {
@@ -1875,97 +2637,138 @@ func float64ToSortableBytes(f float64) []byte {
 // ZAdd 将一个或多个成员元素及其分数值加入到有序集合中
 func (f *FileCache) ZAdd(key string, members ...Z) (int64, error) {
 	var count int64
-	err := f.db.Update(func(tx *bbolt.Tx) error {
-		// 获取有序集合桶
-		bucket, err := getZSetBucket(tx, f.buildKey(key))
+	err := f.update(func(tx *bbolt.Tx) error {
+		var err error
+		count, err = fileTx{f: f, tx: tx}.zadd(key, members)
+		return err
+	})
+
+	return count, err
+}
+
+// zsetBuckets 获取有序集合的成员桶和分数索引桶
+func (t fileTx) zsetBuckets(key string) (*bbolt.Bucket, *bbolt.Bucket, error) {
+	bucket, err := getZSetBucket(t.tx, t.f.buildKey(key))
+	if err != nil {
+		return nil, nil, err
+	}
+	scoreBucket, err := getZSetScoreBucket(t.tx, t.f.buildKey(key))
+	if err != nil {
+		return nil, nil, err
+	}
+	return bucket, scoreBucket, nil
+}
+
+// zadd 将成员及其分数加入到有序集合中，返回新增的成员数
+func (t fileTx) zadd(key string, members []Z) (int64, error) {
+	bucket, scoreBucket, err := t.zsetBuckets(key)
+	if err != nil {
+		return 0, err
+	}
+
+	// 处理成员-分数对
+	var count int64
+	for _, m := range members {
+		// 解析成员
+		member, err := stringValue(m.Member)
 		if err != nil {
-			return err
+			return count, err
 		}
 
-		// 获取分数索引桶
-		scoreBucket, err := getZSetScoreBucket(tx, f.buildKey(key))
+		added, err := zsetPut(bucket, scoreBucket, member, m.Score)
 		if err != nil {
-			return err
+			return count, err
+		}
+		if added {
+			count++
 		}
+	}
 
-		// 处理成员-分数对
-		for _, m := range members {
-			score := m.Score
+	return count, nil
+}
 
-			// 解析成员
-			var member string
-			switch v := m.Member.(type) {
-			case string:
-				member = v
-			case []byte:
-				member = string(v)
-			default:
-				// 尝试将其他类型转换为字符串
-				valueBytes, err := json.Marshal(v)
-				if err != nil {
-					return err
-				}
-				member = string(valueBytes)
-			}
+// zsetGet 读取成员，不存在时返回 nil
+func zsetGet(bucket *bbolt.Bucket, member string) (*zsetMember, error) {
+	data := bucket.Get([]byte(member))
+	if data == nil {
+		return nil, nil
+	}
+	var memberData zsetMember
+	if err := json.Unmarshal(data, &memberData); err != nil {
+		return nil, err
+	}
+	return &memberData, nil
+}
 
-			// 检查成员是否已存在
-			oldData := bucket.Get([]byte(member))
-			if oldData != nil {
-				// 成员已存在，更新分数
-				var oldMember zsetMember
-				if err := json.Unmarshal(oldData, &oldMember); err != nil {
-					return err
-				}
+// zsetPut 写入成员分数并更新分数索引，返回是否新增了成员
+func zsetPut(bucket, scoreBucket *bbolt.Bucket, member string, score float64) (bool, error) {
+	old, err := zsetGet(bucket, member)
+	if err != nil {
+		return false, err
+	}
+	if old != nil {
+		// 如果分数相同，不做任何操作
+		if old.Score == score {
+			return false, nil
+		}
 
-				// 如果分数相同，不做任何操作
-				if oldMember.Score == score {
-					continue
-				}
+		// 删除旧的分数索引
+		if err := scoreBucket.Delete(float64ToSortableBytes(old.Score)); err != nil {
+			return false, err
+		}
+	}
 
-				// 删除旧的分数索引
-				oldScoreKey := float64ToSortableBytes(oldMember.Score)
-				if err := scoreBucket.Delete(oldScoreKey); err != nil {
-					return err
-				}
-			} else {
-				// 成员不存在，计数加一
-				count++
-			}
+	data, err := json.Marshal(zsetMember{Score: score, Value: member})
+	if err != nil {
+		return false, err
+	}
+	if err := bucket.Put([]byte(member), data); err != nil {
+		return false, err
+	}
 
-			// 创建新的成员数据
-			memberData := zsetMember{
-				Score: score,
-				Value: member,
-			}
+	// 保存分数索引
+	return old == nil, scoreBucket.Put(float64ToSortableBytes(score), []byte(member))
+}
 
-			// 序列化成员数据
-			data, err := json.Marshal(memberData)
-			if err != nil {
-				return err
-			}
+// zsetDelete 删除成员及其分数索引，返回成员是否存在
+func zsetDelete(bucket, scoreBucket *bbolt.Bucket, member string) (bool, error) {
+	old, err := zsetGet(bucket, member)
+	if err != nil || old == nil {
+		return false, err
+	}
+	if err := scoreBucket.Delete(float64ToSortableBytes(old.Score)); err != nil {
+		return false, err
+	}
+	return true, bucket.Delete([]byte(member))
+}
 
-			// 保存成员数据
-			if err := bucket.Put([]byte(member), data); err != nil {
-				return err
-			}
+// sortedZSet 在事务中读取有序集合的全部成员，按分数从低到高排序，键不存在时返回空
+func sortedZSet(tx *bbolt.Tx, key string) (ZMembers, error) {
+	bucket, err := getZSetBucket(tx, key)
+	if errors.Is(err, ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
 
-			// 保存分数索引
-			scoreKey := float64ToSortableBytes(score)
-			if err := scoreBucket.Put(scoreKey, []byte(member)); err != nil {
-				return err
-			}
+	var members ZMembers
+	err = bucket.ForEach(func(k, v []byte) error {
+		var member zsetMember
+		if err := json.Unmarshal(v, &member); err != nil {
+			return err
 		}
-
+		members = append(members, ZMember{Score: member.Score, Member: member.Value})
 		return nil
 	})
-
-	return count, err
+	sort.Sort(members)
+	return members, err
 }
 
 // ZScore 返回有序集合中成员的分数值
 func (f *FileCache) ZScore(key string, member string) (float64, error) {
 	var score float64
-	err := f.db.View(func(tx *bbolt.Tx) error {
+	err := f.view(func(tx *bbolt.Tx) error {
 		bucket, err := getZSetBucket(tx, f.buildKey(key))
 		if err != nil {
 			return err
@@ -1993,83 +2796,50 @@ func (f *FileCache) ZScore(key string, member string) (float64, error) {
 // ZRem 移除有序集合中的一个或多个成员
 func (f *FileCache) ZRem(key string, members ...interface{}) (int64, error) {
 	var count int64
-	err := f.db.Update(func(tx *bbolt.Tx) error {
-		// 获取有序集合桶
-		bucket, err := getZSetBucket(tx, f.buildKey(key))
+	err := f.update(func(tx *bbolt.Tx) error {
+		var err error
+		count, err = fileTx{f: f, tx: tx}.zrem(key, members)
+		return err
+	})
+
+	return count, err
+}
+
+// zrem 移除有序集合中的成员，返回移除的成员数
+func (t fileTx) zrem(key string, members []interface{}) (int64, error) {
+	bucket, scoreBucket, err := t.zsetBuckets(key)
+	if err != nil {
+		return 0, err
+	}
+
+	// 处理每个成员
+	var count int64
+	for _, m := range members {
+		// 将成员转换为字符串
+		member, err := stringValue(m)
 		if err != nil {
-			return err
+			return count, err
 		}
 
-		// 获取分数索引桶
-		scoreBucket, err := getZSetScoreBucket(tx, f.buildKey(key))
+		removed, err := zsetDelete(bucket, scoreBucket, member)
 		if err != nil {
-			return err
+			return count, err
 		}
-
-		// 处理每个成员
-		for _, m := range members {
-			// 将成员转换为字符串
-			var member string
-			switch v := m.(type) {
-			case string:
-				member = v
-			case []byte:
-				member = string(v)
-			default:
-				// 尝试将其他类型转换为字符串
-				valueBytes, err := json.Marshal(v)
-				if err != nil {
-					return err
-				}
-				member = string(valueBytes)
-			}
-
-			// 获取成员数据
-			data := bucket.Get([]byte(member))
-			if data == nil {
-				continue
-			}
-
-			// 解析成员数据
-			var memberData zsetMember
-			if err := json.Unmarshal(data, &memberData); err != nil {
-				return err
-			}
-
-			// 删除分数索引
-			scoreKey := float64ToSortableBytes(memberData.Score)
-			if err := scoreBucket.Delete(scoreKey); err != nil {
-				return err
-			}
-
-			// 删除成员
-			if err := bucket.Delete([]byte(member)); err != nil {
-				return err
-			}
-
+		if removed {
 			count++
 		}
+	}
 
-		return nil
-	})
-
-	return count, err
+	return count, nil
 }
 
 // ZCard 获取有序集合的成员数
 func (f *FileCache) ZCard(key string) (int64, error) {
 	var count int64
-	err := f.db.View(func(tx *bbolt.Tx) error {
-		bucket, err := getZSetBucket(tx, f.buildKey(key))
-		if err != nil {
-			return err
-		}
-
-		// 遍历所有成员并计数
-		return bucket.ForEach(func(k, v []byte) error {
-			count++
-			return nil
-		})
+	err := f.view(func(tx *bbolt.Tx) error {
+		members, err := sortedZSet(tx, f.buildKey(key))
+		count = int64(len(members))
+		return err
 	})
 
 	return count, err
@@ -2077,185 +2847,181 @@ func (f *FileCache) ZCard(key string) (int64, error) {
 
 // ZRange 返回有序集合中指定区间内的成员
 func (f *FileCache) ZRange(key string, start, stop int64) ([]string, error) {
-	var result []string
-	err := f.db.View(func(tx *bbolt.Tx) error {
-		// 获取有序集合桶
-		bucket, err := getZSetBucket(tx, f.buildKey(key))
-		if err != nil {
-			return err
+	result := []string{}
+	err := f.view(func(tx *bbolt.Tx) error {
+		members, err := sortedZSet(tx, f.buildKey(key))
+		for _, member := range indexRange(members, start, stop) {
+			result = append(result, member.Member.(string))
 		}
+		return err
+	})
 
-		// 获取所有成员及其分数
-		var members []zsetMember
-		err = bucket.ForEach(func(k, v []byte) error {
-			var member zsetMember
-			if err := json.Unmarshal(v, &member); err != nil {
-				return err
-			}
-			members = append(members, member)
-			return nil
-		})
-		if err != nil {
-			return err
-		}
+	return result, err
+}
 
-		// 按分数排序
-		sort.Slice(members, func(i, j int) bool {
-			if members[i].Score == members[j].Score {
-				return members[i].Value < members[j].Value
-			}
-			return members[i].Score < members[j].Score
-		})
+// ZRangeByScore 返回有序集合中指定分数区间内的成员
+func (f *FileCache) ZRangeByScore(key string, opt ZRangeBy) ([]string, error) {
+	scores, err := parseScoreRange(opt.Min, opt.Max)
+	if err != nil {
+		return nil, err
+	}
 
-		// 处理负索引
-		length := int64(len(members))
-		if start < 0 {
-			start = length + start
-			if start < 0 {
-				start = 0
+	result := []string{}
+	err = f.view(func(tx *bbolt.Tx) error {
+		members, err := sortedZSet(tx, f.buildKey(key))
+		skipped := int64(0)
+		for _, member := range members {
+			if !scores.contains(member.Score) {
+				continue
 			}
-		}
-
-		if stop < 0 {
-			stop = length + stop
-			if stop < 0 {
-				stop = 0
+			if skipped < opt.Offset {
+				skipped++
+				continue
 			}
+			if opt.Count > 0 && int64(len(result)) >= opt.Count {
+				break
+			}
+			result = append(result, member.Member.(string))
 		}
+		return err
+	})
 
-		// 确保索引在有效范围内
-		if start >= length {
-			return nil
-		}
-
-		if stop >= length {
-			stop = length - 1
-		}
+	return result, err
+}
 
-		// 获取范围内的成员
-		for i := start; i <= stop; i++ {
-			result = append(result, members[i].Value)
+// ZRevRange 按分数从高到低返回指定区间内的成员
+func (f *FileCache) ZRevRange(key string, start, stop int64) ([]string, error) {
+	result := []string{}
+	err := f.view(func(tx *bbolt.Tx) error {
+		members, err := sortedZSet(tx, f.buildKey(key))
+		sort.Sort(sort.Reverse(members))
+		for _, member := range indexRange(members, start, stop) {
+			result = append(result, member.Member.(string))
 		}
-
-		return nil
+		return err
 	})
 
 	return result, err
 }
 
-// ZRangeByScore 返回有序集合中指定分数区间内的成员
-func (f *FileCache) ZRangeByScore(key string, min, max float64) ([]string, error) {
-	var result []string
-	err := f.db.View(func(tx *bbolt.Tx) error {
-		// 获取有序集合桶
-		bucket, err := getZSetBucket(tx, f.buildKey(key))
-		if err != nil {
-			return err
-		}
+// ZIncrBy 增加成员的分数，成员不存在时添加
+func (f *FileCache) ZIncrBy(key string, increment float64, member string) (float64, error) {
+	var score float64
+	err := f.update(func(tx *bbolt.Tx) error {
+		var err error
+		score, err = fileTx{f: f, tx: tx}.zincrBy(key, increment, member)
+		return err
+	})
 
-		// 获取所有成员及其分数
-		var members []zsetMember
-		err = bucket.ForEach(func(k, v []byte) error {
-			var member zsetMember
-			if err := json.Unmarshal(v, &member); err != nil {
-				return err
-			}
+	return score, err
+}
 
-			// 只添加在分数范围内的成员
-			if member.Score >= min && member.Score <= max {
-				members = append(members, member)
-			}
-			return nil
-		})
-		if err != nil {
-			return err
-		}
+// zincrBy 增加成员的分数，返回新的分数
+func (t fileTx) zincrBy(key string, increment float64, member string) (float64, error) {
+	bucket, scoreBucket, err := t.zsetBuckets(key)
+	if err != nil {
+		return 0, err
+	}
 
-		// 按分数排序
-		sort.Slice(members, func(i, j int) bool {
-			if members[i].Score == members[j].Score {
-				return members[i].Value < members[j].Value
-			}
-			return members[i].Score < members[j].Score
-		})
+	old, err := zsetGet(bucket, member)
+	if err != nil {
+		return 0, err
+	}
+	score := increment
+	if old != nil {
+		score += old.Score
+	}
+	_, err = zsetPut(bucket, scoreBucket, member, score)
+	return score, err
+}
 
-		// 获取所有成员
-		for _, member := range members {
-			result = append(result, member.Value)
+// ZRank 返回成员按分数从低到高的排名，成员不存在时返回 ErrKeyNotFound
+func (f *FileCache) ZRank(key, member string) (int64, error) {
+	rank := int64(-1)
+	err := f.view(func(tx *bbolt.Tx) error {
+		members, err := sortedZSet(tx, f.buildKey(key))
+		for i, item := range members {
+			if item.Member == member {
+				rank = int64(i)
+				break
+			}
 		}
-
-		return nil
+		return err
 	})
+	if err == nil && rank < 0 {
+		err = ErrKeyNotFound
+	}
 
-	return result, err
+	return rank, err
 }
 
-// ZRangeWithScores 返回有序集合中指定区间内的成员和分数
-func (f *FileCache) ZRangeWithScores(key string, start, stop int64) ([]Z, error) {
-	var result []Z
-	err := f.db.View(func(tx *bbolt.Tx) error {
-		// 获取有序集合桶
+// ZRemRangeByScore 删除分数范围内的成员
+func (f *FileCache) ZRemRangeByScore(key, min, max string) (int64, error) {
+	scores, err := parseScoreRange(min, max)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	err = f.update(func(tx *bbolt.Tx) error {
+		members, err := sortedZSet(tx, f.buildKey(key))
+		if err != nil || len(members) == 0 {
+			return err
+		}
 		bucket, err := getZSetBucket(tx, f.buildKey(key))
 		if err != nil {
 			return err
 		}
-
-		// 获取所有成员及其分数
-		var members []zsetMember
-		err = bucket.ForEach(func(k, v []byte) error {
-			var member zsetMember
-			if err := json.Unmarshal(v, &member); err != nil {
-				return err
-			}
-			members = append(members, member)
-			return nil
-		})
+		scoreBucket, err := getZSetScoreBucket(tx, f.buildKey(key))
 		if err != nil {
 			return err
 		}
 
-		// 按分数排序
-		sort.Slice(members, func(i, j int) bool {
-			if members[i].Score == members[j].Score {
-				return members[i].Value < members[j].Value
+		for _, member := range members {
+			if !scores.contains(member.Score) {
+				continue
 			}
-			return members[i].Score < members[j].Score
-		})
-
-		// 处理负索引
-		length := int64(len(members))
-		if start < 0 {
-			start = length + start
-			if start < 0 {
-				start = 0
+			if _, err := zsetDelete(bucket, scoreBucket, member.Member.(string)); err != nil {
+				return err
 			}
+			count++
 		}
+		return nil
+	})
 
-		if stop < 0 {
-			stop = length + stop
-			if stop < 0 {
-				stop = 0
-			}
-		}
+	return count, err
+}
 
-		// 确保索引在有效范围内
-		if start >= length {
-			return nil
-		}
+// ZCount 统计分数范围内的成员数
+func (f *FileCache) ZCount(key, min, max string) (int64, error) {
+	scores, err := parseScoreRange(min, max)
+	if err != nil {
+		return 0, err
+	}
 
-		if stop >= length {
-			stop = length - 1
+	var count int64
+	err = f.view(func(tx *bbolt.Tx) error {
+		members, err := sortedZSet(tx, f.buildKey(key))
+		for _, member := range members {
+			if scores.contains(member.Score) {
+				count++
+			}
 		}
+		return err
+	})
 
-		// 获取范围内的成员和分数
-		for i := start; i <= stop; i++ {
-			result = append(result, Z{
-				Score:  members[i].Score,
-				Member: members[i].Value,
-			})
-		}
+	return count, err
+}
 
-		return nil
+// ZRangeWithScores 返回有序集合中指定区间内的成员和分数
+func (f *FileCache) ZRangeWithScores(key string, start, stop int64) ([]Z, error) {
+	result := []Z{}
+	err := f.view(func(tx *bbolt.Tx) error {
+		members, err := sortedZSet(tx, f.buildKey(key))
+		for _, member := range indexRange(members, start, stop) {
+			result = append(result, Z{Score: member.Score, Member: member.Member})
+		}
+		return err
 	})
 
 	return result, err
@@ -2309,12 +3075,57 @@ func (f *FileCache) ZRangeCtx(ctx context.Context, key string, start, stop int64
 }
 
 // ZRangeByScoreCtx 返回有序集合中指定分数区间内的成员（带上下文）
-func (f *FileCache) ZRangeByScoreCtx(ctx context.Context, key string, min, max float64) ([]string, error) {
+func (f *FileCache) ZRangeByScoreCtx(ctx context.Context, key string, opt ZRangeBy) ([]string, error) {
+	// 检查上下文是否已取消
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return f.ZRangeByScore(key, opt)
+}
+
+// ZRevRangeCtx 按分数从高到低返回指定区间内的成员（带上下文）
+func (f *FileCache) ZRevRangeCtx(ctx context.Context, key string, start, stop int64) ([]string, error) {
 	// 检查上下文是否已取消
 	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
-	return f.ZRangeByScore(key, min, max)
+	return f.ZRevRange(key, start, stop)
+}
+
+// ZIncrByCtx 增加成员的分数（带上下文）
+func (f *FileCache) ZIncrByCtx(ctx context.Context, key string, increment float64, member string) (float64, error) {
+	// 检查上下文是否已取消
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return f.ZIncrBy(key, increment, member)
+}
+
+// ZRankCtx 返回成员的排名（带上下文）
+func (f *FileCache) ZRankCtx(ctx context.Context, key, member string) (int64, error) {
+	// 检查上下文是否已取消
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return f.ZRank(key, member)
+}
+
+// ZRemRangeByScoreCtx 删除分数范围内的成员（带上下文）
+func (f *FileCache) ZRemRangeByScoreCtx(ctx context.Context, key, min, max string) (int64, error) {
+	// 检查上下文是否已取消
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return f.ZRemRangeByScore(key, min, max)
+}
+
+// ZCountCtx 统计分数范围内的成员数（带上下文）
+func (f *FileCache) ZCountCtx(ctx context.Context, key, min, max string) (int64, error) {
+	// 检查上下文是否已取消
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return f.ZCount(key, min, max)
 }
 
 // ZRangeWithScoresCtx 返回有序集合中指定区间内的成员和分数（带上下文）
@@ -2331,7 +3142,7 @@ func (f *FileCache) ZRangeWithScoresCtx(ctx context.Context, key string, start,
 // Ping 检查缓存连接是否正常
 func (f *FileCache) Ping() error {
 	// 对于文件缓存，只需检查数据库是否可以访问
-	return f.db.View(func(tx *bbolt.Tx) error {
+	return f.view(func(tx *bbolt.Tx) error {
 		// 尝试访问一个桶，如果可以访问则连接正常
 		bucket := tx.Bucket([]byte(defaultBucket))
 		if bucket == nil {
@@ -2362,7 +3173,7 @@ func (f *FileCache) BatchSet(items map[string]interface{}, expiration time.Durat
 	}
 
 	// 更新文件缓存
-	err := f.db.Update(func(tx *bbolt.Tx) error {
+	err := f.update(func(tx *bbolt.Tx) error {
 		bucket := tx.Bucket([]byte(defaultBucket))
 		if bucket == nil {
 			return fmt.Errorf("桶不存在")
@@ -2389,7 +3200,7 @@ func (f *FileCache) BatchSet(items map[string]interface{}, expiration time.Durat
 			}
 
 			// 压缩大型值
-			compressedData, err := compressValue(data)
+			compressedData, err := f.codec.encode(data)
 			if err != nil {
 				return err
 			}
@@ -2459,7 +3270,7 @@ func (f *FileCache) BatchGet(keys []string) (map[string]string, error) {
 	}
 
 	// 从文件缓存中查找缺失的键
-	err := f.db.View(func(tx *bbolt.Tx) error {
+	err := f.view(func(tx *bbolt.Tx) error {
 		bucket := tx.Bucket([]byte(defaultBucket))
 		if bucket == nil {
 			return fmt.Errorf("桶不存在")
@@ -2513,70 +3324,391 @@ func (f *FileCache) BatchGet(keys []string) (map[string]string, error) {
 	return result, err
 }
 
+// FileCompaction 压缩前后的数据库文件大小
+type FileCompaction struct {
+	Before  int64         `json:"before"`
+	After   int64         `json:"after"`
+	Elapsed time.Duration `json:"elapsed"`
+}
+
 // CompactDB 压缩数据库文件，回收空间
 func (f *FileCache) CompactDB() error {
-	// 获取数据库路径
-	dbPath := ""
-	err := f.db.View(func(tx *bbolt.Tx) error {
-		dbPath = tx.DB().Path()
-		return nil
-	})
+	_, err := f.Compact(context.Background())
+	return err
+}
+
+// Compact 在线压缩数据库文件：将数据复制到新文件后替换原文件，回收删除和过期的键留下的空间。
+// 压缩期间其他读写等待压缩完成，不需要停止服务
+func (f *FileCache) Compact(ctx context.Context) (FileCompaction, error) {
+	if err := ctx.Err(); err != nil {
+		return FileCompaction{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	start := time.Now()
+	dbPath := f.db.Path()
+	before, err := fileSize(dbPath)
 	if err != nil {
-		return err
+		return FileCompaction{}, err
 	}
 
-	// 创建临时文件
+	// 创建临时文件，清除上次中断留下的文件
 	tempFile := dbPath + ".compact"
-
-	// 创建目标数据库
-	dstDB, err := bbolt.Open(tempFile, 0600, &bbolt.Options{
-		Timeout: 3 * time.Second,
-	})
+	os.Remove(tempFile)
+	dstDB, err := bbolt.Open(tempFile, 0600, f.dbOptions)
 	if err != nil {
-		return fmt.Errorf("无法创建临时数据库: %w", err)
+		return FileCompaction{}, fmt.Errorf("无法创建临时数据库: %w", err)
+	}
+
+	// 复制所有数据，每个事务最多写入 64MB
+	if err := bbolt.Compact(dstDB, f.db, 64<<20); err != nil {
+		dstDB.Close()
+		os.Remove(tempFile)
+		return FileCompaction{}, fmt.Errorf("压缩数据库失败: %w", err)
+	}
+	if err := dstDB.Close(); err != nil {
+		os.Remove(tempFile)
+		return FileCompaction{}, fmt.Errorf("压缩数据库失败: %w", err)
 	}
-	defer dstDB.Close()
 
-	// 复制所有数据
-	err = bbolt.Compact(dstDB, f.db, 0)
+	// 关闭源数据库并替换文件，替换失败时重新打开原文件
+	if err := f.db.Close(); err != nil {
+		os.Remove(tempFile)
+		return FileCompaction{}, fmt.Errorf("关闭数据库失败: %w", err)
+	}
+	renameErr := os.Rename(tempFile, dbPath)
+	db, err := bbolt.Open(dbPath, 0600, f.dbOptions)
 	if err != nil {
+		return FileCompaction{}, fmt.Errorf("重新打开数据库失败: %w", err)
+	}
+	f.db = db
+	if renameErr != nil {
 		os.Remove(tempFile)
-		return fmt.Errorf("压缩数据库失败: %w", err)
+		return FileCompaction{}, fmt.Errorf("替换数据库文件失败: %w", renameErr)
 	}
 
-	// 关闭源数据库
-	f.db.Close()
+	after, err := fileSize(dbPath)
+	if err != nil {
+		return FileCompaction{}, err
+	}
+	f.counters.compactions.Add(1)
+	return FileCompaction{Before: before, After: after, Elapsed: time.Since(start)}, nil
+}
+
+// fileSize 返回文件大小
+func fileSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
 
-	// 替换文件
-	err = os.Rename(tempFile, dbPath)
+// usage 返回键数量和数据大小
+func (f *FileCache) usage(ctx context.Context) (Usage, error) {
+	if err := ctx.Err(); err != nil {
+		return Usage{}, err
+	}
+	stats, err := f.Stats()
 	if err != nil {
-		return fmt.Errorf("替换数据库文件失败: %w", err)
+		return Usage{}, err
+	}
+	return Usage{Backend: "file", Keys: stats.Keys, Bytes: stats.DataSize}, nil
+}
+
+// FileStats 文件缓存统计
+type FileStats struct {
+	FileSize    int64                      `json:"fileSize"`    // 数据库文件大小
+	DataSize    int64                      `json:"dataSize"`    // 数据占用的大小，不含空闲页面
+	Keys        int64                      `json:"keys"`        // 键数量，包括已过期但尚未清理的键
+	Expired     int64                      `json:"expired"`     // 后台清理的过期键数
+	Evictions   int64                      `json:"evictions"`   // 超出 MaxSize 被淘汰的键数
+	Compactions int64                      `json:"compactions"` // 压缩次数
+	Buckets     map[string]FileBucketStats `json:"buckets"`     // 各个桶的统计
+}
+
+// FileBucketStats 桶的统计
+type FileBucketStats struct {
+	Keys  int64 `json:"keys"`  // 桶中的条目数，哈希、列表、集合和有序集合为键的数量
+	Bytes int64 `json:"bytes"` // 桶及其子桶占用的页面字节数
+}
+
+// Stats 返回文件大小、键数量、各个桶的统计以及清理、淘汰和压缩的次数，需要遍历全部数据
+func (f *FileCache) Stats() (FileStats, error) {
+	stats := FileStats{
+		Expired:     f.counters.expired.Load(),
+		Evictions:   f.counters.evictions.Load(),
+		Compactions: f.counters.compactions.Load(),
+		Buckets:     make(map[string]FileBucketStats),
 	}
 
-	// 重新打开数据库
-	f.db, err = bbolt.Open(dbPath, 0600, &bbolt.Options{
-		Timeout:        3 * time.Second,
-		PageSize:       16 * 1024,
-		FreelistType:   bbolt.FreelistMapType,
-		NoFreelistSync: true,
+	err := f.view(func(tx *bbolt.Tx) error {
+		size, err := fileSize(f.db.Path())
+		if err != nil {
+			return err
+		}
+		stats.FileSize = size
+		stats.DataSize = tx.Size() - int64(f.db.Stats().FreeAlloc)
+
+		return tx.ForEach(func(name []byte, bucket *bbolt.Bucket) error {
+			var n int64
+			c := bucket.Cursor()
+			for k, v := c.First(); k != nil; k, v = c.Next() {
+				// 嵌套的数据类型以子桶存储，只统计子桶
+				if v == nil || string(name) == defaultBucket || string(name) == streamBucket || string(name) == expirationBucket {
+					n++
+				}
+			}
+			bs := bucket.Stats()
+			stats.Buckets[string(name)] = FileBucketStats{
+				Keys:  n,
+				Bytes: int64(bs.BranchInuse + bs.LeafInuse + bs.InlineBucketInuse),
+			}
+			if string(name) != expirationBucket && string(name) != zsetScoreBucket {
+				stats.Keys += n
+			}
+			return nil
+		})
 	})
+	return stats, err
+}
 
-	return err
+// ================== 阻塞列表操作 ==================
+
+// BLPop 阻塞弹出列表头部元素
+func (f *FileCache) BLPop(timeout time.Duration, keys ...string) ([]string, error) {
+	return f.BLPopCtx(context.Background(), timeout, keys...)
 }
 
-// 启动定期压缩任务
-func (f *FileCache) startCompactTask() {
-	go func() {
-		ticker := time.NewTicker(24 * time.Hour) // 每天压缩一次
-		defer ticker.Stop()
+// BRPop 阻塞弹出列表尾部元素
+func (f *FileCache) BRPop(timeout time.Duration, keys ...string) ([]string, error) {
+	return f.BRPopCtx(context.Background(), timeout, keys...)
+}
+
+// BLPopCtx 阻塞弹出列表头部元素，列表写入后唤醒（仅限本进程）
+func (f *FileCache) BLPopCtx(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error) {
+	return f.hub.blockingPop(ctx, timeout, keys, f.buildKey, f.LPopCtx)
+}
+
+// BRPopCtx 阻塞弹出列表尾部元素，列表写入后唤醒（仅限本进程）
+func (f *FileCache) BRPopCtx(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error) {
+	return f.hub.blockingPop(ctx, timeout, keys, f.buildKey, f.RPopCtx)
+}
+
+// ================== 发布订阅 ==================
+
+// Publish 发布消息
+func (f *FileCache) Publish(channel string, message interface{}) (int64, error) {
+	return f.PublishCtx(context.Background(), channel, message)
+}
+
+// Subscribe 订阅频道
+func (f *FileCache) Subscribe(channels ...string) (Subscription, error) {
+	return f.SubscribeCtx(context.Background(), channels...)
+}
+
+// PSubscribe 按通配符订阅频道
+func (f *FileCache) PSubscribe(patterns ...string) (Subscription, error) {
+	return f.PSubscribeCtx(context.Background(), patterns...)
+}
+
+// PublishCtx 发布消息，消息不写入文件，只投递给本进程的订阅
+func (f *FileCache) PublishCtx(ctx context.Context, channel string, message interface{}) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	payload, err := stringValue(message)
+	if err != nil {
+		return 0, err
+	}
+	return f.hub.publish(channel, payload), nil
+}
+
+// SubscribeCtx 订阅频道
+func (f *FileCache) SubscribeCtx(ctx context.Context, channels ...string) (Subscription, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return f.hub.subscribe(channels, nil), nil
+}
+
+// PSubscribeCtx 按通配符订阅频道
+func (f *FileCache) PSubscribeCtx(ctx context.Context, patterns ...string) (Subscription, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return f.hub.subscribe(nil, patterns), nil
+}
+
+// ================== 流操作 ==================
+
+// updateStream 在写事务中读取流，修改后整体写回
+func (f *FileCache) updateStream(key string, create bool, fn func(s *streamData) (bool, error)) error {
+	prefixedKey := []byte(f.buildKey(key))
+	return f.update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(streamBucket))
+		if bucket == nil {
+			return fmt.Errorf("流桶不存在")
+		}
+		if err := checkType(tx, string(prefixedKey), streamBucket); err != nil {
+			return err
+		}
 
-		for range ticker.C {
-			err := f.CompactDB()
+		var stream *streamData
+		if data := bucket.Get(prefixedKey); data != nil {
+			decompressedData, err := decompressValue(data)
 			if err != nil {
-				f.logger.Errorf("压缩数据库失败: %v", err)
-			} else {
-				f.logger.Info("数据库压缩成功")
+				return err
+			}
+			stream = &streamData{}
+			if err := json.Unmarshal(decompressedData, stream); err != nil {
+				return err
 			}
+		} else if create {
+			stream = &streamData{}
+		}
+
+		changed, err := fn(stream)
+		if err != nil || !changed {
+			return err
 		}
-	}()
+
+		data, err := json.Marshal(stream)
+		if err != nil {
+			return err
+		}
+		compressedData, err := f.codec.encode(data)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(prefixedKey, compressedData)
+	})
+}
+
+// XAdd 追加流消息
+func (f *FileCache) XAdd(stream string, values map[string]interface{}) (string, error) {
+	return f.XAddCtx(context.Background(), stream, values)
+}
+
+// XLen 获取流的消息数量
+func (f *FileCache) XLen(stream string) (int64, error) {
+	return f.XLenCtx(context.Background(), stream)
+}
+
+// XRead 读取流
+func (f *FileCache) XRead(args XReadArgs) ([]XStream, error) {
+	return f.XReadCtx(context.Background(), args)
+}
+
+// XGroupCreate 创建消费组
+func (f *FileCache) XGroupCreate(stream, group, start string) error {
+	return f.XGroupCreateCtx(context.Background(), stream, group, start)
+}
+
+// XReadGroup 按消费组读取流
+func (f *FileCache) XReadGroup(args XReadGroupArgs) ([]XStream, error) {
+	return f.XReadGroupCtx(context.Background(), args)
+}
+
+// XAck 确认流消息
+func (f *FileCache) XAck(stream, group string, ids ...string) (int64, error) {
+	return f.XAckCtx(context.Background(), stream, group, ids...)
+}
+
+// XAddCtx 追加流消息
+func (f *FileCache) XAddCtx(ctx context.Context, stream string, values map[string]interface{}) (string, error) {
+	return f.streams.add(ctx, stream, values)
+}
+
+// XLenCtx 获取流的消息数量
+func (f *FileCache) XLenCtx(ctx context.Context, stream string) (int64, error) {
+	return f.streams.len(ctx, stream)
+}
+
+// XReadCtx 读取流
+func (f *FileCache) XReadCtx(ctx context.Context, args XReadArgs) ([]XStream, error) {
+	return f.streams.read(ctx, args)
+}
+
+// XGroupCreateCtx 创建消费组
+func (f *FileCache) XGroupCreateCtx(ctx context.Context, stream, group, start string) error {
+	return f.streams.createGroup(ctx, stream, group, start)
+}
+
+// XReadGroupCtx 按消费组读取流
+func (f *FileCache) XReadGroupCtx(ctx context.Context, args XReadGroupArgs) ([]XStream, error) {
+	return f.streams.readGroup(ctx, args)
+}
+
+// XAckCtx 确认流消息
+func (f *FileCache) XAckCtx(ctx context.Context, stream, group string, ids ...string) (int64, error) {
+	return f.streams.ack(ctx, stream, group, ids)
+}
+
+// ================== 管道 ==================
+
+// fileTx 在 bbolt 事务中执行命令，管道的所有命令共用一个写事务
+type fileTx struct {
+	f  *FileCache
+	tx *bbolt.Tx
+}
+
+// Pipeline 创建命令队列，队列中的命令在同一个写事务中执行
+func (f *FileCache) Pipeline() Pipeliner {
+	return &localPipeline{exec: f.execPipeline}
+}
+
+// TxPipeline 创建事务命令队列，文件缓存的 Pipeline 本身就是原子的，两者相同
+func (f *FileCache) TxPipeline() Pipeliner {
+	return f.Pipeline()
+}
+
+// execPipeline 在一个写事务中执行命令，提交后删除内存缓存层中的键并唤醒阻塞弹出
+func (f *FileCache) execPipeline(keys []string, run func(t pipeTx)) error {
+	err := f.update(func(tx *bbolt.Tx) error {
+		run(fileTx{f: f, tx: tx})
+		return nil
+	})
+	for _, key := range keys {
+		prefixedKey := f.buildKey(key)
+		f.memCache.Delete(prefixedKey)
+		if err == nil {
+			f.hub.signal(prefixedKey)
+		}
+	}
+	return err
+}
+
+func (t fileTx) get(key string) (string, error) {
+	prefixedKey := t.f.buildKey(key)
+	item, err := readItem(t.tx.Bucket([]byte(defaultBucket)), prefixedKey)
+	if err != nil {
+		return "", err
+	}
+	if item == nil {
+		if err := checkType(t.tx, prefixedKey, defaultBucket); err != nil {
+			return "", err
+		}
+		return "", ErrKeyNotFound
+	}
+	return itemValue(item)
+}
+
+func (t fileTx) set(key string, value interface{}, expiration time.Duration) error {
+	item, err := newCacheItem(value, expiration)
+	if err != nil {
+		return err
+	}
+	return t.setItem(t.f.buildKey(key), item)
+}
+
+func (t fileTx) del(key string) (bool, error) {
+	return dropKey(t.tx, t.f.buildKey(key))
+}
+
+func (t fileTx) exists(key string) (bool, error) {
+	current, err := keyType(t.tx, t.f.buildKey(key))
+	return current != "", err
 }
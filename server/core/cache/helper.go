@@ -3,12 +3,16 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/zhoudm1743/go-web/core/log"
 )
 
+// flushBatchSize FlushByPattern 每批遍历的键数
+const flushBatchSize = 100
+
 // CacheHelper 缓存助手类，提供更高级的封装
 type CacheHelper struct {
 	cache  Cache
@@ -33,6 +37,13 @@ func (h *CacheHelper) buildKey(key string) string {
 	return fmt.Sprintf("%s:%s", h.prefix, key)
 }
 
+// warn 记录缓存读写失败，未设置日志时忽略
+func (h *CacheHelper) warn(key string, err error, msg string) {
+	if h.logger != nil {
+		h.logger.WithFields(map[string]interface{}{"key": key, "error": err}).Warn(msg)
+	}
+}
+
 // ================== 默认方法（不带 Context） ==================
 
 // SetJSON 存储 JSON 对象
@@ -56,13 +67,13 @@ func (h *CacheHelper) RememberJSON(key string, expiration time.Duration, dest in
 }
 
 // Lock 分布式锁
-func (h *CacheHelper) Lock(key string, expiration time.Duration) (bool, error) {
+func (h *CacheHelper) Lock(key string, expiration time.Duration) (*Lock, error) {
 	return h.LockCtx(context.Background(), key, expiration)
 }
 
 // Unlock 释放分布式锁
-func (h *CacheHelper) Unlock(key string) error {
-	return h.UnlockCtx(context.Background(), key)
+func (h *CacheHelper) Unlock(lock *Lock) error {
+	return h.UnlockCtx(context.Background(), lock)
 }
 
 // WithLock 使用分布式锁执行函数
@@ -111,6 +122,8 @@ func (h *CacheHelper) GetJSONCtx(ctx context.Context, key string, dest interface
 }
 
 // RememberCtx 记忆模式：如果缓存不存在则执行函数并缓存结果
+//
+// 结果按 Redis 驱动的规则转换为字符串存储，命中时返回字符串；需要保持类型时使用 Remember
 func (h *CacheHelper) RememberCtx(ctx context.Context, key string, expiration time.Duration, fn func() (interface{}, error)) (interface{}, error) {
 	fullKey := h.buildKey(key)
 
@@ -120,21 +133,27 @@ func (h *CacheHelper) RememberCtx(ctx context.Context, key string, expiration ti
 		return data, nil
 	}
 
-	// 缓存不存在，执行函数
-	result, err := fn()
-	if err != nil {
-		return nil, err
-	}
-
-	// 缓存结果
-	if err := h.cache.SetCtx(ctx, fullKey, result, expiration); err != nil {
-		h.logger.WithFields(map[string]interface{}{
-			"key":   fullKey,
-			"error": err,
-		}).Error("缓存设置失败")
-	}
+	// 缓存不存在，同一进程内的并发未命中只执行一次函数
+	result, err := doFlight(ctx, flightKey(h.cache, "string", fullKey), func() (interface{}, error) {
+		result, err := fn()
+		if err != nil {
+			return nil, err
+		}
 
-	return result, nil
+		// 缓存结果
+		value, err := stringValue(result)
+		if err == nil {
+			err = h.cache.SetCtx(context.WithoutCancel(ctx), fullKey, value, expiration)
+		}
+		if err != nil {
+			h.logger.WithFields(map[string]interface{}{
+				"key":   fullKey,
+				"error": err,
+			}).Error("缓存设置失败")
+		}
+		return result, nil
+	})
+	return result, err
 }
 
 // RememberJSONCtx 记忆模式的 JSON 版本
@@ -146,63 +165,68 @@ func (h *CacheHelper) RememberJSONCtx(ctx context.Context, key string, expiratio
 		return nil
 	}
 
-	// 缓存不存在，执行函数
-	result, err := fn()
+	// 缓存不存在，同一进程内的并发未命中只执行一次函数
+	data, err := doFlight(ctx, flightKey(h.cache, "json", fullKey), func() (interface{}, error) {
+		result, err := fn()
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+
+		// 缓存结果
+		if err := h.cache.SetCtx(context.WithoutCancel(ctx), fullKey, string(data), expiration); err != nil {
+			h.logger.WithFields(map[string]interface{}{
+				"key":   fullKey,
+				"error": err,
+			}).Warn("缓存设置失败")
+		}
+		return data, nil
+	})
 	if err != nil {
 		return err
 	}
 
-	// 缓存结果
-	if err := h.SetJSONCtx(ctx, key, result, expiration); err != nil {
-		h.logger.WithFields(map[string]interface{}{
-			"key":   fullKey,
-			"error": err,
-		}).Warn("缓存设置失败")
-	}
-
 	// 将结果复制到目标对象
-	data, err := json.Marshal(result)
-	if err != nil {
-		return err
-	}
-	return json.Unmarshal(data, dest)
+	return json.Unmarshal(data.([]byte), dest)
 }
 
-// LockCtx 分布式锁
-func (h *CacheHelper) LockCtx(ctx context.Context, key string, expiration time.Duration) (bool, error) {
-	fullKey := fmt.Sprintf("lock:%s", h.buildKey(key))
-
-	// 使用 SET NX EX 实现分布式锁
-	// 检查键是否存在
-	exists, err := h.cache.ExistsCtx(ctx, fullKey)
-	if err != nil {
-		return false, err
-	}
-
-	if exists > 0 {
-		return false, nil
+// NewLock 创建分布式锁，键名为 lock:<前缀>:<key>
+func (h *CacheHelper) NewLock(key string, opts LockOptions) *Lock {
+	if opts.Logger == nil {
+		opts.Logger = h.logger
 	}
+	return NewLock(h.cache, fmt.Sprintf("lock:%s", h.buildKey(key)), opts)
+}
 
-	// 设置锁
-	err = h.cache.SetCtx(ctx, fullKey, "locked", expiration)
-	if err != nil {
-		return false, err
+// LockCtx 分布式锁，基于 SETNX 原子获取，不自动续期，expiration 为 0 时租期为 30 秒
+//
+// 锁被占用时返回 nil。返回的锁带有持有者令牌，通过 UnlockCtx 或 Lock.Release 释放，
+// 与使用哪个助手无关
+func (h *CacheHelper) LockCtx(ctx context.Context, key string, expiration time.Duration) (*Lock, error) {
+	lock := h.NewLock(key, LockOptions{TTL: expiration, RenewInterval: -1})
+	ok, err := lock.TryAcquire(ctx)
+	if err != nil || !ok {
+		return nil, err
 	}
-
-	return true, nil
+	return lock, nil
 }
 
-// UnlockCtx 释放分布式锁
-func (h *CacheHelper) UnlockCtx(ctx context.Context, key string) error {
-	fullKey := fmt.Sprintf("lock:%s", h.buildKey(key))
-	_, err := h.cache.DelCtx(ctx, fullKey)
-	return err
+// UnlockCtx 释放 LockCtx 获取的锁，lock 为 nil、已过期或已被其他持有者获取时返回 ErrLockNotHeld
+func (h *CacheHelper) UnlockCtx(ctx context.Context, lock *Lock) error {
+	if lock == nil {
+		return ErrLockNotHeld
+	}
+	return lock.Release(ctx)
 }
 
-// WithLockCtx 使用分布式锁执行函数
+// WithLockCtx 使用分布式锁执行函数，执行期间看门狗自动续期
 func (h *CacheHelper) WithLockCtx(ctx context.Context, key string, expiration time.Duration, fn func() error) error {
 	// 获取锁
-	locked, err := h.LockCtx(ctx, key, expiration)
+	lock := h.NewLock(key, LockOptions{TTL: expiration})
+	locked, err := lock.TryAcquire(ctx)
 	if err != nil {
 		return fmt.Errorf("获取锁失败: %w", err)
 	}
@@ -212,7 +236,7 @@ func (h *CacheHelper) WithLockCtx(ctx context.Context, key string, expiration ti
 
 	// 确保释放锁
 	defer func() {
-		if err := h.UnlockCtx(ctx, key); err != nil {
+		if err := lock.Release(context.WithoutCancel(ctx)); err != nil {
 			h.logger.WithFields(map[string]interface{}{
 				"key":   key,
 				"error": err,
@@ -224,7 +248,7 @@ func (h *CacheHelper) WithLockCtx(ctx context.Context, key string, expiration ti
 	return fn()
 }
 
-// BatchGetCtx 批量获取
+// BatchGetCtx 批量获取，使用一次 MGet 读取，结果只包含存在的键
 func (h *CacheHelper) BatchGetCtx(ctx context.Context, keys []string) (map[string]string, error) {
 	if len(keys) == 0 {
 		return make(map[string]string), nil
@@ -237,49 +261,60 @@ func (h *CacheHelper) BatchGetCtx(ctx context.Context, keys []string) (map[strin
 	}
 
 	// 批量获取
-	result := make(map[string]string)
-	for i, fullKey := range fullKeys {
-		val, err := h.cache.GetCtx(ctx, fullKey)
-		if err == nil {
-			result[keys[i]] = val
+	values, err := h.cache.MGetCtx(ctx, fullKeys...)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]string, len(keys))
+	for i, value := range values {
+		if str, ok := value.(string); ok {
+			result[keys[i]] = str
 		}
 	}
 
 	return result, nil
 }
 
-// BatchSetCtx 批量设置
+// BatchSetCtx 批量设置，所有键和过期时间在一个事务中写入，其他客户端不会看到部分写入的结果
 func (h *CacheHelper) BatchSetCtx(ctx context.Context, data map[string]interface{}, expiration time.Duration) error {
 	if len(data) == 0 {
 		return nil
 	}
 
-	// 批量设置
+	pipe := h.cache.TxPipeline()
 	for key, value := range data {
-		fullKey := h.buildKey(key)
-		if err := h.cache.SetCtx(ctx, fullKey, value, expiration); err != nil {
-			return err
-		}
+		pipe.Set(h.buildKey(key), value, expiration)
 	}
-
-	return nil
+	_, err := pipe.Exec(ctx)
+	return err
 }
 
-// FlushByPatternCtx 根据模式删除键
+// FlushByPatternCtx 根据模式删除键，使用 Scan 分批遍历，不会像 Keys 一样阻塞 Redis
 func (h *CacheHelper) FlushByPatternCtx(ctx context.Context, pattern string) (int64, error) {
-	// 先获取匹配的键
 	fullPattern := h.buildKey(pattern)
-	keys, err := h.cache.KeysCtx(ctx, fullPattern)
-	if err != nil {
-		return 0, err
-	}
 
-	if len(keys) == 0 {
-		return 0, nil
-	}
+	var cursor uint64
+	var deleted int64
+	for {
+		keys, next, err := h.cache.ScanCtx(ctx, cursor, fullPattern, flushBatchSize)
+		if err != nil {
+			return deleted, err
+		}
+
+		// 删除本批匹配的键
+		if len(keys) > 0 {
+			n, err := h.cache.DelCtx(ctx, keys...)
+			deleted += n
+			if err != nil {
+				return deleted, err
+			}
+		}
 
-	// 删除匹配的键
-	return h.cache.DelCtx(ctx, keys...)
+		if next == 0 {
+			return deleted, nil
+		}
+		cursor = next
+	}
 }
 
 // GetOrSetCtx 获取或设置：如果键不存在则设置默认值
@@ -293,7 +328,7 @@ func (h *CacheHelper) GetOrSetCtx(ctx context.Context, key string, defaultValue
 	}
 
 	// 键不存在，设置默认值
-	if err != ErrKeyNotFound {
+	if !errors.Is(err, ErrKeyNotFound) {
 		return "", err
 	}
 
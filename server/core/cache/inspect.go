@@ -0,0 +1,187 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// inspectValueLimit InspectKey 默认返回的最大元素数
+const inspectValueLimit = 100
+
+// KeyInfo 键的类型和剩余过期时间
+type KeyInfo struct {
+	Key  string        `json:"key"`
+	Type string        `json:"type"`
+	TTL  time.Duration `json:"ttl"` // -1 表示未设置过期时间，-2 表示键已不存在
+}
+
+// KeyPage ScanKeys 返回的一批键
+type KeyPage struct {
+	Keys   []KeyInfo `json:"keys"`
+	Cursor uint64    `json:"cursor"` // 下一批的游标，为 0 时遍历结束
+}
+
+// KeyValue 键的值，Value 的类型取决于键的类型：
+// string 为 string，hash 为 map[string]string，list 和 set 为 []string，zset 为 []Z，stream 为 []XMessage
+type KeyValue struct {
+	KeyInfo
+	Size      int64       `json:"size"` // 元素数量，string 为字节数
+	Value     interface{} `json:"value"`
+	Truncated bool        `json:"truncated"` // 元素超过上限，Value 只包含前一部分
+}
+
+// EscapePattern 转义通配符中的特殊字符，使 s 按字面匹配
+func EscapePattern(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// ScanKeys 遍历以 prefix 开头的一批键及其类型和过期时间，cursor 为 0 时开始
+//
+// 遍历与写入并发时，键可能在返回前被删除，此时 Type 为 none、TTL 为 -2
+func ScanKeys(ctx context.Context, c Cache, prefix string, cursor uint64, count int64) (KeyPage, error) {
+	keys, next, err := c.ScanCtx(ctx, cursor, EscapePattern(prefix)+"*", count)
+	if err != nil {
+		return KeyPage{}, err
+	}
+
+	page := KeyPage{Keys: make([]KeyInfo, 0, len(keys)), Cursor: next}
+	for _, key := range keys {
+		info, err := keyInfo(ctx, c, key)
+		if err != nil {
+			return KeyPage{}, err
+		}
+		page.Keys = append(page.Keys, info)
+	}
+	return page, nil
+}
+
+// keyInfo 读取键的类型和过期时间
+func keyInfo(ctx context.Context, c Cache, key string) (KeyInfo, error) {
+	typ, err := c.TypeCtx(ctx, key)
+	if err != nil {
+		return KeyInfo{}, fmt.Errorf("读取 %s 的类型失败: %w", key, err)
+	}
+	ttl, err := c.TTLCtx(ctx, key)
+	if err != nil {
+		return KeyInfo{}, fmt.Errorf("读取 %s 的过期时间失败: %w", key, err)
+	}
+	return KeyInfo{Key: key, Type: typ, TTL: ttl}, nil
+}
+
+// InspectKey 读取键的值，集合类型最多返回 limit 个元素，limit 不大于 0 时为 100；
+// 键不存在时返回 ErrKeyNotFound
+func InspectKey(ctx context.Context, c Cache, key string, limit int64) (KeyValue, error) {
+	if limit <= 0 {
+		limit = inspectValueLimit
+	}
+
+	info, err := keyInfo(ctx, c, key)
+	if err != nil {
+		return KeyValue{}, err
+	}
+	kv := KeyValue{KeyInfo: info}
+
+	switch info.Type {
+	case "none":
+		return kv, ErrKeyNotFound
+	case "string":
+		value, err := c.GetCtx(ctx, key)
+		if err != nil {
+			return kv, err
+		}
+		kv.Size, kv.Value = int64(len(value)), value
+	case "hash":
+		kv.Size, kv.Value, err = inspectHash(ctx, c, key, limit)
+	case "list":
+		kv.Size, kv.Value, err = inspectRange(ctx, key, limit, c.LLenCtx, c.LRangeCtx)
+	case "set":
+		kv.Size, kv.Value, err = inspectSet(ctx, c, key, limit)
+	case "zset":
+		kv.Size, kv.Value, err = inspectRange(ctx, key, limit, c.ZCardCtx, c.ZRangeWithScoresCtx)
+	case "stream":
+		kv.Size, kv.Value, err = inspectStream(ctx, c, key, limit)
+	default:
+		return kv, fmt.Errorf("不支持的键类型: %s", info.Type)
+	}
+	if err != nil {
+		return kv, err
+	}
+	kv.Truncated = kv.Size > limit
+	return kv, nil
+}
+
+// inspectRange 读取列表或有序集合的长度和前 limit 个元素
+func inspectRange[T any](ctx context.Context, key string, limit int64,
+	length func(context.Context, string) (int64, error),
+	rangeFn func(context.Context, string, int64, int64) ([]T, error)) (int64, []T, error) {
+	size, err := length(ctx, key)
+	if err != nil {
+		return 0, nil, err
+	}
+	values, err := rangeFn(ctx, key, 0, limit-1)
+	return size, values, err
+}
+
+// inspectHash 读取哈希的前 limit 个字段，字段按名称排序
+func inspectHash(ctx context.Context, c Cache, key string, limit int64) (int64, map[string]string, error) {
+	all, err := c.HGetAllCtx(ctx, key)
+	if err != nil {
+		return 0, nil, err
+	}
+	if int64(len(all)) <= limit {
+		return int64(len(all)), all, nil
+	}
+
+	fields := make([]string, 0, len(all))
+	for field := range all {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	values := make(map[string]string, limit)
+	for _, field := range fields[:limit] {
+		values[field] = all[field]
+	}
+	return int64(len(all)), values, nil
+}
+
+// inspectSet 读取集合的前 limit 个成员，成员按字典序排序
+func inspectSet(ctx context.Context, c Cache, key string, limit int64) (int64, []string, error) {
+	members, err := c.SMembersCtx(ctx, key)
+	if err != nil {
+		return 0, nil, err
+	}
+	sort.Strings(members)
+	size := int64(len(members))
+	if size > limit {
+		members = members[:limit]
+	}
+	return size, members, nil
+}
+
+// inspectStream 读取流最早的 limit 条消息
+func inspectStream(ctx context.Context, c Cache, key string, limit int64) (int64, []XMessage, error) {
+	size, err := c.XLenCtx(ctx, key)
+	if err != nil {
+		return 0, nil, err
+	}
+	streams, err := c.XReadCtx(ctx, XReadArgs{Streams: map[string]string{key: "0"}, Count: limit})
+	if err != nil {
+		return 0, nil, err
+	}
+	messages := []XMessage{}
+	for _, s := range streams {
+		messages = append(messages, s.Messages...)
+	}
+	return size, messages, nil
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"time"
+
+	"github.com/zhoudm1743/go-web/core/conf"
+	"github.com/zhoudm1743/go-web/core/log"
 )
 
 // 错误定义
@@ -20,6 +23,34 @@ type Z struct {
 	Member interface{}
 }
 
+// ZRangeBy 按分数查询有序集合的条件
+type ZRangeBy struct {
+	// Min、Max 分数范围，支持 -inf 和 +inf，前缀 ( 表示不包含边界，如 "(10"
+	Min, Max string
+	// Offset、Count 跳过的成员数和返回的最大数量，Count 不大于 0 时不限制
+	Offset, Count int64
+}
+
+// New 根据配置创建缓存实例，默认使用内存缓存；开启 Metrics 时返回 *InstrumentedCache
+func New(cfg *conf.Config, logger log.Logger) (Cache, error) {
+	var c Cache
+	var err error
+	switch cfg.Cache.Type {
+	case "redis":
+		c, err = NewRedisCache(cfg, logger)
+	case "file":
+		c, err = NewFileCache(cfg, logger)
+	case "tiered":
+		c, err = NewTieredCache(cfg, logger)
+	default:
+		c, err = NewMemoryCache(cfg, logger)
+	}
+	if err != nil || !cfg.Cache.Metrics {
+		return c, err
+	}
+	return Instrument(c), nil
+}
+
 // Cache 缓存接口
 type Cache interface {
 	// 默认方法（不带 Context，使用 unified.Background()）
@@ -30,6 +61,14 @@ type Cache interface {
 	Exists(keys ...string) (int64, error)
 	Expire(key string, expiration time.Duration) error
 	TTL(key string) (time.Duration, error)
+	SetNX(key string, value interface{}, expiration time.Duration) (bool, error)
+	CompareAndDel(key, value string) (bool, error)
+	CompareAndExpire(key, value string, expiration time.Duration) (bool, error)
+	GetSet(key string, value interface{}) (string, error)
+	MGet(keys ...string) ([]interface{}, error)
+	MSet(values ...interface{}) error
+	Persist(key string) (bool, error)
+	Rename(key, newKey string) error
 
 	// 字符串操作
 	Incr(key string) (int64, error)
@@ -66,9 +105,34 @@ type Cache interface {
 	ZRangeWithScores(key string, start, stop int64) ([]Z, error)
 	ZCard(key string) (int64, error)
 	ZScore(key, member string) (float64, error)
+	ZRangeByScore(key string, opt ZRangeBy) ([]string, error)
+	ZRevRange(key string, start, stop int64) ([]string, error)
+	ZIncrBy(key string, increment float64, member string) (float64, error)
+	ZRank(key, member string) (int64, error)
+	ZRemRangeByScore(key, min, max string) (int64, error)
+	ZCount(key, min, max string) (int64, error)
+
+	// 阻塞列表操作，返回 [键, 值]，超时返回 ErrKeyNotFound
+	BLPop(timeout time.Duration, keys ...string) ([]string, error)
+	BRPop(timeout time.Duration, keys ...string) ([]string, error)
+
+	// 发布订阅
+	Publish(channel string, message interface{}) (int64, error)
+	Subscribe(channels ...string) (Subscription, error)
+	PSubscribe(patterns ...string) (Subscription, error)
+
+	// 流操作
+	XAdd(stream string, values map[string]interface{}) (string, error)
+	XLen(stream string) (int64, error)
+	XRead(args XReadArgs) ([]XStream, error)
+	XGroupCreate(stream, group, start string) error
+	XReadGroup(args XReadGroupArgs) ([]XStream, error)
+	XAck(stream, group string, ids ...string) (int64, error)
 
 	// 其他操作
 	Keys(pattern string) ([]string, error)
+	Scan(cursor uint64, match string, count int64) ([]string, uint64, error)
+	Type(key string) (string, error)
 	Ping() error
 
 	// 带 Context 的方法（精细控制）
@@ -79,6 +143,22 @@ type Cache interface {
 	ExistsCtx(ctx context.Context, keys ...string) (int64, error)
 	ExpireCtx(ctx context.Context, key string, expiration time.Duration) error
 	TTLCtx(ctx context.Context, key string) (time.Duration, error)
+	// SetNXCtx 键不存在时设置，返回是否设置成功
+	SetNXCtx(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error)
+	// CompareAndDelCtx 值等于 value 时删除，返回是否删除
+	CompareAndDelCtx(ctx context.Context, key, value string) (bool, error)
+	// CompareAndExpireCtx 值等于 value 时设置过期时间，返回是否设置成功
+	CompareAndExpireCtx(ctx context.Context, key, value string, expiration time.Duration) (bool, error)
+	// GetSetCtx 设置新值并返回旧值，键不存在时仍然写入并返回 ErrKeyNotFound
+	GetSetCtx(ctx context.Context, key string, value interface{}) (string, error)
+	// MGetCtx 批量读取，结果与 keys 一一对应，不存在或不是字符串的键为 nil，其余为 string
+	MGetCtx(ctx context.Context, keys ...string) ([]interface{}, error)
+	// MSetCtx 批量写入，参数为键值交替的列表或单个 map[string]interface{}，写入的键不过期
+	MSetCtx(ctx context.Context, values ...interface{}) error
+	// PersistCtx 移除过期时间，返回键是否存在且原本设置了过期时间
+	PersistCtx(ctx context.Context, key string) (bool, error)
+	// RenameCtx 重命名键，保留过期时间并覆盖 newKey，键不存在时返回 ErrKeyNotFound
+	RenameCtx(ctx context.Context, key, newKey string) error
 
 	// 字符串操作
 	IncrCtx(ctx context.Context, key string) (int64, error)
@@ -115,11 +195,61 @@ type Cache interface {
 	ZRangeWithScoresCtx(ctx context.Context, key string, start, stop int64) ([]Z, error)
 	ZCardCtx(ctx context.Context, key string) (int64, error)
 	ZScoreCtx(ctx context.Context, key, member string) (float64, error)
+	// ZRangeByScoreCtx 按分数从低到高返回范围内的成员
+	ZRangeByScoreCtx(ctx context.Context, key string, opt ZRangeBy) ([]string, error)
+	// ZRevRangeCtx 按分数从高到低返回排名在 start 到 stop 之间的成员
+	ZRevRangeCtx(ctx context.Context, key string, start, stop int64) ([]string, error)
+	// ZIncrByCtx 增加成员的分数，成员不存在时添加，返回新分数
+	ZIncrByCtx(ctx context.Context, key string, increment float64, member string) (float64, error)
+	// ZRankCtx 返回成员按分数从低到高的排名（从 0 开始），成员不存在时返回 ErrKeyNotFound
+	ZRankCtx(ctx context.Context, key, member string) (int64, error)
+	// ZRemRangeByScoreCtx 删除分数范围内的成员，范围格式同 ZRangeBy，返回删除数量
+	ZRemRangeByScoreCtx(ctx context.Context, key, min, max string) (int64, error)
+	// ZCountCtx 统计分数范围内的成员数
+	ZCountCtx(ctx context.Context, key, min, max string) (int64, error)
+
+	// 阻塞列表操作
+	// BLPopCtx 依次检查 keys，从第一个非空列表的头部弹出元素；全部为空时等待，
+	// timeout 为 0 时等待到 ctx 结束，Redis 驱动的精度为秒
+	BLPopCtx(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error)
+	// BRPopCtx 同 BLPopCtx，从尾部弹出
+	BRPopCtx(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error)
+
+	// 发布订阅，频道名与键一样追加缓存前缀
+	// PublishCtx 发布消息，返回接收的订阅数
+	PublishCtx(ctx context.Context, channel string, message interface{}) (int64, error)
+	// SubscribeCtx 订阅频道，返回前订阅已生效
+	SubscribeCtx(ctx context.Context, channels ...string) (Subscription, error)
+	// PSubscribeCtx 按通配符订阅频道
+	PSubscribeCtx(ctx context.Context, patterns ...string) (Subscription, error)
+
+	// 流操作
+	// XAddCtx 追加消息，返回自动生成的 ID
+	XAddCtx(ctx context.Context, stream string, values map[string]interface{}) (string, error)
+	XLenCtx(ctx context.Context, stream string) (int64, error)
+	// XReadCtx 读取一个或多个流，超时没有消息时返回空结果
+	XReadCtx(ctx context.Context, args XReadArgs) ([]XStream, error)
+	// XGroupCreateCtx 创建消费组，流不存在时一并创建，消费组已存在时不做修改；
+	// start 为 "$" 时只消费之后添加的消息，"0" 时从头消费
+	XGroupCreateCtx(ctx context.Context, stream, group, start string) error
+	// XReadGroupCtx 按消费组读取，流或消费组不存在时返回 ErrNoGroup
+	XReadGroupCtx(ctx context.Context, args XReadGroupArgs) ([]XStream, error)
+	// XAckCtx 确认消息，返回确认数量
+	XAckCtx(ctx context.Context, stream, group string, ids ...string) (int64, error)
 
 	// 其他操作
 	KeysCtx(ctx context.Context, pattern string) ([]string, error)
+	// ScanCtx 按游标分批遍历匹配的键，cursor 为 0 时开始，返回的游标为 0 时结束；
+	// 遍历期间一直存在的键至少返回一次，count 是每批数量的建议值
+	ScanCtx(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error)
+	// TypeCtx 返回键的类型：string、hash、list、set、zset 或 stream，键不存在时返回 none
+	TypeCtx(ctx context.Context, key string) (string, error)
 	PingCtx(ctx context.Context) error
 
+	// 管道和事务，命令加入队列后由 Exec 一次性执行，见 Pipeliner
+	Pipeline() Pipeliner
+	TxPipeline() Pipeliner
+
 	// 工具方法
 	Close() error
 	GetClient() interface{}
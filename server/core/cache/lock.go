@@ -0,0 +1,188 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zhoudm1743/go-web/core/log"
+)
+
+// ErrLockNotHeld 锁已过期或已被其他持有者获取
+var ErrLockNotHeld = errors.New("未持有锁")
+
+// LockOptions 分布式锁选项
+type LockOptions struct {
+	TTL time.Duration // 锁的租期，默认 30 秒
+	// RenewInterval 看门狗续期间隔，默认为租期的 1/3，负数表示不自动续期
+	RenewInterval time.Duration
+	// RetryMin、RetryMax 阻塞获取时的退避区间，默认 10 毫秒到 500 毫秒
+	RetryMin time.Duration
+	RetryMax time.Duration
+	Logger   log.Logger // 记录续期失败，可为空
+}
+
+// Lock 基于缓存的分布式锁
+//
+// 获取锁时写入随机的持有者令牌，释放和续期时比较令牌，不会误释放其他持有者的锁。
+// 持有期间看门狗按 RenewInterval 续期，续期发现锁已丢失时关闭 Lost 返回的通道。
+type Lock struct {
+	cache Cache
+	key   string
+	token string
+	opts  LockOptions
+
+	mu   sync.Mutex
+	stop chan struct{} // 通知看门狗退出
+	done chan struct{} // 看门狗已退出
+	lost chan struct{} // 锁已丢失
+}
+
+// NewLock 创建分布式锁，key 为缓存中的完整键名
+func NewLock(c Cache, key string, opts LockOptions) *Lock {
+	if opts.TTL <= 0 {
+		opts.TTL = 30 * time.Second
+	}
+	if opts.RenewInterval == 0 {
+		opts.RenewInterval = opts.TTL / 3
+	}
+	if opts.RetryMin <= 0 {
+		opts.RetryMin = 10 * time.Millisecond
+	}
+	if opts.RetryMax < opts.RetryMin {
+		opts.RetryMax = max(500*time.Millisecond, opts.RetryMin)
+	}
+	return &Lock{cache: c, key: key, token: uuid.NewString(), opts: opts}
+}
+
+// Key 锁的键名
+func (l *Lock) Key() string {
+	return l.key
+}
+
+// Token 持有者令牌
+func (l *Lock) Token() string {
+	return l.token
+}
+
+// TryAcquire 尝试获取锁，锁已被占用时立即返回 false
+func (l *Lock) TryAcquire(ctx context.Context) (bool, error) {
+	ok, err := l.cache.SetNXCtx(ctx, l.key, l.token, l.opts.TTL)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lost = make(chan struct{})
+	if l.opts.RenewInterval > 0 {
+		l.stop, l.done = make(chan struct{}), make(chan struct{})
+		go l.watch(l.stop, l.done, l.lost)
+	}
+	return true, nil
+}
+
+// Acquire 阻塞获取锁，按指数退避重试，直到获取成功或 ctx 结束
+func (l *Lock) Acquire(ctx context.Context) error {
+	backoff := l.opts.RetryMin
+	for {
+		ok, err := l.TryAcquire(ctx)
+		if err != nil {
+			return fmt.Errorf("获取锁 %s 失败: %w", l.key, err)
+		}
+		if ok {
+			return nil
+		}
+
+		// 随机抖动，避免等待者同时重试
+		wait := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("获取锁 %s 失败: %w", l.key, ctx.Err())
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, l.opts.RetryMax)
+	}
+}
+
+// Refresh 手动续期
+func (l *Lock) Refresh(ctx context.Context) error {
+	ok, err := l.cache.CompareAndExpireCtx(ctx, l.key, l.token, l.opts.TTL)
+	if err != nil {
+		return fmt.Errorf("续期锁 %s 失败: %w", l.key, err)
+	}
+	if !ok {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// Release 释放锁，锁已过期或被其他持有者获取时返回 ErrLockNotHeld
+func (l *Lock) Release(ctx context.Context) error {
+	l.stopWatch()
+
+	ok, err := l.cache.CompareAndDelCtx(ctx, l.key, l.token)
+	if err != nil {
+		return fmt.Errorf("释放锁 %s 失败: %w", l.key, err)
+	}
+	if !ok {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// Lost 返回锁丢失时关闭的通道，未获取过锁时返回 nil
+func (l *Lock) Lost() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lost
+}
+
+// stopWatch 停止看门狗并等待其退出，避免释放后再次续期
+func (l *Lock) stopWatch() {
+	l.mu.Lock()
+	stop, done := l.stop, l.done
+	l.stop, l.done = nil, nil
+	l.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// watch 看门狗，定期续期直到停止或锁丢失
+func (l *Lock) watch(stop, done, lost chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(l.opts.RenewInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), l.opts.RenewInterval)
+		err := l.Refresh(ctx)
+		cancel()
+		switch {
+		case errors.Is(err, ErrLockNotHeld):
+			close(lost)
+			if l.opts.Logger != nil {
+				l.opts.Logger.WithField("key", l.key).Warn("锁已丢失")
+			}
+			return
+		case err != nil && l.opts.Logger != nil:
+			// 暂时性错误，下个周期重试
+			l.opts.Logger.WithFields(map[string]interface{}{"key": l.key, "error": err}).Warn("锁续期失败")
+		}
+	}
+}
@@ -5,37 +5,361 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/zhoudm1743/go-web/core/conf"
 	"github.com/zhoudm1743/go-web/core/log"
 )
 
-func (z ZMembers) Len() int           { return len(z) }
-func (z ZMembers) Less(i, j int) bool { return z[i].Score < z[j].Score }
-func (z ZMembers) Swap(i, j int)      { z[i], z[j] = z[j], z[i] }
+func (z ZMembers) Len() int { return len(z) }
+func (z ZMembers) Less(i, j int) bool {
+	if z[i].Score != z[j].Score {
+		return z[i].Score < z[j].Score
+	}
+	// 分数相同时按成员的字典序排列，与 Redis 一致
+	return fmt.Sprint(z[i].Member) < fmt.Sprint(z[j].Member)
+}
+func (z ZMembers) Swap(i, j int) { z[i], z[j] = z[j], z[i] }
+
+// memoryItemOverhead 每个键的固定开销估算（字节）
+const memoryItemOverhead = 64
+
+// MemoryStats 内存缓存统计
+type MemoryStats struct {
+	Hits      int64 `json:"hits"`      // 读取命中次数
+	Misses    int64 `json:"misses"`    // 读取未命中次数
+	Evictions int64 `json:"evictions"` // 超出容量被淘汰的键数
+	Rejected  int64 `json:"rejected"`  // TinyLFU 未准入的写入次数
+	Expired   int64 `json:"expired"`   // 过期删除的键数
+	Entries   int   `json:"entries"`   // 当前键数量
+	Bytes     int64 `json:"bytes"`     // 当前占用字节数（估算）
+}
+
+// memoryCounters 各分片共享的计数器
+type memoryCounters struct {
+	hits, misses, evictions, rejected, expired atomic.Int64
+}
+
+// memoryItem 缓存项
+type memoryItem struct {
+	value   interface{}
+	expires int64 // 过期时间 UnixNano，0 表示永不过期
+	size    int64 // 键和值的估算字节数
+}
+
+// expired 检查是否过期
+func (i *memoryItem) expired(now int64) bool {
+	return i.expires > 0 && i.expires <= now
+}
+
+// memoryShard 内存缓存分片，各分片独立加锁和淘汰
+type memoryShard struct {
+	mu       sync.Mutex
+	items    map[string]*memoryItem
+	policy   evictionPolicy
+	policyOf string
+	maxItems int
+	maxBytes int64
+	bytes    int64
+	counters *memoryCounters
+}
+
+// lookup 读取未过期的键，过期的键立即删除，调用方需持有分片锁
+func (s *memoryShard) lookup(key string) *memoryItem {
+	item, ok := s.items[key]
+	if !ok {
+		return nil
+	}
+	// 未设置过期时间的键不读取时钟
+	if item.expires > 0 && item.expired(time.Now().UnixNano()) {
+		s.delete(key)
+		s.counters.expired.Add(1)
+		return nil
+	}
+	return item
+}
+
+// get 读取键并记录命中和访问
+func (s *memoryShard) get(key string) *memoryItem {
+	item := s.lookup(key)
+	s.policy.access(key)
+	if item == nil {
+		s.counters.misses.Add(1)
+	} else {
+		s.counters.hits.Add(1)
+	}
+	return item
+}
+
+// fetch 读取键，read 为 true 时计入命中统计
+func (s *memoryShard) fetch(key string, read bool) *memoryItem {
+	if read {
+		return s.get(key)
+	}
+	item := s.lookup(key)
+	if item != nil {
+		s.policy.access(key)
+	}
+	return item
+}
+
+// put 写入键，admit 为 true 时新键需通过淘汰策略的准入判断，返回是否写入
+func (s *memoryShard) put(key string, value interface{}, expires int64, admit bool) bool {
+	item := s.lookup(key)
+	if item == nil {
+		if admit && s.full() {
+			if victim, ok := s.policy.victim(key); ok && !s.policy.admit(key, victim) {
+				s.counters.rejected.Add(1)
+				return false
+			}
+		}
+		item = &memoryItem{}
+		s.items[key] = item
+		s.policy.add(key)
+	} else {
+		s.policy.access(key)
+	}
+
+	item.value, item.expires = value, expires
+	s.resize(key, item, int64(len(key))+sizeOf(value)+memoryItemOverhead)
+	return true
+}
+
+// full 再写入一个新键是否会超出容量
+func (s *memoryShard) full() bool {
+	return (s.maxItems > 0 && len(s.items) >= s.maxItems) || (s.maxBytes > 0 && s.bytes >= s.maxBytes)
+}
+
+// grow 调整键的估算大小
+func (s *memoryShard) grow(key string, item *memoryItem, delta int64) {
+	s.resize(key, item, item.size+delta)
+}
+
+// resize 更新键的估算大小并按需淘汰
+func (s *memoryShard) resize(key string, item *memoryItem, size int64) {
+	s.bytes += size - item.size
+	item.size = size
+	s.evict(key)
+}
+
+// evict 超出容量时按淘汰策略删除键，不会删除正在写入的 keep
+func (s *memoryShard) evict(keep string) {
+	for (s.maxItems > 0 && len(s.items) > s.maxItems) || (s.maxBytes > 0 && s.bytes > s.maxBytes) {
+		victim, ok := s.policy.victim(keep)
+		if !ok {
+			return
+		}
+		s.delete(victim)
+		s.counters.evictions.Add(1)
+	}
+}
+
+// delete 删除键
+func (s *memoryShard) delete(key string) bool {
+	item, ok := s.items[key]
+	if !ok {
+		return false
+	}
+	s.bytes -= item.size
+	delete(s.items, key)
+	s.policy.remove(key)
+	return true
+}
+
+// sweep 删除已过期的键
+func (s *memoryShard) sweep(now int64) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for key, item := range s.items {
+		if item.expired(now) {
+			s.delete(key)
+			count++
+		}
+	}
+	s.counters.expired.Add(int64(count))
+	return count
+}
+
+// reset 清空分片
+func (s *memoryShard) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items = make(map[string]*memoryItem)
+	s.policy = newEvictionPolicy(s.policyOf, max(s.maxItems, 1024))
+	s.bytes = 0
+}
+
+// sizeOf 估算值占用的字节数
+func sizeOf(v interface{}) int64 {
+	switch val := v.(type) {
+	case string:
+		return int64(len(val))
+	case []byte:
+		return int64(len(val))
+	case map[string]interface{}:
+		var size int64
+		for k, v := range val {
+			size += int64(len(k)) + sizeOf(v)
+		}
+		return size
+	case []interface{}:
+		var size int64
+		for _, v := range val {
+			size += sizeOf(v)
+		}
+		return size
+	case map[string]bool:
+		var size int64
+		for k := range val {
+			size += int64(len(k)) + 1
+		}
+		return size
+	case *streamData:
+		return val.Bytes
+	case map[interface{}]float64:
+		var size int64
+		for k := range val {
+			size += sizeOf(k) + 8
+		}
+		return size
+	default:
+		return 8
+	}
+}
 
 // MemoryCache 内存缓存实现
+//
+// 键按哈希分布到多个分片，各分片独立加锁；配置了 MaxEntries 或 MaxBytes 时按淘汰策略删除键，
+// 后台协程按 SweepInterval 清理过期键，Close 时停止。
 type MemoryCache struct {
-	data   map[string]interface{}
-	expiry map[string]time.Time
-	prefix string
-	mu     sync.RWMutex
-	logger log.Logger
+	shards   []*memoryShard
+	mask     uint64
+	prefix   string
+	logger   log.Logger
+	counters memoryCounters
+	hub      *hub
+	streams  streamEmulator
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
 // NewMemoryCache 创建内存缓存
 func NewMemoryCache(cfg *conf.Config, log log.Logger) (Cache, error) {
-	log.Info("使用内存缓存")
+	opts := cfg.Cache.Memory
+	log.WithFields(map[string]interface{}{
+		"maxEntries": opts.MaxEntries,
+		"maxBytes":   opts.MaxBytes,
+		"policy":     opts.Policy,
+	}).Info("使用内存缓存")
+
+	return newMemoryCache(cfg.Cache.Prefix, opts, log), nil
+}
+
+// newMemoryCache 按配置创建分片并启动过期清理
+func newMemoryCache(prefix string, opts conf.MemoryCacheConfig, log log.Logger) *MemoryCache {
+	count := 1
+	for count < opts.Shards {
+		count <<= 1
+	}
+	if opts.Shards <= 0 {
+		count = 32
+	}
+	// 容量较小时减少分片，避免平均到每个分片后过小
+	for count > 1 && opts.MaxEntries > 0 && opts.MaxEntries/count < 8 {
+		count >>= 1
+	}
 
-	return &MemoryCache{
-		data:   make(map[string]interface{}),
-		expiry: make(map[string]time.Time),
-		prefix: cfg.Cache.Prefix,
+	m := &MemoryCache{
+		shards: make([]*memoryShard, count),
+		mask:   uint64(count - 1),
+		prefix: prefix,
 		logger: log,
-	}, nil
+		hub:    newHub(),
+	}
+	m.streams = streamEmulator{hub: m.hub, store: m.updateStream, fullKey: m.buildKey}
+	for i := range m.shards {
+		s := &memoryShard{
+			policyOf: opts.Policy,
+			maxItems: ceilDiv(opts.MaxEntries, count),
+			maxBytes: int64(ceilDiv(int(opts.MaxBytes), count)),
+			counters: &m.counters,
+		}
+		s.reset()
+		m.shards[i] = s
+	}
+
+	if opts.SweepInterval > 0 {
+		m.stop, m.done = make(chan struct{}), make(chan struct{})
+		go m.sweepLoop(opts.SweepInterval)
+	}
+	return m
+}
+
+// ceilDiv 向上取整的除法
+func ceilDiv(a, b int) int {
+	if a <= 0 {
+		return 0
+	}
+	return (a + b - 1) / b
+}
+
+// sweepLoop 定期清理过期键
+func (m *MemoryCache) sweepLoop(interval time.Duration) {
+	defer close(m.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			m.Sweep()
+		}
+	}
+}
+
+// Sweep 立即清理所有分片的过期键，返回清理数量
+func (m *MemoryCache) Sweep() int {
+	now := time.Now().UnixNano()
+	count := 0
+	for _, s := range m.shards {
+		count += s.sweep(now)
+	}
+	return count
+}
+
+// Stats 返回命中、淘汰等统计
+func (m *MemoryCache) Stats() MemoryStats {
+	stats := MemoryStats{
+		Hits:      m.counters.hits.Load(),
+		Misses:    m.counters.misses.Load(),
+		Evictions: m.counters.evictions.Load(),
+		Rejected:  m.counters.rejected.Load(),
+		Expired:   m.counters.expired.Load(),
+	}
+	for _, s := range m.shards {
+		s.mu.Lock()
+		stats.Entries += len(s.items)
+		stats.Bytes += s.bytes
+		s.mu.Unlock()
+	}
+	return stats
+}
+
+// usage 返回键数量和估算的占用
+func (m *MemoryCache) usage(ctx context.Context) (Usage, error) {
+	stats := m.Stats()
+	return Usage{Backend: "memory", Keys: int64(stats.Entries), Bytes: stats.Bytes}, nil
 }
 
 // buildKey 构建带前缀的键
@@ -46,20 +370,48 @@ func (m *MemoryCache) buildKey(key string) string {
 	return m.prefix + key
 }
 
-// isExpired 检查键是否过期
-func (m *MemoryCache) isExpired(key string) bool {
-	if exp, ok := m.expiry[key]; ok {
-		return exp.Before(time.Now())
+// shard 返回键所在的分片
+func (m *MemoryCache) shard(fullKey string) *memoryShard {
+	return m.shards[hashKey(fullKey)&m.mask]
+}
+
+// lockKey 对键所在的分片加锁，返回分片和完整键名
+func (m *MemoryCache) lockKey(key string) (*memoryShard, string) {
+	fullKey := m.buildKey(key)
+	s := m.shard(fullKey)
+	s.mu.Lock()
+	return s, fullKey
+}
+
+// lockKeys 按分片序号依次对多个键所在的分片加锁，避免与其他多键操作互相等待，返回解锁函数
+func (m *MemoryCache) lockKeys(keys []string) func() {
+	indexes := make([]int, 0, len(keys))
+	seen := make(map[int]bool, len(keys))
+	for _, key := range keys {
+		index := int(hashKey(m.buildKey(key)) & m.mask)
+		if !seen[index] {
+			seen[index] = true
+			indexes = append(indexes, index)
+		}
+	}
+	sort.Ints(indexes)
+
+	for _, index := range indexes {
+		m.shards[index].mu.Lock()
+	}
+	return func() {
+		for i := len(indexes) - 1; i >= 0; i-- {
+			m.shards[indexes[i]].mu.Unlock()
+		}
 	}
-	return false
 }
 
-// cleanExpired 清理过期的键
-func (m *MemoryCache) cleanExpired(key string) {
-	if m.isExpired(key) {
-		delete(m.data, key)
-		delete(m.expiry, key)
+// expiresAt 计算过期时间，0 表示永不过期
+func expiresAt(expiration time.Duration) int64 {
+	if expiration <= 0 {
+		return 0
 	}
+	return time.Now().Add(expiration).UnixNano()
 }
 
 // GetClient 获取Redis客户端（内存版本返回nil）
@@ -67,13 +419,18 @@ func (m *MemoryCache) GetClient() interface{} {
 	return nil
 }
 
-// Close 关闭连接
+// Close 停止过期清理、关闭订阅并清空缓存
 func (m *MemoryCache) Close() error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	m.data = make(map[string]interface{})
-	m.expiry = make(map[string]time.Time)
+	m.hub.close()
+	m.closeOnce.Do(func() {
+		if m.stop != nil {
+			close(m.stop)
+			<-m.done
+		}
+	})
+	for _, s := range m.shards {
+		s.reset()
+	}
 	return nil
 }
 
@@ -109,118 +466,320 @@ func (m *MemoryCache) TTL(key string) (time.Duration, error) {
 
 // GetCtx 获取缓存
 func (m *MemoryCache) GetCtx(ctx context.Context, key string) (string, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
 
-	fullKey := m.buildKey(key)
-	m.cleanExpired(fullKey)
+	return s.getString(fullKey)
+}
 
-	if val, ok := m.data[fullKey]; ok {
-		if str, ok := val.(string); ok {
-			return str, nil
-		}
+// getString 读取字符串值
+func (s *memoryShard) getString(fullKey string) (string, error) {
+	item := s.get(fullKey)
+	if item == nil {
+		return "", ErrKeyNotFound
+	}
+	str, ok := itemString(item)
+	if !ok {
 		return "", ErrTypeMismatch
 	}
+	return str, nil
+}
 
-	return "", ErrKeyNotFound
+// itemString 读取字符串类型的值，计数器以 int64 存储
+func itemString(item *memoryItem) (string, bool) {
+	switch v := item.value.(type) {
+	case string:
+		return v, true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	default:
+		return "", false
+	}
 }
 
-// SetCtx 设置缓存
+// SetCtx 设置缓存，TinyLFU 策略下缓存已满且新键访问频率较低时不写入
 func (m *MemoryCache) SetCtx(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	fullKey := m.buildKey(key)
-	m.data[fullKey] = value
-
-	if expiration > 0 {
-		m.expiry[fullKey] = time.Now().Add(expiration)
-	} else {
-		delete(m.expiry, fullKey)
+	str, err := stringValue(value)
+	if err != nil {
+		return err
 	}
 
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
+
+	s.put(fullKey, str, expiresAt(expiration), true)
 	return nil
 }
 
 // DelCtx 删除缓存
 func (m *MemoryCache) DelCtx(ctx context.Context, keys ...string) (int64, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	var count int64
 	for _, key := range keys {
-		fullKey := m.buildKey(key)
-		if _, ok := m.data[fullKey]; ok {
-			delete(m.data, fullKey)
-			delete(m.expiry, fullKey)
+		s, fullKey := m.lockKey(key)
+		if s.lookup(fullKey) != nil && s.delete(fullKey) {
 			count++
 		}
+		s.mu.Unlock()
 	}
-
 	return count, nil
 }
 
 // ExistsCtx 检查键是否存在
 func (m *MemoryCache) ExistsCtx(ctx context.Context, keys ...string) (int64, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
 	var count int64
 	for _, key := range keys {
-		fullKey := m.buildKey(key)
-		m.cleanExpired(fullKey)
-		if _, ok := m.data[fullKey]; ok {
+		s, fullKey := m.lockKey(key)
+		if s.lookup(fullKey) != nil {
 			count++
 		}
+		s.mu.Unlock()
 	}
-
 	return count, nil
 }
 
-// ExpireCtx 设置过期时间
+// ExpireCtx 设置过期时间，键不存在时忽略，expiration 不大于 0 时删除键
 func (m *MemoryCache) ExpireCtx(ctx context.Context, key string, expiration time.Duration) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
 
-	fullKey := m.buildKey(key)
-	if _, ok := m.data[fullKey]; !ok {
-		return ErrKeyNotFound
+	s.expire(fullKey, expiration)
+	return nil
+}
+
+// expire 设置过期时间，expiration 不大于 0 时删除键
+func (s *memoryShard) expire(fullKey string, expiration time.Duration) {
+	item := s.lookup(fullKey)
+	if item == nil {
+		return
 	}
+	if expiration <= 0 {
+		s.delete(fullKey)
+		return
+	}
+	item.expires = expiresAt(expiration)
+}
 
-	if expiration > 0 {
-		m.expiry[fullKey] = time.Now().Add(expiration)
-	} else {
-		delete(m.expiry, fullKey)
+// TTLCtx 获取过期时间，键不存在时返回 -2，未设置过期时间返回 -1
+func (m *MemoryCache) TTLCtx(ctx context.Context, key string) (time.Duration, error) {
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
+
+	item := s.lookup(fullKey)
+	if item == nil {
+		return -2, nil
 	}
 
-	return nil
+	// 如果没有设置过期时间，返回-1表示永不过期
+	if item.expires == 0 {
+		return -1, nil
+	}
+	return time.Duration(item.expires - time.Now().UnixNano()), nil
 }
 
-// TTLCtx 获取过期时间
-func (m *MemoryCache) TTLCtx(ctx context.Context, key string) (time.Duration, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+// Type 获取键的类型
+func (m *MemoryCache) Type(key string) (string, error) {
+	return m.TypeCtx(context.Background(), key)
+}
 
-	fullKey := m.buildKey(key)
-	m.cleanExpired(fullKey)
+// TypeCtx 获取键的类型，键不存在时返回 none
+func (m *MemoryCache) TypeCtx(ctx context.Context, key string) (string, error) {
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
 
-	if _, ok := m.data[fullKey]; !ok {
-		return 0, ErrKeyNotFound
+	item := s.lookup(fullKey)
+	if item == nil {
+		return "none", nil
+	}
+	switch item.value.(type) {
+	case map[string]interface{}:
+		return "hash", nil
+	case []interface{}:
+		return "list", nil
+	case map[string]bool:
+		return "set", nil
+	case map[interface{}]float64:
+		return "zset", nil
+	case *streamData:
+		return "stream", nil
+	default:
+		return "string", nil
+	}
+}
+
+// SetNX 键不存在时设置缓存
+func (m *MemoryCache) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	return m.SetNXCtx(context.Background(), key, value, expiration)
+}
+
+// CompareAndDel 值相等时删除键
+func (m *MemoryCache) CompareAndDel(key, value string) (bool, error) {
+	return m.CompareAndDelCtx(context.Background(), key, value)
+}
+
+// CompareAndExpire 值相等时设置过期时间
+func (m *MemoryCache) CompareAndExpire(key, value string, expiration time.Duration) (bool, error) {
+	return m.CompareAndExpireCtx(context.Background(), key, value, expiration)
+}
+
+// SetNXCtx 键不存在时设置缓存，不受 TinyLFU 准入限制
+func (m *MemoryCache) SetNXCtx(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	str, err := stringValue(value)
+	if err != nil {
+		return false, err
+	}
+
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
+
+	if s.lookup(fullKey) != nil {
+		return false, nil
+	}
+	return s.put(fullKey, str, expiresAt(expiration), false), nil
+}
+
+// CompareAndDelCtx 值相等时删除键
+func (m *MemoryCache) CompareAndDelCtx(ctx context.Context, key, value string) (bool, error) {
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
+
+	if !equalString(s.lookup(fullKey), value) {
+		return false, nil
+	}
+	return s.delete(fullKey), nil
+}
+
+// CompareAndExpireCtx 值相等时设置过期时间
+func (m *MemoryCache) CompareAndExpireCtx(ctx context.Context, key, value string, expiration time.Duration) (bool, error) {
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
+
+	item := s.lookup(fullKey)
+	if !equalString(item, value) {
+		return false, nil
+	}
+	item.expires = expiresAt(expiration)
+	return true, nil
+}
+
+// GetSet 设置新值并返回旧值
+func (m *MemoryCache) GetSet(key string, value interface{}) (string, error) {
+	return m.GetSetCtx(context.Background(), key, value)
+}
+
+// MGet 批量读取
+func (m *MemoryCache) MGet(keys ...string) ([]interface{}, error) {
+	return m.MGetCtx(context.Background(), keys...)
+}
+
+// MSet 批量写入
+func (m *MemoryCache) MSet(values ...interface{}) error {
+	return m.MSetCtx(context.Background(), values...)
+}
+
+// Persist 移除过期时间
+func (m *MemoryCache) Persist(key string) (bool, error) {
+	return m.PersistCtx(context.Background(), key)
+}
+
+// Rename 重命名键
+func (m *MemoryCache) Rename(key, newKey string) error {
+	return m.RenameCtx(context.Background(), key, newKey)
+}
+
+// GetSetCtx 设置新值并返回旧值，新值不过期
+func (m *MemoryCache) GetSetCtx(ctx context.Context, key string, value interface{}) (string, error) {
+	str, err := stringValue(value)
+	if err != nil {
+		return "", err
+	}
+
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
+
+	item := s.fetch(fullKey, true)
+	if item == nil {
+		s.put(fullKey, str, 0, false)
+		return "", ErrKeyNotFound
+	}
+	old, ok := itemString(item)
+	if !ok {
+		return "", ErrTypeMismatch
 	}
+	s.put(fullKey, str, 0, false)
+	return old, nil
+}
 
-	if exp, ok := m.expiry[fullKey]; ok {
-		remaining := exp.Sub(time.Now())
-		if remaining < 0 {
-			// 过期了，应该被清理，但由于我们可能并行访问，所以这里再次检查
-			delete(m.data, fullKey)
-			delete(m.expiry, fullKey)
-			return 0, ErrKeyNotFound
+// MGetCtx 批量读取，不存在或不是字符串的键对应 nil
+func (m *MemoryCache) MGetCtx(ctx context.Context, keys ...string) ([]interface{}, error) {
+	result := make([]interface{}, len(keys))
+	for i, key := range keys {
+		s, fullKey := m.lockKey(key)
+		if item := s.get(fullKey); item != nil {
+			if str, ok := itemString(item); ok {
+				result[i] = str
+			}
 		}
-		return remaining, nil
+		s.mu.Unlock()
+	}
+	return result, nil
+}
+
+// MSetCtx 批量写入，键位于不同分片时逐个写入，其他协程可能读到部分写入的结果
+func (m *MemoryCache) MSetCtx(ctx context.Context, values ...interface{}) error {
+	pairs, err := hashFields(values)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(pairs); i += 2 {
+		s, fullKey := m.lockKey(pairs[i])
+		s.put(fullKey, pairs[i+1], 0, false)
+		s.mu.Unlock()
 	}
+	return nil
+}
 
-	// 如果没有设置过期时间，返回-1表示永不过期
-	return -1, nil
+// PersistCtx 移除过期时间
+func (m *MemoryCache) PersistCtx(ctx context.Context, key string) (bool, error) {
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
+
+	item := s.lookup(fullKey)
+	if item == nil || item.expires == 0 {
+		return false, nil
+	}
+	item.expires = 0
+	return true, nil
+}
+
+// RenameCtx 重命名键，两个键的分片按顺序加锁
+func (m *MemoryCache) RenameCtx(ctx context.Context, key, newKey string) error {
+	fullKey, fullNewKey := m.buildKey(key), m.buildKey(newKey)
+	from, to := m.shard(fullKey), m.shard(fullNewKey)
+	defer m.lockKeys([]string{key, newKey})()
+
+	item := from.lookup(fullKey)
+	if item == nil {
+		return ErrKeyNotFound
+	}
+	if fullKey == fullNewKey {
+		return nil
+	}
+	from.delete(fullKey)
+	to.delete(fullNewKey)
+	to.put(fullNewKey, item.value, item.expires, false)
+	if _, ok := item.value.([]interface{}); ok {
+		m.hub.signal(fullNewKey)
+	}
+	return nil
+}
+
+// equalString 检查缓存项是否为相等的字符串
+func equalString(item *memoryItem, value string) bool {
+	if item == nil {
+		return false
+	}
+	str, ok := item.value.(string)
+	return ok && str == value
 }
 
 // Decr 递减
@@ -248,17 +807,19 @@ func (m *MemoryCache) IncrBy(key string, value int64) (int64, error) {
 	return m.IncrByCtx(context.Background(), key, value)
 }
 
-// IncrByCtx 按指定值递增
+// IncrByCtx 按指定值递增，保留原有的过期时间
 func (m *MemoryCache) IncrByCtx(ctx context.Context, key string, value int64) (int64, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
 
-	fullKey := m.buildKey(key)
-	m.cleanExpired(fullKey)
+	return s.incrBy(fullKey, value)
+}
 
-	var current int64
-	if val, ok := m.data[fullKey]; ok {
-		switch v := val.(type) {
+// incrBy 按指定值递增，保留原有的过期时间
+func (s *memoryShard) incrBy(fullKey string, value int64) (int64, error) {
+	var current, expires int64
+	if item := s.lookup(fullKey); item != nil {
+		switch v := item.value.(type) {
 		case int64:
 			current = v
 		case string:
@@ -268,20 +829,21 @@ func (m *MemoryCache) IncrByCtx(ctx context.Context, key string, value int64) (i
 				return 0, err
 			}
 		default:
-			return 0, errors.New("值类型无法递增")
+			return 0, ErrTypeMismatch
 		}
+		expires = item.expires
 	}
 
 	current += value
-	m.data[fullKey] = current
+	s.put(fullKey, current, expires, false)
 	return current, nil
 }
 
 // parseMemoryInt64 将字符串转换为int64
 func parseMemoryInt64(s string) (int64, error) {
-	var val int64
-	if _, err := fmt.Sscanf(s, "%d", &val); err != nil {
-		return 0, err
+	val, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("值不是整数: %w", err)
 	}
 	return val, nil
 }
@@ -316,22 +878,32 @@ func (m *MemoryCache) HLen(key string) (int64, error) {
 	return m.HLenCtx(context.Background(), key)
 }
 
+// hash 读取哈希表，键不存在时返回 nil
+func (s *memoryShard) hash(key string, read bool) (*memoryItem, map[string]interface{}, error) {
+	item := s.fetch(key, read)
+	if item == nil {
+		return nil, nil, nil
+	}
+	hashMap, ok := item.value.(map[string]interface{})
+	if !ok {
+		return nil, nil, ErrTypeMismatch
+	}
+	return item, hashMap, nil
+}
+
 // HGetCtx 获取哈希表字段值
 func (m *MemoryCache) HGetCtx(ctx context.Context, key, field string) (string, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	fullKey := m.buildKey(key)
-	m.cleanExpired(fullKey)
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
 
-	val, ok := m.data[fullKey]
-	if !ok {
-		return "", ErrKeyNotFound
-	}
+	return s.hget(fullKey, field)
+}
 
-	hashMap, ok := val.(map[string]interface{})
-	if !ok {
-		return "", errors.New("值类型不是哈希表")
+// hget 获取哈希表字段值
+func (s *memoryShard) hget(fullKey, field string) (string, error) {
+	_, hashMap, err := s.hash(fullKey, true)
+	if err != nil {
+		return "", err
 	}
 
 	fieldVal, ok := hashMap[field]
@@ -349,95 +921,141 @@ func (m *MemoryCache) HGetCtx(ctx context.Context, key, field string) (string, e
 
 // HSetCtx 设置哈希表字段值
 func (m *MemoryCache) HSetCtx(ctx context.Context, key string, values ...interface{}) (int64, error) {
-	if len(values)%2 != 0 {
-		return 0, errors.New("哈希表字段和值必须成对出现")
+	fields, err := hashFields(values)
+	if err != nil {
+		return 0, err
 	}
 
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	fullKey := m.buildKey(key)
-	m.cleanExpired(fullKey)
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
 
-	var hashMap map[string]interface{}
+	return s.hset(fullKey, fields)
+}
 
-	// 如果键存在，获取现有哈希表
-	if val, ok := m.data[fullKey]; ok {
-		existingMap, ok := val.(map[string]interface{})
-		if !ok {
-			return 0, errors.New("值类型不是哈希表")
-		}
-		hashMap = existingMap
-	} else {
-		// 否则创建新哈希表
+// hset 设置哈希表字段值，fields 为字段和值交替的字符串
+func (s *memoryShard) hset(fullKey string, fields []string) (int64, error) {
+	// 如果键存在，获取现有哈希表，否则创建新哈希表
+	item, hashMap, err := s.hash(fullKey, false)
+	if err != nil {
+		return 0, err
+	}
+	if item == nil {
 		hashMap = make(map[string]interface{})
-		m.data[fullKey] = hashMap
 	}
 
 	// 设置字段值
-	var count int64
-	for i := 0; i < len(values); i += 2 {
-		fieldName, ok := values[i].(string)
-		if !ok {
-			return count, fmt.Errorf("字段名必须是字符串，位置 %d", i)
-		}
-
-		_, exists := hashMap[fieldName]
-		hashMap[fieldName] = values[i+1]
-
-		if !exists {
+	var count, delta int64
+	for i := 0; i < len(fields); i += 2 {
+		fieldName, value := fields[i], fields[i+1]
+		old, exists := hashMap[fieldName]
+		hashMap[fieldName] = value
+		delta += sizeOf(value)
+		if exists {
+			delta -= sizeOf(old)
+		} else {
+			delta += int64(len(fieldName))
 			count++
 		}
 	}
 
+	if item == nil {
+		if len(hashMap) > 0 {
+			s.put(fullKey, hashMap, 0, false)
+		}
+	} else {
+		s.grow(fullKey, item, delta)
+	}
 	return count, nil
 }
 
-// HDelCtx 删除哈希表字段
-func (m *MemoryCache) HDelCtx(ctx context.Context, key string, fields ...string) (int64, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// hashFields 将 HSet 的参数展开为字段和值交替的字符串，
+// 与 go-redis 一致支持成对的参数、单个 map[string]interface{}、map[string]string 或 []string
+func hashFields(values []interface{}) ([]string, error) {
+	if len(values) == 1 {
+		switch v := values[0].(type) {
+		case map[string]interface{}:
+			values = make([]interface{}, 0, len(v)*2)
+			for field, value := range v {
+				values = append(values, field, value)
+			}
+		case map[string]string:
+			values = make([]interface{}, 0, len(v)*2)
+			for field, value := range v {
+				values = append(values, field, value)
+			}
+		case []string:
+			values = make([]interface{}, len(v))
+			for i, item := range v {
+				values[i] = item
+			}
+		case []interface{}:
+			values = v
+		}
+	}
+	if len(values)%2 != 0 {
+		return nil, errors.New("哈希表字段和值必须成对出现")
+	}
 
-	fullKey := m.buildKey(key)
-	m.cleanExpired(fullKey)
-
-	val, ok := m.data[fullKey]
-	if !ok {
-		return 0, nil
+	fields := make([]string, len(values))
+	for i, value := range values {
+		if i%2 == 0 {
+			field, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("字段名必须是字符串，位置 %d", i)
+			}
+			fields[i] = field
+			continue
+		}
+		str, err := stringValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("字段 %s 的值无法转换为字符串: %w", fields[i-1], err)
+		}
+		fields[i] = str
 	}
+	return fields, nil
+}
 
-	hashMap, ok := val.(map[string]interface{})
-	if !ok {
-		return 0, errors.New("值类型不是哈希表")
+// HDelCtx 删除哈希表字段
+func (m *MemoryCache) HDelCtx(ctx context.Context, key string, fields ...string) (int64, error) {
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
+
+	return s.hdel(fullKey, fields)
+}
+
+// hdel 删除哈希表字段
+func (s *memoryShard) hdel(fullKey string, fields []string) (int64, error) {
+	item, hashMap, err := s.hash(fullKey, false)
+	if item == nil {
+		return 0, err
 	}
 
-	var count int64
+	var count, delta int64
 	for _, field := range fields {
-		if _, ok := hashMap[field]; ok {
+		if old, ok := hashMap[field]; ok {
 			delete(hashMap, field)
+			delta -= int64(len(field)) + sizeOf(old)
 			count++
 		}
 	}
 
+	// 删除最后一个字段时删除键
+	if len(hashMap) == 0 {
+		s.delete(fullKey)
+	} else {
+		s.grow(fullKey, item, delta)
+	}
 	return count, nil
 }
 
 // HGetAllCtx 获取哈希表所有字段值
 func (m *MemoryCache) HGetAllCtx(ctx context.Context, key string) (map[string]string, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	fullKey := m.buildKey(key)
-	m.cleanExpired(fullKey)
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
 
-	val, ok := m.data[fullKey]
-	if !ok {
-		return make(map[string]string), nil
-	}
-
-	hashMap, ok := val.(map[string]interface{})
-	if !ok {
-		return nil, errors.New("值类型不是哈希表")
+	_, hashMap, err := s.hash(fullKey, true)
+	if err != nil {
+		return nil, err
 	}
 
 	result := make(map[string]string, len(hashMap))
@@ -455,20 +1073,12 @@ func (m *MemoryCache) HGetAllCtx(ctx context.Context, key string) (map[string]st
 
 // HExistsCtx 检查哈希表字段是否存在
 func (m *MemoryCache) HExistsCtx(ctx context.Context, key, field string) (bool, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
 
-	fullKey := m.buildKey(key)
-	m.cleanExpired(fullKey)
-
-	val, ok := m.data[fullKey]
-	if !ok {
-		return false, nil
-	}
-
-	hashMap, ok := val.(map[string]interface{})
-	if !ok {
-		return false, errors.New("值类型不是哈希表")
+	_, hashMap, err := s.hash(fullKey, true)
+	if err != nil {
+		return false, err
 	}
 
 	_, exists := hashMap[field]
@@ -477,20 +1087,12 @@ func (m *MemoryCache) HExistsCtx(ctx context.Context, key, field string) (bool,
 
 // HLenCtx 获取哈希表字段数量
 func (m *MemoryCache) HLenCtx(ctx context.Context, key string) (int64, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
 
-	fullKey := m.buildKey(key)
-	m.cleanExpired(fullKey)
-
-	val, ok := m.data[fullKey]
-	if !ok {
-		return 0, nil
-	}
-
-	hashMap, ok := val.(map[string]interface{})
-	if !ok {
-		return 0, errors.New("值类型不是哈希表")
+	_, hashMap, err := s.hash(fullKey, true)
+	if err != nil {
+		return 0, err
 	}
 
 	return int64(len(hashMap)), nil
@@ -503,75 +1105,163 @@ func (m *MemoryCache) Keys(pattern string) ([]string, error) {
 
 // KeysCtx 获取所有匹配的键
 func (m *MemoryCache) KeysCtx(ctx context.Context, pattern string) ([]string, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	now := time.Now().UnixNano()
 
 	var keys []string
-	for key := range m.data {
-		// 清理过期键
-		m.cleanExpired(key)
+	for _, s := range m.shards {
+		s.mu.Lock()
+		for key, item := range s.items {
+			// 如果键已过期，跳过
+			if item.expired(now) {
+				continue
+			}
 
-		// 如果键已过期，跳过
-		if _, ok := m.data[key]; !ok {
-			continue
-		}
+			// 移除前缀进行匹配
+			rawKey := key
+			if m.prefix != "" && strings.HasPrefix(key, m.prefix) {
+				rawKey = key[len(m.prefix):]
+			}
 
-		// 移除前缀进行匹配
-		rawKey := key
-		if m.prefix != "" && strings.HasPrefix(key, m.prefix) {
-			rawKey = key[len(m.prefix):]
+			if matchPattern(pattern, rawKey) {
+				keys = append(keys, rawKey)
+			}
 		}
+		s.mu.Unlock()
+	}
 
-		// 支持通配符 * 匹配
-		matched := false
-		if pattern == "*" {
-			matched = true
-		} else if !strings.Contains(pattern, "*") {
-			matched = (pattern == rawKey)
-		} else if strings.HasSuffix(pattern, "*") && strings.HasPrefix(rawKey, pattern[:len(pattern)-1]) {
-			// 前缀匹配 (prefix*)
-			matched = true
-		} else if strings.HasPrefix(pattern, "*") && strings.HasSuffix(rawKey, pattern[1:]) {
-			// 后缀匹配 (*suffix)
-			matched = true
-		} else if strings.HasPrefix(pattern, "*") && strings.HasSuffix(pattern, "*") && len(pattern) > 2 {
-			// 中间匹配 (*middle*)
-			mid := pattern[1 : len(pattern)-1]
-			matched = strings.Contains(rawKey, mid)
-		}
+	return keys, nil
+}
+
+// Scan 按游标分批遍历匹配的键
+func (m *MemoryCache) Scan(cursor uint64, match string, count int64) ([]string, uint64, error) {
+	return m.ScanCtx(context.Background(), cursor, match, count)
+}
+
+// ScanCtx 按游标分批遍历匹配的键，每次调用都会遍历所有分片
+func (m *MemoryCache) ScanCtx(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	if match == "" {
+		match = "*"
+	}
+	keys, err := m.KeysCtx(ctx, match)
+	if err != nil {
+		return nil, 0, err
+	}
+	page, next := scanPage(keys, cursor, count)
+	return page, next, nil
+}
 
-		if matched {
-			keys = append(keys, rawKey)
+// scanPage 将键按固定的位置排序后取出游标之后的一批，返回下一批的游标
+//
+// 位置由键名的哈希值决定，不随其他键的写入和删除变化，因此遍历期间一直存在的键至少返回一次。
+// 位置相同的键放在同一批，count 不大于 0 时每批 10 个。
+func scanPage(keys []string, cursor uint64, count int64) ([]string, uint64) {
+	if count <= 0 {
+		count = 10
+	}
+	positions := make(map[string]uint64, len(keys))
+	for _, key := range keys {
+		positions[key] = scanPosition(key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if positions[keys[i]] != positions[keys[j]] {
+			return positions[keys[i]] < positions[keys[j]]
 		}
+		return keys[i] < keys[j]
+	})
+
+	start := sort.Search(len(keys), func(i int) bool { return positions[keys[i]] >= cursor })
+	end := start + int(min(count, int64(len(keys)-start)))
+	for end < len(keys) && end > start && positions[keys[end]] == positions[keys[end-1]] {
+		end++
+	}
+	if end >= len(keys) {
+		return keys[start:], 0
 	}
+	return keys[start:end], positions[keys[end]]
+}
 
-	return keys, nil
+// scanPosition 键在遍历中的位置，最低位固定为 1，不会与表示结束的游标 0 相同
+func scanPosition(key string) uint64 {
+	return hashKey(key) | 1
 }
 
-// matchPattern 简单实现通配符匹配
+// matchPattern 按 Redis 的规则匹配通配符：* 匹配任意字符串，? 匹配单个字符，
+// [abc]、[^a]、[a-z] 匹配字符集合，\ 转义下一个字符
 func matchPattern(pattern, str string) bool {
-	// 这是一个简单的实现，仅支持 * 通配符
-	if pattern == "*" {
-		return true
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(str); i++ {
+				if matchPattern(pattern[1:], str[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(str) == 0 {
+				return false
+			}
+		case '[':
+			if len(str) == 0 {
+				return false
+			}
+			var ok bool
+			ok, pattern = matchClass(pattern[1:], str[0])
+			if !ok {
+				return false
+			}
+			str = str[1:]
+			continue
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(str) == 0 || pattern[0] != str[0] {
+				return false
+			}
+		}
+		pattern, str = pattern[1:], str[1:]
 	}
+	return len(str) == 0
+}
 
-	// 处理前缀匹配 (prefix*)
-	if strings.HasSuffix(pattern, "*") && strings.HasPrefix(str, pattern[:len(pattern)-1]) {
-		return true
+// matchClass 匹配 [] 中的字符集合，pattern 从 [ 之后开始，返回是否匹配和 ] 之后的模式
+func matchClass(pattern string, c byte) (bool, string) {
+	negate := len(pattern) > 0 && pattern[0] == '^'
+	if negate {
+		pattern = pattern[1:]
 	}
 
-	// 处理后缀匹配 (*suffix)
-	if strings.HasPrefix(pattern, "*") && strings.HasSuffix(str, pattern[1:]) {
-		return true
+	matched := false
+	for len(pattern) > 0 && pattern[0] != ']' {
+		switch {
+		case pattern[0] == '\\' && len(pattern) > 1:
+			matched = matched || pattern[1] == c
+			pattern = pattern[2:]
+		case len(pattern) > 2 && pattern[1] == '-' && pattern[2] != ']':
+			lo, hi := pattern[0], pattern[2]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			matched = matched || (c >= lo && c <= hi)
+			pattern = pattern[3:]
+		default:
+			matched = matched || pattern[0] == c
+			pattern = pattern[1:]
+		}
 	}
-
-	// 处理中间匹配 (*middle*)
-	if strings.HasPrefix(pattern, "*") && strings.HasSuffix(pattern, "*") && len(pattern) > 2 {
-		mid := pattern[1 : len(pattern)-1]
-		return strings.Contains(str, mid)
+	if len(pattern) > 0 {
+		pattern = pattern[1:]
 	}
-
-	return pattern == str
+	return matched != negate, pattern
 }
 
 // Ping 测试连接
@@ -584,6 +1274,45 @@ func (m *MemoryCache) PingCtx(ctx context.Context) error {
 	return nil // 内存缓存总是可用
 }
 
+// list 读取列表，键不存在时返回 nil
+func (s *memoryShard) list(key string, read bool) (*memoryItem, []interface{}, error) {
+	item := s.fetch(key, read)
+	if item == nil {
+		return nil, nil, nil
+	}
+	list, ok := item.value.([]interface{})
+	if !ok {
+		return nil, nil, ErrTypeMismatch
+	}
+	return item, list, nil
+}
+
+// listValues 将写入列表的元素转换为字符串
+func listValues(values []interface{}) ([]interface{}, error) {
+	elements := make([]interface{}, len(values))
+	for i, value := range values {
+		str, err := stringValue(value)
+		if err != nil {
+			return nil, err
+		}
+		elements[i] = str
+	}
+	return elements, nil
+}
+
+// setList 写入修改后的列表，列表为空时删除键
+func (s *memoryShard) setList(key string, item *memoryItem, list []interface{}, delta int64) {
+	switch {
+	case len(list) == 0:
+		s.delete(key)
+	case item == nil:
+		s.put(key, list, 0, false)
+	default:
+		item.value = list
+		s.grow(key, item, delta)
+	}
+}
+
 // LLen 获取列表长度
 func (m *MemoryCache) LLen(key string) (int64, error) {
 	return m.LLenCtx(context.Background(), key)
@@ -591,20 +1320,12 @@ func (m *MemoryCache) LLen(key string) (int64, error) {
 
 // LLenCtx 获取列表长度
 func (m *MemoryCache) LLenCtx(ctx context.Context, key string) (int64, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	fullKey := m.buildKey(key)
-	m.cleanExpired(fullKey)
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
 
-	val, ok := m.data[fullKey]
-	if !ok {
-		return 0, nil
-	}
-
-	list, ok := val.([]interface{})
-	if !ok {
-		return 0, errors.New("值类型不是列表")
+	_, list, err := s.list(fullKey, true)
+	if err != nil {
+		return 0, err
 	}
 
 	return int64(len(list)), nil
@@ -617,35 +1338,7 @@ func (m *MemoryCache) LPush(key string, values ...interface{}) (int64, error) {
 
 // LPushCtx 在列表左侧添加元素
 func (m *MemoryCache) LPushCtx(ctx context.Context, key string, values ...interface{}) (int64, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	fullKey := m.buildKey(key)
-	m.cleanExpired(fullKey)
-
-	var list []interface{}
-
-	// 如果键存在，获取现有列表
-	if val, ok := m.data[fullKey]; ok {
-		existingList, ok := val.([]interface{})
-		if !ok {
-			return 0, errors.New("值类型不是列表")
-		}
-		list = existingList
-	} else {
-		// 创建新列表
-		list = []interface{}{}
-	}
-
-	// 从左侧添加元素（每个元素都放在最前面）
-	newList := make([]interface{}, 0, len(list)+len(values))
-	for i := 0; i < len(values); i++ {
-		newList = append(newList, values[i])
-	}
-	newList = append(newList, list...)
-
-	m.data[fullKey] = newList
-	return int64(len(newList)), nil
+	return m.push(key, values, true)
 }
 
 // RPush 在列表右侧添加元素
@@ -655,30 +1348,45 @@ func (m *MemoryCache) RPush(key string, values ...interface{}) (int64, error) {
 
 // RPushCtx 在列表右侧添加元素
 func (m *MemoryCache) RPushCtx(ctx context.Context, key string, values ...interface{}) (int64, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	return m.push(key, values, false)
+}
 
-	fullKey := m.buildKey(key)
-	m.cleanExpired(fullKey)
+// push 在列表左侧或右侧添加元素，并唤醒等待该列表的阻塞弹出
+func (m *MemoryCache) push(key string, values []interface{}, left bool) (int64, error) {
+	elements, err := listValues(values)
+	if err != nil {
+		return 0, err
+	}
 
-	var list []interface{}
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
 
-	// 如果键存在，获取现有列表
-	if val, ok := m.data[fullKey]; ok {
-		existingList, ok := val.([]interface{})
-		if !ok {
-			return 0, errors.New("值类型不是列表")
+	length, err := s.push(fullKey, elements, left)
+	if err == nil {
+		m.hub.signal(fullKey)
+	}
+	return length, err
+}
+
+// push 在列表左侧或右侧添加已转换为字符串的元素
+func (s *memoryShard) push(fullKey string, elements []interface{}, left bool) (int64, error) {
+	item, list, err := s.list(fullKey, false)
+	if err != nil {
+		return 0, err
+	}
+
+	if left {
+		// 从左侧逐个添加元素，最后一个参数位于列表头部
+		newList := make([]interface{}, 0, len(list)+len(elements))
+		for i := len(elements) - 1; i >= 0; i-- {
+			newList = append(newList, elements[i])
 		}
-		list = existingList
+		list = append(newList, list...)
 	} else {
-		// 创建新列表
-		list = []interface{}{}
+		list = append(list, elements...)
 	}
 
-	// 从右侧添加元素
-	list = append(list, values...)
-
-	m.data[fullKey] = list
+	s.setList(fullKey, item, list, sizeOf(elements))
 	return int64(len(list)), nil
 }
 
@@ -689,36 +1397,20 @@ func (m *MemoryCache) LPop(key string) (string, error) {
 
 // LPopCtx 弹出列表左侧元素
 func (m *MemoryCache) LPopCtx(ctx context.Context, key string) (string, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	fullKey := m.buildKey(key)
-	m.cleanExpired(fullKey)
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
 
-	val, ok := m.data[fullKey]
-	if !ok {
-		return "", ErrKeyNotFound
-	}
-
-	list, ok := val.([]interface{})
-	if !ok {
-		return "", errors.New("值类型不是列表")
+	item, list, err := s.list(fullKey, false)
+	if err != nil {
+		return "", err
 	}
-
 	if len(list) == 0 {
 		return "", ErrKeyNotFound
 	}
 
-	// 获取第一个元素
+	// 获取第一个元素，列表为空时删除键
 	first := list[0]
-
-	// 更新列表
-	m.data[fullKey] = list[1:]
-
-	// 如果列表为空，考虑删除键
-	if len(list) == 1 {
-		delete(m.data, fullKey)
-	}
+	s.setList(fullKey, item, list[1:], -sizeOf(first))
 
 	// 将值转换为字符串
 	switch v := first.(type) {
@@ -736,36 +1428,20 @@ func (m *MemoryCache) RPop(key string) (string, error) {
 
 // RPopCtx 弹出列表右侧元素
 func (m *MemoryCache) RPopCtx(ctx context.Context, key string) (string, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	fullKey := m.buildKey(key)
-	m.cleanExpired(fullKey)
-
-	val, ok := m.data[fullKey]
-	if !ok {
-		return "", ErrKeyNotFound
-	}
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
 
-	list, ok := val.([]interface{})
-	if !ok {
-		return "", errors.New("值类型不是列表")
+	item, list, err := s.list(fullKey, false)
+	if err != nil {
+		return "", err
 	}
-
 	if len(list) == 0 {
 		return "", ErrKeyNotFound
 	}
 
-	// 获取最后一个元素
+	// 获取最后一个元素，列表为空时删除键
 	last := list[len(list)-1]
-
-	// 更新列表
-	m.data[fullKey] = list[:len(list)-1]
-
-	// 如果列表为空，考虑删除键
-	if len(list) == 1 {
-		delete(m.data, fullKey)
-	}
+	s.setList(fullKey, item, list[:len(list)-1], -sizeOf(last))
 
 	// 将值转换为字符串
 	switch v := last.(type) {
@@ -783,20 +1459,12 @@ func (m *MemoryCache) LRange(key string, start, stop int64) ([]string, error) {
 
 // LRangeCtx 获取列表范围内的元素
 func (m *MemoryCache) LRangeCtx(ctx context.Context, key string, start, stop int64) ([]string, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
 
-	fullKey := m.buildKey(key)
-	m.cleanExpired(fullKey)
-
-	val, ok := m.data[fullKey]
-	if !ok {
-		return []string{}, nil
-	}
-
-	list, ok := val.([]interface{})
-	if !ok {
-		return nil, errors.New("值类型不是列表")
+	_, list, err := s.list(fullKey, true)
+	if err != nil {
+		return nil, err
 	}
 
 	// 调整负索引
@@ -863,74 +1531,91 @@ func (m *MemoryCache) SCard(key string) (int64, error) {
 	return m.SCardCtx(context.Background(), key)
 }
 
+// set 读取集合，键不存在时返回 nil
+func (s *memoryShard) set(key string, read bool) (*memoryItem, map[string]bool, error) {
+	item := s.fetch(key, read)
+	if item == nil {
+		return nil, nil, nil
+	}
+	set, ok := item.value.(map[string]bool)
+	if !ok {
+		return nil, nil, ErrTypeMismatch
+	}
+	return item, set, nil
+}
+
 // SAddCtx 添加集合成员
 func (m *MemoryCache) SAddCtx(ctx context.Context, key string, members ...interface{}) (int64, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	fullKey := m.buildKey(key)
-	m.cleanExpired(fullKey)
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
 
-	var set map[string]bool
+	return s.sadd(fullKey, members)
+}
 
-	// 如果键存在，获取现有集合
-	if val, ok := m.data[fullKey]; ok {
-		existingSet, ok := val.(map[string]bool)
-		if !ok {
-			return 0, errors.New("值类型不是集合")
-		}
-		set = existingSet
-	} else {
-		// 创建新集合
+// sadd 添加集合成员
+func (s *memoryShard) sadd(fullKey string, members []interface{}) (int64, error) {
+	// 如果键存在，获取现有集合，否则创建新集合
+	item, set, err := s.set(fullKey, false)
+	if err != nil {
+		return 0, err
+	}
+	if item == nil {
 		set = make(map[string]bool)
-		m.data[fullKey] = set
 	}
 
 	// 添加成员
-	var added int64
+	var added, delta int64
 	for _, member := range members {
 		// 将成员转换为字符串
 		strMember := fmt.Sprintf("%v", member)
 		if !set[strMember] {
 			set[strMember] = true
+			delta += int64(len(strMember)) + 1
 			added++
 		}
 	}
 
+	if item == nil {
+		if len(set) > 0 {
+			s.put(fullKey, set, 0, false)
+		}
+	} else {
+		s.grow(fullKey, item, delta)
+	}
 	return added, nil
 }
 
 // SRemCtx 删除集合成员
 func (m *MemoryCache) SRemCtx(ctx context.Context, key string, members ...interface{}) (int64, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
 
-	fullKey := m.buildKey(key)
-	m.cleanExpired(fullKey)
-
-	val, ok := m.data[fullKey]
-	if !ok {
-		return 0, nil
-	}
+	return s.srem(fullKey, members)
+}
 
-	set, ok := val.(map[string]bool)
-	if !ok {
-		return 0, errors.New("值类型不是集合")
+// srem 删除集合成员
+func (s *memoryShard) srem(fullKey string, members []interface{}) (int64, error) {
+	item, set, err := s.set(fullKey, false)
+	if item == nil {
+		return 0, err
 	}
 
 	// 删除成员
-	var removed int64
+	var removed, delta int64
 	for _, member := range members {
 		strMember := fmt.Sprintf("%v", member)
 		if set[strMember] {
 			delete(set, strMember)
+			delta -= int64(len(strMember)) + 1
 			removed++
 		}
 	}
 
 	// 如果集合为空，删除键
 	if len(set) == 0 {
-		delete(m.data, fullKey)
+		s.delete(fullKey)
+	} else {
+		s.grow(fullKey, item, delta)
 	}
 
 	return removed, nil
@@ -938,20 +1623,12 @@ func (m *MemoryCache) SRemCtx(ctx context.Context, key string, members ...interf
 
 // SMembersCtx 获取集合所有成员
 func (m *MemoryCache) SMembersCtx(ctx context.Context, key string) ([]string, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	fullKey := m.buildKey(key)
-	m.cleanExpired(fullKey)
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
 
-	val, ok := m.data[fullKey]
-	if !ok {
-		return []string{}, nil
-	}
-
-	set, ok := val.(map[string]bool)
-	if !ok {
-		return nil, errors.New("值类型不是集合")
+	_, set, err := s.set(fullKey, true)
+	if err != nil {
+		return nil, err
 	}
 
 	members := make([]string, 0, len(set))
@@ -964,20 +1641,12 @@ func (m *MemoryCache) SMembersCtx(ctx context.Context, key string) ([]string, er
 
 // SIsMemberCtx 检查成员是否在集合中
 func (m *MemoryCache) SIsMemberCtx(ctx context.Context, key string, member interface{}) (bool, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	fullKey := m.buildKey(key)
-	m.cleanExpired(fullKey)
-
-	val, ok := m.data[fullKey]
-	if !ok {
-		return false, nil
-	}
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
 
-	set, ok := val.(map[string]bool)
-	if !ok {
-		return false, errors.New("值类型不是集合")
+	_, set, err := s.set(fullKey, true)
+	if err != nil {
+		return false, err
 	}
 
 	strMember := fmt.Sprintf("%v", member)
@@ -986,20 +1655,12 @@ func (m *MemoryCache) SIsMemberCtx(ctx context.Context, key string, member inter
 
 // SCardCtx 获取集合成员数
 func (m *MemoryCache) SCardCtx(ctx context.Context, key string) (int64, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	fullKey := m.buildKey(key)
-	m.cleanExpired(fullKey)
-
-	val, ok := m.data[fullKey]
-	if !ok {
-		return 0, nil
-	}
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
 
-	set, ok := val.(map[string]bool)
-	if !ok {
-		return 0, errors.New("值类型不是集合")
+	_, set, err := s.set(fullKey, true)
+	if err != nil {
+		return 0, err
 	}
 
 	return int64(len(set)), nil
@@ -1036,98 +1697,110 @@ func (m *MemoryCache) ZScore(key, member string) (float64, error) {
 	return m.ZScoreCtx(context.Background(), key, member)
 }
 
+// zset 读取有序集合，键不存在时返回 nil
+func (s *memoryShard) zset(key string, read bool) (*memoryItem, map[interface{}]float64, error) {
+	item := s.fetch(key, read)
+	if item == nil {
+		return nil, nil, nil
+	}
+	zset, ok := item.value.(map[interface{}]float64)
+	if !ok {
+		return nil, nil, ErrTypeMismatch
+	}
+	return item, zset, nil
+}
+
 // ZAddCtx 添加有序集合成员
 func (m *MemoryCache) ZAddCtx(ctx context.Context, key string, members ...Z) (int64, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
 
-	fullKey := m.buildKey(key)
-	m.cleanExpired(fullKey)
+	return s.zadd(fullKey, members)
+}
 
+// zadd 添加有序集合成员
+func (s *memoryShard) zadd(fullKey string, members []Z) (int64, error) {
 	// 获取或创建有序集合
-	var zset map[interface{}]float64
-	if val, ok := m.data[fullKey]; ok {
-		if existingZSet, ok := val.(map[interface{}]float64); ok {
-			zset = existingZSet
-		} else {
-			// 类型不匹配，创建新的
-			zset = make(map[interface{}]float64)
-			m.data[fullKey] = zset
-		}
-	} else {
+	item, zset, err := s.zset(fullKey, false)
+	if err != nil {
+		return 0, err
+	}
+	if zset == nil {
 		zset = make(map[interface{}]float64)
-		m.data[fullKey] = zset
 	}
 
-	// 添加成员
-	var added int64
+	// 添加成员，成员统一转换为字符串
+	var added, delta int64
 	for _, member := range members {
-		if _, exists := zset[member.Member]; !exists {
+		name := fmt.Sprint(member.Member)
+		if _, exists := zset[name]; !exists {
+			delta += int64(len(name)) + 8
 			added++
 		}
-		zset[member.Member] = member.Score
+		zset[name] = member.Score
 	}
 
+	if item == nil {
+		s.put(fullKey, zset, 0, false)
+	} else {
+		s.grow(fullKey, item, delta)
+	}
 	return added, nil
 }
 
 // ZRemCtx 删除有序集合成员
 func (m *MemoryCache) ZRemCtx(ctx context.Context, key string, members ...interface{}) (int64, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
 
-	fullKey := m.buildKey(key)
-	m.cleanExpired(fullKey)
+	return s.zrem(fullKey, members)
+}
 
+// zrem 删除有序集合成员
+func (s *memoryShard) zrem(fullKey string, members []interface{}) (int64, error) {
 	// 获取有序集合
-	val, ok := m.data[fullKey]
-	if !ok {
-		return 0, nil
-	}
-
-	zset, ok := val.(map[interface{}]float64)
-	if !ok {
-		return 0, ErrTypeMismatch
+	item, zset, err := s.zset(fullKey, false)
+	if item == nil {
+		return 0, err
 	}
 
 	// 删除成员
-	var removed int64
+	var removed, delta int64
 	for _, member := range members {
-		if _, exists := zset[member]; exists {
-			delete(zset, member)
+		name := fmt.Sprint(member)
+		if _, exists := zset[name]; exists {
+			delete(zset, name)
+			delta -= int64(len(name)) + 8
 			removed++
 		}
 	}
 
+	// 删除最后一个成员时删除键
+	if len(zset) == 0 {
+		s.delete(fullKey)
+	} else {
+		s.grow(fullKey, item, delta)
+	}
 	return removed, nil
 }
 
-// ZRangeCtx 获取有序集合范围
-func (m *MemoryCache) ZRangeCtx(ctx context.Context, key string, start, stop int64) ([]string, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	fullKey := m.buildKey(key)
-	m.cleanExpired(fullKey)
-
-	// 获取有序集合
-	val, ok := m.data[fullKey]
-	if !ok {
-		return []string{}, nil
-	}
-
-	zset, ok := val.(map[interface{}]float64)
-	if !ok {
-		return nil, ErrTypeMismatch
-	}
-
-	// 转换为ZMembers并排序
+// sortedMembers 按分数从低到高排序的全部成员
+func sortedMembers(zset map[interface{}]float64) ZMembers {
 	members := make(ZMembers, 0, len(zset))
 	for member, score := range zset {
 		members = append(members, ZMember{Score: score, Member: member})
 	}
 	sort.Sort(members)
+	return members
+}
 
+// sortedRange 按分数排序后截取范围，start、stop 支持负索引
+func sortedRange(zset map[interface{}]float64, start, stop int64) ZMembers {
+	return indexRange(sortedMembers(zset), start, stop)
+}
+
+// indexRange 按 Redis 的规则截取排名范围，start、stop 支持负索引
+func indexRange(members ZMembers, start, stop int64) ZMembers {
 	// 处理负索引
 	length := int64(len(members))
 	if start < 0 {
@@ -1143,13 +1816,27 @@ func (m *MemoryCache) ZRangeCtx(ctx context.Context, key string, start, stop int
 		stop = length - 1
 	}
 	if start > stop || start >= length {
-		return []string{}, nil
+		return nil
+	}
+	return members[start : stop+1]
+}
+
+// ZRangeCtx 获取有序集合范围
+func (m *MemoryCache) ZRangeCtx(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
+
+	// 获取有序集合
+	_, zset, err := s.zset(fullKey, true)
+	if err != nil {
+		return nil, err
 	}
 
 	// 提取结果
-	result := make([]string, 0, stop-start+1)
-	for i := start; i <= stop; i++ {
-		result = append(result, fmt.Sprintf("%v", members[i].Member))
+	members := sortedRange(zset, start, stop)
+	result := make([]string, 0, len(members))
+	for _, member := range members {
+		result = append(result, fmt.Sprintf("%v", member.Member))
 	}
 
 	return result, nil
@@ -1157,102 +1844,554 @@ func (m *MemoryCache) ZRangeCtx(ctx context.Context, key string, start, stop int
 
 // ZRangeWithScoresCtx 获取有序集合范围及分数
 func (m *MemoryCache) ZRangeWithScoresCtx(ctx context.Context, key string, start, stop int64) ([]Z, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
 
-	fullKey := m.buildKey(key)
-	m.cleanExpired(fullKey)
+	// 获取有序集合
+	_, zset, err := s.zset(fullKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// 提取结果
+	members := sortedRange(zset, start, stop)
+	result := make([]Z, 0, len(members))
+	for _, member := range members {
+		result = append(result, Z{Score: member.Score, Member: member.Member})
+	}
+
+	return result, nil
+}
+
+// ZCardCtx 获取有序集合成员数
+func (m *MemoryCache) ZCardCtx(ctx context.Context, key string) (int64, error) {
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
 
 	// 获取有序集合
-	val, ok := m.data[fullKey]
-	if !ok {
-		return []Z{}, nil
+	_, zset, err := s.zset(fullKey, true)
+	if err != nil {
+		return 0, err
 	}
 
-	zset, ok := val.(map[interface{}]float64)
+	return int64(len(zset)), nil
+}
+
+// ZScoreCtx 获取有序集合成员分数
+func (m *MemoryCache) ZScoreCtx(ctx context.Context, key, member string) (float64, error) {
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
+
+	// 获取有序集合
+	item, zset, err := s.zset(fullKey, true)
+	if err != nil {
+		return 0, err
+	}
+	if item == nil {
+		return 0, ErrKeyNotFound
+	}
+
+	score, ok := zset[member]
 	if !ok {
-		return nil, ErrTypeMismatch
+		return 0, ErrKeyNotFound
 	}
 
-	// 转换为ZMembers并排序
-	members := make(ZMembers, 0, len(zset))
+	return score, nil
+}
+
+// ZRangeByScore 按分数范围获取成员
+func (m *MemoryCache) ZRangeByScore(key string, opt ZRangeBy) ([]string, error) {
+	return m.ZRangeByScoreCtx(context.Background(), key, opt)
+}
+
+// ZRevRange 按分数从高到低获取成员
+func (m *MemoryCache) ZRevRange(key string, start, stop int64) ([]string, error) {
+	return m.ZRevRangeCtx(context.Background(), key, start, stop)
+}
+
+// ZIncrBy 增加成员分数
+func (m *MemoryCache) ZIncrBy(key string, increment float64, member string) (float64, error) {
+	return m.ZIncrByCtx(context.Background(), key, increment, member)
+}
+
+// ZRank 获取成员排名
+func (m *MemoryCache) ZRank(key, member string) (int64, error) {
+	return m.ZRankCtx(context.Background(), key, member)
+}
+
+// ZRemRangeByScore 删除分数范围内的成员
+func (m *MemoryCache) ZRemRangeByScore(key, min, max string) (int64, error) {
+	return m.ZRemRangeByScoreCtx(context.Background(), key, min, max)
+}
+
+// ZCount 统计分数范围内的成员数
+func (m *MemoryCache) ZCount(key, min, max string) (int64, error) {
+	return m.ZCountCtx(context.Background(), key, min, max)
+}
+
+// ZRangeByScoreCtx 按分数范围获取成员
+func (m *MemoryCache) ZRangeByScoreCtx(ctx context.Context, key string, opt ZRangeBy) ([]string, error) {
+	scores, err := parseScoreRange(opt.Min, opt.Max)
+	if err != nil {
+		return nil, err
+	}
+
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
+
+	_, zset, err := s.zset(fullKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0)
+	skipped := int64(0)
+	for _, member := range sortedMembers(zset) {
+		if !scores.contains(member.Score) {
+			continue
+		}
+		if skipped < opt.Offset {
+			skipped++
+			continue
+		}
+		if opt.Count > 0 && int64(len(result)) >= opt.Count {
+			break
+		}
+		result = append(result, fmt.Sprint(member.Member))
+	}
+	return result, nil
+}
+
+// ZRevRangeCtx 按分数从高到低获取成员
+func (m *MemoryCache) ZRevRangeCtx(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
+
+	_, zset, err := s.zset(fullKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	members := sortedMembers(zset)
+	sort.Sort(sort.Reverse(members))
+	result := make([]string, 0)
+	for _, member := range indexRange(members, start, stop) {
+		result = append(result, fmt.Sprint(member.Member))
+	}
+	return result, nil
+}
+
+// ZIncrByCtx 增加成员分数，成员不存在时添加
+func (m *MemoryCache) ZIncrByCtx(ctx context.Context, key string, increment float64, member string) (float64, error) {
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
+
+	return s.zincrBy(fullKey, increment, member)
+}
+
+// zincrBy 增加成员分数，成员不存在时添加
+func (s *memoryShard) zincrBy(fullKey string, increment float64, member string) (float64, error) {
+	item, zset, err := s.zset(fullKey, false)
+	if err != nil {
+		return 0, err
+	}
+	if item == nil {
+		zset = make(map[interface{}]float64)
+	}
+
+	score, exists := zset[member]
+	score += increment
+	zset[member] = score
+	if item == nil {
+		s.put(fullKey, zset, 0, false)
+	} else if !exists {
+		s.grow(fullKey, item, int64(len(member))+8)
+	}
+	return score, nil
+}
+
+// ZRankCtx 获取成员按分数从低到高的排名
+func (m *MemoryCache) ZRankCtx(ctx context.Context, key, member string) (int64, error) {
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
+
+	_, zset, err := s.zset(fullKey, true)
+	if err != nil {
+		return 0, err
+	}
+	if _, ok := zset[member]; !ok {
+		return 0, ErrKeyNotFound
+	}
+
+	for i, item := range sortedMembers(zset) {
+		if item.Member == member {
+			return int64(i), nil
+		}
+	}
+	return 0, ErrKeyNotFound
+}
+
+// ZRemRangeByScoreCtx 删除分数范围内的成员
+func (m *MemoryCache) ZRemRangeByScoreCtx(ctx context.Context, key, min, max string) (int64, error) {
+	scores, err := parseScoreRange(min, max)
+	if err != nil {
+		return 0, err
+	}
+
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
+
+	item, zset, err := s.zset(fullKey, false)
+	if item == nil {
+		return 0, err
+	}
+
+	var removed, delta int64
 	for member, score := range zset {
-		members = append(members, ZMember{Score: score, Member: member})
+		if scores.contains(score) {
+			delete(zset, member)
+			delta -= sizeOf(member) + 8
+			removed++
+		}
 	}
-	sort.Sort(members)
 
-	// 处理负索引
-	length := int64(len(members))
-	if start < 0 {
-		start = length + start
-		if start < 0 {
-			start = 0
+	// 删除最后一个成员时删除键
+	if len(zset) == 0 {
+		s.delete(fullKey)
+	} else {
+		s.grow(fullKey, item, delta)
+	}
+	return removed, nil
+}
+
+// ZCountCtx 统计分数范围内的成员数
+func (m *MemoryCache) ZCountCtx(ctx context.Context, key, min, max string) (int64, error) {
+	scores, err := parseScoreRange(min, max)
+	if err != nil {
+		return 0, err
+	}
+
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
+
+	_, zset, err := s.zset(fullKey, true)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	for _, score := range zset {
+		if scores.contains(score) {
+			count++
 		}
 	}
-	if stop < 0 {
-		stop = length + stop
+	return count, nil
+}
+
+// scoreBound 有序集合的分数边界
+type scoreBound struct {
+	value     float64
+	exclusive bool
+}
+
+// scoreRange 有序集合的分数范围
+type scoreRange struct {
+	min, max scoreBound
+}
+
+// parseScoreRange 解析 Redis 格式的分数范围，如 "-inf"、"+inf"、"10"、"(10"
+func parseScoreRange(min, max string) (scoreRange, error) {
+	var r scoreRange
+	var err error
+	if r.min, err = parseScoreBound(min); err != nil {
+		return r, err
 	}
-	if stop >= length {
-		stop = length - 1
+	r.max, err = parseScoreBound(max)
+	return r, err
+}
+
+// parseScoreBound 解析单个分数边界，前缀 ( 表示不包含边界
+func parseScoreBound(s string) (scoreBound, error) {
+	var b scoreBound
+	if strings.HasPrefix(s, "(") {
+		b.exclusive = true
+		s = s[1:]
 	}
-	if start > stop || start >= length {
-		return []Z{}, nil
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return b, fmt.Errorf("分数范围格式错误 %q: %w", s, err)
 	}
+	b.value = value
+	return b, nil
+}
 
-	// 提取结果
-	result := make([]Z, 0, stop-start+1)
-	for i := start; i <= stop; i++ {
-		result = append(result, Z{Score: members[i].Score, Member: members[i].Member})
+// contains 判断分数是否在范围内
+func (r scoreRange) contains(score float64) bool {
+	if score < r.min.value || (r.min.exclusive && score == r.min.value) {
+		return false
 	}
+	return score < r.max.value || (!r.max.exclusive && score == r.max.value)
+}
 
-	return result, nil
+// BLPop 阻塞弹出列表左侧元素
+func (m *MemoryCache) BLPop(timeout time.Duration, keys ...string) ([]string, error) {
+	return m.BLPopCtx(context.Background(), timeout, keys...)
 }
 
-// ZCardCtx 获取有序集合成员数
-func (m *MemoryCache) ZCardCtx(ctx context.Context, key string) (int64, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+// BRPop 阻塞弹出列表右侧元素
+func (m *MemoryCache) BRPop(timeout time.Duration, keys ...string) ([]string, error) {
+	return m.BRPopCtx(context.Background(), timeout, keys...)
+}
 
-	fullKey := m.buildKey(key)
-	m.cleanExpired(fullKey)
+// BLPopCtx 阻塞弹出列表左侧元素
+func (m *MemoryCache) BLPopCtx(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error) {
+	return m.hub.blockingPop(ctx, timeout, keys, m.buildKey, m.LPopCtx)
+}
 
-	// 获取有序集合
-	val, ok := m.data[fullKey]
-	if !ok {
-		return 0, nil
+// BRPopCtx 阻塞弹出列表右侧元素
+func (m *MemoryCache) BRPopCtx(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error) {
+	return m.hub.blockingPop(ctx, timeout, keys, m.buildKey, m.RPopCtx)
+}
+
+// Publish 发布消息
+func (m *MemoryCache) Publish(channel string, message interface{}) (int64, error) {
+	return m.PublishCtx(context.Background(), channel, message)
+}
+
+// Subscribe 订阅频道
+func (m *MemoryCache) Subscribe(channels ...string) (Subscription, error) {
+	return m.SubscribeCtx(context.Background(), channels...)
+}
+
+// PSubscribe 按通配符订阅频道
+func (m *MemoryCache) PSubscribe(patterns ...string) (Subscription, error) {
+	return m.PSubscribeCtx(context.Background(), patterns...)
+}
+
+// PublishCtx 发布消息
+func (m *MemoryCache) PublishCtx(ctx context.Context, channel string, message interface{}) (int64, error) {
+	payload, err := stringValue(message)
+	if err != nil {
+		return 0, err
 	}
+	return m.hub.publish(channel, payload), nil
+}
 
-	zset, ok := val.(map[interface{}]float64)
-	if !ok {
-		return 0, ErrTypeMismatch
+// SubscribeCtx 订阅频道
+func (m *MemoryCache) SubscribeCtx(ctx context.Context, channels ...string) (Subscription, error) {
+	return m.hub.subscribe(channels, nil), nil
+}
+
+// PSubscribeCtx 按通配符订阅频道
+func (m *MemoryCache) PSubscribeCtx(ctx context.Context, patterns ...string) (Subscription, error) {
+	return m.hub.subscribe(nil, patterns), nil
+}
+
+// updateStream 在分片锁内访问流
+func (m *MemoryCache) updateStream(key string, create bool, fn func(s *streamData) (bool, error)) error {
+	s, fullKey := m.lockKey(key)
+	defer s.mu.Unlock()
+
+	var stream *streamData
+	item := s.fetch(fullKey, false)
+	if item != nil {
+		var ok bool
+		if stream, ok = item.value.(*streamData); !ok {
+			return ErrTypeMismatch
+		}
+	} else if create {
+		stream = &streamData{}
 	}
 
-	return int64(len(zset)), nil
+	changed, err := fn(stream)
+	if err != nil || !changed {
+		return err
+	}
+	if item == nil {
+		s.put(fullKey, stream, 0, false)
+	} else {
+		s.resize(fullKey, item, int64(len(fullKey))+sizeOf(stream)+memoryItemOverhead)
+	}
+	return nil
 }
 
-// ZScoreCtx 获取有序集合成员分数
-func (m *MemoryCache) ZScoreCtx(ctx context.Context, key, member string) (float64, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+// XAdd 追加流消息
+func (m *MemoryCache) XAdd(stream string, values map[string]interface{}) (string, error) {
+	return m.XAddCtx(context.Background(), stream, values)
+}
 
-	fullKey := m.buildKey(key)
-	m.cleanExpired(fullKey)
+// XLen 获取流的消息数量
+func (m *MemoryCache) XLen(stream string) (int64, error) {
+	return m.XLenCtx(context.Background(), stream)
+}
 
-	// 获取有序集合
-	val, ok := m.data[fullKey]
-	if !ok {
-		return 0, ErrKeyNotFound
+// XRead 读取流
+func (m *MemoryCache) XRead(args XReadArgs) ([]XStream, error) {
+	return m.XReadCtx(context.Background(), args)
+}
+
+// XGroupCreate 创建消费组
+func (m *MemoryCache) XGroupCreate(stream, group, start string) error {
+	return m.XGroupCreateCtx(context.Background(), stream, group, start)
+}
+
+// XReadGroup 按消费组读取流
+func (m *MemoryCache) XReadGroup(args XReadGroupArgs) ([]XStream, error) {
+	return m.XReadGroupCtx(context.Background(), args)
+}
+
+// XAck 确认流消息
+func (m *MemoryCache) XAck(stream, group string, ids ...string) (int64, error) {
+	return m.XAckCtx(context.Background(), stream, group, ids...)
+}
+
+// XAddCtx 追加流消息
+func (m *MemoryCache) XAddCtx(ctx context.Context, stream string, values map[string]interface{}) (string, error) {
+	return m.streams.add(ctx, stream, values)
+}
+
+// XLenCtx 获取流的消息数量
+func (m *MemoryCache) XLenCtx(ctx context.Context, stream string) (int64, error) {
+	return m.streams.len(ctx, stream)
+}
+
+// XReadCtx 读取流
+func (m *MemoryCache) XReadCtx(ctx context.Context, args XReadArgs) ([]XStream, error) {
+	return m.streams.read(ctx, args)
+}
+
+// XGroupCreateCtx 创建消费组
+func (m *MemoryCache) XGroupCreateCtx(ctx context.Context, stream, group, start string) error {
+	return m.streams.createGroup(ctx, stream, group, start)
+}
+
+// XReadGroupCtx 按消费组读取流
+func (m *MemoryCache) XReadGroupCtx(ctx context.Context, args XReadGroupArgs) ([]XStream, error) {
+	return m.streams.readGroup(ctx, args)
+}
+
+// XAckCtx 确认流消息
+func (m *MemoryCache) XAckCtx(ctx context.Context, stream, group string, ids ...string) (int64, error) {
+	return m.streams.ack(ctx, stream, group, ids)
+}
+
+// Pipeline 创建命令队列，队列中的命令在同一次加锁中执行
+func (m *MemoryCache) Pipeline() Pipeliner {
+	return &localPipeline{exec: m.execPipeline}
+}
+
+// TxPipeline 创建事务命令队列，内存缓存的 Pipeline 本身就是原子的，两者相同
+func (m *MemoryCache) TxPipeline() Pipeliner {
+	return m.Pipeline()
+}
+
+// execPipeline 对所有键所在的分片加锁后执行命令
+func (m *MemoryCache) execPipeline(keys []string, run func(t pipeTx)) error {
+	defer m.lockKeys(keys)()
+	run(memoryTx{m: m})
+	return nil
+}
+
+// memoryTx 在已加锁的分片上执行管道命令
+type memoryTx struct {
+	m *MemoryCache
+}
+
+// shard 返回键所在的分片和完整键名，调用方已持有分片的锁
+func (t memoryTx) shard(key string) (*memoryShard, string) {
+	fullKey := t.m.buildKey(key)
+	return t.m.shard(fullKey), fullKey
+}
+
+func (t memoryTx) get(key string) (string, error) {
+	s, fullKey := t.shard(key)
+	return s.getString(fullKey)
+}
+
+func (t memoryTx) set(key string, value interface{}, expiration time.Duration) error {
+	str, err := stringValue(value)
+	if err != nil {
+		return err
 	}
+	s, fullKey := t.shard(key)
+	s.put(fullKey, str, expiresAt(expiration), true)
+	return nil
+}
 
-	zset, ok := val.(map[interface{}]float64)
-	if !ok {
-		return 0, ErrTypeMismatch
+func (t memoryTx) del(key string) (bool, error) {
+	s, fullKey := t.shard(key)
+	return s.lookup(fullKey) != nil && s.delete(fullKey), nil
+}
+
+func (t memoryTx) exists(key string) (bool, error) {
+	s, fullKey := t.shard(key)
+	return s.lookup(fullKey) != nil, nil
+}
+
+func (t memoryTx) expire(key string, expiration time.Duration) error {
+	s, fullKey := t.shard(key)
+	s.expire(fullKey, expiration)
+	return nil
+}
+
+func (t memoryTx) incrBy(key string, value int64) (int64, error) {
+	s, fullKey := t.shard(key)
+	return s.incrBy(fullKey, value)
+}
+
+func (t memoryTx) hget(key, field string) (string, error) {
+	s, fullKey := t.shard(key)
+	return s.hget(fullKey, field)
+}
+
+func (t memoryTx) hset(key string, values []interface{}) (int64, error) {
+	fields, err := hashFields(values)
+	if err != nil {
+		return 0, err
 	}
+	s, fullKey := t.shard(key)
+	return s.hset(fullKey, fields)
+}
 
-	score, ok := zset[member]
-	if !ok {
-		return 0, ErrKeyNotFound
+func (t memoryTx) hdel(key string, fields []string) (int64, error) {
+	s, fullKey := t.shard(key)
+	return s.hdel(fullKey, fields)
+}
+
+func (t memoryTx) push(key string, values []interface{}, left bool) (int64, error) {
+	elements, err := listValues(values)
+	if err != nil {
+		return 0, err
 	}
+	s, fullKey := t.shard(key)
+	length, err := s.push(fullKey, elements, left)
+	if err == nil {
+		t.m.hub.signal(fullKey)
+	}
+	return length, err
+}
 
-	return score, nil
+func (t memoryTx) sadd(key string, members []interface{}) (int64, error) {
+	s, fullKey := t.shard(key)
+	return s.sadd(fullKey, members)
+}
+
+func (t memoryTx) srem(key string, members []interface{}) (int64, error) {
+	s, fullKey := t.shard(key)
+	return s.srem(fullKey, members)
+}
+
+func (t memoryTx) zadd(key string, members []Z) (int64, error) {
+	s, fullKey := t.shard(key)
+	return s.zadd(fullKey, members)
+}
+
+func (t memoryTx) zrem(key string, members []interface{}) (int64, error) {
+	s, fullKey := t.shard(key)
+	return s.zrem(fullKey, members)
+}
+
+func (t memoryTx) zincrBy(key string, increment float64, member string) (float64, error) {
+	s, fullKey := t.shard(key)
+	return s.zincrBy(fullKey, increment, member)
 }
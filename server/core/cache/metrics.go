@@ -0,0 +1,899 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LatencyBuckets 延迟直方图各个区间的上限，超过最后一个上限的调用计入最后一个区间
+var LatencyBuckets = []time.Duration{
+	100 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+// Stats 缓存调用统计和占用
+type Stats struct {
+	Since    time.Time               `json:"since"`    // 开始统计的时间，Reset 后重新开始
+	Hits     int64                   `json:"hits"`     // Get、HGet 和 MGet 中每个键的命中次数
+	Misses   int64                   `json:"misses"`   // Get、HGet 和 MGet 中每个键的未命中次数
+	HitRate  float64                 `json:"hitRate"`  // 命中率，没有读取时为 0
+	Commands map[string]CommandStats `json:"commands"` // 按命令统计，命令名为小写的方法名，如 get、zrangebyscore
+	Usage    Usage                   `json:"usage"`    // 键数量和占用
+}
+
+// CommandStats 单个命令的调用统计
+type CommandStats struct {
+	Calls   int64         `json:"calls"`   // 调用次数
+	Errors  int64         `json:"errors"`  // 失败次数，不含键不存在
+	Total   time.Duration `json:"total"`   // 累计耗时
+	Max     time.Duration `json:"max"`     // 最长耗时
+	Buckets []int64       `json:"buckets"` // 各个延迟区间的调用次数，区间上限见 LatencyBuckets，最后一个为超过所有上限的次数
+}
+
+// Usage 缓存的键数量和占用
+type Usage struct {
+	Backend string  `json:"backend"`          // memory、file、redis 或 tiered
+	Keys    int64   `json:"keys"`             // 键数量，Redis 为当前数据库的全部键，不区分前缀
+	Bytes   int64   `json:"bytes"`            // 内存缓存为估算的占用，文件缓存为数据大小，Redis 为 used_memory，无法获取时为 -1
+	Levels  []Usage `json:"levels,omitempty"` // 两级缓存的一级和二级缓存
+}
+
+// usageReporter 能够报告键数量和占用的缓存
+type usageReporter interface {
+	usage(ctx context.Context) (Usage, error)
+}
+
+// UsageOf 返回缓存的键数量和占用，依次解开统计和前缀的包装；不支持的缓存返回只有 Backend 的结果
+func UsageOf(ctx context.Context, c Cache) (Usage, error) {
+	for {
+		switch w := c.(type) {
+		case usageReporter:
+			return w.usage(ctx)
+		case *InstrumentedCache:
+			c = w.Cache
+		case *prefixCache:
+			c = w.Cache
+		default:
+			return Usage{Backend: "unknown", Keys: -1, Bytes: -1}, nil
+		}
+	}
+}
+
+// commandMetrics 单个命令的计数器
+type commandMetrics struct {
+	calls, errors, total, max atomic.Int64
+	buckets                   []atomic.Int64
+}
+
+// observe 记录一次调用
+func (m *commandMetrics) observe(elapsed time.Duration, failed bool) {
+	m.calls.Add(1)
+	if failed {
+		m.errors.Add(1)
+	}
+	m.total.Add(int64(elapsed))
+	for {
+		current := m.max.Load()
+		if int64(elapsed) <= current || m.max.CompareAndSwap(current, int64(elapsed)) {
+			break
+		}
+	}
+
+	i := 0
+	for i < len(LatencyBuckets) && elapsed > LatencyBuckets[i] {
+		i++
+	}
+	m.buckets[i].Add(1)
+}
+
+// snapshot 返回当前的统计
+func (m *commandMetrics) snapshot() CommandStats {
+	stats := CommandStats{
+		Calls:   m.calls.Load(),
+		Errors:  m.errors.Load(),
+		Total:   time.Duration(m.total.Load()),
+		Max:     time.Duration(m.max.Load()),
+		Buckets: make([]int64, len(m.buckets)),
+	}
+	for i := range m.buckets {
+		stats.Buckets[i] = m.buckets[i].Load()
+	}
+	return stats
+}
+
+// InstrumentedCache 统计调用次数、耗时、错误和命中率的缓存包装，cache.metrics 开启时由 New 创建
+//
+// 阻塞命令（BLPop、BRPop、XRead 等）的耗时包含等待时间；管道按 pipeline、txpipeline 统计 Exec 的耗时。
+type InstrumentedCache struct {
+	Cache
+	mu       sync.Mutex
+	since    time.Time
+	commands sync.Map // 命令名 -> *commandMetrics
+	hits     atomic.Int64
+	misses   atomic.Int64
+}
+
+// Instrument 返回统计调用情况的缓存包装
+func Instrument(c Cache) *InstrumentedCache {
+	return &InstrumentedCache{Cache: c, since: time.Now()}
+}
+
+// Unwrap 返回被包装的缓存
+func (c *InstrumentedCache) Unwrap() Cache {
+	return c.Cache
+}
+
+// Unwrap 去掉统计包装，返回实际的缓存，用于断言具体的缓存类型，如 *cache.FileCache
+func Unwrap(c Cache) Cache {
+	if w, ok := c.(*InstrumentedCache); ok {
+		return w.Cache
+	}
+	return c
+}
+
+// Stats 返回调用统计以及键数量和占用，占用的统计可能需要遍历数据（如文件缓存）
+func (c *InstrumentedCache) Stats(ctx context.Context) (Stats, error) {
+	c.mu.Lock()
+	since := c.since
+	c.mu.Unlock()
+
+	stats := Stats{
+		Since:    since,
+		Hits:     c.hits.Load(),
+		Misses:   c.misses.Load(),
+		Commands: make(map[string]CommandStats),
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	c.commands.Range(func(name, m any) bool {
+		stats.Commands[name.(string)] = m.(*commandMetrics).snapshot()
+		return true
+	})
+
+	usage, err := UsageOf(ctx, c.Cache)
+	stats.Usage = usage
+	return stats, err
+}
+
+// Reset 清空调用统计
+func (c *InstrumentedCache) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.since = time.Now()
+	c.commands.Clear()
+	c.hits.Store(0)
+	c.misses.Store(0)
+}
+
+// record 记录一次调用，键不存在不计为失败
+func (c *InstrumentedCache) record(name string, began time.Time, err error) {
+	m, ok := c.commands.Load(name)
+	if !ok {
+		m, _ = c.commands.LoadOrStore(name, &commandMetrics{buckets: make([]atomic.Int64, len(LatencyBuckets)+1)})
+	}
+	m.(*commandMetrics).observe(time.Since(began), err != nil && !errors.Is(err, ErrKeyNotFound))
+}
+
+// lookup 记录一次读取是否命中
+func (c *InstrumentedCache) lookup(err error) {
+	if err == nil {
+		c.hits.Add(1)
+	} else if errors.Is(err, ErrKeyNotFound) {
+		c.misses.Add(1)
+	}
+}
+
+func (c *InstrumentedCache) Get(key string) (string, error) {
+	return c.GetCtx(context.Background(), key)
+}
+
+func (c *InstrumentedCache) Set(key string, value interface{}, expiration time.Duration) error {
+	return c.SetCtx(context.Background(), key, value, expiration)
+}
+
+func (c *InstrumentedCache) Del(keys ...string) (int64, error) {
+	return c.DelCtx(context.Background(), keys...)
+}
+
+func (c *InstrumentedCache) Exists(keys ...string) (int64, error) {
+	return c.ExistsCtx(context.Background(), keys...)
+}
+
+func (c *InstrumentedCache) Expire(key string, expiration time.Duration) error {
+	return c.ExpireCtx(context.Background(), key, expiration)
+}
+
+func (c *InstrumentedCache) TTL(key string) (time.Duration, error) {
+	return c.TTLCtx(context.Background(), key)
+}
+
+func (c *InstrumentedCache) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	return c.SetNXCtx(context.Background(), key, value, expiration)
+}
+
+func (c *InstrumentedCache) CompareAndDel(key, value string) (bool, error) {
+	return c.CompareAndDelCtx(context.Background(), key, value)
+}
+
+func (c *InstrumentedCache) CompareAndExpire(key, value string, expiration time.Duration) (bool, error) {
+	return c.CompareAndExpireCtx(context.Background(), key, value, expiration)
+}
+
+func (c *InstrumentedCache) GetSet(key string, value interface{}) (string, error) {
+	return c.GetSetCtx(context.Background(), key, value)
+}
+
+func (c *InstrumentedCache) MGet(keys ...string) ([]interface{}, error) {
+	return c.MGetCtx(context.Background(), keys...)
+}
+
+func (c *InstrumentedCache) MSet(values ...interface{}) error {
+	return c.MSetCtx(context.Background(), values...)
+}
+
+func (c *InstrumentedCache) Persist(key string) (bool, error) {
+	return c.PersistCtx(context.Background(), key)
+}
+
+func (c *InstrumentedCache) Rename(key, newKey string) error {
+	return c.RenameCtx(context.Background(), key, newKey)
+}
+
+func (c *InstrumentedCache) Incr(key string) (int64, error) {
+	return c.IncrCtx(context.Background(), key)
+}
+
+func (c *InstrumentedCache) Decr(key string) (int64, error) {
+	return c.DecrCtx(context.Background(), key)
+}
+
+func (c *InstrumentedCache) IncrBy(key string, value int64) (int64, error) {
+	return c.IncrByCtx(context.Background(), key, value)
+}
+
+func (c *InstrumentedCache) HGet(key, field string) (string, error) {
+	return c.HGetCtx(context.Background(), key, field)
+}
+
+func (c *InstrumentedCache) HSet(key string, values ...interface{}) (int64, error) {
+	return c.HSetCtx(context.Background(), key, values...)
+}
+
+func (c *InstrumentedCache) HDel(key string, fields ...string) (int64, error) {
+	return c.HDelCtx(context.Background(), key, fields...)
+}
+
+func (c *InstrumentedCache) HGetAll(key string) (map[string]string, error) {
+	return c.HGetAllCtx(context.Background(), key)
+}
+
+func (c *InstrumentedCache) HExists(key, field string) (bool, error) {
+	return c.HExistsCtx(context.Background(), key, field)
+}
+
+func (c *InstrumentedCache) HLen(key string) (int64, error) {
+	return c.HLenCtx(context.Background(), key)
+}
+
+func (c *InstrumentedCache) LPush(key string, values ...interface{}) (int64, error) {
+	return c.LPushCtx(context.Background(), key, values...)
+}
+
+func (c *InstrumentedCache) RPush(key string, values ...interface{}) (int64, error) {
+	return c.RPushCtx(context.Background(), key, values...)
+}
+
+func (c *InstrumentedCache) LPop(key string) (string, error) {
+	return c.LPopCtx(context.Background(), key)
+}
+
+func (c *InstrumentedCache) RPop(key string) (string, error) {
+	return c.RPopCtx(context.Background(), key)
+}
+
+func (c *InstrumentedCache) LLen(key string) (int64, error) {
+	return c.LLenCtx(context.Background(), key)
+}
+
+func (c *InstrumentedCache) LRange(key string, start, stop int64) ([]string, error) {
+	return c.LRangeCtx(context.Background(), key, start, stop)
+}
+
+func (c *InstrumentedCache) SAdd(key string, members ...interface{}) (int64, error) {
+	return c.SAddCtx(context.Background(), key, members...)
+}
+
+func (c *InstrumentedCache) SRem(key string, members ...interface{}) (int64, error) {
+	return c.SRemCtx(context.Background(), key, members...)
+}
+
+func (c *InstrumentedCache) SMembers(key string) ([]string, error) {
+	return c.SMembersCtx(context.Background(), key)
+}
+
+func (c *InstrumentedCache) SIsMember(key string, member interface{}) (bool, error) {
+	return c.SIsMemberCtx(context.Background(), key, member)
+}
+
+func (c *InstrumentedCache) SCard(key string) (int64, error) {
+	return c.SCardCtx(context.Background(), key)
+}
+
+func (c *InstrumentedCache) ZAdd(key string, members ...Z) (int64, error) {
+	return c.ZAddCtx(context.Background(), key, members...)
+}
+
+func (c *InstrumentedCache) ZRem(key string, members ...interface{}) (int64, error) {
+	return c.ZRemCtx(context.Background(), key, members...)
+}
+
+func (c *InstrumentedCache) ZRange(key string, start, stop int64) ([]string, error) {
+	return c.ZRangeCtx(context.Background(), key, start, stop)
+}
+
+func (c *InstrumentedCache) ZRangeWithScores(key string, start, stop int64) ([]Z, error) {
+	return c.ZRangeWithScoresCtx(context.Background(), key, start, stop)
+}
+
+func (c *InstrumentedCache) ZCard(key string) (int64, error) {
+	return c.ZCardCtx(context.Background(), key)
+}
+
+func (c *InstrumentedCache) ZScore(key, member string) (float64, error) {
+	return c.ZScoreCtx(context.Background(), key, member)
+}
+
+func (c *InstrumentedCache) ZRangeByScore(key string, opt ZRangeBy) ([]string, error) {
+	return c.ZRangeByScoreCtx(context.Background(), key, opt)
+}
+
+func (c *InstrumentedCache) ZRevRange(key string, start, stop int64) ([]string, error) {
+	return c.ZRevRangeCtx(context.Background(), key, start, stop)
+}
+
+func (c *InstrumentedCache) ZIncrBy(key string, increment float64, member string) (float64, error) {
+	return c.ZIncrByCtx(context.Background(), key, increment, member)
+}
+
+func (c *InstrumentedCache) ZRank(key, member string) (int64, error) {
+	return c.ZRankCtx(context.Background(), key, member)
+}
+
+func (c *InstrumentedCache) ZRemRangeByScore(key, min, max string) (int64, error) {
+	return c.ZRemRangeByScoreCtx(context.Background(), key, min, max)
+}
+
+func (c *InstrumentedCache) ZCount(key, min, max string) (int64, error) {
+	return c.ZCountCtx(context.Background(), key, min, max)
+}
+
+func (c *InstrumentedCache) BLPop(timeout time.Duration, keys ...string) ([]string, error) {
+	return c.BLPopCtx(context.Background(), timeout, keys...)
+}
+
+func (c *InstrumentedCache) BRPop(timeout time.Duration, keys ...string) ([]string, error) {
+	return c.BRPopCtx(context.Background(), timeout, keys...)
+}
+
+func (c *InstrumentedCache) Publish(channel string, message interface{}) (int64, error) {
+	return c.PublishCtx(context.Background(), channel, message)
+}
+
+func (c *InstrumentedCache) Subscribe(channels ...string) (Subscription, error) {
+	return c.SubscribeCtx(context.Background(), channels...)
+}
+
+func (c *InstrumentedCache) PSubscribe(patterns ...string) (Subscription, error) {
+	return c.PSubscribeCtx(context.Background(), patterns...)
+}
+
+func (c *InstrumentedCache) XAdd(stream string, values map[string]interface{}) (string, error) {
+	return c.XAddCtx(context.Background(), stream, values)
+}
+
+func (c *InstrumentedCache) XLen(stream string) (int64, error) {
+	return c.XLenCtx(context.Background(), stream)
+}
+
+func (c *InstrumentedCache) XRead(args XReadArgs) ([]XStream, error) {
+	return c.XReadCtx(context.Background(), args)
+}
+
+func (c *InstrumentedCache) XGroupCreate(stream, group, start string) error {
+	return c.XGroupCreateCtx(context.Background(), stream, group, start)
+}
+
+func (c *InstrumentedCache) XReadGroup(args XReadGroupArgs) ([]XStream, error) {
+	return c.XReadGroupCtx(context.Background(), args)
+}
+
+func (c *InstrumentedCache) XAck(stream, group string, ids ...string) (int64, error) {
+	return c.XAckCtx(context.Background(), stream, group, ids...)
+}
+
+func (c *InstrumentedCache) Keys(pattern string) ([]string, error) {
+	return c.KeysCtx(context.Background(), pattern)
+}
+
+func (c *InstrumentedCache) Scan(cursor uint64, match string, count int64) ([]string, uint64, error) {
+	return c.ScanCtx(context.Background(), cursor, match, count)
+}
+
+func (c *InstrumentedCache) Type(key string) (string, error) {
+	return c.TypeCtx(context.Background(), key)
+}
+
+func (c *InstrumentedCache) Ping() error {
+	return c.PingCtx(context.Background())
+}
+
+func (c *InstrumentedCache) Pipeline() Pipeliner {
+	return &instrumentedPipeline{Pipeliner: c.Cache.Pipeline(), c: c, name: "pipeline"}
+}
+
+func (c *InstrumentedCache) TxPipeline() Pipeliner {
+	return &instrumentedPipeline{Pipeliner: c.Cache.TxPipeline(), c: c, name: "txpipeline"}
+}
+
+// instrumentedPipeline 统计 Exec 耗时的命令队列
+type instrumentedPipeline struct {
+	Pipeliner
+	c    *InstrumentedCache
+	name string
+}
+
+func (p *instrumentedPipeline) Exec(ctx context.Context) ([]*Cmd, error) {
+	began := time.Now()
+	cmds, err := p.Pipeliner.Exec(ctx)
+	p.c.record(p.name, began, err)
+	return cmds, err
+}
+
+func (c *InstrumentedCache) GetCtx(ctx context.Context, key string) (string, error) {
+	began := time.Now()
+	v, err := c.Cache.GetCtx(ctx, key)
+	c.record("get", began, err)
+	c.lookup(err)
+	return v, err
+}
+
+func (c *InstrumentedCache) HGetCtx(ctx context.Context, key, field string) (string, error) {
+	began := time.Now()
+	v, err := c.Cache.HGetCtx(ctx, key, field)
+	c.record("hget", began, err)
+	c.lookup(err)
+	return v, err
+}
+
+func (c *InstrumentedCache) MGetCtx(ctx context.Context, keys ...string) ([]interface{}, error) {
+	began := time.Now()
+	values, err := c.Cache.MGetCtx(ctx, keys...)
+	c.record("mget", began, err)
+	if err == nil {
+		for _, v := range values {
+			if v == nil {
+				c.misses.Add(1)
+			} else {
+				c.hits.Add(1)
+			}
+		}
+	}
+	return values, err
+}
+
+func (c *InstrumentedCache) SetCtx(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	began := time.Now()
+	err := c.Cache.SetCtx(ctx, key, value, expiration)
+	c.record("set", began, err)
+	return err
+}
+
+func (c *InstrumentedCache) DelCtx(ctx context.Context, keys ...string) (int64, error) {
+	began := time.Now()
+	n, err := c.Cache.DelCtx(ctx, keys...)
+	c.record("del", began, err)
+	return n, err
+}
+
+func (c *InstrumentedCache) ExistsCtx(ctx context.Context, keys ...string) (int64, error) {
+	began := time.Now()
+	n, err := c.Cache.ExistsCtx(ctx, keys...)
+	c.record("exists", began, err)
+	return n, err
+}
+
+func (c *InstrumentedCache) ExpireCtx(ctx context.Context, key string, expiration time.Duration) error {
+	began := time.Now()
+	err := c.Cache.ExpireCtx(ctx, key, expiration)
+	c.record("expire", began, err)
+	return err
+}
+
+func (c *InstrumentedCache) TTLCtx(ctx context.Context, key string) (time.Duration, error) {
+	began := time.Now()
+	ttl, err := c.Cache.TTLCtx(ctx, key)
+	c.record("ttl", began, err)
+	return ttl, err
+}
+
+func (c *InstrumentedCache) SetNXCtx(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	began := time.Now()
+	ok, err := c.Cache.SetNXCtx(ctx, key, value, expiration)
+	c.record("setnx", began, err)
+	return ok, err
+}
+
+func (c *InstrumentedCache) CompareAndDelCtx(ctx context.Context, key, value string) (bool, error) {
+	began := time.Now()
+	ok, err := c.Cache.CompareAndDelCtx(ctx, key, value)
+	c.record("compareanddel", began, err)
+	return ok, err
+}
+
+func (c *InstrumentedCache) CompareAndExpireCtx(ctx context.Context, key, value string, expiration time.Duration) (bool, error) {
+	began := time.Now()
+	ok, err := c.Cache.CompareAndExpireCtx(ctx, key, value, expiration)
+	c.record("compareandexpire", began, err)
+	return ok, err
+}
+
+func (c *InstrumentedCache) GetSetCtx(ctx context.Context, key string, value interface{}) (string, error) {
+	began := time.Now()
+	v, err := c.Cache.GetSetCtx(ctx, key, value)
+	c.record("getset", began, err)
+	return v, err
+}
+
+func (c *InstrumentedCache) MSetCtx(ctx context.Context, values ...interface{}) error {
+	began := time.Now()
+	err := c.Cache.MSetCtx(ctx, values...)
+	c.record("mset", began, err)
+	return err
+}
+
+func (c *InstrumentedCache) PersistCtx(ctx context.Context, key string) (bool, error) {
+	began := time.Now()
+	ok, err := c.Cache.PersistCtx(ctx, key)
+	c.record("persist", began, err)
+	return ok, err
+}
+
+func (c *InstrumentedCache) RenameCtx(ctx context.Context, key, newKey string) error {
+	began := time.Now()
+	err := c.Cache.RenameCtx(ctx, key, newKey)
+	c.record("rename", began, err)
+	return err
+}
+
+func (c *InstrumentedCache) IncrCtx(ctx context.Context, key string) (int64, error) {
+	began := time.Now()
+	n, err := c.Cache.IncrCtx(ctx, key)
+	c.record("incr", began, err)
+	return n, err
+}
+
+func (c *InstrumentedCache) DecrCtx(ctx context.Context, key string) (int64, error) {
+	began := time.Now()
+	n, err := c.Cache.DecrCtx(ctx, key)
+	c.record("decr", began, err)
+	return n, err
+}
+
+func (c *InstrumentedCache) IncrByCtx(ctx context.Context, key string, value int64) (int64, error) {
+	began := time.Now()
+	n, err := c.Cache.IncrByCtx(ctx, key, value)
+	c.record("incrby", began, err)
+	return n, err
+}
+
+func (c *InstrumentedCache) HSetCtx(ctx context.Context, key string, values ...interface{}) (int64, error) {
+	began := time.Now()
+	n, err := c.Cache.HSetCtx(ctx, key, values...)
+	c.record("hset", began, err)
+	return n, err
+}
+
+func (c *InstrumentedCache) HDelCtx(ctx context.Context, key string, fields ...string) (int64, error) {
+	began := time.Now()
+	n, err := c.Cache.HDelCtx(ctx, key, fields...)
+	c.record("hdel", began, err)
+	return n, err
+}
+
+func (c *InstrumentedCache) HGetAllCtx(ctx context.Context, key string) (map[string]string, error) {
+	began := time.Now()
+	values, err := c.Cache.HGetAllCtx(ctx, key)
+	c.record("hgetall", began, err)
+	return values, err
+}
+
+func (c *InstrumentedCache) HExistsCtx(ctx context.Context, key, field string) (bool, error) {
+	began := time.Now()
+	ok, err := c.Cache.HExistsCtx(ctx, key, field)
+	c.record("hexists", began, err)
+	return ok, err
+}
+
+func (c *InstrumentedCache) HLenCtx(ctx context.Context, key string) (int64, error) {
+	began := time.Now()
+	n, err := c.Cache.HLenCtx(ctx, key)
+	c.record("hlen", began, err)
+	return n, err
+}
+
+func (c *InstrumentedCache) LPushCtx(ctx context.Context, key string, values ...interface{}) (int64, error) {
+	began := time.Now()
+	n, err := c.Cache.LPushCtx(ctx, key, values...)
+	c.record("lpush", began, err)
+	return n, err
+}
+
+func (c *InstrumentedCache) RPushCtx(ctx context.Context, key string, values ...interface{}) (int64, error) {
+	began := time.Now()
+	n, err := c.Cache.RPushCtx(ctx, key, values...)
+	c.record("rpush", began, err)
+	return n, err
+}
+
+func (c *InstrumentedCache) LPopCtx(ctx context.Context, key string) (string, error) {
+	began := time.Now()
+	v, err := c.Cache.LPopCtx(ctx, key)
+	c.record("lpop", began, err)
+	return v, err
+}
+
+func (c *InstrumentedCache) RPopCtx(ctx context.Context, key string) (string, error) {
+	began := time.Now()
+	v, err := c.Cache.RPopCtx(ctx, key)
+	c.record("rpop", began, err)
+	return v, err
+}
+
+func (c *InstrumentedCache) LLenCtx(ctx context.Context, key string) (int64, error) {
+	began := time.Now()
+	n, err := c.Cache.LLenCtx(ctx, key)
+	c.record("llen", began, err)
+	return n, err
+}
+
+func (c *InstrumentedCache) LRangeCtx(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	began := time.Now()
+	values, err := c.Cache.LRangeCtx(ctx, key, start, stop)
+	c.record("lrange", began, err)
+	return values, err
+}
+
+func (c *InstrumentedCache) SAddCtx(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	began := time.Now()
+	n, err := c.Cache.SAddCtx(ctx, key, members...)
+	c.record("sadd", began, err)
+	return n, err
+}
+
+func (c *InstrumentedCache) SRemCtx(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	began := time.Now()
+	n, err := c.Cache.SRemCtx(ctx, key, members...)
+	c.record("srem", began, err)
+	return n, err
+}
+
+func (c *InstrumentedCache) SMembersCtx(ctx context.Context, key string) ([]string, error) {
+	began := time.Now()
+	values, err := c.Cache.SMembersCtx(ctx, key)
+	c.record("smembers", began, err)
+	return values, err
+}
+
+func (c *InstrumentedCache) SIsMemberCtx(ctx context.Context, key string, member interface{}) (bool, error) {
+	began := time.Now()
+	ok, err := c.Cache.SIsMemberCtx(ctx, key, member)
+	c.record("sismember", began, err)
+	return ok, err
+}
+
+func (c *InstrumentedCache) SCardCtx(ctx context.Context, key string) (int64, error) {
+	began := time.Now()
+	n, err := c.Cache.SCardCtx(ctx, key)
+	c.record("scard", began, err)
+	return n, err
+}
+
+func (c *InstrumentedCache) ZAddCtx(ctx context.Context, key string, members ...Z) (int64, error) {
+	began := time.Now()
+	n, err := c.Cache.ZAddCtx(ctx, key, members...)
+	c.record("zadd", began, err)
+	return n, err
+}
+
+func (c *InstrumentedCache) ZRemCtx(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	began := time.Now()
+	n, err := c.Cache.ZRemCtx(ctx, key, members...)
+	c.record("zrem", began, err)
+	return n, err
+}
+
+func (c *InstrumentedCache) ZRangeCtx(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	began := time.Now()
+	values, err := c.Cache.ZRangeCtx(ctx, key, start, stop)
+	c.record("zrange", began, err)
+	return values, err
+}
+
+func (c *InstrumentedCache) ZRangeWithScoresCtx(ctx context.Context, key string, start, stop int64) ([]Z, error) {
+	began := time.Now()
+	members, err := c.Cache.ZRangeWithScoresCtx(ctx, key, start, stop)
+	c.record("zrangewithscores", began, err)
+	return members, err
+}
+
+func (c *InstrumentedCache) ZCardCtx(ctx context.Context, key string) (int64, error) {
+	began := time.Now()
+	n, err := c.Cache.ZCardCtx(ctx, key)
+	c.record("zcard", began, err)
+	return n, err
+}
+
+func (c *InstrumentedCache) ZScoreCtx(ctx context.Context, key, member string) (float64, error) {
+	began := time.Now()
+	f, err := c.Cache.ZScoreCtx(ctx, key, member)
+	c.record("zscore", began, err)
+	return f, err
+}
+
+func (c *InstrumentedCache) ZRangeByScoreCtx(ctx context.Context, key string, opt ZRangeBy) ([]string, error) {
+	began := time.Now()
+	values, err := c.Cache.ZRangeByScoreCtx(ctx, key, opt)
+	c.record("zrangebyscore", began, err)
+	return values, err
+}
+
+func (c *InstrumentedCache) ZRevRangeCtx(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	began := time.Now()
+	values, err := c.Cache.ZRevRangeCtx(ctx, key, start, stop)
+	c.record("zrevrange", began, err)
+	return values, err
+}
+
+func (c *InstrumentedCache) ZIncrByCtx(ctx context.Context, key string, increment float64, member string) (float64, error) {
+	began := time.Now()
+	f, err := c.Cache.ZIncrByCtx(ctx, key, increment, member)
+	c.record("zincrby", began, err)
+	return f, err
+}
+
+func (c *InstrumentedCache) ZRankCtx(ctx context.Context, key, member string) (int64, error) {
+	began := time.Now()
+	n, err := c.Cache.ZRankCtx(ctx, key, member)
+	c.record("zrank", began, err)
+	return n, err
+}
+
+func (c *InstrumentedCache) ZRemRangeByScoreCtx(ctx context.Context, key, min, max string) (int64, error) {
+	began := time.Now()
+	n, err := c.Cache.ZRemRangeByScoreCtx(ctx, key, min, max)
+	c.record("zremrangebyscore", began, err)
+	return n, err
+}
+
+func (c *InstrumentedCache) ZCountCtx(ctx context.Context, key, min, max string) (int64, error) {
+	began := time.Now()
+	n, err := c.Cache.ZCountCtx(ctx, key, min, max)
+	c.record("zcount", began, err)
+	return n, err
+}
+
+func (c *InstrumentedCache) BLPopCtx(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error) {
+	began := time.Now()
+	values, err := c.Cache.BLPopCtx(ctx, timeout, keys...)
+	c.record("blpop", began, err)
+	return values, err
+}
+
+func (c *InstrumentedCache) BRPopCtx(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error) {
+	began := time.Now()
+	values, err := c.Cache.BRPopCtx(ctx, timeout, keys...)
+	c.record("brpop", began, err)
+	return values, err
+}
+
+func (c *InstrumentedCache) PublishCtx(ctx context.Context, channel string, message interface{}) (int64, error) {
+	began := time.Now()
+	n, err := c.Cache.PublishCtx(ctx, channel, message)
+	c.record("publish", began, err)
+	return n, err
+}
+
+func (c *InstrumentedCache) SubscribeCtx(ctx context.Context, channels ...string) (Subscription, error) {
+	began := time.Now()
+	sub, err := c.Cache.SubscribeCtx(ctx, channels...)
+	c.record("subscribe", began, err)
+	return sub, err
+}
+
+func (c *InstrumentedCache) PSubscribeCtx(ctx context.Context, patterns ...string) (Subscription, error) {
+	began := time.Now()
+	sub, err := c.Cache.PSubscribeCtx(ctx, patterns...)
+	c.record("psubscribe", began, err)
+	return sub, err
+}
+
+func (c *InstrumentedCache) XAddCtx(ctx context.Context, stream string, values map[string]interface{}) (string, error) {
+	began := time.Now()
+	v, err := c.Cache.XAddCtx(ctx, stream, values)
+	c.record("xadd", began, err)
+	return v, err
+}
+
+func (c *InstrumentedCache) XLenCtx(ctx context.Context, stream string) (int64, error) {
+	began := time.Now()
+	n, err := c.Cache.XLenCtx(ctx, stream)
+	c.record("xlen", began, err)
+	return n, err
+}
+
+func (c *InstrumentedCache) XReadCtx(ctx context.Context, args XReadArgs) ([]XStream, error) {
+	began := time.Now()
+	streams, err := c.Cache.XReadCtx(ctx, args)
+	c.record("xread", began, err)
+	return streams, err
+}
+
+func (c *InstrumentedCache) XGroupCreateCtx(ctx context.Context, stream, group, start string) error {
+	began := time.Now()
+	err := c.Cache.XGroupCreateCtx(ctx, stream, group, start)
+	c.record("xgroupcreate", began, err)
+	return err
+}
+
+func (c *InstrumentedCache) XReadGroupCtx(ctx context.Context, args XReadGroupArgs) ([]XStream, error) {
+	began := time.Now()
+	streams, err := c.Cache.XReadGroupCtx(ctx, args)
+	c.record("xreadgroup", began, err)
+	return streams, err
+}
+
+func (c *InstrumentedCache) XAckCtx(ctx context.Context, stream, group string, ids ...string) (int64, error) {
+	began := time.Now()
+	n, err := c.Cache.XAckCtx(ctx, stream, group, ids...)
+	c.record("xack", began, err)
+	return n, err
+}
+
+func (c *InstrumentedCache) KeysCtx(ctx context.Context, pattern string) ([]string, error) {
+	began := time.Now()
+	values, err := c.Cache.KeysCtx(ctx, pattern)
+	c.record("keys", began, err)
+	return values, err
+}
+
+func (c *InstrumentedCache) ScanCtx(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	began := time.Now()
+	keys, next, err := c.Cache.ScanCtx(ctx, cursor, match, count)
+	c.record("scan", began, err)
+	return keys, next, err
+}
+
+func (c *InstrumentedCache) TypeCtx(ctx context.Context, key string) (string, error) {
+	began := time.Now()
+	v, err := c.Cache.TypeCtx(ctx, key)
+	c.record("type", began, err)
+	return v, err
+}
+
+func (c *InstrumentedCache) PingCtx(ctx context.Context) error {
+	began := time.Now()
+	err := c.Cache.PingCtx(ctx)
+	c.record("ping", began, err)
+	return err
+}
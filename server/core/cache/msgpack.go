@@ -0,0 +1,970 @@
+package cache
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// msgpackCodec MessagePack 编码，比 JSON 紧凑，数值和二进制数据保持原样
+//
+// 结构体编码为以字段名为键的 map，字段名优先取 msgpack 标签，其次 json 标签，支持 "-" 和 omitempty，
+// 嵌入的结构体字段展开，同名时层级浅的优先；time.Time 使用时间戳扩展类型，解码后为 UTC；
+// 实现了 encoding.BinaryMarshaler 的类型编码为二进制数据。解码到 interface{} 时整数为 int64
+// （超出范围时为 uint64），浮点数为 float64，map 为 map[string]interface{}。
+type msgpackCodec struct{}
+
+func (msgpackCodec) ID() byte     { return 3 }
+func (msgpackCodec) Name() string { return "msgpack" }
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	var e msgpackEncoder
+	if err := e.encode(reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return errors.New("msgpack: 反序列化的目标必须是非空指针")
+	}
+	d := msgpackDecoder{data: data}
+	if err := d.decode(rv.Elem()); err != nil {
+		return err
+	}
+	if d.pos != len(d.data) {
+		return errors.New("msgpack: 数据末尾有多余的字节")
+	}
+	return nil
+}
+
+// msgpackTimeExt 时间戳扩展类型 -1
+const msgpackTimeExt = byte(0xff)
+
+var (
+	timeType              = reflect.TypeOf(time.Time{})
+	binaryMarshalerType   = reflect.TypeOf((*encoding.BinaryMarshaler)(nil)).Elem()
+	binaryUnmarshalerType = reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()
+)
+
+// msgpackField 结构体字段的编码信息
+type msgpackField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+// msgpackFieldCache 结构体类型 -> []msgpackField
+var msgpackFieldCache sync.Map
+
+// structFields 返回结构体参与编码的字段
+func structFields(t reflect.Type) []msgpackField {
+	if cached, ok := msgpackFieldCache.Load(t); ok {
+		return cached.([]msgpackField)
+	}
+
+	var fields []msgpackField
+	var collect func(t reflect.Type, index []int)
+	collect = func(t reflect.Type, index []int) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag, ok := f.Tag.Lookup("msgpack")
+			if !ok {
+				tag = f.Tag.Get("json")
+			}
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			fieldIndex := append(append([]int(nil), index...), i)
+
+			// 未命名的嵌入结构体展开到外层
+			if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct && f.Type != timeType {
+				collect(f.Type, fieldIndex)
+				continue
+			}
+			if !f.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			fields = append(fields, msgpackField{name: name, index: fieldIndex, omitEmpty: strings.Contains(opts, "omitempty")})
+		}
+	}
+	collect(t, nil)
+
+	// 同名字段保留层级浅的
+	sort.SliceStable(fields, func(i, j int) bool { return len(fields[i].index) < len(fields[j].index) })
+	seen := make(map[string]bool, len(fields))
+	unique := fields[:0]
+	for _, f := range fields {
+		if !seen[f.name] {
+			seen[f.name] = true
+			unique = append(unique, f)
+		}
+	}
+	sort.Slice(unique, func(i, j int) bool { return lessIndex(unique[i].index, unique[j].index) })
+
+	msgpackFieldCache.Store(t, unique)
+	return unique
+}
+
+// lessIndex 按字段在结构体中的声明顺序比较
+func lessIndex(a, b []int) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
+
+// isEmptyValue 与 encoding/json 的 omitempty 规则一致
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
+
+// ================== 编码 ==================
+
+// msgpackEncoder MessagePack 编码器
+type msgpackEncoder struct {
+	buf []byte
+}
+
+// encode 编码任意值
+func (e *msgpackEncoder) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		e.buf = append(e.buf, 0xc0)
+		return nil
+	}
+	if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+		e.buf = append(e.buf, 0xc0)
+		return nil
+	}
+	// 指针的方法集包含值的方法，值本身能够编码时解引用，只有指针接收者的 MarshalBinary 在指针上调用
+	if v.Kind() == reflect.Pointer && (v.Type().Elem() == timeType || v.Type().Elem().Implements(binaryMarshalerType)) {
+		return e.encode(v.Elem())
+	}
+	if v.Type() == timeType {
+		e.writeTime(v.Interface().(time.Time))
+		return nil
+	}
+	if v.Kind() != reflect.Interface && v.Type().Implements(binaryMarshalerType) {
+		data, err := v.Interface().(encoding.BinaryMarshaler).MarshalBinary()
+		if err != nil {
+			return fmt.Errorf("msgpack: %s: %w", v.Type(), err)
+		}
+		e.writeBin(data)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return e.encode(v.Elem())
+	case reflect.Bool:
+		if v.Bool() {
+			e.buf = append(e.buf, 0xc3)
+		} else {
+			e.buf = append(e.buf, 0xc2)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.writeInt(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.writeUint(v.Uint())
+	case reflect.Float32:
+		e.buf = append(e.buf, 0xca)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, math.Float32bits(float32(v.Float())))
+	case reflect.Float64:
+		e.buf = append(e.buf, 0xcb)
+		e.buf = binary.BigEndian.AppendUint64(e.buf, math.Float64bits(v.Float()))
+	case reflect.String:
+		e.writeString(v.String())
+	case reflect.Slice:
+		if v.IsNil() {
+			e.buf = append(e.buf, 0xc0)
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.writeBin(v.Bytes())
+			return nil
+		}
+		return e.encodeArray(v)
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			data := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(data), v)
+			e.writeBin(data)
+			return nil
+		}
+		return e.encodeArray(v)
+	case reflect.Map:
+		if v.IsNil() {
+			e.buf = append(e.buf, 0xc0)
+			return nil
+		}
+		return e.encodeMap(v)
+	case reflect.Struct:
+		return e.encodeStruct(v)
+	default:
+		return fmt.Errorf("msgpack: 不支持的类型 %s", v.Type())
+	}
+	return nil
+}
+
+// encodeArray 编码切片或数组
+func (e *msgpackEncoder) encodeArray(v reflect.Value) error {
+	e.writeHeader(v.Len(), 0x90, 0xdc, 0xdd)
+	for i := 0; i < v.Len(); i++ {
+		if err := e.encode(v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeMap 编码 map，字符串键按字典序排列，相同的值编码结果相同
+func (e *msgpackEncoder) encodeMap(v reflect.Value) error {
+	keys := v.MapKeys()
+	if v.Type().Key().Kind() == reflect.String {
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	}
+	e.writeHeader(len(keys), 0x80, 0xde, 0xdf)
+	for _, key := range keys {
+		if err := e.encode(key); err != nil {
+			return err
+		}
+		if err := e.encode(v.MapIndex(key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeStruct 编码结构体为以字段名为键的 map
+func (e *msgpackEncoder) encodeStruct(v reflect.Value) error {
+	fields := structFields(v.Type())
+	values := make([]reflect.Value, 0, len(fields))
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		fv := v.FieldByIndex(f.index)
+		if f.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+		names = append(names, f.name)
+		values = append(values, fv)
+	}
+
+	e.writeHeader(len(values), 0x80, 0xde, 0xdf)
+	for i, fv := range values {
+		e.writeString(names[i])
+		if err := e.encode(fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeHeader 写入数组或 map 的长度，fix 为 4 位长度格式的前缀
+func (e *msgpackEncoder) writeHeader(n int, fix, code16, code32 byte) {
+	switch {
+	case n < 16:
+		e.buf = append(e.buf, fix|byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, code16)
+		e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(n))
+	default:
+		e.buf = append(e.buf, code32)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(n))
+	}
+}
+
+// writeInt 写入有符号整数，非负数按无符号整数写入，使用最短的格式
+func (e *msgpackEncoder) writeInt(n int64) {
+	switch {
+	case n >= 0:
+		e.writeUint(uint64(n))
+	case n >= -32:
+		e.buf = append(e.buf, byte(n))
+	case n >= math.MinInt8:
+		e.buf = append(e.buf, 0xd0, byte(n))
+	case n >= math.MinInt16:
+		e.buf = append(e.buf, 0xd1)
+		e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(n))
+	case n >= math.MinInt32:
+		e.buf = append(e.buf, 0xd2)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(n))
+	default:
+		e.buf = append(e.buf, 0xd3)
+		e.buf = binary.BigEndian.AppendUint64(e.buf, uint64(n))
+	}
+}
+
+// writeUint 写入无符号整数，使用最短的格式
+func (e *msgpackEncoder) writeUint(n uint64) {
+	switch {
+	case n <= 0x7f:
+		e.buf = append(e.buf, byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xcc, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, 0xcd)
+		e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(n))
+	case n <= math.MaxUint32:
+		e.buf = append(e.buf, 0xce)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(n))
+	default:
+		e.buf = append(e.buf, 0xcf)
+		e.buf = binary.BigEndian.AppendUint64(e.buf, n)
+	}
+}
+
+// writeString 写入字符串
+func (e *msgpackEncoder) writeString(s string) {
+	switch n := len(s); {
+	case n < 32:
+		e.buf = append(e.buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, 0xda)
+		e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(n))
+	default:
+		e.buf = append(e.buf, 0xdb)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(n))
+	}
+	e.buf = append(e.buf, s...)
+}
+
+// writeBin 写入二进制数据
+func (e *msgpackEncoder) writeBin(data []byte) {
+	switch n := len(data); {
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, 0xc5)
+		e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(n))
+	default:
+		e.buf = append(e.buf, 0xc6)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(n))
+	}
+	e.buf = append(e.buf, data...)
+}
+
+// writeTime 以 96 位时间戳格式写入时间
+func (e *msgpackEncoder) writeTime(t time.Time) {
+	e.buf = append(e.buf, 0xc7, 12, msgpackTimeExt)
+	e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(t.Nanosecond()))
+	e.buf = binary.BigEndian.AppendUint64(e.buf, uint64(t.Unix()))
+}
+
+// ================== 解码 ==================
+
+// msgpackDecoder MessagePack 解码器
+type msgpackDecoder struct {
+	data []byte
+	pos  int
+}
+
+// errTruncated 数据不完整
+var errTruncated = errors.New("msgpack: 数据不完整")
+
+// peek 读取下一个格式字节但不前进
+func (d *msgpackDecoder) peek() (byte, error) {
+	if d.pos >= len(d.data) {
+		return 0, errTruncated
+	}
+	return d.data[d.pos], nil
+}
+
+// readByte 读取一个字节
+func (d *msgpackDecoder) readByte() (byte, error) {
+	c, err := d.peek()
+	if err == nil {
+		d.pos++
+	}
+	return c, err
+}
+
+// read 读取 n 个字节，返回的切片引用原数据
+func (d *msgpackDecoder) read(n int) ([]byte, error) {
+	if n < 0 || len(d.data)-d.pos < n {
+		return nil, errTruncated
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// readN 读取 size 字节的大端无符号整数
+func (d *msgpackDecoder) readN(size int) (uint64, error) {
+	b, err := d.read(size)
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	default:
+		return binary.BigEndian.Uint64(b), nil
+	}
+}
+
+// readLen 读取 size 字节的长度，长度不能超过剩余数据的字节数，避免损坏的数据导致过大的分配
+func (d *msgpackDecoder) readLen(size int) (int, error) {
+	n, err := d.readN(size)
+	if err != nil {
+		return 0, err
+	}
+	if n > uint64(len(d.data)-d.pos) {
+		return 0, errTruncated
+	}
+	return int(n), nil
+}
+
+// typeError 格式与目标类型不匹配
+func typeError(c byte, want interface{}) error {
+	return fmt.Errorf("msgpack: 无法将格式 0x%02x 解码为 %v", c, want)
+}
+
+// readHeader 读取数组或 map 的长度，fix 为 4 位长度格式的前缀
+func (d *msgpackDecoder) readHeader(fix, code16, code32 byte, want string) (int, error) {
+	c, err := d.readByte()
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case c&0xf0 == fix:
+		return int(c & 0x0f), nil
+	case c == code16:
+		return d.readLen(2)
+	case c == code32:
+		return d.readLen(4)
+	}
+	return 0, typeError(c, want)
+}
+
+// readBytes 读取 str 或 bin 的内容
+func (d *msgpackDecoder) readBytes() ([]byte, error) {
+	c, err := d.readByte()
+	if err != nil {
+		return nil, err
+	}
+	var n int
+	switch {
+	case c >= 0xa0 && c <= 0xbf:
+		n = int(c & 0x1f)
+	case c == 0xd9 || c == 0xc4:
+		n, err = d.readLen(1)
+	case c == 0xda || c == 0xc5:
+		n, err = d.readLen(2)
+	case c == 0xdb || c == 0xc6:
+		n, err = d.readLen(4)
+	default:
+		return nil, typeError(c, "字符串")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d.read(n)
+}
+
+// readInteger 读取整数，unsigned 为 true 时值在 u 中，否则在 i 中
+func (d *msgpackDecoder) readInteger() (i int64, u uint64, unsigned bool, err error) {
+	c, err := d.readByte()
+	if err != nil {
+		return 0, 0, false, err
+	}
+	switch {
+	case c <= 0x7f:
+		return 0, uint64(c), true, nil
+	case c >= 0xe0:
+		return int64(int8(c)), 0, false, nil
+	case c >= 0xcc && c <= 0xcf:
+		u, err = d.readN(1 << (c - 0xcc))
+		return 0, u, true, err
+	case c >= 0xd0 && c <= 0xd3:
+		size := 1 << (c - 0xd0)
+		u, err = d.readN(size)
+		switch size {
+		case 1:
+			i = int64(int8(u))
+		case 2:
+			i = int64(int16(u))
+		case 4:
+			i = int64(int32(u))
+		default:
+			i = int64(u)
+		}
+		return i, 0, false, err
+	}
+	return 0, 0, false, typeError(c, "整数")
+}
+
+// readInt 读取有符号整数
+func (d *msgpackDecoder) readInt() (int64, error) {
+	i, u, unsigned, err := d.readInteger()
+	if err != nil || !unsigned {
+		return i, err
+	}
+	if u > math.MaxInt64 {
+		return 0, fmt.Errorf("msgpack: %d 超出 int64 的范围", u)
+	}
+	return int64(u), nil
+}
+
+// readUint 读取无符号整数
+func (d *msgpackDecoder) readUint() (uint64, error) {
+	i, u, unsigned, err := d.readInteger()
+	if err != nil || unsigned {
+		return u, err
+	}
+	if i < 0 {
+		return 0, fmt.Errorf("msgpack: %d 不能解码为无符号整数", i)
+	}
+	return uint64(i), nil
+}
+
+// readFloat 读取浮点数，也接受整数
+func (d *msgpackDecoder) readFloat() (float64, error) {
+	c, err := d.peek()
+	if err != nil {
+		return 0, err
+	}
+	switch c {
+	case 0xca:
+		d.pos++
+		bits, err := d.readN(4)
+		return float64(math.Float32frombits(uint32(bits))), err
+	case 0xcb:
+		d.pos++
+		bits, err := d.readN(8)
+		return math.Float64frombits(bits), err
+	}
+	i, u, unsigned, err := d.readInteger()
+	if unsigned {
+		return float64(u), err
+	}
+	return float64(i), err
+}
+
+// readTime 读取时间戳扩展类型，支持 32、64 和 96 位格式
+func (d *msgpackDecoder) readTime() (time.Time, error) {
+	c, err := d.readByte()
+	if err != nil {
+		return time.Time{}, err
+	}
+	size := 0
+	switch c {
+	case 0xd6:
+		size = 4
+	case 0xd7:
+		size = 8
+	case 0xc7:
+		n, err := d.readN(1)
+		if err != nil {
+			return time.Time{}, err
+		}
+		size = int(n)
+	default:
+		return time.Time{}, typeError(c, timeType)
+	}
+	ext, err := d.readByte()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if ext != msgpackTimeExt {
+		return time.Time{}, fmt.Errorf("msgpack: 扩展类型 %d 不是时间戳", int8(ext))
+	}
+	b, err := d.read(size)
+	if err != nil {
+		return time.Time{}, err
+	}
+	switch size {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(b)), 0).UTC(), nil
+	case 8:
+		v := binary.BigEndian.Uint64(b)
+		return time.Unix(int64(v&(1<<34-1)), int64(v>>34)).UTC(), nil
+	case 12:
+		return time.Unix(int64(binary.BigEndian.Uint64(b[4:])), int64(binary.BigEndian.Uint32(b))).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("msgpack: 时间戳长度 %d 无效", size)
+}
+
+// decode 解码到 v，v 必须可以设置
+func (d *msgpackDecoder) decode(v reflect.Value) error {
+	c, err := d.peek()
+	if err != nil {
+		return err
+	}
+	if c == 0xc0 {
+		d.pos++
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return d.decode(v.Elem())
+	}
+	if v.Type() == timeType {
+		t, err := d.readTime()
+		if err == nil {
+			v.Set(reflect.ValueOf(t))
+		}
+		return err
+	}
+	// 二进制数据交给 UnmarshalBinary，与编码时的 MarshalBinary 对应
+	isBytes := (c >= 0xa0 && c <= 0xbf) || (c >= 0xc4 && c <= 0xc6) || (c >= 0xd9 && c <= 0xdb)
+	if isBytes && v.Kind() != reflect.Interface && v.CanAddr() && v.Addr().Type().Implements(binaryUnmarshalerType) {
+		data, err := d.readBytes()
+		if err != nil {
+			return err
+		}
+		return v.Addr().Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary(append([]byte(nil), data...))
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return fmt.Errorf("msgpack: 无法解码到非空接口 %s", v.Type())
+		}
+		value, err := d.decodeAny()
+		if err != nil {
+			return err
+		}
+		if value == nil {
+			v.Set(reflect.Zero(v.Type()))
+		} else {
+			v.Set(reflect.ValueOf(value))
+		}
+	case reflect.Bool:
+		d.pos++
+		switch c {
+		case 0xc2:
+			v.SetBool(false)
+		case 0xc3:
+			v.SetBool(true)
+		default:
+			return typeError(c, v.Type())
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := d.readInt()
+		if err != nil {
+			return err
+		}
+		if v.OverflowInt(n) {
+			return fmt.Errorf("msgpack: %d 超出 %s 的范围", n, v.Type())
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := d.readUint()
+		if err != nil {
+			return err
+		}
+		if v.OverflowUint(n) {
+			return fmt.Errorf("msgpack: %d 超出 %s 的范围", n, v.Type())
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := d.readFloat()
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.String:
+		b, err := d.readBytes()
+		if err != nil {
+			return err
+		}
+		v.SetString(string(b))
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b, err := d.readBytes()
+			if err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(append([]byte{}, b...)).Convert(v.Type()))
+			return nil
+		}
+		n, err := d.readHeader(0x90, 0xdc, 0xdd, v.Type().String())
+		if err != nil {
+			return err
+		}
+		slice := reflect.MakeSlice(v.Type(), n, n)
+		for i := 0; i < n; i++ {
+			if err := d.decode(slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	case reflect.Array:
+		return d.decodeArray(v, c)
+	case reflect.Map:
+		return d.decodeMap(v)
+	case reflect.Struct:
+		return d.decodeStruct(v)
+	default:
+		return fmt.Errorf("msgpack: 不支持的类型 %s", v.Type())
+	}
+	return nil
+}
+
+// decodeArray 解码到数组，多余的元素丢弃，不足的元素为零值
+func (d *msgpackDecoder) decodeArray(v reflect.Value, c byte) error {
+	v.Set(reflect.Zero(v.Type()))
+	if v.Type().Elem().Kind() == reflect.Uint8 && c != 0xdc && c != 0xdd && c&0xf0 != 0x90 {
+		b, err := d.readBytes()
+		if err != nil {
+			return err
+		}
+		reflect.Copy(v, reflect.ValueOf(b))
+		return nil
+	}
+
+	n, err := d.readHeader(0x90, 0xdc, 0xdd, v.Type().String())
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		if i >= v.Len() {
+			if err := d.skip(); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := d.decode(v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeMap 解码到 map，已有的 map 保留原有的键
+func (d *msgpackDecoder) decodeMap(v reflect.Value) error {
+	n, err := d.readHeader(0x80, 0xde, 0xdf, v.Type().String())
+	if err != nil {
+		return err
+	}
+	if v.IsNil() {
+		v.Set(reflect.MakeMapWithSize(v.Type(), n))
+	}
+	keyType, elemType := v.Type().Key(), v.Type().Elem()
+	for i := 0; i < n; i++ {
+		key := reflect.New(keyType).Elem()
+		if err := d.decode(key); err != nil {
+			return err
+		}
+		elem := reflect.New(elemType).Elem()
+		if err := d.decode(elem); err != nil {
+			return err
+		}
+		v.SetMapIndex(key, elem)
+	}
+	return nil
+}
+
+// decodeStruct 按字段名解码到结构体，字段名不区分大小写，未知的字段忽略
+func (d *msgpackDecoder) decodeStruct(v reflect.Value) error {
+	n, err := d.readHeader(0x80, 0xde, 0xdf, v.Type().String())
+	if err != nil {
+		return err
+	}
+	fields := structFields(v.Type())
+	for i := 0; i < n; i++ {
+		name, err := d.readBytes()
+		if err != nil {
+			return err
+		}
+		field := findField(fields, string(name))
+		if field == nil {
+			if err := d.skip(); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := d.decode(v.FieldByIndex(field.index)); err != nil {
+			return fmt.Errorf("%s.%s: %w", v.Type(), field.name, err)
+		}
+	}
+	return nil
+}
+
+// findField 按名称查找字段，优先完全匹配
+func findField(fields []msgpackField, name string) *msgpackField {
+	for i := range fields {
+		if fields[i].name == name {
+			return &fields[i]
+		}
+	}
+	for i := range fields {
+		if strings.EqualFold(fields[i].name, name) {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+// decodeAny 解码为 interface{} 的值
+func (d *msgpackDecoder) decodeAny() (interface{}, error) {
+	c, err := d.peek()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case c == 0xc0:
+		d.pos++
+		return nil, nil
+	case c == 0xc2 || c == 0xc3:
+		d.pos++
+		return c == 0xc3, nil
+	case c <= 0x7f || c >= 0xe0 || (c >= 0xcc && c <= 0xd3):
+		i, u, unsigned, err := d.readInteger()
+		if unsigned && u <= math.MaxInt64 {
+			return int64(u), err
+		} else if unsigned {
+			return u, err
+		}
+		return i, err
+	case c == 0xca || c == 0xcb:
+		return d.readFloat()
+	case (c >= 0xa0 && c <= 0xbf) || (c >= 0xd9 && c <= 0xdb):
+		b, err := d.readBytes()
+		return string(b), err
+	case c >= 0xc4 && c <= 0xc6:
+		b, err := d.readBytes()
+		return append([]byte{}, b...), err
+	case c&0xf0 == 0x90 || c == 0xdc || c == 0xdd:
+		n, err := d.readHeader(0x90, 0xdc, 0xdd, "数组")
+		if err != nil {
+			return nil, err
+		}
+		values := make([]interface{}, n)
+		for i := range values {
+			if values[i], err = d.decodeAny(); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	case c&0xf0 == 0x80 || c == 0xde || c == 0xdf:
+		return d.decodeAnyMap()
+	case c == 0xd6 || c == 0xd7 || c == 0xc7:
+		return d.readTime()
+	}
+	return nil, typeError(c, "interface{}")
+}
+
+// decodeAnyMap 解码为 map[string]interface{}，键不都是字符串时为 map[interface{}]interface{}
+func (d *msgpackDecoder) decodeAnyMap() (interface{}, error) {
+	n, err := d.readHeader(0x80, 0xde, 0xdf, "map")
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]interface{}, n)
+	values := make([]interface{}, n)
+	allStrings := true
+	for i := 0; i < n; i++ {
+		if keys[i], err = d.decodeAny(); err != nil {
+			return nil, err
+		}
+		if values[i], err = d.decodeAny(); err != nil {
+			return nil, err
+		}
+		if _, ok := keys[i].(string); !ok {
+			allStrings = false
+		}
+	}
+
+	if allStrings {
+		m := make(map[string]interface{}, n)
+		for i, key := range keys {
+			m[key.(string)] = values[i]
+		}
+		return m, nil
+	}
+	m := make(map[interface{}]interface{}, n)
+	for i, key := range keys {
+		if key != nil && !reflect.TypeOf(key).Comparable() {
+			return nil, fmt.Errorf("msgpack: %T 不能作为 map 的键", key)
+		}
+		m[key] = values[i]
+	}
+	return m, nil
+}
+
+// skip 跳过一个值
+func (d *msgpackDecoder) skip() error {
+	c, err := d.peek()
+	if err != nil {
+		return err
+	}
+	switch {
+	case c <= 0x7f || c >= 0xe0 || c == 0xc0 || c == 0xc2 || c == 0xc3:
+		d.pos++
+		return nil
+	case c >= 0xcc && c <= 0xd3:
+		_, _, _, err := d.readInteger()
+		return err
+	case c == 0xca || c == 0xcb:
+		_, err := d.readFloat()
+		return err
+	case (c >= 0xa0 && c <= 0xbf) || (c >= 0xc4 && c <= 0xc6) || (c >= 0xd9 && c <= 0xdb):
+		_, err := d.readBytes()
+		return err
+	case c&0xf0 == 0x90 || c == 0xdc || c == 0xdd:
+		n, err := d.readHeader(0x90, 0xdc, 0xdd, "数组")
+		for i := 0; err == nil && i < n; i++ {
+			err = d.skip()
+		}
+		return err
+	case c&0xf0 == 0x80 || c == 0xde || c == 0xdf:
+		n, err := d.readHeader(0x80, 0xde, 0xdf, "map")
+		for i := 0; err == nil && i < 2*n; i++ {
+			err = d.skip()
+		}
+		return err
+	case c >= 0xd4 && c <= 0xd8:
+		// fixext 1、2、4、8、16：类型字节加数据
+		d.pos++
+		_, err := d.read(1 + 1<<(c-0xd4))
+		return err
+	case c >= 0xc7 && c <= 0xc9:
+		d.pos++
+		n, err := d.readLen(1 << (c - 0xc7))
+		if err == nil {
+			_, err = d.read(1 + n)
+		}
+		return err
+	}
+	return typeError(c, "任意值")
+}
@@ -0,0 +1,345 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Pipeliner 命令队列，命令先加入队列，Exec 时一次性执行
+//
+// Redis 的 Pipeline 只减少往返次数，执行期间可能穿插其他客户端的命令；TxPipeline 以 MULTI/EXEC 执行，
+// 其他客户端不会看到部分结果。内存缓存在同一次加锁中执行，文件缓存在同一个 bbolt 写事务中执行，
+// 两者的 Pipeline 和 TxPipeline 都是原子的。
+//
+// 与 Redis 的事务一致，单条命令出错（如类型不匹配）不会回滚或中止其他命令。
+type Pipeliner interface {
+	Get(key string) *Cmd
+	Set(key string, value interface{}, expiration time.Duration) *Cmd
+	Del(keys ...string) *Cmd
+	Exists(keys ...string) *Cmd
+	Expire(key string, expiration time.Duration) *Cmd
+	Incr(key string) *Cmd
+	Decr(key string) *Cmd
+	IncrBy(key string, value int64) *Cmd
+
+	HGet(key, field string) *Cmd
+	HSet(key string, values ...interface{}) *Cmd
+	HDel(key string, fields ...string) *Cmd
+
+	LPush(key string, values ...interface{}) *Cmd
+	RPush(key string, values ...interface{}) *Cmd
+
+	SAdd(key string, members ...interface{}) *Cmd
+	SRem(key string, members ...interface{}) *Cmd
+
+	ZAdd(key string, members ...Z) *Cmd
+	ZRem(key string, members ...interface{}) *Cmd
+	ZIncrBy(key string, increment float64, member string) *Cmd
+
+	// Len 返回队列中的命令数
+	Len() int
+	// Exec 执行队列中的命令并清空队列，返回全部命令和第一条失败命令的错误
+	Exec(ctx context.Context) ([]*Cmd, error)
+	// Discard 清空队列
+	Discard()
+}
+
+// Cmd 管道中的一条命令，Exec 之后才有结果
+//
+// Get、HGet 的结果为 string，ZIncrBy 为 float64，Set、Expire 为 nil，其余命令为 int64。
+type Cmd struct {
+	name string
+	val  interface{}
+	err  error
+}
+
+// Name 返回命令名，如 "set"
+func (c *Cmd) Name() string {
+	return c.name
+}
+
+// Val 返回命令的结果
+func (c *Cmd) Val() interface{} {
+	return c.val
+}
+
+// Err 返回命令的错误
+func (c *Cmd) Err() error {
+	return c.err
+}
+
+// Result 返回命令的结果和错误
+func (c *Cmd) Result() (interface{}, error) {
+	return c.val, c.err
+}
+
+// Text 以字符串返回结果
+func (c *Cmd) Text() (string, error) {
+	if c.err != nil {
+		return "", c.err
+	}
+	switch v := c.val.(type) {
+	case string:
+		return v, nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case nil:
+		return "", nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// Int64 以整数返回结果
+func (c *Cmd) Int64() (int64, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	switch v := c.val.(type) {
+	case int64:
+		return v, nil
+	case string:
+		return parseMemoryInt64(v)
+	default:
+		return 0, fmt.Errorf("命令 %s 的结果不是整数: %w", c.name, ErrTypeMismatch)
+	}
+}
+
+// Float64 以浮点数返回结果
+func (c *Cmd) Float64() (float64, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	switch v := c.val.(type) {
+	case float64:
+		return v, nil
+	case int64:
+		return float64(v), nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("值不是浮点数: %w", err)
+		}
+		return f, nil
+	default:
+		return 0, fmt.Errorf("命令 %s 的结果不是浮点数: %w", c.name, ErrTypeMismatch)
+	}
+}
+
+// firstError 返回第一条失败命令的错误
+func firstError(cmds []*Cmd) error {
+	for _, cmd := range cmds {
+		if cmd.err != nil {
+			return cmd.err
+		}
+	}
+	return nil
+}
+
+// pipeTx 本地缓存执行管道命令的事务视图，调用时已持有所需的锁或 bbolt 写事务
+type pipeTx interface {
+	get(key string) (string, error)
+	set(key string, value interface{}, expiration time.Duration) error
+	del(key string) (bool, error)
+	exists(key string) (bool, error)
+	expire(key string, expiration time.Duration) error
+	incrBy(key string, value int64) (int64, error)
+	hget(key, field string) (string, error)
+	hset(key string, values []interface{}) (int64, error)
+	hdel(key string, fields []string) (int64, error)
+	push(key string, values []interface{}, left bool) (int64, error)
+	sadd(key string, members []interface{}) (int64, error)
+	srem(key string, members []interface{}) (int64, error)
+	zadd(key string, members []Z) (int64, error)
+	zrem(key string, members []interface{}) (int64, error)
+	zincrBy(key string, increment float64, member string) (float64, error)
+}
+
+// localCmd 本地缓存队列中的命令
+type localCmd struct {
+	cmd  *Cmd
+	keys []string
+	run  func(t pipeTx) (interface{}, error)
+}
+
+// localPipeline 内存缓存和文件缓存共用的命令队列，exec 在一个原子操作中依次调用 run
+type localPipeline struct {
+	exec  func(keys []string, run func(t pipeTx)) error
+	queue []localCmd
+}
+
+// add 将命令加入队列
+func (p *localPipeline) add(name string, keys []string, run func(t pipeTx) (interface{}, error)) *Cmd {
+	cmd := &Cmd{name: name}
+	p.queue = append(p.queue, localCmd{cmd: cmd, keys: keys, run: run})
+	return cmd
+}
+
+// countKeys 按键逐个执行并统计结果为 true 的数量，与 Redis 一致重复的键重复计数
+func countKeys(t pipeTx, keys []string, fn func(t pipeTx, key string) (bool, error)) (interface{}, error) {
+	var n int64
+	for _, key := range keys {
+		ok, err := fn(t, key)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (p *localPipeline) Get(key string) *Cmd {
+	return p.add("get", []string{key}, func(t pipeTx) (interface{}, error) {
+		return t.get(key)
+	})
+}
+
+func (p *localPipeline) Set(key string, value interface{}, expiration time.Duration) *Cmd {
+	return p.add("set", []string{key}, func(t pipeTx) (interface{}, error) {
+		return nil, t.set(key, value, expiration)
+	})
+}
+
+func (p *localPipeline) Del(keys ...string) *Cmd {
+	return p.add("del", keys, func(t pipeTx) (interface{}, error) {
+		return countKeys(t, keys, pipeTx.del)
+	})
+}
+
+func (p *localPipeline) Exists(keys ...string) *Cmd {
+	return p.add("exists", keys, func(t pipeTx) (interface{}, error) {
+		return countKeys(t, keys, pipeTx.exists)
+	})
+}
+
+func (p *localPipeline) Expire(key string, expiration time.Duration) *Cmd {
+	return p.add("expire", []string{key}, func(t pipeTx) (interface{}, error) {
+		return nil, t.expire(key, expiration)
+	})
+}
+
+func (p *localPipeline) Incr(key string) *Cmd {
+	return p.incrBy("incr", key, 1)
+}
+
+func (p *localPipeline) Decr(key string) *Cmd {
+	return p.incrBy("decr", key, -1)
+}
+
+func (p *localPipeline) IncrBy(key string, value int64) *Cmd {
+	return p.incrBy("incrby", key, value)
+}
+
+func (p *localPipeline) incrBy(name, key string, value int64) *Cmd {
+	return p.add(name, []string{key}, func(t pipeTx) (interface{}, error) {
+		return t.incrBy(key, value)
+	})
+}
+
+func (p *localPipeline) HGet(key, field string) *Cmd {
+	return p.add("hget", []string{key}, func(t pipeTx) (interface{}, error) {
+		return t.hget(key, field)
+	})
+}
+
+func (p *localPipeline) HSet(key string, values ...interface{}) *Cmd {
+	return p.add("hset", []string{key}, func(t pipeTx) (interface{}, error) {
+		return t.hset(key, values)
+	})
+}
+
+func (p *localPipeline) HDel(key string, fields ...string) *Cmd {
+	return p.add("hdel", []string{key}, func(t pipeTx) (interface{}, error) {
+		return t.hdel(key, fields)
+	})
+}
+
+func (p *localPipeline) LPush(key string, values ...interface{}) *Cmd {
+	return p.add("lpush", []string{key}, func(t pipeTx) (interface{}, error) {
+		return t.push(key, values, true)
+	})
+}
+
+func (p *localPipeline) RPush(key string, values ...interface{}) *Cmd {
+	return p.add("rpush", []string{key}, func(t pipeTx) (interface{}, error) {
+		return t.push(key, values, false)
+	})
+}
+
+func (p *localPipeline) SAdd(key string, members ...interface{}) *Cmd {
+	return p.add("sadd", []string{key}, func(t pipeTx) (interface{}, error) {
+		return t.sadd(key, members)
+	})
+}
+
+func (p *localPipeline) SRem(key string, members ...interface{}) *Cmd {
+	return p.add("srem", []string{key}, func(t pipeTx) (interface{}, error) {
+		return t.srem(key, members)
+	})
+}
+
+func (p *localPipeline) ZAdd(key string, members ...Z) *Cmd {
+	return p.add("zadd", []string{key}, func(t pipeTx) (interface{}, error) {
+		return t.zadd(key, members)
+	})
+}
+
+func (p *localPipeline) ZRem(key string, members ...interface{}) *Cmd {
+	return p.add("zrem", []string{key}, func(t pipeTx) (interface{}, error) {
+		return t.zrem(key, members)
+	})
+}
+
+func (p *localPipeline) ZIncrBy(key string, increment float64, member string) *Cmd {
+	return p.add("zincrby", []string{key}, func(t pipeTx) (interface{}, error) {
+		return t.zincrBy(key, increment, member)
+	})
+}
+
+// Len 返回队列中的命令数
+func (p *localPipeline) Len() int {
+	return len(p.queue)
+}
+
+// Discard 清空队列
+func (p *localPipeline) Discard() {
+	p.queue = nil
+}
+
+// Exec 在一个原子操作中依次执行队列中的命令，exec 本身失败时所有命令都返回该错误
+func (p *localPipeline) Exec(ctx context.Context) ([]*Cmd, error) {
+	queue := p.queue
+	p.queue = nil
+	if len(queue) == 0 {
+		return nil, nil
+	}
+
+	cmds := make([]*Cmd, len(queue))
+	var keys []string
+	for i, c := range queue {
+		cmds[i] = c.cmd
+		keys = append(keys, c.keys...)
+	}
+
+	err := ctx.Err()
+	if err == nil {
+		err = p.exec(keys, func(t pipeTx) {
+			for _, c := range queue {
+				c.cmd.val, c.cmd.err = c.run(t)
+			}
+		})
+	}
+	if err != nil {
+		for _, cmd := range cmds {
+			cmd.val, cmd.err = nil, err
+		}
+		return cmds, err
+	}
+	return cmds, firstError(cmds)
+}
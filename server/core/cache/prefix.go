@@ -0,0 +1,711 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// prefixCache 为所有键追加前缀的缓存包装，前缀叠加在缓存配置的 Prefix 之上
+type prefixCache struct {
+	Cache
+	prefix string
+}
+
+// WithPrefix 返回为所有键追加前缀的缓存，常用于按租户、模块隔离键空间
+func WithPrefix(c Cache, prefix string) Cache {
+	if prefix == "" {
+		return c
+	}
+	return &prefixCache{Cache: c, prefix: prefix}
+}
+
+// key 追加前缀
+func (p *prefixCache) key(key string) string {
+	return p.prefix + key
+}
+
+// keys 批量追加前缀
+func (p *prefixCache) keys(keys []string) []string {
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = p.prefix + key
+	}
+	return prefixed
+}
+
+// pairs 为 MSet 的键值对参数中的键追加前缀
+func (p *prefixCache) pairs(values []interface{}) ([]interface{}, error) {
+	fields, err := hashFields(values)
+	if err != nil {
+		return nil, err
+	}
+	pairs := make([]interface{}, len(fields))
+	for i := 0; i < len(fields); i += 2 {
+		pairs[i], pairs[i+1] = p.key(fields[i]), fields[i+1]
+	}
+	return pairs, nil
+}
+
+// strip 去掉结果中的前缀
+func (p *prefixCache) strip(keys []string, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+	for i, key := range keys {
+		keys[i] = strings.TrimPrefix(key, p.prefix)
+	}
+	return keys, nil
+}
+
+func (p *prefixCache) Get(key string) (string, error) {
+	return p.Cache.Get(p.key(key))
+}
+
+func (p *prefixCache) Set(key string, value interface{}, expiration time.Duration) error {
+	return p.Cache.Set(p.key(key), value, expiration)
+}
+
+func (p *prefixCache) Del(keys ...string) (int64, error) {
+	return p.Cache.Del(p.keys(keys)...)
+}
+
+func (p *prefixCache) Exists(keys ...string) (int64, error) {
+	return p.Cache.Exists(p.keys(keys)...)
+}
+
+func (p *prefixCache) Expire(key string, expiration time.Duration) error {
+	return p.Cache.Expire(p.key(key), expiration)
+}
+
+func (p *prefixCache) TTL(key string) (time.Duration, error) {
+	return p.Cache.TTL(p.key(key))
+}
+
+func (p *prefixCache) Type(key string) (string, error) {
+	return p.Cache.Type(p.key(key))
+}
+
+func (p *prefixCache) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	return p.Cache.SetNX(p.key(key), value, expiration)
+}
+
+func (p *prefixCache) CompareAndDel(key, value string) (bool, error) {
+	return p.Cache.CompareAndDel(p.key(key), value)
+}
+
+func (p *prefixCache) CompareAndExpire(key, value string, expiration time.Duration) (bool, error) {
+	return p.Cache.CompareAndExpire(p.key(key), value, expiration)
+}
+
+func (p *prefixCache) GetSet(key string, value interface{}) (string, error) {
+	return p.Cache.GetSet(p.key(key), value)
+}
+
+func (p *prefixCache) MGet(keys ...string) ([]interface{}, error) {
+	return p.Cache.MGet(p.keys(keys)...)
+}
+
+func (p *prefixCache) MSet(values ...interface{}) error {
+	pairs, err := p.pairs(values)
+	if err != nil {
+		return err
+	}
+	return p.Cache.MSet(pairs...)
+}
+
+func (p *prefixCache) Persist(key string) (bool, error) {
+	return p.Cache.Persist(p.key(key))
+}
+
+func (p *prefixCache) Rename(key, newKey string) error {
+	return p.Cache.Rename(p.key(key), p.key(newKey))
+}
+
+func (p *prefixCache) Incr(key string) (int64, error) {
+	return p.Cache.Incr(p.key(key))
+}
+
+func (p *prefixCache) Decr(key string) (int64, error) {
+	return p.Cache.Decr(p.key(key))
+}
+
+func (p *prefixCache) IncrBy(key string, value int64) (int64, error) {
+	return p.Cache.IncrBy(p.key(key), value)
+}
+
+func (p *prefixCache) HGet(key, field string) (string, error) {
+	return p.Cache.HGet(p.key(key), field)
+}
+
+func (p *prefixCache) HSet(key string, values ...interface{}) (int64, error) {
+	return p.Cache.HSet(p.key(key), values...)
+}
+
+func (p *prefixCache) HDel(key string, fields ...string) (int64, error) {
+	return p.Cache.HDel(p.key(key), fields...)
+}
+
+func (p *prefixCache) HGetAll(key string) (map[string]string, error) {
+	return p.Cache.HGetAll(p.key(key))
+}
+
+func (p *prefixCache) HExists(key, field string) (bool, error) {
+	return p.Cache.HExists(p.key(key), field)
+}
+
+func (p *prefixCache) HLen(key string) (int64, error) {
+	return p.Cache.HLen(p.key(key))
+}
+
+func (p *prefixCache) LPush(key string, values ...interface{}) (int64, error) {
+	return p.Cache.LPush(p.key(key), values...)
+}
+
+func (p *prefixCache) RPush(key string, values ...interface{}) (int64, error) {
+	return p.Cache.RPush(p.key(key), values...)
+}
+
+func (p *prefixCache) LPop(key string) (string, error) {
+	return p.Cache.LPop(p.key(key))
+}
+
+func (p *prefixCache) RPop(key string) (string, error) {
+	return p.Cache.RPop(p.key(key))
+}
+
+func (p *prefixCache) LLen(key string) (int64, error) {
+	return p.Cache.LLen(p.key(key))
+}
+
+func (p *prefixCache) LRange(key string, start, stop int64) ([]string, error) {
+	return p.Cache.LRange(p.key(key), start, stop)
+}
+
+func (p *prefixCache) SAdd(key string, members ...interface{}) (int64, error) {
+	return p.Cache.SAdd(p.key(key), members...)
+}
+
+func (p *prefixCache) SRem(key string, members ...interface{}) (int64, error) {
+	return p.Cache.SRem(p.key(key), members...)
+}
+
+func (p *prefixCache) SMembers(key string) ([]string, error) {
+	return p.Cache.SMembers(p.key(key))
+}
+
+func (p *prefixCache) SIsMember(key string, member interface{}) (bool, error) {
+	return p.Cache.SIsMember(p.key(key), member)
+}
+
+func (p *prefixCache) SCard(key string) (int64, error) {
+	return p.Cache.SCard(p.key(key))
+}
+
+func (p *prefixCache) ZAdd(key string, members ...Z) (int64, error) {
+	return p.Cache.ZAdd(p.key(key), members...)
+}
+
+func (p *prefixCache) ZRem(key string, members ...interface{}) (int64, error) {
+	return p.Cache.ZRem(p.key(key), members...)
+}
+
+func (p *prefixCache) ZRange(key string, start, stop int64) ([]string, error) {
+	return p.Cache.ZRange(p.key(key), start, stop)
+}
+
+func (p *prefixCache) ZRangeWithScores(key string, start, stop int64) ([]Z, error) {
+	return p.Cache.ZRangeWithScores(p.key(key), start, stop)
+}
+
+func (p *prefixCache) ZCard(key string) (int64, error) {
+	return p.Cache.ZCard(p.key(key))
+}
+
+func (p *prefixCache) ZScore(key, member string) (float64, error) {
+	return p.Cache.ZScore(p.key(key), member)
+}
+
+func (p *prefixCache) ZRangeByScore(key string, opt ZRangeBy) ([]string, error) {
+	return p.Cache.ZRangeByScore(p.key(key), opt)
+}
+
+func (p *prefixCache) ZRevRange(key string, start, stop int64) ([]string, error) {
+	return p.Cache.ZRevRange(p.key(key), start, stop)
+}
+
+func (p *prefixCache) ZIncrBy(key string, increment float64, member string) (float64, error) {
+	return p.Cache.ZIncrBy(p.key(key), increment, member)
+}
+
+func (p *prefixCache) ZRank(key, member string) (int64, error) {
+	return p.Cache.ZRank(p.key(key), member)
+}
+
+func (p *prefixCache) ZRemRangeByScore(key, min, max string) (int64, error) {
+	return p.Cache.ZRemRangeByScore(p.key(key), min, max)
+}
+
+func (p *prefixCache) ZCount(key, min, max string) (int64, error) {
+	return p.Cache.ZCount(p.key(key), min, max)
+}
+
+func (p *prefixCache) Keys(pattern string) ([]string, error) {
+	return p.strip(p.Cache.Keys(p.key(pattern)))
+}
+
+func (p *prefixCache) Scan(cursor uint64, match string, count int64) ([]string, uint64, error) {
+	return p.ScanCtx(context.Background(), cursor, match, count)
+}
+
+func (p *prefixCache) GetCtx(ctx context.Context, key string) (string, error) {
+	return p.Cache.GetCtx(ctx, p.key(key))
+}
+
+func (p *prefixCache) SetCtx(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	return p.Cache.SetCtx(ctx, p.key(key), value, expiration)
+}
+
+func (p *prefixCache) DelCtx(ctx context.Context, keys ...string) (int64, error) {
+	return p.Cache.DelCtx(ctx, p.keys(keys)...)
+}
+
+func (p *prefixCache) ExistsCtx(ctx context.Context, keys ...string) (int64, error) {
+	return p.Cache.ExistsCtx(ctx, p.keys(keys)...)
+}
+
+func (p *prefixCache) ExpireCtx(ctx context.Context, key string, expiration time.Duration) error {
+	return p.Cache.ExpireCtx(ctx, p.key(key), expiration)
+}
+
+func (p *prefixCache) TTLCtx(ctx context.Context, key string) (time.Duration, error) {
+	return p.Cache.TTLCtx(ctx, p.key(key))
+}
+
+func (p *prefixCache) TypeCtx(ctx context.Context, key string) (string, error) {
+	return p.Cache.TypeCtx(ctx, p.key(key))
+}
+
+func (p *prefixCache) SetNXCtx(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	return p.Cache.SetNXCtx(ctx, p.key(key), value, expiration)
+}
+
+func (p *prefixCache) CompareAndDelCtx(ctx context.Context, key, value string) (bool, error) {
+	return p.Cache.CompareAndDelCtx(ctx, p.key(key), value)
+}
+
+func (p *prefixCache) CompareAndExpireCtx(ctx context.Context, key, value string, expiration time.Duration) (bool, error) {
+	return p.Cache.CompareAndExpireCtx(ctx, p.key(key), value, expiration)
+}
+
+func (p *prefixCache) GetSetCtx(ctx context.Context, key string, value interface{}) (string, error) {
+	return p.Cache.GetSetCtx(ctx, p.key(key), value)
+}
+
+func (p *prefixCache) MGetCtx(ctx context.Context, keys ...string) ([]interface{}, error) {
+	return p.Cache.MGetCtx(ctx, p.keys(keys)...)
+}
+
+func (p *prefixCache) MSetCtx(ctx context.Context, values ...interface{}) error {
+	pairs, err := p.pairs(values)
+	if err != nil {
+		return err
+	}
+	return p.Cache.MSetCtx(ctx, pairs...)
+}
+
+func (p *prefixCache) PersistCtx(ctx context.Context, key string) (bool, error) {
+	return p.Cache.PersistCtx(ctx, p.key(key))
+}
+
+func (p *prefixCache) RenameCtx(ctx context.Context, key, newKey string) error {
+	return p.Cache.RenameCtx(ctx, p.key(key), p.key(newKey))
+}
+
+func (p *prefixCache) IncrCtx(ctx context.Context, key string) (int64, error) {
+	return p.Cache.IncrCtx(ctx, p.key(key))
+}
+
+func (p *prefixCache) DecrCtx(ctx context.Context, key string) (int64, error) {
+	return p.Cache.DecrCtx(ctx, p.key(key))
+}
+
+func (p *prefixCache) IncrByCtx(ctx context.Context, key string, value int64) (int64, error) {
+	return p.Cache.IncrByCtx(ctx, p.key(key), value)
+}
+
+func (p *prefixCache) HGetCtx(ctx context.Context, key, field string) (string, error) {
+	return p.Cache.HGetCtx(ctx, p.key(key), field)
+}
+
+func (p *prefixCache) HSetCtx(ctx context.Context, key string, values ...interface{}) (int64, error) {
+	return p.Cache.HSetCtx(ctx, p.key(key), values...)
+}
+
+func (p *prefixCache) HDelCtx(ctx context.Context, key string, fields ...string) (int64, error) {
+	return p.Cache.HDelCtx(ctx, p.key(key), fields...)
+}
+
+func (p *prefixCache) HGetAllCtx(ctx context.Context, key string) (map[string]string, error) {
+	return p.Cache.HGetAllCtx(ctx, p.key(key))
+}
+
+func (p *prefixCache) HExistsCtx(ctx context.Context, key, field string) (bool, error) {
+	return p.Cache.HExistsCtx(ctx, p.key(key), field)
+}
+
+func (p *prefixCache) HLenCtx(ctx context.Context, key string) (int64, error) {
+	return p.Cache.HLenCtx(ctx, p.key(key))
+}
+
+func (p *prefixCache) LPushCtx(ctx context.Context, key string, values ...interface{}) (int64, error) {
+	return p.Cache.LPushCtx(ctx, p.key(key), values...)
+}
+
+func (p *prefixCache) RPushCtx(ctx context.Context, key string, values ...interface{}) (int64, error) {
+	return p.Cache.RPushCtx(ctx, p.key(key), values...)
+}
+
+func (p *prefixCache) LPopCtx(ctx context.Context, key string) (string, error) {
+	return p.Cache.LPopCtx(ctx, p.key(key))
+}
+
+func (p *prefixCache) RPopCtx(ctx context.Context, key string) (string, error) {
+	return p.Cache.RPopCtx(ctx, p.key(key))
+}
+
+func (p *prefixCache) LLenCtx(ctx context.Context, key string) (int64, error) {
+	return p.Cache.LLenCtx(ctx, p.key(key))
+}
+
+func (p *prefixCache) LRangeCtx(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	return p.Cache.LRangeCtx(ctx, p.key(key), start, stop)
+}
+
+func (p *prefixCache) SAddCtx(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	return p.Cache.SAddCtx(ctx, p.key(key), members...)
+}
+
+func (p *prefixCache) SRemCtx(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	return p.Cache.SRemCtx(ctx, p.key(key), members...)
+}
+
+func (p *prefixCache) SMembersCtx(ctx context.Context, key string) ([]string, error) {
+	return p.Cache.SMembersCtx(ctx, p.key(key))
+}
+
+func (p *prefixCache) SIsMemberCtx(ctx context.Context, key string, member interface{}) (bool, error) {
+	return p.Cache.SIsMemberCtx(ctx, p.key(key), member)
+}
+
+func (p *prefixCache) SCardCtx(ctx context.Context, key string) (int64, error) {
+	return p.Cache.SCardCtx(ctx, p.key(key))
+}
+
+func (p *prefixCache) ZAddCtx(ctx context.Context, key string, members ...Z) (int64, error) {
+	return p.Cache.ZAddCtx(ctx, p.key(key), members...)
+}
+
+func (p *prefixCache) ZRemCtx(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	return p.Cache.ZRemCtx(ctx, p.key(key), members...)
+}
+
+func (p *prefixCache) ZRangeCtx(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	return p.Cache.ZRangeCtx(ctx, p.key(key), start, stop)
+}
+
+func (p *prefixCache) ZRangeWithScoresCtx(ctx context.Context, key string, start, stop int64) ([]Z, error) {
+	return p.Cache.ZRangeWithScoresCtx(ctx, p.key(key), start, stop)
+}
+
+func (p *prefixCache) ZCardCtx(ctx context.Context, key string) (int64, error) {
+	return p.Cache.ZCardCtx(ctx, p.key(key))
+}
+
+func (p *prefixCache) ZScoreCtx(ctx context.Context, key, member string) (float64, error) {
+	return p.Cache.ZScoreCtx(ctx, p.key(key), member)
+}
+
+func (p *prefixCache) ZRangeByScoreCtx(ctx context.Context, key string, opt ZRangeBy) ([]string, error) {
+	return p.Cache.ZRangeByScoreCtx(ctx, p.key(key), opt)
+}
+
+func (p *prefixCache) ZRevRangeCtx(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	return p.Cache.ZRevRangeCtx(ctx, p.key(key), start, stop)
+}
+
+func (p *prefixCache) ZIncrByCtx(ctx context.Context, key string, increment float64, member string) (float64, error) {
+	return p.Cache.ZIncrByCtx(ctx, p.key(key), increment, member)
+}
+
+func (p *prefixCache) ZRankCtx(ctx context.Context, key, member string) (int64, error) {
+	return p.Cache.ZRankCtx(ctx, p.key(key), member)
+}
+
+func (p *prefixCache) ZRemRangeByScoreCtx(ctx context.Context, key, min, max string) (int64, error) {
+	return p.Cache.ZRemRangeByScoreCtx(ctx, p.key(key), min, max)
+}
+
+func (p *prefixCache) ZCountCtx(ctx context.Context, key, min, max string) (int64, error) {
+	return p.Cache.ZCountCtx(ctx, p.key(key), min, max)
+}
+
+func (p *prefixCache) KeysCtx(ctx context.Context, pattern string) ([]string, error) {
+	return p.strip(p.Cache.KeysCtx(ctx, p.key(pattern)))
+}
+
+func (p *prefixCache) ScanCtx(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	if match == "" {
+		match = "*"
+	}
+	keys, next, err := p.Cache.ScanCtx(ctx, cursor, p.key(match), count)
+	keys, err = p.strip(keys, err)
+	return keys, next, err
+}
+
+func (p *prefixCache) BLPop(timeout time.Duration, keys ...string) ([]string, error) {
+	return p.BLPopCtx(context.Background(), timeout, keys...)
+}
+
+func (p *prefixCache) BRPop(timeout time.Duration, keys ...string) ([]string, error) {
+	return p.BRPopCtx(context.Background(), timeout, keys...)
+}
+
+func (p *prefixCache) Publish(channel string, message interface{}) (int64, error) {
+	return p.PublishCtx(context.Background(), channel, message)
+}
+
+func (p *prefixCache) Subscribe(channels ...string) (Subscription, error) {
+	return p.SubscribeCtx(context.Background(), channels...)
+}
+
+func (p *prefixCache) PSubscribe(patterns ...string) (Subscription, error) {
+	return p.PSubscribeCtx(context.Background(), patterns...)
+}
+
+func (p *prefixCache) XAdd(stream string, values map[string]interface{}) (string, error) {
+	return p.XAddCtx(context.Background(), stream, values)
+}
+
+func (p *prefixCache) XLen(stream string) (int64, error) {
+	return p.XLenCtx(context.Background(), stream)
+}
+
+func (p *prefixCache) XRead(args XReadArgs) ([]XStream, error) {
+	return p.XReadCtx(context.Background(), args)
+}
+
+func (p *prefixCache) XGroupCreate(stream, group, start string) error {
+	return p.XGroupCreateCtx(context.Background(), stream, group, start)
+}
+
+func (p *prefixCache) XReadGroup(args XReadGroupArgs) ([]XStream, error) {
+	return p.XReadGroupCtx(context.Background(), args)
+}
+
+func (p *prefixCache) XAck(stream, group string, ids ...string) (int64, error) {
+	return p.XAckCtx(context.Background(), stream, group, ids...)
+}
+
+func (p *prefixCache) BLPopCtx(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error) {
+	return p.stripFirst(p.Cache.BLPopCtx(ctx, timeout, p.keys(keys)...))
+}
+
+func (p *prefixCache) BRPopCtx(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error) {
+	return p.stripFirst(p.Cache.BRPopCtx(ctx, timeout, p.keys(keys)...))
+}
+
+// stripFirst 去掉阻塞弹出结果中键名的前缀
+func (p *prefixCache) stripFirst(result []string, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+	result[0] = strings.TrimPrefix(result[0], p.prefix)
+	return result, nil
+}
+
+func (p *prefixCache) PublishCtx(ctx context.Context, channel string, message interface{}) (int64, error) {
+	return p.Cache.PublishCtx(ctx, p.key(channel), message)
+}
+
+func (p *prefixCache) SubscribeCtx(ctx context.Context, channels ...string) (Subscription, error) {
+	return p.subscription(p.Cache.SubscribeCtx(ctx, p.keys(channels)...))
+}
+
+func (p *prefixCache) PSubscribeCtx(ctx context.Context, patterns ...string) (Subscription, error) {
+	return p.subscription(p.Cache.PSubscribeCtx(ctx, p.keys(patterns)...))
+}
+
+// subscription 转发订阅消息并去掉频道名的前缀
+func (p *prefixCache) subscription(sub Subscription, err error) (Subscription, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	out := &prefixSubscription{Subscription: sub, out: make(chan *Message), done: make(chan struct{})}
+	go func() {
+		defer close(out.out)
+		for msg := range sub.Channel() {
+			select {
+			case out.out <- &Message{
+				Channel: strings.TrimPrefix(msg.Channel, p.prefix),
+				Pattern: strings.TrimPrefix(msg.Pattern, p.prefix),
+				Payload: msg.Payload,
+			}:
+			case <-out.done:
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// prefixSubscription 去掉频道名前缀的订阅
+type prefixSubscription struct {
+	Subscription
+	out  chan *Message
+	done chan struct{}
+	once sync.Once
+}
+
+func (s *prefixSubscription) Channel() <-chan *Message {
+	return s.out
+}
+
+func (s *prefixSubscription) Close() error {
+	s.once.Do(func() { close(s.done) })
+	return s.Subscription.Close()
+}
+
+func (p *prefixCache) XAddCtx(ctx context.Context, stream string, values map[string]interface{}) (string, error) {
+	return p.Cache.XAddCtx(ctx, p.key(stream), values)
+}
+
+func (p *prefixCache) XLenCtx(ctx context.Context, stream string) (int64, error) {
+	return p.Cache.XLenCtx(ctx, p.key(stream))
+}
+
+func (p *prefixCache) XReadCtx(ctx context.Context, args XReadArgs) ([]XStream, error) {
+	args.Streams = p.streams(args.Streams)
+	return p.stripStreams(p.Cache.XReadCtx(ctx, args))
+}
+
+func (p *prefixCache) XGroupCreateCtx(ctx context.Context, stream, group, start string) error {
+	return p.Cache.XGroupCreateCtx(ctx, p.key(stream), group, start)
+}
+
+func (p *prefixCache) XReadGroupCtx(ctx context.Context, args XReadGroupArgs) ([]XStream, error) {
+	args.Streams = p.streams(args.Streams)
+	return p.stripStreams(p.Cache.XReadGroupCtx(ctx, args))
+}
+
+func (p *prefixCache) XAckCtx(ctx context.Context, stream, group string, ids ...string) (int64, error) {
+	return p.Cache.XAckCtx(ctx, p.key(stream), group, ids...)
+}
+
+// streams 为流名称追加前缀
+func (p *prefixCache) streams(streams map[string]string) map[string]string {
+	prefixed := make(map[string]string, len(streams))
+	for name, id := range streams {
+		prefixed[p.key(name)] = id
+	}
+	return prefixed
+}
+
+// stripStreams 去掉读取结果中流名称的前缀
+func (p *prefixCache) stripStreams(streams []XStream, err error) ([]XStream, error) {
+	if err != nil {
+		return nil, err
+	}
+	for i := range streams {
+		streams[i].Stream = strings.TrimPrefix(streams[i].Stream, p.prefix)
+	}
+	return streams, nil
+}
+
+func (p *prefixCache) Pipeline() Pipeliner {
+	return &prefixPipeline{Pipeliner: p.Cache.Pipeline(), p: p}
+}
+
+func (p *prefixCache) TxPipeline() Pipeliner {
+	return &prefixPipeline{Pipeliner: p.Cache.TxPipeline(), p: p}
+}
+
+// prefixPipeline 为命令的键追加前缀的命令队列
+type prefixPipeline struct {
+	Pipeliner
+	p *prefixCache
+}
+
+func (q *prefixPipeline) Get(key string) *Cmd {
+	return q.Pipeliner.Get(q.p.key(key))
+}
+
+func (q *prefixPipeline) Set(key string, value interface{}, expiration time.Duration) *Cmd {
+	return q.Pipeliner.Set(q.p.key(key), value, expiration)
+}
+
+func (q *prefixPipeline) Del(keys ...string) *Cmd {
+	return q.Pipeliner.Del(q.p.keys(keys)...)
+}
+
+func (q *prefixPipeline) Exists(keys ...string) *Cmd {
+	return q.Pipeliner.Exists(q.p.keys(keys)...)
+}
+
+func (q *prefixPipeline) Expire(key string, expiration time.Duration) *Cmd {
+	return q.Pipeliner.Expire(q.p.key(key), expiration)
+}
+
+func (q *prefixPipeline) Incr(key string) *Cmd {
+	return q.Pipeliner.Incr(q.p.key(key))
+}
+
+func (q *prefixPipeline) Decr(key string) *Cmd {
+	return q.Pipeliner.Decr(q.p.key(key))
+}
+
+func (q *prefixPipeline) IncrBy(key string, value int64) *Cmd {
+	return q.Pipeliner.IncrBy(q.p.key(key), value)
+}
+
+func (q *prefixPipeline) HGet(key, field string) *Cmd {
+	return q.Pipeliner.HGet(q.p.key(key), field)
+}
+
+func (q *prefixPipeline) HSet(key string, values ...interface{}) *Cmd {
+	return q.Pipeliner.HSet(q.p.key(key), values...)
+}
+
+func (q *prefixPipeline) HDel(key string, fields ...string) *Cmd {
+	return q.Pipeliner.HDel(q.p.key(key), fields...)
+}
+
+func (q *prefixPipeline) LPush(key string, values ...interface{}) *Cmd {
+	return q.Pipeliner.LPush(q.p.key(key), values...)
+}
+
+func (q *prefixPipeline) RPush(key string, values ...interface{}) *Cmd {
+	return q.Pipeliner.RPush(q.p.key(key), values...)
+}
+
+func (q *prefixPipeline) SAdd(key string, members ...interface{}) *Cmd {
+	return q.Pipeliner.SAdd(q.p.key(key), members...)
+}
+
+func (q *prefixPipeline) SRem(key string, members ...interface{}) *Cmd {
+	return q.Pipeliner.SRem(q.p.key(key), members...)
+}
+
+func (q *prefixPipeline) ZAdd(key string, members ...Z) *Cmd {
+	return q.Pipeliner.ZAdd(q.p.key(key), members...)
+}
+
+func (q *prefixPipeline) ZRem(key string, members ...interface{}) *Cmd {
+	return q.Pipeliner.ZRem(q.p.key(key), members...)
+}
+
+func (q *prefixPipeline) ZIncrBy(key string, increment float64, member string) *Cmd {
+	return q.Pipeliner.ZIncrBy(q.p.key(key), increment, member)
+}
@@ -0,0 +1,271 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Message 发布订阅消息
+type Message struct {
+	Channel string // 消息所在的频道
+	Pattern string // 按模式订阅时匹配的模式，按频道订阅时为空
+	Payload string
+}
+
+// Subscription 频道订阅
+type Subscription interface {
+	// Channel 返回接收消息的通道，订阅关闭后通道关闭
+	Channel() <-chan *Message
+	// Close 取消订阅
+	Close() error
+}
+
+// hub 进程内的发布订阅和阻塞等待，内存缓存和文件缓存使用
+//
+// 每个缓存实例拥有独立的 hub，语义与单个 Redis 实例一致：消息只投递给发布时已存在的订阅，
+// 不会持久化；阻塞读取在键写入后被唤醒并重试。
+type hub struct {
+	mu      sync.Mutex
+	subs    map[*hubSubscription]struct{}
+	waiters map[string]map[chan struct{}]struct{}
+}
+
+func newHub() *hub {
+	return &hub{
+		subs:    make(map[*hubSubscription]struct{}),
+		waiters: make(map[string]map[chan struct{}]struct{}),
+	}
+}
+
+// publish 向匹配的订阅投递消息，返回接收次数；同一订阅的频道和多个模式都匹配时各投递一次
+func (h *hub) publish(channel, payload string) int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var count int64
+	for sub := range h.subs {
+		if sub.channels[channel] {
+			sub.push(&Message{Channel: channel, Payload: payload})
+			count++
+		}
+		for _, pattern := range sub.patterns {
+			if matchPattern(pattern, channel) {
+				sub.push(&Message{Channel: channel, Pattern: pattern, Payload: payload})
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// subscribe 创建订阅，channels 按频道名精确匹配，patterns 按通配符匹配
+func (h *hub) subscribe(channels, patterns []string) *hubSubscription {
+	sub := &hubSubscription{
+		hub:      h,
+		channels: make(map[string]bool, len(channels)),
+		patterns: patterns,
+		notify:   make(chan struct{}, 1),
+		out:      make(chan *Message),
+		done:     make(chan struct{}),
+	}
+	for _, channel := range channels {
+		sub.channels[channel] = true
+	}
+
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+
+	go sub.pump()
+	return sub
+}
+
+// close 关闭所有订阅
+func (h *hub) close() {
+	h.mu.Lock()
+	subs := make([]*hubSubscription, 0, len(h.subs))
+	for sub := range h.subs {
+		subs = append(subs, sub)
+	}
+	h.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.Close()
+	}
+}
+
+// watch 登记等待 keys 中任一键写入，返回的通道在写入时收到通知，cancel 取消登记
+func (h *hub) watch(keys []string) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	h.mu.Lock()
+	for _, key := range keys {
+		if h.waiters[key] == nil {
+			h.waiters[key] = make(map[chan struct{}]struct{})
+		}
+		h.waiters[key][ch] = struct{}{}
+	}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		for _, key := range keys {
+			delete(h.waiters[key], ch)
+			if len(h.waiters[key]) == 0 {
+				delete(h.waiters, key)
+			}
+		}
+	}
+}
+
+// signal 通知等待该键的阻塞读取
+func (h *hub) signal(key string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.waiters[key] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// wait 执行 try，未取得结果时等待 keys 写入后重试，直到 try 返回 true、超时或 ctx 结束
+//
+// timeout 为 0 时只执行一次，负数表示不限时。超时返回 false 和 nil，ctx 结束返回 ctx.Err()。
+func (h *hub) wait(ctx context.Context, timeout time.Duration, keys []string, try func() (bool, error)) (bool, error) {
+	if timeout == 0 {
+		return try()
+	}
+
+	// 先登记再尝试，避免尝试和等待之间的写入被遗漏
+	ch, cancel := h.watch(keys)
+	defer cancel()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for {
+		ok, err := try()
+		if err != nil || ok {
+			return ok, err
+		}
+		select {
+		case <-ch:
+		case <-expired:
+			return false, nil
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+}
+
+// hubSubscription 进程内订阅，消息先进入队列再由协程写入通道，发布方不会被慢速的订阅方阻塞
+type hubSubscription struct {
+	hub      *hub
+	channels map[string]bool
+	patterns []string
+
+	mu     sync.Mutex
+	queue  []*Message
+	notify chan struct{}
+	out    chan *Message
+	done   chan struct{}
+	once   sync.Once
+}
+
+// push 消息入队，调用方持有 hub 锁
+func (s *hubSubscription) push(msg *Message) {
+	s.mu.Lock()
+	s.queue = append(s.queue, msg)
+	s.mu.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// pump 按顺序将队列中的消息写入通道
+func (s *hubSubscription) pump() {
+	defer close(s.out)
+	for {
+		select {
+		case <-s.notify:
+		case <-s.done:
+			return
+		}
+
+		s.mu.Lock()
+		batch := s.queue
+		s.queue = nil
+		s.mu.Unlock()
+
+		for _, msg := range batch {
+			select {
+			case s.out <- msg:
+			case <-s.done:
+				return
+			}
+		}
+	}
+}
+
+// Channel 返回接收消息的通道
+func (s *hubSubscription) Channel() <-chan *Message {
+	return s.out
+}
+
+// Close 取消订阅，未读取的消息被丢弃
+func (s *hubSubscription) Close() error {
+	s.once.Do(func() {
+		s.hub.mu.Lock()
+		delete(s.hub.subs, s)
+		s.hub.mu.Unlock()
+		close(s.done)
+	})
+	return nil
+}
+
+// blockingPop 依次尝试从 keys 中弹出元素，全部为空时等待写入，timeout 为 0 时等待到 ctx 结束
+//
+// pop 接收调用方的键名，返回 ErrKeyNotFound 表示列表为空；fullKey 将键名转换为登记等待的完整键名。
+func (h *hub) blockingPop(ctx context.Context, timeout time.Duration, keys []string,
+	fullKey func(string) string, pop func(ctx context.Context, key string) (string, error)) ([]string, error) {
+	if timeout == 0 {
+		timeout = -1
+	}
+	watched := make([]string, len(keys))
+	for i, key := range keys {
+		watched[i] = fullKey(key)
+	}
+
+	var result []string
+	ok, err := h.wait(ctx, timeout, watched, func() (bool, error) {
+		for _, key := range keys {
+			value, err := pop(ctx, key)
+			if errors.Is(err, ErrKeyNotFound) {
+				continue
+			}
+			if err != nil {
+				return false, err
+			}
+			result = []string{key, value}
+			return true, nil
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return result, nil
+}
@@ -2,7 +2,15 @@ package cache
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -10,42 +18,253 @@ import (
 	"github.com/zhoudm1743/go-web/core/log"
 )
 
-// RedisCache Redis 缓存实现
+// 值相等时删除或续期的脚本，保证比较和写入的原子性
+var (
+	compareAndDelScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+	compareAndExpireScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	if tonumber(ARGV[2]) > 0 then
+		return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	end
+	return redis.call("PERSIST", KEYS[1]) + 1
+end
+return 0`)
+)
+
+// errRedisNil 键不存在，同时匹配 ErrKeyNotFound 和 redis.Nil
+var errRedisNil = fmt.Errorf("%w: %w", ErrKeyNotFound, redis.Nil)
+
+// errorHook 将 Redis 的错误转换为与其他驱动一致的错误：redis.Nil 和 no such key 转换为 ErrKeyNotFound，
+// WRONGTYPE 转换为 ErrTypeMismatch，原始错误仍可通过 errors.Is 匹配
+type errorHook struct{}
+
+func (errorHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (errorHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		err := next(ctx, cmd)
+		if mapped := mapRedisError(err); mapped != err {
+			cmd.SetErr(mapped)
+			return mapped
+		}
+		return err
+	}
+}
+
+func (errorHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		err := next(ctx, cmds)
+		for _, cmd := range cmds {
+			if mapped := mapRedisError(cmd.Err()); mapped != cmd.Err() {
+				cmd.SetErr(mapped)
+			}
+		}
+		return mapRedisError(err)
+	}
+}
+
+// mapRedisError 转换单个错误
+func mapRedisError(err error) error {
+	switch {
+	case err == redis.Nil:
+		return errRedisNil
+	case err != nil && strings.HasPrefix(err.Error(), "WRONGTYPE"):
+		return fmt.Errorf("%w: %w", ErrTypeMismatch, err)
+	case err != nil && err.Error() == "ERR no such key":
+		// RENAME 的键不存在
+		return fmt.Errorf("%w: %w", ErrKeyNotFound, err)
+	default:
+		return err
+	}
+}
+
+// RedisCache Redis 缓存实现，支持单节点、哨兵和集群模式
+//
+// 集群模式下 Del、Exists、MGet、MSet 按键拆分执行，不要求键在同一个槽，但 MSet 不再是原子的；
+// Keys、Scan 遍历所有主节点。Rename、多个键的 BLPop/BRPop、多个流的 XRead 以及 TxPipeline 的原子性
+// 仍要求键在同一个槽，可以使用哈希标签，如 {order:1}:items 和 {order:1}:total。
 type RedisCache struct {
-	client *redis.Client
-	logger log.Logger
-	prefix string
+	client  redis.UniversalClient
+	cluster *redis.ClusterClient // 集群模式时与 client 相同
+	logger  log.Logger
+	prefix  string
 }
 
-// NewRedisCache 创建新的 Redis 缓存实例
-func NewRedisCache(cfg *conf.Config, log log.Logger) (Cache, error) {
-	// 创建 Redis 客户端
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%d", cfg.Cache.Host, cfg.Cache.Port),
-		Password: cfg.Cache.Password,
-		DB:       cfg.Cache.DB,
-	})
+// maxConnectBackoff 启动重试的最长等待时间
+const maxConnectBackoff = 30 * time.Second
 
-	// 测试连接
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// NewRedisCache 创建新的 Redis 缓存实例，连接失败时按 ConnectRetries 和 ConnectBackoff 重试
+func NewRedisCache(cfg *conf.Config, log log.Logger) (Cache, error) {
+	opts := cfg.Cache.Redis
+	rdb, err := newRedisClient(cfg.Cache)
+	if err != nil {
+		return nil, err
+	}
+	rdb.AddHook(errorHook{})
 
-	if err := rdb.Ping(ctx).Err(); err != nil {
+	if err := connectRedis(rdb, opts, log); err != nil {
+		rdb.Close()
 		return nil, fmt.Errorf("Redis 连接失败: %w", err)
 	}
 
 	log.WithFields(map[string]interface{}{
-		"host":   cfg.Cache.Host,
-		"port":   cfg.Cache.Port,
+		"mode":   redisMode(opts.Mode),
+		"addrs":  redisAddrs(cfg.Cache),
 		"db":     cfg.Cache.DB,
+		"tls":    opts.TLS.Enabled,
 		"prefix": cfg.Cache.Prefix,
 	}).Info("Redis 连接成功")
 
-	return &RedisCache{
+	r := &RedisCache{
 		client: rdb,
 		logger: log,
 		prefix: cfg.Cache.Prefix,
-	}, nil
+	}
+	r.cluster, _ = rdb.(*redis.ClusterClient)
+	return r, nil
+}
+
+// redisMode 部署模式，默认为单节点
+func redisMode(mode string) string {
+	if mode == "" {
+		return "single"
+	}
+	return mode
+}
+
+// redisAddrs 节点地址，未配置 Addrs 时使用 Host:Port
+func redisAddrs(cfg conf.CacheConfig) []string {
+	if len(cfg.Redis.Addrs) > 0 {
+		return cfg.Redis.Addrs
+	}
+	return []string{fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)}
+}
+
+// newRedisClient 按部署模式创建客户端，命令遵循 ctx 的截止时间和取消，阻塞读取可以被 ctx 中断
+func newRedisClient(cfg conf.CacheConfig) (redis.UniversalClient, error) {
+	opts := cfg.Redis
+	tlsConfig, err := redisTLS(opts.TLS)
+	if err != nil {
+		return nil, err
+	}
+	addrs := redisAddrs(cfg)
+
+	switch redisMode(opts.Mode) {
+	case "single":
+		return redis.NewClient(&redis.Options{
+			Addr:                  addrs[0],
+			Username:              opts.Username,
+			Password:              cfg.Password,
+			DB:                    cfg.DB,
+			PoolSize:              opts.PoolSize,
+			MinIdleConns:          opts.MinIdleConns,
+			DialTimeout:           opts.DialTimeout,
+			ReadTimeout:           opts.ReadTimeout,
+			WriteTimeout:          opts.WriteTimeout,
+			TLSConfig:             tlsConfig,
+			ContextTimeoutEnabled: true,
+		}), nil
+	case "sentinel":
+		if opts.MasterName == "" {
+			return nil, fmt.Errorf("Redis 哨兵模式需要配置 masterName")
+		}
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:            opts.MasterName,
+			SentinelAddrs:         addrs,
+			SentinelUsername:      opts.SentinelUsername,
+			SentinelPassword:      opts.SentinelPassword,
+			Username:              opts.Username,
+			Password:              cfg.Password,
+			DB:                    cfg.DB,
+			PoolSize:              opts.PoolSize,
+			MinIdleConns:          opts.MinIdleConns,
+			DialTimeout:           opts.DialTimeout,
+			ReadTimeout:           opts.ReadTimeout,
+			WriteTimeout:          opts.WriteTimeout,
+			TLSConfig:             tlsConfig,
+			ContextTimeoutEnabled: true,
+		}), nil
+	case "cluster":
+		// 集群只有 0 号数据库，忽略 DB
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:                 addrs,
+			Username:              opts.Username,
+			Password:              cfg.Password,
+			PoolSize:              opts.PoolSize,
+			MinIdleConns:          opts.MinIdleConns,
+			DialTimeout:           opts.DialTimeout,
+			ReadTimeout:           opts.ReadTimeout,
+			WriteTimeout:          opts.WriteTimeout,
+			TLSConfig:             tlsConfig,
+			ContextTimeoutEnabled: true,
+		}), nil
+	default:
+		return nil, fmt.Errorf("不支持的 Redis 部署模式: %s", opts.Mode)
+	}
+}
+
+// redisTLS 创建 TLS 配置，未启用时返回 nil；ServerName 为空时由连接地址推断
+func redisTLS(opts conf.RedisTLSConfig) (*tls.Config, error) {
+	if !opts.Enabled {
+		return nil, nil
+	}
+
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         opts.ServerName,
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+	}
+	if opts.CAFile != "" {
+		data, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取 Redis CA 证书失败: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("Redis CA 证书 %s 中没有有效的证书", opts.CAFile)
+		}
+		config.RootCAs = pool
+	}
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("加载 Redis 客户端证书失败: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// connectRedis 检查连接，失败时等待后重试，等待时间每次翻倍
+func connectRedis(client redis.UniversalClient, opts conf.RedisCacheConfig, logger log.Logger) error {
+	backoff := opts.ConnectBackoff
+	if backoff <= 0 {
+		backoff = 500 * time.Millisecond
+	}
+
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := client.Ping(ctx).Err()
+		cancel()
+		if err == nil || attempt >= opts.ConnectRetries {
+			return err
+		}
+
+		logger.WithFields(map[string]interface{}{
+			"attempt": attempt + 1,
+			"retries": opts.ConnectRetries,
+			"backoff": backoff.String(),
+			"error":   err.Error(),
+		}).Warn("Redis 连接失败，稍后重试")
+		time.Sleep(backoff)
+		backoff = min(backoff*2, maxConnectBackoff)
+	}
 }
 
 // buildKey 构建带前缀的键
@@ -56,6 +275,20 @@ func (r *RedisCache) buildKey(key string) string {
 	return r.prefix + key
 }
 
+// buildKeys 批量构建带前缀的键
+func (r *RedisCache) buildKeys(keys []string) []string {
+	prefixedKeys := make([]string, len(keys))
+	for i, key := range keys {
+		prefixedKeys[i] = r.buildKey(key)
+	}
+	return prefixedKeys
+}
+
+// stripKey 去掉键的前缀
+func (r *RedisCache) stripKey(key string) string {
+	return strings.TrimPrefix(key, r.prefix)
+}
+
 // GetClient 获取原始 Redis 客户端
 func (r *RedisCache) GetClient() interface{} {
 	return r.client
@@ -70,192 +303,209 @@ func (r *RedisCache) Close() error {
 
 // 基础操作
 func (r *RedisCache) Get(key string) (string, error) {
-	return r.client.Get(context.Background(), r.buildKey(key)).Result()
+	return r.GetCtx(context.Background(), key)
 }
 
 func (r *RedisCache) Set(key string, value interface{}, expiration time.Duration) error {
-	return r.client.Set(context.Background(), r.buildKey(key), value, expiration).Err()
+	return r.SetCtx(context.Background(), key, value, expiration)
 }
 
 func (r *RedisCache) Del(keys ...string) (int64, error) {
-	// 转换所有键为带前缀的键
-	prefixedKeys := make([]string, len(keys))
-	for i, key := range keys {
-		prefixedKeys[i] = r.buildKey(key)
-	}
-	return r.client.Del(context.Background(), prefixedKeys...).Result()
+	return r.DelCtx(context.Background(), keys...)
 }
 
 func (r *RedisCache) Exists(keys ...string) (int64, error) {
-	// 转换所有键为带前缀的键
-	prefixedKeys := make([]string, len(keys))
-	for i, key := range keys {
-		prefixedKeys[i] = r.buildKey(key)
-	}
-	return r.client.Exists(context.Background(), prefixedKeys...).Result()
+	return r.ExistsCtx(context.Background(), keys...)
 }
 
 func (r *RedisCache) Expire(key string, expiration time.Duration) error {
-	return r.client.Expire(context.Background(), r.buildKey(key), expiration).Err()
+	return r.ExpireCtx(context.Background(), key, expiration)
 }
 
 func (r *RedisCache) TTL(key string) (time.Duration, error) {
-	return r.client.TTL(context.Background(), r.buildKey(key)).Result()
+	return r.TTLCtx(context.Background(), key)
+}
+
+func (r *RedisCache) Type(key string) (string, error) {
+	return r.TypeCtx(context.Background(), key)
+}
+
+func (r *RedisCache) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	return r.SetNXCtx(context.Background(), key, value, expiration)
+}
+
+func (r *RedisCache) CompareAndDel(key, value string) (bool, error) {
+	return r.CompareAndDelCtx(context.Background(), key, value)
+}
+
+func (r *RedisCache) CompareAndExpire(key, value string, expiration time.Duration) (bool, error) {
+	return r.CompareAndExpireCtx(context.Background(), key, value, expiration)
+}
+
+func (r *RedisCache) GetSet(key string, value interface{}) (string, error) {
+	return r.GetSetCtx(context.Background(), key, value)
+}
+
+func (r *RedisCache) MGet(keys ...string) ([]interface{}, error) {
+	return r.MGetCtx(context.Background(), keys...)
+}
+
+func (r *RedisCache) MSet(values ...interface{}) error {
+	return r.MSetCtx(context.Background(), values...)
+}
+
+func (r *RedisCache) Persist(key string) (bool, error) {
+	return r.PersistCtx(context.Background(), key)
+}
+
+func (r *RedisCache) Rename(key, newKey string) error {
+	return r.RenameCtx(context.Background(), key, newKey)
 }
 
 // 字符串操作
 func (r *RedisCache) Incr(key string) (int64, error) {
-	return r.client.Incr(context.Background(), r.buildKey(key)).Result()
+	return r.IncrCtx(context.Background(), key)
 }
 
 func (r *RedisCache) Decr(key string) (int64, error) {
-	return r.client.Decr(context.Background(), r.buildKey(key)).Result()
+	return r.DecrCtx(context.Background(), key)
 }
 
 func (r *RedisCache) IncrBy(key string, value int64) (int64, error) {
-	return r.client.IncrBy(context.Background(), r.buildKey(key), value).Result()
+	return r.IncrByCtx(context.Background(), key, value)
 }
 
 // 哈希操作
 func (r *RedisCache) HGet(key, field string) (string, error) {
-	return r.client.HGet(context.Background(), r.buildKey(key), field).Result()
+	return r.HGetCtx(context.Background(), key, field)
 }
 
 func (r *RedisCache) HSet(key string, values ...interface{}) (int64, error) {
-	return r.client.HSet(context.Background(), r.buildKey(key), values...).Result()
+	return r.HSetCtx(context.Background(), key, values...)
 }
 
 func (r *RedisCache) HDel(key string, fields ...string) (int64, error) {
-	return r.client.HDel(context.Background(), r.buildKey(key), fields...).Result()
+	return r.HDelCtx(context.Background(), key, fields...)
 }
 
 func (r *RedisCache) HGetAll(key string) (map[string]string, error) {
-	return r.client.HGetAll(context.Background(), r.buildKey(key)).Result()
+	return r.HGetAllCtx(context.Background(), key)
 }
 
 func (r *RedisCache) HExists(key, field string) (bool, error) {
-	return r.client.HExists(context.Background(), r.buildKey(key), field).Result()
+	return r.HExistsCtx(context.Background(), key, field)
 }
 
 func (r *RedisCache) HLen(key string) (int64, error) {
-	return r.client.HLen(context.Background(), r.buildKey(key)).Result()
+	return r.HLenCtx(context.Background(), key)
 }
 
 // 列表操作
 func (r *RedisCache) LPush(key string, values ...interface{}) (int64, error) {
-	return r.client.LPush(context.Background(), r.buildKey(key), values...).Result()
+	return r.LPushCtx(context.Background(), key, values...)
 }
 
 func (r *RedisCache) RPush(key string, values ...interface{}) (int64, error) {
-	return r.client.RPush(context.Background(), r.buildKey(key), values...).Result()
+	return r.RPushCtx(context.Background(), key, values...)
 }
 
 func (r *RedisCache) LPop(key string) (string, error) {
-	return r.client.LPop(context.Background(), r.buildKey(key)).Result()
+	return r.LPopCtx(context.Background(), key)
 }
 
 func (r *RedisCache) RPop(key string) (string, error) {
-	return r.client.RPop(context.Background(), r.buildKey(key)).Result()
+	return r.RPopCtx(context.Background(), key)
 }
 
 func (r *RedisCache) LLen(key string) (int64, error) {
-	return r.client.LLen(context.Background(), r.buildKey(key)).Result()
+	return r.LLenCtx(context.Background(), key)
 }
 
 func (r *RedisCache) LRange(key string, start, stop int64) ([]string, error) {
-	return r.client.LRange(context.Background(), r.buildKey(key), start, stop).Result()
+	return r.LRangeCtx(context.Background(), key, start, stop)
 }
 
 // 集合操作
 func (r *RedisCache) SAdd(key string, members ...interface{}) (int64, error) {
-	return r.client.SAdd(context.Background(), r.buildKey(key), members...).Result()
+	return r.SAddCtx(context.Background(), key, members...)
 }
 
 func (r *RedisCache) SRem(key string, members ...interface{}) (int64, error) {
-	return r.client.SRem(context.Background(), r.buildKey(key), members...).Result()
+	return r.SRemCtx(context.Background(), key, members...)
 }
 
 func (r *RedisCache) SMembers(key string) ([]string, error) {
-	return r.client.SMembers(context.Background(), r.buildKey(key)).Result()
+	return r.SMembersCtx(context.Background(), key)
 }
 
 func (r *RedisCache) SIsMember(key string, member interface{}) (bool, error) {
-	return r.client.SIsMember(context.Background(), r.buildKey(key), member).Result()
+	return r.SIsMemberCtx(context.Background(), key, member)
 }
 
 func (r *RedisCache) SCard(key string) (int64, error) {
-	return r.client.SCard(context.Background(), r.buildKey(key)).Result()
+	return r.SCardCtx(context.Background(), key)
 }
 
 // 有序集合操作
 func (r *RedisCache) ZAdd(key string, members ...Z) (int64, error) {
-	// 转换为redis.Z
-	redisMembers := make([]redis.Z, len(members))
-	for i, m := range members {
-		redisMembers[i] = redis.Z{
-			Score:  m.Score,
-			Member: m.Member,
-		}
-	}
-
-	return r.client.ZAdd(context.Background(), r.buildKey(key), redisMembers...).Result()
+	return r.ZAddCtx(context.Background(), key, members...)
 }
 
 func (r *RedisCache) ZRem(key string, members ...interface{}) (int64, error) {
-	return r.client.ZRem(context.Background(), r.buildKey(key), members...).Result()
+	return r.ZRemCtx(context.Background(), key, members...)
 }
 
 func (r *RedisCache) ZRange(key string, start, stop int64) ([]string, error) {
-	return r.client.ZRange(context.Background(), r.buildKey(key), start, stop).Result()
+	return r.ZRangeCtx(context.Background(), key, start, stop)
 }
 
 func (r *RedisCache) ZRangeWithScores(key string, start, stop int64) ([]Z, error) {
-	result, err := r.client.ZRangeWithScores(context.Background(), r.buildKey(key), start, stop).Result()
-	if err != nil {
-		return nil, err
-	}
-
-	// 转换为我们自定义的Z结构体
-	members := make([]Z, len(result))
-	for i, m := range result {
-		members[i] = Z{
-			Score:  m.Score,
-			Member: m.Member,
-		}
-	}
-
-	return members, nil
+	return r.ZRangeWithScoresCtx(context.Background(), key, start, stop)
 }
 
 func (r *RedisCache) ZCard(key string) (int64, error) {
-	return r.client.ZCard(context.Background(), r.buildKey(key)).Result()
+	return r.ZCardCtx(context.Background(), key)
 }
 
 func (r *RedisCache) ZScore(key, member string) (float64, error) {
-	return r.client.ZScore(context.Background(), r.buildKey(key), member).Result()
+	return r.ZScoreCtx(context.Background(), key, member)
+}
+
+func (r *RedisCache) ZRangeByScore(key string, opt ZRangeBy) ([]string, error) {
+	return r.ZRangeByScoreCtx(context.Background(), key, opt)
+}
+
+func (r *RedisCache) ZRevRange(key string, start, stop int64) ([]string, error) {
+	return r.ZRevRangeCtx(context.Background(), key, start, stop)
+}
+
+func (r *RedisCache) ZIncrBy(key string, increment float64, member string) (float64, error) {
+	return r.ZIncrByCtx(context.Background(), key, increment, member)
+}
+
+func (r *RedisCache) ZRank(key, member string) (int64, error) {
+	return r.ZRankCtx(context.Background(), key, member)
+}
+
+func (r *RedisCache) ZRemRangeByScore(key, min, max string) (int64, error) {
+	return r.ZRemRangeByScoreCtx(context.Background(), key, min, max)
+}
+
+func (r *RedisCache) ZCount(key, min, max string) (int64, error) {
+	return r.ZCountCtx(context.Background(), key, min, max)
 }
 
 // 其他操作
 func (r *RedisCache) Keys(pattern string) ([]string, error) {
-	// 对于 Keys 操作，我们需要添加前缀到模式中
-	prefixedPattern := r.buildKey(pattern)
-	keys, err := r.client.Keys(context.Background(), prefixedPattern).Result()
-	if err != nil {
-		return nil, err
-	}
-
-	// 移除前缀
-	if r.prefix != "" {
-		for i, key := range keys {
-			keys[i] = key[len(r.prefix):]
-		}
-	}
+	return r.KeysCtx(context.Background(), pattern)
+}
 
-	return keys, nil
+func (r *RedisCache) Scan(cursor uint64, match string, count int64) ([]string, uint64, error) {
+	return r.ScanCtx(context.Background(), cursor, match, count)
 }
 
 func (r *RedisCache) Ping() error {
-	return r.client.Ping(context.Background()).Err()
+	return r.PingCtx(context.Background())
 }
 
 // ================== 带 Context 的方法 ==================
@@ -270,21 +520,40 @@ func (r *RedisCache) SetCtx(ctx context.Context, key string, value interface{},
 }
 
 func (r *RedisCache) DelCtx(ctx context.Context, keys ...string) (int64, error) {
-	// 转换所有键为带前缀的键
-	prefixedKeys := make([]string, len(keys))
-	for i, key := range keys {
-		prefixedKeys[i] = r.buildKey(key)
+	if r.cluster != nil && len(keys) > 1 {
+		return r.sumPerKey(ctx, keys, func(pipe redis.Pipeliner, key string) *redis.IntCmd {
+			return pipe.Del(ctx, key)
+		})
 	}
-	return r.client.Del(ctx, prefixedKeys...).Result()
+	return r.client.Del(ctx, r.buildKeys(keys)...).Result()
 }
 
 func (r *RedisCache) ExistsCtx(ctx context.Context, keys ...string) (int64, error) {
-	// 转换所有键为带前缀的键
-	prefixedKeys := make([]string, len(keys))
+	if r.cluster != nil && len(keys) > 1 {
+		return r.sumPerKey(ctx, keys, func(pipe redis.Pipeliner, key string) *redis.IntCmd {
+			return pipe.Exists(ctx, key)
+		})
+	}
+	return r.client.Exists(ctx, r.buildKeys(keys)...).Result()
+}
+
+// sumPerKey 集群模式下将多键命令拆分为每个键一条命令，在管道中执行后累加结果，
+// 避免键不在同一个槽时返回 CROSSSLOT 错误
+func (r *RedisCache) sumPerKey(ctx context.Context, keys []string, queue func(pipe redis.Pipeliner, key string) *redis.IntCmd) (int64, error) {
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.IntCmd, len(keys))
 	for i, key := range keys {
-		prefixedKeys[i] = r.buildKey(key)
+		cmds[i] = queue(pipe, r.buildKey(key))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	var sum int64
+	for _, cmd := range cmds {
+		sum += cmd.Val()
 	}
-	return r.client.Exists(ctx, prefixedKeys...).Result()
+	return sum, nil
 }
 
 func (r *RedisCache) ExpireCtx(ctx context.Context, key string, expiration time.Duration) error {
@@ -295,93 +564,183 @@ func (r *RedisCache) TTLCtx(ctx context.Context, key string) (time.Duration, err
 	return r.client.TTL(ctx, r.buildKey(key)).Result()
 }
 
+func (r *RedisCache) TypeCtx(ctx context.Context, key string) (string, error) {
+	return r.client.Type(ctx, r.buildKey(key)).Result()
+}
+
+func (r *RedisCache) SetNXCtx(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, r.buildKey(key), value, expiration).Result()
+}
+
+func (r *RedisCache) CompareAndDelCtx(ctx context.Context, key, value string) (bool, error) {
+	n, err := compareAndDelScript.Run(ctx, r.client, []string{r.buildKey(key)}, value).Int64()
+	return n > 0, err
+}
+
+func (r *RedisCache) CompareAndExpireCtx(ctx context.Context, key, value string, expiration time.Duration) (bool, error) {
+	n, err := compareAndExpireScript.Run(ctx, r.client, []string{r.buildKey(key)}, value, expiration.Milliseconds()).Int64()
+	return n > 0, err
+}
+
+func (r *RedisCache) GetSetCtx(ctx context.Context, key string, value interface{}) (string, error) {
+	return r.client.GetSet(ctx, r.buildKey(key), value).Result()
+}
+
+// MGetCtx 批量读取，不是字符串的键在 Redis 中同样返回 nil
+func (r *RedisCache) MGetCtx(ctx context.Context, keys ...string) ([]interface{}, error) {
+	if len(keys) == 0 {
+		return []interface{}{}, nil
+	}
+	if r.cluster == nil {
+		return r.client.MGet(ctx, r.buildKeys(keys)...).Result()
+	}
+
+	// 集群模式下逐个读取，与 MGET 一致，不存在或不是字符串的键返回 nil
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.StringCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.Get(ctx, r.buildKey(key))
+	}
+	pipe.Exec(ctx)
+
+	values := make([]interface{}, len(keys))
+	for i, cmd := range cmds {
+		switch err := cmd.Err(); {
+		case err == nil:
+			values[i] = cmd.Val()
+		case errors.Is(err, ErrKeyNotFound), errors.Is(err, ErrTypeMismatch):
+		default:
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// MSetCtx 批量写入，参数格式与 HSet 相同，键追加前缀
+func (r *RedisCache) MSetCtx(ctx context.Context, values ...interface{}) error {
+	pairs, err := hashFields(values)
+	if err != nil {
+		return err
+	}
+	if len(pairs) == 0 {
+		return nil
+	}
+	if r.cluster != nil {
+		// 集群模式下逐个写入，键可以分布在不同的槽
+		pipe := r.client.Pipeline()
+		for i := 0; i < len(pairs); i += 2 {
+			pipe.Set(ctx, r.buildKey(pairs[i]), pairs[i+1], 0)
+		}
+		_, err := pipe.Exec(ctx)
+		return err
+	}
+
+	args := make([]interface{}, len(pairs))
+	for i := 0; i < len(pairs); i += 2 {
+		args[i], args[i+1] = r.buildKey(pairs[i]), pairs[i+1]
+	}
+	return r.client.MSet(ctx, args...).Err()
+}
+
+func (r *RedisCache) PersistCtx(ctx context.Context, key string) (bool, error) {
+	return r.client.Persist(ctx, r.buildKey(key)).Result()
+}
+
+func (r *RedisCache) RenameCtx(ctx context.Context, key, newKey string) error {
+	return r.client.Rename(ctx, r.buildKey(key), r.buildKey(newKey)).Err()
+}
+
 // 字符串操作
 func (r *RedisCache) IncrCtx(ctx context.Context, key string) (int64, error) {
-	return r.client.Incr(ctx, key).Result()
+	return r.client.Incr(ctx, r.buildKey(key)).Result()
 }
 
 func (r *RedisCache) DecrCtx(ctx context.Context, key string) (int64, error) {
-	return r.client.Decr(ctx, key).Result()
+	return r.client.Decr(ctx, r.buildKey(key)).Result()
 }
 
 func (r *RedisCache) IncrByCtx(ctx context.Context, key string, value int64) (int64, error) {
-	return r.client.IncrBy(ctx, key, value).Result()
+	return r.client.IncrBy(ctx, r.buildKey(key), value).Result()
 }
 
 // 哈希操作
 func (r *RedisCache) HGetCtx(ctx context.Context, key, field string) (string, error) {
-	return r.client.HGet(ctx, key, field).Result()
+	return r.client.HGet(ctx, r.buildKey(key), field).Result()
 }
 
 func (r *RedisCache) HSetCtx(ctx context.Context, key string, values ...interface{}) (int64, error) {
-	return r.client.HSet(ctx, key, values...).Result()
+	return r.client.HSet(ctx, r.buildKey(key), values...).Result()
 }
 
 func (r *RedisCache) HDelCtx(ctx context.Context, key string, fields ...string) (int64, error) {
-	return r.client.HDel(ctx, key, fields...).Result()
+	return r.client.HDel(ctx, r.buildKey(key), fields...).Result()
 }
 
 func (r *RedisCache) HGetAllCtx(ctx context.Context, key string) (map[string]string, error) {
-	return r.client.HGetAll(ctx, key).Result()
+	return r.client.HGetAll(ctx, r.buildKey(key)).Result()
 }
 
 func (r *RedisCache) HExistsCtx(ctx context.Context, key, field string) (bool, error) {
-	return r.client.HExists(ctx, key, field).Result()
+	return r.client.HExists(ctx, r.buildKey(key), field).Result()
 }
 
 func (r *RedisCache) HLenCtx(ctx context.Context, key string) (int64, error) {
-	return r.client.HLen(ctx, key).Result()
+	return r.client.HLen(ctx, r.buildKey(key)).Result()
 }
 
 // 列表操作
 func (r *RedisCache) LPushCtx(ctx context.Context, key string, values ...interface{}) (int64, error) {
-	return r.client.LPush(ctx, key, values...).Result()
+	return r.client.LPush(ctx, r.buildKey(key), values...).Result()
 }
 
 func (r *RedisCache) RPushCtx(ctx context.Context, key string, values ...interface{}) (int64, error) {
-	return r.client.RPush(ctx, key, values...).Result()
+	return r.client.RPush(ctx, r.buildKey(key), values...).Result()
 }
 
 func (r *RedisCache) LPopCtx(ctx context.Context, key string) (string, error) {
-	return r.client.LPop(ctx, key).Result()
+	return r.client.LPop(ctx, r.buildKey(key)).Result()
 }
 
 func (r *RedisCache) RPopCtx(ctx context.Context, key string) (string, error) {
-	return r.client.RPop(ctx, key).Result()
+	return r.client.RPop(ctx, r.buildKey(key)).Result()
 }
 
 func (r *RedisCache) LLenCtx(ctx context.Context, key string) (int64, error) {
-	return r.client.LLen(ctx, key).Result()
+	return r.client.LLen(ctx, r.buildKey(key)).Result()
 }
 
 func (r *RedisCache) LRangeCtx(ctx context.Context, key string, start, stop int64) ([]string, error) {
-	return r.client.LRange(ctx, key, start, stop).Result()
+	return r.client.LRange(ctx, r.buildKey(key), start, stop).Result()
 }
 
 // 集合操作
 func (r *RedisCache) SAddCtx(ctx context.Context, key string, members ...interface{}) (int64, error) {
-	return r.client.SAdd(ctx, key, members...).Result()
+	return r.client.SAdd(ctx, r.buildKey(key), members...).Result()
 }
 
 func (r *RedisCache) SRemCtx(ctx context.Context, key string, members ...interface{}) (int64, error) {
-	return r.client.SRem(ctx, key, members...).Result()
+	return r.client.SRem(ctx, r.buildKey(key), members...).Result()
 }
 
 func (r *RedisCache) SMembersCtx(ctx context.Context, key string) ([]string, error) {
-	return r.client.SMembers(ctx, key).Result()
+	return r.client.SMembers(ctx, r.buildKey(key)).Result()
 }
 
 func (r *RedisCache) SIsMemberCtx(ctx context.Context, key string, member interface{}) (bool, error) {
-	return r.client.SIsMember(ctx, key, member).Result()
+	return r.client.SIsMember(ctx, r.buildKey(key), member).Result()
 }
 
 func (r *RedisCache) SCardCtx(ctx context.Context, key string) (int64, error) {
-	return r.client.SCard(ctx, key).Result()
+	return r.client.SCard(ctx, r.buildKey(key)).Result()
 }
 
 // 有序集合操作
 func (r *RedisCache) ZAddCtx(ctx context.Context, key string, members ...Z) (int64, error) {
-	// 转换为redis.Z
+	return r.client.ZAdd(ctx, r.buildKey(key), redisZ(members)...).Result()
+}
+
+// redisZ 转换为 redis.Z
+func redisZ(members []Z) []redis.Z {
 	redisMembers := make([]redis.Z, len(members))
 	for i, m := range members {
 		redisMembers[i] = redis.Z{
@@ -389,8 +748,7 @@ func (r *RedisCache) ZAddCtx(ctx context.Context, key string, members ...Z) (int
 			Member: m.Member,
 		}
 	}
-
-	return r.client.ZAdd(ctx, r.buildKey(key), redisMembers...).Result()
+	return redisMembers
 }
 
 func (r *RedisCache) ZRemCtx(ctx context.Context, key string, members ...interface{}) (int64, error) {
@@ -427,11 +785,665 @@ func (r *RedisCache) ZScoreCtx(ctx context.Context, key, member string) (float64
 	return r.client.ZScore(ctx, r.buildKey(key), member).Result()
 }
 
+func (r *RedisCache) ZRangeByScoreCtx(ctx context.Context, key string, opt ZRangeBy) ([]string, error) {
+	by := &redis.ZRangeBy{Min: opt.Min, Max: opt.Max, Offset: opt.Offset, Count: opt.Count}
+	if by.Offset > 0 && by.Count <= 0 {
+		// LIMIT 的数量为负数时返回偏移之后的全部成员
+		by.Count = -1
+	}
+	return r.client.ZRangeByScore(ctx, r.buildKey(key), by).Result()
+}
+
+func (r *RedisCache) ZRevRangeCtx(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	return r.client.ZRevRange(ctx, r.buildKey(key), start, stop).Result()
+}
+
+func (r *RedisCache) ZIncrByCtx(ctx context.Context, key string, increment float64, member string) (float64, error) {
+	return r.client.ZIncrBy(ctx, r.buildKey(key), increment, member).Result()
+}
+
+func (r *RedisCache) ZRankCtx(ctx context.Context, key, member string) (int64, error) {
+	return r.client.ZRank(ctx, r.buildKey(key), member).Result()
+}
+
+func (r *RedisCache) ZRemRangeByScoreCtx(ctx context.Context, key, min, max string) (int64, error) {
+	return r.client.ZRemRangeByScore(ctx, r.buildKey(key), min, max).Result()
+}
+
+func (r *RedisCache) ZCountCtx(ctx context.Context, key, min, max string) (int64, error) {
+	return r.client.ZCount(ctx, r.buildKey(key), min, max).Result()
+}
+
 // 其他操作
 func (r *RedisCache) KeysCtx(ctx context.Context, pattern string) ([]string, error) {
-	return r.client.Keys(ctx, pattern).Result()
+	// 模式追加前缀，结果移除前缀
+	var keys []string
+	var err error
+	if r.cluster != nil {
+		keys, err = r.clusterKeys(ctx, r.buildKey(pattern), false)
+	} else {
+		keys, err = r.client.Keys(ctx, r.buildKey(pattern)).Result()
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i, key := range keys {
+		keys[i] = r.stripKey(key)
+	}
+	return keys, nil
+}
+
+// ScanCtx 模式追加前缀，结果移除前缀
+func (r *RedisCache) ScanCtx(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	if match == "" {
+		match = "*"
+	}
+	if r.cluster != nil {
+		// 各主节点的游标不能合并为一个，集群模式下每次调用遍历全部节点，再按 scanPage 的规则分批返回
+		keys, err := r.clusterKeys(ctx, r.buildKey(match), true)
+		if err != nil {
+			return nil, 0, err
+		}
+		for i, key := range keys {
+			keys[i] = r.stripKey(key)
+		}
+		page, next := scanPage(keys, cursor, count)
+		return page, next, nil
+	}
+
+	keys, next, err := r.client.Scan(ctx, cursor, r.buildKey(match), count).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+	for i, key := range keys {
+		keys[i] = r.stripKey(key)
+	}
+	return keys, next, nil
+}
+
+// clusterKeys 在集群的所有主节点上查找匹配的键，scan 为 true 时使用 SCAN 分批读取，否则使用 KEYS
+func (r *RedisCache) clusterKeys(ctx context.Context, pattern string, scan bool) ([]string, error) {
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	err := r.cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		var found []string
+		if scan {
+			iter := node.Scan(ctx, 0, pattern, 1000).Iterator()
+			for iter.Next(ctx) {
+				found = append(found, iter.Val())
+			}
+			if err := iter.Err(); err != nil {
+				return err
+			}
+		} else {
+			var err error
+			if found, err = node.Keys(ctx, pattern).Result(); err != nil {
+				return err
+			}
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		for _, key := range found {
+			seen[key] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// SCAN 可能重复返回同一个键
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	return keys, nil
 }
 
 func (r *RedisCache) PingCtx(ctx context.Context) error {
 	return r.client.Ping(ctx).Err()
 }
+
+// usage 返回当前数据库的键数量和 used_memory，集群模式下累加所有主节点；
+// 服务端不支持 INFO memory 时 Bytes 为 -1
+func (r *RedisCache) usage(ctx context.Context) (Usage, error) {
+	usage := Usage{Backend: "redis"}
+	nodes := []redis.Cmdable{r.client}
+	if r.cluster != nil {
+		nodes = nodes[:0]
+		var mu sync.Mutex
+		err := r.cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			mu.Lock()
+			defer mu.Unlock()
+			nodes = append(nodes, node)
+			return nil
+		})
+		if err != nil {
+			return usage, err
+		}
+	}
+
+	for _, node := range nodes {
+		keys, err := node.DBSize(ctx).Result()
+		if err != nil {
+			return usage, err
+		}
+		usage.Keys += keys
+
+		if usage.Bytes < 0 {
+			continue
+		}
+		info, err := node.Info(ctx, "memory").Result()
+		used, ok := infoField(info, "used_memory")
+		if err != nil || !ok {
+			usage.Bytes = -1
+			continue
+		}
+		usage.Bytes += used
+	}
+	return usage, nil
+}
+
+// infoField 从 INFO 的输出中读取整数字段
+func infoField(info, name string) (int64, bool) {
+	for _, line := range strings.Split(info, "\n") {
+		value, ok := strings.CutPrefix(strings.TrimSpace(line), name+":")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// ================== 阻塞列表操作 ==================
+
+func (r *RedisCache) BLPop(timeout time.Duration, keys ...string) ([]string, error) {
+	return r.BLPopCtx(context.Background(), timeout, keys...)
+}
+
+func (r *RedisCache) BRPop(timeout time.Duration, keys ...string) ([]string, error) {
+	return r.BRPopCtx(context.Background(), timeout, keys...)
+}
+
+func (r *RedisCache) BLPopCtx(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error) {
+	result, err := r.client.BLPop(ctx, blockTimeout(ctx, timeout), r.buildKeys(keys)...).Result()
+	return r.popResult(ctx, result, err)
+}
+
+func (r *RedisCache) BRPopCtx(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error) {
+	result, err := r.client.BRPop(ctx, blockTimeout(ctx, timeout), r.buildKeys(keys)...).Result()
+	return r.popResult(ctx, result, err)
+}
+
+// popResult 转换阻塞弹出的结果，超时返回 ErrKeyNotFound
+//
+// Redis 的阻塞超时按秒计算，可能长于 ctx 的剩余时间，ctx 结束导致的读超时返回 ctx.Err()。
+// 读超时按 ctx 的截止时间设置，可能略早于 ctx 结束，此时等待 ctx 结束。
+func (r *RedisCache) popResult(ctx context.Context, result []string, err error) ([]string, error) {
+	var netErr net.Error
+	if _, ok := ctx.Deadline(); ok && errors.As(err, &netErr) && netErr.Timeout() {
+		<-ctx.Done()
+	}
+	if err != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	result[0] = r.stripKey(result[0])
+	return result, nil
+}
+
+// blockTimeout 计算阻塞命令的超时时间，0 表示不限时；ctx 有截止时间时不超过截止时间
+func blockTimeout(ctx context.Context, timeout time.Duration) time.Duration {
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline); timeout <= 0 || remaining < timeout {
+			// 0 在 Redis 中表示不限时，剩余时间不足时取最小值
+			timeout = max(remaining, time.Millisecond)
+		}
+	}
+	return timeout
+}
+
+// ================== 发布订阅 ==================
+
+func (r *RedisCache) Publish(channel string, message interface{}) (int64, error) {
+	return r.PublishCtx(context.Background(), channel, message)
+}
+
+func (r *RedisCache) Subscribe(channels ...string) (Subscription, error) {
+	return r.SubscribeCtx(context.Background(), channels...)
+}
+
+func (r *RedisCache) PSubscribe(patterns ...string) (Subscription, error) {
+	return r.PSubscribeCtx(context.Background(), patterns...)
+}
+
+// PublishCtx 发布消息，消息按内存缓存相同的规则转换为字符串
+func (r *RedisCache) PublishCtx(ctx context.Context, channel string, message interface{}) (int64, error) {
+	payload, err := stringValue(message)
+	if err != nil {
+		return 0, err
+	}
+	return r.client.Publish(ctx, r.buildKey(channel), payload).Result()
+}
+
+func (r *RedisCache) SubscribeCtx(ctx context.Context, channels ...string) (Subscription, error) {
+	return r.subscribe(ctx, r.client.Subscribe(ctx, r.buildKeys(channels)...))
+}
+
+func (r *RedisCache) PSubscribeCtx(ctx context.Context, patterns ...string) (Subscription, error) {
+	return r.subscribe(ctx, r.client.PSubscribe(ctx, r.buildKeys(patterns)...))
+}
+
+// subscribe 等待订阅确认后转发消息
+func (r *RedisCache) subscribe(ctx context.Context, pubsub *redis.PubSub) (Subscription, error) {
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("订阅失败: %w", err)
+	}
+
+	sub := &redisSubscription{pubsub: pubsub, out: make(chan *Message), done: make(chan struct{})}
+	go func() {
+		defer close(sub.out)
+		for msg := range pubsub.Channel() {
+			message := &Message{Channel: r.stripKey(msg.Channel), Payload: msg.Payload}
+			if msg.Pattern != "" {
+				message.Pattern = r.stripKey(msg.Pattern)
+			}
+			select {
+			case sub.out <- message:
+			case <-sub.done:
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
+
+// redisSubscription Redis 订阅，频道名去掉前缀
+type redisSubscription struct {
+	pubsub *redis.PubSub
+	out    chan *Message
+	done   chan struct{}
+	once   sync.Once
+}
+
+func (s *redisSubscription) Channel() <-chan *Message {
+	return s.out
+}
+
+func (s *redisSubscription) Close() error {
+	s.once.Do(func() { close(s.done) })
+	return s.pubsub.Close()
+}
+
+// ================== 流操作 ==================
+
+func (r *RedisCache) XAdd(stream string, values map[string]interface{}) (string, error) {
+	return r.XAddCtx(context.Background(), stream, values)
+}
+
+func (r *RedisCache) XLen(stream string) (int64, error) {
+	return r.XLenCtx(context.Background(), stream)
+}
+
+func (r *RedisCache) XRead(args XReadArgs) ([]XStream, error) {
+	return r.XReadCtx(context.Background(), args)
+}
+
+func (r *RedisCache) XGroupCreate(stream, group, start string) error {
+	return r.XGroupCreateCtx(context.Background(), stream, group, start)
+}
+
+func (r *RedisCache) XReadGroup(args XReadGroupArgs) ([]XStream, error) {
+	return r.XReadGroupCtx(context.Background(), args)
+}
+
+func (r *RedisCache) XAck(stream, group string, ids ...string) (int64, error) {
+	return r.XAckCtx(context.Background(), stream, group, ids...)
+}
+
+// XAddCtx 追加消息，字段值按内存缓存相同的规则转换为字符串
+func (r *RedisCache) XAddCtx(ctx context.Context, stream string, values map[string]interface{}) (string, error) {
+	if len(values) == 0 {
+		return "", errEmptyMessage
+	}
+	fields := make(map[string]interface{}, len(values))
+	for field, value := range values {
+		str, err := stringValue(value)
+		if err != nil {
+			return "", fmt.Errorf("转换字段 %s 失败: %w", field, err)
+		}
+		fields[field] = str
+	}
+	return r.client.XAdd(ctx, &redis.XAddArgs{Stream: r.buildKey(stream), Values: fields}).Result()
+}
+
+func (r *RedisCache) XLenCtx(ctx context.Context, stream string) (int64, error) {
+	return r.client.XLen(ctx, r.buildKey(stream)).Result()
+}
+
+func (r *RedisCache) XReadCtx(ctx context.Context, args XReadArgs) ([]XStream, error) {
+	streams, err := r.client.XRead(ctx, &redis.XReadArgs{
+		Streams: r.streamArgs(args.Streams),
+		Count:   args.Count,
+		Block:   xblock(ctx, args.Block),
+	}).Result()
+	return r.streamResult(streams, err)
+}
+
+// XGroupCreateCtx 创建消费组，已存在时忽略 BUSYGROUP 错误
+func (r *RedisCache) XGroupCreateCtx(ctx context.Context, stream, group, start string) error {
+	err := r.client.XGroupCreateMkStream(ctx, r.buildKey(stream), group, start).Err()
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil
+	}
+	return err
+}
+
+func (r *RedisCache) XReadGroupCtx(ctx context.Context, args XReadGroupArgs) ([]XStream, error) {
+	streams, err := r.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    args.Group,
+		Consumer: args.Consumer,
+		Streams:  r.streamArgs(args.Streams),
+		Count:    args.Count,
+		Block:    xblock(ctx, args.Block),
+		NoAck:    args.NoAck,
+	}).Result()
+	if err != nil && strings.HasPrefix(err.Error(), "NOGROUP") {
+		return nil, fmt.Errorf("%s: %w", err, ErrNoGroup)
+	}
+	return r.streamResult(streams, err)
+}
+
+func (r *RedisCache) XAckCtx(ctx context.Context, stream, group string, ids ...string) (int64, error) {
+	return r.client.XAck(ctx, r.buildKey(stream), group, ids...).Result()
+}
+
+// streamArgs 按名称排序的流名称和起始 ID 参数
+func (r *RedisCache) streamArgs(streams map[string]string) []string {
+	names := streamNames(streams)
+	args := make([]string, 0, len(names)*2)
+	for _, name := range names {
+		args = append(args, r.buildKey(name))
+	}
+	for _, name := range names {
+		args = append(args, streams[name])
+	}
+	return args
+}
+
+// streamResult 转换读取结果，超时没有消息时返回空结果
+func (r *RedisCache) streamResult(streams []redis.XStream, err error) ([]XStream, error) {
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]XStream, len(streams))
+	for i, stream := range streams {
+		messages := make([]XMessage, len(stream.Messages))
+		for j, msg := range stream.Messages {
+			var values map[string]string
+			if msg.Values != nil {
+				values = make(map[string]string, len(msg.Values))
+				for k, v := range msg.Values {
+					values[k] = fmt.Sprint(v)
+				}
+			}
+			messages[j] = XMessage{ID: msg.ID, Values: values}
+		}
+		result[i] = XStream{Stream: r.stripKey(stream.Stream), Messages: messages}
+	}
+	return result, nil
+}
+
+// xblock 转换等待时间：0 不等待，负数等待到 ctx 结束
+func xblock(ctx context.Context, block time.Duration) time.Duration {
+	switch {
+	case block == 0:
+		return -1
+	case block < 0:
+		return blockTimeout(ctx, 0)
+	default:
+		return blockTimeout(ctx, block)
+	}
+}
+
+// ================== 管道 ==================
+
+// Pipeline 创建命令队列，Exec 时一次往返发送全部命令，命令之间可能穿插其他客户端的命令
+func (r *RedisCache) Pipeline() Pipeliner {
+	return &redisPipeline{r: r, pipe: r.client.Pipeline()}
+}
+
+// TxPipeline 创建事务命令队列，Exec 时以 MULTI/EXEC 执行
+//
+// 集群模式下按哈希标签分组，每组一个事务：同一组内的命令是原子的，不同组之间不是。
+// 需要原子执行的键应使用相同的哈希标签，如 {order:1}:items 和 {order:1}:total。
+func (r *RedisCache) TxPipeline() Pipeliner {
+	if r.cluster != nil {
+		return &redisPipeline{r: r, tags: make(map[string]redis.Pipeliner)}
+	}
+	return &redisPipeline{r: r, pipe: r.client.TxPipeline()}
+}
+
+// redisCmd 队列中的命令和读取 go-redis 命令结果的函数
+type redisCmd struct {
+	cmd    *Cmd
+	result func() (interface{}, error)
+}
+
+// redisPipeline 基于 go-redis 管道的命令队列，加入队列时的 ctx 不影响执行，执行使用 Exec 的 ctx
+type redisPipeline struct {
+	r     *RedisCache
+	pipe  redis.Pipeliner
+	queue []redisCmd
+
+	// 集群模式的事务按哈希标签分组，pipes 为各组的事务，按首次使用的顺序执行
+	tags  map[string]redis.Pipeliner
+	pipes []redis.Pipeliner
+}
+
+// on 返回执行 key 的命令的 go-redis 管道
+func (p *redisPipeline) on(key string) redis.Pipeliner {
+	if p.tags == nil {
+		return p.pipe
+	}
+	tag := hashTag(p.r.buildKey(key))
+	pipe, ok := p.tags[tag]
+	if !ok {
+		pipe = p.r.client.TxPipeline()
+		p.tags[tag] = pipe
+		p.pipes = append(p.pipes, pipe)
+	}
+	return pipe
+}
+
+// hashTag 返回集群计算槽位使用的部分：第一个 { 与其后第一个 } 之间的内容非空时为该内容，否则为整个键
+func hashTag(key string) string {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			return key[start+1 : start+1+end]
+		}
+	}
+	return key
+}
+
+// add 将命令加入队列
+func (p *redisPipeline) add(name string, result func() (interface{}, error)) *Cmd {
+	cmd := &Cmd{name: name}
+	p.queue = append(p.queue, redisCmd{cmd: cmd, result: result})
+	return cmd
+}
+
+func (p *redisPipeline) Get(key string) *Cmd {
+	cmd := p.on(key).Get(context.Background(), p.r.buildKey(key))
+	return p.add("get", func() (interface{}, error) { return cmd.Result() })
+}
+
+func (p *redisPipeline) Set(key string, value interface{}, expiration time.Duration) *Cmd {
+	cmd := p.on(key).Set(context.Background(), p.r.buildKey(key), value, expiration)
+	return p.add("set", func() (interface{}, error) { return nil, cmd.Err() })
+}
+
+func (p *redisPipeline) Del(keys ...string) *Cmd {
+	return p.add("del", p.sum(keys, func(pipe redis.Pipeliner, keys []string) *redis.IntCmd {
+		return pipe.Del(context.Background(), keys...)
+	}))
+}
+
+func (p *redisPipeline) Exists(keys ...string) *Cmd {
+	return p.add("exists", p.sum(keys, func(pipe redis.Pipeliner, keys []string) *redis.IntCmd {
+		return pipe.Exists(context.Background(), keys...)
+	}))
+}
+
+// sum 加入多键命令，集群模式下与 DelCtx 一致按键拆分，返回累加各条命令结果的函数
+func (p *redisPipeline) sum(keys []string, queue func(pipe redis.Pipeliner, keys []string) *redis.IntCmd) func() (interface{}, error) {
+	if p.r.cluster == nil || len(keys) < 2 {
+		var first string
+		if len(keys) > 0 {
+			first = keys[0]
+		}
+		cmd := queue(p.on(first), p.r.buildKeys(keys))
+		return func() (interface{}, error) { return cmd.Result() }
+	}
+
+	cmds := make([]*redis.IntCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = queue(p.on(key), []string{p.r.buildKey(key)})
+	}
+	return func() (interface{}, error) {
+		var n int64
+		for _, cmd := range cmds {
+			if err := cmd.Err(); err != nil {
+				return nil, err
+			}
+			n += cmd.Val()
+		}
+		return n, nil
+	}
+}
+
+func (p *redisPipeline) Expire(key string, expiration time.Duration) *Cmd {
+	cmd := p.on(key).Expire(context.Background(), p.r.buildKey(key), expiration)
+	return p.add("expire", func() (interface{}, error) { return nil, cmd.Err() })
+}
+
+func (p *redisPipeline) Incr(key string) *Cmd {
+	cmd := p.on(key).Incr(context.Background(), p.r.buildKey(key))
+	return p.add("incr", func() (interface{}, error) { return cmd.Result() })
+}
+
+func (p *redisPipeline) Decr(key string) *Cmd {
+	cmd := p.on(key).Decr(context.Background(), p.r.buildKey(key))
+	return p.add("decr", func() (interface{}, error) { return cmd.Result() })
+}
+
+func (p *redisPipeline) IncrBy(key string, value int64) *Cmd {
+	cmd := p.on(key).IncrBy(context.Background(), p.r.buildKey(key), value)
+	return p.add("incrby", func() (interface{}, error) { return cmd.Result() })
+}
+
+func (p *redisPipeline) HGet(key, field string) *Cmd {
+	cmd := p.on(key).HGet(context.Background(), p.r.buildKey(key), field)
+	return p.add("hget", func() (interface{}, error) { return cmd.Result() })
+}
+
+func (p *redisPipeline) HSet(key string, values ...interface{}) *Cmd {
+	cmd := p.on(key).HSet(context.Background(), p.r.buildKey(key), values...)
+	return p.add("hset", func() (interface{}, error) { return cmd.Result() })
+}
+
+func (p *redisPipeline) HDel(key string, fields ...string) *Cmd {
+	cmd := p.on(key).HDel(context.Background(), p.r.buildKey(key), fields...)
+	return p.add("hdel", func() (interface{}, error) { return cmd.Result() })
+}
+
+func (p *redisPipeline) LPush(key string, values ...interface{}) *Cmd {
+	cmd := p.on(key).LPush(context.Background(), p.r.buildKey(key), values...)
+	return p.add("lpush", func() (interface{}, error) { return cmd.Result() })
+}
+
+func (p *redisPipeline) RPush(key string, values ...interface{}) *Cmd {
+	cmd := p.on(key).RPush(context.Background(), p.r.buildKey(key), values...)
+	return p.add("rpush", func() (interface{}, error) { return cmd.Result() })
+}
+
+func (p *redisPipeline) SAdd(key string, members ...interface{}) *Cmd {
+	cmd := p.on(key).SAdd(context.Background(), p.r.buildKey(key), members...)
+	return p.add("sadd", func() (interface{}, error) { return cmd.Result() })
+}
+
+func (p *redisPipeline) SRem(key string, members ...interface{}) *Cmd {
+	cmd := p.on(key).SRem(context.Background(), p.r.buildKey(key), members...)
+	return p.add("srem", func() (interface{}, error) { return cmd.Result() })
+}
+
+func (p *redisPipeline) ZAdd(key string, members ...Z) *Cmd {
+	cmd := p.on(key).ZAdd(context.Background(), p.r.buildKey(key), redisZ(members)...)
+	return p.add("zadd", func() (interface{}, error) { return cmd.Result() })
+}
+
+func (p *redisPipeline) ZRem(key string, members ...interface{}) *Cmd {
+	cmd := p.on(key).ZRem(context.Background(), p.r.buildKey(key), members...)
+	return p.add("zrem", func() (interface{}, error) { return cmd.Result() })
+}
+
+func (p *redisPipeline) ZIncrBy(key string, increment float64, member string) *Cmd {
+	cmd := p.on(key).ZIncrBy(context.Background(), p.r.buildKey(key), increment, member)
+	return p.add("zincrby", func() (interface{}, error) { return cmd.Result() })
+}
+
+// Len 返回队列中的命令数
+func (p *redisPipeline) Len() int {
+	return len(p.queue)
+}
+
+// Discard 清空队列
+func (p *redisPipeline) Discard() {
+	if p.tags != nil {
+		p.tags, p.pipes = make(map[string]redis.Pipeliner), nil
+	} else {
+		p.pipe.Discard()
+	}
+	p.queue = nil
+}
+
+// Exec 一次往返执行队列中的命令，错误已由 errorHook 转换
+func (p *redisPipeline) Exec(ctx context.Context) ([]*Cmd, error) {
+	queue := p.queue
+	p.queue = nil
+	if len(queue) == 0 {
+		return nil, nil
+	}
+
+	var err error
+	if p.tags != nil {
+		pipes := p.pipes
+		p.tags, p.pipes = make(map[string]redis.Pipeliner), nil
+		for _, pipe := range pipes {
+			if _, e := pipe.Exec(ctx); e != nil && err == nil {
+				err = e
+			}
+		}
+	} else {
+		_, err = p.pipe.Exec(ctx)
+	}
+	cmds := make([]*Cmd, len(queue))
+	for i, c := range queue {
+		c.cmd.val, c.cmd.err = c.result()
+		cmds[i] = c.cmd
+	}
+	return cmds, err
+}
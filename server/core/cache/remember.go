@@ -0,0 +1,317 @@
+package cache
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrNotFound 记忆模式中表示结果不存在，配置了 NotFoundTTL 时会被缓存
+var ErrNotFound = errors.New("结果不存在")
+
+// flights 进程内合并同一键的并发加载
+var flights singleflight.Group
+
+// RememberOptions 记忆模式选项
+type RememberOptions struct {
+	TTL time.Duration // 过期时间，0 表示不过期
+	// NotFoundTTL 加载函数返回 ErrNotFound 时缓存该结果的时间，0 表示不缓存
+	NotFoundTTL time.Duration
+	// Beta 提前刷新系数（XFetch），过期前按加载耗时和该系数随机提前刷新，0 表示不提前刷新，通常取 1
+	Beta float64
+	// Lock 跨实例合并：未命中时先获取该过期时间的锁，只有持有锁的实例执行加载，0 表示只在进程内合并
+	Lock time.Duration
+	// LockWait 未获取到锁时等待其他实例写入缓存的最长时间，超时后自行加载，默认等于 Lock
+	LockWait time.Duration
+}
+
+// entryCodecID 记忆模式缓存内容的编码标记，0 不允许注册为编码，Typed.Get 据此识别并解析
+const entryCodecID = byte(0)
+
+// entry 记忆模式的缓存内容，以编码标记开头的 JSON，各缓存驱动存储的内容相同
+type entry struct {
+	Value    json.RawMessage `json:"v,omitempty"` // JSON 编码的值
+	Data     []byte          `json:"b,omitempty"` // 其他编码或压缩后的值，带编码头部
+	NotFound bool            `json:"n,omitempty"`
+	Delta    time.Duration   `json:"d,omitempty"` // 加载耗时
+	Expiry   int64           `json:"e,omitempty"` // 过期时间，Unix 毫秒
+}
+
+// stale 按 XFetch 算法判断是否提前刷新：加载越慢、越接近过期，提前刷新的概率越大
+func (e *entry) stale(beta float64) bool {
+	if beta <= 0 || e.Expiry == 0 || e.NotFound {
+		return false
+	}
+	gap := -float64(e.Delta) * beta * math.Log(rand.Float64())
+	return time.Now().Add(time.Duration(gap)).UnixMilli() >= e.Expiry
+}
+
+// setValue 保存 encodeValue 编码的值，未压缩的 JSON 直接保存，便于查看
+func (e *entry) setValue(data []byte) {
+	if data[1] == JSONCodec.ID() && data[2] == byte(CompressionNone) {
+		e.Value = data[codecHeaderSize:]
+		return
+	}
+	e.Data = data
+}
+
+// decode 解析值，缓存的是不存在的结果时返回 ErrNotFound
+func (e *entry) decode(v interface{}) error {
+	if e.NotFound {
+		return ErrNotFound
+	}
+	if e.Data != nil {
+		return decodeValue(e.Data, v)
+	}
+	if err := json.Unmarshal(e.Value, v); err != nil {
+		return fmt.Errorf("反序列化失败: %w", err)
+	}
+	return nil
+}
+
+// decodeEntry 解析缓存内容
+func decodeEntry[T any](e *entry) (T, error) {
+	var v T
+	err := e.decode(&v)
+	return v, err
+}
+
+// parseEntry 解析带编码标记的缓存内容
+func parseEntry(data []byte) (*entry, error) {
+	if len(data) < codecHeaderSize || data[0] != codecMagic || data[1] != entryCodecID {
+		return nil, errors.New("不是记忆模式的缓存内容")
+	}
+	var e entry
+	if err := json.Unmarshal(data[codecHeaderSize:], &e); err != nil {
+		return nil, fmt.Errorf("解析记忆模式的缓存内容失败: %w", err)
+	}
+	return &e, nil
+}
+
+// jsonEncoder Remember 的值使用 JSON 编码
+func jsonEncoder(v interface{}) ([]byte, error) {
+	return encodeValue(JSONCodec, CompressionNone, 0, v)
+}
+
+// Remember 类型安全的记忆模式：缓存不存在时执行 fn 并缓存结果
+//
+// 结果序列化为 JSON 后存储，内存、文件和 Redis 驱动的行为一致。同一进程内的并发未命中只执行一次 fn；
+// 配置 Lock 时跨实例只有一个实例执行 fn，其余实例等待缓存写入。
+//
+//	menus, err := cache.Remember(ctx, helper, "menus:1", cache.RememberOptions{TTL: time.Minute, Beta: 1}, func() ([]Menu, error) {
+//		return loadMenus(1)
+//	})
+func Remember[T any](ctx context.Context, h *CacheHelper, key string, opts RememberOptions, fn func() (T, error)) (T, error) {
+	e, err := h.remember(ctx, key, opts, jsonEncoder, func() (interface{}, error) {
+		return fn()
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return decodeEntry[T](e)
+}
+
+// remember 记忆模式的实现，encode 为值的编码方式，返回命中或加载后的缓存内容
+func (h *CacheHelper) remember(ctx context.Context, key string, opts RememberOptions,
+	encode func(interface{}) ([]byte, error), fn func() (interface{}, error)) (*entry, error) {
+	fullKey := h.buildKey(key)
+	cached, hit := h.loadEntry(ctx, fullKey)
+	if hit && !cached.stale(opts.Beta) {
+		return cached, nil
+	}
+
+	v, err := doFlight(ctx, flightKey(h.cache, "entry", fullKey), func() (interface{}, error) {
+		// 加载与发起请求的调用方解耦，避免其取消影响合并的其他调用方
+		ctx := context.WithoutCancel(ctx)
+		if !hit {
+			// 等待期间其他调用可能已写入缓存
+			if e, ok := h.loadEntry(ctx, fullKey); ok {
+				return e, nil
+			}
+		}
+
+		if opts.Lock > 0 {
+			lock, err := h.LockCtx(ctx, "remember:"+key, opts.Lock)
+			switch {
+			case err != nil:
+				h.warn(fullKey, err, "获取加载锁失败")
+			case lock != nil:
+				defer func() {
+					if err := h.UnlockCtx(ctx, lock); err != nil {
+						h.warn(fullKey, err, "释放加载锁失败")
+					}
+				}()
+				// 上一个持有锁的实例可能已在加锁前写入缓存
+				if e, ok := h.loadEntry(ctx, fullKey); ok && (!hit || e.Expiry != cached.Expiry) {
+					return e, nil
+				}
+			case hit:
+				// 其他实例正在刷新，继续使用旧值
+				return cached, nil
+			default:
+				if e, ok := h.waitEntry(ctx, fullKey, opts); ok {
+					return e, nil
+				}
+			}
+		}
+
+		return h.loadAndStore(ctx, fullKey, opts, encode, fn)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*entry), nil
+}
+
+// loadEntry 读取记忆模式的缓存内容，其他格式的值视为未命中
+func (h *CacheHelper) loadEntry(ctx context.Context, fullKey string) (*entry, bool) {
+	data, err := h.cache.GetCtx(ctx, fullKey)
+	if err != nil {
+		return nil, false
+	}
+	e, err := parseEntry([]byte(data))
+	if err != nil {
+		return nil, false
+	}
+	return e, true
+}
+
+// waitEntry 等待持有锁的实例写入缓存
+func (h *CacheHelper) waitEntry(ctx context.Context, fullKey string, opts RememberOptions) (*entry, bool) {
+	wait := opts.LockWait
+	if wait <= 0 {
+		wait = opts.Lock
+	}
+	interval := min(wait/10+time.Millisecond, 50*time.Millisecond)
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-timer.C:
+			return nil, false
+		case <-ticker.C:
+			if e, ok := h.loadEntry(ctx, fullKey); ok {
+				return e, true
+			}
+		}
+	}
+}
+
+// loadAndStore 执行加载并写入缓存，ErrNotFound 按 NotFoundTTL 缓存
+func (h *CacheHelper) loadAndStore(ctx context.Context, fullKey string, opts RememberOptions,
+	encode func(interface{}) ([]byte, error), fn func() (interface{}, error)) (*entry, error) {
+	start := time.Now()
+	result, err := fn()
+	e := &entry{Delta: time.Since(start)}
+	ttl := opts.TTL
+
+	switch {
+	case errors.Is(err, ErrNotFound):
+		if opts.NotFoundTTL <= 0 {
+			return nil, err
+		}
+		e.NotFound = true
+		ttl = opts.NotFoundTTL
+	case err != nil:
+		return nil, err
+	default:
+		data, err := encode(result)
+		if err != nil {
+			return nil, err
+		}
+		e.setValue(data)
+	}
+	if ttl > 0 {
+		e.Expiry = time.Now().Add(ttl).UnixMilli()
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("序列化失败: %w", err)
+	}
+	header := []byte{codecMagic, entryCodecID, byte(CompressionNone)}
+	if err := h.cache.SetCtx(ctx, fullKey, string(append(header, data...)), ttl); err != nil {
+		h.warn(fullKey, err, "缓存设置失败")
+	}
+	return e, nil
+}
+
+// doFlight 合并同一键的并发加载，调用方的 ctx 结束时立即返回 ctx 的错误，加载继续执行供其他调用方使用；
+// 加载函数 panic 时返回错误，避免在合并加载的协程中导致进程退出
+func doFlight(ctx context.Context, key string, fn func() (interface{}, error)) (interface{}, error) {
+	ch := flights.DoChan(key, func() (v interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("加载函数 panic: %v", r)
+			}
+		}()
+		return fn()
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		return res.Val, res.Err
+	}
+}
+
+// flightKey 合并加载的键，区分底层缓存实例和前缀包装，不同租户的同名键不会合并；
+// kind 区分返回结果类型不同的调用，同一键上混用 Remember 和 RememberJSONCtx 时不会合并
+func flightKey(c Cache, kind, key string) string {
+	prefix := ""
+	for {
+		p, ok := c.(*prefixCache)
+		if !ok {
+			break
+		}
+		prefix = p.prefix + prefix
+		c = p.Cache
+	}
+	return fmt.Sprintf("%p:%s:%s%s", c, kind, prefix, key)
+}
+
+// stringValue 将值转换为字符串存储，与 Redis 驱动的序列化方式一致，其他类型使用 JSON
+func stringValue(v interface{}) (string, error) {
+	switch val := v.(type) {
+	case string:
+		return val, nil
+	case []byte:
+		return string(val), nil
+	case int:
+		return strconv.Itoa(val), nil
+	case int64:
+		return strconv.FormatInt(val, 10), nil
+	case uint64:
+		return strconv.FormatUint(val, 10), nil
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64), nil
+	case bool:
+		if val {
+			return "1", nil
+		}
+		return "0", nil
+	case time.Time:
+		return val.Format(time.RFC3339Nano), nil
+	case encoding.BinaryMarshaler:
+		data, err := val.MarshalBinary()
+		return string(data), err
+	case fmt.Stringer:
+		return val.String(), nil
+	}
+	data, err := json.Marshal(v)
+	return string(data), err
+}
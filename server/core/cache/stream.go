@@ -0,0 +1,434 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoGroup 流或消费组不存在
+var ErrNoGroup = errors.New("消费组不存在")
+
+// errEmptyMessage 追加的消息没有字段
+var errEmptyMessage = errors.New("流消息不能为空")
+
+// XMessage 流消息
+type XMessage struct {
+	ID     string
+	Values map[string]string
+}
+
+// XStream 流及读取到的消息
+type XStream struct {
+	Stream   string
+	Messages []XMessage
+}
+
+// XReadArgs 读取流的参数
+type XReadArgs struct {
+	// Streams 流名称到起始 ID，返回 ID 大于起始 ID 的消息，"0" 表示从头读取，"$" 表示只读取之后添加的消息
+	Streams map[string]string
+	Count   int64         // 每个流最多返回的消息数，0 表示不限制
+	Block   time.Duration // 没有消息时的等待时间，0 表示不等待，负数表示等待到 ctx 结束
+}
+
+// XReadGroupArgs 按消费组读取流的参数
+type XReadGroupArgs struct {
+	Group    string
+	Consumer string
+	// Streams 流名称到起始 ID，">" 表示读取尚未投递给消费组的消息，
+	// 其他 ID 表示重新读取该消费者已投递但未确认的消息（此时不等待）
+	Streams map[string]string
+	Count   int64         // 每个流最多返回的消息数，0 表示不限制
+	Block   time.Duration // 没有消息时的等待时间，0 表示不等待，负数表示等待到 ctx 结束
+	NoAck   bool          // 投递的消息不加入待确认列表
+}
+
+// streamID 流消息 ID，由毫秒时间戳和同一毫秒内的序号组成
+type streamID struct {
+	ms, seq uint64
+}
+
+// parseStreamID 解析 "毫秒-序号" 或 "毫秒" 格式的 ID
+func parseStreamID(s string) (streamID, error) {
+	msPart, seqPart, hasSeq := strings.Cut(s, "-")
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return streamID{}, fmt.Errorf("无效的流消息 ID %q", s)
+	}
+	var seq uint64
+	if hasSeq {
+		if seq, err = strconv.ParseUint(seqPart, 10, 64); err != nil {
+			return streamID{}, fmt.Errorf("无效的流消息 ID %q", s)
+		}
+	}
+	return streamID{ms: ms, seq: seq}, nil
+}
+
+func (id streamID) String() string {
+	return strconv.FormatUint(id.ms, 10) + "-" + strconv.FormatUint(id.seq, 10)
+}
+
+func (id streamID) less(other streamID) bool {
+	return id.ms < other.ms || (id.ms == other.ms && id.seq < other.seq)
+}
+
+func (id streamID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+func (id *streamID) UnmarshalText(text []byte) error {
+	parsed, err := parseStreamID(string(text))
+	*id = parsed
+	return err
+}
+
+// streamData 流的存储结构，内存缓存直接保存，文件缓存序列化为 JSON
+type streamData struct {
+	Entries []streamEntry           `json:"e"`
+	Last    streamID                `json:"l"`
+	Groups  map[string]*streamGroup `json:"g,omitempty"`
+	Bytes   int64                   `json:"b"` // 消息的估算字节数
+}
+
+type streamEntry struct {
+	ID     streamID          `json:"i"`
+	Values map[string]string `json:"v"`
+}
+
+// streamGroup 消费组
+type streamGroup struct {
+	Last    streamID                    `json:"l"` // 最后投递给消费组的消息
+	Pending map[streamID]*streamPending `json:"p,omitempty"`
+}
+
+// streamPending 已投递未确认的消息
+type streamPending struct {
+	Consumer   string `json:"c"`
+	Deliveries int64  `json:"d"`
+	Delivered  int64  `json:"t"` // 最近投递时间，Unix 毫秒
+}
+
+// add 追加消息，ID 取当前毫秒时间，不大于上一条消息时在其基础上递增序号
+func (s *streamData) add(values map[string]interface{}) (string, error) {
+	if len(values) == 0 {
+		return "", errEmptyMessage
+	}
+
+	entry := streamEntry{ID: streamID{ms: uint64(time.Now().UnixMilli())}, Values: make(map[string]string, len(values))}
+	if !s.Last.less(entry.ID) {
+		entry.ID = streamID{ms: s.Last.ms, seq: s.Last.seq + 1}
+	}
+	for field, value := range values {
+		str, err := stringValue(value)
+		if err != nil {
+			return "", fmt.Errorf("转换字段 %s 失败: %w", field, err)
+		}
+		entry.Values[field] = str
+		s.Bytes += int64(len(field) + len(str))
+	}
+
+	s.Entries = append(s.Entries, entry)
+	s.Last = entry.ID
+	return entry.ID.String(), nil
+}
+
+// message 转换为返回值，复制字段避免调用方修改存储
+func (e streamEntry) message() XMessage {
+	values := make(map[string]string, len(e.Values))
+	for k, v := range e.Values {
+		values[k] = v
+	}
+	return XMessage{ID: e.ID.String(), Values: values}
+}
+
+// after 返回 ID 大于 id 的消息，count 为 0 时不限制数量
+func (s *streamData) after(id streamID, count int64) []XMessage {
+	i := sort.Search(len(s.Entries), func(i int) bool { return id.less(s.Entries[i].ID) })
+	entries := s.Entries[i:]
+	if count > 0 && int64(len(entries)) > count {
+		entries = entries[:count]
+	}
+
+	messages := make([]XMessage, len(entries))
+	for i, entry := range entries {
+		messages[i] = entry.message()
+	}
+	return messages
+}
+
+// find 按 ID 查找消息
+func (s *streamData) find(id streamID) (streamEntry, bool) {
+	i := sort.Search(len(s.Entries), func(i int) bool { return !s.Entries[i].ID.less(id) })
+	if i < len(s.Entries) && s.Entries[i].ID == id {
+		return s.Entries[i], true
+	}
+	return streamEntry{}, false
+}
+
+// resolve 解析起始 ID，"$" 表示最后一条消息
+func (s *streamData) resolve(id string) (streamID, error) {
+	if id == "$" {
+		if s == nil {
+			return streamID{}, nil
+		}
+		return s.Last, nil
+	}
+	return parseStreamID(id)
+}
+
+// createGroup 创建消费组，已存在时不做修改，返回是否创建
+func (s *streamData) createGroup(name, start string) (bool, error) {
+	if _, ok := s.Groups[name]; ok {
+		return false, nil
+	}
+	last, err := s.resolve(start)
+	if err != nil {
+		return false, err
+	}
+	if s.Groups == nil {
+		s.Groups = make(map[string]*streamGroup)
+	}
+	s.Groups[name] = &streamGroup{Last: last}
+	return true, nil
+}
+
+// readGroup 按消费组读取，id 为 ">" 时读取新消息，否则读取 consumer 已投递未确认的消息
+func (s *streamData) readGroup(g *streamGroup, consumer, id string, count int64, noAck bool) ([]XMessage, error) {
+	now := time.Now().UnixMilli()
+	if g.Pending == nil {
+		// 文件缓存反序列化后为空
+		g.Pending = make(map[streamID]*streamPending)
+	}
+	if id == ">" {
+		messages := s.after(g.Last, count)
+		for _, msg := range messages {
+			msgID, _ := parseStreamID(msg.ID)
+			g.Last = msgID
+			if !noAck {
+				g.Pending[msgID] = &streamPending{Consumer: consumer, Deliveries: 1, Delivered: now}
+			}
+		}
+		return messages, nil
+	}
+
+	start, err := parseStreamID(id)
+	if err != nil {
+		return nil, err
+	}
+	var ids []streamID
+	for pendingID, p := range g.Pending {
+		if p.Consumer == consumer && start.less(pendingID) {
+			ids = append(ids, pendingID)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].less(ids[j]) })
+	if count > 0 && int64(len(ids)) > count {
+		ids = ids[:count]
+	}
+
+	messages := make([]XMessage, 0, len(ids))
+	for _, pendingID := range ids {
+		p := g.Pending[pendingID]
+		p.Deliveries++
+		p.Delivered = now
+		if entry, ok := s.find(pendingID); ok {
+			messages = append(messages, entry.message())
+		} else {
+			messages = append(messages, XMessage{ID: pendingID.String()})
+		}
+	}
+	return messages, nil
+}
+
+// ack 确认消息，返回确认数量
+func (s *streamData) ack(group string, ids []string) (int64, error) {
+	g := s.Groups[group]
+	if g == nil {
+		return 0, nil
+	}
+	var count int64
+	for _, id := range ids {
+		parsed, err := parseStreamID(id)
+		if err != nil {
+			return count, err
+		}
+		if _, ok := g.Pending[parsed]; ok {
+			delete(g.Pending, parsed)
+			count++
+		}
+	}
+	return count, nil
+}
+
+// streamStore 在锁或事务中访问流：流不存在且 create 为 false 时 fn 收到 nil，fn 返回 true 时保存修改
+type streamStore func(key string, create bool, fn func(s *streamData) (bool, error)) error
+
+// streamEmulator 基于 streamStore 实现流操作，内存缓存和文件缓存共用
+type streamEmulator struct {
+	hub     *hub
+	store   streamStore
+	fullKey func(key string) string
+}
+
+func (e *streamEmulator) add(ctx context.Context, stream string, values map[string]interface{}) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	var id string
+	err := e.store(stream, true, func(s *streamData) (bool, error) {
+		var err error
+		id, err = s.add(values)
+		return err == nil, err
+	})
+	if err != nil {
+		return "", err
+	}
+	e.hub.signal(e.fullKey(stream))
+	return id, nil
+}
+
+func (e *streamEmulator) len(ctx context.Context, stream string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	var n int64
+	err := e.store(stream, false, func(s *streamData) (bool, error) {
+		if s != nil {
+			n = int64(len(s.Entries))
+		}
+		return false, nil
+	})
+	return n, err
+}
+
+func (e *streamEmulator) read(ctx context.Context, args XReadArgs) ([]XStream, error) {
+	names := streamNames(args.Streams)
+
+	// "$" 在调用时解析，等待期间添加的消息都会返回
+	starts := make(map[string]streamID, len(names))
+	for _, name := range names {
+		err := e.store(name, false, func(s *streamData) (bool, error) {
+			id, err := s.resolve(args.Streams[name])
+			starts[name] = id
+			return false, err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var result []XStream
+	_, err := e.hub.wait(ctx, args.Block, e.watched(names), func() (bool, error) {
+		result = nil
+		for _, name := range names {
+			var messages []XMessage
+			err := e.store(name, false, func(s *streamData) (bool, error) {
+				if s != nil {
+					messages = s.after(starts[name], args.Count)
+				}
+				return false, nil
+			})
+			if err != nil {
+				return false, err
+			}
+			if len(messages) > 0 {
+				result = append(result, XStream{Stream: name, Messages: messages})
+			}
+		}
+		return len(result) > 0, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (e *streamEmulator) createGroup(ctx context.Context, stream, group, start string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return e.store(stream, true, func(s *streamData) (bool, error) {
+		return s.createGroup(group, start)
+	})
+}
+
+func (e *streamEmulator) readGroup(ctx context.Context, args XReadGroupArgs) ([]XStream, error) {
+	names := streamNames(args.Streams)
+
+	// 读取待确认消息时不等待
+	block := args.Block
+	for _, name := range names {
+		if args.Streams[name] != ">" {
+			block = 0
+		}
+	}
+
+	var result []XStream
+	_, err := e.hub.wait(ctx, block, e.watched(names), func() (bool, error) {
+		result = nil
+		for _, name := range names {
+			id := args.Streams[name]
+			var messages []XMessage
+			err := e.store(name, false, func(s *streamData) (bool, error) {
+				if s == nil || s.Groups[args.Group] == nil {
+					return false, fmt.Errorf("读取流 %s 失败: %w", name, ErrNoGroup)
+				}
+				var err error
+				messages, err = s.readGroup(s.Groups[args.Group], args.Consumer, id, args.Count, args.NoAck)
+				return len(messages) > 0, err
+			})
+			if err != nil {
+				return false, err
+			}
+			// 与 Redis 一致，读取待确认消息时即使为空也返回该流
+			if len(messages) > 0 || id != ">" {
+				result = append(result, XStream{Stream: name, Messages: messages})
+			}
+		}
+		return len(result) > 0, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (e *streamEmulator) ack(ctx context.Context, stream, group string, ids []string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	var n int64
+	err := e.store(stream, false, func(s *streamData) (bool, error) {
+		if s == nil {
+			return false, nil
+		}
+		var err error
+		n, err = s.ack(group, ids)
+		return n > 0, err
+	})
+	return n, err
+}
+
+// watched 返回登记等待的完整键名
+func (e *streamEmulator) watched(names []string) []string {
+	keys := make([]string, len(names))
+	for i, name := range names {
+		keys[i] = e.fullKey(name)
+	}
+	return keys
+}
+
+// streamNames 按名称排序的流，结果顺序与之一致
+func streamNames(streams map[string]string) []string {
+	names := make([]string, 0, len(streams))
+	for name := range streams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,490 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/zhoudm1743/go-web/core/conf"
+	"github.com/zhoudm1743/go-web/core/log"
+)
+
+// Bus 缓存失效消息的发布订阅通道
+type Bus interface {
+	Publish(ctx context.Context, payload string) error
+	// Subscribe 注册消息处理函数，每条消息调用一次
+	Subscribe(handler func(payload string)) error
+	Close() error
+}
+
+// MemoryBus 进程内的消息通道，用于单实例部署和测试，发布时同步调用处理函数
+type MemoryBus struct {
+	mu       sync.RWMutex
+	handlers []func(string)
+}
+
+// NewMemoryBus 创建进程内消息通道
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{}
+}
+
+// Publish 发布消息
+func (b *MemoryBus) Publish(ctx context.Context, payload string) error {
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(payload)
+	}
+	return nil
+}
+
+// Subscribe 注册消息处理函数
+func (b *MemoryBus) Subscribe(handler func(payload string)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers[:len(b.handlers):len(b.handlers)], handler)
+	return nil
+}
+
+// Close 移除所有处理函数
+func (b *MemoryBus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = nil
+	return nil
+}
+
+// RedisBus 基于 Redis Pub/Sub 的消息通道，用于多实例部署
+type RedisBus struct {
+	client  redis.UniversalClient
+	channel string
+
+	mu     sync.Mutex
+	pubsub []*redis.PubSub
+}
+
+// NewRedisBus 创建 Redis 消息通道
+func NewRedisBus(client redis.UniversalClient, channel string) *RedisBus {
+	return &RedisBus{client: client, channel: channel}
+}
+
+// Publish 发布消息
+func (b *RedisBus) Publish(ctx context.Context, payload string) error {
+	return b.client.Publish(ctx, b.channel, payload).Err()
+}
+
+// Subscribe 订阅频道，断线后由客户端自动重连
+func (b *RedisBus) Subscribe(handler func(payload string)) error {
+	ctx := context.Background()
+	pubsub := b.client.Subscribe(ctx, b.channel)
+	// 等待订阅确认，确保返回后发布的消息都能收到
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return fmt.Errorf("订阅频道 %s 失败: %w", b.channel, err)
+	}
+
+	b.mu.Lock()
+	b.pubsub = append(b.pubsub, pubsub)
+	b.mu.Unlock()
+
+	go func() {
+		for msg := range pubsub.Channel() {
+			handler(msg.Payload)
+		}
+	}()
+	return nil
+}
+
+// Close 取消所有订阅
+func (b *RedisBus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var firstErr error
+	for _, pubsub := range b.pubsub {
+		if err := pubsub.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	b.pubsub = nil
+	return firstErr
+}
+
+// invalidation 失效消息
+type invalidation struct {
+	Node string   `json:"n"` // 发布消息的实例，实例忽略自己发布的消息
+	Keys []string `json:"k"`
+}
+
+// TieredOptions 两级缓存选项
+type TieredOptions struct {
+	L1TTL time.Duration // 一级缓存的最长保留时间，默认 1 分钟
+	Node  string        // 实例标识，默认随机生成
+}
+
+// TieredCache 两级缓存：读取时先查一级缓存（通常为内存），未命中时读取二级缓存（Redis 或文件）并回填；
+// 写入时写二级缓存，删除本实例的一级缓存并通过 Bus 通知其他实例删除。
+//
+// 一级缓存只保存字符串值，哈希、列表、集合和有序集合直接读写二级缓存。回填时的保留时间不超过
+// 二级缓存的剩余时间，因此只延长过期时间的操作（如锁续期）不需要失效。
+type TieredCache struct {
+	Cache // 二级缓存，未覆盖的方法直接调用
+	l1    Cache
+	bus   Bus
+	opts  TieredOptions
+	log   log.Logger
+
+	// gen 失效次数，读取二级缓存期间发生失效时不回填，避免回填旧值
+	gen atomic.Uint64
+}
+
+// NewTiered 组合两个缓存，bus 为空时只在本实例内失效
+func NewTiered(l1, l2 Cache, bus Bus, opts TieredOptions, logger log.Logger) (*TieredCache, error) {
+	if opts.L1TTL <= 0 {
+		opts.L1TTL = time.Minute
+	}
+	if opts.Node == "" {
+		opts.Node = uuid.NewString()
+	}
+
+	t := &TieredCache{Cache: l2, l1: l1, bus: bus, opts: opts, log: logger}
+	if bus != nil {
+		if err := bus.Subscribe(t.receive); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// NewTieredCache 按配置创建两级缓存，二级缓存为 Redis 时通过 Redis Pub/Sub 通知其他实例
+func NewTieredCache(cfg *conf.Config, logger log.Logger) (Cache, error) {
+	opts := cfg.Cache.Tiered
+	if opts.L1 == "tiered" || opts.L2 == "tiered" {
+		return nil, fmt.Errorf("两级缓存不能嵌套")
+	}
+
+	// 只统计两级缓存整体的调用，各级缓存不再包装
+	build := func(typ string) (Cache, error) {
+		sub := *cfg
+		sub.Cache.Type, sub.Cache.Metrics = typ, false
+		return New(&sub, logger)
+	}
+	l1, err := build(opts.L1)
+	if err != nil {
+		return nil, fmt.Errorf("创建一级缓存失败: %w", err)
+	}
+	l2, err := build(opts.L2)
+	if err != nil {
+		l1.Close()
+		return nil, fmt.Errorf("创建二级缓存失败: %w", err)
+	}
+
+	var bus Bus
+	if client, ok := l2.GetClient().(redis.UniversalClient); ok {
+		bus = NewRedisBus(client, cfg.Cache.Prefix+opts.Channel)
+	} else {
+		bus = NewMemoryBus()
+		logger.Warn("二级缓存不是 Redis，一级缓存只在本实例内失效")
+	}
+
+	t, err := NewTiered(l1, l2, bus, TieredOptions{L1TTL: opts.L1TTL}, logger)
+	if err != nil {
+		l1.Close()
+		l2.Close()
+		return nil, err
+	}
+	logger.WithFields(map[string]interface{}{
+		"l1":   opts.L1,
+		"l2":   opts.L2,
+		"node": t.opts.Node,
+	}).Info("使用两级缓存")
+	return t, nil
+}
+
+// usage 返回二级缓存的键数量和占用，Levels 为两级各自的结果
+func (t *TieredCache) usage(ctx context.Context) (Usage, error) {
+	l1, err := UsageOf(ctx, t.l1)
+	if err != nil {
+		return Usage{}, err
+	}
+	l2, err := UsageOf(ctx, t.Cache)
+	if err != nil {
+		return Usage{}, err
+	}
+	return Usage{Backend: "tiered", Keys: l2.Keys, Bytes: l2.Bytes, Levels: []Usage{l1, l2}}, nil
+}
+
+// L1 返回一级缓存
+func (t *TieredCache) L1() Cache {
+	return t.l1
+}
+
+// L2 返回二级缓存
+func (t *TieredCache) L2() Cache {
+	return t.Cache
+}
+
+// receive 处理其他实例的失效消息
+func (t *TieredCache) receive(payload string) {
+	var msg invalidation
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		t.log.WithField("error", err).Warn("解析缓存失效消息失败")
+		return
+	}
+	if msg.Node == t.opts.Node {
+		return
+	}
+	t.gen.Add(1)
+	t.l1.DelCtx(context.Background(), msg.Keys...)
+}
+
+// invalidate 删除本实例的一级缓存并通知其他实例
+func (t *TieredCache) invalidate(ctx context.Context, keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	t.gen.Add(1)
+	t.l1.DelCtx(ctx, keys...)
+	if t.bus == nil {
+		return
+	}
+
+	payload, _ := json.Marshal(invalidation{Node: t.opts.Node, Keys: keys})
+	if err := t.bus.Publish(context.WithoutCancel(ctx), string(payload)); err != nil {
+		t.log.WithFields(map[string]interface{}{"keys": keys, "error": err}).Warn("发布缓存失效消息失败")
+	}
+}
+
+// after 写入二级缓存成功后失效一级缓存
+func (t *TieredCache) after(ctx context.Context, err error, keys ...string) {
+	if err == nil {
+		t.invalidate(ctx, keys...)
+	}
+}
+
+// Close 取消订阅并关闭两级缓存
+func (t *TieredCache) Close() error {
+	if t.bus != nil {
+		t.bus.Close()
+	}
+	t.l1.Close()
+	return t.Cache.Close()
+}
+
+func (t *TieredCache) Get(key string) (string, error) {
+	return t.GetCtx(context.Background(), key)
+}
+
+func (t *TieredCache) Set(key string, value interface{}, expiration time.Duration) error {
+	return t.SetCtx(context.Background(), key, value, expiration)
+}
+
+func (t *TieredCache) Del(keys ...string) (int64, error) {
+	return t.DelCtx(context.Background(), keys...)
+}
+
+func (t *TieredCache) Expire(key string, expiration time.Duration) error {
+	return t.ExpireCtx(context.Background(), key, expiration)
+}
+
+func (t *TieredCache) Incr(key string) (int64, error) {
+	return t.IncrByCtx(context.Background(), key, 1)
+}
+
+func (t *TieredCache) Decr(key string) (int64, error) {
+	return t.IncrByCtx(context.Background(), key, -1)
+}
+
+func (t *TieredCache) IncrBy(key string, value int64) (int64, error) {
+	return t.IncrByCtx(context.Background(), key, value)
+}
+
+func (t *TieredCache) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	return t.SetNXCtx(context.Background(), key, value, expiration)
+}
+
+func (t *TieredCache) CompareAndDel(key, value string) (bool, error) {
+	return t.CompareAndDelCtx(context.Background(), key, value)
+}
+
+func (t *TieredCache) GetSet(key string, value interface{}) (string, error) {
+	return t.GetSetCtx(context.Background(), key, value)
+}
+
+func (t *TieredCache) MSet(values ...interface{}) error {
+	return t.MSetCtx(context.Background(), values...)
+}
+
+func (t *TieredCache) Rename(key, newKey string) error {
+	return t.RenameCtx(context.Background(), key, newKey)
+}
+
+// Pipeline 创建二级缓存的命令队列，执行后失效写入的字符串键
+func (t *TieredCache) Pipeline() Pipeliner {
+	return &tieredPipeline{Pipeliner: t.Cache.Pipeline(), t: t}
+}
+
+// TxPipeline 创建二级缓存的事务命令队列，执行后失效写入的字符串键
+func (t *TieredCache) TxPipeline() Pipeliner {
+	return &tieredPipeline{Pipeliner: t.Cache.TxPipeline(), t: t}
+}
+
+// tieredPipeline 记录会修改字符串的命令的键，Exec 之后失效一级缓存，读取命令直接读二级缓存
+type tieredPipeline struct {
+	Pipeliner
+	t    *TieredCache
+	keys []string
+}
+
+func (p *tieredPipeline) Set(key string, value interface{}, expiration time.Duration) *Cmd {
+	p.keys = append(p.keys, key)
+	return p.Pipeliner.Set(key, value, expiration)
+}
+
+func (p *tieredPipeline) Del(keys ...string) *Cmd {
+	p.keys = append(p.keys, keys...)
+	return p.Pipeliner.Del(keys...)
+}
+
+func (p *tieredPipeline) Expire(key string, expiration time.Duration) *Cmd {
+	p.keys = append(p.keys, key)
+	return p.Pipeliner.Expire(key, expiration)
+}
+
+func (p *tieredPipeline) Incr(key string) *Cmd {
+	p.keys = append(p.keys, key)
+	return p.Pipeliner.Incr(key)
+}
+
+func (p *tieredPipeline) Decr(key string) *Cmd {
+	p.keys = append(p.keys, key)
+	return p.Pipeliner.Decr(key)
+}
+
+func (p *tieredPipeline) IncrBy(key string, value int64) *Cmd {
+	p.keys = append(p.keys, key)
+	return p.Pipeliner.IncrBy(key, value)
+}
+
+// Exec 执行命令，部分命令失败时其他命令可能已经写入，因此总是失效记录的键
+func (p *tieredPipeline) Exec(ctx context.Context) ([]*Cmd, error) {
+	keys := p.keys
+	p.keys = nil
+	cmds, err := p.Pipeliner.Exec(ctx)
+	p.t.invalidate(ctx, keys...)
+	return cmds, err
+}
+
+func (p *tieredPipeline) Discard() {
+	p.keys = nil
+	p.Pipeliner.Discard()
+}
+
+// GetCtx 先读一级缓存，未命中时读取二级缓存并回填
+func (t *TieredCache) GetCtx(ctx context.Context, key string) (string, error) {
+	if value, err := t.l1.GetCtx(ctx, key); err == nil {
+		return value, nil
+	}
+
+	gen := t.gen.Load()
+	value, err := t.Cache.GetCtx(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	// 回填的保留时间不超过二级缓存的剩余时间
+	ttl := t.opts.L1TTL
+	if remaining, err := t.Cache.TTLCtx(ctx, key); err == nil && remaining > 0 && remaining < ttl {
+		ttl = remaining
+	}
+	if t.gen.Load() == gen {
+		t.l1.SetCtx(ctx, key, value, ttl)
+	}
+	return value, nil
+}
+
+func (t *TieredCache) SetCtx(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	err := t.Cache.SetCtx(ctx, key, value, expiration)
+	t.after(ctx, err, key)
+	return err
+}
+
+func (t *TieredCache) DelCtx(ctx context.Context, keys ...string) (int64, error) {
+	n, err := t.Cache.DelCtx(ctx, keys...)
+	t.after(ctx, err, keys...)
+	return n, err
+}
+
+func (t *TieredCache) ExpireCtx(ctx context.Context, key string, expiration time.Duration) error {
+	err := t.Cache.ExpireCtx(ctx, key, expiration)
+	t.after(ctx, err, key)
+	return err
+}
+
+func (t *TieredCache) IncrCtx(ctx context.Context, key string) (int64, error) {
+	return t.IncrByCtx(ctx, key, 1)
+}
+
+func (t *TieredCache) DecrCtx(ctx context.Context, key string) (int64, error) {
+	return t.IncrByCtx(ctx, key, -1)
+}
+
+func (t *TieredCache) IncrByCtx(ctx context.Context, key string, value int64) (int64, error) {
+	n, err := t.Cache.IncrByCtx(ctx, key, value)
+	t.after(ctx, err, key)
+	return n, err
+}
+
+func (t *TieredCache) SetNXCtx(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	ok, err := t.Cache.SetNXCtx(ctx, key, value, expiration)
+	if ok {
+		t.after(ctx, err, key)
+	}
+	return ok, err
+}
+
+func (t *TieredCache) CompareAndDelCtx(ctx context.Context, key, value string) (bool, error) {
+	ok, err := t.Cache.CompareAndDelCtx(ctx, key, value)
+	if ok {
+		t.after(ctx, err, key)
+	}
+	return ok, err
+}
+
+// GetSetCtx 键不存在时二级缓存同样写入了新值，因此也需要失效
+func (t *TieredCache) GetSetCtx(ctx context.Context, key string, value interface{}) (string, error) {
+	old, err := t.Cache.GetSetCtx(ctx, key, value)
+	if err == nil || errors.Is(err, ErrKeyNotFound) {
+		t.invalidate(ctx, key)
+	}
+	return old, err
+}
+
+func (t *TieredCache) MSetCtx(ctx context.Context, values ...interface{}) error {
+	pairs, err := hashFields(values)
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		keys = append(keys, pairs[i])
+	}
+
+	err = t.Cache.MSetCtx(ctx, values...)
+	t.after(ctx, err, keys...)
+	return err
+}
+
+func (t *TieredCache) RenameCtx(ctx context.Context, key, newKey string) error {
+	err := t.Cache.RenameCtx(ctx, key, newKey)
+	t.after(ctx, err, key, newKey)
+	return err
+}
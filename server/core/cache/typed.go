@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/zhoudm1743/go-web/core/log"
+)
+
+// TypedOptions 类型安全缓存的选项
+type TypedOptions struct {
+	// Codec 写入使用的编码，默认 JSONCodec；读取时按值中的标记选择编码，修改后仍能读取旧值
+	Codec Codec
+	// Compression 写入时的压缩算法，默认不压缩
+	Compression Compression
+	// CompressionThreshold 编码结果达到该字节数才压缩，默认 4096
+	CompressionThreshold int
+	Logger               log.Logger // 记录 Remember 写入缓存和加锁失败，可为空
+}
+
+// Typed 类型安全的缓存，值按 Codec 编码为带有编码和压缩标记的字节串后存储，
+// 内存、文件和 Redis 缓存存储的内容相同，切换缓存驱动或编码后仍能读取
+//
+//	users := cache.NewTyped[User](facades.Cache(), cache.TypedOptions{Codec: cache.MsgpackCodec})
+//	err := users.Set(ctx, "user:1", user, time.Hour)
+//	user, err := users.Get(ctx, "user:1")
+type Typed[T any] struct {
+	cache  Cache
+	opts   TypedOptions
+	helper *CacheHelper // Remember 使用
+}
+
+// NewTyped 创建类型安全的缓存，键的前缀与 c 相同，需要隔离时使用 WithPrefix
+func NewTyped[T any](c Cache, opts TypedOptions) *Typed[T] {
+	if opts.Codec == nil {
+		opts.Codec = JSONCodec
+	}
+	if opts.CompressionThreshold <= 0 {
+		opts.CompressionThreshold = defaultCompressionThreshold
+	}
+	return &Typed[T]{cache: c, opts: opts, helper: NewCacheHelper(c, opts.Logger, "")}
+}
+
+// Cache 返回底层缓存
+func (t *Typed[T]) Cache() Cache {
+	return t.cache
+}
+
+// Get 读取并解码值，键不存在时返回 ErrKeyNotFound
+//
+// 没有编码标记的值按 JSON 解析，可以读取 CacheHelper.SetJSON 写入的值；
+// 也可以读取 Remember 写入的值，缓存的是不存在的结果时返回 ErrNotFound
+func (t *Typed[T]) Get(ctx context.Context, key string) (T, error) {
+	var v T
+	data, err := t.cache.GetCtx(ctx, key)
+	if err != nil {
+		return v, err
+	}
+	err = decodeValue([]byte(data), &v)
+	return v, err
+}
+
+// Set 编码并写入值，expiration 为 0 表示不过期
+func (t *Typed[T]) Set(ctx context.Context, key string, value T, expiration time.Duration) error {
+	data, err := encodeValue(t.opts.Codec, t.opts.Compression, t.opts.CompressionThreshold, value)
+	if err != nil {
+		return err
+	}
+	return t.cache.SetCtx(ctx, key, string(data), expiration)
+}
+
+// Remember 记忆模式，与 Remember 函数的实现相同，支持提前刷新、缓存不存在的结果和跨实例加锁，
+// 值按 Typed 的编码和压缩选项存储
+//
+//	user, err := users.Remember(ctx, "user:1", cache.RememberOptions{TTL: time.Hour}, func() (User, error) {
+//		return loadUser(1)
+//	})
+func (t *Typed[T]) Remember(ctx context.Context, key string, opts RememberOptions, fn func() (T, error)) (T, error) {
+	encode := func(v interface{}) ([]byte, error) {
+		return encodeValue(t.opts.Codec, t.opts.Compression, t.opts.CompressionThreshold, v)
+	}
+	e, err := t.helper.remember(ctx, key, opts, encode, func() (interface{}, error) {
+		return fn()
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return decodeEntry[T](e)
+}
@@ -15,9 +15,14 @@ type Config struct {
 	HTTP     HTTPConfig     `mapstructure:"http"`
 	JWT      JWTConfig      `mapstructure:"jwt"`
 	Database DatabaseConfig `mapstructure:"database"`
-	Log      LogConfig      `mapstructure:"log"`
-	Cache    CacheConfig    `mapstructure:"cache"`
-	viper    *viper.Viper   // 存储viper实例，用于获取配置
+	// Databases 命名的业务数据库连接，通过 facades.DB("name") 访问
+	Databases map[string]ConnectionConfig `mapstructure:"databases"`
+	Log       LogConfig                   `mapstructure:"log"`
+	Cache     CacheConfig                 `mapstructure:"cache"`
+	Tenant    TenantConfig                `mapstructure:"tenant"`
+	Backup    BackupConfig                `mapstructure:"backup"`
+	Trash     TrashConfig                 `mapstructure:"trash"`
+	viper     *viper.Viper                // 存储viper实例，用于获取配置
 }
 
 // AppConfig 应用配置
@@ -49,10 +54,48 @@ type JWTConfig struct {
 type DatabaseConfig struct {
 	Driver          string
 	DSN             string
+	Replicas        []string // 只读副本DSN，配置后查询走副本、写入走主库
+	Policy          string   // 副本负载均衡策略：random、round_robin、least_conn
 	MaxOpenConns    int
 	MaxIdleConns    int
 	ConnMaxLifetime time.Duration
 	LogLevel        string
+	SlowThreshold   time.Duration // 慢查询阈值，超过时以警告级别记录并计入慢查询统计，为0时不记录慢查询
+	LogSampleRate   float64       // 普通SQL日志的采样率(0, 1]，慢查询和错误始终记录
+	SlowQueryLimit  int           // 慢查询统计最多保留的语句数
+	AutoMigrate     bool          // 启动时自动执行未完成的迁移
+	AutoSeed        bool          // 启动时自动执行数据填充
+	FixturesPath    string        // 数据文件目录，供 db:seed 命令加载
+}
+
+// ConnectionConfig 单个数据库连接配置
+type ConnectionConfig struct {
+	Driver          string
+	DSN             string
+	Replicas        []string // 只读副本DSN
+	Policy          string   // 副本负载均衡策略
+	MaxOpenConns    int      // 为0时继承默认连接的配置
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	LogLevel        string
+	SlowThreshold   time.Duration // 为0时继承默认连接的配置
+	LogSampleRate   float64       // 为0时继承默认连接的配置
+}
+
+// Connection 默认连接的连接配置
+func (d DatabaseConfig) Connection() ConnectionConfig {
+	return ConnectionConfig{
+		Driver:          d.Driver,
+		DSN:             d.DSN,
+		Replicas:        d.Replicas,
+		Policy:          d.Policy,
+		MaxOpenConns:    d.MaxOpenConns,
+		MaxIdleConns:    d.MaxIdleConns,
+		ConnMaxLifetime: d.ConnMaxLifetime,
+		LogLevel:        d.LogLevel,
+		SlowThreshold:   d.SlowThreshold,
+		LogSampleRate:   d.LogSampleRate,
+	}
 }
 
 // LogConfig 日志配置
@@ -71,6 +114,114 @@ type CacheConfig struct {
 	DB       int
 	Prefix   string // 键前缀
 	FilePath string // 文件缓存路径，仅当 Type 为 file 时使用
+	Metrics  bool   // 统计调用次数、耗时和命中率，见 cache.InstrumentedCache
+	// Redis 哨兵、集群、TLS、连接池和启动重试，Type 为 redis 或两级缓存的二级缓存为 redis 时使用
+	Redis RedisCacheConfig `mapstructure:"redis"`
+	// File 文件缓存的持久化、压缩和容量，Type 为 file 或两级缓存的二级缓存为 file 时使用
+	File FileCacheConfig `mapstructure:"file"`
+	// Memory 内存缓存容量和淘汰策略，Type 为 memory 或两级缓存的一级缓存为 memory 时使用
+	Memory MemoryCacheConfig `mapstructure:"memory"`
+	// Tiered 两级缓存，仅当 Type 为 tiered 时使用
+	Tiered TieredCacheConfig `mapstructure:"tiered"`
+	// Model 模型查询缓存
+	Model ModelCacheConfig `mapstructure:"model"`
+}
+
+// RedisCacheConfig Redis 连接配置，密码和数据库编号使用 CacheConfig 的 Password、DB
+type RedisCacheConfig struct {
+	Mode       string   // 部署模式：single（默认）、sentinel 或 cluster
+	Addrs      []string // 哨兵或集群节点的地址，为空时使用 Host:Port
+	MasterName string   // 哨兵模式的主节点名称
+	Username   string   // ACL 用户名，为空时只使用密码认证
+	// SentinelUsername、SentinelPassword 连接哨兵节点的认证信息，与数据节点不同时配置
+	SentinelUsername string
+	SentinelPassword string
+
+	PoolSize     int           // 每个节点的最大连接数，0 表示每个 CPU 10 个
+	MinIdleConns int           // 每个节点保持的最少空闲连接数
+	DialTimeout  time.Duration // 建立连接的超时时间，0 表示 5 秒
+	ReadTimeout  time.Duration // 读取超时时间，0 表示 3 秒，-1 表示不超时
+	WriteTimeout time.Duration // 写入超时时间，0 表示与 ReadTimeout 相同
+
+	TLS RedisTLSConfig `mapstructure:"tls"`
+
+	// ConnectRetries 启动时连接失败的重试次数，0 表示不重试
+	ConnectRetries int
+	// ConnectBackoff 第一次重试前的等待时间，之后每次翻倍，最长 30 秒
+	ConnectBackoff time.Duration
+}
+
+// RedisTLSConfig Redis TLS 配置
+type RedisTLSConfig struct {
+	Enabled    bool
+	CAFile     string // 校验服务端证书的 CA 证书，为空时使用系统证书
+	CertFile   string // 客户端证书，服务端要求双向认证时配置
+	KeyFile    string // 客户端私钥
+	ServerName string // 校验证书时使用的服务端名称，为空时使用连接地址的主机名
+	// InsecureSkipVerify 跳过服务端证书校验，仅用于测试环境
+	InsecureSkipVerify bool
+}
+
+// FileCacheConfig 文件缓存配置
+type FileCacheConfig struct {
+	SyncMode             string        // 持久化方式：normal（默认）、full 或 off
+	Compression          string        // 压缩算法：gzip（默认）、flate 或 none
+	CompressionThreshold int           // 超过该字节数的值才压缩，0 表示 4096
+	CleanupInterval      time.Duration // 后台清理过期键的间隔，0 表示只在读取时判断过期
+	CompactInterval      time.Duration // 后台压缩数据库文件的间隔，0 表示不自动压缩
+	MaxSize              int64         // 数据大小上限（字节），超出后淘汰最早过期的键，0 表示不限制
+}
+
+// MemoryCacheConfig 内存缓存配置
+type MemoryCacheConfig struct {
+	MaxEntries int    // 最大键数量，0 表示不限制
+	MaxBytes   int64  // 最大占用字节数（按键和值估算），0 表示不限制
+	Policy     string // 达到上限时的淘汰策略：lru、lfu 或 tinylfu
+	Shards     int    // 分片数量，向上取整为 2 的幂，容量上限平均分配到各分片
+	// SweepInterval 后台清理过期键的间隔，0 表示不清理，过期键只在访问时删除
+	SweepInterval time.Duration
+}
+
+// TieredCacheConfig 两级缓存配置
+type TieredCacheConfig struct {
+	L1      string        // 一级缓存类型，通常为 memory
+	L2      string        // 二级缓存类型：redis 或 file
+	L1TTL   time.Duration // 一级缓存的最长保留时间
+	Channel string        // 失效消息的 Redis 频道，自动追加键前缀
+}
+
+// ModelCacheConfig 模型查询缓存配置
+type ModelCacheConfig struct {
+	Enabled bool          // 是否在数据库连接上注册缓存插件，注册后查询仍需显式开启或在 Tables 中配置
+	TTL     time.Duration // 默认过期时间
+	// Tables 按表自动缓存主键查询并指定过期时间，为 0 时使用默认过期时间
+	Tables map[string]time.Duration
+}
+
+// TenantConfig 多租户配置
+type TenantConfig struct {
+	Enabled     bool     // 是否启用多租户，未启用时所有请求属于超级租户
+	Resolvers   []string // 租户识别方式及顺序：header、subdomain、jwt
+	Header      string   // 租户请求头，值为租户编码
+	Domain      string   // 主域名，子域名识别时去掉该后缀得到租户编码
+	SuperTenant string   // 超级租户（平台运营方）编码
+	// AllowUnscoped 上下文中没有租户时不过滤直接查询租户模型，默认返回错误，
+	// 跨租户查询应通过 tenant.SkipScope 显式声明
+	AllowUnscoped bool
+}
+
+// BackupConfig 数据库备份配置
+type BackupConfig struct {
+	Path     string        // 备份目录
+	Format   string        // 备份格式：auto（sqlite 使用快照，其他驱动使用逻辑导出）、sqlite、jsonl
+	Interval time.Duration // 定时备份间隔，为0时不定时备份
+	Keep     int           // 保留的备份数量，为0时不清理
+}
+
+// TrashConfig 回收站配置
+type TrashConfig struct {
+	Retention time.Duration // 软删除记录的保留期，超过后永久删除
+	Interval  time.Duration // 定时清理间隔，为0时不定时清理
 }
 
 // setDefaultConfig 设置配置的默认值
@@ -101,6 +252,13 @@ func setDefaultConfig(config *Config) {
 	config.Database.MaxIdleConns = 10
 	config.Database.ConnMaxLifetime = time.Hour
 	config.Database.LogLevel = "info"
+	config.Database.SlowThreshold = 200 * time.Millisecond
+	config.Database.LogSampleRate = 1
+	config.Database.SlowQueryLimit = 100
+	config.Database.Policy = "random"
+	config.Database.AutoMigrate = true
+	config.Database.AutoSeed = true
+	config.Database.FixturesPath = "fixtures"
 
 	// 日志配置默认值
 	config.Log.Level = "info"
@@ -115,6 +273,37 @@ func setDefaultConfig(config *Config) {
 	config.Cache.DB = 0
 	config.Cache.Prefix = "go-web:"
 	config.Cache.FilePath = "cache"
+	config.Cache.Redis.Mode = "single"
+	config.Cache.Redis.ConnectRetries = 5
+	config.Cache.Redis.ConnectBackoff = 500 * time.Millisecond
+	config.Cache.Metrics = true
+	config.Cache.File.SyncMode = "normal"
+	config.Cache.File.Compression = "gzip"
+	config.Cache.File.CompressionThreshold = 4096
+	config.Cache.File.CleanupInterval = 5 * time.Minute
+	config.Cache.File.CompactInterval = 24 * time.Hour
+	config.Cache.Memory.Policy = "lru"
+	config.Cache.Memory.Shards = 32
+	config.Cache.Memory.SweepInterval = time.Minute
+	config.Cache.Tiered.L1 = "memory"
+	config.Cache.Tiered.L2 = "redis"
+	config.Cache.Tiered.L1TTL = time.Minute
+	config.Cache.Tiered.Channel = "cache:invalidate"
+	config.Cache.Model.TTL = 5 * time.Minute
+
+	// 多租户配置默认值
+	config.Tenant.Enabled = false
+	config.Tenant.Resolvers = []string{"header", "subdomain", "jwt"}
+	config.Tenant.Header = "X-Tenant"
+	config.Tenant.SuperTenant = "platform"
+
+	// 备份配置默认值
+	config.Backup.Path = "backups"
+	config.Backup.Format = "auto"
+	config.Backup.Keep = 7
+
+	// 回收站配置默认值
+	config.Trash.Retention = 30 * 24 * time.Hour
 }
 
 // NewConfig 创建配置
@@ -233,6 +422,10 @@ func (c *Config) Get(key string) interface{} {
 			return c.Database.Driver
 		case "dsn":
 			return c.Database.DSN
+		case "replicas":
+			return c.Database.Replicas
+		case "policy":
+			return c.Database.Policy
 		case "maxOpenConns":
 			return c.Database.MaxOpenConns
 		case "maxIdleConns":
@@ -241,6 +434,19 @@ func (c *Config) Get(key string) interface{} {
 			return c.Database.ConnMaxLifetime
 		case "logLevel":
 			return c.Database.LogLevel
+		case "autoMigrate":
+			return c.Database.AutoMigrate
+		case "autoSeed":
+			return c.Database.AutoSeed
+		case "fixturesPath":
+			return c.Database.FixturesPath
+		}
+	case "databases":
+		if len(parts) == 1 {
+			return c.Databases
+		}
+		if conn, ok := c.Databases[parts[1]]; ok && len(parts) == 2 {
+			return conn
 		}
 	case "log":
 		if len(parts) == 1 {
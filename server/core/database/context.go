@@ -0,0 +1,96 @@
+package database
+
+import (
+	"context"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// 上下文键
+type (
+	connectionKey  struct{}
+	primaryKey     struct{}
+	txKey          struct{}
+	afterCommitKey struct{}
+)
+
+// WithConnection 在上下文中指定本次请求使用的连接
+func WithConnection(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, connectionKey{}, name)
+}
+
+// ConnectionFromContext 获取上下文中指定的连接名称，未指定时返回空
+func ConnectionFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(connectionKey{}).(string)
+	return name
+}
+
+// WithPrimary 在上下文中指定查询也走主库
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// PrimaryFromContext 判断上下文是否指定走主库
+func PrimaryFromContext(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+	return primary
+}
+
+// WithTx 在上下文中存放请求事务
+func WithTx(ctx context.Context, tx *gorm.DB) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// TxFromContext 获取上下文中的请求事务，不存在时返回nil
+func TxFromContext(ctx context.Context) *gorm.DB {
+	tx, _ := ctx.Value(txKey{}).(*gorm.DB)
+	return tx
+}
+
+// afterCommit 事务提交后执行的回调
+type afterCommit struct {
+	mu   sync.Mutex
+	keys map[string]struct{}
+	fns  []func()
+}
+
+// WithAfterCommit 在上下文中开启提交后回调队列，开启事务的一方在提交成功后调用 RunAfterCommit
+func WithAfterCommit(ctx context.Context) context.Context {
+	return context.WithValue(ctx, afterCommitKey{}, &afterCommit{keys: map[string]struct{}{}})
+}
+
+// AfterCommit 将回调加入上下文的提交后回调队列，相同 key 的回调只保留第一个
+//
+// 上下文没有回调队列时返回 false，调用方应立即执行。
+func AfterCommit(ctx context.Context, key string, fn func()) bool {
+	queue, _ := ctx.Value(afterCommitKey{}).(*afterCommit)
+	if queue == nil {
+		return false
+	}
+
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	if _, exists := queue.keys[key]; !exists {
+		queue.keys[key] = struct{}{}
+		queue.fns = append(queue.fns, fn)
+	}
+	return true
+}
+
+// RunAfterCommit 按加入顺序执行并清空提交后回调，事务回滚时不调用
+func RunAfterCommit(ctx context.Context) {
+	queue, _ := ctx.Value(afterCommitKey{}).(*afterCommit)
+	if queue == nil {
+		return
+	}
+
+	queue.mu.Lock()
+	fns := queue.fns
+	queue.fns, queue.keys = nil, map[string]struct{}{}
+	queue.mu.Unlock()
+
+	for _, fn := range fns {
+		fn()
+	}
+}
@@ -0,0 +1,182 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/zhoudm1743/go-web/core/conf"
+	"github.com/zhoudm1743/go-web/core/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Logger 结构化的GORM日志记录器
+//
+// 每条SQL以日志字段记录耗时、影响行数、调用位置、请求ID和连接名称。
+// 超过慢查询阈值的语句以警告级别记录并计入 SlowQueries 统计，
+// 普通语句在 info 级别下按采样率记录。
+type Logger struct {
+	log        log.Logger
+	level      logger.LogLevel
+	slow       time.Duration
+	sampleRate float64
+	connection string
+	driver     string
+}
+
+// NewLogger 按连接配置创建GORM日志记录器
+func NewLogger(l log.Logger, connection string, cfg conf.ConnectionConfig) *Logger {
+	return &Logger{
+		log:        l,
+		level:      parseLogLevel(cfg.LogLevel),
+		slow:       cfg.SlowThreshold,
+		sampleRate: cfg.LogSampleRate,
+		connection: connection,
+		driver:     cfg.Driver,
+	}
+}
+
+// parseLogLevel 解析GORM日志级别，默认只记录错误
+func parseLogLevel(level string) logger.LogLevel {
+	switch level {
+	case "info":
+		return logger.Info
+	case "warn":
+		return logger.Warn
+	case "silent":
+		return logger.Silent
+	default:
+		return logger.Error
+	}
+}
+
+// LogMode 设置日志级别
+func (l *Logger) LogMode(level logger.LogLevel) logger.Interface {
+	clone := *l
+	clone.level = level
+	return &clone
+}
+
+// Info 记录信息日志
+func (l *Logger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.log != nil && l.level >= logger.Info {
+		l.fields(ctx).Infof(msg, data...)
+	}
+}
+
+// Warn 记录警告日志
+func (l *Logger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.log != nil && l.level >= logger.Warn {
+		l.fields(ctx).Warnf(msg, data...)
+	}
+}
+
+// Error 记录错误日志
+func (l *Logger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.log != nil && l.level >= logger.Error {
+		l.fields(ctx).Errorf(msg, data...)
+	}
+}
+
+// Trace 记录SQL执行情况
+func (l *Logger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	elapsed := time.Since(begin)
+	isError := err != nil && !errors.Is(err, gorm.ErrRecordNotFound)
+	isSlow := l.slow > 0 && elapsed > l.slow
+
+	switch {
+	case isError && l.level >= logger.Error:
+	case isSlow:
+	case l.level >= logger.Info && l.sampled():
+	default:
+		return
+	}
+
+	sql, rows := fc()
+	caller := callerLocation()
+	if isSlow {
+		SlowQueries().Record(SlowSample{
+			Connection: l.connection,
+			SQL:        sql,
+			Driver:     l.driver,
+			Elapsed:    elapsed,
+			Rows:       rows,
+			Caller:     caller,
+			RequestID:  log.RequestIDFromContext(ctx),
+		})
+	}
+	if l.log == nil {
+		return
+	}
+
+	entry := l.fields(ctx).WithFields(logrus.Fields{
+		"sql":         sql,
+		"duration_ms": float64(elapsed.Microseconds()) / 1000,
+		"rows":        rows,
+		"caller":      caller,
+	})
+	switch {
+	case isError && l.level >= logger.Error:
+		entry.WithError(err).Error("[SQL] 执行失败")
+	case isSlow:
+		if l.level >= logger.Warn {
+			entry.WithField("slow_threshold", l.slow.String()).Warn("[SQL] 慢查询")
+		}
+	default:
+		entry.Info("[SQL]")
+	}
+}
+
+// fields 公共日志字段
+func (l *Logger) fields(ctx context.Context) *logrus.Entry {
+	fields := logrus.Fields{"connection": l.connection}
+	if id := log.RequestIDFromContext(ctx); id != "" {
+		fields["request_id"] = id
+	}
+	return l.log.WithFields(fields)
+}
+
+// sampled 普通SQL是否记录
+func (l *Logger) sampled() bool {
+	return l.sampleRate <= 0 || l.sampleRate >= 1 || rand.Float64() < l.sampleRate
+}
+
+// skipPackages 查找调用位置时跳过的包
+var skipPackages = func() []string {
+	pkg := reflect.TypeOf(Logger{}).PkgPath()
+	module := strings.TrimSuffix(pkg, "/core/database")
+	return []string{"gorm.io/", pkg + ".", module + "/core/repository.", module + "/core/tenant."}
+}()
+
+// callerLocation 获取执行SQL的业务代码位置，跳过GORM和框架内部的调用
+func callerLocation() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !skipFrame(frame.Function) {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// skipFrame 判断调用帧是否属于需要跳过的包
+func skipFrame(function string) bool {
+	for _, prefix := range skipPackages {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
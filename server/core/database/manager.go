@@ -0,0 +1,262 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zhoudm1743/go-web/core/conf"
+	"github.com/zhoudm1743/go-web/core/log"
+	"gorm.io/gorm"
+)
+
+// DefaultConnection 默认连接名称，对应配置中的 database 节点
+const DefaultConnection = "default"
+
+// Connector 模型实现该接口以指定所属的连接
+type Connector interface {
+	Connection() string
+}
+
+// HealthStatus 连接健康状态
+type HealthStatus struct {
+	Name     string `json:"name"`            // 连接名称
+	Role     string `json:"role"`            // primary 或 replica
+	Index    int    `json:"index"`           // 副本序号
+	Healthy  bool   `json:"healthy"`         // 是否健康
+	Latency  string `json:"latency"`         // Ping耗时
+	Error    string `json:"error,omitempty"` // 错误信息
+	Open     int    `json:"open"`            // 打开的连接数
+	InUse    int    `json:"inUse"`           // 使用中的连接数
+	Idle     int    `json:"idle"`            // 空闲连接数
+	WaitTime string `json:"waitTime"`        // 等待连接的总时间
+}
+
+// Manager 数据库连接管理器
+type Manager struct {
+	mu          sync.RWMutex
+	connections map[string]*gorm.DB
+	configs     map[string]conf.ConnectionConfig
+}
+
+// NewManager 根据配置创建默认连接和所有命名连接
+func NewManager(p DBParams) (*Manager, error) {
+	m := &Manager{
+		connections: make(map[string]*gorm.DB),
+		configs:     make(map[string]conf.ConnectionConfig),
+	}
+
+	SlowQueries().SetLimit(p.Config.Database.SlowQueryLimit)
+
+	if err := m.Add(DefaultConnection, p.Config.Database.Connection(), p.Logger); err != nil {
+		return nil, err
+	}
+
+	// 按名称排序创建，保证日志和错误顺序稳定
+	names := make([]string, 0, len(p.Config.Databases))
+	for name := range p.Config.Databases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		cfg := inherit(p.Config.Databases[name], p.Config.Database)
+		if err := m.Add(name, cfg, p.Logger); err != nil {
+			m.Close()
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// NewManagerWith 使用已有的默认连接创建管理器
+func NewManagerWith(db *gorm.DB) *Manager {
+	return &Manager{
+		connections: map[string]*gorm.DB{DefaultConnection: db},
+		configs:     make(map[string]conf.ConnectionConfig),
+	}
+}
+
+// inherit 未配置的连接池参数继承默认连接
+func inherit(cfg conf.ConnectionConfig, base conf.DatabaseConfig) conf.ConnectionConfig {
+	if cfg.MaxOpenConns == 0 {
+		cfg.MaxOpenConns = base.MaxOpenConns
+	}
+	if cfg.MaxIdleConns == 0 {
+		cfg.MaxIdleConns = base.MaxIdleConns
+	}
+	if cfg.ConnMaxLifetime == 0 {
+		cfg.ConnMaxLifetime = base.ConnMaxLifetime
+	}
+	if cfg.LogLevel == "" {
+		cfg.LogLevel = base.LogLevel
+	}
+	if cfg.Policy == "" {
+		cfg.Policy = base.Policy
+	}
+	if cfg.SlowThreshold == 0 {
+		cfg.SlowThreshold = base.SlowThreshold
+	}
+	if cfg.LogSampleRate == 0 {
+		cfg.LogSampleRate = base.LogSampleRate
+	}
+	return cfg
+}
+
+// Add 创建并注册一个命名连接
+func (m *Manager) Add(name string, cfg conf.ConnectionConfig, l log.Logger) error {
+	if name == "" {
+		return fmt.Errorf("连接名称不能为空")
+	}
+
+	db, err := Open(name, cfg, l)
+	if err != nil {
+		return fmt.Errorf("创建数据库连接 %s 失败: %w", name, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if old, exists := m.connections[name]; exists {
+		closeAll(old)
+	}
+	m.connections[name] = db
+	m.configs[name] = cfg
+	return nil
+}
+
+// Default 获取默认连接
+func (m *Manager) Default() *gorm.DB {
+	return m.Get(DefaultConnection)
+}
+
+// Get 获取命名连接，名称为空表示默认连接，不存在时返回nil
+func (m *Manager) Get(name string) *gorm.DB {
+	if name == "" {
+		name = DefaultConnection
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.connections[name]
+}
+
+// Connection 获取命名连接，不存在时返回错误
+func (m *Manager) Connection(name string) (*gorm.DB, error) {
+	db := m.Get(name)
+	if db == nil {
+		return nil, fmt.Errorf("数据库连接 %s 未配置", name)
+	}
+	return db, nil
+}
+
+// Has 判断连接是否存在
+func (m *Manager) Has(name string) bool {
+	return m.Get(name) != nil
+}
+
+// Names 获取所有连接名称，默认连接排在第一个
+func (m *Manager) Names() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.connections))
+	for name := range m.connections {
+		if name != DefaultConnection {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return append([]string{DefaultConnection}, names...)
+}
+
+// Model 获取模型所属的连接，模型未实现 Connector 时使用默认连接
+func (m *Manager) Model(model interface{}) (*gorm.DB, error) {
+	if c, ok := model.(Connector); ok {
+		return m.Connection(c.Connection())
+	}
+	return m.Connection(DefaultConnection)
+}
+
+// Context 获取上下文指定的连接，并绑定上下文
+//
+// 上下文中存在请求事务时直接返回该事务。
+func (m *Manager) Context(ctx context.Context) (*gorm.DB, error) {
+	if tx := TxFromContext(ctx); tx != nil {
+		return tx.WithContext(ctx), nil
+	}
+
+	db, err := m.Connection(ConnectionFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	db = db.WithContext(ctx)
+	if PrimaryFromContext(ctx) {
+		db = Write(db)
+	}
+	return db, nil
+}
+
+// Health 检查所有连接（含副本）的健康状态
+func (m *Manager) Health(ctx context.Context) []HealthStatus {
+	var result []HealthStatus
+	for _, name := range m.Names() {
+		db := m.Get(name)
+		if db == nil {
+			continue
+		}
+
+		for i, pool := range connPools(db) {
+			status := HealthStatus{Name: name, Role: "primary"}
+			if i > 0 {
+				status.Role, status.Index = "replica", i
+			}
+
+			start := time.Now()
+			pingCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
+			err := pool.PingContext(pingCtx)
+			cancel()
+
+			status.Latency = time.Since(start).String()
+			status.Healthy = err == nil
+			if err != nil {
+				status.Error = err.Error()
+			}
+
+			stats := pool.Stats()
+			status.Open, status.InUse, status.Idle = stats.OpenConnections, stats.InUse, stats.Idle
+			status.WaitTime = stats.WaitDuration.String()
+			result = append(result, status)
+		}
+	}
+	return result
+}
+
+// Close 关闭所有连接
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var errs []error
+	for name, db := range m.connections {
+		if err := closeAll(db); err != nil {
+			errs = append(errs, fmt.Errorf("关闭数据库连接 %s 失败: %w", name, err))
+		}
+	}
+	m.connections = make(map[string]*gorm.DB)
+	return errors.Join(errs...)
+}
+
+// closeAll 关闭主库和所有副本的连接池
+func closeAll(db *gorm.DB) error {
+	var errs []error
+	for _, pool := range connPools(db) {
+		if err := pool.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/zhoudm1743/go-web/core/conf"
+)
+
+// testItem 测试模型
+type testItem struct {
+	ID   uint
+	Name string
+}
+
+// reportItem 指定连接的测试模型
+type reportItem struct {
+	ID uint
+}
+
+func (reportItem) Connection() string { return "report" }
+
+// newTestConfig 创建测试配置
+func newTestConfig(t *testing.T) *conf.Config {
+	dir := t.TempDir()
+	return &conf.Config{
+		Database: conf.DatabaseConfig{
+			Driver:       "sqlite",
+			DSN:          filepath.Join(dir, "primary.db"),
+			Replicas:     []string{filepath.Join(dir, "replica.db")},
+			Policy:       "round_robin",
+			MaxOpenConns: 1,
+			LogLevel:     "silent",
+		},
+		Databases: map[string]conf.ConnectionConfig{
+			"report": {Driver: "sqlite", DSN: filepath.Join(dir, "report.db")},
+		},
+	}
+}
+
+// TestReadWriteSplitting 测试查询走副本、写入走主库
+func TestReadWriteSplitting(t *testing.T) {
+	m, err := NewManager(DBParams{Config: newTestConfig(t)})
+	if err != nil {
+		t.Fatalf("创建连接管理器失败: %v", err)
+	}
+	defer m.Close()
+
+	db := m.Default()
+	// 建表需要分别在主库和副本上执行
+	if err := db.AutoMigrate(&testItem{}); err != nil {
+		t.Fatalf("主库建表失败: %v", err)
+	}
+	if err := Read(db).Exec("CREATE TABLE test_items (id integer PRIMARY KEY, name text)").Error; err != nil {
+		t.Fatalf("副本建表失败: %v", err)
+	}
+
+	if err := db.Create(&testItem{Name: "a"}).Error; err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+
+	var count int64
+	db.Model(&testItem{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("查询应走副本, 实际记录数: %d", count)
+	}
+
+	ctx := WithPrimary(context.Background())
+	primary, err := m.Context(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	primary.Model(&testItem{}).Count(&count)
+	if count != 1 {
+		t.Fatalf("强制主库查询应读到写入的数据, 实际记录数: %d", count)
+	}
+}
+
+// TestNamedConnections 测试命名连接、模型连接与健康检查
+func TestNamedConnections(t *testing.T) {
+	cfg := newTestConfig(t)
+	m, err := NewManager(DBParams{Config: cfg})
+	if err != nil {
+		t.Fatalf("创建连接管理器失败: %v", err)
+	}
+	defer m.Close()
+
+	if names := m.Names(); len(names) != 2 || names[0] != DefaultConnection || names[1] != "report" {
+		t.Fatalf("连接名称不正确: %v", names)
+	}
+
+	if _, err := m.Connection("missing"); err == nil {
+		t.Fatal("未配置的连接应返回错误")
+	}
+
+	report, err := m.Model(reportItem{})
+	if err != nil || report != m.Get("report") {
+		t.Fatalf("模型应使用 report 连接: %v", err)
+	}
+
+	ctx := WithConnection(context.Background(), "report")
+	if db, err := m.Context(ctx); err != nil || db.Statement.Context != ctx {
+		t.Fatalf("应返回绑定上下文的 report 连接: %v", err)
+	}
+
+	checks := m.Health(context.Background())
+	if len(checks) != 3 {
+		t.Fatalf("期望3个健康检查结果(主库、副本、report), 实际: %+v", checks)
+	}
+	for _, check := range checks {
+		if !check.Healthy {
+			t.Fatalf("连接 %s(%s) 不健康: %s", check.Name, check.Role, check.Error)
+		}
+	}
+	if checks[1].Role != "replica" {
+		t.Fatalf("第二个检查结果应为副本: %+v", checks[1])
+	}
+}
+
+// TestInvalidPolicy 测试不支持的负载均衡策略
+func TestInvalidPolicy(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Database.Policy = "unknown"
+	if _, err := NewManager(DBParams{Config: cfg}); err == nil {
+		t.Fatal("期望不支持的策略返回错误")
+	}
+}
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/zhoudm1743/go-web/core/conf"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// useReplicas 注册只读副本，查询走副本，写入和事务走主库
+func useReplicas(db *gorm.DB, cfg conf.ConnectionConfig) error {
+	replicas := make([]gorm.Dialector, 0, len(cfg.Replicas))
+	for _, dsn := range cfg.Replicas {
+		dialector, err := newDialector(cfg.Driver, dsn)
+		if err != nil {
+			return err
+		}
+		replicas = append(replicas, dialector)
+	}
+
+	policy, err := newPolicy(cfg.Policy)
+	if err != nil {
+		return err
+	}
+
+	resolver := dbresolver.Register(dbresolver.Config{
+		Replicas: replicas,
+		Policy:   policy,
+	}).
+		SetMaxOpenConns(cfg.MaxOpenConns).
+		SetMaxIdleConns(cfg.MaxIdleConns).
+		SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
+	if err := db.Use(resolver); err != nil {
+		return fmt.Errorf("注册只读副本失败: %w", err)
+	}
+	return nil
+}
+
+// newPolicy 创建副本负载均衡策略
+func newPolicy(name string) (dbresolver.Policy, error) {
+	switch name {
+	case "", "random":
+		return dbresolver.RandomPolicy{}, nil
+	case "round_robin":
+		return dbresolver.StrictRoundRobinPolicy(), nil
+	case "least_conn":
+		return dbresolver.PolicyFunc(leastConn), nil
+	default:
+		return nil, fmt.Errorf("不支持的负载均衡策略: %s", name)
+	}
+}
+
+// leastConn 选择正在使用的连接数最少的副本
+func leastConn(pools []gorm.ConnPool) gorm.ConnPool {
+	best, bestInUse := pools[0], -1
+	for _, pool := range pools {
+		sqlDB, ok := pool.(*sql.DB)
+		if !ok {
+			continue
+		}
+		if inUse := sqlDB.Stats().InUse; bestInUse < 0 || inUse < bestInUse {
+			best, bestInUse = pool, inUse
+		}
+	}
+	return best
+}
+
+// connPools 获取连接的所有连接池，第一个为主库，其余为副本
+func connPools(db *gorm.DB) []*sql.DB {
+	plugin, ok := db.Config.Plugins[(&dbresolver.DBResolver{}).Name()].(*dbresolver.DBResolver)
+	if !ok {
+		if sqlDB, err := db.DB(); err == nil {
+			return []*sql.DB{sqlDB}
+		}
+		return nil
+	}
+
+	var pools []*sql.DB
+	seen := make(map[*sql.DB]bool)
+	plugin.Call(func(pool gorm.ConnPool) error {
+		if sqlDB, ok := pool.(*sql.DB); ok && !seen[sqlDB] {
+			seen[sqlDB] = true
+			pools = append(pools, sqlDB)
+		}
+		return nil
+	})
+	return pools
+}
+
+// Write 强制使用主库，用于写后立即读的场景
+func Write(db *gorm.DB) *gorm.DB {
+	return db.Clauses(dbresolver.Write)
+}
+
+// Read 强制使用副本
+func Read(db *gorm.DB) *gorm.DB {
+	return db.Clauses(dbresolver.Read)
+}
@@ -0,0 +1,163 @@
+package database
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SlowSample 一次慢查询
+type SlowSample struct {
+	Connection string        // 连接名称
+	Driver     string        // 数据库驱动，用于归一化语句
+	SQL        string        // 完整语句
+	Elapsed    time.Duration // 耗时
+	Rows       int64         // 影响行数
+	Caller     string        // 调用位置
+	RequestID  string        // 请求ID
+}
+
+// SlowQuery 按语句聚合的慢查询统计
+type SlowQuery struct {
+	Connection string    `json:"connection"` // 连接名称
+	SQL        string    `json:"sql"`        // 归一化的语句，参数替换为 ?
+	Example    string    `json:"example"`    // 最近一次的完整语句
+	Caller     string    `json:"caller"`     // 最近一次的调用位置
+	RequestID  string    `json:"requestId"`  // 最近一次的请求ID
+	Count      int64     `json:"count"`      // 次数
+	TotalMs    float64   `json:"totalMs"`    // 总耗时
+	AvgMs      float64   `json:"avgMs"`      // 平均耗时
+	MaxMs      float64   `json:"maxMs"`      // 最大耗时
+	Rows       int64     `json:"rows"`       // 最近一次影响的行数
+	LastAt     time.Time `json:"lastAt"`     // 最近一次时间
+}
+
+// SlowLog 进程内的慢查询聚合器，按连接和归一化语句分组
+type SlowLog struct {
+	mu      sync.Mutex
+	limit   int
+	entries map[string]*SlowQuery
+}
+
+// NewSlowLog 创建慢查询聚合器，limit 为最多保留的语句数
+func NewSlowLog(limit int) *SlowLog {
+	return &SlowLog{limit: limit, entries: make(map[string]*SlowQuery)}
+}
+
+// slowLog 全局慢查询聚合器
+var slowLog = NewSlowLog(100)
+
+// SlowQueries 获取全局慢查询聚合器
+func SlowQueries() *SlowLog {
+	return slowLog
+}
+
+// SetLimit 设置最多保留的语句数，小于等于0时不限制
+func (s *SlowLog) SetLimit(limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limit = limit
+	s.evict()
+}
+
+// Record 记录一次慢查询
+func (s *SlowLog) Record(sample SlowSample) {
+	normalized := NormalizeSQL(sample.SQL, sample.Driver)
+	key := sample.Connection + "\x00" + normalized
+	ms := float64(sample.Elapsed.Microseconds()) / 1000
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = &SlowQuery{Connection: sample.Connection, SQL: normalized}
+		s.entries[key] = entry
+	}
+	entry.Example = sample.SQL
+	entry.Caller = sample.Caller
+	entry.RequestID = sample.RequestID
+	entry.Rows = sample.Rows
+	entry.LastAt = time.Now()
+	entry.Count++
+	entry.TotalMs += ms
+	entry.AvgMs = entry.TotalMs / float64(entry.Count)
+	if ms > entry.MaxMs {
+		entry.MaxMs = ms
+	}
+	if !ok {
+		s.evict()
+	}
+}
+
+// evict 超出数量时淘汰总耗时最小的语句
+func (s *SlowLog) evict() {
+	for s.limit > 0 && len(s.entries) > s.limit {
+		var minKey string
+		var minTotal float64
+		for key, entry := range s.entries {
+			if minKey == "" || entry.TotalMs < minTotal {
+				minKey, minTotal = key, entry.TotalMs
+			}
+		}
+		delete(s.entries, minKey)
+	}
+}
+
+// Top 按指定字段降序返回前 n 条统计，by 可选 total、max、avg、count、last，默认 total
+func (s *SlowLog) Top(n int, by string) []SlowQuery {
+	s.mu.Lock()
+	list := make([]SlowQuery, 0, len(s.entries))
+	for _, entry := range s.entries {
+		list = append(list, *entry)
+	}
+	s.mu.Unlock()
+
+	less := func(a, b *SlowQuery) bool { return a.TotalMs > b.TotalMs }
+	switch by {
+	case "max":
+		less = func(a, b *SlowQuery) bool { return a.MaxMs > b.MaxMs }
+	case "avg":
+		less = func(a, b *SlowQuery) bool { return a.AvgMs > b.AvgMs }
+	case "count":
+		less = func(a, b *SlowQuery) bool { return a.Count > b.Count }
+	case "last":
+		less = func(a, b *SlowQuery) bool { return a.LastAt.After(b.LastAt) }
+	}
+	sort.Slice(list, func(i, j int) bool { return less(&list[i], &list[j]) })
+
+	if n > 0 && len(list) > n {
+		list = list[:n]
+	}
+	return list
+}
+
+// Reset 清空统计
+func (s *SlowLog) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = make(map[string]*SlowQuery)
+}
+
+var (
+	singleQuoted = regexp.MustCompile(`'(?:[^'\\]|\\.|'')*'`)
+	doubleQuoted = regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)
+	numberLit    = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	placeholders = regexp.MustCompile(`\?(?:\s*,\s*\?)+`)
+	whitespace   = regexp.MustCompile(`\s+`)
+)
+
+// NormalizeSQL 将语句中的字面量替换为 ?，用于聚合同一类语句
+//
+// PostgreSQL 的双引号表示标识符，不做替换。
+func NormalizeSQL(sql, driver string) string {
+	sql = singleQuoted.ReplaceAllString(sql, "?")
+	if driver != "postgres" {
+		sql = doubleQuoted.ReplaceAllString(sql, "?")
+	}
+	sql = numberLit.ReplaceAllString(sql, "?")
+	sql = placeholders.ReplaceAllString(sql, "?")
+	return strings.TrimSpace(whitespace.ReplaceAllString(sql, " "))
+}
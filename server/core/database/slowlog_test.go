@@ -0,0 +1,70 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/zhoudm1743/go-web/core/conf"
+)
+
+// TestNormalizeSQL 测试语句归一化
+func TestNormalizeSQL(t *testing.T) {
+	cases := []struct {
+		sql, driver, want string
+	}{
+		{"SELECT * FROM `admins` WHERE username = \"admin\" AND id = 12 LIMIT 1", "sqlite",
+			"SELECT * FROM `admins` WHERE username = ? AND id = ? LIMIT ?"},
+		{"SELECT * FROM \"roles\" WHERE code = 'it''s' AND id IN (1,2, 3)", "postgres",
+			"SELECT * FROM \"roles\" WHERE code = ? AND id IN (?)"},
+		{"UPDATE t2 SET  a = 1.5\n WHERE b = 'x'", "mysql", "UPDATE t2 SET a = ? WHERE b = ?"},
+	}
+	for _, c := range cases {
+		if got := NormalizeSQL(c.sql, c.driver); got != c.want {
+			t.Errorf("%s: 期望 %q, 实际 %q", c.sql, c.want, got)
+		}
+	}
+}
+
+// TestSlowQueryLog 测试慢查询聚合
+func TestSlowQueryLog(t *testing.T) {
+	db, err := Open("slow", conf.ConnectionConfig{
+		Driver:        "sqlite",
+		DSN:           filepath.Join(t.TempDir(), "slow.db"),
+		LogLevel:      "silent",
+		SlowThreshold: time.Nanosecond,
+	}, nil)
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	defer closeAll(db)
+	if err := db.AutoMigrate(&testItem{}); err != nil {
+		t.Fatalf("建表失败: %v", err)
+	}
+
+	SlowQueries().Reset()
+	defer SlowQueries().Reset()
+
+	var item testItem
+	db.Where("name = ?", "a").Find(&item)
+	db.Where("name = ?", "b").Find(&item)
+	db.Create(&testItem{Name: "c"})
+
+	top := SlowQueries().Top(10, "count")
+	if len(top) != 2 {
+		t.Fatalf("应聚合为2类语句, 实际: %+v", top)
+	}
+	if top[0].Count != 2 || top[0].Connection != "slow" || top[0].SQL != "SELECT * FROM `test_items` WHERE name = ?" {
+		t.Fatalf("聚合结果错误: %+v", top[0])
+	}
+	if top[0].Example != "SELECT * FROM `test_items` WHERE name = \"b\"" || top[0].Caller == "" {
+		t.Fatalf("应保留最近一次的语句和调用位置: %+v", top[0])
+	}
+
+	// 超出数量时淘汰总耗时最小的语句
+	SlowQueries().SetLimit(1)
+	defer SlowQueries().SetLimit(100)
+	if got := SlowQueries().Top(0, ""); len(got) != 1 {
+		t.Fatalf("应只保留1条, 实际: %d", len(got))
+	}
+}
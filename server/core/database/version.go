@@ -0,0 +1,168 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrVersionConflict 乐观锁冲突，记录已被其他请求修改
+var ErrVersionConflict = errors.New("数据已被修改")
+
+// ConflictError 乐观锁冲突错误，可通过 errors.Is(err, ErrVersionConflict) 判断
+type ConflictError struct {
+	Table   string // 表名
+	Version int64  // 更新时期望的版本号
+}
+
+// Error 实现error接口
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s 版本 %d 已被修改", e.Table, e.Version)
+}
+
+// Is 匹配 ErrVersionConflict
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrVersionConflict
+}
+
+// versionField 版本字段名，模型包含整数类型的该字段即启用乐观锁
+const versionField = "Version"
+
+// versionKey 语句中记录更新前版本号的键
+const versionKey = "version:expected"
+
+// VersionPlugin GORM 乐观锁插件
+//
+// 对包含 Version 字段的模型，创建时版本号为 1；通过模型更新（Save、Updates、Update）时
+// 追加 version = 当前版本 条件并将版本号加一，没有更新到任何记录时返回 ConflictError。
+// 模型的版本号为 0 时（例如 db.Model(&Admin{}).Where(...).Update）以及 UpdateColumn 不做检查。
+type VersionPlugin struct{}
+
+// Name 插件名称
+func (VersionPlugin) Name() string {
+	return "version"
+}
+
+// Initialize 注册回调
+func (p VersionPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Create().Before("gorm:create").Register("version:create", p.create); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("version:before_update", p.beforeUpdate); err != nil {
+		return err
+	}
+	return callbacks.Update().After("gorm:update").Register("version:after_update", p.afterUpdate)
+}
+
+// lookupVersion 获取模型的版本字段，非整数类型或不存在时返回nil
+func lookupVersion(db *gorm.DB) *schema.Field {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return nil
+	}
+	field := db.Statement.Schema.LookUpField(versionField)
+	if field == nil || field.DBName == "" {
+		return nil
+	}
+	switch reflect.Indirect(reflect.New(field.FieldType)).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return field
+	}
+	return nil
+}
+
+// versionOf 读取记录的版本号
+func versionOf(db *gorm.DB, field *schema.Field, rv reflect.Value) int64 {
+	value, zero := field.ValueOf(db.Statement.Context, rv)
+	if zero {
+		return 0
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint())
+	}
+	return 0
+}
+
+// create 创建时版本号为 1，已赋值的记录保持不变
+func (VersionPlugin) create(db *gorm.DB) {
+	field := lookupVersion(db)
+	if field == nil {
+		return
+	}
+
+	ctx, rv := db.Statement.Context, db.Statement.ReflectValue
+	set := func(v reflect.Value) {
+		if v.Kind() != reflect.Struct {
+			return
+		}
+		if _, zero := field.ValueOf(ctx, v); zero {
+			_ = db.AddError(field.Set(ctx, v, 1))
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			set(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		set(rv)
+	}
+}
+
+// beforeUpdate 追加版本条件并递增版本号
+func (VersionPlugin) beforeUpdate(db *gorm.DB) {
+	field := lookupVersion(db)
+	// UpdateColumn、UpdateColumns 跳过钩子，与 updated_at 一样不检查和更新版本号
+	if field == nil || db.Statement.SkipHooks || db.Statement.ReflectValue.Kind() != reflect.Struct {
+		return
+	}
+	// 显式更新版本字段时由调用方负责
+	if m, ok := db.Statement.Dest.(map[string]interface{}); ok {
+		if _, exists := m[field.DBName]; exists {
+			return
+		}
+		if _, exists := m[field.Name]; exists {
+			return
+		}
+	}
+
+	version := versionOf(db, field, db.Statement.ReflectValue)
+	if version == 0 {
+		return
+	}
+
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: version},
+	}})
+	db.Statement.SetColumn(field.DBName, version+1, true)
+	db.InstanceSet(versionKey, version)
+}
+
+// afterUpdate 没有更新到记录时返回冲突错误，成功时同步模型的版本号
+func (VersionPlugin) afterUpdate(db *gorm.DB) {
+	value, ok := db.InstanceGet(versionKey)
+	if !ok {
+		return
+	}
+	version := value.(int64)
+	field := db.Statement.Schema.LookUpField(versionField)
+
+	next := version + 1
+	if db.Error == nil && db.RowsAffected == 0 && !db.DryRun {
+		_ = db.AddError(&ConflictError{Table: db.Statement.Table, Version: version})
+		next = version
+	}
+	if db.Statement.ReflectValue.CanAddr() {
+		_ = field.Set(db.Statement.Context, db.Statement.ReflectValue, next)
+	}
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/zhoudm1743/go-web/core/conf"
+)
+
+// versionedItem 带版本号的测试模型
+type versionedItem struct {
+	ID      uint
+	Name    string
+	Version uint
+}
+
+// TestVersionConflict 测试乐观锁的版本递增与冲突检测
+func TestVersionConflict(t *testing.T) {
+	db, err := Open("test", conf.ConnectionConfig{
+		Driver:   "sqlite",
+		DSN:      filepath.Join(t.TempDir(), "version.db"),
+		LogLevel: "silent",
+	}, nil)
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	defer closeAll(db)
+	if err := db.AutoMigrate(&versionedItem{}); err != nil {
+		t.Fatalf("建表失败: %v", err)
+	}
+
+	item := &versionedItem{Name: "a"}
+	if err := db.Create(item).Error; err != nil {
+		t.Fatalf("创建失败: %v", err)
+	}
+	if item.Version != 1 {
+		t.Fatalf("创建后版本号应为1, 实际: %d", item.Version)
+	}
+
+	// 两个请求读取到同一版本
+	var first, second versionedItem
+	db.First(&first, item.ID)
+	db.First(&second, item.ID)
+
+	if err := db.Model(&first).Updates(map[string]interface{}{"name": "b"}).Error; err != nil {
+		t.Fatalf("更新失败: %v", err)
+	}
+	if first.Version != 2 {
+		t.Fatalf("更新后版本号应为2, 实际: %d", first.Version)
+	}
+
+	err = db.Model(&second).Updates(map[string]interface{}{"name": "c"}).Error
+	if !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("期望 ErrVersionConflict, 实际: %v", err)
+	}
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) || conflict.Version != 1 {
+		t.Fatalf("期望版本1冲突, 实际: %v", err)
+	}
+	if second.Version != 1 {
+		t.Fatalf("冲突时不应修改模型版本号, 实际: %d", second.Version)
+	}
+
+	// Save 同样检查版本
+	second.Name = "d"
+	if err := db.Save(&second).Error; !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("Save 期望 ErrVersionConflict, 实际: %v", err)
+	}
+	first.Name = "e"
+	if err := db.Save(&first).Error; err != nil {
+		t.Fatalf("Save 失败: %v", err)
+	}
+
+	var got versionedItem
+	db.First(&got, item.ID)
+	if got.Name != "e" || got.Version != 3 {
+		t.Fatalf("期望 e/3, 实际: %s/%d", got.Name, got.Version)
+	}
+
+	var count int64
+	db.Model(&versionedItem{}).Count(&count)
+	if count != 1 {
+		t.Fatalf("冲突的 Save 不应插入新记录, 实际: %d", count)
+	}
+}
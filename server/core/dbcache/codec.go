@@ -0,0 +1,122 @@
+package dbcache
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// record 缓存中的一条记录，键为模型字段名
+type record map[string]json.RawMessage
+
+// columns 模型中对应数据库列的字段，关联不缓存
+func columns(sch *schema.Schema) []*schema.Field {
+	fields := make([]*schema.Field, 0, len(sch.Fields))
+	for _, field := range sch.Fields {
+		if field.DBName != "" && field.Readable {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// encode 将查询结果按模型字段序列化为 JSON
+func encode(db *gorm.DB) (string, error) {
+	ctx, rv := db.Statement.Context, db.Statement.ReflectValue
+	fields := columns(db.Statement.Schema)
+
+	row := func(v reflect.Value) (map[string]interface{}, error) {
+		v = reflect.Indirect(v)
+		if v.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("不支持的记录类型: %s", v.Type())
+		}
+		m := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			m[field.Name], _ = field.ValueOf(ctx, v)
+		}
+		return m, nil
+	}
+
+	var rows []map[string]interface{}
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		rows = make([]map[string]interface{}, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			m, err := row(rv.Index(i))
+			if err != nil {
+				return "", err
+			}
+			rows = append(rows, m)
+		}
+	default:
+		m, err := row(rv)
+		if err != nil {
+			return "", err
+		}
+		rows = append(rows, m)
+	}
+
+	data, err := json.Marshal(rows)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// decode 将缓存的 JSON 写入查询目标，返回记录数
+func decode(db *gorm.DB, data string) (int64, error) {
+	var rows []record
+	if err := json.Unmarshal([]byte(data), &rows); err != nil {
+		return 0, err
+	}
+	ctx, rv := db.Statement.Context, db.Statement.ReflectValue
+	fields := columns(db.Statement.Schema)
+
+	fill := func(v reflect.Value, r record) error {
+		for _, field := range fields {
+			raw, ok := r[field.Name]
+			if !ok {
+				continue
+			}
+			value := reflect.New(field.FieldType)
+			if err := json.Unmarshal(raw, value.Interface()); err != nil {
+				return fmt.Errorf("字段 %s: %w", field.Name, err)
+			}
+			if err := field.Set(ctx, v, value.Elem().Interface()); err != nil {
+				return fmt.Errorf("字段 %s: %w", field.Name, err)
+			}
+		}
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Slice:
+		elem := rv.Type().Elem()
+		list := reflect.MakeSlice(rv.Type(), 0, len(rows))
+		for _, r := range rows {
+			item := reflect.New(modelType(elem))
+			if err := fill(item.Elem(), r); err != nil {
+				return 0, err
+			}
+			if elem.Kind() == reflect.Ptr {
+				list = reflect.Append(list, item)
+			} else {
+				list = reflect.Append(list, item.Elem())
+			}
+		}
+		rv.Set(list)
+	case reflect.Struct:
+		if len(rows) == 0 {
+			return 0, nil
+		}
+		if err := fill(rv, rows[0]); err != nil {
+			return 0, err
+		}
+	default:
+		return 0, fmt.Errorf("不支持的查询目标: %s", rv.Type())
+	}
+	return int64(len(rows)), nil
+}
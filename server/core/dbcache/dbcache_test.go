@@ -0,0 +1,245 @@
+package dbcache
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/zhoudm1743/go-web/core/cache"
+	"github.com/zhoudm1743/go-web/core/conf"
+	"github.com/zhoudm1743/go-web/core/database"
+	"github.com/zhoudm1743/go-web/core/log"
+	"gorm.io/gorm"
+)
+
+// cacheUser 测试模型，按主键自动缓存
+type cacheUser struct {
+	ID        uint
+	Name      string
+	Password  string `json:"-"`
+	ParentID  *uint
+	DeletedAt gorm.DeletedAt
+}
+
+// cachePost 测试模型，需显式开启缓存
+type cachePost struct {
+	ID    uint
+	Title string
+}
+
+// openTestDB 打开注册了缓存插件的临时数据库
+func openTestDB(t *testing.T) (*gorm.DB, cache.Cache) {
+	t.Helper()
+	db, err := database.Open("test", conf.ConnectionConfig{
+		Driver:   "sqlite",
+		DSN:      filepath.Join(t.TempDir(), "cache.db"),
+		LogLevel: "silent",
+	}, nil)
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	cfg := &conf.Config{Log: conf.LogConfig{Level: "error", OutputPath: "stdout"}}
+	logger, err := log.NewLogger(log.LoggerParams{Config: cfg})
+	if err != nil {
+		t.Fatalf("创建日志失败: %v", err)
+	}
+	c, _ := cache.NewMemoryCache(cfg, logger)
+
+	err = db.Use(&Plugin{Cache: c, Logger: logger, Config: conf.ModelCacheConfig{
+		TTL:    time.Minute,
+		Tables: map[string]time.Duration{"cache_users": 0},
+	}})
+	if err != nil {
+		t.Fatalf("注册插件失败: %v", err)
+	}
+	if err := db.AutoMigrate(&cacheUser{}, &cachePost{}); err != nil {
+		t.Fatalf("创建表失败: %v", err)
+	}
+	one := uint(1)
+	db.Create(&[]cacheUser{{Name: "alice", Password: "secret"}, {Name: "bob", ParentID: &one}})
+	db.Create(&[]cachePost{{Title: "a"}, {Title: "b"}})
+	return db, c
+}
+
+// TestPrimaryLookup 测试按表配置的主键查询缓存和写入失效
+func TestPrimaryLookup(t *testing.T) {
+	db, _ := openTestDB(t)
+
+	var user cacheUser
+	if err := db.First(&user, 2).Error; err != nil {
+		t.Fatalf("查询失败: %v", err)
+	}
+	// 原生 SQL 不触发失效，再次查询读取缓存
+	db.Exec("UPDATE cache_users SET name = 'raw' WHERE id = 2")
+	var cached cacheUser
+	if err := db.First(&cached, 2).Error; err != nil || cached.Name != "bob" || cached.ParentID == nil || *cached.ParentID != 1 {
+		t.Fatalf("期望命中缓存: %+v, %v", cached, err)
+	}
+	if err := db.Scopes(NoCache).First(&cached, 2).Error; err != nil || cached.Name != "raw" {
+		t.Fatalf("NoCache 应查询数据库: %+v", cached)
+	}
+
+	// json:"-" 的字段同样缓存
+	var alice cacheUser
+	db.First(&alice, 1)
+	alice = cacheUser{}
+	if db.First(&alice, 1); alice.Password != "secret" {
+		t.Fatalf("缓存丢失字段: %+v", alice)
+	}
+
+	// 通过模型更新后失效
+	db.Model(&cacheUser{ID: 2}).Update("name", "carol")
+	if db.First(&cached, 2); cached.Name != "carol" {
+		t.Fatalf("更新后缓存未失效: %+v", cached)
+	}
+
+	// 删除后失效，软删除的记录查询不到
+	db.Delete(&cacheUser{}, 2)
+	if err := db.First(&cached, 2).Error; err != gorm.ErrRecordNotFound {
+		t.Fatalf("删除后缓存未失效: %v", err)
+	}
+
+	// 非主键条件不自动缓存
+	var users []cacheUser
+	db.Where("name = ?", "alice").Find(&users)
+	db.Exec("UPDATE cache_users SET name = 'dave' WHERE id = 1")
+	if db.Where("name = ?", "alice").Find(&users); len(users) != 0 {
+		t.Fatalf("非主键查询不应缓存: %+v", users)
+	}
+}
+
+// TestCachedScope 测试显式开启缓存、标签失效和事务
+func TestCachedScope(t *testing.T) {
+	db, c := openTestDB(t)
+	ctx := context.Background()
+
+	var posts []cachePost
+	db.Order("id").Find(&posts)
+	db.Exec("UPDATE cache_posts SET title = 'x' WHERE id = 1")
+	if db.Order("id").Find(&posts); posts[0].Title != "x" {
+		t.Fatal("未开启缓存的表不应缓存")
+	}
+
+	list := func() []*cachePost {
+		var posts []*cachePost
+		if err := db.Scopes(Cached(0, "posts")).Order("id").Find(&posts).Error; err != nil {
+			t.Fatalf("查询失败: %v", err)
+		}
+		return posts
+	}
+	list()
+	db.Exec("UPDATE cache_posts SET title = 'y' WHERE id = 1")
+	if posts := list(); len(posts) != 2 || posts[0].Title != "x" {
+		t.Fatalf("期望命中缓存: %+v", posts)
+	}
+	if err := Invalidate(ctx, c, "posts"); err != nil {
+		t.Fatalf("失效失败: %v", err)
+	}
+	if posts := list(); posts[0].Title != "y" {
+		t.Fatalf("标签失效后应查询数据库: %+v", posts[0])
+	}
+
+	// 事务中不读取缓存
+	db.Exec("UPDATE cache_posts SET title = 'z' WHERE id = 1")
+	db.Transaction(func(tx *gorm.DB) error {
+		var post cachePost
+		if tx.Scopes(Cached(0, "posts")).Order("id").First(&post); post.Title != "z" {
+			t.Fatalf("事务中不应读取缓存: %+v", post)
+		}
+		return nil
+	})
+
+	// 创建后失效表标签
+	db.Create(&cachePost{Title: "c"})
+	if posts := list(); len(posts) != 3 {
+		t.Fatalf("创建后缓存未失效: %d", len(posts))
+	}
+}
+
+// TestInvalidateAfterCommit 测试事务中的写入在提交后失效，提交前读取的旧数据不会留在缓存中
+func TestInvalidateAfterCommit(t *testing.T) {
+	db, _ := openTestDB(t)
+	ctx := database.WithAfterCommit(context.Background())
+
+	read := func() string {
+		t.Helper()
+		var user cacheUser
+		if err := db.WithContext(ctx).First(&user, 1).Error; err != nil {
+			t.Fatalf("查询失败: %v", err)
+		}
+		return user.Name
+	}
+
+	tx := db.WithContext(ctx).Begin()
+	if err := tx.Model(&cacheUser{ID: 1}).Update("name", "erin").Error; err != nil {
+		tx.Rollback()
+		t.Fatalf("更新失败: %v", err)
+	}
+	// 提交前其他连接读到旧数据并写入缓存
+	if name := read(); name != "alice" {
+		t.Fatalf("提交前应读到旧数据: %s", name)
+	}
+	if err := tx.Commit().Error; err != nil {
+		t.Fatalf("提交失败: %v", err)
+	}
+	database.RunAfterCommit(ctx)
+	if name := read(); name != "erin" {
+		t.Fatalf("提交后缓存未失效: %s", name)
+	}
+
+	// 回滚时不执行失效
+	tx = db.WithContext(ctx).Begin()
+	tx.Model(&cacheUser{ID: 1}).Update("name", "frank")
+	tx.Rollback()
+	if name := read(); name != "erin" {
+		t.Fatalf("回滚后应读到原数据: %s", name)
+	}
+}
+
+// TestTagTTLOnlyExtends 测试过期时间较短的查询不会缩短标签集合的过期时间
+func TestTagTTLOnlyExtends(t *testing.T) {
+	db, c := openTestDB(t)
+	ctx := context.Background()
+
+	// 主键查询使用默认的 1 分钟，随后过期时间更短的查询写入同一标签
+	var user cacheUser
+	db.First(&user, 1)
+	var users []cacheUser
+	db.Scopes(Cached(50 * time.Millisecond)).Order("id").Find(&users)
+
+	if ttl, err := c.TTLCtx(ctx, tagPrefix+"cache_users"); err != nil || ttl < 50*time.Second {
+		t.Fatalf("标签集合的过期时间不应缩短: %v, %v", ttl, err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	db.Model(&cacheUser{ID: 1}).Update("name", "grace")
+	if db.First(&user, 1); user.Name != "grace" {
+		t.Fatalf("更新后主键查询的缓存未失效: %+v", user)
+	}
+}
+
+// TestCacheKeyPointerVars 测试指针参数按指向的值生成缓存键
+func TestCacheKeyPointerVars(t *testing.T) {
+	db, _ := openTestDB(t)
+
+	// 同一个指针指向不同的值时不能命中之前的结果
+	title := "a"
+	var post cachePost
+	if err := db.Scopes(Cached(0)).Where("title = ?", &title).First(&post).Error; err != nil || post.ID != 1 {
+		t.Fatalf("查询失败: %+v, %v", post, err)
+	}
+	title = "b"
+	post = cachePost{}
+	if err := db.Scopes(Cached(0)).Where("title = ?", &title).First(&post).Error; err != nil || post.ID != 2 {
+		t.Fatalf("指针指向的值改变后命中了旧结果: %+v, %v", post, err)
+	}
+
+	// 不同的指针指向相同的值时命中缓存
+	db.Exec("UPDATE cache_posts SET title = 'x' WHERE id = 2")
+	other := "b"
+	post = cachePost{}
+	if err := db.Scopes(Cached(0)).Where("title = ?", &other).First(&post).Error; err != nil || post.ID != 2 {
+		t.Fatalf("相同的值应命中缓存: %+v, %v", post, err)
+	}
+}
@@ -0,0 +1,353 @@
+package dbcache
+
+import (
+	"context"
+	"crypto/sha1"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/zhoudm1743/go-web/core/cache"
+	"github.com/zhoudm1743/go-web/core/conf"
+	"github.com/zhoudm1743/go-web/core/database"
+	"github.com/zhoudm1743/go-web/core/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+)
+
+// 缓存键前缀
+const (
+	keyPrefix = "db:"     // 查询结果，db:表名:SQL摘要
+	tagPrefix = "db:tag:" // 标签下的查询结果键集合
+)
+
+// optionsKey 语句设置中记录查询缓存选项的键
+const optionsKey = "dbcache:options"
+
+// options 查询缓存选项
+type options struct {
+	ttl      time.Duration
+	tags     []string
+	disabled bool
+}
+
+// Cached 开启查询缓存，ttl 为 0 时使用表或默认的过期时间
+//
+// 结果以表名为标签，表有写入时自动失效；tags 为额外的标签，可通过 Invalidate 手动失效。
+//
+//	db.Scopes(dbcache.Cached(time.Minute, "menu-tree")).Find(&menus)
+func Cached(ttl time.Duration, tags ...string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Set(optionsKey, &options{ttl: ttl, tags: tags})
+	}
+}
+
+// NoCache 跳过查询缓存，包括表配置的主键查询缓存
+func NoCache(db *gorm.DB) *gorm.DB {
+	return db.Set(optionsKey, &options{disabled: true})
+}
+
+// Plugin GORM 模型查询缓存插件（cache-aside）
+//
+// 查询通过 Cached 显式开启，或者表在配置的 Tables 中且为主键查询时自动缓存。
+// 缓存键为表名加 SQL 和参数的摘要，租户等条件自然隔离；记录按模型字段序列化为 JSON，
+// 不受 json 标签影响。同一表的创建、更新、删除会失效该表标签下的所有结果。
+// 事务中的查询不读写缓存，避免缓存未提交的数据，事务中的写入在请求事务提交后失效；
+// 原生 SQL 的写入不会触发失效。
+type Plugin struct {
+	Cache  cache.Cache
+	Config conf.ModelCacheConfig
+	Logger log.Logger
+}
+
+// Name 插件名称
+func (*Plugin) Name() string {
+	return "dbcache"
+}
+
+// Initialize 注册回调
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Query().Replace("gorm:query", p.query); err != nil {
+		return err
+	}
+	if err := cb.Create().After("gorm:create").Register("dbcache:create", p.invalidate); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("dbcache:update", p.invalidate); err != nil {
+		return err
+	}
+	return cb.Delete().After("gorm:delete").Register("dbcache:delete", p.invalidate)
+}
+
+// Setup 在所有连接上注册模型缓存插件，未启用时不注册
+func Setup(cfg conf.ModelCacheConfig, manager *database.Manager, c cache.Cache, l log.Logger) error {
+	if !cfg.Enabled || manager == nil || c == nil {
+		return nil
+	}
+	plugin := &Plugin{Cache: c, Config: cfg, Logger: l}
+	for _, name := range manager.Names() {
+		if err := manager.Get(name).Use(plugin); err != nil && !errors.Is(err, gorm.ErrRegistered) {
+			return fmt.Errorf("连接 %s 注册模型缓存插件失败: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Invalidate 失效标签下的所有查询结果，表名即为标签
+func Invalidate(ctx context.Context, c cache.Cache, tags ...string) error {
+	for _, tag := range tags {
+		keys, err := c.SMembersCtx(ctx, tagPrefix+tag)
+		if err != nil && !errors.Is(err, cache.ErrKeyNotFound) {
+			return fmt.Errorf("读取缓存标签 %s 失败: %w", tag, err)
+		}
+		if _, err := c.DelCtx(ctx, append(keys, tagPrefix+tag)...); err != nil {
+			return fmt.Errorf("删除缓存标签 %s 失败: %w", tag, err)
+		}
+	}
+	return nil
+}
+
+// query 替换 gorm:query，命中缓存时直接写入结果，否则查询数据库后写入缓存
+func (p *Plugin) query(db *gorm.DB) {
+	if db.Error != nil || db.DryRun {
+		callbacks.Query(db)
+		return
+	}
+	callbacks.BuildQuerySQL(db)
+	if db.Error != nil {
+		return
+	}
+
+	ttl, tags, ok := p.cacheable(db)
+	if !ok {
+		callbacks.Query(db)
+		return
+	}
+
+	ctx := db.Statement.Context
+	key := cacheKey(db)
+	if data, err := p.Cache.GetCtx(ctx, key); err == nil {
+		n, err := decode(db, data)
+		if err == nil {
+			db.RowsAffected = n
+			return
+		}
+		p.warn(key, "缓存结果解析失败", err)
+	}
+
+	callbacks.Query(db)
+	if db.Error != nil || db.RowsAffected == 0 {
+		return
+	}
+	data, err := encode(db)
+	if err != nil {
+		p.warn(key, "缓存结果序列化失败", err)
+		return
+	}
+	if err := p.Cache.SetCtx(ctx, key, data, ttl); err != nil {
+		p.warn(key, "写入查询缓存失败", err)
+		return
+	}
+	for _, tag := range tags {
+		if _, err := p.Cache.SAddCtx(ctx, tagPrefix+tag, key); err != nil {
+			p.warn(key, "写入缓存标签失败", err)
+			continue
+		}
+		p.extendTag(ctx, tagPrefix+tag, ttl)
+	}
+}
+
+// extendTag 将标签集合的过期时间延长到不短于 ttl
+//
+// 同一标签下结果的过期时间可能不同，集合只延长不缩短，保证集合不早于其中任何结果过期，
+// 否则写入时找不到仍然有效的结果。并发写入时可能短暂缩短，最多延迟到下一次写入结果时恢复。
+func (p *Plugin) extendTag(ctx context.Context, tagKey string, ttl time.Duration) {
+	remaining, err := p.Cache.TTLCtx(ctx, tagKey)
+	if err != nil {
+		p.warn(tagKey, "读取缓存标签过期时间失败", err)
+		return
+	}
+	// 新建的集合没有过期时间，返回负数
+	if remaining >= ttl {
+		return
+	}
+	if err := p.Cache.ExpireCtx(ctx, tagKey, ttl); err != nil {
+		p.warn(tagKey, "设置缓存标签过期时间失败", err)
+	}
+}
+
+// cacheable 判断查询是否使用缓存，返回过期时间和标签
+func (p *Plugin) cacheable(db *gorm.DB) (time.Duration, []string, bool) {
+	stmt := db.Statement
+	if stmt.Schema == nil || stmt.Table == "" || len(stmt.Joins) > 0 {
+		return 0, nil, false
+	}
+	// 事务中可能读到未提交的数据
+	if _, ok := stmt.ConnPool.(gorm.TxCommitter); ok {
+		return 0, nil, false
+	}
+	// 加锁查询必须读数据库
+	if _, ok := stmt.Clauses["FOR"]; ok {
+		return 0, nil, false
+	}
+	// 只缓存扫描到模型的查询
+	rv := stmt.ReflectValue
+	if rv.Kind() == reflect.Slice {
+		if modelType(rv.Type().Elem()) != stmt.Schema.ModelType {
+			return 0, nil, false
+		}
+	} else if rv.Kind() != reflect.Struct || rv.Type() != stmt.Schema.ModelType {
+		return 0, nil, false
+	}
+
+	tableTTL, listed := p.Config.Tables[stmt.Table]
+	var opts *options
+	if v, ok := db.Get(optionsKey); ok {
+		opts, _ = v.(*options)
+	}
+	switch {
+	case opts != nil && opts.disabled:
+		return 0, nil, false
+	case opts == nil && !(listed && primaryLookup(db)):
+		return 0, nil, false
+	}
+
+	ttl := p.Config.TTL
+	if tableTTL > 0 {
+		ttl = tableTTL
+	}
+	tags := []string{stmt.Table}
+	if opts != nil {
+		if opts.ttl > 0 {
+			ttl = opts.ttl
+		}
+		tags = append(tags, opts.tags...)
+	}
+	if ttl <= 0 {
+		return 0, nil, false
+	}
+	return ttl, tags, true
+}
+
+// modelType 去掉指针后的类型
+func modelType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// primaryLookup 判断是否为主键查询：条件包含主键，且只有字段的等值或 IN 条件
+func primaryLookup(db *gorm.DB) bool {
+	where, ok := db.Statement.Clauses["WHERE"].Expression.(clause.Where)
+	if !ok {
+		return false
+	}
+	primary := db.Statement.Schema.PrioritizedPrimaryField
+	if primary == nil {
+		return false
+	}
+
+	found := false
+	for _, expr := range where.Exprs {
+		var column clause.Column
+		switch e := expr.(type) {
+		case clause.Eq:
+			column, ok = e.Column.(clause.Column)
+		case clause.IN:
+			column, ok = e.Column.(clause.Column)
+		default:
+			return false
+		}
+		if !ok {
+			return false
+		}
+		if column.Name == clause.PrimaryKey || column.Name == primary.DBName {
+			found = true
+		}
+	}
+	return found
+}
+
+// cacheKey 查询结果的缓存键
+//
+// 参数按解引用后的值编码，指针参数按指向的值而不是地址区分
+func cacheKey(db *gorm.DB) string {
+	vars := make([]interface{}, len(db.Statement.Vars))
+	for i, v := range db.Statement.Vars {
+		vars[i] = keyValue(v)
+	}
+	encoded, err := json.Marshal(vars)
+	if err != nil {
+		encoded = []byte(fmt.Sprintf("%#v", vars))
+	}
+	sum := sha1.Sum([]byte(db.Statement.SQL.String() + "|" + string(encoded)))
+	return keyPrefix + db.Statement.Table + ":" + hex.EncodeToString(sum[:])
+}
+
+// keyValue 参数在缓存键中的值：解引用指针，driver.Valuer 使用写入数据库的值，切片逐个转换
+func keyValue(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil
+	}
+	if valuer, ok := rv.Interface().(driver.Valuer); ok {
+		if value, err := valuer.Value(); err == nil {
+			return value
+		}
+	}
+	if rv.Kind() == reflect.Array || (rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8) {
+		list := make([]interface{}, rv.Len())
+		for i := range list {
+			list[i] = keyValue(rv.Index(i).Interface())
+		}
+		return list
+	}
+	return rv.Interface()
+}
+
+// invalidate 写入成功后失效表标签下的查询结果
+//
+// 事务中的写入在提交后失效，避免提交前其他请求把旧数据重新写入缓存；
+// 上下文没有提交后回调队列时（如直接调用 db.Transaction）立即失效。
+func (p *Plugin) invalidate(db *gorm.DB) {
+	if db.Error != nil || db.DryRun || db.Statement.Table == "" {
+		return
+	}
+	ctx, table := db.Statement.Context, db.Statement.Table
+	run := func(ctx context.Context) {
+		if err := Invalidate(ctx, p.Cache, table); err != nil {
+			p.warn(tagPrefix+table, "失效查询缓存失败", err)
+		}
+	}
+	if _, ok := db.Statement.ConnPool.(gorm.TxCommitter); ok {
+		// 提交时请求可能已取消，失效不随请求中止
+		if database.AfterCommit(ctx, tagPrefix+table, func() { run(context.WithoutCancel(ctx)) }) {
+			return
+		}
+	}
+	run(ctx)
+}
+
+// warn 缓存异常不影响查询，只记录日志
+func (p *Plugin) warn(key, msg string, err error) {
+	if p.Logger == nil {
+		return
+	}
+	p.Logger.WithFields(map[string]interface{}{
+		"key":   key,
+		"error": err,
+	}).Warn(msg)
+}
@@ -0,0 +1,131 @@
+package dbschema
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zhoudm1743/go-web/core/conf"
+	"github.com/zhoudm1743/go-web/core/database"
+	"gorm.io/gorm"
+)
+
+// diffUser 测试模型
+type diffUser struct {
+	ID        uint
+	TenantID  uint   `gorm:"not null;uniqueIndex:idx_diff_users_tenant_name"`
+	Name      string `gorm:"size:50;not null;uniqueIndex:idx_diff_users_tenant_name"`
+	Email     string `gorm:"size:100;unique"`
+	Nickname  string `gorm:"size:50"`
+	Enabled   bool   `gorm:"default:true"`
+	DeletedAt gorm.DeletedAt
+}
+
+// diffPost 测试模型：数据库中不存在
+type diffPost struct {
+	ID    uint
+	Title string
+}
+
+// openTestDB 打开临时 SQLite 数据库
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.Open("test", conf.ConnectionConfig{
+		Driver:   "sqlite",
+		DSN:      filepath.Join(t.TempDir(), "schema.db"),
+		LogLevel: "silent",
+	}, nil)
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// TestDiffInSync 测试 AutoMigrate 建立的表与模型一致
+func TestDiffInSync(t *testing.T) {
+	db := openTestDB(t)
+	if err := db.AutoMigrate(&diffUser{}); err != nil {
+		t.Fatalf("建表失败: %v", err)
+	}
+
+	report, err := Diff(db, []interface{}{&diffUser{}}, Options{ExtraTables: true})
+	if err != nil {
+		t.Fatalf("比较失败: %v", err)
+	}
+	if report.HasChanges() {
+		t.Fatalf("期望没有差异, 实际:\n%s", report)
+	}
+
+	table, err := Inspect(db, "diff_users")
+	if err != nil || table == nil {
+		t.Fatalf("读取表结构失败: %v", err)
+	}
+	if c := table.Column("name"); c == nil || c.Nullable || c.PrimaryKey {
+		t.Fatalf("列信息不正确: %+v", c)
+	}
+	if idx := table.Index("idx_diff_users_tenant_name"); idx == nil || !idx.Unique || len(idx.Columns) != 2 {
+		t.Fatalf("索引信息不正确: %+v", idx)
+	}
+}
+
+// TestDiffDrift 测试发现表结构漂移并生成迁移
+func TestDiffDrift(t *testing.T) {
+	db := openTestDB(t)
+	err := db.Exec("CREATE TABLE `diff_users` (" +
+		"`id` integer PRIMARY KEY AUTOINCREMENT, `tenant_id` integer NOT NULL, `name` varchar(30) NOT NULL, " +
+		"`email` varchar(100), `enabled` numeric DEFAULT true, `deleted_at` datetime, `legacy` text)").Error
+	if err != nil {
+		t.Fatalf("建表失败: %v", err)
+	}
+	db.Exec("CREATE INDEX `idx_diff_users_legacy` ON `diff_users`(`legacy`)")
+	db.Exec("CREATE TABLE `orphans` (`id` integer)")
+
+	report, err := Diff(db, []interface{}{&diffUser{}, &diffPost{}}, Options{ExtraTables: true})
+	if err != nil {
+		t.Fatalf("比较失败: %v", err)
+	}
+
+	found := make(map[string]bool)
+	for _, change := range report.Changes {
+		found[string(change.Kind)+":"+change.Table+":"+change.Column+change.Index] = true
+	}
+	for _, key := range []string{
+		"missing_column:diff_users:nickname",
+		"extra_column:diff_users:legacy",
+		"type_mismatch:diff_users:name",
+		"missing_index:diff_users:idx_diff_users_tenant_name",
+		"missing_index:diff_users:emailuni_diff_users_email",
+		"extra_index:diff_users:idx_diff_users_legacy",
+		"missing_table:diff_posts:",
+		"extra_table:orphans:",
+	} {
+		if !found[key] {
+			t.Errorf("缺少差异 %s, 报告:\n%s", key, report)
+		}
+	}
+	if len(report.Changes) != 8 {
+		t.Errorf("期望8处差异, 实际 %d:\n%s", len(report.Changes), report)
+	}
+
+	source, err := report.Migration("20250701000000", "sync_schema")
+	if err != nil {
+		t.Fatalf("生成迁移失败: %v", err)
+	}
+	code := string(source)
+	for _, want := range []string{
+		`tx.Migrator().AddColumn(&dbschema.diffUser{}, "Nickname")`,
+		`tx.Migrator().AlterColumn(&dbschema.diffUser{}, "Name")`,
+		`tx.Migrator().CreateConstraint(&dbschema.diffUser{}, "uni_diff_users_email")`,
+		`tx.AutoMigrate(&dbschema.diffPost{})`,
+		`// if err := tx.Migrator().DropColumn(&dbschema.diffUser{}, "legacy"); err != nil {`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("迁移缺少语句 %s:\n%s", want, code)
+		}
+	}
+}
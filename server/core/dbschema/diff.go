@@ -0,0 +1,378 @@
+package dbschema
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// ChangeKind 差异类型
+type ChangeKind string
+
+// 差异类型
+const (
+	MissingTable     ChangeKind = "missing_table"     // 模型对应的表不存在
+	MissingColumn    ChangeKind = "missing_column"    // 模型字段对应的列不存在
+	ExtraColumn      ChangeKind = "extra_column"      // 数据库中有模型未定义的列
+	TypeMismatch     ChangeKind = "type_mismatch"     // 列类型或长度不一致
+	NullableMismatch ChangeKind = "nullable_mismatch" // 列是否可空不一致
+	MissingIndex     ChangeKind = "missing_index"     // 模型定义的索引或唯一约束不存在
+	ExtraIndex       ChangeKind = "extra_index"       // 数据库中有模型未定义的索引
+	ExtraTable       ChangeKind = "extra_table"       // 数据库中有没有对应模型的表
+)
+
+// Change 一项差异
+type Change struct {
+	Kind     ChangeKind     `json:"kind"`
+	Table    string         `json:"table"`
+	Column   string         `json:"column,omitempty"`
+	Index    string         `json:"index,omitempty"`
+	Expected string         `json:"expected,omitempty"` // 模型的定义
+	Actual   string         `json:"actual,omitempty"`   // 数据库中的定义
+	Model    *schema.Schema `json:"-"`                  // 对应的模型，ExtraTable 为nil
+	Field    *schema.Field  `json:"-"`                  // 对应的模型字段
+}
+
+// Destructive 修复该差异是否需要删除数据库对象
+func (c Change) Destructive() bool {
+	switch c.Kind {
+	case ExtraColumn, ExtraIndex, ExtraTable:
+		return true
+	}
+	return false
+}
+
+// Report 模型与数据库的差异报告
+type Report struct {
+	Driver  string   `json:"driver"`
+	Tables  []string `json:"tables"` // 检查的模型表
+	Changes []Change `json:"changes"`
+}
+
+// HasChanges 是否存在差异
+func (r *Report) HasChanges() bool {
+	return len(r.Changes) > 0
+}
+
+// Options 比较选项
+type Options struct {
+	// Tables 只检查指定的表，为空时检查所有模型
+	Tables []string
+	// ExtraTables 是否报告没有对应模型的表，指定 Tables 时不报告
+	ExtraTables bool
+	// IgnoreTables 不报告为多余的表，例如迁移记录表
+	IgnoreTables []string
+	// IgnoreIndexes 不报告为多余的索引，例如第三方库在模型之外创建的索引
+	IgnoreIndexes []string
+}
+
+// Diff 比较模型与数据库的表结构
+func Diff(db *gorm.DB, models []interface{}, opts Options) (*Report, error) {
+	report := &Report{Driver: dialect(db)}
+	known := make(map[string]bool)
+	for _, name := range opts.IgnoreTables {
+		known[name] = true
+	}
+
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, fmt.Errorf("解析模型 %T 失败: %w", model, err)
+		}
+		s := stmt.Schema
+		// 多个模型对应同一张表时只检查第一个
+		if contains(report.Tables, s.Table) || (len(opts.Tables) > 0 && !contains(opts.Tables, s.Table)) {
+			continue
+		}
+		known[s.Table] = true
+		report.Tables = append(report.Tables, s.Table)
+
+		table, err := Inspect(db, s.Table)
+		if err != nil {
+			return nil, fmt.Errorf("读取表 %s 失败: %w", s.Table, err)
+		}
+		if table == nil {
+			report.Changes = append(report.Changes, Change{Kind: MissingTable, Table: s.Table, Model: s})
+			continue
+		}
+		report.Changes = append(report.Changes, diffColumns(db, s, table)...)
+		report.Changes = append(report.Changes, diffIndexes(s, table, opts.IgnoreIndexes)...)
+	}
+
+	if opts.ExtraTables && len(opts.Tables) == 0 {
+		tables, err := Tables(db)
+		if err != nil {
+			return nil, err
+		}
+		for _, table := range tables {
+			if !known[table.Name] {
+				report.Changes = append(report.Changes, Change{Kind: ExtraTable, Table: table.Name})
+			}
+		}
+	}
+	return report, nil
+}
+
+// modelFields 模型中对应数据库列的字段，按定义顺序排列
+func modelFields(s *schema.Schema) []*schema.Field {
+	fields := make([]*schema.Field, 0, len(s.DBNames))
+	for _, field := range s.Fields {
+		if field.DBName != "" && !field.IgnoreMigration {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// diffColumns 比较列
+func diffColumns(db *gorm.DB, s *schema.Schema, table *Table) []Change {
+	var changes []Change
+	expected := make(map[string]bool)
+
+	for _, field := range modelFields(s) {
+		expected[strings.ToLower(field.DBName)] = true
+		modelType := db.Dialector.DataTypeOf(field)
+
+		column := table.Column(field.DBName)
+		if column == nil {
+			changes = append(changes, Change{
+				Kind: MissingColumn, Table: s.Table, Column: field.DBName,
+				Expected: modelType, Model: s, Field: field,
+			})
+			continue
+		}
+
+		if detail, ok := sameType(modelType, field, column); !ok {
+			changes = append(changes, Change{
+				Kind: TypeMismatch, Table: s.Table, Column: field.DBName,
+				Expected: modelType, Actual: detail, Model: s, Field: field,
+			})
+		}
+
+		// SQLite 的整数主键不会标记为 NOT NULL，主键不比较可空
+		if !field.PrimaryKey && field.NotNull == column.Nullable {
+			changes = append(changes, Change{
+				Kind: NullableMismatch, Table: s.Table, Column: field.DBName,
+				Expected: nullability(!field.NotNull), Actual: nullability(column.Nullable),
+				Model: s, Field: field,
+			})
+		}
+	}
+
+	for _, column := range table.Columns {
+		if !expected[strings.ToLower(column.Name)] {
+			changes = append(changes, Change{
+				Kind: ExtraColumn, Table: s.Table, Column: column.Name,
+				Actual: column.Type, Model: s,
+			})
+		}
+	}
+	return changes
+}
+
+// nullability 可空的描述
+func nullability(nullable bool) string {
+	if nullable {
+		return "NULL"
+	}
+	return "NOT NULL"
+}
+
+// diffIndexes 比较索引和唯一约束
+//
+// 普通索引按名称比较；字段上的 unique 约束各数据库的命名不同（SQLite 为 sqlite_autoindex_*），
+// 按列比较。
+func diffIndexes(s *schema.Schema, table *Table, ignore []string) []Change {
+	var changes []Change
+	expected := make(map[string]bool)
+	for _, name := range ignore {
+		expected[name] = true
+	}
+
+	for _, index := range s.ParseIndexes() {
+		expected[index.Name] = true
+		if table.Index(index.Name) != nil {
+			continue
+		}
+		columns := make([]string, 0, len(index.Fields))
+		for _, option := range index.Fields {
+			if option.Field != nil {
+				columns = append(columns, option.DBName)
+			}
+		}
+		changes = append(changes, Change{
+			Kind: MissingIndex, Table: s.Table, Index: index.Name,
+			Expected: describeIndex(columns, index.Class == "UNIQUE"), Model: s,
+		})
+	}
+
+	uniqueColumns := make(map[string]bool)
+	uniques := s.ParseUniqueConstraints()
+	names := make([]string, 0, len(uniques))
+	for name := range uniques {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		column := uniques[name].Field.DBName
+		uniqueColumns[column] = true
+		expected[name] = true
+		if findUnique(table, column) == nil {
+			changes = append(changes, Change{
+				Kind: MissingIndex, Table: s.Table, Index: name, Column: column,
+				Expected: describeIndex([]string{column}, true), Model: s, Field: uniques[name].Field,
+			})
+		}
+	}
+
+	for _, index := range table.Indexes {
+		if index.PrimaryKey || expected[index.Name] {
+			continue
+		}
+		if index.Unique && len(index.Columns) == 1 && uniqueColumns[index.Columns[0]] {
+			continue
+		}
+		// 模型未定义的唯一约束无法通过名称删除，只有普通索引报告为多余
+		if index.Constraint {
+			continue
+		}
+		changes = append(changes, Change{
+			Kind: ExtraIndex, Table: s.Table, Index: index.Name,
+			Actual: describeIndex(index.Columns, index.Unique), Model: s,
+		})
+	}
+	return changes
+}
+
+// findUnique 查找只包含指定列的唯一索引
+func findUnique(table *Table, column string) *Index {
+	for i := range table.Indexes {
+		index := &table.Indexes[i]
+		if index.Unique && len(index.Columns) == 1 && strings.EqualFold(index.Columns[0], column) {
+			return index
+		}
+	}
+	return nil
+}
+
+// describeIndex 索引的描述，例如 UNIQUE (tenant_id, code)
+func describeIndex(columns []string, unique bool) string {
+	desc := "(" + strings.Join(columns, ", ") + ")"
+	if unique {
+		return "UNIQUE " + desc
+	}
+	return desc
+}
+
+// 类型族，不同数据库对同一类型的命名不同，只比较类型族
+const (
+	familyInteger = "integer"
+	familyDecimal = "decimal"
+	familyBoolean = "boolean"
+	familyString  = "string"
+	familyTime    = "time"
+	familyBinary  = "binary"
+	familyJSON    = "json"
+)
+
+// typeParams 类型中的参数和修饰，例如 (50)、unsigned
+var typeParams = regexp.MustCompile(`\(.*?\)|\bunsigned\b|\bzerofill\b`)
+
+// typeFamily 获取类型所属的类型族，无法识别时返回空字符串
+func typeFamily(typ string) string {
+	t := strings.ToLower(strings.TrimSpace(typ))
+	if t == "tinyint(1)" || strings.HasPrefix(t, "bool") {
+		return familyBoolean
+	}
+	t = strings.TrimSpace(typeParams.ReplaceAllString(t, ""))
+	switch {
+	case strings.Contains(t, "int") || strings.Contains(t, "serial"):
+		return familyInteger
+	case strings.Contains(t, "json"):
+		return familyJSON
+	case strings.Contains(t, "char") || strings.Contains(t, "text") || strings.Contains(t, "clob") ||
+		t == "uuid" || t == "enum" || t == "set":
+		return familyString
+	case strings.Contains(t, "date") || strings.Contains(t, "time"):
+		return familyTime
+	case strings.Contains(t, "real") || strings.Contains(t, "floa") || strings.Contains(t, "doub") ||
+		strings.Contains(t, "numeric") || strings.Contains(t, "decimal"):
+		return familyDecimal
+	case strings.Contains(t, "blob") || strings.Contains(t, "binary") || t == "bytea":
+		return familyBinary
+	}
+	return ""
+}
+
+// compatible 类型族是否兼容
+//
+// MySQL 和 SQLite 没有独立的布尔类型，布尔列以整数或 numeric 存储。
+func compatible(expected, actual string) bool {
+	if expected == actual || expected == "" || actual == "" {
+		return true
+	}
+	pair := map[string]bool{expected: true, actual: true}
+	return pair[familyBoolean] && (pair[familyInteger] || pair[familyDecimal])
+}
+
+// sameType 比较模型字段与列的类型，不一致时返回列的描述
+func sameType(modelType string, field *schema.Field, column *Column) (string, bool) {
+	actual := column.Type
+	if column.Size > 0 && !strings.Contains(actual, "(") {
+		actual = fmt.Sprintf("%s(%d)", actual, column.Size)
+	}
+
+	expectedFamily := typeFamily(modelType)
+	if expectedFamily == "" && field.FieldType != nil {
+		expectedFamily = kindFamily(field.FieldType)
+	}
+	if !compatible(expectedFamily, typeFamily(column.Type)) {
+		return actual, false
+	}
+
+	// 字符串长度不一致
+	if expectedFamily == familyString {
+		size := field.Size
+		if size == 0 {
+			size = parseSize(modelType)
+		}
+		if size > 0 && column.Size > 0 && size != column.Size {
+			return actual, false
+		}
+	}
+	return actual, true
+}
+
+// kindFamily 根据 Go 类型推断类型族
+func kindFamily(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return familyBoolean
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return familyInteger
+	case reflect.Float32, reflect.Float64:
+		return familyDecimal
+	case reflect.String:
+		return familyString
+	}
+	return ""
+}
+
+// contains 判断字符串是否在列表中
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,416 @@
+package dbschema
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Table 数据库中的表
+type Table struct {
+	Name    string   `json:"tableName"`    // 表名
+	Comment string   `json:"tableComment"` // 表注释
+	Columns []Column `json:"columns,omitempty"`
+	Indexes []Index  `json:"indexes,omitempty"`
+}
+
+// Column 数据库中的列
+type Column struct {
+	Name       string `json:"columnName"`    // 列名
+	Type       string `json:"dataType"`      // 数据类型，例如 varchar、integer
+	Size       int    `json:"size"`          // 字符串长度，未限制时为0
+	Nullable   bool   `json:"nullable"`      // 是否可空
+	PrimaryKey bool   `json:"primaryKey"`    // 是否主键
+	Default    string `json:"default"`       // 默认值
+	Comment    string `json:"columnComment"` // 列注释，SQLite不支持
+}
+
+// Index 数据库中的索引
+type Index struct {
+	Name       string   `json:"name"`       // 索引名
+	Columns    []string `json:"columns"`    // 按顺序排列的列
+	Unique     bool     `json:"unique"`     // 是否唯一
+	PrimaryKey bool     `json:"primaryKey"` // 是否主键索引
+	Constraint bool     `json:"constraint"` // 是否由唯一约束或主键自动创建，例如 SQLite 的 sqlite_autoindex_*
+}
+
+// dialect 归一化驱动名称
+func dialect(db *gorm.DB) string {
+	switch name := db.Dialector.Name(); name {
+	case "sqlite3":
+		return "sqlite"
+	case "postgresql":
+		return "postgres"
+	default:
+		return name
+	}
+}
+
+// Tables 获取数据库中的表（不含列和索引），按表名排序
+func Tables(db *gorm.DB) ([]Table, error) {
+	var query string
+	switch dialect(db) {
+	case "sqlite":
+		query = `
+			SELECT
+				name AS table_name,
+				'' AS table_comment
+			FROM
+				sqlite_master
+			WHERE
+				type = 'table' AND
+				name NOT LIKE 'sqlite_%'
+			ORDER BY
+				name
+		`
+	case "postgres":
+		query = `
+			SELECT
+				table_name AS table_name,
+				COALESCE(obj_description((quote_ident(table_name)::text)::regclass, 'pg_class'), '') AS table_comment
+			FROM
+				information_schema.tables
+			WHERE
+				table_schema = current_schema() AND
+				table_type = 'BASE TABLE' AND
+				table_name NOT LIKE 'pg_%'
+			ORDER BY
+				table_name
+		`
+	default: // MySQL
+		query = `
+			SELECT
+				table_name AS table_name,
+				table_comment AS table_comment
+			FROM
+				information_schema.tables
+			WHERE
+				table_schema = DATABASE() AND
+				table_type = 'BASE TABLE'
+			ORDER BY
+				table_name
+		`
+	}
+
+	var rows []struct {
+		TableName    string
+		TableComment string
+	}
+	if err := db.Raw(query).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("获取表列表失败: %w", err)
+	}
+
+	tables := make([]Table, 0, len(rows))
+	for _, row := range rows {
+		tables = append(tables, Table{Name: row.TableName, Comment: row.TableComment})
+	}
+	return tables, nil
+}
+
+// sizePattern 从类型中解析长度，例如 varchar(50)
+var sizePattern = regexp.MustCompile(`\((\d+)\)`)
+
+// parseSize 解析类型中的长度
+func parseSize(typ string) int {
+	if m := sizePattern.FindStringSubmatch(typ); m != nil {
+		size, _ := strconv.Atoi(m[1])
+		return size
+	}
+	return 0
+}
+
+// Columns 获取表的列，按定义顺序排列
+func Columns(db *gorm.DB, table string) ([]Column, error) {
+	switch dialect(db) {
+	case "sqlite":
+		// 使用pragma_table_info获取表结构
+		var rows []struct {
+			Cid       int     `gorm:"column:cid"`
+			Name      string  `gorm:"column:name"`
+			Type      string  `gorm:"column:type"`
+			NotNull   int     `gorm:"column:notnull"`
+			DfltValue *string `gorm:"column:dflt_value"`
+			Pk        int     `gorm:"column:pk"`
+		}
+		query := `
+			SELECT
+				cid, name, type, "notnull", dflt_value, pk
+			FROM
+				pragma_table_info(?)
+			ORDER BY
+				cid
+		`
+		if err := db.Raw(query, table).Scan(&rows).Error; err != nil {
+			return nil, fmt.Errorf("获取表字段失败: %w", err)
+		}
+
+		columns := make([]Column, 0, len(rows))
+		for _, row := range rows {
+			column := Column{
+				Name:       row.Name,
+				Type:       row.Type,
+				Size:       parseSize(row.Type),
+				Nullable:   row.NotNull == 0,
+				PrimaryKey: row.Pk > 0,
+			}
+			if row.DfltValue != nil {
+				column.Default = *row.DfltValue
+			}
+			columns = append(columns, column)
+		}
+		return columns, nil
+
+	case "postgres":
+		var rows []struct {
+			ColumnName    string
+			DataType      string
+			Size          *int
+			IsNullable    string
+			ColumnDefault *string
+			ColumnComment *string
+			ColumnKey     string
+		}
+		query := `
+			SELECT
+				column_name AS column_name,
+				data_type AS data_type,
+				character_maximum_length AS size,
+				is_nullable AS is_nullable,
+				column_default AS column_default,
+				col_description(
+					(quote_ident(table_schema) || '.' || quote_ident(table_name))::regclass::oid,
+					ordinal_position
+				) AS column_comment,
+				CASE
+					WHEN EXISTS (
+						SELECT 1 FROM information_schema.table_constraints tc
+						JOIN information_schema.constraint_column_usage ccu
+						ON tc.constraint_name = ccu.constraint_name AND tc.table_schema = ccu.table_schema
+						WHERE tc.constraint_type = 'PRIMARY KEY'
+						AND tc.table_schema = c.table_schema
+						AND tc.table_name = c.table_name
+						AND ccu.column_name = c.column_name
+					) THEN 'PRI'
+					ELSE ''
+				END AS column_key
+			FROM
+				information_schema.columns c
+			WHERE
+				table_schema = current_schema()
+				AND table_name = ?
+			ORDER BY
+				ordinal_position
+		`
+		if err := db.Raw(query, table).Scan(&rows).Error; err != nil {
+			return nil, fmt.Errorf("获取表字段失败: %w", err)
+		}
+
+		columns := make([]Column, 0, len(rows))
+		for _, row := range rows {
+			column := Column{
+				Name:       row.ColumnName,
+				Type:       row.DataType,
+				Nullable:   row.IsNullable == "YES",
+				PrimaryKey: row.ColumnKey == "PRI",
+			}
+			if row.Size != nil {
+				column.Size = *row.Size
+			}
+			if row.ColumnDefault != nil {
+				column.Default = *row.ColumnDefault
+			}
+			if row.ColumnComment != nil {
+				column.Comment = *row.ColumnComment
+			}
+			columns = append(columns, column)
+		}
+		return columns, nil
+
+	default: // MySQL
+		var rows []struct {
+			ColumnName    string
+			DataType      string
+			Size          *int
+			IsNullable    string
+			ColumnDefault *string
+			ColumnComment string
+			ColumnKey     string
+		}
+		query := `
+			SELECT
+				column_name AS column_name,
+				data_type AS data_type,
+				character_maximum_length AS size,
+				is_nullable AS is_nullable,
+				column_default AS column_default,
+				column_comment AS column_comment,
+				column_key AS column_key
+			FROM
+				information_schema.columns
+			WHERE
+				table_schema = DATABASE()
+				AND table_name = ?
+			ORDER BY
+				ordinal_position
+		`
+		if err := db.Raw(query, table).Scan(&rows).Error; err != nil {
+			return nil, fmt.Errorf("获取表字段失败: %w", err)
+		}
+
+		columns := make([]Column, 0, len(rows))
+		for _, row := range rows {
+			column := Column{
+				Name:       row.ColumnName,
+				Type:       row.DataType,
+				Nullable:   row.IsNullable == "YES",
+				PrimaryKey: row.ColumnKey == "PRI",
+				Comment:    row.ColumnComment,
+			}
+			if row.Size != nil {
+				column.Size = *row.Size
+			}
+			if row.ColumnDefault != nil {
+				column.Default = *row.ColumnDefault
+			}
+			columns = append(columns, column)
+		}
+		return columns, nil
+	}
+}
+
+// Indexes 获取表的索引，按索引名排序
+func Indexes(db *gorm.DB, table string) ([]Index, error) {
+	// 每行一个索引列，按索引名和列序号排序
+	var rows []struct {
+		IndexName  string
+		ColumnName string
+		IsUnique   bool
+		IsPrimary  bool
+		Origin     string
+	}
+
+	var query string
+	switch dialect(db) {
+	case "sqlite":
+		// origin: c 为 CREATE INDEX 创建，u 为唯一约束，pk 为主键
+		query = `
+			SELECT
+				il.name AS index_name,
+				ii.name AS column_name,
+				il."unique" AS is_unique,
+				il.origin = 'pk' AS is_primary,
+				il.origin AS origin
+			FROM
+				pragma_index_list(?) il, pragma_index_info(il.name) ii
+			ORDER BY
+				il.name, ii.seqno
+		`
+	case "postgres":
+		query = `
+			SELECT
+				i.relname AS index_name,
+				a.attname AS column_name,
+				ix.indisunique AS is_unique,
+				ix.indisprimary AS is_primary,
+				CASE WHEN con.oid IS NULL THEN 'c' ELSE 'u' END AS origin
+			FROM
+				pg_class t
+				JOIN pg_namespace n ON n.oid = t.relnamespace
+				JOIN pg_index ix ON ix.indrelid = t.oid
+				JOIN pg_class i ON i.oid = ix.indexrelid
+				JOIN LATERAL unnest(ix.indkey) WITH ORDINALITY AS k(attnum, ord) ON true
+				JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+				LEFT JOIN pg_constraint con ON con.conindid = ix.indexrelid
+			WHERE
+				n.nspname = current_schema()
+				AND t.relname = ?
+			ORDER BY
+				i.relname, k.ord
+		`
+	default: // MySQL
+		query = `
+			SELECT
+				index_name AS index_name,
+				column_name AS column_name,
+				non_unique = 0 AS is_unique,
+				index_name = 'PRIMARY' AS is_primary,
+				'c' AS origin
+			FROM
+				information_schema.statistics
+			WHERE
+				table_schema = DATABASE()
+				AND table_name = ?
+			ORDER BY
+				index_name, seq_in_index
+		`
+	}
+
+	if err := db.Raw(query, table).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("获取表索引失败: %w", err)
+	}
+
+	byName := make(map[string]*Index)
+	var names []string
+	for _, row := range rows {
+		index, ok := byName[row.IndexName]
+		if !ok {
+			index = &Index{
+				Name:       row.IndexName,
+				Unique:     row.IsUnique,
+				PrimaryKey: row.IsPrimary,
+				Constraint: row.IsPrimary || row.Origin != "c",
+			}
+			byName[row.IndexName] = index
+			names = append(names, row.IndexName)
+		}
+		index.Columns = append(index.Columns, row.ColumnName)
+	}
+
+	sort.Strings(names)
+	indexes := make([]Index, 0, len(names))
+	for _, name := range names {
+		indexes = append(indexes, *byName[name])
+	}
+	return indexes, nil
+}
+
+// Inspect 获取表的完整结构，表不存在时返回nil
+func Inspect(db *gorm.DB, table string) (*Table, error) {
+	if !db.Migrator().HasTable(table) {
+		return nil, nil
+	}
+
+	columns, err := Columns(db, table)
+	if err != nil {
+		return nil, err
+	}
+	indexes, err := Indexes(db, table)
+	if err != nil {
+		return nil, err
+	}
+	return &Table{Name: table, Columns: columns, Indexes: indexes}, nil
+}
+
+// Column 按名称查找列，不区分大小写
+func (t *Table) Column(name string) *Column {
+	for i := range t.Columns {
+		if strings.EqualFold(t.Columns[i].Name, name) {
+			return &t.Columns[i]
+		}
+	}
+	return nil
+}
+
+// Index 按名称查找索引
+func (t *Table) Index(name string) *Index {
+	for i := range t.Indexes {
+		if t.Indexes[i].Name == name {
+			return &t.Indexes[i]
+		}
+	}
+	return nil
+}
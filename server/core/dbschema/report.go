@@ -0,0 +1,261 @@
+package dbschema
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/zhoudm1743/go-web/core/migration"
+)
+
+// changeLabels 差异的描述
+var changeLabels = map[ChangeKind]string{
+	MissingTable:     "+ 缺少表",
+	MissingColumn:    "+ 缺少列",
+	ExtraColumn:      "- 多余列",
+	TypeMismatch:     "~ 类型不一致",
+	NullableMismatch: "~ 可空不一致",
+	MissingIndex:     "+ 缺少索引",
+	ExtraIndex:       "- 多余索引",
+	ExtraTable:       "- 多余表",
+}
+
+// String 输出可读的差异报告，按表分组
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "数据库: %s，检查 %d 张表，", r.Driver, len(r.Tables))
+	if !r.HasChanges() {
+		b.WriteString("模型与数据库一致\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "发现 %d 处差异\n", len(r.Changes))
+
+	var tables []string
+	byTable := make(map[string][]Change)
+	for _, change := range r.Changes {
+		if _, ok := byTable[change.Table]; !ok {
+			tables = append(tables, change.Table)
+		}
+		byTable[change.Table] = append(byTable[change.Table], change)
+	}
+
+	for _, table := range tables {
+		fmt.Fprintf(&b, "\n%s\n", table)
+		for _, change := range byTable[table] {
+			fmt.Fprintf(&b, "  %s", changeLabels[change.Kind])
+			switch change.Kind {
+			case MissingColumn:
+				fmt.Fprintf(&b, " %s %s", change.Column, change.Expected)
+			case ExtraColumn:
+				fmt.Fprintf(&b, " %s %s", change.Column, change.Actual)
+			case TypeMismatch, NullableMismatch:
+				fmt.Fprintf(&b, " %s: 模型 %s，数据库 %s", change.Column, change.Expected, change.Actual)
+			case MissingIndex:
+				fmt.Fprintf(&b, " %s %s", change.Index, change.Expected)
+			case ExtraIndex:
+				fmt.Fprintf(&b, " %s %s", change.Index, change.Actual)
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// statement 迁移中的一条语句
+type statement struct {
+	Comment string // 说明
+	Code    string // 语句，不含错误处理
+	Manual  bool   // 需要人工确认，生成为注释
+}
+
+// migrationTemplate 迁移文件模板，与代码生成器生成的迁移一致
+const migrationTemplate = `package migrations
+
+import (
+{{- range .Imports}}
+	{{.}}
+{{- end}}
+)
+
+func init() {
+	migration.Register(&migration.Migration{
+		Version: "{{.Version}}",
+		Name:    "{{.Name}}",
+		Up: func(tx *gorm.DB) error {
+{{- template "statements" .Up}}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+{{- template "statements" .Down}}
+			return nil
+		},
+	})
+}
+{{define "statements"}}
+{{- range .}}
+			// {{.Comment}}
+{{- if .Manual}}
+			// if err := {{.Code}}; err != nil {
+			// 	return err
+			// }
+{{- else}}
+			if err := {{.Code}}; err != nil {
+				return err
+			}
+{{- end}}
+{{- end}}
+{{- end}}
+`
+
+// Migration 根据差异生成迁移文件
+//
+// 新增表、列、索引和修改列类型会生成可执行的语句，删除多余的表、列和索引可能丢失数据，
+// 生成为注释，确认后再取消注释。Down 只回滚新增的对象。
+func (r *Report) Migration(version, name string) ([]byte, error) {
+	imports := newImports()
+	imports.add("gorm.io/gorm")
+	imports.add(reflect.TypeOf(migration.Migration{}).PkgPath())
+
+	var up, down []statement
+	altered := make(map[string]bool)
+	for _, change := range r.Changes {
+		model := ""
+		if change.Model != nil {
+			model = "&" + imports.add(change.Model.ModelType.PkgPath()) + "." + change.Model.ModelType.Name() + "{}"
+		}
+
+		switch change.Kind {
+		case MissingTable:
+			up = append(up, statement{Comment: "创建表 " + change.Table, Code: fmt.Sprintf("tx.AutoMigrate(%s)", model)})
+			down = append(down, statement{Comment: "删除表 " + change.Table, Code: fmt.Sprintf("tx.Migrator().DropTable(%s)", model)})
+		case MissingColumn:
+			up = append(up, statement{
+				Comment: fmt.Sprintf("%s 增加列 %s", change.Table, change.Column),
+				Code:    fmt.Sprintf("tx.Migrator().AddColumn(%s, %q)", model, change.Field.Name),
+			})
+			down = append(down, statement{
+				Comment: fmt.Sprintf("%s 删除列 %s", change.Table, change.Column),
+				Code:    fmt.Sprintf("tx.Migrator().DropColumn(%s, %q)", model, change.Field.Name),
+			})
+		case TypeMismatch, NullableMismatch:
+			key := change.Table + "." + change.Column
+			if altered[key] {
+				continue
+			}
+			altered[key] = true
+			up = append(up, statement{
+				Comment: fmt.Sprintf("%s 修改列 %s 为 %s", change.Table, change.Column, change.Expected),
+				Code:    fmt.Sprintf("tx.Migrator().AlterColumn(%s, %q)", model, change.Field.Name),
+			})
+		case MissingIndex:
+			method := "Index"
+			if change.Field != nil {
+				method = "Constraint"
+			}
+			up = append(up, statement{
+				Comment: fmt.Sprintf("%s 创建索引 %s %s", change.Table, change.Index, change.Expected),
+				Code:    fmt.Sprintf("tx.Migrator().Create%s(%s, %q)", method, model, change.Index),
+			})
+			down = append(down, statement{
+				Comment: fmt.Sprintf("%s 删除索引 %s", change.Table, change.Index),
+				Code:    fmt.Sprintf("tx.Migrator().Drop%s(%s, %q)", method, model, change.Index),
+			})
+		case ExtraColumn:
+			up = append(up, statement{
+				Comment: fmt.Sprintf("%s 的列 %s 未在模型中定义，确认后删除", change.Table, change.Column),
+				Code:    fmt.Sprintf("tx.Migrator().DropColumn(%s, %q)", model, change.Column),
+				Manual:  true,
+			})
+		case ExtraIndex:
+			up = append(up, statement{
+				Comment: fmt.Sprintf("%s 的索引 %s 未在模型中定义，确认后删除", change.Table, change.Index),
+				Code:    fmt.Sprintf("tx.Migrator().DropIndex(%s, %q)", model, change.Index),
+				Manual:  true,
+			})
+		case ExtraTable:
+			up = append(up, statement{
+				Comment: fmt.Sprintf("表 %s 没有对应的模型，确认后删除", change.Table),
+				Code:    fmt.Sprintf("tx.Migrator().DropTable(%q)", change.Table),
+				Manual:  true,
+			})
+		}
+	}
+
+	// 回滚按相反顺序执行
+	for i, j := 0, len(down)-1; i < j; i, j = i+1, j-1 {
+		down[i], down[j] = down[j], down[i]
+	}
+
+	t, err := template.New("migration").Parse(migrationTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("解析迁移模板失败: %w", err)
+	}
+	var buf bytes.Buffer
+	err = t.Execute(&buf, map[string]interface{}{
+		"Imports": imports.list(),
+		"Version": version,
+		"Name":    name,
+		"Up":      up,
+		"Down":    down,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("渲染迁移模板失败: %w", err)
+	}
+
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("格式化迁移文件失败: %w", err)
+	}
+	return source, nil
+}
+
+// imports 生成代码的导入列表，包名冲突时使用别名
+type imports struct {
+	aliases map[string]string // 包路径 -> 包名
+	used    map[string]bool
+}
+
+// newImports 创建导入列表
+func newImports() *imports {
+	return &imports{aliases: make(map[string]string), used: make(map[string]bool)}
+}
+
+// add 添加导入并返回引用时使用的包名
+func (im *imports) add(pkgPath string) string {
+	if alias, ok := im.aliases[pkgPath]; ok {
+		return alias
+	}
+	base := path.Base(pkgPath)
+	alias := base
+	for i := 2; im.used[alias]; i++ {
+		alias = base + strconv.Itoa(i)
+	}
+	im.aliases[pkgPath] = alias
+	im.used[alias] = true
+	return alias
+}
+
+// list 按包路径排序的导入语句
+func (im *imports) list() []string {
+	paths := make([]string, 0, len(im.aliases))
+	for p := range im.aliases {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	lines := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if alias := im.aliases[p]; alias != path.Base(p) {
+			lines = append(lines, alias+" "+strconv.Quote(p))
+		} else {
+			lines = append(lines, strconv.Quote(p))
+		}
+	}
+	return lines
+}
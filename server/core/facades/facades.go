@@ -1,9 +1,12 @@
 package facades
 
 import (
+	"context"
+
 	"github.com/gin-gonic/gin"
 	"github.com/zhoudm1743/go-web/core/app"
 	"github.com/zhoudm1743/go-web/core/cache"
+	"github.com/zhoudm1743/go-web/core/database"
 	"github.com/zhoudm1743/go-web/core/log"
 	"gorm.io/gorm"
 )
@@ -13,6 +16,7 @@ var (
 	appManagerInstance AppManagerInterface
 	loggerInstance     log.Logger
 	dbInstance         *gorm.DB
+	dbManagerInstance  *database.Manager
 	routeInstance      RouterInterface
 	cacheInstance      cache.Cache
 )
@@ -57,8 +61,19 @@ func SetLog(l log.Logger) {
 }
 
 // SetDB 设置全局DB
-func SetDB(database *gorm.DB) {
-	dbInstance = database
+func SetDB(db *gorm.DB) {
+	dbInstance = db
+	if db != nil && dbManagerInstance == nil {
+		dbManagerInstance = database.NewManagerWith(db)
+	}
+}
+
+// SetDBManager 设置全局数据库连接管理器
+func SetDBManager(manager *database.Manager) {
+	dbManagerInstance = manager
+	if manager != nil {
+		dbInstance = manager.Default()
+	}
 }
 
 // SetRoute 设置全局Router
@@ -81,11 +96,50 @@ func Log() log.Logger {
 	return loggerInstance
 }
 
-// DB 获取全局DB
-func DB() *gorm.DB {
+// DB 获取全局DB，可指定连接名称，连接不存在时返回nil
+func DB(name ...string) *gorm.DB {
+	if len(name) == 0 || name[0] == "" || name[0] == database.DefaultConnection {
+		return dbInstance
+	}
+	if dbManagerInstance == nil {
+		return nil
+	}
+	return dbManagerInstance.Get(name[0])
+}
+
+// DBManager 获取全局数据库连接管理器
+func DBManager() *database.Manager {
+	return dbManagerInstance
+}
+
+// DBFor 获取模型所属连接，模型通过实现 Connection() string 指定连接
+func DBFor(model interface{}) *gorm.DB {
+	if c, ok := model.(database.Connector); ok {
+		return DB(c.Connection())
+	}
 	return dbInstance
 }
 
+// DBContext 获取上下文指定的连接并绑定上下文，未指定时使用默认连接
+//
+// 请求处于事务中时返回该事务，查询随请求取消而中止。
+func DBContext(ctx context.Context) *gorm.DB {
+	if dbManagerInstance == nil {
+		if tx := database.TxFromContext(ctx); tx != nil {
+			return tx.WithContext(ctx)
+		}
+		if dbInstance == nil {
+			return nil
+		}
+		return dbInstance.WithContext(ctx)
+	}
+	db, err := dbManagerInstance.Context(ctx)
+	if err != nil {
+		return nil
+	}
+	return db
+}
+
 // Route 获取全局Router
 func Route() RouterInterface {
 	return routeInstance
@@ -95,3 +149,8 @@ func Route() RouterInterface {
 func Cache() cache.Cache {
 	return cacheInstance
 }
+
+// DBFrom 获取当前请求的数据库连接，等同于 DBContext(c.Request.Context())
+func DBFrom(c *gin.Context) *gorm.DB {
+	return DBContext(c.Request.Context())
+}
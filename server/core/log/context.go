@@ -0,0 +1,20 @@
+package log
+
+import "context"
+
+// requestIDKey 上下文中保存请求ID的键
+type requestIDKey struct{}
+
+// WithRequestID 将请求ID保存到上下文
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext 获取上下文中的请求ID，不存在时返回空字符串
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
@@ -29,6 +29,8 @@ func GinLoggerMiddleware(logger Logger) gin.HandlerFunc {
 		statusCode := c.Writer.Status()
 		// 获取错误信息
 		errorMessage := c.Errors.ByType(gin.ErrorTypePrivate).String()
+		// 获取请求ID
+		requestID := RequestIDFromContext(c.Request.Context())
 
 		// 根据状态码选择日志级别
 		if statusCode >= 500 {
@@ -38,6 +40,7 @@ func GinLoggerMiddleware(logger Logger) gin.HandlerFunc {
 				"client_ip":   clientIP,
 				"method":      method,
 				"path":        path,
+				"request_id":  requestID,
 				"error":       errorMessage,
 			}).Error("[GIN]")
 		} else if statusCode >= 400 {
@@ -47,6 +50,7 @@ func GinLoggerMiddleware(logger Logger) gin.HandlerFunc {
 				"client_ip":   clientIP,
 				"method":      method,
 				"path":        path,
+				"request_id":  requestID,
 				"error":       errorMessage,
 			}).Warn("[GIN]")
 		} else {
@@ -57,6 +61,7 @@ func GinLoggerMiddleware(logger Logger) gin.HandlerFunc {
 					"client_ip":   clientIP,
 					"method":      method,
 					"path":        path,
+					"request_id":  requestID,
 				}).Debug("[GIN]") // 将正常请求的日志级别降低到DEBUG
 			}
 		}
@@ -25,10 +25,12 @@ func CasbinHandler() gin.HandlerFunc {
 		act := c.Request.Method
 		// 获取用户的角色
 		sub := strconv.Itoa(int(claims.RoleID))
+		// 获取当前租户对应的策略域
+		dom := utils.CasbinDomain(c.Request.Context())
 
 		// 获取casbin实例并检查权限
 		e := utils.Casbin()
-		success, err := e.Enforce(sub, obj, act)
+		success, err := e.Enforce(sub, dom, obj, act)
 
 		if err != nil {
 			response.Fail(c, response.SystemError)
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/zhoudm1743/go-web/core/database"
+	"github.com/zhoudm1743/go-web/core/facades"
+	"github.com/zhoudm1743/go-web/core/response"
+)
+
+// UseConnection 指定路由使用的数据库连接，处理函数通过 facades.DBContext 获取
+func UseConnection(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if manager := facades.DBManager(); manager == nil || !manager.Has(name) {
+			response.FailWithMsg(c, response.SystemError, "数据库连接 "+name+" 未配置")
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(database.WithConnection(c.Request.Context(), name))
+		c.Next()
+	}
+}
+
+// UsePrimary 指定路由的查询也走主库，用于写后立即读的场景
+func UsePrimary() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(database.WithPrimary(c.Request.Context()))
+		c.Next()
+	}
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/zhoudm1743/go-web/core/log"
+)
+
+// RequestIDHeader 请求ID请求头与响应头
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDKey gin上下文中保存请求ID的键
+const RequestIDKey = "requestId"
+
+// RequestID 为每个请求分配请求ID，优先使用客户端传入的 X-Request-ID
+//
+// 请求ID写入响应头并保存到请求上下文，SQL日志通过 log.RequestIDFromContext 关联到请求。
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if id == "" || len(id) > 64 {
+			id = uuid.NewString()
+		}
+
+		c.Set(RequestIDKey, id)
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(log.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
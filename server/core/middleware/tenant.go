@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhoudm1743/go-web/core/facades"
+	"github.com/zhoudm1743/go-web/core/response"
+	"github.com/zhoudm1743/go-web/core/tenant"
+	"github.com/zhoudm1743/go-web/core/utils"
+	"gorm.io/gorm"
+)
+
+// TenantKey gin上下文中存放当前租户的键
+const TenantKey = "tenant"
+
+// Tenant 租户识别中间件
+//
+// 按配置的顺序从请求头、子域名或令牌识别租户，都没有时使用超级租户，
+// 识别结果存入请求上下文，后续通过 facades.DBFrom(c) 的查询自动按租户过滤。
+// 令牌所属租户与请求的租户不一致时，只有超级租户的令牌允许切换。
+// 未启用多租户时所有请求都属于超级租户。
+func Tenant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		db := facades.DB()
+		if db == nil {
+			c.Next()
+			return
+		}
+		db = db.WithContext(c.Request.Context())
+
+		if !tenant.Enabled() {
+			// 租户表尚未迁移时不设置租户
+			if t, err := tenant.Lookup(db, tenant.SuperCode()); err == nil {
+				setTenant(c, t)
+			}
+			c.Next()
+			return
+		}
+
+		t, err := resolveTenant(c, db)
+		if err != nil {
+			switch {
+			case errors.Is(err, tenant.ErrNotFound):
+				response.Fail(c, response.TenantNotFound)
+			case errors.Is(err, tenant.ErrDisabled):
+				response.Fail(c, response.TenantDisabled)
+			case errors.Is(err, errTenantMismatch):
+				response.Fail(c, response.TenantMismatch)
+			default:
+				response.Fail(c, response.SystemError)
+			}
+			c.Abort()
+			return
+		}
+
+		setTenant(c, t)
+		c.Next()
+	}
+}
+
+// errTenantMismatch 令牌与请求的租户不一致
+var errTenantMismatch = errors.New("令牌与租户不一致")
+
+// resolveTenant 识别当前请求的租户
+func resolveTenant(c *gin.Context, db *gorm.DB) (*tenant.Tenant, error) {
+	// 令牌无效时交给后续的认证中间件处理
+	var claims *utils.CustomClaims
+	if c.GetHeader("Authorization") != "" {
+		claims, _ = utils.GetClaims(c)
+	}
+
+	var (
+		t    *tenant.Tenant
+		code string
+		err  error
+	)
+	for _, resolver := range tenant.Config().Resolvers {
+		switch resolver {
+		case tenant.ResolverHeader:
+			code = tenant.CodeFromHeader(c.Request)
+		case tenant.ResolverSubdomain:
+			code = tenant.CodeFromSubdomain(c.Request)
+		case tenant.ResolverJWT:
+			if claims != nil && claims.TenantID != 0 {
+				t, err = tenant.Find(db, claims.TenantID)
+			}
+		}
+		if code != "" || t != nil || err != nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if t == nil {
+		if code == "" {
+			code = tenant.SuperCode()
+		}
+		if t, err = tenant.Lookup(db, code); err != nil {
+			return nil, err
+		}
+	}
+	if !t.Active() {
+		return nil, tenant.ErrDisabled
+	}
+
+	// 令牌属于其他租户时，只有超级租户可以切换
+	if claims != nil && claims.TenantID != 0 && claims.TenantID != t.ID {
+		owner, err := tenant.Find(db, claims.TenantID)
+		if err != nil || !tenant.IsSuper(owner) {
+			return nil, errTenantMismatch
+		}
+	}
+	return t, nil
+}
+
+// setTenant 保存当前租户到gin上下文和请求上下文
+func setTenant(c *gin.Context, t *tenant.Tenant) {
+	c.Set(TenantKey, t)
+	c.Request = c.Request.WithContext(tenant.WithTenant(c.Request.Context(), t))
+}
@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"bytes"
+	"database/sql"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhoudm1743/go-web/core/database"
+	"github.com/zhoudm1743/go-web/core/facades"
+	"github.com/zhoudm1743/go-web/core/response"
+)
+
+// TxKey gin上下文中存放请求事务的键
+const TxKey = "db.tx"
+
+// Transaction 请求级事务中间件
+//
+// 对写请求（GET、HEAD、OPTIONS 除外）开启事务，处理函数通过 facades.DBFrom(c)
+// 获取事务。请求成功（2xx 且业务码为成功）时提交，失败或 panic 时回滚。
+// 响应在事务结束后才写出，提交失败时返回系统错误，因此不适用于流式响应。
+// 通过 database.AfterCommit 加入的回调在提交成功后、写出响应前执行。
+func Transaction(opts ...*sql.TxOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		runInTransaction(c, c.Next, opts...)
+	}
+}
+
+// Transactional 为单个处理函数开启事务，不区分请求方法
+func Transactional(handler gin.HandlerFunc, opts ...*sql.TxOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		runInTransaction(c, func() { handler(c) }, opts...)
+	}
+}
+
+// runInTransaction 在事务中执行处理函数
+func runInTransaction(c *gin.Context, next func(), opts ...*sql.TxOptions) {
+	// 已处于事务中则直接加入
+	if _, exists := c.Get(TxKey); exists {
+		next()
+		return
+	}
+
+	// 模型缓存等需要在提交后执行的操作加入该队列
+	c.Request = c.Request.WithContext(database.WithAfterCommit(c.Request.Context()))
+
+	db := facades.DBFrom(c)
+	if db == nil {
+		response.FailWithMsg(c, response.SystemError, "数据库未初始化")
+		c.Abort()
+		return
+	}
+
+	tx := db.Begin(opts...)
+	if tx.Error != nil {
+		response.FailWithMsg(c, response.SystemError, "开启事务失败")
+		c.Abort()
+		return
+	}
+
+	c.Set(TxKey, tx)
+	c.Request = c.Request.WithContext(database.WithTx(c.Request.Context(), tx))
+
+	// 缓冲响应，事务结束后再写出
+	writer := &bufferedWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+	c.Writer = writer
+
+	defer func() {
+		if r := recover(); r != nil {
+			c.Writer = writer.ResponseWriter
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	next()
+
+	success := response.IsSuccess(c)
+	c.Writer = writer.ResponseWriter
+
+	if !success {
+		tx.Rollback()
+		writer.flush()
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		if logger := facades.Log(); logger != nil {
+			logger.Errorf("提交事务失败: %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		}
+		response.FailWithMsg(c, response.SystemError, "提交事务失败")
+		return
+	}
+	database.RunAfterCommit(c.Request.Context())
+	writer.flush()
+}
+
+// bufferedWriter 缓冲响应的写入器
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status  int
+	written bool
+	body    bytes.Buffer
+}
+
+// WriteHeader 记录状态码
+func (w *bufferedWriter) WriteHeader(code int) {
+	if code > 0 && !w.written {
+		w.status = code
+	}
+}
+
+// WriteHeaderNow 标记响应头已写出
+func (w *bufferedWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+// Write 写入缓冲区
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	w.written = true
+	return w.body.Write(data)
+}
+
+// WriteString 写入缓冲区
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+// Status 缓冲的状态码
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+// Size 缓冲的响应体大小
+func (w *bufferedWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+// Written 是否已写入响应
+func (w *bufferedWriter) Written() bool {
+	return w.written
+}
+
+// Flush 事务结束前不写出
+func (w *bufferedWriter) Flush() {}
+
+// flush 将缓冲的响应写出
+func (w *bufferedWriter) flush() {
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.written {
+		w.ResponseWriter.WriteHeaderNow()
+		w.ResponseWriter.Write(w.body.Bytes())
+	}
+}
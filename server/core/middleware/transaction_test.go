@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/zhoudm1743/go-web/core/database"
+	"github.com/zhoudm1743/go-web/core/facades"
+	"github.com/zhoudm1743/go-web/core/response"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// txItem 测试模型
+type txItem struct {
+	ID   uint
+	Name string
+}
+
+// setupTxTest 创建测试数据库和路由
+func setupTxTest(t *testing.T) (*gorm.DB, *gin.Engine) {
+	gin.SetMode(gin.TestMode)
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "tx.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	if err := db.AutoMigrate(&txItem{}); err != nil {
+		t.Fatalf("创建表失败: %v", err)
+	}
+	facades.SetDB(db)
+
+	r := gin.New()
+	r.Use(gin.Recovery(), Transaction())
+	r.POST("/ok", func(c *gin.Context) {
+		facades.DBFrom(c).Create(&txItem{Name: "ok"})
+		response.Ok(c)
+	})
+	r.POST("/fail", func(c *gin.Context) {
+		facades.DBFrom(c).Create(&txItem{Name: "fail"})
+		response.Fail(c, response.Failed)
+	})
+	r.POST("/panic", func(c *gin.Context) {
+		facades.DBFrom(c).Create(&txItem{Name: "panic"})
+		panic("boom")
+	})
+	r.GET("/read", func(c *gin.Context) {
+		if _, exists := c.Get(TxKey); exists {
+			t.Error("读请求不应开启事务")
+		}
+		response.Ok(c)
+	})
+	return db, r
+}
+
+// TestTransactionMiddleware 测试成功提交、失败与panic回滚
+func TestTransactionMiddleware(t *testing.T) {
+	db, r := setupTxTest(t)
+
+	cases := []struct {
+		path   string
+		status int
+		saved  bool
+	}{
+		{"/ok", http.StatusOK, true},
+		{"/fail", http.StatusOK, false},
+		{"/panic", http.StatusInternalServerError, false},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tc.path, nil))
+		if w.Code != tc.status {
+			t.Fatalf("%s: 期望状态码 %d, 实际 %d", tc.path, tc.status, w.Code)
+		}
+
+		var count int64
+		db.Model(&txItem{}).Where("name = ?", strings.TrimPrefix(tc.path, "/")).Count(&count)
+		if (count == 1) != tc.saved {
+			t.Fatalf("%s: 期望保存=%v, 实际记录数 %d", tc.path, tc.saved, count)
+		}
+	}
+
+	// 成功请求的响应体应在提交后写出
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/ok", nil))
+	if !strings.Contains(w.Body.String(), `"code":0`) {
+		t.Fatalf("响应体不正确: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/read", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("读请求失败: %d", w.Code)
+	}
+}
+
+// TestTransactionAfterCommit 测试提交成功后执行回调，回滚时丢弃
+func TestTransactionAfterCommit(t *testing.T) {
+	_, r := setupTxTest(t)
+
+	var calls []string
+	queue := func(c *gin.Context, name string) {
+		if !database.AfterCommit(c.Request.Context(), name, func() { calls = append(calls, name) }) {
+			t.Error("事务中的请求应有提交后回调队列")
+		}
+	}
+	r.POST("/hook/ok", func(c *gin.Context) {
+		queue(c, "ok")
+		queue(c, "ok")
+		response.Ok(c)
+	})
+	r.POST("/hook/fail", func(c *gin.Context) {
+		queue(c, "fail")
+		response.Fail(c, response.Failed)
+	})
+
+	for _, path := range []string{"/hook/ok", "/hook/fail"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, path, nil))
+	}
+	if strings.Join(calls, ",") != "ok" {
+		t.Fatalf("应只在提交后执行一次回调, 实际: %v", calls)
+	}
+}
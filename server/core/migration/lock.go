@@ -0,0 +1,218 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrLocked 迁移锁被其他实例持有
+var ErrLocked = errors.New("迁移锁被其他实例持有")
+
+// locker 迁移锁
+type locker interface {
+	// Lock 获取锁，超时返回 ErrLocked
+	Lock(ctx context.Context) error
+	// Unlock 释放锁
+	Unlock(ctx context.Context) error
+}
+
+// newLocker 根据方言创建迁移锁
+func newLocker(db *gorm.DB, name string, timeout time.Duration) locker {
+	switch normalizeDialect(db.Dialector.Name()) {
+	case "mysql":
+		return &mysqlLocker{db: db, name: name, timeout: timeout}
+	case "postgres":
+		return &postgresLocker{db: db, key: int64(crc32.ChecksumIEEE([]byte(name))), timeout: timeout}
+	default:
+		return &tableLocker{db: db, name: name, timeout: timeout, ttl: 10 * time.Minute}
+	}
+}
+
+// mysqlLocker 基于 GET_LOCK 的会话级锁
+type mysqlLocker struct {
+	db      *gorm.DB
+	name    string
+	timeout time.Duration
+	conn    *sql.Conn
+}
+
+// Lock 获取锁
+func (l *mysqlLocker) Lock(ctx context.Context) error {
+	sqlDB, err := l.db.DB()
+	if err != nil {
+		return err
+	}
+	// 会话级锁必须在同一个连接上获取和释放
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("获取数据库连接失败: %w", err)
+	}
+
+	var result sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", l.name, int(l.timeout.Seconds())).Scan(&result); err != nil {
+		conn.Close()
+		return fmt.Errorf("获取迁移锁失败: %w", err)
+	}
+	if !result.Valid || result.Int64 != 1 {
+		conn.Close()
+		return ErrLocked
+	}
+
+	l.conn = conn
+	return nil
+}
+
+// Unlock 释放锁
+func (l *mysqlLocker) Unlock(ctx context.Context) error {
+	if l.conn == nil {
+		return nil
+	}
+	defer func() {
+		l.conn.Close()
+		l.conn = nil
+	}()
+	_, err := l.conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", l.name)
+	return err
+}
+
+// postgresLocker 基于 pg_try_advisory_lock 的会话级锁
+type postgresLocker struct {
+	db      *gorm.DB
+	key     int64
+	timeout time.Duration
+	conn    *sql.Conn
+}
+
+// Lock 获取锁
+func (l *postgresLocker) Lock(ctx context.Context) error {
+	sqlDB, err := l.db.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("获取数据库连接失败: %w", err)
+	}
+
+	err = poll(ctx, l.timeout, func() (bool, error) {
+		var ok bool
+		if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", l.key).Scan(&ok); err != nil {
+			return false, fmt.Errorf("获取迁移锁失败: %w", err)
+		}
+		return ok, nil
+	})
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	l.conn = conn
+	return nil
+}
+
+// Unlock 释放锁
+func (l *postgresLocker) Unlock(ctx context.Context) error {
+	if l.conn == nil {
+		return nil
+	}
+	defer func() {
+		l.conn.Close()
+		l.conn = nil
+	}()
+	_, err := l.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", l.key)
+	return err
+}
+
+// MigrationLock 锁表模型，用于不支持会话锁的数据库（如 sqlite）
+type MigrationLock struct {
+	Name     string    `gorm:"primaryKey;type:varchar(64)"` // 锁名称
+	Owner    string    `gorm:"type:varchar(100)"`           // 持有者
+	LockedAt time.Time // 加锁时间
+}
+
+// TableName 指定表名
+func (MigrationLock) TableName() string {
+	return "schema_migrations_lock"
+}
+
+// tableLocker 基于唯一主键的锁表实现
+type tableLocker struct {
+	db      *gorm.DB
+	name    string
+	owner   string
+	timeout time.Duration
+	ttl     time.Duration // 超过该时间的锁视为进程异常退出后遗留的死锁
+}
+
+// Lock 获取锁
+func (l *tableLocker) Lock(ctx context.Context) error {
+	db := l.db.WithContext(ctx)
+	if err := db.AutoMigrate(&MigrationLock{}); err != nil {
+		return fmt.Errorf("创建迁移锁表失败: %w", err)
+	}
+
+	hostname, _ := os.Hostname()
+	l.owner = fmt.Sprintf("%s:%d:%s", hostname, os.Getpid(), uuid.NewString()[:8])
+
+	return poll(ctx, l.timeout, func() (bool, error) {
+		// 清理过期的锁
+		if err := db.Where("name = ? AND locked_at < ?", l.name, time.Now().Add(-l.ttl)).
+			Delete(&MigrationLock{}).Error; err != nil {
+			return false, err
+		}
+
+		result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&MigrationLock{
+			Name:     l.name,
+			Owner:    l.owner,
+			LockedAt: time.Now(),
+		})
+		if result.Error != nil {
+			return false, fmt.Errorf("获取迁移锁失败: %w", result.Error)
+		}
+		return result.RowsAffected == 1, nil
+	})
+}
+
+// Unlock 释放锁
+func (l *tableLocker) Unlock(ctx context.Context) error {
+	return l.db.WithContext(ctx).
+		Where("name = ? AND owner = ?", l.name, l.owner).
+		Delete(&MigrationLock{}).Error
+}
+
+// poll 轮询尝试直到成功或超时
+func poll(ctx context.Context, timeout time.Duration, try func() (bool, error)) error {
+	deadline := time.Now().Add(timeout)
+	interval := 100 * time.Millisecond
+
+	for {
+		ok, err := try()
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return ErrLocked
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+		if interval < time.Second {
+			interval *= 2
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package migration
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration 迁移定义
+type Migration struct {
+	Version string                  // 版本号，使用时间戳格式，例如 20240101120000
+	Name    string                  // 迁移名称
+	Up      func(tx *gorm.DB) error // 升级操作
+	Down    func(tx *gorm.DB) error // 回滚操作
+
+	// Dialects 限定执行的数据库方言(sqlite/mysql/postgres)，为空表示全部
+	Dialects []string
+	// DisableTransaction 不在事务中执行（例如 postgres 的 CREATE INDEX CONCURRENTLY）
+	DisableTransaction bool
+}
+
+// ID 迁移唯一标识
+func (m *Migration) ID() string {
+	if m.Name == "" {
+		return m.Version
+	}
+	return m.Version + "_" + m.Name
+}
+
+// supports 判断迁移是否适用于指定方言
+func (m *Migration) supports(dialect string) bool {
+	if len(m.Dialects) == 0 {
+		return true
+	}
+	for _, d := range m.Dialects {
+		if normalizeDialect(d) == dialect {
+			return true
+		}
+	}
+	return false
+}
+
+// SchemaMigration 迁移记录模型
+type SchemaMigration struct {
+	Version   string    `gorm:"primaryKey;type:varchar(64);comment:版本号" json:"version"` // 版本号
+	Name      string    `gorm:"type:varchar(255);comment:迁移名称" json:"name"`             // 迁移名称
+	Batch     int       `gorm:"index;comment:批次" json:"batch"`                          // 批次
+	AppliedAt time.Time `gorm:"comment:执行时间" json:"appliedAt"`                          // 执行时间
+}
+
+// TableName 指定表名
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Status 迁移状态
+type Status struct {
+	Version   string     `json:"version"`   // 版本号
+	Name      string     `json:"name"`      // 迁移名称
+	Applied   bool       `json:"applied"`   // 是否已执行
+	Batch     int        `json:"batch"`     // 批次
+	AppliedAt *time.Time `json:"appliedAt"` // 执行时间
+	Missing   bool       `json:"missing"`   // 数据库中存在记录但代码中已找不到
+}
+
+// 全局迁移注册表
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]*Migration)
+)
+
+// Register 注册迁移，通常在迁移文件的 init 函数中调用
+func Register(migrations ...*Migration) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	for _, m := range migrations {
+		if m == nil {
+			continue
+		}
+		if m.Version == "" {
+			panic("迁移版本号不能为空")
+		}
+		if _, exists := registry[m.Version]; exists {
+			panic(fmt.Sprintf("迁移版本号重复: %s", m.Version))
+		}
+		registry[m.Version] = m
+	}
+}
+
+// Registered 获取所有已注册的迁移，按版本号排序
+func Registered() []*Migration {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	list := make([]*Migration, 0, len(registry))
+	for _, m := range registry {
+		list = append(list, m)
+	}
+	sortMigrations(list)
+	return list
+}
+
+// sortMigrations 按版本号升序排序
+func sortMigrations(list []*Migration) {
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Version < list[j].Version
+	})
+}
+
+// normalizeDialect 统一方言名称
+func normalizeDialect(name string) string {
+	switch name {
+	case "sqlite3", "sqlite", "memory":
+		return "sqlite"
+	case "postgresql", "postgres", "pgx":
+		return "postgres"
+	default:
+		return name
+	}
+}
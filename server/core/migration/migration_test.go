@@ -0,0 +1,292 @@
+package migration
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB 创建内存数据库
+func openTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	return db
+}
+
+// TestMigratorUpDown 测试迁移执行与回滚
+func TestMigratorUpDown(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	type Post struct {
+		ID    uint
+		Title string
+	}
+
+	migrations := []*Migration{
+		{
+			Version: "20250101000002",
+			Name:    "add_title_index",
+			Up: func(tx *gorm.DB) error {
+				return tx.Exec("CREATE INDEX idx_posts_title ON posts(title)").Error
+			},
+			Down: func(tx *gorm.DB) error {
+				return tx.Exec("DROP INDEX idx_posts_title").Error
+			},
+		},
+		{
+			Version: "20250101000001",
+			Name:    "create_posts",
+			Up: func(tx *gorm.DB) error {
+				return tx.AutoMigrate(&Post{})
+			},
+			Down: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable(&Post{})
+			},
+		},
+		{
+			Version:  "20250101000003",
+			Name:     "mysql_only",
+			Dialects: []string{"mysql"},
+			Up: func(tx *gorm.DB) error {
+				t.Error("不应在sqlite上执行mysql迁移")
+				return nil
+			},
+		},
+	}
+
+	m := NewMigrator(db, WithMigrations(migrations...), WithLogger(t.Logf))
+
+	applied, err := m.Up(ctx, 1)
+	if err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+	if len(applied) != 1 || applied[0].Version != "20250101000001" {
+		t.Fatalf("应按版本号顺序执行第一个迁移, 实际: %v", applied)
+	}
+
+	if _, err := m.Up(ctx, 0); err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+	if !db.Migrator().HasIndex(&Post{}, "idx_posts_title") {
+		t.Fatal("索引未创建")
+	}
+
+	status, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("获取迁移状态失败: %v", err)
+	}
+	if len(status) != 2 || !status[0].Applied || !status[1].Applied || status[1].Batch != 2 {
+		t.Fatalf("迁移状态不正确: %+v", status)
+	}
+
+	// 默认回滚最后一个批次
+	reverted, err := m.Down(ctx, 0)
+	if err != nil {
+		t.Fatalf("回滚失败: %v", err)
+	}
+	if len(reverted) != 1 || db.Migrator().HasIndex(&Post{}, "idx_posts_title") {
+		t.Fatal("应只回滚最后一个批次")
+	}
+
+	if err := m.Redo(ctx, 1); err != nil {
+		t.Fatalf("重做失败: %v", err)
+	}
+	if !db.Migrator().HasTable(&Post{}) {
+		t.Fatal("重做后表应存在")
+	}
+}
+
+// TestMigratorRedo 测试重做只重新执行回滚的迁移
+func TestMigratorRedo(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	var calls []string
+	migration := func(version string) *Migration {
+		return &Migration{
+			Version: version,
+			Name:    "redo",
+			Up: func(tx *gorm.DB) error {
+				calls = append(calls, "up "+version)
+				return nil
+			},
+			Down: func(tx *gorm.DB) error {
+				calls = append(calls, "down "+version)
+				return nil
+			},
+		}
+	}
+
+	if _, err := NewMigrator(db, WithMigrations(migration("1"), migration("3"), migration("4"))).Up(ctx, 0); err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+
+	// 合并分支后出现了版本号更小的未执行迁移，重做不应执行它
+	calls = nil
+	m := NewMigrator(db, WithMigrations(migration("1"), migration("2"), migration("3"), migration("4")))
+	if err := m.Redo(ctx, 2); err != nil {
+		t.Fatalf("重做失败: %v", err)
+	}
+	want := []string{"down 4", "down 3", "up 3", "up 4"}
+	if strings.Join(calls, ",") != strings.Join(want, ",") {
+		t.Fatalf("重做顺序不正确, 期望 %v, 实际 %v", want, calls)
+	}
+
+	status, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("获取迁移状态失败: %v", err)
+	}
+	if status[1].Applied {
+		t.Fatal("未执行的迁移不应被重做执行")
+	}
+	if !status[2].Applied || !status[3].Applied || status[2].Batch != 2 || status[3].Batch != 2 {
+		t.Fatalf("重做的迁移应记录到新批次: %+v", status)
+	}
+}
+
+// TestMigratorFailureRollsBack 测试迁移失败时不记录版本
+func TestMigratorFailureRollsBack(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	m := NewMigrator(db, WithMigrations(&Migration{
+		Version: "20250101000001",
+		Name:    "broken",
+		Up: func(tx *gorm.DB) error {
+			return tx.Exec("CREATE TABLE broken (").Error
+		},
+	}), WithLogger(t.Logf))
+
+	if _, err := m.Up(ctx, 0); err == nil {
+		t.Fatal("期望迁移失败")
+	}
+
+	var count int64
+	db.Model(&SchemaMigration{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("失败的迁移不应被记录, 实际记录数: %d", count)
+	}
+}
+
+// TestLoadFS 测试SQL迁移文件加载
+func TestLoadFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sql/20250101000001_create_tags.sql": {Data: []byte(`
+-- 标签表
+-- +migrate Up
+CREATE TABLE tags (id INTEGER PRIMARY KEY, name TEXT);
+INSERT INTO tags (name) VALUES ('a;b');
+
+-- +migrate Down
+DROP TABLE tags;
+`)},
+		"sql/20250101000002_seed.postgres.sql": {Data: []byte(`
+-- +migrate Up
+-- +migrate StatementBegin
+CREATE FUNCTION noop() RETURNS void AS $$ BEGIN; END; $$ LANGUAGE plpgsql;
+-- +migrate StatementEnd
+`)},
+		"sql/readme.md": {Data: []byte("ignored")},
+	}
+
+	migrations, err := LoadFS(fsys, "sql")
+	if err != nil {
+		t.Fatalf("加载迁移失败: %v", err)
+	}
+	if len(migrations) != 2 {
+		t.Fatalf("期望2个迁移, 实际: %d", len(migrations))
+	}
+	if migrations[1].supports("sqlite") {
+		t.Fatal("仅postgres的迁移不应在sqlite上执行")
+	}
+
+	db := openTestDB(t)
+	m := NewMigrator(db, WithMigrations(migrations...), WithLogger(t.Logf))
+	if _, err := m.Up(context.Background(), 0); err != nil {
+		t.Fatalf("执行SQL迁移失败: %v", err)
+	}
+
+	var name string
+	db.Raw("SELECT name FROM tags").Scan(&name)
+	if name != "a;b" {
+		t.Fatalf("SQL语句拆分错误, 实际: %q", name)
+	}
+
+	if _, err := m.Down(context.Background(), 0); err != nil {
+		t.Fatalf("回滚SQL迁移失败: %v", err)
+	}
+	if db.Migrator().HasTable("tags") {
+		t.Fatal("回滚后表应被删除")
+	}
+}
+
+// TestMigratorTableUnderLock 测试迁移记录表在获取迁移锁后才创建，查看状态时不建表
+func TestMigratorTableUnderLock(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	m := NewMigrator(db, WithMigrations(&Migration{
+		Version: "20250101000001",
+		Name:    "noop",
+		Up:      func(tx *gorm.DB) error { return nil },
+	}), WithLockTimeout(0), WithLogger(t.Logf))
+
+	status, err := m.Status(ctx)
+	if err != nil || len(status) != 1 || status[0].Applied {
+		t.Fatalf("迁移记录表不存在时应全部待执行: %+v, %v", status, err)
+	}
+	if db.Migrator().HasTable(&SchemaMigration{}) {
+		t.Fatal("查看状态不应创建迁移记录表")
+	}
+
+	// 其他实例持有迁移锁时不建表
+	holder := &tableLocker{db: db, name: defaultLockName, ttl: 10 * time.Minute}
+	if err := holder.Lock(ctx); err != nil {
+		t.Fatalf("获取锁失败: %v", err)
+	}
+	if _, err := m.Up(ctx, 0); err != ErrLocked {
+		t.Fatalf("期望 ErrLocked, 实际: %v", err)
+	}
+	if db.Migrator().HasTable(&SchemaMigration{}) {
+		t.Fatal("未获取迁移锁时不应创建迁移记录表")
+	}
+
+	holder.Unlock(ctx)
+	if applied, err := m.Up(ctx, 0); err != nil || len(applied) != 1 {
+		t.Fatalf("执行迁移失败: %v, %v", applied, err)
+	}
+}
+
+// TestTableLocker 测试锁表互斥
+func TestTableLocker(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	first := &tableLocker{db: db, name: "test", timeout: 0, ttl: 10 * time.Minute}
+	second := &tableLocker{db: db, name: "test", timeout: 0, ttl: 10 * time.Minute}
+
+	if err := first.Lock(ctx); err != nil {
+		t.Fatalf("获取锁失败: %v", err)
+	}
+	if err := second.Lock(ctx); err != ErrLocked {
+		t.Fatalf("期望 ErrLocked, 实际: %v", err)
+	}
+	if err := first.Unlock(ctx); err != nil {
+		t.Fatalf("释放锁失败: %v", err)
+	}
+	if err := second.Lock(ctx); err != nil {
+		t.Fatalf("释放后应能获取锁: %v", err)
+	}
+}
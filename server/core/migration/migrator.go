@@ -0,0 +1,347 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 默认配置
+const (
+	defaultLockName    = "go-web:migrate"
+	defaultLockTimeout = 30 * time.Second
+)
+
+// Migrator 迁移执行器
+type Migrator struct {
+	db          *gorm.DB
+	migrations  []*Migration
+	lockName    string
+	lockTimeout time.Duration
+	logf        func(format string, args ...interface{})
+}
+
+// Option 迁移执行器选项
+type Option func(*Migrator)
+
+// WithMigrations 指定迁移列表，默认使用全局注册表
+func WithMigrations(migrations ...*Migration) Option {
+	return func(m *Migrator) {
+		m.migrations = migrations
+	}
+}
+
+// WithLockTimeout 设置获取迁移锁的超时时间
+func WithLockTimeout(timeout time.Duration) Option {
+	return func(m *Migrator) {
+		m.lockTimeout = timeout
+	}
+}
+
+// WithLogger 设置日志输出函数
+func WithLogger(logf func(format string, args ...interface{})) Option {
+	return func(m *Migrator) {
+		m.logf = logf
+	}
+}
+
+// NewMigrator 创建迁移执行器
+func NewMigrator(db *gorm.DB, opts ...Option) *Migrator {
+	m := &Migrator{
+		db:          db,
+		lockName:    defaultLockName,
+		lockTimeout: defaultLockTimeout,
+		logf:        func(format string, args ...interface{}) { fmt.Printf(format+"\n", args...) },
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.migrations == nil {
+		m.migrations = Registered()
+	} else {
+		sortMigrations(m.migrations)
+	}
+	return m
+}
+
+// Dialect 当前数据库方言
+func (m *Migrator) Dialect() string {
+	return normalizeDialect(m.db.Dialector.Name())
+}
+
+// Up 执行未完成的迁移，steps 为 0 表示执行全部
+func (m *Migrator) Up(ctx context.Context, steps int) ([]*Migration, error) {
+	var applied []*Migration
+	err := m.withLock(ctx, func(db *gorm.DB) error {
+		records, err := m.records(db)
+		if err != nil {
+			return err
+		}
+
+		batch := nextBatch(records)
+		for _, mig := range m.migrations {
+			if steps > 0 && len(applied) >= steps {
+				break
+			}
+			if _, done := records[mig.Version]; done {
+				continue
+			}
+			if !mig.supports(m.Dialect()) {
+				continue
+			}
+			if err := m.apply(db, mig, batch); err != nil {
+				return err
+			}
+			applied = append(applied, mig)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down 回滚迁移，steps 为 0 表示回滚最后一个批次
+func (m *Migrator) Down(ctx context.Context, steps int) ([]*Migration, error) {
+	var reverted []*Migration
+	err := m.withLock(ctx, func(db *gorm.DB) error {
+		var err error
+		reverted, err = m.down(db, steps)
+		return err
+	})
+	return reverted, err
+}
+
+// Redo 回滚并重新执行迁移，steps 为 0 表示最后一个批次
+//
+// 回滚和重新执行在同一个迁移锁内完成，只重新执行本次回滚的迁移，按版本号升序执行
+func (m *Migrator) Redo(ctx context.Context, steps int) error {
+	return m.withLock(ctx, func(db *gorm.DB) error {
+		reverted, err := m.down(db, steps)
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			return nil
+		}
+
+		records, err := m.records(db)
+		if err != nil {
+			return err
+		}
+		batch := nextBatch(records)
+
+		sortMigrations(reverted)
+		for _, mig := range reverted {
+			if err := m.apply(db, mig, batch); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Status 获取迁移状态
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	db := m.db.WithContext(ctx)
+	records, err := m.records(db)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		if !mig.supports(m.Dialect()) {
+			continue
+		}
+		status := Status{Version: mig.Version, Name: mig.Name}
+		if r, ok := records[mig.Version]; ok {
+			appliedAt := r.AppliedAt
+			status.Applied = true
+			status.Batch = r.Batch
+			status.AppliedAt = &appliedAt
+			delete(records, mig.Version)
+		}
+		list = append(list, status)
+	}
+
+	// 已执行但代码中已不存在的迁移
+	for _, r := range records {
+		appliedAt := r.AppliedAt
+		list = append(list, Status{
+			Version:   r.Version,
+			Name:      r.Name,
+			Applied:   true,
+			Batch:     r.Batch,
+			AppliedAt: &appliedAt,
+			Missing:   true,
+		})
+	}
+	return list, nil
+}
+
+// Forget 删除迁移记录但不执行回滚，用于表已被手动删除的场景
+func (m *Migrator) Forget(ctx context.Context, version string) error {
+	return m.withLock(ctx, func(db *gorm.DB) error {
+		return db.Where("version = ?", version).Delete(&SchemaMigration{}).Error
+	})
+}
+
+// withLock 在迁移锁保护下执行，迁移记录表在加锁后创建，避免多个实例同时建表
+func (m *Migrator) withLock(ctx context.Context, fn func(db *gorm.DB) error) error {
+	db := m.db.WithContext(ctx)
+	lock := newLocker(m.db, m.lockName, m.lockTimeout)
+	if err := lock.Lock(ctx); err != nil {
+		return err
+	}
+	defer func() {
+		// 使用独立上下文，确保请求取消后仍能释放锁
+		if err := lock.Unlock(context.Background()); err != nil {
+			m.logf("释放迁移锁失败: %v", err)
+		}
+	}()
+
+	if err := m.ensureTable(db); err != nil {
+		return err
+	}
+	return fn(db)
+}
+
+// run 执行单个迁移并更新记录
+func (m *Migrator) run(db *gorm.DB, mig *Migration, action, record func(tx *gorm.DB) error) error {
+	if mig.DisableTransaction {
+		if err := action(db); err != nil {
+			return err
+		}
+		return record(db)
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := action(tx); err != nil {
+			return err
+		}
+		return record(tx)
+	})
+}
+
+// down 按版本号倒序回滚迁移，需在迁移锁内调用
+func (m *Migrator) down(db *gorm.DB, steps int) ([]*Migration, error) {
+	targets, err := m.rollbackTargets(db, steps)
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []*Migration
+	for _, record := range targets {
+		mig := m.find(record.Version)
+		if mig == nil {
+			return reverted, fmt.Errorf("找不到迁移 %s_%s，无法回滚", record.Version, record.Name)
+		}
+		if mig.Down == nil {
+			return reverted, fmt.Errorf("迁移 %s 不支持回滚", mig.ID())
+		}
+
+		start := time.Now()
+		err := m.run(db, mig, mig.Down, func(tx *gorm.DB) error {
+			return tx.Where("version = ?", mig.Version).Delete(&SchemaMigration{}).Error
+		})
+		if err != nil {
+			return reverted, fmt.Errorf("回滚迁移 %s 失败: %w", mig.ID(), err)
+		}
+		m.logf("已回滚: %s (%s)", mig.ID(), time.Since(start).Round(time.Millisecond))
+		reverted = append(reverted, mig)
+	}
+	return reverted, nil
+}
+
+// apply 执行单个迁移并记录到指定批次，需在迁移锁内调用
+func (m *Migrator) apply(db *gorm.DB, mig *Migration, batch int) error {
+	if mig.Up == nil {
+		return fmt.Errorf("迁移 %s 缺少 Up 操作", mig.ID())
+	}
+
+	start := time.Now()
+	err := m.run(db, mig, mig.Up, func(tx *gorm.DB) error {
+		return tx.Create(&SchemaMigration{
+			Version:   mig.Version,
+			Name:      mig.Name,
+			Batch:     batch,
+			AppliedAt: time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("执行迁移 %s 失败: %w", mig.ID(), err)
+	}
+	m.logf("已迁移: %s (%s)", mig.ID(), time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// nextBatch 下一次执行的批次号
+func nextBatch(records map[string]SchemaMigration) int {
+	batch := 0
+	for _, r := range records {
+		if r.Batch > batch {
+			batch = r.Batch
+		}
+	}
+	return batch + 1
+}
+
+// ensureTable 确保迁移记录表存在
+func (m *Migrator) ensureTable(db *gorm.DB) error {
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return fmt.Errorf("创建迁移记录表失败: %w", err)
+	}
+	return nil
+}
+
+// records 获取已执行的迁移记录，迁移记录表不存在时视为没有执行过迁移
+func (m *Migrator) records(db *gorm.DB) (map[string]SchemaMigration, error) {
+	if !db.Migrator().HasTable(&SchemaMigration{}) {
+		return map[string]SchemaMigration{}, nil
+	}
+
+	var list []SchemaMigration
+	if err := db.Order("version ASC").Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("查询迁移记录失败: %w", err)
+	}
+
+	records := make(map[string]SchemaMigration, len(list))
+	for _, r := range list {
+		records[r.Version] = r
+	}
+	return records, nil
+}
+
+// rollbackTargets 获取需要回滚的迁移记录，按版本号倒序
+func (m *Migrator) rollbackTargets(db *gorm.DB, steps int) ([]SchemaMigration, error) {
+	var targets []SchemaMigration
+	query := db.Order("version DESC")
+
+	if steps > 0 {
+		query = query.Limit(steps)
+	} else {
+		var lastBatch int
+		if err := db.Model(&SchemaMigration{}).Select("COALESCE(MAX(batch), 0)").Scan(&lastBatch).Error; err != nil {
+			return nil, fmt.Errorf("查询迁移批次失败: %w", err)
+		}
+		if lastBatch == 0 {
+			return nil, nil
+		}
+		query = query.Where("batch = ?", lastBatch)
+	}
+
+	if err := query.Find(&targets).Error; err != nil {
+		return nil, fmt.Errorf("查询迁移记录失败: %w", err)
+	}
+	return targets, nil
+}
+
+// find 根据版本号查找迁移
+func (m *Migrator) find(version string) *Migration {
+	for _, mig := range m.migrations {
+		if mig.Version == version {
+			return mig
+		}
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package migration
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// SQL迁移文件命名规则：
+//
+//	<版本号>_<名称>.sql                 所有方言通用
+//	<版本号>_<名称>.<方言>.sql          仅对指定方言生效，优先于通用文件
+//
+// 文件内容使用注释指令划分升级与回滚部分：
+//
+//	-- +migrate Up
+//	CREATE TABLE ...;
+//	-- +migrate Down
+//	DROP TABLE ...;
+//
+// 包含分号的语句块（如存储过程）可使用 StatementBegin/StatementEnd 包裹。
+var sqlFilePattern = regexp.MustCompile(`^(\d+)_([A-Za-z0-9_\-]+?)(?:\.(sqlite|mysql|postgres))?\.sql$`)
+
+const (
+	directiveUp             = "-- +migrate Up"
+	directiveDown           = "-- +migrate Down"
+	directiveStatementBegin = "-- +migrate StatementBegin"
+	directiveStatementEnd   = "-- +migrate StatementEnd"
+	directiveNoTransaction  = "-- +migrate NoTransaction"
+)
+
+// sqlScript 解析后的SQL脚本
+type sqlScript struct {
+	up            []string
+	down          []string
+	noTransaction bool
+}
+
+// LoadFS 从文件系统加载SQL迁移文件
+func LoadFS(fsys fs.FS, dir string) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取迁移目录失败: %w", err)
+	}
+
+	type sqlMigration struct {
+		version string
+		name    string
+		scripts map[string]*sqlScript // 方言 => 脚本，空字符串表示通用
+	}
+	grouped := make(map[string]*sqlMigration)
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		matches := sqlFilePattern.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			continue
+		}
+		version, name, dialect := matches[1], matches[2], matches[3]
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("读取迁移文件 %s 失败: %w", entry.Name(), err)
+		}
+		script, err := parseSQL(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("解析迁移文件 %s 失败: %w", entry.Name(), err)
+		}
+
+		item, ok := grouped[version]
+		if !ok {
+			item = &sqlMigration{version: version, name: name, scripts: make(map[string]*sqlScript)}
+			grouped[version] = item
+		} else if item.name != name {
+			return nil, fmt.Errorf("迁移版本号 %s 对应多个名称: %s, %s", version, item.name, name)
+		}
+		if _, exists := item.scripts[dialect]; exists {
+			return nil, fmt.Errorf("迁移文件重复: %s", entry.Name())
+		}
+		item.scripts[dialect] = script
+	}
+
+	migrations := make([]*Migration, 0, len(grouped))
+	for _, item := range grouped {
+		scripts := item.scripts
+		m := &Migration{
+			Version: item.version,
+			Name:    item.name,
+			Up: func(tx *gorm.DB) error {
+				script, err := pickScript(scripts, tx)
+				if err != nil {
+					return err
+				}
+				return execStatements(tx, script.up)
+			},
+			Down: func(tx *gorm.DB) error {
+				script, err := pickScript(scripts, tx)
+				if err != nil {
+					return err
+				}
+				return execStatements(tx, script.down)
+			},
+		}
+
+		// 没有通用脚本时，仅在提供了脚本的方言上执行
+		if _, ok := scripts[""]; !ok {
+			for dialect := range scripts {
+				m.Dialects = append(m.Dialects, dialect)
+			}
+		}
+		for _, script := range scripts {
+			if script.noTransaction {
+				m.DisableTransaction = true
+			}
+		}
+		migrations = append(migrations, m)
+	}
+
+	sortMigrations(migrations)
+	return migrations, nil
+}
+
+// RegisterFS 加载并注册文件系统中的SQL迁移
+func RegisterFS(fsys fs.FS, dir string) error {
+	migrations, err := LoadFS(fsys, dir)
+	if err != nil {
+		return err
+	}
+	Register(migrations...)
+	return nil
+}
+
+// pickScript 根据当前方言选择脚本
+func pickScript(scripts map[string]*sqlScript, tx *gorm.DB) (*sqlScript, error) {
+	dialect := normalizeDialect(tx.Dialector.Name())
+	if script, ok := scripts[dialect]; ok {
+		return script, nil
+	}
+	if script, ok := scripts[""]; ok {
+		return script, nil
+	}
+	return nil, fmt.Errorf("没有适用于 %s 的迁移脚本", dialect)
+}
+
+// execStatements 逐条执行SQL语句
+func execStatements(tx *gorm.DB, statements []string) error {
+	for _, stmt := range statements {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("执行SQL失败: %w\n%s", err, stmt)
+		}
+	}
+	return nil
+}
+
+// parseSQL 解析SQL迁移脚本
+func parseSQL(content string) (*sqlScript, error) {
+	script := &sqlScript{}
+
+	var (
+		current      *[]string
+		buf          strings.Builder
+		inStatement  bool
+		foundSection bool
+	)
+
+	flush := func() {
+		stmt := strings.TrimSpace(buf.String())
+		buf.Reset()
+		if stmt == "" || current == nil {
+			return
+		}
+		*current = append(*current, stmt)
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, directiveUp):
+			flush()
+			current = &script.up
+			foundSection = true
+			continue
+		case strings.HasPrefix(trimmed, directiveDown):
+			flush()
+			current = &script.down
+			foundSection = true
+			continue
+		case strings.HasPrefix(trimmed, directiveNoTransaction):
+			script.noTransaction = true
+			continue
+		case strings.HasPrefix(trimmed, directiveStatementBegin):
+			flush()
+			inStatement = true
+			continue
+		case strings.HasPrefix(trimmed, directiveStatementEnd):
+			flush()
+			inStatement = false
+			continue
+		}
+
+		if current == nil {
+			// 指令之前的内容忽略（通常是文件头注释）
+			continue
+		}
+
+		buf.WriteString(line)
+		buf.WriteString("\n")
+
+		if !inStatement && strings.HasSuffix(trimmed, ";") {
+			flush()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if inStatement {
+		return nil, fmt.Errorf("StatementBegin 缺少对应的 StatementEnd")
+	}
+	flush()
+
+	if !foundSection {
+		return nil, fmt.Errorf("缺少 %q 指令", directiveUp)
+	}
+	return script, nil
+}
@@ -4,6 +4,8 @@ import (
 	"github.com/zhoudm1743/go-web/core"
 	"github.com/zhoudm1743/go-web/core/cache"
 	"github.com/zhoudm1743/go-web/core/conf"
+	"github.com/zhoudm1743/go-web/core/database"
+	"github.com/zhoudm1743/go-web/core/dbcache"
 	"github.com/zhoudm1743/go-web/core/facades"
 	"github.com/zhoudm1743/go-web/core/log"
 )
@@ -28,21 +30,7 @@ func (c *CacheProvider) Register(app core.Application) error {
 	// 使用DI解析依赖
 	return container.Invoke(func(config *conf.Config, logger log.Logger) error {
 		// 根据配置创建缓存实例
-		var cacheInstance cache.Cache
-		var err error
-
-		switch config.Cache.Type {
-		case "redis":
-			// 创建Redis缓存
-			cacheInstance, err = cache.NewRedisCache(config, logger)
-		case "file":
-			// 创建文件缓存
-			cacheInstance, err = cache.NewFileCache(config, logger)
-		default:
-			// 默认使用内存缓存
-			cacheInstance, err = cache.NewMemoryCache(config, logger)
-		}
-
+		cacheInstance, err := cache.New(config, logger)
 		if err != nil {
 			return err
 		}
@@ -57,7 +45,10 @@ func (c *CacheProvider) Register(app core.Application) error {
 		// 设置全局Facade
 		facades.SetCache(cacheInstance)
 
-		return nil
+		// 在数据库连接上注册模型查询缓存插件
+		return container.Invoke(func(manager *database.Manager) error {
+			return dbcache.Setup(config.Cache.Model, manager, cacheInstance, logger)
+		})
 	})
 }
 
@@ -54,14 +54,15 @@ func (d *DatabaseProvider) Register(app core.Application) error {
 		fmt.Println("使用临时日志实例代替容器中的日志实例")
 	}
 
-	// 创建数据库连接
-	db, err := database.NewDB(database.DBParams{
+	// 创建默认连接和所有命名连接
+	manager, err := database.NewManager(database.DBParams{
 		Config: config,
 		Logger: logger,
 	})
 	if err != nil {
 		return fmt.Errorf("创建数据库连接失败: %w", err)
 	}
+	db := manager.Default()
 
 	// 注册数据库到容器
 	if err := container.Provide(func() *gorm.DB {
@@ -77,8 +78,15 @@ func (d *DatabaseProvider) Register(app core.Application) error {
 		return fmt.Errorf("注册GormDB到容器失败: %w", err)
 	}
 
+	// 注册连接管理器
+	if err := container.Provide(func() *database.Manager {
+		return manager
+	}); err != nil {
+		return fmt.Errorf("注册数据库连接管理器到容器失败: %w", err)
+	}
+
 	// 设置全局Facade
-	facades.SetDB(db)
+	facades.SetDBManager(manager)
 
 	fmt.Println("数据库提供者注册成功")
 	return nil
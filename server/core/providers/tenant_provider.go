@@ -0,0 +1,36 @@
+package providers
+
+import (
+	"github.com/zhoudm1743/go-web/core"
+	"github.com/zhoudm1743/go-web/core/conf"
+	"github.com/zhoudm1743/go-web/core/database"
+	"github.com/zhoudm1743/go-web/core/tenant"
+)
+
+// TenantProvider 多租户服务提供者
+type TenantProvider struct{}
+
+// NewTenantProvider 创建多租户服务提供者
+func NewTenantProvider() *TenantProvider {
+	return &TenantProvider{}
+}
+
+// Name 提供者名称
+func (t *TenantProvider) Name() string {
+	return "tenant"
+}
+
+// Register 注册服务到容器
+//
+// 在所有数据库连接上注册租户隔离插件。未启用多租户时同样注册，
+// 这样创建的数据都归属超级租户，之后启用多租户时无需迁移数据。
+func (t *TenantProvider) Register(app core.Application) error {
+	return app.GetContainer().Invoke(func(config *conf.Config, manager *database.Manager) error {
+		return tenant.Setup(config.Tenant, manager)
+	})
+}
+
+// Boot 启动服务
+func (t *TenantProvider) Boot(app core.Application) error {
+	return nil
+}
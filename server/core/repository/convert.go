@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// 支持的时间格式
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// convert 按字段类型转换查询参数，非字符串参数或未知字段原样返回
+func convert(field *schema.Field, value interface{}) (interface{}, error) {
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case json.Number:
+		s = v.String()
+	default:
+		return value, nil
+	}
+	if field == nil {
+		return s, nil
+	}
+
+	t := field.FieldType
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(s, 10, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.ParseUint(s, 10, 64)
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(s, 64)
+	case reflect.Bool:
+		return strconv.ParseBool(s)
+	}
+
+	if field.DataType == schema.Time {
+		for _, layout := range timeLayouts {
+			if tm, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+				return tm, nil
+			}
+		}
+		return nil, fmt.Errorf("时间格式无效")
+	}
+	return s, nil
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// cursorPaginate 游标分页
+//
+// 以排序列加主键作为键集，游标记录上一页最后一条记录的键值，
+// 适合数据量大或频繁插入的列表，不统计总数。
+func (r *Repository[T]) cursorPaginate(q *Query) (*Page[T], error) {
+	sch, err := r.schema()
+	if err != nil {
+		return nil, err
+	}
+
+	// 追加主键，保证排序唯一
+	sorts := append([]Sort{}, q.Sorts...)
+	if pk := sch.PrioritizedPrimaryField; pk != nil {
+		included := false
+		for _, s := range sorts {
+			if column(s.Column).Name == pk.DBName {
+				included = true
+				break
+			}
+		}
+		if !included {
+			desc := len(sorts) > 0 && sorts[len(sorts)-1].Desc
+			sorts = append(sorts, Sort{Column: pk.DBName, Desc: desc})
+		}
+	}
+	if len(sorts) == 0 {
+		return nil, fmt.Errorf("游标分页需要排序字段或主键")
+	}
+
+	keyset := *q
+	keyset.Sorts = sorts
+	db := r.Query(&keyset)
+
+	// 应用游标条件
+	if q.Cursor != "" {
+		values, err := decodeCursor(q.Cursor, len(sorts))
+		if err != nil {
+			return nil, err
+		}
+		for i, s := range sorts {
+			if values[i], err = convert(sch.LookUpField(column(s.Column).Name), values[i]); err != nil {
+				return nil, fmt.Errorf("游标无效: %w", err)
+			}
+		}
+		db = db.Where(keysetAfter(sorts, values))
+	}
+
+	// 多查一条判断是否还有下一页
+	var list []T
+	if err := db.Limit(q.PageSize + 1).Find(&list).Error; err != nil {
+		return nil, err
+	}
+
+	page := &Page[T]{List: list, PageSize: q.PageSize}
+	if len(list) > q.PageSize {
+		page.List, page.HasMore = list[:q.PageSize], true
+
+		last := &page.List[len(page.List)-1]
+		values := make([]interface{}, len(sorts))
+		for i, s := range sorts {
+			if values[i], err = fieldValue(r.db, sch, last, s.Column); err != nil {
+				return nil, err
+			}
+		}
+		if page.NextCursor, err = encodeCursor(values); err != nil {
+			return nil, err
+		}
+	}
+	if page.List == nil {
+		page.List = []T{}
+	}
+	return page, nil
+}
+
+// keysetAfter 构建键集条件：(a > ?) OR (a = ? AND b > ?) ...
+func keysetAfter(sorts []Sort, values []interface{}) clause.Expression {
+	ors := make([]clause.Expression, 0, len(sorts))
+	for i, s := range sorts {
+		ands := make([]clause.Expression, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, clause.Eq{Column: column(sorts[j].Column), Value: values[j]})
+		}
+		if s.Desc {
+			ands = append(ands, clause.Lt{Column: column(s.Column), Value: values[i]})
+		} else {
+			ands = append(ands, clause.Gt{Column: column(s.Column), Value: values[i]})
+		}
+		ors = append(ors, clause.And(ands...))
+	}
+	return clause.Or(ors...)
+}
+
+// encodeCursor 编码游标
+func encodeCursor(values []interface{}) (string, error) {
+	for i, v := range values {
+		// 时间统一使用 RFC3339Nano，避免时区和精度丢失
+		if t, ok := v.(time.Time); ok {
+			values[i] = t.Format(time.RFC3339Nano)
+		}
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("编码游标失败: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor 解码游标
+func decodeCursor(cursor string, n int) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("游标无效")
+	}
+
+	var values []interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&values); err != nil || len(values) != n {
+		return nil, fmt.Errorf("游标无效")
+	}
+	return values, nil
+}
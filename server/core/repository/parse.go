@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 保留的查询参数
+const (
+	paramPage     = "page"
+	paramPageSize = "pageSize"
+	paramSort     = "sort"
+	paramCursor   = "cursor"
+	paramKeyword  = "keyword"
+	paramTrashed  = "trashed"
+)
+
+// 单个 in 条件允许的最大值个数
+const maxInValues = 100
+
+// Fields 查询字段白名单，只有白名单中的字段可以被过滤和排序
+type Fields struct {
+	Filter      map[string]string // 可过滤字段，键为查询参数名，值为数据库列名
+	Sort        map[string]string // 可排序字段，键为查询参数名，值为数据库列名
+	Search      []string          // 关键字模糊搜索的列
+	DefaultSort string            // 默认排序，格式同 sort 参数，例如 "-id"
+	PageSize    int               // 默认每页条数，默认10
+	MaxPageSize int               // 最大每页条数，默认100
+	Trashed     bool              // 是否允许通过 trashed 参数查询已删除记录
+}
+
+// Columns 根据列名生成白名单，同时接受蛇形和驼峰两种参数名
+func Columns(columns ...string) map[string]string {
+	m := make(map[string]string, len(columns)*2)
+	for _, col := range columns {
+		name := col
+		if i := strings.LastIndex(col, "."); i >= 0 {
+			name = col[i+1:]
+		}
+		m[name] = col
+		m[lowerCamel(name)] = col
+	}
+	return m
+}
+
+// lowerCamel 蛇形转小驼峰
+func lowerCamel(s string) string {
+	parts := strings.Split(s, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] == "" {
+			continue
+		}
+		r := []rune(parts[i])
+		r[0] = unicode.ToUpper(r[0])
+		parts[i] = string(r)
+	}
+	return strings.Join(parts, "")
+}
+
+// ParseQuery 从gin请求参数解析查询条件
+//
+// 支持的参数格式：
+//
+//	name=foo                等于
+//	name[like]=foo          模糊匹配，操作符见 Operator
+//	status[in]=1,2          多个值用逗号分隔
+//	createdAt[between]=a,b  区间
+//	sort=-createdAt,name    排序，- 表示降序
+//	page=1&pageSize=20      偏移分页
+//	cursor=&pageSize=20     游标分页，首页传空游标，之后传上一页返回的 nextCursor
+//	keyword=foo             在 Search 列上模糊搜索
+//	trashed=with|only       包含或只查询已删除记录，需 Fields.Trashed 开启
+//
+// 不在白名单中的普通参数会被忽略，带操作符的参数不在白名单中时返回错误。
+func ParseQuery(c *gin.Context, fields Fields) (*Query, error) {
+	return ParseValues(c.Request.URL.Query(), fields)
+}
+
+// ParseValues 从URL参数解析查询条件
+func ParseValues(values url.Values, fields Fields) (*Query, error) {
+	q := NewQuery()
+	if fields.PageSize > 0 {
+		q.PageSize = fields.PageSize
+	}
+	maxPageSize := fields.MaxPageSize
+	if maxPageSize <= 0 {
+		maxPageSize = 100
+	}
+
+	for key, vals := range values {
+		if len(vals) == 0 {
+			continue
+		}
+		value := vals[0]
+
+		switch key {
+		case paramPage:
+			page, err := strconv.Atoi(value)
+			if err != nil || page < 1 {
+				return nil, fmt.Errorf("页码无效: %s", value)
+			}
+			q.Page = page
+			continue
+		case paramPageSize:
+			size, err := strconv.Atoi(value)
+			if err != nil || size < 1 {
+				return nil, fmt.Errorf("每页条数无效: %s", value)
+			}
+			if size > maxPageSize {
+				size = maxPageSize
+			}
+			q.PageSize = size
+			continue
+		case paramSort:
+			continue
+		case paramCursor:
+			q.UseCursor, q.Cursor = true, value
+			continue
+		case paramKeyword:
+			q.Keyword = strings.TrimSpace(value)
+			q.Search = fields.Search
+			continue
+		case paramTrashed:
+			if !fields.Trashed {
+				return nil, fmt.Errorf("不允许查询已删除记录")
+			}
+			switch Trashed(value) {
+			case TrashedWithout, TrashedWith, TrashedOnly:
+				q.Trashed = Trashed(value)
+			default:
+				return nil, fmt.Errorf("trashed 参数无效: %s", value)
+			}
+			continue
+		}
+
+		// 解析 name[op] 形式
+		name, op := key, OpEq
+		if i := strings.IndexByte(key, '['); i > 0 && strings.HasSuffix(key, "]") {
+			name, op = key[:i], Operator(key[i+1:len(key)-1])
+			if !op.valid() {
+				return nil, fmt.Errorf("不支持的操作符: %s", op)
+			}
+		}
+
+		col, ok := fields.Filter[name]
+		if !ok {
+			if name != key {
+				return nil, fmt.Errorf("字段 %s 不允许过滤", name)
+			}
+			// 普通参数可能由控制器自行处理，忽略
+			continue
+		}
+
+		filter := Filter{Column: col, Op: op}
+		switch op {
+		case OpIn, OpBetween:
+			parts := strings.Split(value, ",")
+			if op == OpIn && len(parts) > maxInValues {
+				return nil, fmt.Errorf("字段 %s 的 in 条件最多 %d 个值", name, maxInValues)
+			}
+			for _, p := range parts {
+				filter.Values = append(filter.Values, strings.TrimSpace(p))
+			}
+			if op == OpBetween && len(filter.Values) != 2 {
+				return nil, fmt.Errorf("字段 %s 的 between 条件需要两个值", name)
+			}
+		default:
+			filter.Values = []interface{}{value}
+		}
+		q.Filters = append(q.Filters, filter)
+	}
+
+	// 排序
+	order, trusted := values.Get(paramSort), false
+	if order == "" {
+		order, trusted = fields.DefaultSort, true
+	}
+	sorts, err := parseSort(order, fields.Sort, trusted)
+	if err != nil {
+		return nil, err
+	}
+	q.Sorts = sorts
+
+	// 参数遍历顺序不固定，按列名排序保证生成的SQL稳定
+	sort.SliceStable(q.Filters, func(i, j int) bool {
+		return q.Filters[i].Column < q.Filters[j].Column
+	})
+	return q, nil
+}
+
+// parseSort 解析排序参数，trusted 为 true 时表示来自默认排序，不校验白名单
+func parseSort(value string, allowed map[string]string, trusted bool) ([]Sort, error) {
+	var sorts []Sort
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		desc := false
+		switch item[0] {
+		case '-':
+			desc, item = true, item[1:]
+		case '+':
+			item = item[1:]
+		}
+
+		col, ok := allowed[item]
+		if !ok {
+			if !trusted {
+				return nil, fmt.Errorf("字段 %s 不允许排序", item)
+			}
+			col = item
+		}
+		sorts = append(sorts, Sort{Column: col, Desc: desc})
+	}
+	return sorts, nil
+}
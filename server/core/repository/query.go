@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Operator 过滤操作符
+type Operator string
+
+// 支持的过滤操作符
+const (
+	OpEq      Operator = "eq"      // 等于
+	OpNe      Operator = "ne"      // 不等于
+	OpLike    Operator = "like"    // 模糊匹配
+	OpIn      Operator = "in"      // 包含于，多个值用逗号分隔
+	OpBetween Operator = "between" // 区间，两个值用逗号分隔
+	OpGt      Operator = "gt"      // 大于
+	OpGte     Operator = "gte"     // 大于等于
+	OpLt      Operator = "lt"      // 小于
+	OpLte     Operator = "lte"     // 小于等于
+)
+
+// valid 判断操作符是否支持
+func (op Operator) valid() bool {
+	switch op {
+	case OpEq, OpNe, OpLike, OpIn, OpBetween, OpGt, OpGte, OpLt, OpLte:
+		return true
+	}
+	return false
+}
+
+// Trashed 软删除记录的查询方式
+type Trashed string
+
+// 软删除查询方式
+const (
+	TrashedWithout Trashed = ""     // 不包含已删除记录
+	TrashedWith    Trashed = "with" // 包含已删除记录
+	TrashedOnly    Trashed = "only" // 只查询已删除记录
+)
+
+// Filter 过滤条件
+type Filter struct {
+	Column string        // 数据库列名
+	Op     Operator      // 操作符
+	Values []interface{} // 值，in 和 between 有多个值
+}
+
+// Sort 排序条件
+type Sort struct {
+	Column string // 数据库列名
+	Desc   bool   // 是否降序
+}
+
+// Query 查询条件
+type Query struct {
+	Filters  []Filter
+	Sorts    []Sort
+	Keyword  string   // 关键字，在 Search 列上模糊匹配
+	Search   []string // 关键字搜索的列
+	Trashed  Trashed  // 软删除记录的查询方式
+	Page     int      // 页码，从1开始
+	PageSize int      // 每页条数
+
+	// 游标分页，Cursor 为上一页返回的 NextCursor，首页为空
+	UseCursor bool
+	Cursor    string
+}
+
+// NewQuery 创建查询条件
+func NewQuery() *Query {
+	return &Query{Page: 1, PageSize: 10}
+}
+
+// Where 添加过滤条件
+func (q *Query) Where(column string, op Operator, values ...interface{}) *Query {
+	q.Filters = append(q.Filters, Filter{Column: column, Op: op, Values: values})
+	return q
+}
+
+// OrderBy 添加排序条件
+func (q *Query) OrderBy(column string, desc bool) *Query {
+	q.Sorts = append(q.Sorts, Sort{Column: column, Desc: desc})
+	return q
+}
+
+// Paginate 设置偏移分页
+func (q *Query) Paginate(page, pageSize int) *Query {
+	q.Page, q.PageSize, q.UseCursor = page, pageSize, false
+	return q
+}
+
+// After 设置游标分页
+func (q *Query) After(cursor string, limit int) *Query {
+	q.Cursor, q.PageSize, q.UseCursor = cursor, limit, true
+	return q
+}
+
+// Offset 偏移量
+func (q *Query) Offset() int {
+	if q.Page <= 1 {
+		return 0
+	}
+	return (q.Page - 1) * q.PageSize
+}
+
+// column 构建列表达式，支持 table.column 形式
+func column(name string) clause.Column {
+	if i := strings.LastIndex(name, "."); i > 0 {
+		return clause.Column{Table: name[:i], Name: name[i+1:]}
+	}
+	return clause.Column{Name: name}
+}
+
+// expression 将过滤条件转换为SQL表达式
+func (f Filter) expression() (clause.Expression, error) {
+	col := column(f.Column)
+	need := func(n int) error {
+		if len(f.Values) < n {
+			return fmt.Errorf("字段 %s 的 %s 条件缺少参数", f.Column, f.Op)
+		}
+		return nil
+	}
+
+	switch f.Op {
+	case OpEq, "":
+		if err := need(1); err != nil {
+			return nil, err
+		}
+		return clause.Eq{Column: col, Value: f.Values[0]}, nil
+	case OpNe:
+		if err := need(1); err != nil {
+			return nil, err
+		}
+		return clause.Neq{Column: col, Value: f.Values[0]}, nil
+	case OpLike:
+		if err := need(1); err != nil {
+			return nil, err
+		}
+		return clause.Like{Column: col, Value: "%" + fmt.Sprint(f.Values[0]) + "%"}, nil
+	case OpIn:
+		if err := need(1); err != nil {
+			return nil, err
+		}
+		return clause.IN{Column: col, Values: f.Values}, nil
+	case OpBetween:
+		if len(f.Values) != 2 {
+			return nil, fmt.Errorf("字段 %s 的 between 条件需要两个参数", f.Column)
+		}
+		return clause.Expr{SQL: "? BETWEEN ? AND ?", Vars: []interface{}{col, f.Values[0], f.Values[1]}}, nil
+	case OpGt:
+		if err := need(1); err != nil {
+			return nil, err
+		}
+		return clause.Gt{Column: col, Value: f.Values[0]}, nil
+	case OpGte:
+		if err := need(1); err != nil {
+			return nil, err
+		}
+		return clause.Gte{Column: col, Value: f.Values[0]}, nil
+	case OpLt:
+		if err := need(1); err != nil {
+			return nil, err
+		}
+		return clause.Lt{Column: col, Value: f.Values[0]}, nil
+	case OpLte:
+		if err := need(1); err != nil {
+			return nil, err
+		}
+		return clause.Lte{Column: col, Value: f.Values[0]}, nil
+	default:
+		return nil, fmt.Errorf("不支持的操作符: %s", f.Op)
+	}
+}
+
+// Scope 将过滤、关键字和排序条件应用到查询，不包含分页
+func (q *Query) Scope(db *gorm.DB) *gorm.DB {
+	for _, f := range q.Filters {
+		expr, err := f.expression()
+		if err != nil {
+			db.AddError(err)
+			return db
+		}
+		db = db.Where(expr)
+	}
+
+	if q.Keyword != "" && len(q.Search) > 0 {
+		exprs := make([]clause.Expression, 0, len(q.Search))
+		for _, name := range q.Search {
+			exprs = append(exprs, clause.Like{Column: column(name), Value: "%" + q.Keyword + "%"})
+		}
+		db = db.Where(clause.Or(exprs...))
+	}
+
+	for _, s := range q.Sorts {
+		db = db.Order(clause.OrderByColumn{Column: column(s.Column), Desc: s.Desc})
+	}
+	return db
+}
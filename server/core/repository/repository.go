@@ -0,0 +1,310 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Page 分页结果
+type Page[T any] struct {
+	List       []T    `json:"list"`                 // 数据列表
+	Total      int64  `json:"total"`                // 总数，游标分页时不统计
+	Page       int    `json:"page,omitempty"`       // 页码，游标分页时为空
+	PageSize   int    `json:"pageSize"`             // 每页条数
+	NextCursor string `json:"nextCursor,omitempty"` // 下一页游标
+	HasMore    bool   `json:"hasMore"`              // 是否还有下一页
+}
+
+// Repository 通用数据仓库
+type Repository[T any] struct {
+	db     *gorm.DB
+	scopes []func(*gorm.DB) *gorm.DB
+}
+
+// New 创建数据仓库
+func New[T any](db *gorm.DB) *Repository[T] {
+	return &Repository[T]{db: db}
+}
+
+// Scopes 返回附加了作用域的仓库副本，例如预加载或固定条件
+func (r *Repository[T]) Scopes(scopes ...func(*gorm.DB) *gorm.DB) *Repository[T] {
+	return &Repository[T]{
+		db:     r.db,
+		scopes: append(append([]func(*gorm.DB) *gorm.DB{}, r.scopes...), scopes...),
+	}
+}
+
+// Preload 返回预加载了关联的仓库副本
+func (r *Repository[T]) Preload(query string, args ...interface{}) *Repository[T] {
+	return r.Scopes(func(db *gorm.DB) *gorm.DB {
+		return db.Preload(query, args...)
+	})
+}
+
+// DB 获取带模型和作用域的查询
+func (r *Repository[T]) DB() *gorm.DB {
+	var model T
+	return r.db.Model(&model).Scopes(r.scopes...)
+}
+
+// schema 解析模型结构
+func (r *Repository[T]) schema() (*schema.Schema, error) {
+	var model T
+	stmt := &gorm.Statement{DB: r.db}
+	if err := stmt.Parse(&model); err != nil {
+		return nil, fmt.Errorf("解析模型失败: %w", err)
+	}
+	return stmt.Schema, nil
+}
+
+// Query 应用查询条件（不含分页）
+func (r *Repository[T]) Query(q *Query) *gorm.DB {
+	db := r.DB()
+	if q == nil {
+		return db
+	}
+
+	sch, err := r.schema()
+	if err != nil {
+		db.AddError(err)
+		return db
+	}
+
+	// 软删除
+	if q.Trashed != TrashedWithout {
+		deletedAt := sch.LookUpField("deleted_at")
+		if deletedAt == nil {
+			db.AddError(fmt.Errorf("模型 %s 不支持软删除", sch.Name))
+			return db
+		}
+		db = db.Unscoped()
+		if q.Trashed == TrashedOnly {
+			db = db.Where(clause.Neq{Column: clause.Column{Table: clause.CurrentTable, Name: deletedAt.DBName}, Value: nil})
+		}
+	}
+
+	// 按字段类型转换参数，避免严格类型的数据库比较出错
+	converted := *q
+	converted.Filters = make([]Filter, len(q.Filters))
+	for i, f := range q.Filters {
+		values := make([]interface{}, len(f.Values))
+		field := sch.LookUpField(column(f.Column).Name)
+		for j, v := range f.Values {
+			if values[j], err = convert(field, v); err != nil {
+				db.AddError(fmt.Errorf("字段 %s 的值 %v 无效: %w", f.Column, v, err))
+				return db
+			}
+		}
+		converted.Filters[i] = Filter{Column: f.Column, Op: f.Op, Values: values}
+	}
+	return converted.Scope(db)
+}
+
+// All 查询所有符合条件的记录
+func (r *Repository[T]) All(q *Query) ([]T, error) {
+	var list []T
+	if err := r.Query(q).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Count 统计符合条件的记录数
+func (r *Repository[T]) Count(q *Query) (int64, error) {
+	var total int64
+	if err := r.Query(q).Count(&total).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// Paginate 分页查询，根据 Query.UseCursor 选择偏移分页或游标分页
+func (r *Repository[T]) Paginate(q *Query) (*Page[T], error) {
+	if q == nil {
+		q = NewQuery()
+	}
+	if q.PageSize <= 0 {
+		q.PageSize = 10
+	}
+	if q.UseCursor {
+		return r.cursorPaginate(q)
+	}
+
+	total, err := r.Count(q)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page[T]{List: []T{}, Total: total, Page: q.Page, PageSize: q.PageSize}
+	if page.Page < 1 {
+		page.Page = 1
+	}
+	if total == 0 {
+		return page, nil
+	}
+
+	if err := r.Query(q).Offset(q.Offset()).Limit(q.PageSize).Find(&page.List).Error; err != nil {
+		return nil, err
+	}
+	page.HasMore = int64(q.Offset()+len(page.List)) < total
+	return page, nil
+}
+
+// First 按主键查询
+func (r *Repository[T]) First(id interface{}) (*T, error) {
+	var entity T
+	if err := r.DB().First(&entity, id).Error; err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+// FirstWhere 按条件查询第一条记录
+func (r *Repository[T]) FirstWhere(query interface{}, args ...interface{}) (*T, error) {
+	var entity T
+	if err := r.DB().Where(query, args...).First(&entity).Error; err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+// Exists 判断是否存在符合条件的记录
+func (r *Repository[T]) Exists(query interface{}, args ...interface{}) (bool, error) {
+	var count int64
+	if err := r.DB().Where(query, args...).Limit(1).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Create 创建记录
+func (r *Repository[T]) Create(entity *T) error {
+	return r.db.Create(entity).Error
+}
+
+// Save 保存记录的所有字段
+func (r *Repository[T]) Save(entity *T) error {
+	return r.db.Save(entity).Error
+}
+
+// Updates 更新记录的指定字段，values 可以是结构体或 map
+func (r *Repository[T]) Updates(entity *T, values interface{}) error {
+	return r.db.Model(entity).Updates(values).Error
+}
+
+// Delete 按主键删除记录，模型支持软删除时为软删除
+func (r *Repository[T]) Delete(ids ...interface{}) error {
+	if len(ids) == 0 {
+		return errors.New("删除条件不能为空")
+	}
+	var model T
+	return r.db.Delete(&model, ids...).Error
+}
+
+// deletedAt 获取模型的软删除字段
+func (r *Repository[T]) deletedAt() (*schema.Schema, *schema.Field, error) {
+	sch, err := r.schema()
+	if err != nil {
+		return nil, nil, err
+	}
+	field := sch.LookUpField("deleted_at")
+	if field == nil {
+		return nil, nil, fmt.Errorf("模型 %s 不支持软删除", sch.Name)
+	}
+	if sch.PrioritizedPrimaryField == nil {
+		return nil, nil, fmt.Errorf("模型 %s 没有主键", sch.Name)
+	}
+	return sch, field, nil
+}
+
+// trashed 只包含已删除记录的查询
+func (r *Repository[T]) trashed() (*gorm.DB, *schema.Schema, error) {
+	sch, field, err := r.deletedAt()
+	if err != nil {
+		return nil, nil, err
+	}
+	var model T
+	db := r.db.Model(&model).Unscoped().
+		Where(clause.Neq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: nil})
+	return db, sch, nil
+}
+
+// byPrimaryKey 按主键过滤
+func byPrimaryKey(sch *schema.Schema, ids []interface{}) clause.Expression {
+	return clause.IN{
+		Column: clause.Column{Table: clause.CurrentTable, Name: sch.PrioritizedPrimaryField.DBName},
+		Values: ids,
+	}
+}
+
+// FirstTrashed 按主键查询已软删除的记录
+func (r *Repository[T]) FirstTrashed(id interface{}) (*T, error) {
+	db, _, err := r.trashed()
+	if err != nil {
+		return nil, err
+	}
+	var entity T
+	if err := db.Scopes(r.scopes...).First(&entity, id).Error; err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+// Restore 按主键恢复已软删除的记录，返回恢复的条数
+func (r *Repository[T]) Restore(ids ...interface{}) (int64, error) {
+	if len(ids) == 0 {
+		return 0, errors.New("恢复条件不能为空")
+	}
+	db, sch, err := r.trashed()
+	if err != nil {
+		return 0, err
+	}
+	field := sch.LookUpField("deleted_at")
+	result := db.Where(byPrimaryKey(sch, ids)).Update(field.DBName, nil)
+	return result.RowsAffected, result.Error
+}
+
+// ForceDelete 按主键永久删除已软删除的记录，返回删除的条数
+//
+// 只删除回收站中的记录，未删除的记录需先调用 Delete。
+func (r *Repository[T]) ForceDelete(ids ...interface{}) (int64, error) {
+	if len(ids) == 0 {
+		return 0, errors.New("删除条件不能为空")
+	}
+	db, sch, err := r.trashed()
+	if err != nil {
+		return 0, err
+	}
+	var model T
+	result := db.Where(byPrimaryKey(sch, ids)).Delete(&model)
+	return result.RowsAffected, result.Error
+}
+
+// Purge 永久删除在 before 之前软删除的记录，返回删除的条数
+func (r *Repository[T]) Purge(before time.Time) (int64, error) {
+	_, field, err := r.deletedAt()
+	if err != nil {
+		return 0, err
+	}
+	var model T
+	result := r.db.Unscoped().
+		Where(clause.Lt{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: before}).
+		Delete(&model)
+	return result.RowsAffected, result.Error
+}
+
+// fieldValue 读取记录中指定列的值
+func fieldValue(db *gorm.DB, sch *schema.Schema, entity interface{}, col string) (interface{}, error) {
+	field := sch.LookUpField(column(col).Name)
+	if field == nil {
+		return nil, fmt.Errorf("模型 %s 不存在字段 %s", sch.Name, col)
+	}
+	value, _ := field.ValueOf(db.Statement.Context, reflect.Indirect(reflect.ValueOf(entity)))
+	return value, nil
+}
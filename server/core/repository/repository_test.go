@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testArticle 测试模型
+type testArticle struct {
+	ID        uint
+	Title     string
+	Views     int
+	Status    uint
+	DeletedAt gorm.DeletedAt
+}
+
+// testFields 测试白名单
+var testFields = Fields{
+	Filter:      Columns("title", "views", "status"),
+	Sort:        Columns("id", "views"),
+	Search:      []string{"title"},
+	DefaultSort: "-id",
+	Trashed:     true,
+}
+
+// openTestRepo 创建测试仓库，写入10条数据，第10条已软删除
+func openTestRepo(t *testing.T) *Repository[testArticle] {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&testArticle{}); err != nil {
+		t.Fatalf("创建表失败: %v", err)
+	}
+
+	repo := New[testArticle](db)
+	for i := 1; i <= 10; i++ {
+		article := &testArticle{Title: fmt.Sprintf("article-%02d", i), Views: i % 4, Status: uint(i%2 + 1)}
+		if err := repo.Create(article); err != nil {
+			t.Fatalf("写入失败: %v", err)
+		}
+	}
+	if err := repo.Delete(10); err != nil {
+		t.Fatalf("删除失败: %v", err)
+	}
+	return repo
+}
+
+// parse 解析查询字符串
+func parse(t *testing.T, raw string) *Query {
+	values, _ := url.ParseQuery(raw)
+	q, err := ParseValues(values, testFields)
+	if err != nil {
+		t.Fatalf("解析 %q 失败: %v", raw, err)
+	}
+	return q
+}
+
+// TestPaginateWithFilters 测试过滤、排序与偏移分页
+func TestPaginateWithFilters(t *testing.T) {
+	repo := openTestRepo(t)
+
+	cases := []struct {
+		query string
+		total int64
+		first string
+	}{
+		{"page=1&pageSize=3", 9, "article-09"},
+		{"status=2&sort=views,-id", 5, "article-09"},
+		{"views[gte]=2&views[lt]=3", 2, "article-06"},
+		{"views[in]=0,1&sort=id", 5, "article-01"},
+		{"views[between]=1,2&title[ne]=article-01", 4, "article-09"},
+		{"keyword=-0&title[like]=article", 9, "article-09"},
+		{"trashed=only", 1, "article-10"},
+		{"trashed=with&pageSize=1", 10, "article-10"},
+	}
+	for _, tc := range cases {
+		page, err := repo.Paginate(parse(t, tc.query))
+		if err != nil {
+			t.Fatalf("%s: 查询失败: %v", tc.query, err)
+		}
+		if page.Total != tc.total || len(page.List) == 0 || page.List[0].Title != tc.first {
+			t.Fatalf("%s: 期望总数 %d 首条 %s, 实际 %d %+v", tc.query, tc.total, tc.first, page.Total, page.List)
+		}
+	}
+}
+
+// TestCursorPagination 测试游标分页遍历所有记录
+func TestCursorPagination(t *testing.T) {
+	repo := openTestRepo(t)
+
+	var titles []string
+	cursor := ""
+	for i := 0; i < 10; i++ {
+		page, err := repo.Paginate(parse(t, "sort=-views&pageSize=4&cursor="+cursor))
+		if err != nil {
+			t.Fatalf("游标查询失败: %v", err)
+		}
+		for _, item := range page.List {
+			titles = append(titles, item.Title)
+		}
+		if !page.HasMore {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	if len(titles) != 9 {
+		t.Fatalf("应遍历9条未删除记录, 实际: %v", titles)
+	}
+	seen := make(map[string]bool)
+	for _, title := range titles {
+		if seen[title] {
+			t.Fatalf("游标分页出现重复记录: %v", titles)
+		}
+		seen[title] = true
+	}
+	// views 降序，相同 views 按 id 降序
+	if titles[0] != "article-07" || titles[1] != "article-03" {
+		t.Fatalf("排序不正确: %v", titles)
+	}
+}
+
+// TestParseWhitelist 测试白名单校验
+func TestParseWhitelist(t *testing.T) {
+	invalid := []string{
+		"password[eq]=1",
+		"sort=password",
+		"views[regexp]=1",
+		"views[between]=1",
+		"page=0",
+	}
+	for _, raw := range invalid {
+		values, _ := url.ParseQuery(raw)
+		if _, err := ParseValues(values, testFields); err == nil {
+			t.Fatalf("%s: 期望返回错误", raw)
+		}
+	}
+
+	// 不在白名单中的普通参数被忽略
+	q := parse(t, "password=1&withRelations=true")
+	if len(q.Filters) != 0 {
+		t.Fatalf("普通参数不应成为过滤条件: %+v", q.Filters)
+	}
+
+	// 类型不匹配的值返回错误
+	if _, err := openTestRepo(t).Paginate(parse(t, "views=abc")); err == nil {
+		t.Fatal("期望类型转换错误")
+	}
+}
+
+// TestTrash 测试回收站的恢复、永久删除与按保留期清理
+func TestTrash(t *testing.T) {
+	repo := openTestRepo(t)
+
+	if _, err := repo.FirstTrashed(1); err == nil {
+		t.Fatal("未删除的记录不应出现在回收站")
+	}
+	if item, err := repo.FirstTrashed(10); err != nil || item.Title != "article-10" {
+		t.Fatalf("查询回收站记录失败: %v", err)
+	}
+
+	// 只恢复已删除的记录
+	if n, err := repo.Restore(1, 10); err != nil || n != 1 {
+		t.Fatalf("恢复失败: %d, %v", n, err)
+	}
+	if _, err := repo.First(10); err != nil {
+		t.Fatalf("恢复后应可查询: %v", err)
+	}
+
+	// 未删除的记录不会被永久删除
+	if n, err := repo.ForceDelete(2); err != nil || n != 0 {
+		t.Fatalf("未删除的记录不应被永久删除: %d, %v", n, err)
+	}
+	if err := repo.Delete(2, 3); err != nil {
+		t.Fatalf("删除失败: %v", err)
+	}
+	if n, err := repo.ForceDelete(2); err != nil || n != 1 {
+		t.Fatalf("永久删除失败: %d, %v", n, err)
+	}
+	if total, _ := repo.Count(&Query{Trashed: TrashedWith}); total != 9 {
+		t.Fatalf("期望剩余9条记录, 实际 %d", total)
+	}
+
+	// 按删除时间清理
+	if n, err := repo.Purge(time.Now().Add(-time.Hour)); err != nil || n != 0 {
+		t.Fatalf("保留期内的记录不应被清理: %d, %v", n, err)
+	}
+	if n, err := repo.Purge(time.Now().Add(time.Second)); err != nil || n != 1 {
+		t.Fatalf("清理失败: %d, %v", n, err)
+	}
+	if total, _ := repo.Count(&Query{Trashed: TrashedOnly}); total != 0 {
+		t.Fatalf("回收站应为空, 实际 %d", total)
+	}
+}
@@ -0,0 +1,42 @@
+package response
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetETag 以记录版本号设置 ETag 响应头
+func SetETag(c *gin.Context, version uint) {
+	if version > 0 {
+		c.Header("ETag", strconv.Quote(strconv.FormatUint(uint64(version), 10)))
+	}
+}
+
+// IfMatch 解析 If-Match 请求头中的版本号，未提供或为 * 时返回 false
+func IfMatch(c *gin.Context) (uint, bool) {
+	value := strings.TrimSpace(c.GetHeader("If-Match"))
+	value = strings.Trim(strings.TrimPrefix(value, "W/"), `"`)
+	if value == "" || value == "*" {
+		return 0, false
+	}
+	version, err := strconv.ParseUint(value, 10, 0)
+	if err != nil || version == 0 {
+		return 0, false
+	}
+	return uint(version), true
+}
+
+// ExpectVersion 更新时期望的版本号，优先使用 If-Match 请求头，其次依次使用第一个非零的版本号
+func ExpectVersion(c *gin.Context, versions ...uint) uint {
+	if v, ok := IfMatch(c); ok {
+		return v
+	}
+	for _, v := range versions {
+		if v > 0 {
+			return v
+		}
+	}
+	return 0
+}
@@ -42,6 +42,14 @@ var (
 	Request404Error = RespType{code: 404, msg: "请求资源不存在"}
 	Request405Error = RespType{code: 405, msg: "请求方法不允许"}
 
+	// 并发修改冲突
+	VersionConflict = RespType{code: 409, msg: "数据已被他人修改，请刷新后重试"}
+
+	// 租户相关错误
+	TenantNotFound = RespType{code: 404, msg: "租户不存在"}
+	TenantDisabled = RespType{code: 403, msg: "租户已停用"}
+	TenantMismatch = RespType{code: 403, msg: "无权访问该租户"}
+
 	// 系统错误
 	SystemError = RespType{code: 500, msg: "系统内部错误"}
 )
@@ -78,11 +86,15 @@ func (rt RespType) Data() interface{} {
 	return rt.data
 }
 
+// CodeKey 上下文中记录业务状态码的键
+const CodeKey = "response.code"
+
 // Result 统一响应
 func Result(c *gin.Context, resp RespType, data interface{}) {
 	if data == nil {
 		data = resp.data
 	}
+	c.Set(CodeKey, resp.code)
 	c.JSON(http.StatusOK, Response{
 		Code:    resp.code,
 		Message: resp.msg,
@@ -90,6 +102,18 @@ func Result(c *gin.Context, resp RespType, data interface{}) {
 	})
 }
 
+// IsSuccess 判断请求是否以成功结束：HTTP状态码为2xx、没有错误且业务状态码为成功
+func IsSuccess(c *gin.Context) bool {
+	if status := c.Writer.Status(); status < http.StatusOK || status >= http.StatusMultipleChoices {
+		return false
+	}
+	if len(c.Errors) > 0 {
+		return false
+	}
+	code, exists := c.Get(CodeKey)
+	return !exists || code == Success.code
+}
+
 // Ok 成功响应
 func Ok(c *gin.Context) {
 	Result(c, Success, map[string]bool{"success": true})
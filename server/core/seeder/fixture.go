@@ -0,0 +1,169 @@
+package seeder
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// Fixture 数据文件结构
+//
+//	model: menus            # 通过 RegisterModel 注册的模型名
+//	keys: [name]            # 唯一键
+//	updates: [title, icon]  # 已存在时覆盖的字段，可省略
+//	records:
+//	  - name: article
+//	    title: 文章管理
+type Fixture struct {
+	Model   string                   `json:"model" yaml:"model"`
+	Keys    []string                 `json:"keys" yaml:"keys"`
+	Updates []string                 `json:"updates" yaml:"updates"`
+	Records []map[string]interface{} `json:"records" yaml:"records"`
+}
+
+// 数据文件可用的模型
+var (
+	modelsMu sync.RWMutex
+	models   = make(map[string]reflect.Type)
+)
+
+// RegisterModel 注册数据文件中可引用的模型
+func RegisterModel(name string, model interface{}) {
+	modelsMu.Lock()
+	defer modelsMu.Unlock()
+
+	t := reflect.TypeOf(model)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	models[name] = t
+}
+
+// lookupModel 查找已注册的模型
+func lookupModel(name string) (reflect.Type, bool) {
+	modelsMu.RLock()
+	defer modelsMu.RUnlock()
+
+	t, ok := models[name]
+	return t, ok
+}
+
+// LoadFixtures 加载数据文件目录
+//
+// 先加载目录下的通用文件，再加载 <dir>/<env> 下的环境文件，
+// 同一目录内按文件名排序，可通过数字前缀控制顺序。
+func LoadFixtures(db *gorm.DB, dir, env string, logf func(format string, args ...interface{})) error {
+	dirs := []string{dir, filepath.Join(dir, normalizeEnv(env))}
+	for _, d := range dirs {
+		files, err := fixtureFiles(d)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			if err := LoadFixtureFile(db, file); err != nil {
+				return err
+			}
+			if logf != nil {
+				logf("已加载数据文件: %s", file)
+			}
+		}
+	}
+	return nil
+}
+
+// LoadFixtureFile 加载单个数据文件，在一个事务中执行
+func LoadFixtureFile(db *gorm.DB, file string) error {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("读取数据文件 %s 失败: %w", file, err)
+	}
+
+	var fixture Fixture
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".json":
+		err = json.Unmarshal(content, &fixture)
+	default:
+		err = yaml.Unmarshal(content, &fixture)
+	}
+	if err != nil {
+		return fmt.Errorf("解析数据文件 %s 失败: %w", file, err)
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		return ApplyFixture(tx, &fixture)
+	}); err != nil {
+		return fmt.Errorf("加载数据文件 %s 失败: %w", file, err)
+	}
+	return nil
+}
+
+// ApplyFixture 写入数据
+func ApplyFixture(tx *gorm.DB, fixture *Fixture) error {
+	modelType, ok := lookupModel(fixture.Model)
+	if !ok {
+		return fmt.Errorf("模型 %s 未注册", fixture.Model)
+	}
+
+	for i, values := range fixture.Records {
+		record := reflect.New(modelType)
+		if err := assignValues(tx, record.Interface(), values); err != nil {
+			return fmt.Errorf("第 %d 条记录: %w", i+1, err)
+		}
+		if err := upsert(tx, record.Interface(), fixture.Keys, fixture.Updates); err != nil {
+			return fmt.Errorf("第 %d 条记录: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// assignValues 按字段名或列名给模型赋值
+func assignValues(tx *gorm.DB, record interface{}, values map[string]interface{}) error {
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(record); err != nil {
+		return fmt.Errorf("解析模型失败: %w", err)
+	}
+
+	rv := reflect.ValueOf(record).Elem()
+	for key, value := range values {
+		field := stmt.Schema.LookUpField(key)
+		if field == nil {
+			return fmt.Errorf("模型 %s 不存在字段 %s", stmt.Schema.Name, key)
+		}
+		if err := field.Set(tx.Statement.Context, rv, value); err != nil {
+			return fmt.Errorf("设置字段 %s 失败: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// fixtureFiles 列出目录下的数据文件
+func fixtureFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取数据文件目录失败: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".yaml", ".yml", ".json":
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
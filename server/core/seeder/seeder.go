@@ -0,0 +1,131 @@
+package seeder
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Seeder 数据填充定义
+type Seeder struct {
+	Name  string                  // 名称，唯一
+	Order int                     // 执行顺序，越小越先执行
+	Envs  []string                // 适用环境(dev/test/prod)，为空表示全部环境
+	Run   func(tx *gorm.DB) error // 填充逻辑，必须是幂等的
+}
+
+// supports 判断是否适用于指定环境
+func (s *Seeder) supports(env string) bool {
+	if len(s.Envs) == 0 {
+		return true
+	}
+	env = normalizeEnv(env)
+	for _, e := range s.Envs {
+		if normalizeEnv(e) == env {
+			return true
+		}
+	}
+	return false
+}
+
+// 全局填充注册表
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]*Seeder)
+)
+
+// Register 注册填充，通常在 init 函数中调用
+func Register(seeders ...*Seeder) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	for _, s := range seeders {
+		if s == nil {
+			continue
+		}
+		if s.Name == "" {
+			panic("填充名称不能为空")
+		}
+		if _, exists := registry[s.Name]; exists {
+			panic(fmt.Sprintf("填充名称重复: %s", s.Name))
+		}
+		registry[s.Name] = s
+	}
+}
+
+// Registered 获取所有已注册的填充，按顺序和名称排序
+func Registered() []*Seeder {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	list := make([]*Seeder, 0, len(registry))
+	for _, s := range registry {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Order != list[j].Order {
+			return list[i].Order < list[j].Order
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// Options 执行选项
+type Options struct {
+	Env          string                                   // 运行环境，为空表示 dev
+	Only         []string                                 // 只执行指定名称的填充
+	FixturesPath string                                   // 数据文件目录，为空表示不加载
+	Logf         func(format string, args ...interface{}) // 日志输出
+}
+
+// Run 按顺序执行适用于当前环境的填充，每个填充在独立事务中执行
+func Run(ctx context.Context, db *gorm.DB, opts Options) error {
+	if opts.Logf == nil {
+		opts.Logf = func(format string, args ...interface{}) { fmt.Printf(format+"\n", args...) }
+	}
+
+	only := make(map[string]bool, len(opts.Only))
+	for _, name := range opts.Only {
+		only[name] = true
+	}
+
+	db = db.WithContext(ctx)
+	for _, s := range Registered() {
+		if len(only) > 0 && !only[s.Name] {
+			continue
+		}
+		if !s.supports(opts.Env) {
+			continue
+		}
+
+		start := time.Now()
+		if err := db.Transaction(s.Run); err != nil {
+			return fmt.Errorf("执行填充 %s 失败: %w", s.Name, err)
+		}
+		opts.Logf("已填充: %s (%s)", s.Name, time.Since(start).Round(time.Millisecond))
+	}
+
+	if opts.FixturesPath != "" && len(only) == 0 {
+		if err := LoadFixtures(db, opts.FixturesPath, opts.Env, opts.Logf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// normalizeEnv 统一环境名称
+func normalizeEnv(env string) string {
+	switch env {
+	case "", "development", "develop":
+		return "dev"
+	case "production":
+		return "prod"
+	default:
+		return env
+	}
+}
@@ -0,0 +1,110 @@
+package seeder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testTag 测试模型
+type testTag struct {
+	ID    uint
+	Slug  string `gorm:"uniqueIndex"`
+	Title string
+}
+
+// openTestDB 创建内存数据库
+func openTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&testTag{}); err != nil {
+		t.Fatalf("创建表失败: %v", err)
+	}
+	return db
+}
+
+// TestUpsert 测试按唯一键插入与更新
+func TestUpsert(t *testing.T) {
+	db := openTestDB(t)
+
+	tag := &testTag{Slug: "go", Title: "Go"}
+	if err := Upsert(db, tag, []string{"Slug"}); err != nil {
+		t.Fatalf("插入失败: %v", err)
+	}
+
+	// 只插入模式不覆盖已有数据，并回填主键
+	again := &testTag{Slug: "go", Title: "Golang"}
+	if err := Upsert(db, again, []string{"slug"}); err != nil {
+		t.Fatalf("重复填充失败: %v", err)
+	}
+	if again.ID != tag.ID || again.Title != "Go" {
+		t.Fatalf("只插入模式不应覆盖已有数据: %+v", again)
+	}
+
+	// 指定更新字段时覆盖
+	updated := &testTag{Slug: "go", Title: "Golang"}
+	if err := Upsert(db, updated, []string{"Slug"}, "Title"); err != nil {
+		t.Fatalf("更新失败: %v", err)
+	}
+
+	var count int64
+	db.Model(&testTag{}).Count(&count)
+	var saved testTag
+	db.First(&saved, tag.ID)
+	if count != 1 || saved.Title != "Golang" {
+		t.Fatalf("期望1条记录且标题已更新, 实际: %d %+v", count, saved)
+	}
+}
+
+// TestLoadFixtures 测试数据文件按环境加载
+func TestLoadFixtures(t *testing.T) {
+	db := openTestDB(t)
+	RegisterModel("test_tags", &testTag{})
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "test"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"01_tags.yaml": "model: test_tags\nkeys: [slug]\nrecords:\n  - slug: go\n    title: Go\n",
+		"test/tags.json": `{"model":"test_tags","keys":["slug"],"updates":["title"],` +
+			`"records":[{"slug":"go","title":"Go(test)"},{"slug":"rust","title":"Rust"}]}`,
+		"prod/tags.yaml": "model: test_tags\nkeys: [slug]\nrecords:\n  - slug: prod\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := Run(context.Background(), db, Options{Env: "test", FixturesPath: dir, Logf: t.Logf}); err != nil {
+		t.Fatalf("加载数据文件失败: %v", err)
+	}
+
+	var tags []testTag
+	db.Order("slug").Find(&tags)
+	if len(tags) != 2 || tags[0].Title != "Go(test)" || tags[1].Slug != "rust" {
+		t.Fatalf("数据文件加载结果不正确: %+v", tags)
+	}
+}
+
+// TestSupports 测试环境过滤
+func TestSupports(t *testing.T) {
+	s := &Seeder{Envs: []string{"dev", "test"}}
+	if !s.supports("") || !s.supports("development") || s.supports("production") {
+		t.Fatal("环境过滤不正确")
+	}
+}
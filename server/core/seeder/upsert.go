@@ -0,0 +1,78 @@
+package seeder
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Upsert 按唯一键插入或更新记录
+//
+// keys 为用于查找已有记录的字段（字段名或列名）。
+// updates 为记录已存在时需要覆盖的字段，为空表示只插入不更新，
+// 这样管理员在后台修改过的数据不会在下次填充时被还原。
+// 执行后 record 的主键会被回填。
+func Upsert[T any](tx *gorm.DB, record *T, keys []string, updates ...string) error {
+	return upsert(tx, record, keys, updates)
+}
+
+// upsert Upsert 的反射实现，供数据文件加载使用
+func upsert(tx *gorm.DB, record interface{}, keys []string, updates []string) error {
+	if len(keys) == 0 {
+		return fmt.Errorf("唯一键不能为空")
+	}
+
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(record); err != nil {
+		return fmt.Errorf("解析模型失败: %w", err)
+	}
+	sch := stmt.Schema
+	rv := reflect.ValueOf(record).Elem()
+
+	// 构建查找条件
+	conds := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		field := sch.LookUpField(key)
+		if field == nil {
+			return fmt.Errorf("模型 %s 不存在字段 %s", sch.Name, key)
+		}
+		value, _ := field.ValueOf(tx.Statement.Context, rv)
+		conds[field.DBName] = value
+	}
+
+	// 包含软删除的记录，避免重新创建管理员已删除的数据
+	existing := reflect.New(rv.Type())
+	err := tx.Unscoped().Where(conds).First(existing.Interface()).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return tx.Create(record).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(updates) == 0 {
+		// 只插入模式：用已有记录回填
+		rv.Set(existing.Elem())
+		return nil
+	}
+
+	// 回填主键后按指定字段更新
+	if err := copyPrimaryKeys(tx, sch, existing.Elem(), rv); err != nil {
+		return err
+	}
+	return tx.Model(record).Select(updates).Updates(record).Error
+}
+
+// copyPrimaryKeys 复制主键值
+func copyPrimaryKeys(tx *gorm.DB, sch *schema.Schema, from, to reflect.Value) error {
+	for _, field := range sch.PrimaryFields {
+		value, _ := field.ValueOf(tx.Statement.Context, from)
+		if err := field.Set(tx.Statement.Context, to, value); err != nil {
+			return fmt.Errorf("设置主键失败: %w", err)
+		}
+	}
+	return nil
+}
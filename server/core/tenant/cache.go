@@ -0,0 +1,36 @@
+package tenant
+
+import (
+	"context"
+
+	"github.com/zhoudm1743/go-web/core/cache"
+	"github.com/zhoudm1743/go-web/core/facades"
+)
+
+// CachePrefix 租户缓存键前缀，叠加在缓存配置的 Prefix 之上，例如 go-web:t:acme:key
+func CachePrefix(t *Tenant) string {
+	if t == nil || IsSuper(t) {
+		return ""
+	}
+	return "t:" + t.Code + ":"
+}
+
+// Cache 获取当前租户的缓存，键自动加上租户前缀
+//
+// 超级租户和没有租户的上下文使用全局键空间。
+func Cache(ctx context.Context) cache.Cache {
+	c := facades.Cache()
+	if c == nil {
+		return nil
+	}
+	return cache.WithPrefix(c, CachePrefix(FromContext(ctx)))
+}
+
+// CacheHelper 获取当前租户的缓存助手
+func CacheHelper(ctx context.Context) *cache.CacheHelper {
+	c := Cache(ctx)
+	if c == nil {
+		return nil
+	}
+	return cache.NewCacheHelper(c, facades.Log(), "")
+}
@@ -0,0 +1,58 @@
+package tenant
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// 上下文键
+type (
+	tenantKey struct{}
+	skipKey   struct{}
+)
+
+// WithTenant 在上下文中存放当前租户
+func WithTenant(ctx context.Context, t *Tenant) context.Context {
+	return context.WithValue(ctx, tenantKey{}, t)
+}
+
+// FromContext 获取上下文中的租户，不存在时返回nil
+func FromContext(ctx context.Context) *Tenant {
+	if ctx == nil {
+		return nil
+	}
+	t, _ := ctx.Value(tenantKey{}).(*Tenant)
+	return t
+}
+
+// IDFromContext 获取上下文中的租户ID
+func IDFromContext(ctx context.Context) (uint, bool) {
+	if t := FromContext(ctx); t != nil {
+		return t.ID, true
+	}
+	return 0, false
+}
+
+// SkipScope 返回不按租户过滤的上下文，用于平台运营的跨租户查询和后台任务
+func SkipScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipKey{}, true)
+}
+
+// Skipped 判断上下文是否跳过租户过滤
+func Skipped(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	skip, _ := ctx.Value(skipKey{}).(bool)
+	return skip
+}
+
+// ContextFor 返回以指定编码的租户为当前租户的上下文，用于命令行和后台任务
+func ContextFor(ctx context.Context, db *gorm.DB, code string) (context.Context, error) {
+	t, err := Lookup(db.WithContext(ctx), code)
+	if err != nil {
+		return nil, err
+	}
+	return WithTenant(ctx, t), nil
+}
@@ -0,0 +1,135 @@
+package tenant
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/zhoudm1743/go-web/core/conf"
+	"github.com/zhoudm1743/go-web/core/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrTenantRequired 查询租户模型时上下文中没有租户
+var ErrTenantRequired = errors.New("未指定租户")
+
+// fieldName 租户字段名，模型包含该字段即视为租户模型
+const fieldName = "TenantID"
+
+// Plugin GORM 租户隔离插件
+//
+// 对包含 TenantID 字段的模型，查询、更新、删除时自动追加 tenant_id 条件，
+// 创建时自动填充租户ID。租户来自 db.WithContext(ctx) 传入的上下文，
+// 上下文中没有租户时返回 ErrTenantRequired（配置 AllowUnscoped 时不过滤），
+// 创建时使用超级租户，跨租户查询通过 SkipScope 显式跳过过滤。
+type Plugin struct{}
+
+// Name 插件名称
+func (Plugin) Name() string {
+	return "tenant"
+}
+
+// Initialize 注册回调
+func (p Plugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Create().Before("gorm:create").Register("tenant:create", p.fill); err != nil {
+		return err
+	}
+	if err := callbacks.Query().Before("gorm:query").Register("tenant:query", p.scope); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("gorm:row").Register("tenant:row", p.scope); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("tenant:update", p.scope); err != nil {
+		return err
+	}
+	return callbacks.Delete().Before("gorm:delete").Register("tenant:delete", p.scope)
+}
+
+// tenantField 获取模型的租户字段，非租户模型返回nil
+func tenantField(db *gorm.DB) *schema.Field {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return nil
+	}
+	return db.Statement.Schema.LookUpField(fieldName)
+}
+
+// scope 追加租户过滤条件
+func (Plugin) scope(db *gorm.DB) {
+	field := tenantField(db)
+	if field == nil {
+		return
+	}
+
+	ctx := db.Statement.Context
+	if Skipped(ctx) {
+		return
+	}
+	id, ok := IDFromContext(ctx)
+	if !ok {
+		if !Config().AllowUnscoped {
+			_ = db.AddError(ErrTenantRequired)
+		}
+		return
+	}
+
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: id},
+	}})
+}
+
+// fill 创建时填充租户ID，已赋值的记录保持不变
+func (Plugin) fill(db *gorm.DB) {
+	field := tenantField(db)
+	if field == nil {
+		return
+	}
+
+	id, ok := IDFromContext(db.Statement.Context)
+	if !ok {
+		super, err := Lookup(db, SuperCode())
+		if err != nil {
+			return
+		}
+		id = super.ID
+	}
+
+	ctx, rv := db.Statement.Context, db.Statement.ReflectValue
+	set := func(v reflect.Value) {
+		// 以 map 写入时由调用方提供租户ID
+		if v.Kind() != reflect.Struct {
+			return
+		}
+		if _, zero := field.ValueOf(ctx, v); zero {
+			if err := field.Set(ctx, v, id); err != nil {
+				_ = db.AddError(err)
+			}
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			set(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		set(rv)
+	}
+}
+
+// Setup 应用多租户配置并在所有连接上注册租户插件，可重复调用
+func Setup(cfg conf.TenantConfig, manager *database.Manager) error {
+	Configure(cfg)
+	if manager == nil {
+		return nil
+	}
+	for _, name := range manager.Names() {
+		if err := manager.Get(name).Use(Plugin{}); err != nil && !errors.Is(err, gorm.ErrRegistered) {
+			return fmt.Errorf("连接 %s 注册租户插件失败: %w", name, err)
+		}
+	}
+	return nil
+}
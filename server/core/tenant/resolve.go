@@ -0,0 +1,37 @@
+package tenant
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// 租户识别方式
+const (
+	ResolverHeader    = "header"    // 请求头，值为租户编码
+	ResolverSubdomain = "subdomain" // 子域名，acme.example.com 识别为 acme
+	ResolverJWT       = "jwt"       // 令牌中的租户ID
+)
+
+// CodeFromHeader 从请求头获取租户编码
+func CodeFromHeader(r *http.Request) string {
+	return strings.TrimSpace(r.Header.Get(Config().Header))
+}
+
+// CodeFromSubdomain 从子域名获取租户编码，未配置主域名或不是其子域名时返回空
+func CodeFromSubdomain(r *http.Request) string {
+	domain := strings.ToLower(strings.Trim(Config().Domain, "."))
+	if domain == "" {
+		return ""
+	}
+
+	host := strings.ToLower(r.Host)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	sub, ok := strings.CutSuffix(host, "."+domain)
+	if !ok || sub == "" || strings.Contains(sub, ".") || sub == "www" {
+		return ""
+	}
+	return sub
+}
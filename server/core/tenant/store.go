@@ -0,0 +1,101 @@
+package tenant
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 错误定义
+var (
+	// ErrNotFound 租户不存在
+	ErrNotFound = errors.New("租户不存在")
+	// ErrDisabled 租户已停用
+	ErrDisabled = errors.New("租户已停用")
+)
+
+// cacheTTL 租户信息在进程内的缓存时间
+const cacheTTL = time.Minute
+
+// cacheEntry 缓存项
+type cacheEntry struct {
+	tenant  *Tenant
+	expires time.Time
+}
+
+// 进程内租户缓存，按编码和ID索引
+var (
+	storeMu sync.RWMutex
+	byCode  = make(map[string]cacheEntry)
+	byID    = make(map[uint]cacheEntry)
+)
+
+// Lookup 按编码查找租户
+func Lookup(db *gorm.DB, code string) (*Tenant, error) {
+	storeMu.RLock()
+	entry, ok := byCode[code]
+	storeMu.RUnlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.tenant, nil
+	}
+	return load(db, "code = ?", code)
+}
+
+// Find 按ID查找租户
+func Find(db *gorm.DB, id uint) (*Tenant, error) {
+	storeMu.RLock()
+	entry, ok := byID[id]
+	storeMu.RUnlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.tenant, nil
+	}
+	return load(db, "id = ?", id)
+}
+
+// load 从数据库加载租户并缓存
+//
+// 使用调用方的连接（可能是事务）查询，避免单连接数据库在事务中死锁。
+func load(db *gorm.DB, query string, arg interface{}) (*Tenant, error) {
+	var t Tenant
+	err := db.Session(&gorm.Session{NewDB: true}).Where(query, arg).First(&t).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询租户失败: %w", err)
+	}
+
+	entry := cacheEntry{tenant: &t, expires: time.Now().Add(cacheTTL)}
+	storeMu.Lock()
+	byCode[t.Code] = entry
+	byID[t.ID] = entry
+	storeMu.Unlock()
+	return &t, nil
+}
+
+// Forget 清除租户缓存，修改或停用租户后调用
+func Forget() {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	byCode = make(map[string]cacheEntry)
+	byID = make(map[uint]cacheEntry)
+}
+
+// Create 创建租户
+func Create(db *gorm.DB, t *Tenant) error {
+	if t.Code == "" {
+		return fmt.Errorf("租户编码不能为空")
+	}
+	if t.Status == 0 {
+		t.Status = 1
+	}
+	if err := db.Create(t).Error; err != nil {
+		return fmt.Errorf("创建租户失败: %w", err)
+	}
+	Forget()
+	return nil
+}
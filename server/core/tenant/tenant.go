@@ -0,0 +1,90 @@
+package tenant
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/zhoudm1743/go-web/core/conf"
+	"gorm.io/gorm"
+)
+
+// Tenant 租户模型
+type Tenant struct {
+	ID        uint           `gorm:"primarykey" json:"id"`                                         // 主键ID
+	CreatedAt time.Time      `json:"createdAt"`                                                    // 创建时间
+	UpdatedAt time.Time      `json:"updatedAt"`                                                    // 更新时间
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`                                               // 删除时间
+	Code      string         `gorm:"type:varchar(50);not null;unique;comment:租户编码" json:"code"`    // 租户编码，同时用作子域名
+	Name      string         `gorm:"type:varchar(100);not null;comment:租户名称" json:"name"`          // 租户名称
+	Status    uint           `gorm:"type:tinyint(1);default:1;comment:状态 1:启用 2:禁用" json:"status"` // 状态
+	Remark    string         `gorm:"type:varchar(255);comment:备注" json:"remark"`                   // 备注
+}
+
+// TableName 指定表名
+func (Tenant) TableName() string {
+	return "tenants"
+}
+
+// Active 租户是否启用
+func (t *Tenant) Active() bool {
+	return t.Status == 1
+}
+
+// Domain casbin 策略中使用的域
+func (t *Tenant) Domain() string {
+	return strconv.FormatUint(uint64(t.ID), 10)
+}
+
+// Model 租户隔离模型，嵌入后查询自动按当前租户过滤、创建时自动填充租户ID
+//
+// 需要联合唯一索引的模型可以直接声明 TenantID 字段，效果相同。
+type Model struct {
+	TenantID uint `gorm:"index;not null;default:0;comment:租户ID" json:"tenantId"` // 租户ID
+}
+
+// 当前多租户配置
+var (
+	configMu sync.RWMutex
+	config   = conf.TenantConfig{
+		Resolvers:   []string{ResolverHeader, ResolverSubdomain, ResolverJWT},
+		Header:      "X-Tenant",
+		SuperTenant: "platform",
+	}
+)
+
+// Configure 设置多租户配置
+func Configure(cfg conf.TenantConfig) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	if cfg.Header == "" {
+		cfg.Header = "X-Tenant"
+	}
+	if cfg.SuperTenant == "" {
+		cfg.SuperTenant = "platform"
+	}
+	config = cfg
+}
+
+// Config 获取多租户配置
+func Config() conf.TenantConfig {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return config
+}
+
+// Enabled 是否启用多租户
+func Enabled() bool {
+	return Config().Enabled
+}
+
+// SuperCode 超级租户编码
+func SuperCode() string {
+	return Config().SuperTenant
+}
+
+// IsSuper 判断是否为超级租户
+func IsSuper(t *Tenant) bool {
+	return t != nil && t.Code == SuperCode()
+}
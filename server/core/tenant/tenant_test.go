@@ -0,0 +1,132 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/zhoudm1743/go-web/core/conf"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// post 测试用租户模型
+type post struct {
+	ID    uint
+	Title string
+	Model
+}
+
+// openTestDB 创建注册了租户插件的内存数据库
+func openTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := db.Use(Plugin{}); err != nil {
+		t.Fatalf("注册插件失败: %v", err)
+	}
+	if err := db.AutoMigrate(&Tenant{}, &post{}); err != nil {
+		t.Fatalf("迁移失败: %v", err)
+	}
+	Forget()
+	t.Cleanup(func() { Configure(conf.TenantConfig{}) })
+	return db
+}
+
+// TestPluginScope 测试按租户过滤和自动填充
+func TestPluginScope(t *testing.T) {
+	db := openTestDB(t)
+
+	super := &Tenant{Code: "platform", Name: "平台"}
+	acme := &Tenant{Code: "acme", Name: "Acme"}
+	for _, tt := range []*Tenant{super, acme} {
+		if err := Create(db, tt); err != nil {
+			t.Fatalf("创建租户失败: %v", err)
+		}
+	}
+
+	ctxAcme := WithTenant(context.Background(), acme)
+	ctxSuper := WithTenant(context.Background(), super)
+
+	if err := db.WithContext(ctxAcme).Create(&[]post{{Title: "a1"}, {Title: "a2"}}).Error; err != nil {
+		t.Fatalf("创建失败: %v", err)
+	}
+	if err := db.WithContext(ctxSuper).Create(&post{Title: "p1"}).Error; err != nil {
+		t.Fatalf("创建失败: %v", err)
+	}
+	// 没有租户时归属超级租户
+	if err := db.Create(&post{Title: "p2"}).Error; err != nil {
+		t.Fatalf("创建失败: %v", err)
+	}
+
+	var posts []post
+	db.WithContext(ctxAcme).Order("id").Find(&posts)
+	if len(posts) != 2 || posts[0].TenantID != acme.ID {
+		t.Fatalf("应只查询到当前租户的数据, 实际: %+v", posts)
+	}
+
+	var count int64
+	db.WithContext(ctxSuper).Model(&post{}).Count(&count)
+	if count != 2 {
+		t.Fatalf("超级租户应有2条数据, 实际: %d", count)
+	}
+	db.WithContext(SkipScope(ctxAcme)).Model(&post{}).Count(&count)
+	if count != 4 {
+		t.Fatalf("跳过过滤时应查询到全部数据, 实际: %d", count)
+	}
+
+	// 不能更新和删除其他租户的数据
+	res := db.WithContext(ctxAcme).Model(&post{}).Where("title = ?", "p1").Update("title", "hacked")
+	if res.Error != nil || res.RowsAffected != 0 {
+		t.Fatalf("不应更新其他租户的数据: %v, %d", res.Error, res.RowsAffected)
+	}
+	res = db.WithContext(ctxAcme).Where("1 = 1").Delete(&post{})
+	if res.Error != nil || res.RowsAffected != 2 {
+		t.Fatalf("应只删除当前租户的数据: %v, %d", res.Error, res.RowsAffected)
+	}
+
+	// 默认必须指定租户或显式跳过过滤
+	if err := db.Find(&posts).Error; !errors.Is(err, ErrTenantRequired) {
+		t.Fatalf("期望 ErrTenantRequired, 实际: %v", err)
+	}
+	if err := db.Model(&post{}).Where("1 = 1").Update("title", "x").Error; !errors.Is(err, ErrTenantRequired) {
+		t.Fatalf("更新时期望 ErrTenantRequired, 实际: %v", err)
+	}
+	if err := db.WithContext(SkipScope(context.Background())).Find(&posts).Error; err != nil || len(posts) != 2 {
+		t.Fatalf("跳过过滤时应查询到全部数据: %d, %v", len(posts), err)
+	}
+	Configure(conf.TenantConfig{AllowUnscoped: true})
+	if err := db.Find(&posts).Error; err != nil || len(posts) != 2 {
+		t.Fatalf("AllowUnscoped 时不应过滤: %d, %v", len(posts), err)
+	}
+}
+
+// TestCodeFromSubdomain 测试子域名识别
+func TestCodeFromSubdomain(t *testing.T) {
+	Configure(conf.TenantConfig{Domain: "example.com"})
+	t.Cleanup(func() { Configure(conf.TenantConfig{}) })
+
+	cases := map[string]string{
+		"acme.example.com":      "acme",
+		"ACME.example.com:8080": "acme",
+		"example.com":           "",
+		"www.example.com":       "",
+		"a.b.example.com":       "",
+		"acme.other.com":        "",
+	}
+	for host, want := range cases {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Host = host
+		if got := CodeFromSubdomain(r); got != want {
+			t.Errorf("%s: 期望 %q, 实际 %q", host, want, got)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package trash
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhoudm1743/go-web/core/facades"
+	"github.com/zhoudm1743/go-web/core/repository"
+	"github.com/zhoudm1743/go-web/core/response"
+	"gorm.io/gorm"
+)
+
+// Handler 资源的回收站接口：已删除列表、恢复和永久删除
+//
+// 查询通过请求的数据库连接执行，多租户时只能操作当前租户的记录。
+type Handler[T any] struct {
+	Name   string            // 资源名称，用于提示信息，例如 "管理员"
+	Fields repository.Fields // 列表允许查询的字段，默认按删除时间倒序
+	// DB 获取数据库连接，为空时使用 facades.DBFrom
+	DB func(ctx *gin.Context) *gorm.DB
+	// Restoring 恢复前的检查，返回的错误作为提示信息，例如父级菜单已删除
+	Restoring func(db *gorm.DB, item *T) error
+}
+
+// db 获取请求的数据库连接
+func (h *Handler[T]) db(ctx *gin.Context) *gorm.DB {
+	if h.DB != nil {
+		return h.DB(ctx)
+	}
+	return facades.DBFrom(ctx)
+}
+
+// fields 回收站列表的查询字段，允许按删除时间过滤和排序
+func (h *Handler[T]) fields() repository.Fields {
+	fields := h.Fields
+	fields.Trashed = true
+	fields.Filter = merge(fields.Filter, repository.Columns("deleted_at"))
+	fields.Sort = merge(fields.Sort, repository.Columns("deleted_at"))
+	fields.DefaultSort = "-deleted_at,-id"
+	return fields
+}
+
+// merge 合并白名单，不修改原白名单
+func merge(a, b map[string]string) map[string]string {
+	m := make(map[string]string, len(a)+len(b))
+	for k, v := range a {
+		m[k] = v
+	}
+	for k, v := range b {
+		m[k] = v
+	}
+	return m
+}
+
+// id 解析路径中的ID
+func (h *Handler[T]) id(ctx *gin.Context) (uint64, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		response.FailWithMsg(ctx, response.ParamsValidError, h.Name+"ID无效")
+		return 0, false
+	}
+	return id, true
+}
+
+// List 获取已删除的记录
+func (h *Handler[T]) List(ctx *gin.Context) {
+	query, err := repository.ParseQuery(ctx, h.fields())
+	if err != nil {
+		response.FailWithMsg(ctx, response.ParamsValidError, err.Error())
+		return
+	}
+	query.Trashed = repository.TrashedOnly
+
+	db := h.db(ctx)
+	page, err := repository.New[T](db).Paginate(query)
+	if err != nil {
+		response.Fail(ctx, response.SystemError)
+		return
+	}
+
+	list, err := entries(db, page.List)
+	if err != nil {
+		response.Fail(ctx, response.SystemError)
+		return
+	}
+	response.OkWithData(ctx, &repository.Page[map[string]interface{}]{
+		List:       list,
+		Total:      page.Total,
+		Page:       page.Page,
+		PageSize:   page.PageSize,
+		NextCursor: page.NextCursor,
+		HasMore:    page.HasMore,
+	})
+}
+
+// entries 将记录转换为带 deletedAt 的对象，模型的删除时间通常不输出到JSON
+func entries[T any](db *gorm.DB, items []T) ([]map[string]interface{}, error) {
+	var model T
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(&model); err != nil {
+		return nil, err
+	}
+	field := stmt.Schema.LookUpField("deleted_at")
+
+	list := make([]map[string]interface{}, 0, len(items))
+	for i := range items {
+		data, err := json.Marshal(&items[i])
+		if err != nil {
+			return nil, err
+		}
+		entry := make(map[string]interface{})
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, err
+		}
+		if field != nil {
+			entry["deletedAt"], _ = field.ValueOf(db.Statement.Context, reflect.ValueOf(&items[i]).Elem())
+		}
+		list = append(list, entry)
+	}
+	return list, nil
+}
+
+// Restore 恢复已删除的记录
+func (h *Handler[T]) Restore(ctx *gin.Context) {
+	id, ok := h.id(ctx)
+	if !ok {
+		return
+	}
+
+	db := h.db(ctx)
+	repo := repository.New[T](db)
+	item, err := repo.FirstTrashed(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.FailWithMsg(ctx, response.Failed, h.Name+"不在回收站中")
+			return
+		}
+		response.Fail(ctx, response.SystemError)
+		return
+	}
+
+	if h.Restoring != nil {
+		if err := h.Restoring(db, item); err != nil {
+			response.FailWithMsg(ctx, response.Failed, err.Error())
+			return
+		}
+	}
+
+	if _, err := repo.Restore(id); err != nil {
+		response.Fail(ctx, response.SystemError)
+		return
+	}
+
+	response.OkWithMsg(ctx, "恢复成功")
+}
+
+// ForceDelete 永久删除回收站中的记录
+func (h *Handler[T]) ForceDelete(ctx *gin.Context) {
+	id, ok := h.id(ctx)
+	if !ok {
+		return
+	}
+
+	n, err := repository.New[T](h.db(ctx)).ForceDelete(id)
+	if err != nil {
+		response.Fail(ctx, response.SystemError)
+		return
+	}
+	if n == 0 {
+		response.FailWithMsg(ctx, response.Failed, h.Name+"不在回收站中")
+		return
+	}
+
+	response.OkWithMsg(ctx, "永久删除成功")
+}
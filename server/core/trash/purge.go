@@ -0,0 +1,112 @@
+package trash
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/zhoudm1743/go-web/core/backup"
+	"github.com/zhoudm1743/go-web/core/conf"
+	"github.com/zhoudm1743/go-web/core/log"
+	"github.com/zhoudm1743/go-web/core/tenant"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Result 一次清理的结果
+type Result struct {
+	Before time.Time        // 清理在该时间之前删除的记录
+	DryRun bool             // 只统计不删除
+	Tables []string         // 检查的表，按模型顺序排列
+	Counts map[string]int64 // 每张表清理的记录数
+}
+
+// Total 清理的记录总数
+func (r *Result) Total() int64 {
+	var total int64
+	for _, n := range r.Counts {
+		total += n
+	}
+	return total
+}
+
+// Purge 永久删除在 before 之前软删除的记录，不区分租户
+//
+// 只处理包含 deleted_at 字段的模型，按模型顺序逆序删除，先删除引用方。
+// db 返回模型所属的数据库连接，例如 facades.DBFor；dryRun 为 true 时只统计不删除。
+func Purge(ctx context.Context, models []interface{}, db func(model interface{}) *gorm.DB, before time.Time, dryRun bool) (*Result, error) {
+	result := &Result{Before: before, DryRun: dryRun, Counts: make(map[string]int64)}
+	ctx = tenant.SkipScope(ctx)
+
+	for i := len(models) - 1; i >= 0; i-- {
+		model := models[i]
+		conn := db(model)
+		if conn == nil {
+			return result, fmt.Errorf("模型 %T 的数据库连接未配置", model)
+		}
+		conn = conn.WithContext(ctx)
+
+		stmt := &gorm.Statement{DB: conn}
+		if err := stmt.Parse(model); err != nil {
+			return result, fmt.Errorf("解析模型 %T 失败: %w", model, err)
+		}
+		field := stmt.Schema.LookUpField("deleted_at")
+		if field == nil {
+			continue
+		}
+
+		query := conn.Unscoped().Model(model).
+			Where(clause.Lt{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: before})
+		var count int64
+		if dryRun {
+			if err := query.Count(&count).Error; err != nil {
+				return result, fmt.Errorf("统计 %s 失败: %w", stmt.Schema.Table, err)
+			}
+		} else {
+			tx := query.Delete(model)
+			if tx.Error != nil {
+				return result, fmt.Errorf("清理 %s 失败: %w", stmt.Schema.Table, tx.Error)
+			}
+			count = tx.RowsAffected
+		}
+
+		result.Tables = append([]string{stmt.Schema.Table}, result.Tables...)
+		result.Counts[stmt.Schema.Table] = count
+	}
+	return result, nil
+}
+
+// PurgeRegistered 按保留期清理通过 backup.Register 注册的模型
+func PurgeRegistered(ctx context.Context, db func(model interface{}) *gorm.DB, retention time.Duration, dryRun bool) (*Result, error) {
+	if retention < 0 {
+		return nil, fmt.Errorf("保留期不能为负数: %s", retention)
+	}
+	return Purge(ctx, backup.Registered(), db, time.Now().Add(-retention), dryRun)
+}
+
+// Schedule 按配置定时清理回收站，ctx 取消时退出
+func Schedule(ctx context.Context, cfg conf.TrashConfig, db func(model interface{}) *gorm.DB, l log.Logger) {
+	if cfg.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+	l.Infof("回收站定时清理已启动，间隔: %s，保留期: %s", cfg.Interval, cfg.Retention)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			result, err := PurgeRegistered(ctx, db, cfg.Retention, false)
+			if err != nil {
+				l.Errorf("回收站清理失败: %v", err)
+				continue
+			}
+			if total := result.Total(); total > 0 {
+				l.Infof("回收站清理完成: 永久删除 %d 条 %s 之前删除的记录", total, result.Before.Format(time.DateTime))
+			}
+		}
+	}
+}
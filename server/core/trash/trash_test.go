@@ -0,0 +1,154 @@
+package trash
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// trashNote 测试模型
+type trashNote struct {
+	ID        uint           `json:"id"`
+	Title     string         `json:"title"`
+	Locked    bool           `json:"-"`
+	DeletedAt gorm.DeletedAt `json:"-"`
+}
+
+// trashLog 测试模型：不支持软删除
+type trashLog struct {
+	ID      uint
+	Message string
+}
+
+// openTestDB 创建测试数据库，写入3条笔记并删除后两条
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&trashNote{}, &trashLog{}); err != nil {
+		t.Fatalf("创建表失败: %v", err)
+	}
+
+	notes := []trashNote{{Title: "a"}, {Title: "b"}, {Title: "c", Locked: true}}
+	if err := db.Create(&notes).Error; err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+	db.Create(&trashLog{Message: "log"})
+	if err := db.Delete(&trashNote{}, []uint{2, 3}).Error; err != nil {
+		t.Fatalf("删除失败: %v", err)
+	}
+	return db
+}
+
+// TestPurge 测试按删除时间清理
+func TestPurge(t *testing.T) {
+	db := openTestDB(t)
+	models := []interface{}{&trashLog{}, &trashNote{}}
+	resolve := func(interface{}) *gorm.DB { return db }
+
+	result, err := Purge(context.Background(), models, resolve, time.Now().Add(time.Second), true)
+	if err != nil {
+		t.Fatalf("统计失败: %v", err)
+	}
+	if result.Total() != 2 || len(result.Tables) != 1 || result.Tables[0] != "trash_notes" {
+		t.Fatalf("统计结果不正确: %+v", result)
+	}
+
+	if result, _ := Purge(context.Background(), models, resolve, time.Now().Add(-time.Hour), false); result.Total() != 0 {
+		t.Fatalf("保留期内的记录不应被清理: %+v", result)
+	}
+	if result, err := Purge(context.Background(), models, resolve, time.Now().Add(time.Second), false); err != nil || result.Total() != 2 {
+		t.Fatalf("清理失败: %+v, %v", result, err)
+	}
+
+	var count int64
+	db.Unscoped().Model(&trashNote{}).Count(&count)
+	if count != 1 {
+		t.Fatalf("期望剩余1条笔记, 实际 %d", count)
+	}
+	db.Model(&trashLog{}).Count(&count)
+	if count != 1 {
+		t.Fatal("不支持软删除的表不应被清理")
+	}
+}
+
+// TestHandler 测试回收站接口
+func TestHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := openTestDB(t)
+	h := &Handler[trashNote]{
+		Name: "笔记",
+		DB:   func(*gin.Context) *gorm.DB { return db },
+		Restoring: func(db *gorm.DB, note *trashNote) error {
+			if note.Locked {
+				return errors.New("笔记已锁定")
+			}
+			return nil
+		},
+	}
+	r := gin.New()
+	r.GET("/notes/trash", h.List)
+	r.PUT("/note/:id/restore", h.Restore)
+	r.DELETE("/note/:id/force", h.ForceDelete)
+
+	call := func(method, path string) (int, string, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		var body struct {
+			Code int                    `json:"code"`
+			Msg  string                 `json:"message"`
+			Data map[string]interface{} `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("%s %s 响应无效: %d %s", method, path, w.Code, w.Body.String())
+		}
+		return body.Code, body.Msg, body.Data
+	}
+
+	// 只列出已删除的记录，按删除时间倒序并带删除时间
+	code, _, data := call(http.MethodGet, "/notes/trash?keyword=a")
+	if code != 0 || data["total"].(float64) != 2 {
+		t.Fatalf("回收站列表不正确: %d %v", code, data)
+	}
+	first := data["list"].([]interface{})[0].(map[string]interface{})
+	if first["id"].(float64) != 3 || first["deletedAt"] == nil {
+		t.Fatalf("回收站记录不正确: %v", first)
+	}
+
+	if code, _, _ := call(http.MethodPut, "/note/1/restore"); code == 0 {
+		t.Fatal("未删除的记录不应能恢复")
+	}
+	if code, msg, _ := call(http.MethodPut, "/note/3/restore"); code == 0 || msg != "笔记已锁定" {
+		t.Fatalf("恢复检查未生效: %d %s", code, msg)
+	}
+	if code, _, _ := call(http.MethodPut, "/note/2/restore"); code != 0 {
+		t.Fatal("恢复失败")
+	}
+	if code, _, _ := call(http.MethodDelete, "/note/2/force"); code == 0 {
+		t.Fatal("已恢复的记录不应能永久删除")
+	}
+	if code, _, _ := call(http.MethodDelete, "/note/3/force"); code != 0 {
+		t.Fatal("永久删除失败")
+	}
+
+	var count int64
+	db.Unscoped().Model(&trashNote{}).Count(&count)
+	if count != 2 {
+		t.Fatalf("期望剩余2条笔记, 实际 %d", count)
+	}
+}
@@ -1,12 +1,14 @@
 package utils
 
 import (
+	"context"
 	"sync"
 
 	"github.com/casbin/casbin/v2"
 	"github.com/casbin/casbin/v2/model"
 	gormadapter "github.com/casbin/gorm-adapter/v3"
 	"github.com/zhoudm1743/go-web/core/facades"
+	"github.com/zhoudm1743/go-web/core/tenant"
 	"gorm.io/gorm"
 )
 
@@ -30,22 +32,22 @@ func Casbin() *casbin.Enforcer {
 			panic("初始化casbin adapter失败: " + err.Error())
 		}
 
-		// 从字符串初始化模型
+		// 从字符串初始化模型，使用带域的RBAC模型按租户隔离策略，域为 * 的策略对所有租户生效
 		m := `
 		[request_definition]
-		r = sub, obj, act
+		r = sub, dom, obj, act
 		
 		[policy_definition]
-		p = sub, obj, act
+		p = sub, dom, obj, act
 		
 		[role_definition]
-		g = _, _
+		g = _, _, _
 		
 		[policy_effect]
 		e = some(where (p.eft == allow))
 		
 		[matchers]
-		m = g(r.sub, p.sub) && keyMatch2(r.obj, p.obj) && (r.act == p.act || p.act == "*")
+		m = g(r.sub, p.sub, r.dom) && (r.dom == p.dom || p.dom == "*") && keyMatch2(r.obj, p.obj) && (r.act == p.act || p.act == "*")
 		`
 
 		// 创建enforcer
@@ -71,6 +73,14 @@ func Casbin() *casbin.Enforcer {
 	return casbinEnforcer
 }
 
+// CasbinDomain 获取上下文中租户对应的策略域，没有租户时返回 *
+func CasbinDomain(ctx context.Context) string {
+	if t := tenant.FromContext(ctx); t != nil {
+		return t.Domain()
+	}
+	return "*"
+}
+
 // InitCasbinTables 初始化Casbin数据表和基本策略
 func InitCasbinTables(db *gorm.DB) error {
 	// 创建casbin表
@@ -80,23 +90,36 @@ func InitCasbinTables(db *gorm.DB) error {
 		return err
 	}
 
+	// 基础策略属于超级租户
+	domain := "*"
+	if db.Migrator().HasTable(&tenant.Tenant{}) {
+		if super, err := tenant.Lookup(db, tenant.SuperCode()); err == nil {
+			domain = super.Domain()
+		}
+	}
+
+	// 升级不带域的旧策略(sub, obj, act)，租户表尚未迁移时归入所有域
+	if err := db.Exec("UPDATE casbin_rule SET v3 = v2, v2 = v1, v1 = ? WHERE ptype = 'p' AND (v3 = '' OR v3 IS NULL)", domain).Error; err != nil {
+		return err
+	}
+
 	// 获取enforcer
 	enforcer := Casbin()
 
 	// 创建基础角色和权限
 	// 1. 超级管理员角色拥有所有权限
-	_, err = enforcer.AddPolicy("1", "/*", "*") // roleID=1 为超级管理员
+	_, err = enforcer.AddPolicy("1", domain, "/*", "*") // roleID=1 为超级管理员
 	if err != nil {
 		return err
 	}
 
 	// 2. 普通用户只能访问部分API
-	_, err = enforcer.AddPolicy("2", "/api/user/info", "GET") // roleID=2 为普通用户
+	_, err = enforcer.AddPolicy("2", domain, "/api/user/info", "GET") // roleID=2 为普通用户
 	if err != nil {
 		return err
 	}
 
-	_, err = enforcer.AddPolicy("2", "/api/user/changePassword", "PUT")
+	_, err = enforcer.AddPolicy("2", domain, "/api/user/changePassword", "PUT")
 	if err != nil {
 		return err
 	}
@@ -14,6 +14,7 @@ type CustomClaims struct {
 	UserID   int    `json:"userId"`
 	Username string `json:"username"`
 	RoleID   int    `json:"roleId"`
+	TenantID uint   `json:"tenantId,omitempty"` // 租户ID，未启用多租户时为超级租户
 	jwt.RegisteredClaims
 }
 
@@ -28,6 +29,11 @@ func GetJWTSecret() []byte {
 
 // GenerateToken 生成JWT
 func GenerateToken(userId int, username string, roleId int) (string, error) {
+	return GenerateTenantToken(0, userId, username, roleId)
+}
+
+// GenerateTenantToken 生成带租户ID的JWT
+func GenerateTenantToken(tenantId uint, userId int, username string, roleId int) (string, error) {
 	// 从配置获取过期时间，默认7天
 	expiresDays := 7
 	if value := facades.Config().Get("jwt.token_expire_days"); value != nil {
@@ -41,6 +47,7 @@ func GenerateToken(userId int, username string, roleId int) (string, error) {
 		UserID:   userId,
 		Username: username,
 		RoleID:   roleId,
+		TenantID: tenantId,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 24 * time.Duration(expiresDays))),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -10,19 +10,17 @@ import (
 
 func main() {
 	// 解析命令行参数
+	// CLI模式示例: go run . -mode cli migrate:status
 	mode := flag.String("mode", "http", "运行模式: http, cli")
 	flag.Parse()
 
 	// 初始化应用
-	app, err := bootstrap.InitializeApp()
+	app, err := bootstrap.InitializeApp(*mode, flag.Args())
 	if err != nil {
 		fmt.Printf("初始化应用失败: %v\n", err)
 		os.Exit(1)
 	}
 
-	// 设置应用模式
-	app.SetMode(*mode)
-
 	// 运行应用
 	if err := app.Run(); err != nil {
 		fmt.Printf("运行应用失败: %v\n", err)
@@ -24,6 +24,14 @@ type Config struct {
 	HasDelete     bool // 是否有删除
 	HasDetail     bool // 是否有详情
 	HasPagination bool // 是否分页
+	HasTrash      bool // 是否生成回收站（已删除列表、恢复、永久删除），需同时开启删除
+	HasCache      bool // 详情接口是否使用模型查询缓存，需同时开启详情
+
+	// 迁移
+	MigrationVersion string // 迁移版本号，为空时使用生成时间
+
+	// 数据库
+	BusinessDB string // 业务数据库连接名称，为空表示默认连接
 
 	// 字段配置
 	Fields []*Field
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"text/template"
 )
@@ -15,11 +16,17 @@ func (g *Generator) generateController() error {
 	const controllerTemplate = `package controllers
 
 import (
+	{{if .HasUpdate}}"errors"{{end}}
 	"github.com/gin-gonic/gin"
 	"github.com/zhoudm1743/go-web/apps/{{.PackageName}}/dto"
 	"github.com/zhoudm1743/go-web/apps/{{.PackageName}}/models"
+	{{if .HasUpdate}}"github.com/zhoudm1743/go-web/core/database"{{end}}
+	{{if .HasCache}}"github.com/zhoudm1743/go-web/core/dbcache"{{end}}
 	"github.com/zhoudm1743/go-web/core/facades"
+	"github.com/zhoudm1743/go-web/core/repository"
 	"github.com/zhoudm1743/go-web/core/response"
+	{{if .HasTrash}}"github.com/zhoudm1743/go-web/core/trash"{{end}}
+	{{if or (and .HasList .JoinFields) (and .HasTrash .BusinessDB)}}"gorm.io/gorm"{{end}}
 	"strconv"
 )
 
@@ -31,76 +38,68 @@ func New{{.StructName}}Controller() *{{.StructName}}Controller {
 	return &{{.StructName}}Controller{}
 }
 {{if .HasList}}
+// {{.VarName}}QueryFields {{.Description}}列表允许查询的字段
+var {{.VarName}}QueryFields = repository.Fields{
+	Filter:      repository.Columns({{.FilterColumns}}),
+	Sort:        repository.Columns({{.SortColumns}}),
+	Search:      []string{ {{.SearchColumns}} },
+	DefaultSort: "-id",
+}
+
 // Get{{.PluralName}} 获取{{.Description}}列表
 func (c *{{.StructName}}Controller) Get{{.PluralName}}(ctx *gin.Context) {
+	query, err := repository.ParseQuery(ctx, {{.VarName}}QueryFields)
+	if err != nil {
+		response.FailWithMsg(ctx, response.ParamsValidError, err.Error())
+		return
+	}
+
+	db := {{if .BusinessDB}}facades.DB("{{.BusinessDB}}").WithContext(ctx.Request.Context()){{else}}facades.DBFrom(ctx){{end}}
+	repo := repository.New[models.{{.StructName}}](db)
+	{{if .HasRelations}}
 	var params dto.{{.StructName}}QueryParams
 	if err := ctx.ShouldBindQuery(&params); err != nil {
 		response.FailWithMsg(ctx, response.ParamsValidError, "请求参数有误")
 		return
 	}
 
-	// 默认分页参数
-	if params.Page <= 0 {
-		params.Page = 1
-	}
-	if params.PageSize <= 0 {
-		params.PageSize = 10
-	}
-
-	db := facades.DB()
-	var total int64
-	var items []*models.{{.StructName}}
-	
-	query := db.Model(&models.{{.StructName}}{})
-	
-	// 应用查询条件
-	{{range .QueryFields}}
-	if params.{{.FieldName}} != {{.ZeroValue}} {
-		query = query.Where("{{.ColumnName}} = ?", params.{{.FieldName}})
-	}
-	{{end}}
-
-	{{if .HasRelations}}
 	// 应用关联表查询
 	{{range .JoinFields}}
 	if params.{{.RelatedFieldName}}Filter != "" {
-		query = query.Joins("JOIN {{.JoinTable}} ON {{.JoinCondition}}").
-			Where("{{.FilterCondition}} = ?", params.{{.RelatedFieldName}}Filter)
+		filter := params.{{.RelatedFieldName}}Filter
+		repo = repo.Scopes(func(tx *gorm.DB) *gorm.DB {
+			return tx.Joins("JOIN {{.JoinTable}} ON {{.JoinCondition}}").
+				Where("{{.FilterCondition}} = ?", filter)
+		})
 	}
 	{{end}}
-	
+
 	// 应用预加载
 	if params.WithRelations {
-		query = (&models.{{.StructName}}{}).LoadRelations(query)
+		repo = repo.Scopes((&models.{{.StructName}}{}).LoadRelations)
 	} else {
 		// 默认预加载关键关联
 		{{range .PreloadFields}}
-		query = query.Preload("{{.FieldName}}")
+		repo = repo.Preload("{{.FieldName}}")
 		{{end}}
 	}
 	{{end}}
 
-	// 获取总数
-	if err := query.Count(&total).Error; err != nil {
-		response.Fail(ctx, response.SystemError)
-		return
-	}
-
 	// 分页查询
-	if err := query.Offset((params.Page - 1) * params.PageSize).
-		Limit(params.PageSize).
-		Find(&items).Error; err != nil {
+	page, err := repo.Paginate(query)
+	if err != nil {
 		response.Fail(ctx, response.SystemError)
 		return
 	}
 
 	// 构造响应数据
 	result := &dto.{{.StructName}}ListResponse{
-		Total: total,
-		List:  make([]*dto.{{.StructName}}Response, len(items)),
+		Total: page.Total,
+		List:  make([]*dto.{{.StructName}}Response, len(page.List)),
 	}
 
-	for i, item := range items {
+	for i := range page.List {
+		item := &page.List[i]
 		resp := &dto.{{.StructName}}Response{
 			ID:        item.ID,
 			CreatedAt: item.CreatedAt.Format("2006-01-02 15:04:05"),
@@ -126,10 +125,10 @@ func (c *{{.StructName}}Controller) Get{{.StructName}}(ctx *gin.Context) {
 		return
 	}
 
-	db := facades.DB()
+	db := {{if .BusinessDB}}facades.DB("{{.BusinessDB}}").WithContext(ctx.Request.Context()){{else}}facades.DBFrom(ctx){{end}}
 	var item models.{{.StructName}}
 	
-	query := db{{range .PreloadFields}}.Preload("{{.FieldName}}"){{end}}
+	query := db{{if .HasCache}}.Scopes(dbcache.Cached(0)){{end}}{{range .PreloadFields}}.Preload("{{.FieldName}}"){{end}}
 	{{if .HasRelations}}
 	// 预加载关联数据
 	withRelations := ctx.Query("withRelations")
@@ -153,6 +152,7 @@ func (c *{{.StructName}}Controller) Get{{.StructName}}(ctx *gin.Context) {
 	// 复制其他字段
 	response.Copy(resp, item)
 
+	response.SetETag(ctx, item.Version)
 	response.OkWithData(ctx, resp)
 }
 {{end}}
@@ -170,7 +170,7 @@ func (c *{{.StructName}}Controller) Create{{.StructName}}(ctx *gin.Context) {
 	item := &models.{{.StructName}}{}
 	response.Copy(item, req)
 
-	db := facades.DB()
+	db := {{if .BusinessDB}}facades.DB("{{.BusinessDB}}").WithContext(ctx.Request.Context()){{else}}facades.DBFrom(ctx){{end}}
 	if err := db.Create(item).Error; err != nil {
 		response.Fail(ctx, response.SystemError)
 		return
@@ -189,13 +189,16 @@ func (c *{{.StructName}}Controller) Update{{.StructName}}(ctx *gin.Context) {
 		return
 	}
 
-	db := facades.DB()
+	db := {{if .BusinessDB}}facades.DB("{{.BusinessDB}}").WithContext(ctx.Request.Context()){{else}}facades.DBFrom(ctx){{end}}
 	var item models.{{.StructName}}
 	if err := db.First(&item, req.ID).Error; err != nil {
 		response.FailWithMsg(ctx, response.Failed, "{{.Description}}不存在")
 		return
 	}
 
+	// 按读取时的版本号更新，期间被他人修改时返回冲突
+	item.Version = response.ExpectVersion(ctx, req.Version, item.Version)
+
 	// 只更新提供的字段
 	updates := map[string]interface{}{}
 
@@ -210,10 +213,15 @@ func (c *{{.StructName}}Controller) Update{{.StructName}}(ctx *gin.Context) {
 	{{end}}
 
 	if err := db.Model(&item).Updates(updates).Error; err != nil {
+		if errors.Is(err, database.ErrVersionConflict) {
+			response.Fail(ctx, response.VersionConflict)
+			return
+		}
 		response.Fail(ctx, response.SystemError)
 		return
 	}
 
+	response.SetETag(ctx, item.Version)
 	response.OkWithMsg(ctx, "更新成功")
 }
 {{end}}
@@ -228,7 +236,7 @@ func (c *{{.StructName}}Controller) Delete{{.StructName}}(ctx *gin.Context) {
 		return
 	}
 
-	db := facades.DB()
+	db := {{if .BusinessDB}}facades.DB("{{.BusinessDB}}").WithContext(ctx.Request.Context()){{else}}facades.DBFrom(ctx){{end}}
 	if err := db.Delete(&models.{{.StructName}}{}, itemID).Error; err != nil {
 		response.Fail(ctx, response.SystemError)
 		return
@@ -237,6 +245,32 @@ func (c *{{.StructName}}Controller) Delete{{.StructName}}(ctx *gin.Context) {
 	response.OkWithMsg(ctx, "删除成功")
 }
 {{end}}
+
+{{if .HasTrash}}
+// {{.VarName}}Trash {{.Description}}回收站
+var {{.VarName}}Trash = &trash.Handler[models.{{.StructName}}]{
+	Name: "{{.Description}}",
+	{{if .HasList}}Fields: {{.VarName}}QueryFields,{{end}}
+	{{if .BusinessDB}}DB: func(ctx *gin.Context) *gorm.DB {
+		return facades.DB("{{.BusinessDB}}").WithContext(ctx.Request.Context())
+	},{{end}}
+}
+
+// GetTrashed{{.PluralName}} 获取已删除的{{.Description}}列表
+func (c *{{.StructName}}Controller) GetTrashed{{.PluralName}}(ctx *gin.Context) {
+	{{.VarName}}Trash.List(ctx)
+}
+
+// Restore{{.StructName}} 恢复已删除的{{.Description}}
+func (c *{{.StructName}}Controller) Restore{{.StructName}}(ctx *gin.Context) {
+	{{.VarName}}Trash.Restore(ctx)
+}
+
+// ForceDelete{{.StructName}} 永久删除回收站中的{{.Description}}
+func (c *{{.StructName}}Controller) ForceDelete{{.StructName}}(ctx *gin.Context) {
+	{{.VarName}}Trash.ForceDelete(ctx)
+}
+{{end}}
 `
 
 	// 准备模板数据
@@ -249,6 +283,10 @@ func (c *{{.StructName}}Controller) Delete{{.StructName}}(ctx *gin.Context) {
 	type TemplateData struct {
 		*Config
 		PluralName    string
+		VarName       string
+		FilterColumns string
+		SortColumns   string
+		SearchColumns string
 		QueryFields   []FieldData
 		UpdateFields  []FieldData
 		HasRelations  bool
@@ -266,6 +304,7 @@ func (c *{{.StructName}}Controller) Delete{{.StructName}}(ctx *gin.Context) {
 	data := TemplateData{
 		Config:        g.Config,
 		PluralName:    ToPlural(g.Config.StructName),
+		VarName:       ToLowerCamel(g.Config.StructName),
 		QueryFields:   make([]FieldData, 0),
 		UpdateFields:  make([]FieldData, 0),
 		HasRelations:  false,
@@ -377,6 +416,29 @@ func (c *{{.StructName}}Controller) Delete{{.StructName}}(ctx *gin.Context) {
 		}
 	}
 
+	// 查询字段白名单
+	filterColumns := []string{`"id"`}
+	sortColumns := []string{`"id"`, `"created_at"`}
+	var searchColumns []string
+	for _, field := range g.Config.Fields {
+		if field.IsRelation || field.IsPrimaryKey || field.ColumnName == "" {
+			continue
+		}
+		quoted := strconv.Quote(field.ColumnName)
+		if field.IsFilterable || field.IsSearchable {
+			filterColumns = append(filterColumns, quoted)
+		}
+		if field.IsSearchable {
+			searchColumns = append(searchColumns, quoted)
+		}
+		if field.IsSortable && field.ColumnName != "created_at" {
+			sortColumns = append(sortColumns, quoted)
+		}
+	}
+	data.FilterColumns = strings.Join(filterColumns, ", ")
+	data.SortColumns = strings.Join(sortColumns, ", ")
+	data.SearchColumns = strings.Join(searchColumns, ", ")
+
 	// 解析模板
 	t, err := template.New("controller").Parse(controllerTemplate)
 	if err != nil {
@@ -27,7 +27,8 @@ type {{.StructName}}CreateRequest struct {
 
 // {{.StructName}}UpdateRequest 更新{{.Description}}请求
 type {{.StructName}}UpdateRequest struct {
-	ID uint ` + "`" + `json:"id" binding:"required"` + "`" + ` // ID
+	ID      uint ` + "`" + `json:"id" binding:"required"` + "`" + ` // ID
+	Version uint ` + "`" + `json:"version"` + "`" + `                // 读取时的版本号，也可通过 If-Match 请求头传入
 {{range .UpdateFields}}
 	{{.FieldName}} {{.FieldType}} ` + "`" + `json:"{{.JsonName}}" {{if .Binding}}binding:"{{.Binding}}"{{end}}` + "`" + `{{if .FieldDesc}} // {{.FieldDesc}}{{end}}
 {{end}}
@@ -53,6 +54,7 @@ type {{.StructName}}Response struct {
 	ID        uint   ` + "`" + `json:"id"` + "`" + `         // ID
 	CreatedAt string ` + "`" + `json:"createdAt"` + "`" + `  // 创建时间
 	UpdatedAt string ` + "`" + `json:"updatedAt"` + "`" + `  // 更新时间
+	Version   uint   ` + "`" + `json:"version"` + "`" + `    // 版本号
 {{range .ResponseFields}}
 	{{.FieldName}} {{.FieldType}} ` + "`" + `json:"{{.JsonName}}"` + "`" + `{{if .FieldDesc}} // {{.FieldDesc}}{{end}}
 {{end}}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"text/template"
 )
@@ -77,6 +78,32 @@ func (g *Generator) generateIndexPage(dir string) error {
 
     <template #content>
       <n-card :bordered="false">
+{{- if .HasTrash}}
+        <n-tabs v-model:value="activeTab" type="line" @update:value="handleTabChange">
+          <n-tab-pane name="list" tab="{{ .Description }}列表">
+            <n-data-table
+              remote
+              :loading="tableLoading"
+              :columns="columns"
+              :data="tableData"
+              :pagination="pagination"
+              @update:page="handlePageChange"
+              @update:page-size="handlePageSizeChange"
+            />
+          </n-tab-pane>
+          <n-tab-pane name="trash" tab="回收站">
+            <n-data-table
+              remote
+              :loading="trashLoading"
+              :columns="trashColumns"
+              :data="trashData"
+              :pagination="trashPagination"
+              @update:page="handleTrashPageChange"
+              @update:page-size="handleTrashPageSizeChange"
+            />
+          </n-tab-pane>
+        </n-tabs>
+{{- else}}
         <n-data-table
           remote
           :loading="tableLoading"
@@ -86,6 +113,7 @@ func (g *Generator) generateIndexPage(dir string) error {
           @update:page="handlePageChange"
           @update:page-size="handlePageSizeChange"
         />
+{{- end}}
       </n-card>
     </template>
 
@@ -105,7 +133,7 @@ import { reactive, ref } from 'vue';
 import { useMessage } from 'naive-ui';
 import { CommonWrapper } from '@/components/common';
 import TableModal from './components/TableModal.vue';
-import { create{{.StructName}}, delete{{.StructName}}, get{{.PluralName}}, update{{.StructName}} } from '@/service/api/{{.ApiFile}}';
+import { {{.ApiImports}} } from '@/service/api/{{.ApiFile}}';
 
 // 表格设置
 const tableLoading = ref(false);
@@ -251,6 +279,120 @@ async function handleModalSubmit(formData) {
     modalLoading.value = false;
   }
 }
+{{- if .HasTrash}}
+
+// 回收站设置
+const activeTab = ref('list');
+const trashLoading = ref(false);
+const trashData = ref([]);
+const trashPagination = reactive({
+  page: 1,
+  pageSize: 10,
+  itemCount: 0,
+  showSizePicker: true,
+  pageSizes: [10, 20, 30, 50],
+});
+
+// 回收站表格列
+const trashColumns = [
+{{.ColumnDefs}}
+  {
+    title: '删除时间',
+    key: 'deletedAt',
+    width: 180,
+  },
+  {
+    title: '操作',
+    key: 'actions',
+    width: 150,
+    fixed: 'right',
+    render(row) {
+      return [
+        <n-button
+          key="restore"
+          type="primary"
+          text
+          size="small"
+          onClick={() => handleRestore(row)}
+        >
+          恢复
+        </n-button>,
+        <n-button
+          key="forceDelete"
+          type="error"
+          text
+          size="small"
+          onClick={() => handleForceDelete(row)}
+        >
+          永久删除
+        </n-button>
+      ];
+    },
+  },
+];
+
+// 加载回收站数据
+async function loadTrashData() {
+  try {
+    trashLoading.value = true;
+    const res = await getTrashed{{.PluralName}}({
+      page: trashPagination.page,
+      pageSize: trashPagination.pageSize
+    });
+
+    trashData.value = res.data.list;
+    trashPagination.itemCount = res.data.total;
+  } catch (error) {
+    message.error('获取回收站数据失败');
+  } finally {
+    trashLoading.value = false;
+  }
+}
+
+// 切换标签页时刷新数据
+function handleTabChange(name: string) {
+  if (name === 'trash') {
+    loadTrashData();
+  } else {
+    loadTableData();
+  }
+}
+
+// 处理回收站页码变化
+function handleTrashPageChange(page: number) {
+  trashPagination.page = page;
+  loadTrashData();
+}
+
+// 处理回收站每页条数变化
+function handleTrashPageSizeChange(pageSize: number) {
+  trashPagination.pageSize = pageSize;
+  trashPagination.page = 1;
+  loadTrashData();
+}
+
+// 处理恢复
+async function handleRestore(row) {
+  try {
+    await restore{{.StructName}}(row.id);
+    message.success('恢复成功');
+    loadTrashData();
+  } catch (error) {
+    message.error('恢复失败');
+  }
+}
+
+// 处理永久删除
+async function handleForceDelete(row) {
+  try {
+    await forceDelete{{.StructName}}(row.id);
+    message.success('永久删除成功');
+    loadTrashData();
+  } catch (error) {
+    message.error('永久删除失败');
+  }
+}
+{{- end}}
 </script>
 `
 
@@ -262,6 +404,8 @@ async function handleModalSubmit(formData) {
 		ApiFile     string
 		PluralName  string
 		ColumnDefs  string
+		ApiImports  string
+		HasTrash    bool
 	}
 
 	// 生成列定义
@@ -287,7 +431,16 @@ async function handleModalSubmit(formData) {
 		ApiFile:     strings.ToLower(g.Config.StructName),
 		PluralName:  ToPlural(g.Config.StructName),
 		ColumnDefs:  columnDefs.String(),
+		HasTrash:    g.Config.HasTrash,
+	}
+
+	// 页面使用的API，按名称排序
+	imports := []string{"create" + data.StructName, "delete" + data.StructName, "get" + data.PluralName, "update" + data.StructName}
+	if data.HasTrash {
+		imports = append(imports, "forceDelete"+data.StructName, "getTrashed"+data.PluralName, "restore"+data.StructName)
 	}
+	sort.Strings(imports)
+	data.ApiImports = strings.Join(imports, ", ")
 
 	// 解析和渲染模板
 	t, err := template.New("indexPage").Parse(indexTemplate)
@@ -545,6 +698,7 @@ export interface {{.StructName}}CreateRequest {
 // {{.Description}}更新请求
 export interface {{.StructName}}UpdateRequest {
   id: number;
+  version?: number;
 {{.UpdateFields}}
 }
 
@@ -553,6 +707,7 @@ export interface {{.StructName}}Response {
   id: number;
   createdAt: string;
   updatedAt: string;
+  version: number;
 {{.ResponseFields}}
 }
 
@@ -604,6 +759,44 @@ export const delete{{.StructName}} = (id: number) => {
     method: 'DELETE',
   });
 };
+{{- if .HasTrash}}
+
+// 回收站中的{{.Description}}
+export interface {{.StructName}}TrashResponse extends {{.StructName}}Response {
+  deletedAt: string;
+}
+
+// 回收站列表响应
+export interface {{.StructName}}TrashListResponse {
+  total: number;
+  list: {{.StructName}}TrashResponse[];
+}
+
+// 获取已删除的{{.Description}}列表
+export const getTrashed{{.PluralName}} = (params: {{.StructName}}QueryParams) => {
+  return http.request<{{.StructName}}TrashListResponse>({
+    url: '/{{.ApiPrefix}}/trash',
+    method: 'GET',
+    params,
+  });
+};
+
+// 恢复已删除的{{.Description}}
+export const restore{{.StructName}} = (id: number) => {
+  return http.request<void>({
+    url: '/{{.ApiPrefix}}/restore/' + id,
+    method: 'PUT',
+  });
+};
+
+// 永久删除回收站中的{{.Description}}
+export const forceDelete{{.StructName}} = (id: number) => {
+  return http.request<void>({
+    url: '/{{.ApiPrefix}}/force/' + id,
+    method: 'DELETE',
+  });
+};
+{{- end}}
 `
 
 	// 准备模板数据
@@ -656,6 +849,7 @@ export const delete{{.StructName}} = (id: number) => {
 		CreateFields   string
 		UpdateFields   string
 		ResponseFields string
+		HasTrash       bool
 	}
 
 	data := TemplateData{
@@ -667,6 +861,7 @@ export const delete{{.StructName}} = (id: number) => {
 		CreateFields:   createFields.String(),
 		UpdateFields:   updateFields.String(),
 		ResponseFields: responseFields.String(),
+		HasTrash:       g.Config.HasTrash,
 	}
 
 	t, err := template.New("apiFile").Parse(apiTemplate)
@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"strings"
 	"unicode"
+
+	"gorm.io/gorm"
 )
 
 // // Config 代码生成器配置
@@ -70,6 +72,13 @@ func (g *Generator) SetRootPath(path string) {
 	g.RootPath = path
 }
 
+// UseDB 指定历史记录使用的数据库连接，例如请求事务
+func (g *Generator) UseDB(db *gorm.DB) {
+	if db != nil {
+		g.History.DB = db
+	}
+}
+
 // InitHistoryDB 初始化历史记录数据库
 func (g *Generator) InitHistoryDB() error {
 	return g.History.Migrate()
@@ -77,11 +86,26 @@ func (g *Generator) InitHistoryDB() error {
 
 // Run 执行代码生成
 func (g *Generator) Run() error {
+	// 回收站依赖删除功能
+	g.Config.HasTrash = g.Config.HasTrash && g.Config.HasDelete
+	// 查询缓存只用于详情接口
+	g.Config.HasCache = g.Config.HasCache && g.Config.HasDetail
+
 	// 生成模型
 	if err := g.generateModel(); err != nil {
 		return err
 	}
 
+	// 生成迁移
+	if err := g.generateMigration(); err != nil {
+		return err
+	}
+
+	// 生成菜单填充
+	if err := g.generateSeeder(); err != nil {
+		return err
+	}
+
 	// 生成DTO
 	if err := g.generateDTO(); err != nil {
 		return err
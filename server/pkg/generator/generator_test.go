@@ -1,6 +1,8 @@
 package generator
 
 import (
+	"go/parser"
+	"go/token"
 	"os"
 	"path/filepath"
 	"strings"
@@ -278,3 +280,152 @@ func validateFileContent(t *testing.T, content, file string, config *Config) {
 func contains(s, substr string) bool {
 	return strings.Contains(s, substr)
 }
+
+// TestUpdateRoutesFile 测试更新路由文件时保留插入位置之后的内容
+func TestUpdateRoutesFile(t *testing.T) {
+	content := strings.Join([]string{
+		"func RegisterRoutes(r *gin.RouterGroup) {",
+		"\t// 初始化控制器",
+		"\tauthController := controllers.NewAuthController()",
+		"",
+		"\tprivateRoutes := r.Group(\"/admin\")",
+		"\t{",
+		"\t\tprivateRoutes.GET(\"/info\", authController.Info)",
+		"\t}",
+		"",
+		"\tcacheRoutes := r.Group(\"/admin/cache\")",
+		"\t{",
+		"\t}",
+		"}",
+	}, "\n")
+
+	routes := updateRoutesFile(content, "Book", "", false)
+	for _, want := range []string{
+		"\t// 初始化控制器\n\tbookController := controllers.NewBookController()\n\tauthController := controllers.NewAuthController()",
+		"privateRoutes.GET(\"/info\", authController.Info)",
+		"privateRoutes.DELETE(\"/book/:id\", bookController.DeleteBook)\n\t}",
+		"\tcacheRoutes := r.Group(\"/admin/cache\")\n\t{\n\t}\n}",
+	} {
+		if !strings.Contains(routes, want) {
+			t.Errorf("路由文件缺少 %q:\n%s", want, routes)
+		}
+	}
+}
+
+// TestTrashGeneration 测试开启回收站时生成的控制器、路由和前端代码
+func TestTrashGeneration(t *testing.T) {
+	root := t.TempDir()
+	g := New(&Config{
+		StructName:  "Book",
+		TableName:   "books",
+		PackageName: "admin",
+		Description: "图书",
+		ApiPrefix:   "book",
+		HasList:     true,
+		HasDelete:   true,
+		HasTrash:    true,
+		Fields: []*Field{
+			{FieldName: "Title", FieldType: "string", ColumnName: "title", FieldDesc: "标题", IsSearchable: true},
+		},
+	})
+	g.SetRootPath(root)
+
+	if err := g.generateController(); err != nil {
+		t.Fatalf("生成控制器失败: %v", err)
+	}
+	if err := g.generateApiFile(); err != nil {
+		t.Fatalf("生成API文件失败: %v", err)
+	}
+	viewsDir := filepath.Join(root, "front-end/src/views/book")
+	if err := os.MkdirAll(viewsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.generateIndexPage(viewsDir); err != nil {
+		t.Fatalf("生成页面失败: %v", err)
+	}
+
+	controllerPath := filepath.Join(root, "server/apps/admin/controllers/book_controller.go")
+	if _, err := parser.ParseFile(token.NewFileSet(), controllerPath, nil, 0); err != nil {
+		t.Fatalf("生成的控制器无法解析: %v", err)
+	}
+
+	routes := updateRoutesFile("func RegisterRoutes(r *gin.RouterGroup) {\n\tprivateRoutes := r.Group(\"/admin\")\n\t{\n\t}\n}", "Book", g.Config.ApiPrefix, true)
+
+	for path, wants := range map[string][]string{
+		controllerPath: {
+			`"github.com/zhoudm1743/go-web/core/trash"`,
+			`var bookTrash = &trash.Handler[models.Book]{`,
+			`Fields: bookQueryFields,`,
+			`func (c *BookController) GetTrashedBooks(ctx *gin.Context)`,
+			`func (c *BookController) RestoreBook(ctx *gin.Context)`,
+			`func (c *BookController) ForceDeleteBook(ctx *gin.Context)`,
+		},
+		filepath.Join(root, "front-end/src/service/api/book.ts"): {
+			`export const getTrashedBooks = `,
+			`url: '/book/restore/' + id,`,
+			`url: '/book/force/' + id,`,
+		},
+		filepath.Join(viewsDir, "index.vue"): {
+			`<n-tab-pane name="trash" tab="回收站">`,
+			`import { createBook, deleteBook, forceDeleteBook, getBooks, getTrashedBooks, restoreBook, updateBook } from`,
+			`onClick={() => handleRestore(row)}`,
+		},
+		"routes": {
+			`privateRoutes.GET("/book/trash", bookController.GetTrashedBooks)`,
+			`privateRoutes.PUT("/book/restore/:id", bookController.RestoreBook)`,
+			`privateRoutes.DELETE("/book/force/:id", bookController.ForceDeleteBook)`,
+		},
+	} {
+		content := routes
+		if path != "routes" {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("读取 %s 失败: %v", path, err)
+			}
+			content = string(data)
+		}
+		for _, want := range wants {
+			if !strings.Contains(content, want) {
+				t.Errorf("%s 缺少 %s", filepath.Base(path), want)
+			}
+		}
+	}
+}
+
+// TestCacheGeneration 测试详情接口的查询缓存选项
+func TestCacheGeneration(t *testing.T) {
+	root := t.TempDir()
+	g := New(&Config{
+		StructName:  "Book",
+		TableName:   "books",
+		PackageName: "admin",
+		Description: "图书",
+		ApiPrefix:   "book",
+		HasDetail:   true,
+		HasCache:    true,
+		Fields: []*Field{
+			{FieldName: "Title", FieldType: "string", ColumnName: "title", FieldDesc: "标题"},
+		},
+	})
+	g.SetRootPath(root)
+
+	if err := g.generateController(); err != nil {
+		t.Fatalf("生成控制器失败: %v", err)
+	}
+	controllerPath := filepath.Join(root, "server/apps/admin/controllers/book_controller.go")
+	if _, err := parser.ParseFile(token.NewFileSet(), controllerPath, nil, 0); err != nil {
+		t.Fatalf("生成的控制器无法解析: %v", err)
+	}
+	data, err := os.ReadFile(controllerPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`"github.com/zhoudm1743/go-web/core/dbcache"`,
+		`query := db.Scopes(dbcache.Cached(0))`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("控制器缺少 %s", want)
+		}
+	}
+}
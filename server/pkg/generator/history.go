@@ -1,6 +1,7 @@
 package generator
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -9,6 +10,7 @@ import (
 	"time"
 
 	"github.com/zhoudm1743/go-web/core/facades"
+	"github.com/zhoudm1743/go-web/core/migration"
 	"gorm.io/gorm"
 )
 
@@ -29,6 +31,7 @@ type HistoryModel struct {
 	MenuID      uint           `gorm:"comment:菜单ID" json:"menuId"`            // 菜单ID
 	Flag        uint8          `gorm:"default:0;comment:标记" json:"flag"`      // 标记 0:未删除 1:已删除
 	BusinessDB  string         `gorm:"comment:业务数据库" json:"businessDb"`       // 业务数据库
+	Migration   string         `gorm:"comment:迁移版本号" json:"migration"`        // 迁移版本号
 }
 
 // TableName 指定表名
@@ -89,7 +92,8 @@ func (h *HistoryManager) Create(config *Config, templates map[string]string) (ui
 		Fields:      string(fieldsJSON),
 		Templates:   string(templatesJSON),
 		Flag:        0,
-		BusinessDB:  "", // 默认使用主数据库
+		BusinessDB:  config.BusinessDB,
+		Migration:   config.MigrationVersion,
 	}
 
 	if h.DB == nil {
@@ -183,10 +187,24 @@ func (h *HistoryManager) RollBack(id uint, deleteFiles, deleteAPI, deleteMenu, d
 
 	// 删除数据库表
 	if deleteTable && record.Table != "" {
-		if err := h.DB.Exec("DROP TABLE IF EXISTS " + record.Table).Error; err != nil {
+		businessDB := h.DB
+		if record.BusinessDB != "" {
+			businessDB = facades.DB(record.BusinessDB)
+		}
+		if businessDB == nil {
+			return fmt.Errorf("业务数据库 %s 未配置", record.BusinessDB)
+		}
+		if err := businessDB.Migrator().DropTable(record.Table); err != nil {
 			fmt.Printf("警告: 删除表 %s 失败: %v\n", record.Table, err)
 		} else {
 			fmt.Printf("已删除表: %s\n", record.Table)
+
+			// 同步删除迁移记录，避免迁移状态与实际表结构不一致
+			if record.Migration != "" {
+				if err := migration.NewMigrator(h.DB).Forget(context.Background(), record.Migration); err != nil {
+					fmt.Printf("警告: 删除迁移记录 %s 失败: %v\n", record.Migration, err)
+				}
+			}
 		}
 	}
 
@@ -0,0 +1,103 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+	"time"
+)
+
+// generateMigration 生成数据库迁移文件
+func (g *Generator) generateMigration() error {
+	// 业务数据库的表已存在，迁移只在默认连接上执行，因此不生成
+	if g.Config.BusinessDB != "" {
+		fmt.Printf("表 %s 位于业务数据库 %s，跳过生成迁移文件\n", g.Config.TableName, g.Config.BusinessDB)
+		return nil
+	}
+
+	// 迁移模板
+	const migrationTemplate = `package migrations
+
+import (
+	"{{.ModuleName}}/apps/{{.PackageName}}/models"
+	"{{.ModuleName}}/core/backup"
+	"{{.ModuleName}}/core/migration"
+	"gorm.io/gorm"
+)
+
+func init() {
+	// 参与逻辑备份
+	backup.Register(&models.{{.StructName}}{})
+
+	migration.Register(&migration.Migration{
+		Version: "{{.Version}}",
+		Name:    "create_{{.TableName}}",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.{{.StructName}}{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.{{.StructName}}{})
+		},
+	})
+}
+`
+
+	// 准备模板数据
+	type TemplateData struct {
+		*Config
+		Version string
+	}
+
+	data := TemplateData{
+		Config:  g.Config,
+		Version: g.migrationVersion(),
+	}
+
+	if data.ModuleName == "" {
+		data.ModuleName = "github.com/zhoudm1743/go-web"
+	}
+
+	// 解析模板
+	t, err := template.New("migration").Parse(migrationTemplate)
+	if err != nil {
+		return fmt.Errorf("解析迁移模板失败: %w", err)
+	}
+
+	// 渲染模板
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return fmt.Errorf("渲染迁移模板失败: %w", err)
+	}
+
+	// 确保目录存在
+	dir := filepath.Join(g.RootPath, "server/apps", g.Config.PackageName, "migrations")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+
+	// 写入文件
+	filename := filepath.Join(dir, fmt.Sprintf("%s_create_%s.go", data.Version, g.Config.TableName))
+	if err := os.WriteFile(filename, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("写入迁移文件失败: %w", err)
+	}
+
+	// 记录生成的文件
+	g.AddGeneratedFile(filename, "migration")
+
+	fmt.Printf("生成迁移文件: %s\n", filename)
+	if g.Config.PackageName != "admin" {
+		fmt.Printf("提示: 请在应用 %s 中导入 %s/apps/%s/migrations 以注册迁移\n",
+			g.Config.PackageName, data.ModuleName, g.Config.PackageName)
+	}
+	return nil
+}
+
+// migrationVersion 生成迁移版本号
+func (g *Generator) migrationVersion() string {
+	if g.Config.MigrationVersion == "" {
+		g.Config.MigrationVersion = time.Now().Format("20060102150405")
+	}
+	return g.Config.MigrationVersion
+}
@@ -26,6 +26,7 @@ type {{.StructName}} struct {
 	CreatedAt time.Time      ` + "`" + `json:"createdAt"` + "`" + `                           // 创建时间
 	UpdatedAt time.Time      ` + "`" + `json:"updatedAt"` + "`" + `                           // 更新时间
 	DeletedAt gorm.DeletedAt ` + "`" + `gorm:"index" json:"-"` + "`" + `                      // 删除时间
+	Version   uint           ` + "`" + `gorm:"not null;default:1;comment:版本号" json:"version"` + "`" + ` // 版本号，用于乐观锁
 {{range .Fields}}
 	{{.FieldName}} {{.FieldType}} ` + "`" + `{{.GormTag}}` + "`" + `{{if .FieldDesc}} // {{.FieldDesc}}{{end}}
 {{end}}
@@ -35,7 +36,12 @@ type {{.StructName}} struct {
 func ({{.StructName}}) TableName() string {
 	return "{{.TableName}}"
 }
-
+{{if .BusinessDB}}
+// Connection 所属的数据库连接
+func ({{.StructName}}) Connection() string {
+	return "{{.BusinessDB}}"
+}
+{{end}}
 {{if .HasRelations}}
 // LoadRelations 关系预加载
 func (m *{{.StructName}}) LoadRelations(db *gorm.DB) *gorm.DB {
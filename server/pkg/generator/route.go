@@ -23,7 +23,7 @@ func (g *Generator) generateRoute() error {
 	controllerImport := fmt.Sprintf(`controllers.New%sController()`, g.Config.StructName)
 	if !strings.Contains(string(fileContent), controllerImport) {
 		// 需要添加控制器变量定义
-		newRouteContent := updateRoutesFile(string(fileContent), g.Config.StructName)
+		newRouteContent := updateRoutesFile(string(fileContent), g.Config.StructName, g.Config.ApiPrefix, g.Config.HasTrash)
 
 		// 写回文件
 		if err := os.WriteFile(routesFilePath, []byte(newRouteContent), 0644); err != nil {
@@ -46,6 +46,9 @@ func (g *Generator) generateRoute() error {
 		{{if .HasCreate}}{{.VarName}}Group.POST("/create", {{.VarName}}Controller.Create{{.StructName}}){{end}}
 		{{if .HasUpdate}}{{.VarName}}Group.PUT("/update", {{.VarName}}Controller.Update{{.StructName}}){{end}}
 		{{if .HasDelete}}{{.VarName}}Group.DELETE("/delete/:id", {{.VarName}}Controller.Delete{{.StructName}}){{end}}
+		{{if .HasTrash}}{{.VarName}}Group.GET("/trash", {{.VarName}}Controller.GetTrashed{{.PluralName}})
+		{{.VarName}}Group.PUT("/restore/:id", {{.VarName}}Controller.Restore{{.StructName}})
+		{{.VarName}}Group.DELETE("/force/:id", {{.VarName}}Controller.ForceDelete{{.StructName}}){{end}}
 	}`
 
 	// 准备模板数据
@@ -97,10 +100,13 @@ func (g *Generator) generateRoute() error {
 	return nil
 }
 
-// updateRoutesFile 更新路由文件，添加控制器变量和路由注册
-func updateRoutesFile(content string, structName string) string {
+// updateRoutesFile 更新路由文件，添加控制器变量和路由注册，hasTrash 为 true 时同时在 apiPrefix 下注册回收站路由
+func updateRoutesFile(content string, structName string, apiPrefix string, hasTrash bool) string {
 	lines := strings.Split(content, "\n")
 	varName := strings.ToLower(structName) + "Controller"
+	if apiPrefix == "" {
+		apiPrefix = strings.ToLower(structName)
+	}
 
 	// 检查文件中是否已存在控制器变量定义
 	controllerVarPattern := fmt.Sprintf("%s := controllers.New%sController()", varName, structName)
@@ -149,12 +155,7 @@ func updateRoutesFile(content string, structName string) string {
 
 	// 如果找到了插入位置，添加控制器变量
 	if controllerInitIndex >= 0 && !strings.Contains(content, controllerVarPattern) {
-		newLines := append(
-			lines[:controllerInitIndex+1],
-			fmt.Sprintf("\t%s := controllers.New%sController()", varName, structName),
-		)
-		newLines = append(newLines, lines[controllerInitIndex+1:]...)
-		lines = newLines
+		lines = insertLines(lines, controllerInitIndex+1, fmt.Sprintf("\t%s := controllers.New%sController()", varName, structName))
 	}
 
 	// 2. 添加路由注册
@@ -209,13 +210,24 @@ func updateRoutesFile(content string, structName string) string {
 		routeCode += fmt.Sprintf("\n\t\tprivateRoutes.POST(\"/%s\", %s.Create%s)", strings.ToLower(structName), varName, structName)
 		routeCode += fmt.Sprintf("\n\t\tprivateRoutes.PUT(\"/%s\", %s.Update%s)", strings.ToLower(structName), varName, structName)
 		routeCode += fmt.Sprintf("\n\t\tprivateRoutes.DELETE(\"/%s/:id\", %s.Delete%s)", strings.ToLower(structName), varName, structName)
+		if hasTrash {
+			// 与路由组模板和前端 API 的地址保持一致
+			routeCode += fmt.Sprintf("\n\t\tprivateRoutes.GET(\"/%s/trash\", %s.GetTrashed%s)", apiPrefix, varName, pluralName)
+			routeCode += fmt.Sprintf("\n\t\tprivateRoutes.PUT(\"/%s/restore/:id\", %s.Restore%s)", apiPrefix, varName, structName)
+			routeCode += fmt.Sprintf("\n\t\tprivateRoutes.DELETE(\"/%s/force/:id\", %s.ForceDelete%s)", apiPrefix, varName, structName)
+		}
 
 		// 在私有路由组的结尾大括号前插入代码
-		line := lines[privateRoutesBlockEndIndex]
-		lines[privateRoutesBlockEndIndex] = routeCode
-		newLines := append(lines[:privateRoutesBlockEndIndex+1], line)
-		lines = newLines
+		lines = insertLines(lines, privateRoutesBlockEndIndex, routeCode)
 	}
 
 	return strings.Join(lines, "\n")
 }
+
+// insertLines 在 index 处插入行，返回新的切片，不修改 lines，避免 append 覆盖插入位置之后的行
+func insertLines(lines []string, index int, extra ...string) []string {
+	result := make([]string, 0, len(lines)+len(extra))
+	result = append(result, lines[:index]...)
+	result = append(result, extra...)
+	return append(result, lines[index:]...)
+}
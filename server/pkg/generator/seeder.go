@@ -0,0 +1,89 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// generateSeeder 生成菜单填充文件
+func (g *Generator) generateSeeder() error {
+	// 填充模板
+	const seederTemplate = `package seeders
+{{if not .IsAdmin}}
+import (
+	adminseeders "{{.ModuleName}}/apps/admin/seeders"
+)
+{{end}}
+func init() {
+	{{if not .IsAdmin}}adminseeders.{{end}}RegisterMenus("{{.PackageName}}:menu:{{.TableName}}", {{if not .IsAdmin}}adminseeders.{{end}}MenuSeed{
+		Name:      "{{.RouteName}}",
+		Path:      "/{{.RoutePath}}",
+		Component: "/{{.RoutePath}}/index.vue",
+		Icon:      "icon-park-outline:table-file",
+		Title:     "{{.Title}}",
+	})
+}
+`
+
+	// 准备模板数据
+	type TemplateData struct {
+		*Config
+		IsAdmin   bool
+		RouteName string
+		RoutePath string
+		Title     string
+	}
+
+	data := TemplateData{
+		Config:    g.Config,
+		IsAdmin:   g.Config.PackageName == "admin",
+		RouteName: ToLowerCamel(g.Config.StructName),
+		RoutePath: strings.ToLower(g.Config.StructName),
+		Title:     g.Config.Description,
+	}
+
+	if data.ModuleName == "" {
+		data.ModuleName = "github.com/zhoudm1743/go-web"
+	}
+	if data.Title == "" {
+		data.Title = g.Config.StructName
+	}
+
+	// 解析模板
+	t, err := template.New("seeder").Parse(seederTemplate)
+	if err != nil {
+		return fmt.Errorf("解析填充模板失败: %w", err)
+	}
+
+	// 渲染模板
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return fmt.Errorf("渲染填充模板失败: %w", err)
+	}
+
+	// 确保目录存在
+	dir := filepath.Join(g.RootPath, "server/apps", g.Config.PackageName, "seeders")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+
+	// 写入文件
+	filename := filepath.Join(dir, fmt.Sprintf("%s_menu.go", g.Config.TableName))
+	if err := os.WriteFile(filename, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("写入填充文件失败: %w", err)
+	}
+
+	// 记录生成的文件
+	g.AddGeneratedFile(filename, "seeder")
+
+	fmt.Printf("生成菜单填充文件: %s\n", filename)
+	if !data.IsAdmin {
+		fmt.Printf("提示: 请在应用 %s 中导入 %s/apps/%s/seeders 以注册菜单\n",
+			g.Config.PackageName, data.ModuleName, g.Config.PackageName)
+	}
+	return nil
+}
@@ -36,6 +36,8 @@ func (c *CodeGenController) GenerateCode(ctx *gin.Context) {
 		HasDelete     bool     `json:"hasDelete"`
 		HasDetail     bool     `json:"hasDetail"`
 		HasPagination bool     `json:"hasPagination"`
+		HasTrash      bool     `json:"hasTrash"`
+		HasCache      bool     `json:"hasCache"`
 		Fields        []*Field `json:"fields"`
 	}
 
@@ -75,6 +77,8 @@ func (c *CodeGenController) GenerateCode(ctx *gin.Context) {
 		HasDelete:     req.HasDelete,
 		HasDetail:     req.HasDetail,
 		HasPagination: req.HasPagination,
+		HasTrash:      req.HasTrash,
+		HasCache:      req.HasCache,
 		Fields:        req.Fields,
 	}
 
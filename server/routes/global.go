@@ -4,13 +4,21 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/zhoudm1743/go-web/core/facades"
+	"github.com/zhoudm1743/go-web/core/middleware"
 )
 
 // RegisterGlobalMiddlewares 注册全局中间件
 func RegisterGlobalMiddlewares(router *gin.Engine) {
+	// 请求ID中间件，用于关联请求日志和SQL日志
+	router.Use(middleware.RequestID())
+
 	// 跨域中间件
 	router.Use(corsMiddleware())
 
+	// 租户识别中间件，需在认证和事务中间件之前执行
+	router.Use(middleware.Tenant())
+
 	// 请求日志中间件在app.go中已经添加
 }
 
@@ -24,6 +32,24 @@ func RegisterGlobalRoutes(router *gin.Engine) {
 		})
 	})
 
+	// 数据库健康检查路由，任一连接异常时返回503
+	// 公开接口只返回整体状态，各连接的详情通过管理后台的诊断接口查看
+	router.GET("/health/db", func(c *gin.Context) {
+		status, code := "ok", http.StatusOK
+		manager := facades.DBManager()
+		if manager == nil {
+			status, code = "error", http.StatusServiceUnavailable
+		} else {
+			for _, check := range manager.Health(c.Request.Context()) {
+				if !check.Healthy {
+					status, code = "error", http.StatusServiceUnavailable
+					break
+				}
+			}
+		}
+		c.JSON(code, gin.H{"status": status})
+	})
+
 	// 版本信息路由
 	router.GET("/version", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -40,7 +66,8 @@ func corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Tenant, If-Match, X-Request-ID")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "ETag, X-Request-ID")
 
 		// 处理OPTIONS请求
 		if c.Request.Method == "OPTIONS" {